@@ -0,0 +1,103 @@
+package fastparser
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var benchSmallGET = []byte("GET /api/users HTTP/1.1\r\nHost: example.com\r\nAccept: application/json\r\nUser-Agent: shape-http/1.0\r\n\r\n")
+
+// benchManyHeaders is a request with 30 header fields, a mix of names in
+// and out of the intern table, as a browser behind a proxy sends.
+var benchManyHeaders = func() []byte {
+	var b strings.Builder
+	b.WriteString("GET /dashboard?tab=overview HTTP/1.1\r\n")
+	names := []string{
+		"Host", "User-Agent", "Accept", "Accept-Language", "Accept-Encoding",
+		"Referer", "Cookie", "Connection", "Cache-Control", "Pragma",
+		"Origin", "Authorization", "If-None-Match", "If-Modified-Since", "X-Forwarded-For",
+		"X-Forwarded-Proto", "X-Forwarded-Host", "X-Real-IP", "X-Request-ID", "Via",
+		"Sec-Fetch-Dest", "Sec-Fetch-Mode", "Sec-Fetch-Site", "Sec-Fetch-User", "Sec-Ch-Ua",
+		"Sec-Ch-Ua-Mobile", "Sec-Ch-Ua-Platform", "Upgrade-Insecure-Requests", "DNT", "Priority",
+	}
+	for i, name := range names {
+		b.WriteString(name + ": value-" + strconv.Itoa(i) + "-abcdefghijklmnop\r\n")
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}()
+
+const benchBodySize = 1 << 20
+
+var benchLargeBody = func() []byte {
+	head := "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/octet-stream\r\nContent-Length: " + strconv.Itoa(benchBodySize) + "\r\n\r\n"
+	return append([]byte(head), make([]byte, benchBodySize)...)
+}()
+
+// benchChunkedBody carries 1 MiB in 16 KiB chunks.
+var benchChunkedBody = func() []byte {
+	var b strings.Builder
+	b.WriteString("POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n")
+	chunk := strings.Repeat("x", 16<<10)
+	for n := 0; n < benchBodySize; n += len(chunk) {
+		b.WriteString(strconv.FormatInt(int64(len(chunk)), 16) + "\r\n" + chunk + "\r\n")
+	}
+	b.WriteString("0\r\n\r\n")
+	return []byte(b.String())
+}()
+
+var benchInputs = []struct {
+	name string
+	data []byte
+}{
+	{"SmallGET", benchSmallGET},
+	{"30Headers", benchManyHeaders},
+	{"ContentLength1MiB", benchLargeBody},
+	{"Chunked1MiB", benchChunkedBody},
+}
+
+func BenchmarkParseRequest(b *testing.B) {
+	for _, in := range benchInputs {
+		b.Run(in.name, func(b *testing.B) {
+			b.SetBytes(int64(len(in.data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := UnmarshalRequest(in.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseLenient(b *testing.B) {
+	for _, in := range benchInputs {
+		b.Run(in.name, func(b *testing.B) {
+			b.SetBytes(int64(len(in.data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if result := NewLenientParser(in.data).Parse(); result.Request == nil {
+					b.Fatal("no request")
+				}
+			}
+		})
+	}
+}
+
+// smallGETAllocBudget is the most allocations a strict parse of
+// benchSmallGET may make: the Request, its headers slice, and the message
+// head as one string that the target and every field name and value are
+// sliced from.
+const smallGETAllocBudget = 3
+
+func TestAllocBudget_SmallGET(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := UnmarshalRequest(benchSmallGET); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > smallGETAllocBudget {
+		t.Errorf("UnmarshalRequest(small GET) = %v allocs, budget %d", allocs, smallGETAllocBudget)
+	}
+}
@@ -1,9 +1,12 @@
 package fastparser
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"strings"
 )
 
 // Dechunk decodes a chunked transfer-encoded body.
@@ -12,67 +15,336 @@ import (
 // Chunk extensions after ';' are ignored.
 func Dechunk(data []byte) ([]byte, error) {
 	var result []byte
+	if _, _, err := walkChunks(data, func(chunk []byte) { result = append(result, chunk...) }); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// walkChunks validates the chunked framing of data, calling visit (when
+// non-nil) with each chunk's data in order, and returns the decoded length
+// and the offset just past the last-chunk line, where any trailer section
+// begins. visit receives subslices of data. Errors are those reported by
+// Dechunk.
+func walkChunks(data []byte, visit func(chunk []byte)) (total, end int, err error) {
+	return walkChunksFrom(data, nil, visit)
+}
+
+// chunkProgress records how far the framing of a chunked body that is still
+// arriving has been validated: pos is the offset just past the last
+// complete chunk and total the payload bytes before it.
+type chunkProgress struct {
+	pos, total int
+}
+
+// walkChunksFrom is walkChunks starting at from, when non-nil, and moving
+// from forward past every complete chunk it validates. visit sees only
+// the chunks after the starting point.
+func walkChunksFrom(data []byte, from *chunkProgress, visit func(chunk []byte)) (total, end int, err error) {
 	pos := 0
+	if from != nil {
+		pos, total = from.pos, from.total
+	}
 	length := len(data)
 
 	for {
 		if pos >= length {
-			return nil, fmt.Errorf("http: chunked encoding: unexpected end of data")
+			return 0, 0, chunkErrorf(true, "unexpected end of data")
 		}
 
 		// Read chunk size line
 		lineEnd := findLineEnd(data, pos)
 		if lineEnd < 0 {
-			return nil, fmt.Errorf("http: chunked encoding: unterminated chunk size line")
+			return 0, 0, chunkErrorf(false, "unterminated chunk size line")
 		}
 
 		sizeLine := data[pos:lineEnd]
 		// Advance past the line ending
 		pos = skipLineEnding(data, lineEnd)
 
-		// Strip chunk extension (everything after ';')
-		if semi := bytes.IndexByte(sizeLine, ';'); semi >= 0 {
-			sizeLine = sizeLine[:semi]
-		}
-		sizeLine = bytes.TrimSpace(sizeLine)
-
-		// Parse hex size
-		sizeStr := string(sizeLine)
-		size, err := parseHexSize(sizeStr)
+		size, err := parseChunkSizeLine(sizeLine)
 		if err != nil {
-			return nil, fmt.Errorf("http: chunked encoding: invalid chunk size %q: %w", sizeStr, err)
+			return 0, 0, err
 		}
 
 		// size 0 = last chunk
 		if size == 0 {
-			// Skip optional trailers and final CRLF
-			break
+			// The trailer section, if any, starts at pos
+			return total, pos, nil
 		}
 
 		// Read chunk data
-		if pos+size > length {
-			return nil, fmt.Errorf("http: chunked encoding: chunk data truncated (expected %d bytes, %d available)", size, length-pos)
+		if size > length-pos {
+			return 0, 0, chunkErrorf(true, "chunk data truncated (expected %d bytes, %d available)", size, length-pos)
+		}
+		if visit != nil {
+			visit(data[pos : pos+size])
 		}
-		result = append(result, data[pos:pos+size]...)
+		total += size
 		pos += size
 
 		// Expect CRLF after chunk data
 		if pos >= length {
-			return nil, fmt.Errorf("http: chunked encoding: missing CRLF after chunk data")
+			return 0, 0, chunkErrorf(false, "missing CRLF after chunk data")
 		}
 		if data[pos] == '\r' && pos+1 < length && data[pos+1] == '\n' {
 			pos += 2
 		} else if data[pos] == '\n' {
 			pos++
 		} else {
-			return nil, fmt.Errorf("http: chunked encoding: expected CRLF after chunk data, got %q", data[pos])
+			return 0, 0, chunkErrorf(false, "expected CRLF after chunk data, got %q", data[pos])
+		}
+		if from != nil {
+			from.pos, from.total = pos, total
 		}
 	}
+}
 
-	if len(result) == 0 {
-		return nil, nil
+// ParseChunked decodes a complete chunked body at the start of data,
+// including its trailer section and the blank line that ends it. It
+// returns the payload, the trailer fields, and the number of bytes
+// consumed; anything after that belongs to the next message. Unlike
+// Dechunk, input that stops before the final blank line is an error.
+func ParseChunked(data []byte) (body []byte, trailers []Header, n int, err error) {
+	size, pos, err := walkChunks(data, nil)
+	if err != nil {
+		return nil, nil, 0, err
 	}
-	return result, nil
+	if size > 0 {
+		body = make([]byte, 0, size)
+		walkChunks(data, func(chunk []byte) { body = append(body, chunk...) })
+	}
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return nil, nil, 0, chunkErrorf(true, "unexpected end of data in trailer section")
+		}
+		line := data[pos:lineEnd]
+		pos = skipLineEnding(data, lineEnd)
+		if len(line) == 0 {
+			return body, trailers, pos, nil
+		}
+		h, err := parseTrailerField(line)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		trailers = append(trailers, h)
+	}
+}
+
+// bareChunkLine returns the offset of the first line terminator of the
+// framing of data, a chunked body that walkChunks accepts, that is LF
+// alone: after a chunk size line, after chunk data, or in the trailer
+// section. It returns -1 if every one is CRLF.
+func bareChunkLine(data []byte) int {
+	pos := 0
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return -1
+		}
+		if data[lineEnd] == '\n' {
+			return lineEnd
+		}
+		size, err := parseChunkSizeLine(data[pos:lineEnd])
+		pos = lineEnd + 2
+		if err != nil || size == 0 {
+			break
+		}
+		pos += size
+		if pos < len(data) && data[pos] == '\n' {
+			return pos
+		}
+		pos += 2
+	}
+	for pos < len(data) {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return -1
+		}
+		if data[lineEnd] == '\n' {
+			return lineEnd
+		}
+		if lineEnd == pos {
+			return -1
+		}
+		pos = lineEnd + 2
+	}
+	return -1
+}
+
+// trailerEnd returns the offset just past the blank line ending the trailer
+// section that starts at pos, or len(data) if the section is unterminated.
+func trailerEnd(data []byte, pos int) int {
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return len(data)
+		}
+		empty := lineEnd == pos
+		pos = skipLineEnding(data, lineEnd)
+		if empty {
+			return pos
+		}
+	}
+}
+
+// trailersEnded reports whether the trailer section starting at pos ends
+// with its blank line within data.
+func trailersEnded(data []byte, pos int) bool {
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return false
+		}
+		if lineEnd == pos {
+			return true
+		}
+		pos = skipLineEnding(data, lineEnd)
+	}
+}
+
+// parseTrailerField parses one "name: value" line of a trailer section.
+func parseTrailerField(line []byte) (Header, error) {
+	colon := bytes.IndexByte(line, ':')
+	if colon <= 0 {
+		return Header{}, chunkErrorf(false, "malformed trailer field %s", quoteInput(string(line)))
+	}
+	return Header{Key: string(line[:colon]), Value: string(trimOWS(line[colon+1:]))}, nil
+}
+
+// parseChunkSizeLine returns the chunk size from a chunk-size line with its
+// line ending removed, ignoring any chunk extension.
+func parseChunkSizeLine(line []byte) (int, error) {
+	if semi := bytes.IndexByte(line, ';'); semi >= 0 {
+		line = line[:semi]
+	}
+	sizeStr := string(bytes.TrimSpace(line))
+	size, err := parseHexSize(sizeStr)
+	if err != nil {
+		return 0, chunkErrorf(false, "invalid chunk size %s: %w", quoteInput(sizeStr), err)
+	}
+	if size < 0 || len(strings.TrimLeft(sizeStr, "0")) > 15 {
+		return 0, chunkErrorf(false, "chunk size %s is too large", quoteInput(sizeStr))
+	}
+	return size, nil
+}
+
+// ChunkedReader decodes a chunked body from a stream with the same
+// grammar as ParseChunked. Read returns io.EOF once the trailer section
+// has been consumed, after which Trailers holds its fields.
+type ChunkedReader struct {
+	Trailers []Header
+
+	r         *bufio.Reader
+	remaining int  // payload bytes left in the current chunk
+	inChunk   bool // a chunk's data has started and its CRLF is still due
+	err       error
+}
+
+// NewChunkedReader returns a ChunkedReader reading from r. If r is a
+// *bufio.Reader it is used directly, so bytes after the chunked body stay
+// in it; otherwise r is wrapped and may be read past the body's end.
+func NewChunkedReader(r io.Reader) *ChunkedReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &ChunkedReader{r: br}
+}
+
+func (cr *ChunkedReader) Read(p []byte) (int, error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	if cr.remaining == 0 {
+		if cr.err = cr.nextChunk(); cr.err != nil {
+			return 0, cr.err
+		}
+	}
+	if len(p) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err := cr.r.Read(p)
+	cr.remaining -= n
+	if n == 0 && err != nil {
+		cr.err = chunkErrorf(true, "chunk data truncated (expected %d bytes)", cr.remaining)
+		return 0, cr.err
+	}
+	return n, nil
+}
+
+// nextChunk finishes the current chunk and reads the next chunk-size line,
+// or the trailer section after the last chunk, in which case it returns
+// io.EOF.
+func (cr *ChunkedReader) nextChunk() error {
+	if cr.inChunk {
+		c, err := cr.r.ReadByte()
+		if err != nil {
+			return chunkErrorf(false, "missing CRLF after chunk data")
+		}
+		if c == '\r' {
+			if next, err := cr.r.ReadByte(); err != nil || next != '\n' {
+				return chunkErrorf(false, "expected CRLF after chunk data, got %q", c)
+			}
+		} else if c != '\n' {
+			return chunkErrorf(false, "expected CRLF after chunk data, got %q", c)
+		}
+		cr.inChunk = false
+	}
+
+	line, err := cr.readLine()
+	if err == io.EOF && line == nil {
+		return chunkErrorf(true, "unexpected end of data")
+	}
+	if err != nil {
+		return chunkErrorf(false, "unterminated chunk size line")
+	}
+	size, err := parseChunkSizeLine(line)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		cr.remaining = size
+		cr.inChunk = true
+		return nil
+	}
+
+	for {
+		line, err := cr.readLine()
+		if err != nil {
+			return chunkErrorf(true, "unexpected end of data in trailer section")
+		}
+		if len(line) == 0 {
+			return io.EOF
+		}
+		h, err := parseTrailerField(line)
+		if err != nil {
+			return err
+		}
+		cr.Trailers = append(cr.Trailers, h)
+	}
+}
+
+// readLine reads through the next LF and returns the line without its LF
+// or CRLF ending, as findLineEnd delimits lines. At EOF it returns the
+// partial line (nil if empty) and io.EOF.
+func (cr *ChunkedReader) readLine() ([]byte, error) {
+	line, err := cr.r.ReadBytes('\n')
+	if err != nil {
+		if len(line) == 0 {
+			line = nil
+		}
+		return line, err
+	}
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line, nil
 }
 
 // findLineEnd finds the position of \r\n or \n starting from pos.
@@ -156,3 +156,13 @@ func TestDechunk_EmptyData(t *testing.T) {
 		t.Error("expected error for empty input")
 	}
 }
+
+// TestDechunk_OversizedChunk checks that chunk sizes near or beyond the
+// int range are rejected rather than overflowing the bounds check.
+func TestDechunk_OversizedChunk(t *testing.T) {
+	for _, size := range []string{"7fffffffffffffff", "ffffffffffffffff", "10000000000000000"} {
+		if _, err := Dechunk([]byte(size + "\r\nX\r\n0\r\n\r\n")); err == nil {
+			t.Errorf("Dechunk with size %s succeeded, want error", size)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package fastparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// transferCodings returns the transfer codings listed by the
+// Transfer-Encoding headers, in the order they were applied.
+func transferCodings(headers []Header) []string {
+	var codings []string
+	for _, h := range headers {
+		if !eqFold(h.Key, HeaderTransferEncoding) {
+			continue
+		}
+		for _, part := range splitComma(h.Value) {
+			if part = trimString(part); part != "" {
+				codings = append(codings, part)
+			}
+		}
+	}
+	return codings
+}
+
+// checkChunkedLast returns an error unless chunked is applied exactly once
+// and last, as RFC 9112 §6.1 requires of a chunked message.
+func checkChunkedLast(codings []string) error {
+	for i, c := range codings {
+		if eqFold(c, "chunked") && i != len(codings)-1 {
+			return fmt.Errorf("chunked must be the last transfer coding, got %s", quoteInput(strings.Join(codings, ", ")))
+		}
+	}
+	return nil
+}
+
+// decodeTransferCodings undoes codings, the transfer codings applied
+// before chunked, from the last one back, each decoding to at most limit
+// bytes when limit is positive. It stops at the first it cannot undo and
+// returns the body decoded so far, the codings still applied to it and why
+// it stopped.
+func decodeTransferCodings(body []byte, codings []string, limit int) ([]byte, []string, error) {
+	for len(codings) > 0 {
+		coding := codings[len(codings)-1]
+		decoded, err := DecodeCoding(body, coding, "transfer coding", limit)
+		if err != nil {
+			return body, codings, err
+		}
+		body, codings = decoded, codings[:len(codings)-1]
+	}
+	return body, nil, nil
+}
+
+// DecodeCoding reverses one content or transfer coding: identity, gzip
+// (and x-gzip) or deflate, the latter as zlib or, as some servers send it,
+// raw DEFLATE. what names the kind of coding in errors, as in
+// "unsupported transfer coding \"br\"". When limit is positive, data that
+// decodes to more than limit bytes is an error, found without decoding
+// more than limit+1 bytes.
+func DecodeCoding(data []byte, coding, what string, limit int) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case eqFold(coding, "identity"):
+		return data, nil
+	case eqFold(coding, "gzip"), eqFold(coding, "x-gzip"):
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case eqFold(coding, "deflate"):
+		r, err = zlib.NewReader(bytes.NewReader(data))
+		if errors.Is(err, zlib.ErrHeader) {
+			r, err = flate.NewReader(bytes.NewReader(data)), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported %s %s", what, quoteInput(coding))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", coding, what, err)
+	}
+	defer r.Close()
+	var src io.Reader = r
+	if limit > 0 {
+		src = io.LimitReader(r, int64(limit)+1)
+	}
+	out, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", coding, what, err)
+	}
+	if limit > 0 && len(out) > limit {
+		return nil, fmt.Errorf("%s %s decodes to more than %d bytes", coding, what, limit)
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package fastparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func zlibbed(s string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+// chunkedMessage frames body in two chunks after a response head with the
+// given Transfer-Encoding.
+func chunkedMessage(te string, body []byte) []byte {
+	half := len(body) / 2
+	return []byte(fmt.Sprintf("HTTP/1.1 200 OK\r\nTransfer-Encoding: %s\r\nX-After: 1\r\n\r\n%x\r\n%s\r\n%x\r\n%s\r\n0\r\n\r\n",
+		te, half, body[:half], len(body)-half, body[half:]))
+}
+
+// decodeLimits opts the strict parser in to undoing transfer codings.
+var decodeLimits = Limits{DecodeTransferCodings: true}
+
+func TestParseResponse_TransferCodings(t *testing.T) {
+	const text = "hello, transfer codings"
+	tests := []struct {
+		te   string
+		body []byte
+	}{
+		{"gzip, chunked", gzipped(text)},
+		{"x-gzip, chunked", gzipped(text)},
+		{"deflate, chunked", zlibbed(text)},
+		{"identity, chunked", []byte(text)},
+		{"gzip, gzip, Chunked", gzipped(string(gzipped(text)))},
+	}
+	for _, tt := range tests {
+		resp, err := UnmarshalResponseWithLimits(chunkedMessage(tt.te, tt.body), decodeLimits)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.te, err)
+			continue
+		}
+		if string(resp.Body) != text {
+			t.Errorf("%s: Body = %q, want %q", tt.te, resp.Body, text)
+		}
+		want := []Header{{Key: "X-After", Value: "1"}, {Key: "Content-Length", Value: fmt.Sprint(len(text))}}
+		if fmt.Sprint(resp.Headers) != fmt.Sprint(want) {
+			t.Errorf("%s: Headers = %v, want %v", tt.te, resp.Headers, want)
+		}
+	}
+}
+
+func TestParseResponse_TransferCodingErrors(t *testing.T) {
+	tests := []struct {
+		te      string
+		body    []byte
+		wantErr string
+	}{
+		{"br, chunked", []byte("brotli"), `unsupported transfer coding "br"`},
+		{"gzip, br, chunked", []byte("brotli"), `unsupported transfer coding "br"`},
+		{"gzip, chunked", []byte("not gzip"), "invalid gzip transfer coding"},
+		{"chunked, gzip", gzipped("x"), `chunked must be the last transfer coding, got "chunked, gzip"`},
+		{"chunked, chunked", []byte("xx"), "chunked must be the last transfer coding"},
+	}
+	for _, tt := range tests {
+		_, err := UnmarshalResponseWithLimits(chunkedMessage(tt.te, tt.body), decodeLimits)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.te, err, tt.wantErr)
+		}
+	}
+	// A request is held to the same rules.
+	req := "POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\nTransfer-Encoding: gzip\r\n\r\n0\r\n\r\n"
+	if _, err := UnmarshalRequest([]byte(req)); err == nil || !strings.Contains(err.Error(), "chunked must be the last") {
+		t.Errorf("request error = %v", err)
+	}
+}
+
+func TestLenient_TransferCodings(t *testing.T) {
+	const text = "hello, transfer codings"
+	tests := []struct {
+		te      string
+		body    []byte
+		wantTE  string
+		want    string
+		warning string
+	}{
+		{"gzip, chunked", gzipped(text), "chunked", text, ""},
+		{"br, gzip, chunked", gzipped("br-coded"), "br, chunked", "br-coded", `unsupported transfer coding "br", body left with transfer codings "br"`},
+		{"br, chunked", []byte("brotli"), "br, chunked", "brotli", `unsupported transfer coding "br", body left with transfer codings "br"`},
+		{"gzip, chunked", []byte("not gzip"), "gzip, chunked", "not gzip", "invalid gzip transfer coding"},
+		{"chunked, gzip", gzipped(text), "chunked, gzip", string(gzipped(text)), `chunked must be the last transfer coding, got "chunked, gzip", only chunked was decoded`},
+	}
+	for _, tt := range tests {
+		data := chunkedMessage(tt.te, tt.body)
+		result := NewLenientParserWithOptions(data, LenientOptions{DecodeTransferCodings: true}).Parse()
+		resp := result.Response
+		if string(resp.Body) != tt.want {
+			t.Errorf("%s: Body = %q, want %q", tt.te, resp.Body, tt.want)
+		}
+		if got := getHeader(resp.Headers, "Transfer-Encoding"); got != tt.wantTE {
+			t.Errorf("%s: Transfer-Encoding = %q, want %q", tt.te, got, tt.wantTE)
+		}
+		if len(resp.Headers) != 2 || resp.Headers[1].Key != "X-After" {
+			t.Errorf("%s: Headers = %v", tt.te, resp.Headers)
+		}
+		switch {
+		case tt.warning == "" && len(result.Warnings) != 0:
+			t.Errorf("%s: Warnings = %q", tt.te, result.Warnings)
+		case tt.warning != "" && (len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], tt.warning)):
+			t.Errorf("%s: Warnings = %q, want %q", tt.te, result.Warnings, tt.warning)
+		}
+		if !result.Complete || result.Partial {
+			t.Errorf("%s: Complete = %v, Partial = %v", tt.te, result.Complete, result.Partial)
+		}
+	}
+
+	// A body cut short is left as it arrived.
+	data := chunkedMessage("gzip, chunked", gzipped(text))
+	result := NewLenientParserWithOptions(data[:len(data)-5], LenientOptions{DecodeTransferCodings: true}).Parse()
+	if got := getHeader(result.Response.Headers, "Transfer-Encoding"); got != "gzip, chunked" {
+		t.Errorf("truncated: Transfer-Encoding = %q", got)
+	}
+}
+
+func TestTransferCodings_OptIn(t *testing.T) {
+	const text = "hello, transfer codings"
+	data := chunkedMessage("gzip, chunked", gzipped(text))
+	resp, err := UnmarshalResponse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != string(gzipped(text)) || getHeader(resp.Headers, "Transfer-Encoding") != "gzip" {
+		t.Errorf("strict: Body = %q, Headers = %v, want the gzip body and Transfer-Encoding: gzip", resp.Body, resp.Headers)
+	}
+	result := NewLenientParser(data).Parse()
+	if string(result.Response.Body) != string(gzipped(text)) || len(result.Warnings) != 0 {
+		t.Errorf("lenient: Body = %q, Warnings = %q", result.Response.Body, result.Warnings)
+	}
+}
+
+// TestTransferCodings_Limit checks that a decompression bomb is stopped
+// at the limit rather than inflated in full.
+func TestTransferCodings_Limit(t *testing.T) {
+	bomb := gzipped(strings.Repeat("\x00", 1<<20))
+	data := chunkedMessage("gzip, chunked", bomb)
+
+	_, err := UnmarshalResponseWithLimits(data, Limits{DecodeTransferCodings: true, MaxDecodedBodyBytes: 4096})
+	if err == nil || !strings.Contains(err.Error(), "gzip transfer coding decodes to more than 4096 bytes") {
+		t.Errorf("strict error = %v", err)
+	}
+	if _, err := UnmarshalResponseWithLimits(data, decodeLimits); err != nil {
+		t.Errorf("strict without a limit: %v", err)
+	}
+
+	opts := LenientOptions{DecodeTransferCodings: true, MaxBodyBytes: 1 << 19}
+	result := NewLenientParserWithOptions(data, opts).Parse()
+	if string(result.Response.Body) != string(bomb) || getHeader(result.Response.Headers, "Transfer-Encoding") != "gzip, chunked" {
+		t.Errorf("lenient: Body is %d bytes, Headers = %v, want the gzip body left as it is", len(result.Response.Body), result.Response.Headers)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "decodes to more than 524288 bytes") {
+		t.Errorf("lenient: Warnings = %q", result.Warnings)
+	}
+}
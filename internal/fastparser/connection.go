@@ -0,0 +1,45 @@
+package fastparser
+
+import "fmt"
+
+// connectionSpecific are the header fields HTTP/2 and HTTP/3 forbid
+// because they describe an HTTP/1.1 connection (RFC 9113 §8.2.2, RFC 9114
+// §4.2). TE is allowed only as "TE: trailers".
+var connectionSpecific = []string{
+	HeaderConnection, "Keep-Alive", "Proxy-Connection", HeaderTransferEncoding, HeaderUpgrade,
+}
+
+// isMultiplexedVersion reports whether version is HTTP/2 or HTTP/3, the
+// versions without connection-specific header fields.
+func isMultiplexedVersion(version string) bool {
+	switch version {
+	case "HTTP/2", "HTTP/2.0", "HTTP/3", "HTTP/3.0":
+		return true
+	}
+	return false
+}
+
+// ConnectionHeaderWarnings returns a warning for each header in headers
+// that a message of version may not carry: the connection-specific fields
+// when version is HTTP/2 or HTTP/3, and none otherwise.
+func ConnectionHeaderWarnings(version string, headers []Header) []string {
+	if !isMultiplexedVersion(version) {
+		return nil
+	}
+	rfc := "RFC 9113 §8.2.2"
+	if version[5] == '3' {
+		rfc = "RFC 9114 §4.2"
+	}
+	var warnings []string
+	for _, h := range headers {
+		for _, name := range connectionSpecific {
+			if eqFold(h.Key, name) {
+				warnings = append(warnings, fmt.Sprintf("header %s is connection-specific and not allowed in %s (%s)", quoteInput(h.Key), version, rfc))
+			}
+		}
+		if eqFold(h.Key, HeaderTE) && !eqFold(trimString(h.Value), "trailers") {
+			warnings = append(warnings, fmt.Sprintf("header %s is only allowed as %s in %s (%s)", quoteInput(h.Key), quoteInput("TE: trailers"), version, rfc))
+		}
+	}
+	return warnings
+}
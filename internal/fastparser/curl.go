@@ -3,8 +3,15 @@ package fastparser
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ParseCurl parses a curl command string and returns a ParseResult with
@@ -12,13 +19,143 @@ import (
 //
 // It never errors on malformed input — issues are reported as Warnings.
 func ParseCurl(cmd string) *ParseResult {
-	cp := &curlParser{}
+	return ParseCurlWithOptions(cmd, CurlOptions{})
+}
+
+// CurlOptions configures ParseCurlWithOptions.
+type CurlOptions struct {
+	// FileReader, when non-nil, reads the local files a command names,
+	// such as the -T upload file. Without it those files are not read.
+	FileReader func(name string) ([]byte, error)
+
+	// BoundarySeed, when non-zero, seeds the multipart boundary generated
+	// for -F fields. Without it the seed is derived from the fields, so a
+	// command always gives the same boundary.
+	BoundarySeed int64
+
+	// MaxGlobRequests caps the requests built from a URL with globbing
+	// patterns; 0 means DefaultMaxGlobRequests.
+	MaxGlobRequests int
+}
+
+// ClientHints records what a curl command asks of the client beyond the
+// request itself.
+type ClientHints struct {
+	// BodyFile names the file a -T upload body comes from ("-" for stdin)
+	// when it was not read, leaving the request body empty.
+	BodyFile string
+
+	// Authority is the URL's host[:port], which curl connects to, when an
+	// explicit -H "Host: ..." sends a different one.
+	Authority string
+
+	// AuthScheme names the authentication curl negotiates itself:
+	// "digest", "ntlm", "negotiate" or "aws-sigv4". The request carries no
+	// Authorization header for it; Credentials holds the user:password
+	// from -u or the URL, and AWSSigV4 the --aws-sigv4 provider string
+	// (such as "aws:amz:us-east-1:s3").
+	AuthScheme  string
+	Credentials string
+	AWSSigV4    string
+
+	// Resolve and ConnectTo hold the --resolve and --connect-to entries,
+	// in order, which change the address curl connects to without
+	// changing the request.
+	Resolve   []ResolveEntry
+	ConnectTo []ConnectToEntry
+}
+
+// ResolveEntry is one address of a --resolve host:port:addr[,addr...]
+// argument: connections to Host ("*" for any host) on Port go to Address,
+// an IP address without brackets.
+type ResolveEntry struct {
+	Host    string
+	Port    int
+	Address string
+}
+
+// ConnectToEntry is a --connect-to HOST1:PORT1:HOST2:PORT2 argument:
+// connections to Host on Port go to ToHost on ToPort instead. An empty
+// host or a zero port matches any, or for ToHost and ToPort keeps the
+// original. IPv6 addresses are given without brackets.
+type ConnectToEntry struct {
+	Host   string
+	Port   int
+	ToHost string
+	ToPort int
+}
+
+// ParseCurlWithOptions is ParseCurl with explicit options.
+func ParseCurlWithOptions(cmd string, opts CurlOptions) *ParseResult {
+	cp := &curlParser{opts: opts}
 	result := cp.parse(cmd)
+	fields := strings.Fields(stripNonCurlLines(cmd))
+	switch {
+	case len(fields) == 0:
+		result.Verdict = NotCurl
+	case looksLikeProse(fields):
+		result = &ParseResult{Partial: true, Verdict: NotCurl}
+		cp.warnings = []string{"input does not look like a curl command"}
+	case result.Partial:
+		result.Verdict = CurlPartial
+	default:
+		result.Verdict = CurlComplete
+	}
 	result.Warnings = cp.warnings
 	return result
 }
 
+// CurlVerdict classifies a ParseCurl result. The zero value means the
+// result did not come from ParseCurl.
+type CurlVerdict int
+
+// Curl verdicts.
+const (
+	CurlComplete CurlVerdict = iota + 1 // a request was built; there may be warnings
+	CurlPartial                         // a curl command missing its URL, or untokenizable
+	NotCurl                             // the input is not a curl command
+)
+
+// looksLikeProse reports whether the whitespace-separated fields of a
+// command read as text rather than a command: there is no leading "curl",
+// no field that looks like a flag or URL, and at least three plain words.
+func looksLikeProse(fields []string) bool {
+	if strings.EqualFold(fields[0], "curl") {
+		return false
+	}
+	words := 0
+	for _, f := range fields {
+		if len(f) > 1 && f[0] == '-' || strings.Contains(f, "://") {
+			return false
+		}
+		bare := strings.TrimRight(strings.TrimLeft(f, "\"'(["), ".,;:!?\"')]")
+		host, _, hasPath := strings.Cut(bare, "/")
+		if isHostnameLike([]byte(host)) || hasPath && host == "" {
+			return false
+		}
+		if isWord(bare) {
+			words++
+		}
+	}
+	return words >= 3
+}
+
+// isWord reports whether s is non-empty and all letters, allowing inner
+// apostrophes and hyphens ("don't", "well-known").
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && !(i > 0 && (r == '\'' || r == '-')) {
+			return false
+		}
+	}
+	return true
+}
+
 type curlParser struct {
+	opts     CurlOptions
 	warnings []string
 }
 
@@ -55,7 +192,7 @@ func (cp *curlParser) parse(cmd string) *ParseResult {
 	cmd = strings.ReplaceAll(cmd, "\n", " ")
 	cmd = strings.ReplaceAll(cmd, "\r", " ")
 
-	tokens, err := shellSplit(cmd)
+	tokens, quoted, err := cp.splitCommand(cmd)
 	if err != nil {
 		cp.warn(fmt.Sprintf("malformed curl command: %v", err))
 		result.Partial = true
@@ -70,85 +207,176 @@ func (cp *curlParser) parse(cmd string) *ParseResult {
 
 	// Tolerate an optional leading "curl" token.
 	if strings.EqualFold(tokens[0], "curl") {
-		tokens = tokens[1:]
+		tokens, quoted = tokens[1:], quoted[1:]
 	}
 
-	// Expand compound short flags like -sS → [-s, -S] before the main loop.
-	tokens = expandShortFlags(tokens)
+	// A pipe, redirect or command list operator ends the curl command.
+	var tail string
+	if tokens, quoted, tail = cutShellTail(tokens, quoted); tail != "" {
+		cp.warn(fmt.Sprintf("trailing shell operator %s ignored", quoteInput(tail)))
+	}
+
+	// Expand compound short flags like -sS → [-s, -S] before the main loop,
+	// keeping track of which tokens were quoted. A flag's argument is left
+	// alone even when it starts with "-", as in "--resolve -host:443". A
+	// lone "--" ends the options: it is dropped, and every token after it,
+	// from index positionalFrom on, is positional.
+	var expanded []string
+	var expandedQuoted []bool
+	isArg := false
+	positionalFrom := -1
+	for i, tok := range tokens {
+		if !isArg && positionalFrom < 0 && tok == "--" {
+			positionalFrom = len(expanded)
+			continue
+		}
+		exp := []string{tok}
+		if !isArg && positionalFrom < 0 {
+			exp = expandShortFlags(exp)
+		}
+		for _, t := range exp {
+			expanded = append(expanded, t)
+			expandedQuoted = append(expandedQuoted, quoted[i])
+		}
+		isArg = !isArg && curlTakesArg(exp[len(exp)-1])
+	}
+	tokens, quoted = expanded, expandedQuoted
 
 	var (
 		method         string
 		rawURL         string
+		urlIndex       = -1 // token index of rawURL, or of its last fragment
+		urlQuoted      bool
 		version        = "HTTP/1.1"
 		headers        []Header
-		dataParts      []string
+		dataParts      []curlDataPart // -d, --json and --data-urlencode, in order
 		formFields     []string
-		urlEncFields   []string
+		urlEncoded     bool // a --data-urlencode part is among dataParts
+		rangeSpec      string
+		uploadFile     string
 		explicitMethod bool
+		jsonData       bool
+		user           string // -u credentials
+		userAt         = -1   // index in headers where -u was given
+		bearer         string // --oauth2-bearer token
+		bearerAt       = -1
+		authScheme     string // "" for Basic
+		globoff        bool
+		pathAsIs       bool
 	)
 
+	// positional takes tokens[i] as the URL, or the next fragment of an
+	// unquoted URL broken at a space, as in
+	// "curl https://example.com/search?q=hello world".
+	positional := func(i int) {
+		tok := tokens[i]
+		if rawURL == "" {
+			rawURL, urlIndex, urlQuoted = tok, i, quoted[i]
+		} else if urlIndex == i-1 && !urlQuoted && !quoted[i] && urlContinues(rawURL, tok) {
+			rawURL += "%20" + tok
+			urlIndex = i
+			cp.warn(fmt.Sprintf("joined URL fragments separated by unencoded space: %s", quoteInput(tok)))
+		} else {
+			cp.warn(fmt.Sprintf("unexpected positional argument %s, skipping", quoteInput(tok)))
+		}
+	}
+
 	for i := 0; i < len(tokens); i++ {
 		tok := tokens[i]
+		if positionalFrom >= 0 && i >= positionalFrom {
+			positional(i)
+			continue
+		}
 
-		// Helper: consume the next token as an argument.
-		next := func() (string, bool) {
-			if i+1 < len(tokens) {
-				i++
-				return tokens[i], true
-			}
-			return "", false
+		// An option that takes an argument consumes the next token as v;
+		// ok is false when the command ends first.
+		takesArg, known := curlFlags[tok]
+		var v string
+		var ok bool
+		if takesArg && i+1 < len(tokens) {
+			i++
+			v, ok = tokens[i], true
 		}
 
 		switch tok {
 		// Method
 		case "-X", "--request":
-			if v, ok := next(); ok {
+			if ok {
 				method = strings.ToUpper(v)
 				explicitMethod = true
 			}
 
 		// Headers
 		case "-H", "--header":
-			if v, ok := next(); ok {
-				headers = append(headers, parseCurlHeader(v))
+			if ok {
+				if strings.HasPrefix(v, "@") {
+					headers = append(headers, cp.readHeaderFile(v)...)
+				} else {
+					headers = append(headers, parseCurlHeader(v))
+				}
 			}
 
 		// Body data — multiple -d flags are joined with "&" (curl behaviour).
 		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii":
-			if v, ok := next(); ok {
+			if ok {
+				if strings.HasPrefix(v, "@") {
+					cp.warn(fmt.Sprintf("file upload %s is not supported, body skipped", quoteInput(v)))
+				} else {
+					dataParts = append(dataParts, curlDataPart{value: v, flag: tok})
+				}
+			}
+
+		// --json is --data-binary plus JSON Content-Type and Accept headers;
+		// repeated --json values are concatenated.
+		case "--json":
+			if ok {
 				if strings.HasPrefix(v, "@") {
-					cp.warn(fmt.Sprintf("file upload %q is not supported, body skipped", v))
+					cp.warn(fmt.Sprintf("file upload %s is not supported, body skipped", quoteInput(v)))
 				} else {
-					dataParts = append(dataParts, v)
+					dataParts = append(dataParts, curlDataPart{value: v, flag: tok})
 				}
+				jsonData = true
 			}
 
 		// Multipart form data
 		case "-F", "--form":
-			if v, ok := next(); ok {
+			if ok {
 				formFields = append(formFields, v)
 			}
 
-		// URL-encoded form data
+		// URL-encoded form data, joined with the -d parts in command-line
+		// order.
 		case "--data-urlencode":
-			if v, ok := next(); ok {
-				urlEncFields = append(urlEncFields, v)
+			if ok {
+				dataParts = append(dataParts, curlDataPart{value: v, encode: true, flag: tok})
+				urlEncoded = true
 			}
 
 		// Cookie header.
 		case "-b", "--cookie":
-			if v, ok := next(); ok {
-				headers = append(headers, Header{Key: "Cookie", Value: v})
+			if ok {
+				headers = append(headers, Header{Key: HeaderCookie, Value: v})
 			}
 
-		// Basic auth — convert to Authorization: Basic header.
+		// Credentials, sent as Basic auth unless another scheme is chosen.
 		case "-u", "--user":
-			if v, ok := next(); ok {
-				if !strings.ContainsRune(v, ':') {
-					cp.warn(fmt.Sprintf("-u %q: no colon found; encoding username only (password was not provided)", v))
-				}
-				encoded := base64.StdEncoding.EncodeToString([]byte(v))
-				headers = append(headers, Header{Key: "Authorization", Value: "Basic " + encoded})
+			if ok {
+				user, userAt = v, len(headers)
+			}
+
+		// Authentication schemes.
+		case "--basic":
+			authScheme = ""
+		case "--digest", "--ntlm", "--negotiate":
+			authScheme = tok[2:]
+		case "--aws-sigv4":
+			if ok {
+				authScheme = "aws-sigv4"
+				result.ClientHints.AWSSigV4 = v
+			}
+		case "--oauth2-bearer":
+			if ok {
+				bearer, bearerAt = v, len(headers)
 			}
 
 		// HTTP version
@@ -164,54 +392,59 @@ func (cp *curlParser) parse(cmd string) *ParseResult {
 		// -I / --head implies HEAD method.
 		case "-I", "--head":
 			if !explicitMethod {
-				method = "HEAD"
+				method = MethodHead
 			}
 
-		// Flags that are silently ignored (no argument).
-		case "-v", "--verbose",
-			"-s", "--silent",
-			"-S", "--show-error",
-			"-L", "--location",
-			"--compressed",
-			"-k", "--insecure",
-			"-i", "--include",
-			"-O", // write to file named by remote
-			"-g", "--globoff",
-			"--no-keepalive",
-			"--fail", "-f",
-			"--no-progress-meter",
-			"-#", "--progress-bar":
-			// silently skip
-
-		// Flags that are silently ignored (consume one argument).
-		case "-o", "--output",
-			"-m", "--max-time",
-			"--connect-timeout",
-			"-A", "--user-agent",
-			"--proxy", "-x",
-			"--cert", "--key", "--cacert",
-			"--resolve",
-			"-e", "--referer",
-			"--limit-rate",
-			"-w", "--write-out",
-			"--retry",
-			"--dns-servers",
-			"--interface",
-			"--local-port",
-			"--max-redirs":
-			next() // consume and discard
+		// Byte ranges → Range: bytes=<spec>, passed through as written.
+		case "-r", "--range":
+			if ok {
+				if !isCurlRange(v) {
+					cp.warn(fmt.Sprintf("-r %s is not a byte range such as 0-499, 500- or -500; passed through as is", quoteInput(v)))
+				}
+				rangeSpec = v
+			}
 
-		default:
-			if strings.HasPrefix(tok, "-") {
-				cp.warn(fmt.Sprintf("unknown curl flag %q, skipping", tok))
-			} else {
-				// Positional argument — the URL.
-				if rawURL == "" {
-					rawURL = tok
-				} else {
-					cp.warn(fmt.Sprintf("unexpected positional argument %q, skipping", tok))
+		// Upload a file as the body of a PUT.
+		case "-T", "--upload-file":
+			if ok {
+				uploadFile = v
+			}
+
+		// Resuming needs the local file, so the offset cannot be applied.
+		case "-C", "--continue-at":
+			if ok {
+				cp.warn(fmt.Sprintf("-C %s: resume offset ignored", quoteInput(v)))
+			}
+
+		// URL globbing is off: brackets and braces are literal.
+		case "-g", "--globoff":
+			globoff = true
+
+		// The URL path is sent without removing its dot segments.
+		case "--path-as-is":
+			pathAsIs = true
+
+		// Connection overrides, kept as hints.
+		case "--resolve":
+			if ok {
+				result.ClientHints.Resolve = append(result.ClientHints.Resolve, cp.parseResolve(v)...)
+			}
+		case "--connect-to":
+			if ok {
+				if e, ok := cp.parseConnectTo(v); ok {
+					result.ClientHints.ConnectTo = append(result.ClientHints.ConnectTo, e)
 				}
 			}
+
+		default:
+			switch {
+			case known:
+				// an option without effect on the request, such as -s or -o
+			case strings.HasPrefix(tok, "-"):
+				cp.warn(fmt.Sprintf("unknown curl flag %s, skipping", quoteInput(tok)))
+			default:
+				positional(i)
+			}
 		}
 	}
 
@@ -221,53 +454,145 @@ func (cp *curlParser) parse(cmd string) *ParseResult {
 		return result
 	}
 
-	// Build body from the first non-empty body source.
+	// Expand the URL's globbing patterns, as curl does without -g; the
+	// first URL makes Request and every one a request in Requests. A URL
+	// without patterns loses the backslashes of escaped glob characters.
+	var globURLs []string
+	if !globoff {
+		patterns, tail := parseURLGlob(rawURL)
+		if len(patterns) == 0 {
+			rawURL = tail
+		} else {
+			limit := cp.opts.MaxGlobRequests
+			if limit <= 0 {
+				limit = DefaultMaxGlobRequests
+			}
+			var total int
+			var saturated bool
+			globURLs, total, saturated = expandURLGlob(patterns, tail, limit)
+			count := strconv.Itoa(total)
+			if saturated {
+				count = "more than " + count
+			}
+			if total > len(globURLs) {
+				cp.warn(fmt.Sprintf("URL contains globbing patterns; curl would issue %s requests, only the first %d are built", count, len(globURLs)))
+			} else {
+				cp.warn(fmt.Sprintf("URL contains globbing patterns; curl would issue %s requests", count))
+			}
+			rawURL = globURLs[0]
+		}
+	}
+
+	// Build body from the first non-empty body source. curl refuses -F
+	// together with -d or --data-urlencode; the form is kept here.
 	var body []byte
 	var autoContentType string
 
+	if len(formFields) > 0 && len(dataParts) > 0 && uploadFile == "" {
+		flags := dataFlags(dataParts)
+		cp.warn(fmt.Sprintf("-F cannot be combined with %s, which curl rejects (\"You can only select one HTTP request method\"); the %s data was dropped", flags, flags))
+	}
 	switch {
+	case uploadFile != "":
+		body = cp.readUpload(uploadFile, &result.ClientHints)
 	case len(formFields) > 0:
 		b, boundary := buildMultipartForm(formFields, cp)
 		body = b
 		autoContentType = "multipart/form-data; boundary=" + boundary
-	case len(urlEncFields) > 0:
-		body = []byte(buildURLEncoded(urlEncFields))
-		autoContentType = "application/x-www-form-urlencoded"
+	case len(dataParts) > 0 && jsonData:
+		body = []byte(joinDataParts(dataParts, ""))
+		autoContentType = "application/json"
 	case len(dataParts) > 0:
-		body = []byte(strings.Join(dataParts, "&"))
+		body = []byte(joinDataParts(dataParts, "&"))
+		if urlEncoded {
+			autoContentType = "application/x-www-form-urlencoded"
+		}
 	}
 
-	// Default method: GET, or POST when a body is present.
+	// Default method: PUT for an upload, else GET, or POST when a body is
+	// present.
 	if method == "" {
-		if len(body) > 0 {
-			method = "POST"
+		if uploadFile != "" {
+			method = MethodPut
+		} else if len(body) > 0 {
+			method = MethodPost
 		} else {
-			method = "GET"
+			method = MethodGet
 		}
 	}
 
 	// Parse the URL into scheme, userinfo, host, path components.
-	scheme, userinfo, host, path := parseCurlURL(rawURL)
+	scheme, userinfo, host, path := curlTarget(rawURL, uploadFile, urlQuoted, pathAsIs, cp.warn)
+	if host != "" {
+		result.URL = BuildURL(scheme, host, path)
+	}
+
+	// CONNECT uses authority-form (RFC 9112 §3.2.3): the request-target is
+	// host:port rather than a path. Default the port from the scheme.
+	if method == MethodConnect && host != "" {
+		path = connectTarget(scheme, host)
+	}
+
+	if bearer != "" && !curlHeadersHas(headers, HeaderAuthorization) {
+		auth := Header{Key: HeaderAuthorization, Value: "Bearer " + bearer}
+		headers = append(headers[:bearerAt], append([]Header{auth}, headers[bearerAt:]...)...)
+	}
 
-	// Credentials embedded in the URL (user:pass@host) → Authorization: Basic.
-	if userinfo != "" && !curlHeadersHas(headers, "Authorization") {
-		encoded := base64.StdEncoding.EncodeToString([]byte(userinfo))
-		headers = append(headers, Header{Key: "Authorization", Value: "Basic " + encoded})
+	// Credentials from -u, else embedded in the URL (user:pass@host), become
+	// Authorization: Basic unless an explicit header or another scheme
+	// takes precedence.
+	if user != "" && authScheme == "" && !strings.ContainsRune(user, ':') {
+		cp.warn("-u: no colon found; encoding username only (password was not provided)")
+	}
+	credentials := user
+	if credentials == "" {
+		credentials, userAt = userinfo, len(headers)
+	}
+	switch {
+	case authScheme != "":
+		result.ClientHints.AuthScheme, result.ClientHints.Credentials = authScheme, credentials
+		switch {
+		case curlHeadersHas(headers, HeaderAuthorization):
+		case authScheme == "aws-sigv4":
+			cp.warn("--aws-sigv4: the signature must be computed when the request is sent; Authorization header omitted")
+		default:
+			cp.warn(fmt.Sprintf("--%s: authentication needs the server's challenge; Authorization header omitted", authScheme))
+		}
+	case credentials != "" && !curlHeadersHas(headers, HeaderAuthorization):
+		encoded := base64.StdEncoding.EncodeToString([]byte(credentials))
+		auth := Header{Key: HeaderAuthorization, Value: "Basic " + encoded}
+		headers = append(headers[:userAt], append([]Header{auth}, headers[userAt:]...)...)
 	}
 
 	// Inject Host header (prepend so it appears first, matching lenient behaviour).
-	if host != "" && !curlHeadersHas(headers, "Host") {
-		headers = append([]Header{{Key: "Host", Value: host}}, headers...)
+	if host != "" && !curlHeadersHas(headers, HeaderHost) {
+		headers = append([]Header{{Key: HeaderHost, Value: host}}, headers...)
+	} else if host != "" && !eqFold(curlHeaderValue(headers, HeaderHost), host) {
+		result.ClientHints.Authority = host
+	}
+
+	// A -H "Range: ..." header overrides -r, as in curl.
+	if rangeSpec != "" && !curlHeadersHas(headers, HeaderRange) {
+		headers = append(headers, Header{Key: HeaderRange, Value: "bytes=" + rangeSpec})
 	}
 
 	// Auto Content-Type for form bodies (only when not explicitly set).
-	if autoContentType != "" && !curlHeadersHas(headers, "Content-Type") {
-		headers = append(headers, Header{Key: "Content-Type", Value: autoContentType})
+	if autoContentType != "" && !curlHeadersHas(headers, HeaderContentType) {
+		headers = append(headers, Header{Key: HeaderContentType, Value: autoContentType})
+	}
+
+	if jsonData && !curlHeadersHas(headers, HeaderAccept) {
+		headers = append(headers, Header{Key: HeaderAccept, Value: "application/json"})
 	}
 
 	// Auto Content-Length when a body is present and the header is absent.
-	if len(body) > 0 && !curlHeadersHas(headers, "Content-Length") {
-		headers = append(headers, Header{Key: "Content-Length", Value: fmt.Sprintf("%d", len(body))})
+	if len(body) > 0 && !curlHeadersHas(headers, HeaderContentLength) {
+		headers = append(headers, Header{Key: HeaderContentLength, Value: fmt.Sprintf("%d", len(body))})
+	}
+
+	cp.checkBody(headers, body)
+	for _, w := range ConnectionHeaderWarnings(version, headers) {
+		cp.warn(w)
 	}
 
 	result.Request = &Request{
@@ -278,9 +603,349 @@ func (cp *curlParser) parse(cmd string) *ParseResult {
 		Headers: headers,
 		Body:    body,
 	}
+	if len(globURLs) > 0 {
+		result.Requests = []*Request{result.Request}
+		for _, u := range globURLs[1:] {
+			result.Requests = append(result.Requests, globRequest(result.Request, host, u, uploadFile, urlQuoted, pathAsIs))
+		}
+	}
 	return result
 }
 
+// connectTarget is the authority-form target of a CONNECT to host,
+// defaulting the port from the scheme.
+func connectTarget(scheme, host string) string {
+	if isAuthorityForm(host) {
+		return host
+	}
+	if scheme == "http" {
+		return host + ":80"
+	}
+	return host + ":443"
+}
+
+// curlTarget splits rawURL into its parts and normalizes the path and
+// host as curl sends them: the -T file name appended to a path ending in
+// '/', dot segments removed unless asIs, characters not allowed in a
+// request-target percent-encoded unless quoted, and the host lowercased,
+// or converted with ToASCIIHost when it is not ASCII. warn, when not nil,
+// is called with a note on each change but lowercasing.
+func curlTarget(rawURL, uploadFile string, quoted, asIs bool, warn func(string)) (scheme, userinfo, host, path string) {
+	if warn == nil {
+		warn = func(string) {}
+	}
+	scheme, userinfo, host, path = parseCurlURL(rawURL)
+	if uploadFile != "" && uploadFile != "-" && uploadFile != "." {
+		path = appendUploadName(path, uploadFile)
+	}
+	if !asIs {
+		if dedotted, _ := RemoveDotSegments(path); dedotted != path {
+			warn(fmt.Sprintf("URL path %s sent as %s, dot segments removed; --path-as-is keeps them", quoteInput(path), quoteInput(dedotted)))
+			path = dedotted
+		}
+	}
+	if !quoted {
+		var escaped []string
+		if path, escaped = escapeTargetChars(path); len(escaped) > 0 {
+			warn(fmt.Sprintf("percent-encoded characters not allowed in a request-target: %s", strings.Join(escaped, " ")))
+		}
+	}
+	if !hasNonASCII(host) {
+		host = strings.ToLower(host)
+	} else if ascii, err := toASCIIHost(host); err != nil {
+		warn(fmt.Sprintf("%v; sent as is", err))
+	} else {
+		warn(fmt.Sprintf("internationalized host %s sent as %s", quoteInput(host), quoteInput(ascii)))
+		host = ascii
+	}
+	return scheme, userinfo, host, path
+}
+
+// globRequest returns the request for rawURL, another URL of the glob
+// whose first URL, sent to firstHost, gave first: a copy of it with the
+// target, scheme and injected Host header of rawURL, normalized as the
+// first URL was but without warnings.
+func globRequest(first *Request, firstHost, rawURL, uploadFile string, quoted, asIs bool) *Request {
+	scheme, _, host, path := curlTarget(rawURL, uploadFile, quoted, asIs, nil)
+	if first.Method == MethodConnect && host != "" {
+		path = connectTarget(scheme, host)
+	}
+	req := *first
+	req.Path, req.Scheme = path, scheme
+	req.Headers = append([]Header(nil), first.Headers...)
+	for i, h := range req.Headers {
+		if eqFold(h.Key, HeaderHost) && h.Value == firstHost && host != "" {
+			req.Headers[i].Value = host
+		}
+	}
+	return &req
+}
+
+// readUpload returns the body of a -T upload: the file's contents when a
+// FileReader is configured and can read it, or nil with a warning and the
+// file recorded in hints.BodyFile. "-" and "." read stdin, which a parser
+// never has.
+func (cp *curlParser) readUpload(name string, hints *ClientHints) []byte {
+	if name == "-" || name == "." {
+		cp.warn(fmt.Sprintf("-T %s: stdin is not available, body left empty", quoteInput(name)))
+		hints.BodyFile = "-"
+		return nil
+	}
+	if cp.opts.FileReader == nil {
+		cp.warn(fmt.Sprintf("-T %s: file not read, body left empty", quoteInput(name)))
+		hints.BodyFile = name
+		return nil
+	}
+	data, err := cp.opts.FileReader(name)
+	if err != nil {
+		cp.warn(fmt.Sprintf("-T %s: %v, body left empty", quoteInput(name), err))
+		hints.BodyFile = name
+		return nil
+	}
+	return data
+}
+
+// readHeaderFile returns the headers of a "-H @file" argument, one per
+// line, skipping blank and '#' comment lines, when a FileReader is
+// configured and can read the file. Lines that are not "name: value",
+// including folded continuations, are skipped with a warning.
+func (cp *curlParser) readHeaderFile(arg string) []Header {
+	name := arg[1:]
+	if name == "-" {
+		cp.warn(fmt.Sprintf("header file %s is stdin, which is not available; headers omitted", quoteInput(arg)))
+		return nil
+	}
+	if cp.opts.FileReader == nil {
+		cp.warn(fmt.Sprintf("header file %s not read, headers omitted", quoteInput(arg)))
+		return nil
+	}
+	data, err := cp.opts.FileReader(name)
+	if err != nil {
+		cp.warn(fmt.Sprintf("header file %s: %v, headers omitted", quoteInput(arg), err))
+		return nil
+	}
+	var headers []Header
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		h := parseCurlHeader(line)
+		if line[0] == ' ' || line[0] == '\t' || !strings.Contains(line, ":") || h.Key == "" {
+			cp.warn(fmt.Sprintf("header file %s line %d: invalid header %s, skipped", quoteInput(arg), i+1, quoteInput(RedactHeaderLine(line))))
+			continue
+		}
+		headers = append(headers, h)
+	}
+	return headers
+}
+
+// parseResolve parses a --resolve argument, [+]host:port:addr[,addr...],
+// into one entry per address, warning about and skipping what is
+// malformed. A "-host:port" entry, which clears curl's DNS cache, yields
+// none.
+func (cp *curlParser) parseResolve(arg string) []ResolveEntry {
+	if strings.HasPrefix(arg, "-") {
+		cp.warn(fmt.Sprintf("--resolve %s removes a DNS cache entry, ignored", quoteInput(arg)))
+		return nil
+	}
+	fields := splitHostFields(strings.TrimPrefix(arg, "+"), 3)
+	if len(fields) != 3 || fields[0] == "" || fields[2] == "" {
+		cp.warn(fmt.Sprintf("--resolve %s: want host:port:address, skipped", quoteInput(arg)))
+		return nil
+	}
+	port, ok := parsePort(fields[1])
+	if !ok || port == 0 {
+		cp.warn(fmt.Sprintf("--resolve %s: invalid port %s, skipped", quoteInput(arg), quoteInput(fields[1])))
+		return nil
+	}
+	var entries []ResolveEntry
+	for _, addr := range strings.Split(fields[2], ",") {
+		ip := strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		if net.ParseIP(ip) == nil {
+			cp.warn(fmt.Sprintf("--resolve %s: invalid address %s, skipped", quoteInput(arg), quoteInput(addr)))
+			continue
+		}
+		entries = append(entries, ResolveEntry{Host: fields[0], Port: port, Address: ip})
+	}
+	return entries
+}
+
+// parseConnectTo parses a --connect-to argument, HOST1:PORT1:HOST2:PORT2
+// with any part empty, warning about it when it is malformed.
+func (cp *curlParser) parseConnectTo(arg string) (ConnectToEntry, bool) {
+	fields := splitHostFields(arg, 4)
+	if len(fields) != 4 {
+		cp.warn(fmt.Sprintf("--connect-to %s: want HOST1:PORT1:HOST2:PORT2, skipped", quoteInput(arg)))
+		return ConnectToEntry{}, false
+	}
+	port, ok1 := parsePort(fields[1])
+	toPort, ok2 := parsePort(fields[3])
+	if !ok1 || !ok2 {
+		cp.warn(fmt.Sprintf("--connect-to %s: invalid port, skipped", quoteInput(arg)))
+		return ConnectToEntry{}, false
+	}
+	host, toHost := unbracket(fields[0]), unbracket(fields[2])
+	if strings.Contains(host, ":") && net.ParseIP(host) == nil || strings.Contains(toHost, ":") && net.ParseIP(toHost) == nil {
+		cp.warn(fmt.Sprintf("--connect-to %s: invalid IPv6 address, skipped", quoteInput(arg)))
+		return ConnectToEntry{}, false
+	}
+	return ConnectToEntry{Host: host, Port: port, ToHost: toHost, ToPort: toPort}, true
+}
+
+// splitHostFields splits s at colons outside square brackets into at most
+// n fields, the last taking the rest of s.
+func splitHostFields(s string, n int) []string {
+	var fields []string
+	start, bracket := 0, false
+	for i := 0; i < len(s) && len(fields) < n-1; i++ {
+		switch s[i] {
+		case '[':
+			bracket = true
+		case ']':
+			bracket = false
+		case ':':
+			if !bracket {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// parsePort parses a port number, or "" as 0.
+func parsePort(s string) (int, bool) {
+	if s == "" {
+		return 0, true
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 || s[0] == '+' {
+		return 0, false
+	}
+	return port, true
+}
+
+// unbracket strips the brackets from an IPv6 address literal.
+func unbracket(host string) string {
+	if len(host) >= 2 && host[0] == '[' && host[len(host)-1] == ']' {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
+
+// appendUploadName adds the upload file's base name to a path that ends in
+// "/", as curl does when a -T URL names no remote file.
+func appendUploadName(path, file string) string {
+	p, query, hasQuery := strings.Cut(path, "?")
+	if !strings.HasSuffix(p, "/") {
+		return path
+	}
+	p += file[strings.LastIndexAny(file, "/\\")+1:]
+	if hasQuery {
+		p += "?" + query
+	}
+	return p
+}
+
+// isCurlRange reports whether spec is a comma-separated list of byte
+// ranges in the forms curl's -r accepts: "0-499", "500-" or "-500".
+func isCurlRange(spec string) bool {
+	for _, r := range strings.Split(spec, ",") {
+		first, last, ok := strings.Cut(r, "-")
+		if !ok || first == "" && last == "" ||
+			strings.Trim(first, "0123456789") != "" || strings.Trim(last, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkBody warns when body does not fit the effective Content-Type: JSON
+// that does not parse, or a form body with characters that must be
+// percent-encoded. These are typically quoting mistakes in a pasted
+// command; the body itself is left alone.
+func (cp *curlParser) checkBody(headers []Header, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	var ct string
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderContentType) {
+			ct = h.Value
+			break
+		}
+	}
+	mediaType, _, _ := strings.Cut(ct, ";")
+	mediaType = strings.ToLower(trimString(mediaType))
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if json.Valid(body) {
+			return
+		}
+		var v interface{}
+		err := json.Unmarshal(body, &v)
+		off := len(body)
+		if se, ok := err.(*json.SyntaxError); ok {
+			off = int(se.Offset) - 1 // Offset counts the offending byte
+			if off < 0 {
+				off = 0
+			}
+		}
+		cp.warn(fmt.Sprintf("body is not valid JSON for Content-Type %s: %v at offset %d\n%s", excerpt(mediaType), err, off, caretSnippet(body, off)))
+
+	case mediaType == "application/x-www-form-urlencoded":
+		if off := formEncodingError(body); off >= 0 {
+			cp.warn(fmt.Sprintf("body byte %q at offset %d must be percent-encoded for Content-Type %s\n%s", body[off], off, excerpt(mediaType), caretSnippet(body, off)))
+		}
+	}
+}
+
+// formEncodingError returns the offset of the first byte in body that may
+// not appear unencoded in a URL query — including a '%' not followed by
+// two hex digits — or -1 if there is none.
+func formEncodingError(body []byte) int {
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(body) || !isHexDigit(body[i+1]) || !isHexDigit(body[i+2]) {
+				return i
+			}
+		case c <= ' ' || c >= 0x7f:
+			return i
+		case strings.IndexByte("\"#<>[\\]^`{|}", c) >= 0:
+			return i
+		}
+	}
+	return -1
+}
+
+// caretSnippet returns the line of data around off, at most 60 bytes,
+// followed by a line with a caret under off.
+func caretSnippet(data []byte, off int) string {
+	const width = 60
+	start := bytes.LastIndexByte(data[:off], '\n') + 1
+	end := len(data)
+	if i := bytes.IndexByte(data[off:], '\n'); i >= 0 {
+		end = off + i
+	}
+	if off-start > width/2 {
+		start = off - width/2
+	}
+	if end-start > width {
+		end = start + width
+	}
+	line := strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return ' '
+		}
+		return r
+	}, string(data[start:end]))
+	return "  " + line + "\n  " + strings.Repeat(" ", utf8.RuneCount(data[start:off])) + "^"
+}
+
 // parseCurlHeader splits "Key: Value" on the first colon.
 func parseCurlHeader(s string) Header {
 	colon := strings.IndexByte(s, ':')
@@ -345,6 +1010,139 @@ func parseCurlURL(rawURL string) (scheme, userinfo, host, path string) {
 	return scheme, userinfo, host, path
 }
 
+// RemoveDotSegments resolves the "." and ".." segments of the path part of
+// target, leaving its query alone, by the algorithm of RFC 3986 §5.2.4
+// that curl applies unless given --path-as-is. A ".." above the root is
+// dropped, and aboveRoot reports that one was. Encoded dots such as
+// "%2e%2e" are not segments and stay.
+func RemoveDotSegments(target string) (path string, aboveRoot bool) {
+	in, query := target, ""
+	if i := strings.IndexByte(target, '?'); i >= 0 {
+		in, query = target[:i], target[i:]
+	}
+	if !strings.Contains(in, ".") {
+		return target, false
+	}
+	out := make([]byte, 0, len(in))
+	dropLast := func() {
+		aboveRoot = aboveRoot || len(out) == 0
+		out = out[:max(bytes.LastIndexByte(out, '/'), 0)]
+	}
+	for in != "" {
+		switch {
+		case strings.HasPrefix(in, "../"):
+			in, aboveRoot = in[3:], true
+		case strings.HasPrefix(in, "./"), strings.HasPrefix(in, "/./"):
+			in = in[2:]
+		case in == "/.":
+			in = "/"
+		case strings.HasPrefix(in, "/../"):
+			in = in[3:]
+			dropLast()
+		case in == "/..":
+			in = "/"
+			dropLast()
+		case in == ".":
+			in = ""
+		case in == "..":
+			in, aboveRoot = "", true
+		default:
+			end := strings.IndexByte(in[1:], '/') + 1
+			if end == 0 {
+				end = len(in)
+			}
+			out = append(out, in[:end]...)
+			in = in[end:]
+		}
+	}
+	return string(out) + query, aboveRoot
+}
+
+// BuildURL joins scheme, host and path into a normalized absolute URL: the
+// scheme defaults to "http" and is lowercased, as is the host; a bare IPv6
+// literal is bracketed; the scheme's default port (80 or 443) and any
+// fragment are dropped. An empty path yields no trailing slash, as for the
+// asterisk and authority request-target forms.
+func BuildURL(scheme, host, path string) string {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" {
+		scheme = "http"
+	}
+	host = strings.ToLower(host)
+
+	name, port := host, ""
+	if strings.HasPrefix(host, "[") {
+		if end := strings.IndexByte(host, ']'); end >= 0 && strings.HasPrefix(host[end+1:], ":") {
+			name, port = host[:end+1], host[end+2:]
+		}
+	} else if strings.Count(host, ":") == 1 {
+		i := strings.IndexByte(host, ':')
+		name, port = host[:i], host[i+1:]
+	} else if strings.Contains(host, ":") {
+		name = "[" + host + "]"
+	}
+	if port == "" || (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		host = name
+	} else {
+		host = name + ":" + port
+	}
+
+	if i := strings.IndexByte(path, '#'); i >= 0 {
+		path = path[:i]
+	}
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	return scheme + "://" + host + path
+}
+
+// urlContinues reports whether frag, an unquoted positional argument right
+// after the URL, is more likely the rest of a URL broken at a space than a
+// second argument: the URL has a path and either a query string or ends
+// mid-word, and frag does not look like a URL of its own.
+func urlContinues(rawURL, frag string) bool {
+	if strings.Contains(frag, "://") {
+		return false
+	}
+	rest := rawURL
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	if !strings.Contains(rest, "/") {
+		return false
+	}
+	if strings.Contains(rest, "?") {
+		return true
+	}
+	last := rest[len(rest)-1]
+	return last >= 'a' && last <= 'z' || last >= 'A' && last <= 'Z' || last >= '0' && last <= '9'
+}
+
+// escapeTargetChars percent-encodes the characters that cannot appear in a
+// request-target but that an unquoted URL may carry: '{', '}', '|', space
+// and '"'. It also returns the characters it encoded, quoted, in order of
+// first appearance.
+func escapeTargetChars(path string) (string, []string) {
+	if !strings.ContainsAny(path, "{}| \"") {
+		return path, nil
+	}
+	var b strings.Builder
+	var escaped []string
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch c {
+		case '{', '}', '|', ' ', '"':
+			writeEscape(&b, c)
+			if q := strconv.Quote(string(c)); !containsString(escaped, q) {
+				escaped = append(escaped, q)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), escaped
+}
+
 // curlHeadersHas reports whether headers contains a header with key (case-insensitive).
 func curlHeadersHas(headers []Header, key string) bool {
 	for _, h := range headers {
@@ -355,20 +1153,108 @@ func curlHeadersHas(headers []Header, key string) bool {
 	return false
 }
 
+// curlHeaderValue returns the value of the first header named key, or "".
+func curlHeaderValue(headers []Header, key string) string {
+	for _, h := range headers {
+		if eqFold(h.Key, key) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// shellOperators are the tokens that end a simple command: pipes, command
+// list operators and redirections.
+var shellOperators = map[string]bool{
+	"|": true, "|&": true, "||": true, "&&": true, "&": true, ";": true,
+	">": true, ">>": true, "<": true, "2>": true, "2>>": true, "&>": true,
+}
+
+// cutShellTail cuts tokens at the first unquoted shell operator, a
+// redirection with its target attached such as "2>/dev/null", or a ";"
+// ending a token, and returns the tokens before it and the rest rejoined
+// with spaces, or "" if there is no operator.
+func cutShellTail(tokens []string, quoted []bool) ([]string, []bool, string) {
+	for i, tok := range tokens {
+		if quoted[i] {
+			continue
+		}
+		if shellOperators[tok] || isRedirection(tok) {
+			return tokens[:i], quoted[:i], strings.Join(tokens[i:], " ")
+		}
+		if strings.HasSuffix(tok, ";") {
+			rest := append([]string{";"}, tokens[i+1:]...)
+			head := append(tokens[:i:i], tok[:len(tok)-1])
+			return head, quoted[:i+1], strings.Join(rest, " ")
+		}
+	}
+	return tokens, quoted, ""
+}
+
+func isRedirection(tok string) bool {
+	for _, op := range []string{">", "<", "2>", "&>"} {
+		if strings.HasPrefix(tok, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommand tokenizes cmd with shellSplitQuoted, recovering from the
+// unclosed quotes of a hand-edited paste with a warning. A single quote
+// between two letters inside a single-quoted argument, as in
+// -d '{"name":"O'Brien"}', is read as an apostrophe when that makes the
+// quotes balance; failing that, a single quote left open with no quote
+// characters after it is closed at the end of the input.
+func (cp *curlParser) splitCommand(cmd string) ([]string, []bool, error) {
+	tokens, quoted, open, err := splitShell(cmd, false)
+	if err == nil {
+		return tokens, quoted, nil
+	}
+	if t, q, _, err := splitShell(cmd, true); err == nil {
+		cp.warn("unescaped single quote inside a single-quoted argument read as an apostrophe")
+		return t, q, nil
+	}
+	if cmd[open] == '\'' && !strings.ContainsAny(cmd[open+1:], `'"`) {
+		if t, q, _, err := splitShell(cmd+"'", false); err == nil {
+			cp.warn("unclosed single quote closed at the end of the command")
+			return t, q, nil
+		}
+	}
+	return tokens, quoted, err
+}
+
 // shellSplit tokenizes a shell command string respecting single and double quotes.
 // It returns an error only for unclosed quotes.
 func shellSplit(s string) ([]string, error) {
-	var tokens []string
+	tokens, _, err := shellSplitQuoted(s)
+	return tokens, err
+}
+
+// shellSplitQuoted is shellSplit that also reports, per token, whether any
+// part of it was quoted or backslash-escaped.
+func shellSplitQuoted(s string) ([]string, []bool, error) {
+	tokens, quoted, _, err := splitShell(s, false)
+	return tokens, quoted, err
+}
+
+// splitShell is shellSplitQuoted that also returns the offset of the quote
+// left open when err is set. With apostrophes, a single quote between two
+// letters inside single quotes is literal rather than closing them.
+func splitShell(s string, apostrophes bool) (tokens []string, quoted []bool, open int, err error) {
 	var cur bytes.Buffer
 	inSingle := false
 	inDouble := false
 	hasContent := false
+	isQuoted := false
 
 	for i := 0; i < len(s); i++ {
 		c := s[i]
 		switch {
 		case inSingle:
-			if c == '\'' {
+			if c == '\'' && apostrophes && i > 0 && i+1 < len(s) && isLetter(s[i-1]) && isLetter(s[i+1]) {
+				cur.WriteByte(c)
+			} else if c == '\'' {
 				inSingle = false
 			} else {
 				cur.WriteByte(c)
@@ -393,22 +1279,23 @@ func shellSplit(s string) ([]string, error) {
 				hasContent = true
 			}
 		case c == '\'':
-			inSingle = true
-			hasContent = true // empty quotes still yield an empty token
+			inSingle, open = true, i
+			hasContent, isQuoted = true, true // empty quotes still yield an empty token
 		case c == '"':
-			inDouble = true
-			hasContent = true
+			inDouble, open = true, i
+			hasContent, isQuoted = true, true
 		case c == '\\':
 			if i+1 < len(s) {
 				cur.WriteByte(s[i+1])
 				i++
-				hasContent = true
+				hasContent, isQuoted = true, true
 			}
 		case c == ' ' || c == '\t':
 			if hasContent {
 				tokens = append(tokens, cur.String())
+				quoted = append(quoted, isQuoted)
 				cur.Reset()
-				hasContent = false
+				hasContent, isQuoted = false, false
 			}
 		default:
 			cur.WriteByte(c)
@@ -417,67 +1304,139 @@ func shellSplit(s string) ([]string, error) {
 	}
 
 	if inSingle {
-		return nil, fmt.Errorf("unclosed single quote")
+		return nil, nil, open, fmt.Errorf("unclosed single quote")
 	}
 	if inDouble {
-		return nil, fmt.Errorf("unclosed double quote")
+		return nil, nil, open, fmt.Errorf("unclosed double quote")
 	}
 	if hasContent {
 		tokens = append(tokens, cur.String())
+		quoted = append(quoted, isQuoted)
 	}
-	return tokens, nil
+	return tokens, quoted, 0, nil
+}
+
+// isLetter reports whether c is an ASCII letter.
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
 }
 
-// buildMultipartForm encodes form fields as multipart/form-data with a fixed
-// boundary. File upload references (@filename) are skipped with a warning.
+// maxBoundaryTries bounds how many boundaries buildMultipartForm generates
+// looking for one that no form value contains.
+const maxBoundaryTries = 8
+
+// buildMultipartForm encodes form fields as multipart/form-data with a
+// generated boundary that no field contains; see CurlOptions.BoundarySeed.
+// File upload references (@filename) are skipped with a warning.
 func buildMultipartForm(fields []string, cp *curlParser) (body []byte, boundary string) {
-	boundary = "ShapeHttpFormBoundary"
-	var buf bytes.Buffer
+	var names, values []string
 	for _, field := range fields {
 		eq := strings.IndexByte(field, '=')
 		if eq < 0 {
-			cp.warn(fmt.Sprintf("-F value %q has no '=', skipped", field))
+			cp.warn(fmt.Sprintf("-F value %s has no '=', skipped", quoteInput(field)))
 			continue
 		}
 		name := field[:eq]
 		value := field[eq+1:]
 		if strings.HasPrefix(value, "@") {
-			cp.warn(fmt.Sprintf("-F file upload %q is not supported, skipped", field))
+			cp.warn(fmt.Sprintf("-F file upload %s is not supported, skipped", quoteInput(field)))
 			continue
 		}
+		names = append(names, name)
+		values = append(values, value)
+	}
+
+	seed := cp.opts.BoundarySeed
+	if seed == 0 {
+		h := fnv.New64a()
+		for _, f := range fields {
+			h.Write([]byte(f))
+			h.Write([]byte{0})
+		}
+		seed = int64(h.Sum64())
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for try := 1; ; try++ {
+		boundary = fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64())
+		collides := false
+		for i := range names {
+			if strings.Contains(names[i], boundary) || strings.Contains(values[i], boundary) {
+				collides = true
+				break
+			}
+		}
+		if !collides {
+			break
+		}
+		if try == maxBoundaryTries {
+			cp.warn(fmt.Sprintf("multipart boundary %s occurs in a -F value after %d tries", quoteInput(boundary), try))
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	for i := range names {
 		buf.WriteString("--" + boundary + "\r\n")
-		buf.WriteString("Content-Disposition: form-data; name=\"" + name + "\"\r\n")
+		buf.WriteString("Content-Disposition: form-data; name=\"" + names[i] + "\"\r\n")
 		buf.WriteString("\r\n")
-		buf.WriteString(value)
+		buf.WriteString(values[i])
 		buf.WriteString("\r\n")
 	}
 	buf.WriteString("--" + boundary + "--\r\n")
 	return buf.Bytes(), boundary
 }
 
-// buildURLEncoded builds an application/x-www-form-urlencoded body from
-// --data-urlencode fields. Supported formats per curl(1):
-//
-//	"name=value"   → name=percentEncode(value)
-//	"=value"       → percentEncode(value)
-//	"name"         → percentEncode(name) (treated as value only)
-func buildURLEncoded(fields []string) string {
-	parts := make([]string, 0, len(fields))
-	for _, field := range fields {
-		eq := strings.IndexByte(field, '=')
-		if eq < 0 {
-			parts = append(parts, percentEncode(field))
-		} else {
-			name := field[:eq]
-			value := field[eq+1:]
-			if name == "" {
-				parts = append(parts, percentEncode(value))
-			} else {
-				parts = append(parts, name+"="+percentEncode(value))
-			}
+// curlDataPart is one -d, --json or --data-urlencode value; encode is set
+// for --data-urlencode.
+type curlDataPart struct {
+	value  string
+	encode bool
+	flag   string // the option that gave it, such as "-d" or "--json"
+}
+
+// dataFlags lists the options that gave parts, each once, in order, as
+// "-d", "-d and --json" or "-d, --json and --data-urlencode".
+func dataFlags(parts []curlDataPart) string {
+	var flags []string
+	for _, part := range parts {
+		if !containsString(flags, part.flag) {
+			flags = append(flags, part.flag)
+		}
+	}
+	if len(flags) == 1 {
+		return flags[0]
+	}
+	return strings.Join(flags[:len(flags)-1], ", ") + " and " + flags[len(flags)-1]
+}
+
+// joinDataParts joins the data parts with sep, encoding the
+// --data-urlencode ones with urlEncodeField.
+func joinDataParts(parts []curlDataPart, sep string) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		values[i] = part.value
+		if part.encode {
+			values[i] = urlEncodeField(part.value)
 		}
 	}
-	return strings.Join(parts, "&")
+	return strings.Join(values, sep)
+}
+
+// urlEncodeField encodes one --data-urlencode field. Supported formats per
+// curl(1):
+//
+//	"name=value"   → name=PercentEncode(value)
+//	"=value"       → PercentEncode(value)
+//	"name"         → PercentEncode(name) (treated as value only)
+func urlEncodeField(field string) string {
+	eq := strings.IndexByte(field, '=')
+	switch {
+	case eq < 0:
+		return PercentEncode(field)
+	case eq == 0:
+		return PercentEncode(field[1:])
+	}
+	return field[:eq+1] + PercentEncode(field[eq+1:])
 }
 
 // stripNonCurlLines removes lines that are not part of a curl command:
@@ -503,22 +1462,89 @@ func stripNonCurlLines(cmd string) string {
 	return strings.Join(kept, "\n")
 }
 
+// curlFlags lists every name of the curl options ParseCurl knows, each
+// mapped to whether it consumes the next token as its argument. The parse
+// switch gives meaning to most of them; the rest are accepted and ignored.
+var curlFlags = map[string]bool{
+	"-X": true, "--request": true,
+	"-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true,
+	"--json": true, "--data-urlencode": true,
+	"-F": true, "--form": true,
+	"-b": true, "--cookie": true,
+	"-u": true, "--user": true,
+	"--basic": false, "--digest": false, "--ntlm": false, "--negotiate": false,
+	"--aws-sigv4": true, "--oauth2-bearer": true,
+	"--http2": false, "--http2-prior-knowledge": false, "--http3": false,
+	"--http1.0": false, "--http1.1": false,
+	"-I": false, "--head": false,
+	"-r": true, "--range": true,
+	"-T": true, "--upload-file": true,
+	"-C": true, "--continue-at": true,
+	"-g": false, "--globoff": false,
+	"--path-as-is": false,
+	"--resolve":    true, "--connect-to": true,
+
+	// Ignored, without an argument.
+	"-v": false, "--verbose": false,
+	"-s": false, "--silent": false,
+	"-S": false, "--show-error": false,
+	"-L": false, "--location": false,
+	"--compressed": false,
+	"-k":           false, "--insecure": false,
+	"-i": false, "--include": false,
+	"-O":             false, // write to file named by remote
+	"--no-keepalive": false,
+	"-f":             false, "--fail": false,
+	"--no-progress-meter": false,
+	"-#":                  false, "--progress-bar": false,
+
+	// Ignored, with an argument.
+	"-o": true, "--output": true,
+	"-m": true, "--max-time": true,
+	"--connect-timeout": true,
+	"-A":                true, "--user-agent": true,
+	"-x": true, "--proxy": true,
+	"--cert": true, "--key": true, "--cacert": true,
+	"-e": true, "--referer": true,
+	"--limit-rate": true,
+	"-w":           true, "--write-out": true,
+	"--retry":       true,
+	"--dns-servers": true,
+	"--interface":   true,
+	"--local-port":  true,
+	"--max-redirs":  true,
+}
+
+// shortArgFlags are the single-char flags of curlFlags that take an
+// argument, for splitting compound short flags.
+var shortArgFlags = func() map[byte]bool {
+	m := make(map[byte]bool)
+	for name, arg := range curlFlags {
+		if arg && len(name) == 2 && name[0] == '-' {
+			m[name[1]] = true
+		}
+	}
+	return m
+}()
+
+// curlTakesArg reports whether the flag tok consumes the next token.
+func curlTakesArg(tok string) bool {
+	return curlFlags[tok]
+}
+
 // expandShortFlags expands compound short flags into individual tokens.
 // Examples: -sS → [-s, -S], -vk → [-v, -k], -XPOST → [-X, POST].
 // When a char that takes an argument is encountered, the remaining characters
 // in the compound become that argument (curl behaviour). Tokens that start
 // with "--", consist of a single char, or start with '-#' are passed through.
 func expandShortFlags(tokens []string) []string {
-	// Single-char flags that consume the next token as their argument.
-	shortArgFlags := map[byte]bool{
-		'X': true, 'H': true, 'd': true, 'F': true,
-		'u': true, 'o': true, 'A': true, 'e': true,
-		'm': true, 'w': true, 'x': true, 'b': true,
-	}
 	out := make([]string, 0, len(tokens))
 	for _, tok := range tokens {
 		// Only expand tokens of the form -(two or more letters/digits).
-		if len(tok) > 2 && tok[0] == '-' && tok[1] != '-' && tok[1] != '#' {
+		// A digit after the dash is a value such as the suffix range in
+		// "-r -500", not a compound flag.
+		if len(tok) > 2 && tok[0] == '-' && tok[1] != '-' && tok[1] != '#' && (tok[1] < '0' || tok[1] > '9') {
 			chars := tok[1:]
 			for i := 0; i < len(chars); i++ {
 				c := chars[i]
@@ -537,18 +1563,3 @@ func expandShortFlags(tokens []string) []string {
 	}
 	return out
 }
-
-// percentEncode percent-encodes a string per RFC 3986 unreserved characters.
-func percentEncode(s string) string {
-	var buf bytes.Buffer
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
-			c == '-' || c == '_' || c == '.' || c == '~' {
-			buf.WriteByte(c)
-		} else {
-			fmt.Fprintf(&buf, "%%%02X", c)
-		}
-	}
-	return buf.String()
-}
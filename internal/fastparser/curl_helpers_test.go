@@ -2,9 +2,12 @@ package fastparser
 
 // curl_helpers_test.go — targeted unit tests for curl.go helpers that were
 // previously below 90% coverage: expandShortFlags, shellSplit, parseCurlURL,
-// buildMultipartForm, buildURLEncoded, stripNonCurlLines, parseCurlHeader.
+// buildMultipartForm, urlEncodeField, joinDataParts, stripNonCurlLines,
+// parseCurlHeader.
 
 import (
+	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 )
@@ -226,28 +229,46 @@ func TestBuildMultipartForm_FileUploadSkipped(t *testing.T) {
 	}
 }
 
-// ── buildURLEncoded edge cases ─────────────────────────────────────────────
+func TestBuildMultipartForm_BoundaryTriesExhausted(t *testing.T) {
+	// A value holding every boundary the seed yields keeps the last one,
+	// with a warning.
+	rng := rand.New(rand.NewSource(1))
+	var value strings.Builder
+	for i := 0; i < maxBoundaryTries; i++ {
+		fmt.Fprintf(&value, "%016x%016x;", rng.Uint64(), rng.Uint64())
+	}
+	cp := &curlParser{opts: CurlOptions{BoundarySeed: 1}}
+	_, boundary := buildMultipartForm([]string{"a=" + value.String()}, cp)
+	if !strings.Contains(value.String(), boundary) {
+		t.Errorf("boundary %s not among the colliding ones", boundary)
+	}
+	if len(cp.warnings) != 1 || !strings.Contains(cp.warnings[0], "occurs in a -F value") {
+		t.Errorf("warnings = %q", cp.warnings)
+	}
+}
+
+// ── urlEncodeField and joinDataParts edge cases ───────────────────────────
 
-func TestBuildURLEncoded_EmptyName(t *testing.T) {
+func TestURLEncodeField_EmptyName(t *testing.T) {
 	// "=value" format (empty name) → percent-encode the value with no name prefix.
-	got := buildURLEncoded([]string{"=hello world"})
+	got := urlEncodeField("=hello world")
 	if got != "hello%20world" {
-		t.Errorf("buildURLEncoded(=hello world) = %q, want hello%%20world", got)
+		t.Errorf("urlEncodeField(=hello world) = %q, want hello%%20world", got)
 	}
 }
 
-func TestBuildURLEncoded_NoEquals(t *testing.T) {
+func TestURLEncodeField_NoEquals(t *testing.T) {
 	// A field with no "=" is treated as a value-only entry.
-	got := buildURLEncoded([]string{"hello world"})
+	got := urlEncodeField("hello world")
 	if got != "hello%20world" {
-		t.Errorf("buildURLEncoded(hello world) = %q, want hello%%20world", got)
+		t.Errorf("urlEncodeField(hello world) = %q, want hello%%20world", got)
 	}
 }
 
-func TestBuildURLEncoded_Multiple(t *testing.T) {
-	// Multiple fields joined with "&".
-	got := buildURLEncoded([]string{"q=hello world", "lang=en"})
-	if got != "q=hello%20world&lang=en" {
+func TestJoinDataParts_Multiple(t *testing.T) {
+	// Multiple fields joined with "&"; only --data-urlencode parts are encoded.
+	got := joinDataParts([]curlDataPart{{value: "q=hello world", encode: true}, {value: "lang=en"}, {value: "a b"}}, "&")
+	if got != "q=hello%20world&lang=en&a b" {
 		t.Errorf("got %q", got)
 	}
 }
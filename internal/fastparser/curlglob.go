@@ -0,0 +1,184 @@
+package fastparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxGlobRequests is how many requests ParseCurl builds from a
+// globbed URL when CurlOptions.MaxGlobRequests is zero.
+const DefaultMaxGlobRequests = 100
+
+// globPattern is one {set} or [range] of a globbed URL. A set lists its
+// alternatives; a range runs from first to last by step, over numbers
+// padded to width digits or over letters.
+type globPattern struct {
+	set               []string
+	first, last, step int
+	width             int
+	letters           bool
+	literalBefore     string // the URL text between the previous pattern and this one
+}
+
+// size returns how many values the pattern takes.
+func (g *globPattern) size() int {
+	if g.set != nil {
+		return len(g.set)
+	}
+	return (g.last-g.first)/g.step + 1
+}
+
+// value returns the pattern's i-th value.
+func (g *globPattern) value(i int) string {
+	if g.set != nil {
+		return g.set[i]
+	}
+	n := g.first + i*g.step
+	if g.letters {
+		return string(rune(n))
+	}
+	s := strconv.Itoa(n)
+	if len(s) < g.width {
+		s = strings.Repeat("0", g.width-len(s)) + s
+	}
+	return s
+}
+
+// parseURLGlob finds curl's globbing patterns in url: {a,b,c} sets and
+// [1-10], [001-100], [a-z] ranges with an optional :step. A backslash
+// makes any of "{}[]," literal. Text that is not a valid pattern, such as
+// an IPv6 literal "[::1]", an unclosed brace or a "{name}" template
+// placeholder, which curl would send as "name", is kept as it is. It
+// returns the patterns and the literal text after the last one, or no
+// patterns if url has none.
+func parseURLGlob(url string) (patterns []globPattern, tail string) {
+	var lit strings.Builder
+	for i := 0; i < len(url); i++ {
+		c := url[i]
+		switch {
+		case c == '\\' && i+1 < len(url) && strings.IndexByte("{}[],", url[i+1]) >= 0:
+			lit.WriteByte(url[i+1])
+			i++
+			continue
+		case c == '{':
+			if g, n, ok := parseGlobSet(url[i:]); ok {
+				g.literalBefore = lit.String()
+				patterns = append(patterns, g)
+				lit.Reset()
+				i += n - 1
+				continue
+			}
+		case c == '[':
+			if end := strings.IndexByte(url[i:], ']'); end > 0 {
+				if g, ok := parseGlobRange(url[i+1 : i+end]); ok {
+					g.literalBefore = lit.String()
+					patterns = append(patterns, g)
+					lit.Reset()
+					i += end
+					continue
+				}
+			}
+		}
+		lit.WriteByte(c)
+	}
+	return patterns, lit.String()
+}
+
+// parseGlobSet parses the {a,b,c} set at the start of s and returns it
+// and its length in s.
+func parseGlobSet(s string) (globPattern, int, bool) {
+	var (
+		alts []string
+		cur  strings.Builder
+	)
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				cur.WriteByte(s[i])
+			}
+		case ',':
+			alts = append(alts, cur.String())
+			cur.Reset()
+		case '}':
+			if alts == nil {
+				return globPattern{}, 0, false // a {name} placeholder, not a set
+			}
+			return globPattern{set: append(alts, cur.String())}, i + 1, true
+		case '{', '[':
+			return globPattern{}, 0, false // curl does not nest patterns
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	return globPattern{}, 0, false
+}
+
+// parseGlobRange parses the inside of a [first-last:step] range.
+func parseGlobRange(s string) (globPattern, bool) {
+	spec, stepText, hasStep := strings.Cut(s, ":")
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok || first == "" || last == "" {
+		return globPattern{}, false
+	}
+	g := globPattern{step: 1}
+	if hasStep {
+		n, err := strconv.Atoi(stepText)
+		if err != nil || n < 1 {
+			return globPattern{}, false
+		}
+		g.step = n
+	}
+	switch {
+	case len(first) == 1 && len(last) == 1 && isLetter(first[0]) && isLetter(last[0]):
+		if (first[0] >= 'a') != (last[0] >= 'a') {
+			return globPattern{}, false // a range stays within one case
+		}
+		g.first, g.last, g.letters = int(first[0]), int(last[0]), true
+	case strings.Trim(first, "0123456789") == "" && strings.Trim(last, "0123456789") == "" && len(first) <= 9 && len(last) <= 9:
+		g.first, _ = strconv.Atoi(first)
+		g.last, _ = strconv.Atoi(last)
+		if len(first) > 1 && first[0] == '0' {
+			g.width = len(first)
+		}
+	default:
+		return globPattern{}, false
+	}
+	return g, g.first <= g.last
+}
+
+// maxGlobCount is where expandURLGlob stops counting URLs.
+const maxGlobCount = 1 << 40
+
+// expandURLGlob returns the URLs patterns and tail describe, in curl's
+// order, the last pattern varying fastest, at most limit of them, and how
+// many there are in all. Past maxGlobCount the count stops there, with
+// saturated set.
+func expandURLGlob(patterns []globPattern, tail string, limit int) (urls []string, total int, saturated bool) {
+	total = 1
+	for i := range patterns {
+		if n := patterns[i].size(); total > maxGlobCount/n {
+			total, saturated = maxGlobCount, true
+		} else {
+			total *= n
+		}
+	}
+	idx := make([]int, len(patterns))
+	for len(urls) < limit && len(urls) < total {
+		var b strings.Builder
+		for i := range patterns {
+			b.WriteString(patterns[i].literalBefore)
+			b.WriteString(patterns[i].value(idx[i]))
+		}
+		b.WriteString(tail)
+		urls = append(urls, b.String())
+		for i := len(patterns) - 1; i >= 0; i-- {
+			if idx[i]++; idx[i] < patterns[i].size() {
+				break
+			}
+			idx[i] = 0
+		}
+	}
+	return urls, total, saturated
+}
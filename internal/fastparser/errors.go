@@ -0,0 +1,52 @@
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error categories. Parse errors keep their descriptive messages but match
+// one of these under errors.Is.
+var (
+	ErrMalformedStartLine    = errors.New("http: malformed start line")
+	ErrMalformedHeader       = errors.New("http: malformed header")
+	ErrWhitespaceBeforeColon = errors.New("http: whitespace before colon in header name")
+	ErrBareLineEnding        = errors.New("http: line not terminated by CRLF")
+	ErrBodyTruncated         = errors.New("http: body truncated")
+	ErrInvalidChunk          = errors.New("http: invalid chunked encoding")
+	ErrInvalidStatusCode     = errors.New("http: invalid status code")
+	ErrUnsupportedType       = errors.New("http: unsupported type")
+)
+
+// kindError is an error that also matches the category sentinels in kinds.
+type kindError struct {
+	err   error
+	kinds []error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() []error {
+	return append([]error{e.err}, e.kinds...)
+}
+
+// WithKind returns an error with err's message that errors.Is also
+// matches to each of kinds.
+func WithKind(err error, kinds ...error) error {
+	return &kindError{err: err, kinds: kinds}
+}
+
+// failf is errorf for an error of the given kind.
+func (p *Parser) failf(kind error, format string, args ...interface{}) error {
+	return WithKind(p.errorf(format, args...), kind)
+}
+
+// chunkErrorf returns a chunked encoding error, also of kind
+// ErrBodyTruncated if truncated is set.
+func chunkErrorf(truncated bool, format string, args ...interface{}) error {
+	err := fmt.Errorf("http: chunked encoding: "+format, args...)
+	if truncated {
+		return WithKind(err, ErrInvalidChunk, ErrBodyTruncated)
+	}
+	return WithKind(err, ErrInvalidChunk)
+}
@@ -0,0 +1,201 @@
+package fastparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fullStops maps the ideographic and fullwidth full stops, which UTS #46
+// treats as label separators, to ".".
+var fullStops = strings.NewReplacer("\u3002", ".", "\uff0e", ".", "\uff61", ".")
+
+// ToASCIIHost converts host, optionally followed by ":port", to its ASCII
+// wire form: labels lowercased and those with non-ASCII characters
+// Punycode-encoded with the "xn--" prefix (RFC 5890, RFC 3492). The
+// mapping is a subset of UTS #46 lookup processing, nontransitional and
+// without normalization; a non-ASCII label may hold only letters, marks,
+// digits and '-', so emoji are an error, and one evidently not in NFC, as
+// composable reports, is an error too rather than encoded wrongly.
+func ToASCIIHost(host string) (string, error) {
+	ascii, err := toASCIIHost(host)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	return ascii, nil
+}
+
+func toASCIIHost(host string) (string, error) {
+	name, port := host, ""
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && strings.IndexByte(host[i:], ']') < 0 {
+		name, port = host[:i], host[i:]
+	}
+	if strings.HasPrefix(name, "[") {
+		return strings.ToLower(host), nil
+	}
+	if !utf8.ValidString(name) {
+		return "", fmt.Errorf("host %s is not valid UTF-8", quoteInput(host))
+	}
+	name = fullStops.Replace(name)
+
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == "" {
+			if i == len(labels)-1 && i > 0 {
+				continue // the root label of a fully qualified name
+			}
+			return "", fmt.Errorf("host %s has an empty label", quoteInput(host))
+		}
+		ascii, err := toASCIILabel(label)
+		if err != nil {
+			return "", fmt.Errorf("host %s: %v", quoteInput(host), err)
+		}
+		labels[i] = ascii
+	}
+	name = strings.Join(labels, ".")
+	if len(name) > 253 {
+		return "", fmt.Errorf("host %s is longer than 253 bytes once encoded", quoteInput(host))
+	}
+	return name + port, nil
+}
+
+// hasNonASCII reports whether s holds a byte outside ASCII.
+func hasNonASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+func toASCIILabel(label string) (string, error) {
+	if !hasNonASCII(label) {
+		label = strings.ToLower(label)
+	} else {
+		runes := make([]rune, 0, len(label))
+		for _, r := range label {
+			r = unicode.ToLower(r)
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) {
+				return "", fmt.Errorf("character %q not allowed in a hostname", r)
+			}
+			if len(runes) > 0 && composable(runes[len(runes)-1], r) {
+				return "", fmt.Errorf("label %s is not in Unicode NFC: U+%04X follows %q", quoteInput(label), r, runes[len(runes)-1])
+			}
+			runes = append(runes, r)
+		}
+		if runes[0] == '-' || runes[len(runes)-1] == '-' {
+			return "", fmt.Errorf("label %s starts or ends with '-'", quoteInput(label))
+		}
+		label = "xn--" + punycode(runes)
+	}
+	if len(label) > 63 {
+		return "", fmt.Errorf("label %s is longer than 63 bytes once encoded", quoteInput(label))
+	}
+	return label, nil
+}
+
+// composable reports whether r following prev suggests decomposed input
+// that NFC would compose: a combining diacritical mark (U+0300-U+036F)
+// after a Latin, Greek or Cyrillic letter, as in "u\u0308" for "ü", or a
+// Hangul leading consonant jamo followed by a vowel jamo. Without the
+// Unicode composition tables this errs on the side of rejecting a label.
+func composable(prev, r rune) bool {
+	switch {
+	case r >= 0x300 && r <= 0x36f:
+		return unicode.In(prev, unicode.Latin, unicode.Greek, unicode.Cyrillic)
+	case r >= 0x1161 && r <= 0x1175:
+		return prev >= 0x1100 && prev <= 0x1112
+	}
+	return false
+}
+
+// Bootstring parameters for Punycode, RFC 3492 §5.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycode encodes runes as RFC 3492 §6.3 does, without the "xn--"
+// prefix. Labels are at most a few dozen runes, so the arithmetic cannot
+// overflow an int.
+func punycode(runes []rune) string {
+	var b strings.Builder
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			b.WriteByte(byte(r))
+		}
+	}
+	basic := b.Len()
+	if basic > 0 {
+		b.WriteByte('-')
+	}
+	n, delta, bias := punyInitialN, 0, punyInitialBias
+	for h := basic; h < len(runes); {
+		m := int(unicode.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				b.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			b.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return b.String()
+}
+
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > (punyBase-punyTMin)*punyTMax/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
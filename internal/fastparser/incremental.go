@@ -0,0 +1,125 @@
+package fastparser
+
+// IncrementalParser parses a message leniently as its bytes arrive. Each
+// Feed re-parses the buffered input, except that once the start line and
+// header section are complete they are kept: later feeds only extend the
+// body, resuming from the parser state at the body's start, and a chunked
+// body's framing is validated only past the last complete chunk. Bodies
+// alias the internal buffer, which Feed only appends to.
+type IncrementalParser struct {
+	opts LenientOptions
+	buf  []byte
+
+	// mark is the parser state at the start of the body once the head
+	// is stable, and head the result it was taken from.
+	mark   *LenientParser
+	head   *ParseResult
+	chunks chunkProgress
+
+	work int // see Work
+}
+
+// NewIncrementalParser returns an IncrementalParser that parses with opts.
+// BodyNoCopy is always in effect.
+func NewIncrementalParser(opts LenientOptions) *IncrementalParser {
+	opts.BodyNoCopy = true
+	return &IncrementalParser{opts: opts}
+}
+
+// Feed appends data to the input and returns the result of parsing all of
+// it so far, which equals what a LenientParser would return for the same
+// bytes.
+func (ip *IncrementalParser) Feed(data []byte) *ParseResult {
+	ip.buf = append(ip.buf, data...)
+	if ip.mark != nil {
+		ip.work += len(data)
+		return ip.mark.resumeBody(ip.buf, ip.head)
+	}
+
+	ip.work += len(ip.buf)
+	p := NewLenientParserWithOptions(ip.buf, ip.opts)
+	var mark LenientParser
+	var chunks chunkProgress
+	p.bodyMark, p.chunks = &mark, &chunks
+	result := p.Parse()
+	if stableHead(&mark, ip.buf, result) {
+		mark.bodyMark = nil
+		mark.chunks = &ip.chunks
+		ip.mark, ip.head, ip.chunks = &mark, result, chunks
+	}
+	return result
+}
+
+// Work returns the number of bytes handed to full parses, plus those
+// appended while resuming a body, since the parser was created or Reset.
+// Tests use it to check that feeding a message in pieces is not
+// quadratic.
+func (ip *IncrementalParser) Work() int {
+	return ip.work
+}
+
+// Reset discards the input so the parser can be reused for another
+// message.
+func (ip *IncrementalParser) Reset() {
+	*ip = IncrementalParser{opts: ip.opts}
+}
+
+// Buffered returns the input fed since the parser was created or reset.
+func (ip *IncrementalParser) Buffered() []byte {
+	return ip.buf
+}
+
+// stableHead reports whether no further input can change how the head
+// that mark was taken at parses: it ended in a blank line with at least
+// one field before it, so it cannot have been a stray blank line, and
+// with an LF, so it is not a CR whose LF is yet to come; the input was
+// parsed in place rather than rewritten; the message type was not
+// guessed; and the message is not an interim response that a final one
+// may follow.
+func stableHead(mark *LenientParser, buf []byte, result *ParseResult) bool {
+	if mark.data == nil || !mark.headEnded || mark.stats.HeaderCount == 0 ||
+		len(mark.data) != len(buf) || &mark.data[0] != &buf[0] || mark.data[mark.pos-1] != '\n' {
+		return false
+	}
+	if result.Confidence == ConfidenceLow {
+		return false
+	}
+	if resp := result.Response; resp != nil && resp.StatusCode < 200 && resp.StatusCode != 101 {
+		return false
+	}
+	return true
+}
+
+// clone returns a copy of p that shares no mutable state with it.
+func (p *LenientParser) clone() LenientParser {
+	c := *p
+	c.warnings = append([]string(nil), p.warnings...)
+	c.kindOrder = append([]WarningKind(nil), p.kindOrder...)
+	c.invalidNames = append([]InvalidHeaderName(nil), p.invalidNames...)
+	if p.kindCounts != nil {
+		c.kindCounts = make(map[WarningKind]int, len(p.kindCounts))
+		for k, n := range p.kindCounts {
+			c.kindCounts[k] = n
+		}
+	}
+	return c
+}
+
+// resumeBody finishes, over data, the parse that p is the body mark of:
+// data extends the input p was given and head is the result that parse
+// returned.
+func (p *LenientParser) resumeBody(data []byte, head *ParseResult) *ParseResult {
+	q := p.clone()
+	q.data, q.length = data, len(data)
+	result := *head
+	if head.Request != nil {
+		req := *head.Request
+		req.Body, req.RawBody, req.Headers = q.readBody(req.Headers, false)
+		result.Request = &req
+	} else {
+		resp := *head.Response
+		resp.Body, resp.RawBody, resp.Headers = q.readBody(resp.Headers, readsUntilClose(&resp))
+		result.Response = &resp
+	}
+	return q.finish(&result)
+}
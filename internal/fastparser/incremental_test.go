@@ -0,0 +1,41 @@
+package fastparser
+
+import "testing"
+
+// TestIncrementalParser_StableHead checks when Feed stops re-parsing the
+// head.
+func TestIncrementalParser_StableHead(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		stable bool
+	}{
+		{"headers ended", "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 9\r\n\r\nab", true},
+		{"headers open", "POST / HTTP/1.1\r\nHost: a\r\n", false},
+		{"CR awaiting LF", "POST / HTTP/1.1\r\nHost: a\r\n\r", false},
+		{"no fields, blank may be stray", "GET / HTTP/1.1\r\n\r\n", false},
+		{"interim response", "HTTP/1.1 100 Continue\r\nX: 1\r\n\r\n", false},
+		{"final response", "HTTP/1.1 200 OK\r\nServer: x\r\n\r\n", true},
+		{"indented, rewritten", "  GET / HTTP/1.1\r\n  Host: a\r\n\r\n", false},
+	}
+	for _, tt := range tests {
+		ip := NewIncrementalParser(LenientOptions{})
+		ip.Feed([]byte(tt.data))
+		if got := ip.mark != nil; got != tt.stable {
+			t.Errorf("%s: stable head = %v, want %v", tt.name, got, tt.stable)
+		}
+	}
+}
+
+func TestIncrementalParser_ResumesChunks(t *testing.T) {
+	ip := NewIncrementalParser(LenientOptions{})
+	ip.Feed([]byte("POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\n\r\n"))
+	ip.Feed([]byte("3\r\nabc\r\n4\r\nde"))
+	if ip.chunks.pos != 8 || ip.chunks.total != 3 {
+		t.Errorf("chunk progress = %+v, want past the first chunk", ip.chunks)
+	}
+	result := ip.Feed([]byte("fg\r\n0\r\n\r\n"))
+	if string(result.Request.Body) != "abcdefg" || !result.Complete || result.Partial {
+		t.Errorf("Body %q, Complete %v, Partial %v", result.Request.Body, result.Complete, result.Partial)
+	}
+}
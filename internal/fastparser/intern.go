@@ -6,10 +6,56 @@ package fastparser
 // to avoid allocating the temporary string (the mapaccess optimization).
 // This means internMethod(someBytes) is zero-alloc for known methods.
 
-var methods = map[string]string{
-	"GET": "GET", "HEAD": "HEAD", "POST": "POST",
-	"PUT": "PUT", "DELETE": "DELETE", "CONNECT": "CONNECT",
-	"OPTIONS": "OPTIONS", "TRACE": "TRACE", "PATCH": "PATCH",
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// methods holds the interned method table. It is replaced copy-on-write by
+// RegisterMethods so lookups on the hot path need no lock.
+var methods atomic.Pointer[map[string]string]
+
+// methodsMu serializes RegisterMethods writers.
+var methodsMu sync.Mutex
+
+func init() {
+	m := map[string]string{
+		"GET": "GET", "HEAD": "HEAD", "POST": "POST",
+		"PUT": "PUT", "DELETE": "DELETE", "CONNECT": "CONNECT",
+		"OPTIONS": "OPTIONS", "TRACE": "TRACE", "PATCH": "PATCH",
+	}
+	methods.Store(&m)
+}
+
+// RegisterMethods adds extension methods (e.g. WebDAV's PROPFIND) to the
+// intern table so parsing them does not allocate. It is intended for use
+// from init functions; calling it concurrently with parsing is safe but
+// each call copies the table.
+func RegisterMethods(names ...string) {
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+	old := *methods.Load()
+	m := make(map[string]string, len(old)+len(names))
+	for k, v := range old {
+		m[k] = v
+	}
+	for _, name := range names {
+		if name != "" {
+			m[name] = name
+		}
+	}
+	methods.Store(&m)
+}
+
+// SaveMethods returns a function that restores the intern table to its
+// current contents, so that tests registering methods can undo it.
+func SaveMethods() (restore func()) {
+	saved := methods.Load()
+	return func() {
+		methodsMu.Lock()
+		defer methodsMu.Unlock()
+		methods.Store(saved)
+	}
 }
 
 var versions = map[string]string{
@@ -75,31 +121,9 @@ var headerNames = map[string]string{
 	"X-Real-IP":           "X-Real-IP",
 }
 
-var reasons = map[string]string{
-	"OK":                    "OK",
-	"Created":               "Created",
-	"Accepted":              "Accepted",
-	"No Content":            "No Content",
-	"Moved Permanently":     "Moved Permanently",
-	"Found":                 "Found",
-	"Not Modified":          "Not Modified",
-	"Bad Request":           "Bad Request",
-	"Unauthorized":          "Unauthorized",
-	"Forbidden":             "Forbidden",
-	"Not Found":             "Not Found",
-	"Method Not Allowed":    "Method Not Allowed",
-	"Conflict":              "Conflict",
-	"Gone":                  "Gone",
-	"Internal Server Error": "Internal Server Error",
-	"Not Implemented":       "Not Implemented",
-	"Bad Gateway":           "Bad Gateway",
-	"Service Unavailable":   "Service Unavailable",
-	"Gateway Timeout":       "Gateway Timeout",
-}
-
 // internMethod returns an interned string for known HTTP methods, avoiding allocation.
 func internMethod(b []byte) string {
-	if s, ok := methods[string(b)]; ok {
+	if s, ok := (*methods.Load())[string(b)]; ok {
 		return s
 	}
 	return string(b)
@@ -120,11 +144,3 @@ func internHeaderName(b []byte) string {
 	}
 	return string(b)
 }
-
-// internReason returns an interned string for known reason phrases, avoiding allocation.
-func internReason(b []byte) string {
-	if s, ok := reasons[string(b)]; ok {
-		return s
-	}
-	return string(b)
-}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // ParseResult holds the result of lenient parsing.
@@ -13,8 +16,171 @@ type ParseResult struct {
 	Response *Response
 	Warnings []string
 	Partial  bool
+	URL      string // absolute URL; set by ParseCurl only
+
+	// Requests holds a request per URL of a ParseCurl command whose URL
+	// globs, Request first; it is nil otherwise.
+	Requests []*Request
+
+	// Complete is set by the lenient parser when the message's framing is
+	// satisfied, so that more input could not change it.
+	Complete bool
+
+	// Informational holds interim 1xx responses that preceded Response.
+	Informational []*Response
+
+	// Stats describes the bytes of Request or Response as far as they
+	// were read, so it is filled for partial messages too.
+	Stats Stats
+
+	// DetectedAs and Confidence record how the lenient parser classified
+	// the input (KindRequest or KindResponse) and how sure it was.
+	DetectedAs MessageKind
+	Confidence Confidence
+
+	// Verdict classifies a ParseCurl result; it is zero for the lenient
+	// parser.
+	Verdict CurlVerdict
+
+	// ClientHints is set by ParseCurl only.
+	ClientHints ClientHints
+
+	// Observations is set by the lenient parser only.
+	Observations FormatObservations
+
+	// InvalidHeaderNames lists the header names the lenient parser kept
+	// although they are not tokens, in order of appearance.
+	InvalidHeaderNames []InvalidHeaderName
+}
+
+// InvalidHeaderName is a header name outside the RFC 9110 token grammar,
+// such as "Résumé-Token" or "", which the lenient parser kept in Headers
+// unchanged. Sanitized is the name with each run of offending bytes
+// replaced by '-', or dropped at either end, so "X-Custom Header" gives
+// "X-Custom-Header"; it is "" when no token bytes remain.
+type InvalidHeaderName struct {
+	Name      string
+	Sanitized string
+}
+
+// Confidence grades how sure a parser is about its request/response
+// classification. The zero value means no detection took place.
+type Confidence int
+
+// Confidence levels.
+const (
+	ConfidenceLow Confidence = iota + 1
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+// Default warning aggregation limits used when LenientOptions leaves a field zero.
+const (
+	DefaultMaxRepeatedWarnings = 20
+	DefaultMaxWarnings         = 1000
+)
+
+// DefaultMaxFoldedLines is the obs-fold continuation lines joined to one
+// header when LenientOptions.MaxFoldedLines is zero.
+const DefaultMaxFoldedLines = 100
+
+// LenientOptions tunes the lenient parser. Zero values select the defaults.
+type LenientOptions struct {
+	// MaxRepeatedWarnings is how many warnings of one kind are kept before
+	// further occurrences collapse into a single summary entry. Negative
+	// disables aggregation.
+	MaxRepeatedWarnings int
+	// MaxWarnings caps the number of warnings kept. Once reached, a final
+	// "warning limit reached" entry is appended. Negative disables the cap.
+	MaxWarnings int
+	// MaxBodyBytes, when positive, keeps only the first MaxBodyBytes bytes
+	// of the (decoded) body and marks the result partial.
+	MaxBodyBytes int
+	// BodyNoCopy makes the body alias data instead of copying it whenever
+	// the bytes are contiguous in the input. The caller must not modify
+	// data while the result is in use.
+	BodyNoCopy bool
+	// KeepRawBody keeps the wire bytes of a chunked body that decoded
+	// cleanly in RawBody, in full regardless of MaxBodyBytes.
+	KeepRawBody bool
+	// JoinWrappedHeaders appends a colon-less line that looks like the
+	// hard-wrapped rest of the previous header's value to that value.
+	JoinWrappedHeaders bool
+	// MaxHeaderValueBytes, when positive, limits the length of a header
+	// value, wrapped lines joined, other than Content-Length and
+	// Transfer-Encoding; MaxHeaderValueAction says what happens to a
+	// longer one.
+	MaxHeaderValueBytes  int
+	MaxHeaderValueAction HeaderValueAction
+	// MaxFoldedLines is how many obs-fold continuation lines are joined to
+	// one header; further ones are skipped as malformed headers.
+	// Zero means DefaultMaxFoldedLines; negative disables the cap.
+	MaxFoldedLines int
+	// SniffBodies warns when the start of a body evidently holds another
+	// type than its Content-Type declares, per ContentTypeMismatch. Bodies
+	// with a Content-Encoding, or a transfer coding left undecoded, are not
+	// sniffed.
+	SniffBodies bool
+	// ConvertIDNHosts converts a request's Host with non-ASCII characters
+	// to its ASCII form with ToASCIIHost, with a warning, and lowercases
+	// an ASCII one.
+	ConvertIDNHosts bool
+	// DecodeTransferCodings undoes the transfer codings applied before
+	// chunked, each decoding to at most MaxBodyBytes bytes when that is
+	// positive; a coding it cannot undo is warned about.
+	DecodeTransferCodings bool
 }
 
+// HeaderValueAction is what the lenient parser does with a header value
+// over LenientOptions.MaxHeaderValueBytes.
+type HeaderValueAction int
+
+// Header value actions.
+const (
+	TruncateHeaderValue HeaderValueAction = iota // keep the first MaxHeaderValueBytes bytes
+	DropHeader                                   // drop the header
+)
+
+// WarningKind groups warnings for aggregation and for the policies of
+// pkg/http's strictness levels. The value doubles as the label used in the
+// summary entry for suppressed repeats and as the key of WarningCounts.
+type WarningKind string
+
+const (
+	WarnEmptyInput            WarningKind = "empty input"
+	WarnNoStartLine           WarningKind = "no start line"
+	WarnBodyIncomplete        WarningKind = "message body is incomplete"
+	WarnRequestLine           WarningKind = "malformed request line"
+	WarnTargetNormalized      WarningKind = "request-target normalized"
+	WarnStatusLine            WarningKind = "malformed status line"
+	WarnStrayBlankLine        WarningKind = "stray blank line before headers"
+	WarnImplicitHost          WarningKind = "implicit Host header"
+	WarnMalformedHeader       WarningKind = "malformed header (no colon)"
+	WarnWhitespaceBeforeColon WarningKind = "whitespace before colon"
+	WarnChunkedError          WarningKind = "chunked encoding error"
+	WarnContentLengthMismatch WarningKind = "Content-Length mismatch"
+	WarnAmbiguousType         WarningKind = "ambiguous message type"
+	WarnBodyTruncated         WarningKind = "body truncated"
+	WarnInterimOnly           WarningKind = "interim response without final response"
+	WarnByteOrderMark         WarningKind = "byte order mark stripped"
+	WarnIndented              WarningKind = "common indentation stripped"
+	WarnWrappedHeader         WarningKind = "joined wrapped header value"
+	WarnDoubledCR             WarningKind = "doubled carriage returns"
+	WarnDuplicateHost         WarningKind = "conflicting Host headers"
+	WarnTransferCoding        WarningKind = "transfer coding not decoded"
+	WarnStringLiteral         WarningKind = "string literal unescaped"
+	WarnLongHeaderValue       WarningKind = "header value over the size limit"
+	WarnInferredBody          WarningKind = "inferred body start"
+	WarnContentTypeMismatch   WarningKind = "Content-Type does not match body"
+	WarnConnectionHeader      WarningKind = "connection-specific header"
+	WarnLongFold              WarningKind = "too many continuation lines"
+	WarnIDNHost               WarningKind = "internationalized host converted"
+	WarnInvalidHeaderName     WarningKind = "invalid header name"
+	WarnContentLengthWithTE   WarningKind = "Content-Length with Transfer-Encoding"
+	WarnConflictingLength     WarningKind = "conflicting Content-Length headers"
+	WarnInvalidContentLength  WarningKind = "invalid Content-Length"
+)
+
 // LenientParser provides best-effort HTTP message parsing that never fails
 // on malformed input. It extracts what it can and reports issues as warnings.
 type LenientParser struct {
@@ -22,65 +188,466 @@ type LenientParser struct {
 	pos      int
 	length   int
 	line     int
+	partial  bool
 	warnings []string
+
+	maxRepeated int
+	maxWarnings int
+	maxBody     int
+	bodyNoCopy  bool
+	keepRaw     bool
+	joinWrapped bool
+	maxValue    int
+	valueAction HeaderValueAction
+	maxFolded   int
+	sniff       bool
+	convertIDN  bool
+	decodeTE    bool
+
+	interimEnded bool // the last response parsed was interim with another after it
+	headEnded    bool // the last header section parsed ended in a blank line
+	complete     bool // the message's framing is satisfied; see ParseResult.Complete
+	stats        Stats
+	observed     FormatObservations
+	nameCases    caseTally // of the names in the last header section read
+
+	// rewritten is set when a pre-pass such as dedent replaced p.data, so
+	// offsets in it no longer locate bytes of the input. HeaderEndOffset
+	// and BodyOffset, which promise that, are then reported as -1.
+	rewritten    bool
+	kindCounts   map[WarningKind]int
+	kindOrder    []WarningKind       // kinds in order of first occurrence
+	dropped      int                 // warnings dropped by the MaxWarnings cap
+	invalidNames []InvalidHeaderName // see ParseResult.InvalidHeaderNames
+
+	// bodyMark, when non-nil, receives a copy of the parser as it stands
+	// at the start of each body, and chunks, when non-nil, resumes chunk
+	// validation where an earlier parse left off; see IncrementalParser.
+	bodyMark *LenientParser
+	chunks   *chunkProgress
 }
 
 // NewLenientParser creates a new lenient parser for the given data.
 func NewLenientParser(data []byte) *LenientParser {
-	return &LenientParser{
-		data:   data,
-		pos:    0,
-		length: len(data),
-		line:   1,
+	return NewLenientParserWithOptions(data, LenientOptions{})
+}
+
+// NewLenientParserWithOptions creates a new lenient parser for the given data
+// using opts to tune warning aggregation and body handling.
+func NewLenientParserWithOptions(data []byte, opts LenientOptions) *LenientParser {
+	p := &LenientParser{
+		data:        data,
+		pos:         0,
+		length:      len(data),
+		line:        1,
+		maxRepeated: opts.MaxRepeatedWarnings,
+		maxWarnings: opts.MaxWarnings,
+		maxBody:     opts.MaxBodyBytes,
+		bodyNoCopy:  opts.BodyNoCopy,
+		keepRaw:     opts.KeepRawBody,
+		joinWrapped: opts.JoinWrappedHeaders,
+		maxValue:    opts.MaxHeaderValueBytes,
+		valueAction: opts.MaxHeaderValueAction,
+		maxFolded:   opts.MaxFoldedLines,
+		sniff:       opts.SniffBodies,
+		convertIDN:  opts.ConvertIDNHosts,
+		decodeTE:    opts.DecodeTransferCodings,
+	}
+	if p.maxRepeated == 0 {
+		p.maxRepeated = DefaultMaxRepeatedWarnings
 	}
+	if p.maxWarnings == 0 {
+		p.maxWarnings = DefaultMaxWarnings
+	}
+	if p.maxFolded == 0 {
+		p.maxFolded = DefaultMaxFoldedLines
+	}
+	return p
 }
 
 // Parse auto-detects and parses the message with best-effort extraction.
 func (p *LenientParser) Parse() *ParseResult {
 	result := &ParseResult{}
 
+	result.Stats = Stats{HeaderEndOffset: -1, BodyOffset: -1}
 	if p.length == 0 {
-		p.addWarning(1, "empty input")
+		p.addWarning(1, WarnEmptyInput, "empty input")
 		result.Partial = true
+		result.Warnings = p.flushWarnings()
 		return result
 	}
 
+	p.stripBOM()
+	p.unquoteLiteral()
+	p.dedent()
+	p.undoubleCR()
+
 	// RFC 9112 §2.2: skip leading blank lines before the start-line.
 	for p.pos < p.length && (p.data[p.pos] == '\r' || p.data[p.pos] == '\n') {
 		if p.data[p.pos] == '\n' {
 			p.line++
+			p.observed.LeadingBlankLines++
 		}
 		p.pos++
 	}
 
 	if p.pos >= p.length {
-		p.addWarning(1, "empty input")
+		p.addWarning(1, WarnEmptyInput, "empty input")
 		result.Partial = true
-		result.Warnings = p.warnings
+		result.Warnings = p.flushWarnings()
 		return result
 	}
 
-	if bytes.HasPrefix(p.data[p.pos:], []byte("HTTP/")) {
-		resp := p.parseResponseLenient()
-		result.Response = resp
+	result.DetectedAs, result.Confidence = detectStartLine(p.peekLine())
+	switch {
+	case result.Confidence == ConfidenceLow:
+		result.DetectedAs = p.parseAmbiguous(result)
+	case result.DetectedAs == KindResponse:
+		result.Response, result.Informational = p.parseResponsesLenient()
+	default:
+		result.Request = p.parseRequestLenient()
+	}
+
+	return p.finish(result)
+}
+
+// finish fills in the fields of result that describe the whole parse.
+func (p *LenientParser) finish(result *ParseResult) *ParseResult {
+	result.Partial = p.partial
+	result.Complete = p.complete
+	result.Stats = p.stats
+	if p.rewritten {
+		result.Stats.HeaderEndOffset, result.Stats.BodyOffset = -1, -1
+	}
+	if result.Request != nil || result.Response != nil {
+		p.observed.HeaderCase = p.nameCases.guess()
+	}
+	result.Observations = p.observed
+	result.InvalidHeaderNames = p.invalidNames
+	result.Warnings = p.flushWarnings()
+	return result
+}
+
+// stripBOM skips a UTF-8 byte order mark at the start of the input. A
+// UTF-16 mark means the whole message is UTF-16, so the input is transcoded
+// to UTF-8 and offsets in Stats refer to the transcoded bytes.
+func (p *LenientParser) stripBOM() {
+	switch {
+	case bytes.HasPrefix(p.data, []byte("\xef\xbb\xbf")):
+		p.pos = 3
+		p.observed.HadBOM = true
+		p.addWarning(1, WarnByteOrderMark, "UTF-8 byte order mark stripped")
+	case bytes.HasPrefix(p.data, []byte("\xfe\xff")), bytes.HasPrefix(p.data, []byte("\xff\xfe")):
+		bigEndian := p.data[0] == 0xfe
+		units := make([]uint16, 0, (p.length-2)/2)
+		for i := 2; i+1 < p.length; i += 2 {
+			if bigEndian {
+				units = append(units, uint16(p.data[i])<<8|uint16(p.data[i+1]))
+			} else {
+				units = append(units, uint16(p.data[i+1])<<8|uint16(p.data[i]))
+			}
+		}
+		p.data = []byte(string(utf16.Decode(units)))
+		p.length = len(p.data)
+		p.rewritten = true
+		name := "UTF-16LE"
+		if bigEndian {
+			name = "UTF-16BE"
+		}
+		p.observed.HadBOM = true
+		p.addWarning(1, WarnByteOrderMark, name+" byte order mark stripped; input transcoded to UTF-8")
+	}
+}
+
+// dedent strips whitespace that starts every non-blank line, as left by a
+// message pasted from an indented YAML block or a source-code literal. It
+// only does so when the first de-indented line looks like a start line, so
+// a message where just the headers happen to be indented is left alone.
+// Whitespace-only lines become empty. The input is rewritten, so offsets
+// in Stats refer to the de-indented bytes; see rewritten.
+func (p *LenientParser) dedent() {
+	data := p.data[p.pos:]
+	var prefix, first []byte
+	found := false
+	for rest := data; len(rest) > 0; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = nil
+		}
+		content := bytes.TrimLeft(line, " \t")
+		if len(bytes.TrimRight(content, "\r")) == 0 {
+			continue
+		}
+		indent := line[:len(line)-len(content)]
+		if !found {
+			if len(indent) == 0 {
+				return // the common case: nothing to strip
+			}
+			prefix, first, found = indent, bytes.TrimRight(line, "\r"), true
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(indent) && prefix[n] == indent[n] {
+			n++
+		}
+		if prefix = prefix[:n]; n == 0 {
+			return
+		}
+	}
+	if !found {
+		return
+	}
+	if first = first[len(prefix):]; first[0] == ' ' || first[0] == '\t' {
+		return
+	}
+	if _, conf := detectStartLine(first); conf == ConfidenceLow {
+		return
+	}
+
+	out := make([]byte, 0, len(data))
+	lines := 0
+	for rest := data; len(rest) > 0; {
+		line, nl := rest, false
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest, nl = rest[:i], rest[i+1:], true
+		} else {
+			rest = nil
+		}
+		if bytes.HasPrefix(line, prefix) {
+			line = line[len(prefix):]
+			lines++
+		}
+		if len(bytes.TrimLeft(line, " \t\r")) == 0 {
+			line = bytes.TrimLeft(line, " \t")
+		}
+		out = append(out, line...)
+		if nl {
+			out = append(out, '\n')
+		}
+	}
+	p.data, p.length, p.pos = out, len(out), 0
+	p.rewritten = true
+	p.observed.IndentBytes = len(prefix)
+	p.addWarning(0, WarnIndented, fmt.Sprintf("stripped %d bytes of common leading whitespace from %d lines", len(prefix), lines))
+}
+
+// undoubleCR rewrites the doubled carriage returns some Windows terminals
+// paste into the head: a line ending of "\r\r\n" (or any run of CRs
+// before the LF) becomes "\r\n", and a stray "\r" starting the line after
+// a CRLF is dropped. Left alone, the first "\r" reads as a bare-CR line
+// ending and the next as the blank line that ends the headers. Only the
+// head up to its first blank line is rewritten; when it is, offsets in
+// Stats refer to the rewritten bytes; see rewritten.
+func (p *LenientParser) undoubleCR() {
+	data := p.data[p.pos:]
+	var out []byte // nil until the first rewritten line
+	off, prevCRLF := 0, false
+	for off < len(data) {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i < 0 {
+			break
+		}
+		line := data[off : off+i]
+		next := off + i + 1
+		if prevCRLF && len(line) > 1 && line[0] == '\r' && line[1] != '\r' {
+			line = line[1:]
+		}
+		content := bytes.TrimRight(line, "\r")
+		if out == nil && (len(line) < i || len(line)-len(content) > 1) {
+			out = append(make([]byte, 0, len(data)), data[:off]...)
+		}
+		if out != nil {
+			out = append(out, content...)
+			if len(content) < len(line) {
+				out = append(out, '\r')
+			}
+			out = append(out, '\n')
+		}
+		prevCRLF = len(content) < len(line)
+		off = next
+		if len(content) == 0 {
+			break
+		}
+	}
+	if out == nil {
+		return
+	}
+	out = append(out, data[off:]...)
+	p.data, p.length, p.pos = out, len(out), 0
+	p.rewritten = true
+	p.addWarning(0, WarnDoubledCR, "normalized doubled carriage returns")
+}
+
+// detectStartLine classifies a start line. "HTTP/" followed by a
+// three-digit status code, or a known method followed by a plausible
+// request-target, is high confidence. Either shape with a flaw is medium:
+// no status code, a known method with an unusual target (such as the bare
+// "host/path" form the lenient parser normalizes), a lowercase known
+// method, or an unregistered uppercase method with a plausible target and
+// version. Anything else is low.
+func detectStartLine(line []byte) (MessageKind, Confidence) {
+	fields := bytes.Fields(line)
+	if bytes.HasPrefix(line, []byte("HTTP/")) {
+		if len(fields) >= 2 && len(fields[1]) == 3 && isDigits(fields[1]) {
+			return KindResponse, ConfidenceHigh
+		}
+		return KindResponse, ConfidenceMedium
+	}
+	if len(fields) < 2 {
+		return KindRequest, ConfidenceLow
+	}
+	method, plausible := fields[0], plausibleTarget(fields[0], fields[1])
+	if _, known := (*methods.Load())[string(method)]; known {
+		if plausible {
+			return KindRequest, ConfidenceHigh
+		}
+		return KindRequest, ConfidenceMedium
+	}
+	if _, known := (*methods.Load())[strings.ToUpper(string(method))]; known {
+		return KindRequest, ConfidenceMedium
+	}
+	if plausible && isUpperToken(method) && len(fields) >= 3 && bytes.HasPrefix(fields[2], []byte("HTTP/")) {
+		return KindRequest, ConfidenceMedium
+	}
+	return KindRequest, ConfidenceLow
+}
+
+// plausibleTarget reports whether target looks like a request-target for
+// method: origin-form, asterisk-form, absolute-form, or authority-form for
+// CONNECT.
+func plausibleTarget(method, target []byte) bool {
+	switch {
+	case target[0] == '/' || string(target) == "*":
+		return true
+	case hasPrefixFold(target, "http://") || hasPrefixFold(target, "https://"):
+		return true
+	}
+	return eqFold(string(method), MethodConnect) && isAuthorityForm(string(target))
+}
+
+// parseAmbiguous parses low-confidence input both as a request and as a
+// response, keeps whichever interpretation has fewer structural problems
+// (the request on a tie), and records the choice as a warning. Structural
+// problems are the warnings each parse raised plus start-line defects the
+// lenient parsers tolerate silently: a request-line version that is not
+// "HTTP/..." or an implausible target, and a status line that does not
+// start with "HTTP/" or lacks a three-digit code.
+func (p *LenientParser) parseAmbiguous(result *ParseResult) MessageKind {
+	startLine := p.line
+	fields := bytes.Fields(p.peekLine())
+	asReq, asResp := p.clone(), p.clone()
+	req := asReq.parseRequestLenient()
+	resp, interim := asResp.parseResponsesLenient()
+	nReq := asReq.warningCount() + requestLineDefects(fields)
+	nResp := asResp.warningCount() + statusLineDefects(fields)
+
+	kind, chosen, other, nChosen, nOther := KindRequest, "request", "response", nReq, nResp
+	if nResp < nReq {
+		*p = asResp
+		result.Response, result.Informational = resp, interim
+		kind, chosen, other, nChosen, nOther = KindResponse, "response", "request", nResp, nReq
 	} else {
-		req := p.parseRequestLenient()
+		*p = asReq
 		result.Request = req
 	}
+	p.addWarning(startLine, WarnAmbiguousType, fmt.Sprintf(
+		"low-confidence detection: parsed as %s (%d structural problems, %d as %s)", chosen, nChosen, nOther, other))
+	return kind
+}
 
-	result.Warnings = p.warnings
-	return result
+// requestLineDefects counts request-line flaws that raise no warning.
+func requestLineDefects(fields [][]byte) int {
+	n := 0
+	if len(fields) >= 2 && !plausibleTarget(fields[0], fields[1]) {
+		n++
+	}
+	if len(fields) >= 3 && !bytes.HasPrefix(fields[2], []byte("HTTP/")) {
+		n++
+	}
+	return n
+}
+
+// statusLineDefects counts status-line flaws that raise no warning.
+func statusLineDefects(fields [][]byte) int {
+	n := 0
+	if len(fields) == 0 || !bytes.HasPrefix(fields[0], []byte("HTTP/")) {
+		n++
+	}
+	if len(fields) < 2 || len(fields[1]) != 3 || !isDigits(fields[1]) {
+		n++
+	}
+	return n
+}
+
+// warningCount is the number of warnings raised so far, including any
+// suppressed by aggregation.
+func (p *LenientParser) warningCount() int {
+	n := 0
+	for _, c := range p.kindCounts {
+		n += c
+	}
+	return n
+}
+
+// WarningCounts returns how many warnings of each kind Parse raised,
+// including those aggregation suppressed, keyed by the kind's label as
+// used in summary entries, such as "malformed header (no colon)", or nil
+// if there were none.
+func (p *LenientParser) WarningCounts() map[string]int {
+	if len(p.kindCounts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(p.kindCounts))
+	for k, n := range p.kindCounts {
+		counts[string(k)] = n
+	}
+	return counts
+}
+
+// peekLine returns the line at the current position without consuming it.
+func (p *LenientParser) peekLine() []byte {
+	rest := p.data[p.pos:]
+	if i := bytes.IndexAny(rest, "\r\n"); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+func isUpperToken(b []byte) bool {
+	for _, c := range b {
+		if (c < 'A' || c > 'Z') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && eqFold(string(b[:len(prefix)]), prefix)
 }
 
 func (p *LenientParser) parseRequestLenient() *Request {
 	req := &Request{}
+	p.stats = Stats{StartOffset: p.pos, HeaderEndOffset: -1, BodyOffset: -1}
 
 	// Parse request line
 	line := p.readLineLenient()
 	if line == nil {
-		p.addWarning(1, "empty request, no start line found")
+		p.addWarning(1, WarnNoStartLine, "empty request, no start line found")
 		return req
 	}
+	p.stats.StartLineBytes = p.pos - p.stats.StartOffset
 
 	method, path, version := p.parseRequestLineLenient(line)
 
@@ -96,6 +663,14 @@ func (p *LenientParser) parseRequestLenient() *Request {
 
 	// Parse headers
 	req.Headers = p.parseHeadersLenient()
+	p.measureHeaders(len(req.Headers))
+
+	// Keep the first Host header; RFC 9112 §3.2 rejects a request with more
+	// than one.
+	var firstHost, otherHost string
+	if req.Headers, firstHost, otherHost = dedupeHost(req.Headers); otherHost != "" {
+		p.addWarning(0, WarnDuplicateHost, fmt.Sprintf("multiple Host headers %s and %s, kept the first", quoteInput(firstHost), quoteInput(otherHost)))
+	}
 
 	// Inject the host extracted from the request-target if no Host header is
 	// already present. If the user also supplied a bare host:port header line
@@ -103,36 +678,134 @@ func (p *LenientParser) parseRequestLenient() *Request {
 	if impliedHost != "" {
 		hasHost := false
 		for _, h := range req.Headers {
-			if eqFold(h.Key, "Host") {
+			if eqFold(h.Key, HeaderHost) {
 				hasHost = true
 				break
 			}
 		}
 		if !hasHost {
-			req.Headers = append([]Header{{Key: "Host", Value: impliedHost}}, req.Headers...)
+			req.Headers = append([]Header{{Key: HeaderHost, Value: impliedHost}}, req.Headers...)
 		}
 	}
 
-	// Parse body
-	body, partial := p.parseBodyLenient(req.Headers)
-	req.Body = body
-	if partial {
-		// Set partial on the result via a secondary mechanism — caller checks warnings
-		p.addWarning(0, "message body is incomplete")
+	if p.convertIDN {
+		p.convertIDNHost(req.Headers)
 	}
 
+	p.checkConnectionHeaders(req.Version, req.Headers)
+	p.checkFraming(req.Headers)
+
+	req.Body, req.RawBody, req.Headers = p.readBody(req.Headers, false)
 	return req
 }
 
+// convertIDNHost rewrites a Host header with non-ASCII characters in
+// headers to its ASCII form, leaving it as is with a warning when it
+// cannot be converted. An ASCII Host is lowercased, as ToASCIIHost would.
+func (p *LenientParser) convertIDNHost(headers []Header) {
+	for i, h := range headers {
+		if !eqFold(h.Key, HeaderHost) {
+			continue
+		}
+		if !hasNonASCII(h.Value) {
+			headers[i].Value = strings.ToLower(h.Value)
+			continue
+		}
+		ascii, err := toASCIIHost(h.Value)
+		if err != nil {
+			p.addWarning(0, WarnIDNHost, fmt.Sprintf("%v; Host left unconverted", err))
+			continue
+		}
+		p.addWarning(0, WarnIDNHost, fmt.Sprintf("internationalized Host %s converted to %s", quoteInput(h.Value), quoteInput(ascii)))
+		headers[i].Value = ascii
+	}
+}
+
+// checkConnectionHeaders warns about the headers an HTTP/2 or HTTP/3
+// message, such as one copied from browser developer tools, may not carry.
+func (p *LenientParser) checkConnectionHeaders(version string, headers []Header) {
+	for _, w := range ConnectionHeaderWarnings(version, headers) {
+		p.addWarning(0, WarnConnectionHeader, w)
+	}
+}
+
+// checkFraming warns about framing headers that contradict each other or
+// do not parse, the ambiguities request smuggling relies on: a
+// Content-Length that is not a non-negative integer, Content-Length values
+// that differ, and Content-Length sent with Transfer-Encoding (RFC 9112
+// §6.3). The body is still read as parseBodyLenient would: by the chunked
+// coding if there is one, else by the first Content-Length if it is valid.
+func (p *LenientParser) checkFraming(headers []Header) {
+	var length, coding string
+	for _, h := range headers {
+		switch {
+		case eqFold(h.Key, HeaderContentLength):
+			v := strings.TrimSpace(h.Value)
+			if _, err := strconv.ParseUint(v, 10, 63); err != nil {
+				p.addWarning(0, WarnInvalidContentLength, fmt.Sprintf("invalid Content-Length %s", quoteInput(h.Value)))
+				continue
+			}
+			if length == "" {
+				length = v
+			} else if v != length {
+				p.addWarning(0, WarnConflictingLength, fmt.Sprintf("conflicting Content-Length values %s and %s", quoteInput(length), quoteInput(v)))
+			}
+		case eqFold(h.Key, HeaderTransferEncoding) && coding == "":
+			coding = h.Value
+		}
+	}
+	if length == "" || coding == "" {
+		return
+	}
+	msg := fmt.Sprintf("Content-Length %s sent with Transfer-Encoding %s", quoteInput(length), quoteInput(coding))
+	if isChunked(headers) {
+		msg += ", Content-Length ignored"
+	}
+	p.addWarning(0, WarnContentLengthWithTE, msg)
+}
+
+// parseResponsesLenient parses a response and, while it is an interim 1xx
+// response directly followed by another status line, the responses after
+// it. It returns the final response and the interim ones before it.
+func (p *LenientParser) parseResponsesLenient() (*Response, []*Response) {
+	var interim []*Response
+	for {
+		resp := p.parseResponseLenient()
+		if !p.interimEnded {
+			return resp, interim
+		}
+		p.interimEnded = false
+		interim = append(interim, resp)
+	}
+}
+
+// isInterim reports whether resp is an interim response (RFC 9110 §15.2),
+// which never has content. 101 Switching Protocols is final in HTTP/1.1:
+// what follows it is the new protocol.
+func isInterim(resp *Response) bool {
+	if resp.StatusCode < 100 || resp.StatusCode > 199 || resp.StatusCode == 101 {
+		return false
+	}
+	for _, h := range resp.Headers {
+		if eqFold(h.Key, HeaderContentLength) || eqFold(h.Key, HeaderTransferEncoding) {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *LenientParser) parseResponseLenient() *Response {
 	resp := &Response{}
+	startLine := p.line
+	p.stats = Stats{StartOffset: p.pos, HeaderEndOffset: -1, BodyOffset: -1}
 
 	// Parse status line
 	line := p.readLineLenient()
 	if line == nil {
-		p.addWarning(1, "empty response, no start line found")
+		p.addWarning(1, WarnNoStartLine, "empty response, no start line found")
 		return resp
 	}
+	p.stats.StartLineBytes = p.pos - p.stats.StartOffset
 
 	version, statusCode, reason := p.parseStatusLineLenient(line)
 	resp.Version = version
@@ -141,15 +814,127 @@ func (p *LenientParser) parseResponseLenient() *Response {
 
 	// Parse headers
 	resp.Headers = p.parseHeadersLenient()
+	p.measureHeaders(len(resp.Headers))
+
+	// An interim response ends at its header section when the next
+	// response follows.
+	if isInterim(resp) {
+		if bytes.HasPrefix(p.data[p.pos:], []byte("HTTP/")) {
+			p.interimEnded = true
+			return resp
+		}
+		p.addWarning(startLine, WarnInterimOnly, fmt.Sprintf("interim %d response is not followed by a final response", resp.StatusCode))
+	}
 
-	// Parse body
-	body, partial := p.parseBodyLenient(resp.Headers)
-	resp.Body = body
+	p.checkConnectionHeaders(resp.Version, resp.Headers)
+	p.checkFraming(resp.Headers)
+
+	resp.Body, resp.RawBody, resp.Headers = p.readBody(resp.Headers, readsUntilClose(resp))
+	if isInterim(resp) {
+		p.complete = false // a final response is still to come
+	}
+	return resp
+}
+
+// readsUntilClose reports whether resp, lacking framing headers, has a body
+// that runs to the end of the connection (RFC 9112 §6.3): every status but
+// 1xx, 204 and 304.
+func readsUntilClose(resp *Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode != 204 && resp.StatusCode != 304
+}
+
+// readBody parses and measures the body at p.pos and records whether the
+// message is complete. untilClose says an unframed body runs to the end of
+// the connection rather than being empty. out is headers, or a copy with
+// Transfer-Encoding updated for the transfer codings decoded.
+func (p *LenientParser) readBody(headers []Header, untilClose bool) (body, raw []byte, out []Header) {
+	if p.bodyMark != nil {
+		*p.bodyMark = p.clone()
+	}
+	bodyLine := p.line
+	body, raw, partial := p.parseBodyLenient(headers, untilClose)
+	if p.decodeTE && p.complete && isChunked(headers) && len(body) == p.stats.DecodedBodyBytes {
+		body, headers = p.decodeTransferCodings(headers, body)
+	}
+	if p.sniff {
+		p.sniffBody(bodyLine, headers, body)
+	}
+	p.measureBody()
 	if partial {
-		p.addWarning(0, "message body is incomplete")
+		p.partial = true
+		p.addWarning(0, WarnBodyIncomplete, "message body is incomplete")
 	}
+	p.complete = p.complete && p.headEnded && !partial
+	return body, raw, headers
+}
 
-	return resp
+// sniffBody warns if body, starting on line, evidently holds another type
+// than the Content-Type in headers declares. A body still carrying a
+// content coding, or a transfer coding other than chunked, is not sniffed.
+func (p *LenientParser) sniffBody(line int, headers []Header, body []byte) {
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderContentEncoding) && !strings.EqualFold(trimString(h.Value), "identity") {
+			return
+		}
+	}
+	for _, c := range transferCodings(headers) {
+		if !eqFold(c, "chunked") && !eqFold(c, "identity") {
+			return
+		}
+	}
+	declared, sniffed := declaredMediaType(headers), SniffContentType(body)
+	if ContentTypeMismatch(declared, sniffed) {
+		p.addWarning(line, WarnContentTypeMismatch, fmt.Sprintf("Content-Type is %s but the body looks like %s", declared, sniffed))
+	}
+}
+
+// decodeTransferCodings undoes the transfer codings applied before chunked
+// to body, a complete chunked body, as far as it can, warning about those
+// it cannot undo. When any were undone it returns headers with a single
+// Transfer-Encoding listing what remains, chunked included, in place of
+// the first; headers itself is not modified.
+func (p *LenientParser) decodeTransferCodings(headers []Header, body []byte) ([]byte, []Header) {
+	codings := transferCodings(headers)
+	if err := checkChunkedLast(codings); err != nil {
+		p.addWarning(0, WarnTransferCoding, fmt.Sprintf("%v, only chunked was decoded", err))
+		return body, headers
+	}
+	decoded, rest, err := decodeTransferCodings(body, codings[:len(codings)-1], p.maxBody)
+	if err != nil {
+		p.addWarning(0, WarnTransferCoding, fmt.Sprintf("%v, body left with transfer codings %s", err, quoteInput(strings.Join(rest, ", "))))
+	}
+	if len(rest) == len(codings)-1 {
+		return body, headers
+	}
+	value := strings.Join(append(rest, codings[len(codings)-1]), ", ")
+	out := make([]Header, 0, len(headers))
+	replaced := false
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderTransferEncoding) {
+			if !replaced {
+				out = append(out, Header{Key: h.Key, Value: value})
+				replaced = true
+			}
+			continue
+		}
+		out = append(out, h)
+	}
+	p.stats.DecodedBodyBytes = len(decoded)
+	return decoded[:p.bodyLimit(len(decoded))], out
+}
+
+// measureHeaders records the header section that ended at p.pos.
+func (p *LenientParser) measureHeaders(count int) {
+	p.stats.HeaderBytes = p.pos - p.stats.StartOffset - p.stats.StartLineBytes
+	p.stats.HeaderCount = count
+	p.stats.TotalBytes = p.stats.StartLineBytes + p.stats.HeaderBytes
+}
+
+// measureBody records the body that ended at p.pos. parseBodyLenient has
+// already set DecodedBodyBytes.
+func (p *LenientParser) measureBody() {
+	p.stats.BodyBytes = p.pos - p.stats.StartOffset - p.stats.TotalBytes
+	p.stats.TotalBytes += p.stats.BodyBytes
 }
 
 func (p *LenientParser) parseRequestLineLenient(line []byte) (method, path, version string) {
@@ -158,20 +943,59 @@ func (p *LenientParser) parseRequestLineLenient(line []byte) (method, path, vers
 
 	switch len(parts) {
 	case 0:
-		p.addWarning(p.line-1, "empty request line")
+		p.addWarning(p.line-1, WarnRequestLine, "empty request line")
 		return "", "", "HTTP/1.1"
 	case 1:
 		// Just method, no path or version
-		p.addWarning(p.line-1, "request line has only method, no path or version")
+		p.addWarning(p.line-1, WarnRequestLine, "request line has only method, no path or version")
 		return string(parts[0]), "/", "HTTP/1.1"
 	case 2:
 		// Method + path, missing version
-		p.addWarning(p.line-1, "missing HTTP version in request-line, defaulting to HTTP/1.1")
+		p.addWarning(p.line-1, WarnRequestLine, "missing HTTP version in request-line, defaulting to HTTP/1.1")
 		return string(parts[0]), string(parts[1]), "HTTP/1.1"
-	default:
-		// Normal: method path version (extra parts joined into path? No — version is last)
-		return string(parts[0]), string(parts[1]), string(parts[2])
 	}
+	if target, hasVersion, ok := joinSpacedTarget(parts); ok {
+		p.addWarning(p.line-1, WarnTargetNormalized, "request-target contained unencoded spaces, percent-encoded")
+		if !hasVersion {
+			p.addWarning(p.line-1, WarnRequestLine, "missing HTTP version in request-line, defaulting to HTTP/1.1")
+			return string(parts[0]), target, "HTTP/1.1"
+		}
+		return string(parts[0]), target, string(parts[len(parts)-1])
+	}
+	// Normal: method path version; any further fields are ignored.
+	return string(parts[0]), string(parts[1]), string(parts[2])
+}
+
+// joinSpacedTarget recovers a request-target whose spaces were pasted
+// unencoded, as in "GET /search?q=hello world HTTP/1.1", by joining the
+// fields between the method and the version (or the end of the line, if
+// there is no version) with "%20". It is conservative: the target must
+// start like an origin-form or absolute URL, no joined field may look like
+// a version, a line without a version is only repaired when the target
+// has a query, and fields with '=' are only joined onto a target that has
+// a '?' before them.
+func joinSpacedTarget(parts [][]byte) (target string, hasVersion, ok bool) {
+	pieces := parts[1:]
+	if hasVersion = bytes.HasPrefix(parts[len(parts)-1], []byte("HTTP/")); hasVersion {
+		pieces = pieces[:len(pieces)-1]
+	}
+	if len(pieces) < 2 {
+		return "", false, false
+	}
+	first := pieces[0]
+	if first[0] != '/' && !bytes.HasPrefix(first, []byte("http://")) && !bytes.HasPrefix(first, []byte("https://")) {
+		return "", false, false
+	}
+	query := bytes.IndexByte(first, '?') >= 0
+	if !hasVersion && !query {
+		return "", false, false
+	}
+	for _, piece := range pieces[1:] {
+		if bytes.HasPrefix(piece, []byte("HTTP/")) || !query && bytes.IndexByte(piece, '=') >= 0 {
+			return "", false, false
+		}
+	}
+	return string(bytes.Join(pieces, []byte("%20"))), hasVersion, true
 }
 
 // normalizePathLenient inspects the request-target for embedded host
@@ -199,21 +1023,35 @@ func (p *LenientParser) normalizePathLenient(path string) (normalizedPath, impli
 	}
 	if schemeLen > 0 {
 		rest := path[schemeLen:] // "authority/path" or just "authority"
-		slashIdx := strings.IndexByte(rest, '/')
+		end := strings.IndexAny(rest, "/?")
 		var authority, urlPath string
-		if slashIdx < 0 {
+		switch {
+		case end < 0:
 			authority = rest
 			urlPath = "/"
-		} else {
-			authority = rest[:slashIdx]
-			urlPath = rest[slashIdx:]
+		case rest[end] == '?':
+			authority = rest[:end]
+			urlPath = "/" + rest[end:]
+		default:
+			authority = rest[:end]
+			urlPath = rest[end:]
 		}
 		// Strip userinfo (user:pass@host) from the authority if present.
 		if at := strings.LastIndexByte(authority, '@'); at >= 0 {
 			authority = authority[at+1:]
 		}
+		// A target such as "http://a.comhttp://b.com/", two URLs run
+		// together, yields no usable authority; inventing a Host from it
+		// would be worse than keeping the target as sent. Its "a.comhttp:"
+		// is an authority with an empty port, so a path starting "//"
+		// after one gives it away.
+		runTogether := strings.HasSuffix(authority, ":") && strings.HasPrefix(urlPath, "//")
+		if authority != "" && (!isAuthority(authority) || runTogether) {
+			p.addWarning(1, WarnRequestLine, fmt.Sprintf("absolute-form request-target %s has invalid authority %s, left unchanged", quoteInput(RedactUserinfo(path)), quoteInput(authority)))
+			return path, "", ""
+		}
 		if authority != "" {
-			p.addWarning(1, fmt.Sprintf("absolute-form request-target: extracted Host %q, using path %q", authority, urlPath))
+			p.addWarning(1, WarnTargetNormalized, fmt.Sprintf("absolute-form request-target: extracted Host %s, using path %s", quoteInput(authority), quoteInput(urlPath)))
 			return urlPath, authority, scheme
 		}
 		return urlPath, "", scheme
@@ -229,7 +1067,7 @@ func (p *LenientParser) normalizePathLenient(path string) (normalizedPath, impli
 				// Has at least one colon inside brackets — looks like IPv6.
 				if len(rest) > 0 && rest[0] == '/' {
 					// "[::1]/api"
-					p.addWarning(1, fmt.Sprintf("request-target %q contains bare IPv6 host prefix, extracted Host %q, using path %q", path, bracket, rest))
+					p.addWarning(1, WarnTargetNormalized, fmt.Sprintf("request-target %s contains bare IPv6 host prefix, extracted Host %s, using path %s", quoteInput(path), quoteInput(bracket), quoteInput(rest)))
 					return rest, bracket, ""
 				}
 				if len(rest) > 1 && rest[0] == ':' {
@@ -240,7 +1078,7 @@ func (p *LenientParser) normalizePathLenient(path string) (normalizedPath, impli
 						urlPath := rest[1+slashIdx:]     // "/api/users" (includes leading /)
 						if isPortStr(portPart) {
 							authority := bracket + ":" + portPart
-							p.addWarning(1, fmt.Sprintf("request-target %q contains bare IPv6 host prefix, extracted Host %q, using path %q", path, authority, urlPath))
+							p.addWarning(1, WarnTargetNormalized, fmt.Sprintf("request-target %s contains bare IPv6 host prefix, extracted Host %s, using path %s", quoteInput(path), quoteInput(authority), quoteInput(urlPath)))
 							return urlPath, authority, ""
 						}
 					}
@@ -260,7 +1098,7 @@ func (p *LenientParser) normalizePathLenient(path string) (normalizedPath, impli
 				prefix := path[:slashIdx]
 				rest := path[slashIdx:] // includes leading /
 				if isHostnameLike([]byte(prefix)) {
-					p.addWarning(1, fmt.Sprintf("request-target %q contains bare host prefix, extracted Host %q, using path %q", path, prefix, rest))
+					p.addWarning(1, WarnTargetNormalized, fmt.Sprintf("request-target %s contains bare host prefix, extracted Host %s, using path %s", quoteInput(path), quoteInput(prefix), quoteInput(rest)))
 					return rest, prefix, ""
 				}
 			}
@@ -275,17 +1113,17 @@ func (p *LenientParser) parseStatusLineLenient(line []byte) (version string, sta
 
 	switch len(parts) {
 	case 0:
-		p.addWarning(p.line-1, "empty status line")
+		p.addWarning(p.line-1, WarnStatusLine, "empty status line")
 		return "HTTP/1.1", 0, ""
 	case 1:
 		// Just version
-		p.addWarning(p.line-1, "status line has only version, no status code")
+		p.addWarning(p.line-1, WarnStatusLine, "status line has only version, no status code")
 		return string(parts[0]), 0, ""
 	case 2:
 		// Version + status code, no reason
 		code, err := strconv.Atoi(string(parts[1]))
 		if err != nil {
-			p.addWarning(p.line-1, fmt.Sprintf("invalid status code %q, setting to 0", string(parts[1])))
+			p.addWarning(p.line-1, WarnStatusLine, fmt.Sprintf("invalid status code %s, setting to 0", quoteInput(string(parts[1]))))
 			code = 0
 		}
 		return string(parts[0]), code, ""
@@ -293,18 +1131,61 @@ func (p *LenientParser) parseStatusLineLenient(line []byte) (version string, sta
 		// Version + status code + reason (reason may contain spaces)
 		code, err := strconv.Atoi(string(parts[1]))
 		if err != nil {
-			p.addWarning(p.line-1, fmt.Sprintf("invalid status code %q, setting to 0", string(parts[1])))
+			p.addWarning(p.line-1, WarnStatusLine, fmt.Sprintf("invalid status code %s, setting to 0", quoteInput(string(parts[1]))))
 			code = 0
 		}
-		// Reconstruct reason from remaining parts
-		reasonStart := bytes.Index(line, parts[1]) + len(parts[1])
-		reason = string(bytes.TrimSpace(line[reasonStart:]))
+		// The reason is the rest of the line after the status code
+		// field, found by position: the code's digits may also occur in
+		// the version or begin the reason.
+		reason = string(bytes.TrimSpace(line[fieldsEnd(line, 2):]))
 		return string(parts[0]), code, reason
 	}
 }
 
+// fieldsEnd returns the offset in line just past its n-th field, splitting
+// on white space as bytes.Fields does, or len(line) if it has fewer.
+func fieldsEnd(line []byte, n int) int {
+	pos := 0
+	for i := 0; i < n; i++ {
+		start := bytes.IndexFunc(line[pos:], notSpace)
+		if start < 0 {
+			return len(line)
+		}
+		pos += start
+		end := bytes.IndexFunc(line[pos:], unicode.IsSpace)
+		if end < 0 {
+			return len(line)
+		}
+		pos += end
+	}
+	return pos
+}
+
+func notSpace(r rune) bool { return !unicode.IsSpace(r) }
+
+// framingHeader reports whether key is a header that decides body
+// framing, which MaxHeaderValueBytes leaves alone.
+func framingHeader(key string) bool {
+	return strings.EqualFold(key, HeaderContentLength) || strings.EqualFold(key, HeaderTransferEncoding)
+}
+
 func (p *LenientParser) parseHeadersLenient() []Header {
 	var headers []Header
+	p.headEnded = false
+	p.nameCases = caseTally{}
+
+	// For JoinWrappedHeaders: the length of the line that produced the
+	// last header, or 0 if that header may not be extended, and the
+	// longest header line so far, taken as the wrap width.
+	lastLen, width := 0, 0
+	// full is the last header's value before any truncation, which wrapped
+	// lines join onto; dropped is set when that header was dropped over
+	// MaxHeaderValueBytes, so that its wrapped lines are dropped too.
+	full, dropped := "", false
+
+	// overflow is set while skipping past the continuation lines of a
+	// header beyond maxFolded, which are not joined.
+	overflow := false
 
 	for {
 		if p.pos >= p.length {
@@ -315,10 +1196,13 @@ func (p *LenientParser) parseHeadersLenient() []Header {
 		emptyLen := 0
 		if p.data[p.pos] == '\r' && p.pos+1 < p.length && p.data[p.pos+1] == '\n' {
 			emptyLen = 2
+			p.observed.noteEnding(LineEndingCRLF)
 		} else if p.data[p.pos] == '\n' {
 			emptyLen = 1
+			p.observed.noteEnding(LineEndingLF)
 		} else if p.data[p.pos] == '\r' {
 			emptyLen = 1
+			p.observed.noteEnding(LineEndingCR)
 		}
 
 		if emptyLen > 0 {
@@ -329,12 +1213,27 @@ func (p *LenientParser) parseHeadersLenient() []Header {
 			if len(headers) == 0 && looksLikeHeaderField(p.data[p.pos+emptyLen:]) {
 				p.pos += emptyLen
 				p.line++
-				p.addWarning(p.line-1, "skipped stray blank line before headers")
+				p.observed.StrayBlankLines++
+				p.addWarning(p.line-1, WarnStrayBlankLine, "skipped stray blank line before headers")
 				continue
 			}
 			// Normal path: blank line ends the headers section.
+			p.stats.HeaderEndOffset = p.pos
 			p.pos += emptyLen
+			p.stats.BodyOffset = p.pos
 			p.line++
+			p.headEnded = true
+			return headers
+		}
+
+		// A body pasted without the blank line before it, recognized by its
+		// shape agreeing with the Content-Type already seen.
+		if bodyShaped(headers, p.data[p.pos:]) {
+			if !isChunked(headers) && !curlHeadersHas(headers, HeaderContentLength) {
+				headers = append(headers, Header{Key: HeaderContentLength, Value: strconv.Itoa(p.length - p.pos)})
+			}
+			p.stats.HeaderEndOffset, p.stats.BodyOffset = p.pos, p.pos
+			p.addWarning(p.line, WarnInferredBody, "missing blank line before body, inferred body start")
 			return headers
 		}
 
@@ -342,15 +1241,36 @@ func (p *LenientParser) parseHeadersLenient() []Header {
 		if line == nil {
 			return headers
 		}
+		prevLen := lastLen
+		lastLen = 0
+		if len(line) > width {
+			width = len(line)
+		}
 
 		// Handle obs-fold (continuation lines)
-		for p.pos < p.length && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
-			cont := p.readLineLenient()
-			if cont == nil {
-				break
+		if overflow && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			// Past the fold cap a continuation line is dropped, never read
+			// as a header of its own, so it cannot smuggle one in.
+			if p.admit(WarnMalformedHeader) {
+				p.record(p.line-1, fmt.Sprintf("continuation line over the fold limit, skipped: %s", excerpt(RedactHeaderLine(string(bytes.TrimLeft(line, " \t"))))))
+			}
+			continue
+		} else {
+			overflow = false
+			for folded := 0; p.pos < p.length && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t'); folded++ {
+				if p.maxFolded > 0 && folded == p.maxFolded {
+					overflow = true
+					p.warnLongFold(line)
+					break
+				}
+				cont := p.readLineLenient()
+				if cont == nil {
+					break
+				}
+				p.observed.ObsFoldCount++
+				line = append(line, ' ')
+				line = append(line, bytes.TrimLeft(cont, " \t")...)
 			}
-			line = append(line, ' ')
-			line = append(line, bytes.TrimLeft(cont, " \t")...)
 		}
 
 		// Parse "Key: Value" — lenient: accept whitespace before colon.
@@ -359,34 +1279,73 @@ func (p *LenientParser) parseHeadersLenient() []Header {
 		// which confuses the normal colon-splitting logic. Handle them first.
 		if len(line) > 0 && line[0] == '[' {
 			if h := parseIPv6HostLine(line); h != "" {
-				p.addWarning(p.line-1, fmt.Sprintf("bare IPv6 address %q treated as implicit Host header", h))
-				headers = append(headers, Header{Key: "Host", Value: h})
+				if p.admit(WarnImplicitHost) {
+					p.record(p.line-1, fmt.Sprintf("bare IPv6 address %s treated as implicit Host header", quoteInput(h)))
+				}
+				headers = append(headers, Header{Key: HeaderHost, Value: h})
 			} else {
-				p.addWarning(p.line-1, fmt.Sprintf("malformed header (no colon), skipped: %s", string(line)))
+				if p.admit(WarnMalformedHeader) {
+					p.record(p.line-1, fmt.Sprintf("malformed header (no colon), skipped: %s", excerpt(RedactHeaderLine(string(line)))))
+				}
 			}
 			continue
 		}
 
 		colon := bytes.IndexByte(line, ':')
 		if colon < 0 {
-			// No colon — check if the line looks like a bare hostname/host:port.
-			// A common editor pattern is to write the host on its own line without
-			// the "Host:" prefix (e.g. "example.com" or "api.example.com:8080").
-			if isHostnameLike(line) {
-				p.addWarning(p.line-1, fmt.Sprintf("bare hostname %q treated as implicit Host header", string(line)))
-				headers = append(headers, Header{Key: "Host", Value: string(bytes.TrimSpace(line))})
+			// No colon — the rest of a hard-wrapped value, when joining them,
+			// which goes first since a wrapped token such as a JWT may look
+			// like a hostname; else check if the line looks like a bare
+			// hostname/host:port. A common editor pattern is to write the host
+			// on its own line without the "Host:" prefix (e.g. "example.com"
+			// or "api.example.com:8080").
+			if p.joinWrapped && prevLen > 0 && looksWrapped(line, prevLen, width) {
+				lastLen = len(line)
+				if !dropped {
+					p.joinWrappedLine(&headers, &full, &dropped, line)
+				}
+			} else if isHostnameLike(line) {
+				if p.admit(WarnImplicitHost) {
+					p.record(p.line-1, fmt.Sprintf("bare hostname %s treated as implicit Host header", quoteInput(string(line))))
+				}
+				headers = append(headers, Header{Key: HeaderHost, Value: string(bytes.TrimSpace(line))})
 			} else {
-				p.addWarning(p.line-1, fmt.Sprintf("malformed header (no colon), skipped: %s", string(line)))
+				if p.admit(WarnMalformedHeader) {
+					p.record(p.line-1, fmt.Sprintf("malformed header (no colon), skipped: %s", excerpt(RedactHeaderLine(string(line)))))
+				}
 			}
 			continue
 		}
 
 		key := string(bytes.TrimRight(line[:colon], " \t"))
 		if key != string(line[:colon]) {
-			p.addWarning(p.line-1, fmt.Sprintf("whitespace before colon in header name %q, accepted leniently", string(line[:colon])))
+			p.observed.WhitespaceBeforeColonCount++
+			if p.admit(WarnWhitespaceBeforeColon) {
+				p.record(p.line-1, fmt.Sprintf("whitespace before colon in header name %s, accepted leniently", quoteInput(string(line[:colon]))))
+			}
 		}
 
-		value := string(trimOWSBytes(line[colon+1:]))
+		raw := trimOWSBytes(line[colon+1:])
+		if len(raw) > p.stats.LargestHeaderBytes || p.stats.LargestHeader == "" {
+			p.stats.LargestHeader, p.stats.LargestHeaderBytes = key, len(raw)
+		}
+		value := string(raw)
+		if p.overLimit(key, len(value)) {
+			if p.valueAction == DropHeader {
+				p.nameCases.note(key)
+				if p.admit(WarnLongHeaderValue) {
+					p.record(p.line-1, fmt.Sprintf("header %s value is %d bytes, over the limit of %d; dropped", quoteInput(key), len(raw), p.maxValue))
+				}
+				lastLen, dropped = len(line), true
+				continue
+			}
+			if p.admit(WarnLongHeaderValue) {
+				p.record(p.line-1, fmt.Sprintf("header %s value is %d bytes, over the limit of %d; truncated", quoteInput(key), len(raw), p.maxValue))
+			}
+			full, value = value, truncateValue(value, p.maxValue)
+		} else {
+			full = value
+		}
 
 		// CR-3: a bare "host:port" line split on its colon.
 		//
@@ -399,52 +1358,318 @@ func (p *LenientParser) parseHeadersLenient() []Header {
 		//      hyphens (Content-Type) or start with an uppercase letter (Accept).
 		if (isHostnameKeyStr(key) || isSingleLabelHost(key)) && isPortStr(value) {
 			hostPort := key + ":" + value
-			p.addWarning(p.line-1, fmt.Sprintf("bare host:port %q treated as implicit Host header", hostPort))
-			headers = append(headers, Header{Key: "Host", Value: hostPort})
+			if p.admit(WarnImplicitHost) {
+				p.record(p.line-1, fmt.Sprintf("bare host:port %s treated as implicit Host header", quoteInput(hostPort)))
+			}
+			headers = append(headers, Header{Key: HeaderHost, Value: hostPort})
 			continue
 		}
 
+		p.checkHeaderName(key)
+		p.nameCases.note(key)
 		headers = append(headers, Header{Key: key, Value: value})
+		if !strings.EqualFold(key, HeaderHost) {
+			lastLen, dropped = len(line), false
+		}
+	}
+}
+
+// joinWrappedLine joins line, the rest of a hard-wrapped value, onto the
+// last of headers, whose value before truncation is full. The joined
+// value is held to MaxHeaderValueBytes as a value read whole would be:
+// truncated, or dropped with the header, setting dropped.
+func (p *LenientParser) joinWrappedLine(headers *[]Header, full *string, dropped *bool, line []byte) {
+	last := &(*headers)[len(*headers)-1]
+	before := len(*full)
+	if strings.HasSuffix(*full, ";") || strings.HasSuffix(*full, ",") {
+		*full += " "
 	}
+	*full += string(bytes.TrimSpace(line))
+	if p.admit(WarnWrappedHeader) {
+		p.record(p.line-1, fmt.Sprintf("joined wrapped header value onto %s", quoteInput(last.Key)))
+	}
+	if !p.overLimit(last.Key, len(*full)) {
+		last.Value = *full
+		return
+	}
+	if p.valueAction == DropHeader {
+		if p.admit(WarnLongHeaderValue) {
+			p.record(p.line-1, fmt.Sprintf("header %s value is %d bytes once joined, over the limit of %d; dropped", quoteInput(last.Key), len(*full), p.maxValue))
+		}
+		*headers, *dropped = (*headers)[:len(*headers)-1], true
+		return
+	}
+	if before <= p.maxValue && p.admit(WarnLongHeaderValue) {
+		p.record(p.line-1, fmt.Sprintf("header %s value is %d bytes once joined, over the limit of %d; truncated", quoteInput(last.Key), len(*full), p.maxValue))
+	}
+	last.Value = truncateValue(*full, p.maxValue)
 }
 
-func (p *LenientParser) parseBodyLenient(headers []Header) (body []byte, partial bool) {
+// overLimit reports whether an n-byte value of header key is over
+// MaxHeaderValueBytes, which never applies to framing headers.
+func (p *LenientParser) overLimit(key string, n int) bool {
+	return p.maxValue > 0 && n > p.maxValue && !framingHeader(key)
+}
+
+// truncateValue keeps the first limit bytes of value and notes how many
+// were cut.
+func truncateValue(value string, limit int) string {
+	return value[:limit] + fmt.Sprintf("...[truncated %d bytes]", len(value)-limit)
+}
+
+// checkHeaderName records key, and warns, if it is not a token. The key
+// stays as it is in Headers. The warning quotes the name unless it starts
+// with a sensitive one, such as "Authorization Bearer abc", whose
+// remainder is redacted.
+func (p *LenientParser) checkHeaderName(key string) {
+	var sanitized strings.Builder
+	valid := key != ""
+	pending := false // a run of offending bytes awaits its '-'
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !isTchar(c) {
+			valid, pending = false, true
+			continue
+		}
+		if pending && sanitized.Len() > 0 && c != '-' && !strings.HasSuffix(sanitized.String(), "-") {
+			sanitized.WriteByte('-')
+		}
+		pending = false
+		sanitized.WriteByte(c)
+	}
+	if valid {
+		return
+	}
+	p.invalidNames = append(p.invalidNames, InvalidHeaderName{Name: key, Sanitized: sanitized.String()})
+	if !p.admit(WarnInvalidHeaderName) {
+		return
+	}
+	if key == "" {
+		p.record(p.line-1, "empty header name, kept as is")
+		return
+	}
+	shown := escapeNonToken(key)
+	if redacted := RedactHeaderLine(key); redacted != key {
+		shown = quoteInput(redacted)
+	}
+	p.record(p.line-1, fmt.Sprintf("header name %s has bytes outside the token grammar, kept as is", shown))
+}
+
+// escapeNonToken quotes name with each byte that is not a tchar written
+// as \xNN, cut to about maxExcerpt bytes.
+func escapeNonToken(name string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(name); i++ {
+		if b.Len() > maxExcerpt {
+			b.WriteString("...")
+			break
+		}
+		if c := name[i]; isTchar(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "\\x%02x", c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// bodyShaped reports whether rest, the input at the start of a header
+// line, is evidently a body given the Content-Type in headers: a JSON
+// object or array, XML or HTML markup, or a name=value[&name=value...]
+// form line.
+func bodyShaped(headers []Header, rest []byte) bool {
+	if len(rest) == 0 {
+		return false
+	}
+	mediaType := declaredMediaType(headers)
+	switch rest[0] {
+	case '{', '[':
+		return isJSONMediaType(mediaType)
+	case '<':
+		return isXMLMediaType(mediaType) || mediaType == "text/html"
+	}
+	if mediaType != "application/x-www-form-urlencoded" {
+		return false
+	}
+	line := rest
+	if i := bytes.IndexAny(line, "\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	for _, pair := range bytes.Split(line, []byte("&")) {
+		name, _, ok := bytes.Cut(pair, []byte("="))
+		if !ok || len(name) == 0 || bytes.ContainsAny(pair, " \t:") {
+			return false
+		}
+	}
+	return true
+}
+
+// warnLongFold warns that the header on line has more continuation lines
+// than maxFolded, counting those left at p.pos.
+func (p *LenientParser) warnLongFold(line []byte) {
+	n := p.maxFolded
+	for at := p.pos; at < p.length && (p.data[at] == ' ' || p.data[at] == '\t'); n++ {
+		i := bytes.IndexByte(p.data[at:], '\n')
+		if i < 0 {
+			n++
+			break
+		}
+		at += i + 1
+	}
+	name, _, _ := bytes.Cut(line, []byte(":"))
+	p.addWarning(p.line-1-p.maxFolded, WarnLongFold, fmt.Sprintf("header %s has %d continuation lines, over the limit of %d; the rest are skipped",
+		quoteInput(string(bytes.TrimSpace(name))), n, p.maxFolded))
+}
+
+// declaredMediaType returns the media type of the first Content-Type in
+// headers, lowercased and without parameters, or "".
+func declaredMediaType(headers []Header) string {
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderContentType) {
+			mediaType, _, _ := strings.Cut(h.Value, ";")
+			return strings.ToLower(trimString(mediaType))
+		}
+	}
+	return ""
+}
+
+// looksWrapped reports whether line, which has no colon, is plausibly the
+// hard-wrapped continuation of a header line of prevLen bytes in a header
+// block whose longest line is width bytes. Either line consists solely of
+// token and base64 characters, as a wrapped credential does, or the
+// previous line was cut at the wrap width (at least 40 bytes) and line is
+// no longer. Lines that read as prose never qualify.
+func looksWrapped(line []byte, prevLen, width int) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return false
+	}
+	words := 0
+	for _, f := range strings.Fields(string(line)) {
+		if isWord(strings.TrimRight(f, ".,;!?")) {
+			words++
+		}
+	}
+	if words >= 3 {
+		return false
+	}
+	tokenOnly := true
+	for _, c := range line {
+		if !isTchar(c) && c != '/' && c != '=' {
+			tokenOnly = false
+			break
+		}
+	}
+	return tokenOnly || prevLen >= 40 && prevLen >= width && len(line) <= prevLen
+}
+
+// parseBodyLenient reads the rest of the input as the body. raw is the
+// chunked wire form of a body that decoded, when KeepRawBody is set. It
+// sets p.complete when the body's framing is satisfied: a chunked body
+// through the end of its trailer section, Content-Length bytes, or, for an
+// unframed body, always unless untilClose.
+func (p *LenientParser) parseBodyLenient(headers []Header, untilClose bool) (body, raw []byte, partial bool) {
+	chunked, cl := isChunked(headers), getContentLength(headers)
 	if p.pos >= p.length {
-		return nil, false
+		p.complete = !chunked && (cl == 0 || cl < 0 && !untilClose)
+		return nil, nil, false
 	}
+	wire := p.data[p.pos:]
+	p.pos = p.length
+	p.stats.DecodedBodyBytes = len(wire)
 
-	// Check for chunked
-	if isChunked(headers) {
-		decoded, err := Dechunk(p.data[p.pos:])
+	// Check for chunked. The framing is validated before anything is
+	// copied, so a broken body costs no decode buffer.
+	if chunked {
+		size, end, err := walkChunksFrom(wire, p.chunks, nil)
 		if err != nil {
-			// Partial chunked decode — return what we can
-			p.addWarning(0, fmt.Sprintf("chunked encoding error: %v, returning available data", err))
-			// Try to extract whatever we got before the error
-			remaining := p.data[p.pos:]
-			return remaining, true
+			// Partial chunked decode — return the raw bytes
+			p.addWarning(0, WarnChunkedError, fmt.Sprintf("chunked encoding error: %v, returning available data", err))
+			return p.keepBody(wire), nil, true
+		}
+		p.stats.DecodedBodyBytes = size
+		p.complete = trailersEnded(wire, end)
+		if p.keepRaw {
+			raw = wire
+			if !p.bodyNoCopy {
+				raw = append([]byte(nil), wire...)
+			}
 		}
-		return decoded, false
+		return p.decodeChunks(wire, size), raw, false
 	}
 
 	// Read all available body bytes — Content-Length is treated as advisory
 	// in lenient mode. A wrong Content-Length is a formatting inconsistency;
 	// the body data that follows is still valid and must not be discarded.
-	available := p.length - p.pos
-	body = make([]byte, available)
-	copy(body, p.data[p.pos:])
-	p.pos = p.length
+	available := len(wire)
+	body = p.keepBody(wire)
 
-	cl := getContentLength(headers)
+	p.complete = cl >= 0 && int64(available) >= cl || cl < 0 && !untilClose
 	if cl >= 0 && int64(available) != cl {
-		p.addWarning(0, fmt.Sprintf("Content-Length declared %d, actual body is %d bytes", cl, available))
+		p.addWarning(0, WarnContentLengthMismatch, fmt.Sprintf("Content-Length declared %d, actual body is %d bytes", cl, available))
 		// If actual is less than declared the message may have been truncated
 		// in transit; signal that to the caller.
 		if int64(available) < cl {
-			return body, true
+			return body, nil, true
 		}
 	}
 
-	return body, false
+	return body, nil, false
+}
+
+// bodyLimit returns how many of size body bytes to keep, warning and
+// marking the result partial when MaxBodyBytes cuts the body short.
+func (p *LenientParser) bodyLimit(size int) int {
+	if p.maxBody <= 0 || size <= p.maxBody {
+		return size
+	}
+	p.partial = true
+	p.addWarning(0, WarnBodyTruncated, fmt.Sprintf("body truncated from %d to %d bytes by MaxBodyBytes", size, p.maxBody))
+	return p.maxBody
+}
+
+// keepBody returns the retained prefix of raw, copied unless BodyNoCopy.
+func (p *LenientParser) keepBody(raw []byte) []byte {
+	raw = raw[:p.bodyLimit(len(raw))]
+	if p.bodyNoCopy {
+		return raw
+	}
+	body := make([]byte, len(raw))
+	copy(body, raw)
+	return body
+}
+
+// decodeChunks decodes validated chunked data holding size payload bytes
+// into a single buffer of the retained length. With BodyNoCopy, a payload
+// that arrived in one chunk aliases the input.
+func (p *LenientParser) decodeChunks(raw []byte, size int) []byte {
+	if size == 0 {
+		return nil
+	}
+	n := p.bodyLimit(size)
+	if p.bodyNoCopy {
+		var first []byte
+		chunks := 0
+		walkChunks(raw, func(chunk []byte) {
+			if chunks == 0 {
+				first = chunk
+			}
+			chunks++
+		})
+		if chunks == 1 || len(first) >= n {
+			return first[:n]
+		}
+	}
+	body := make([]byte, 0, n)
+	walkChunks(raw, func(chunk []byte) {
+		if room := n - len(body); room < len(chunk) {
+			chunk = chunk[:room]
+		}
+		body = append(body, chunk...)
+	})
+	return body
 }
 
 func (p *LenientParser) readLineLenient() []byte {
@@ -458,12 +1683,14 @@ func (p *LenientParser) readLineLenient() []byte {
 			line := p.data[start:p.pos]
 			p.pos += 2
 			p.line++
+			p.observed.noteEnding(LineEndingCRLF)
 			return line
 		}
 		if p.data[p.pos] == '\n' {
 			line := p.data[start:p.pos]
 			p.pos++
 			p.line++
+			p.observed.noteEnding(LineEndingLF)
 			return line
 		}
 		if p.data[p.pos] == '\r' {
@@ -471,6 +1698,7 @@ func (p *LenientParser) readLineLenient() []byte {
 			line := p.data[start:p.pos]
 			p.pos++
 			p.line++
+			p.observed.noteEnding(LineEndingCR)
 			return line
 		}
 		p.pos++
@@ -481,7 +1709,39 @@ func (p *LenientParser) readLineLenient() []byte {
 	return line
 }
 
-func (p *LenientParser) addWarning(line int, msg string) {
+// addWarning records a warning of the given kind, subject to aggregation.
+func (p *LenientParser) addWarning(line int, kind WarningKind, msg string) {
+	if p.admit(kind) {
+		p.record(line, msg)
+	}
+}
+
+// admit counts an occurrence of kind and reports whether it should be
+// recorded. Once a kind has been seen more than maxRepeated times, or once
+// maxWarnings entries are stored, occurrences are only counted so that
+// flushWarnings can summarize them. Per-line call sites check admit before
+// formatting their message so suppressed warnings cost no allocation.
+func (p *LenientParser) admit(kind WarningKind) bool {
+	if p.kindCounts == nil {
+		p.kindCounts = make(map[WarningKind]int)
+	}
+	n := p.kindCounts[kind] + 1
+	p.kindCounts[kind] = n
+	if n == 1 {
+		p.kindOrder = append(p.kindOrder, kind)
+	}
+	if p.maxRepeated > 0 && n > p.maxRepeated {
+		return false
+	}
+	if p.maxWarnings > 0 && len(p.warnings) >= p.maxWarnings {
+		p.dropped++
+		return false
+	}
+	return true
+}
+
+// record appends msg to the warnings, prefixed with the line number if known.
+func (p *LenientParser) record(line int, msg string) {
 	if line > 0 {
 		p.warnings = append(p.warnings, fmt.Sprintf("line %d: %s", line, msg))
 	} else {
@@ -489,6 +1749,54 @@ func (p *LenientParser) addWarning(line int, msg string) {
 	}
 }
 
+// flushWarnings appends summary entries for suppressed repeats and the
+// warning cap, then returns the final warnings slice.
+func (p *LenientParser) flushWarnings() []string {
+	if p.maxRepeated > 0 {
+		for _, kind := range p.kindOrder {
+			extra := p.kindCounts[kind] - p.maxRepeated
+			if extra <= 0 {
+				continue
+			}
+			if p.maxWarnings > 0 && len(p.warnings) >= p.maxWarnings {
+				p.dropped += extra
+				continue
+			}
+			p.warnings = append(p.warnings, fmt.Sprintf("%s: %s further occurrences suppressed", kind, formatCount(extra)))
+		}
+	}
+	if p.dropped > 0 {
+		p.warnings = append(p.warnings, fmt.Sprintf("warning limit reached: %s further warnings suppressed", formatCount(p.dropped)))
+	}
+	return p.warnings
+}
+
+// formatCount formats n with comma thousands separators (e.g. 9981 → "9,981").
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	lead := len(s) % 3
+	if lead > 0 {
+		b.WriteString(s[:lead])
+	}
+	for i := lead; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+// isTchar reports whether c may appear in an RFC 9110 token.
+func isTchar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
 // trimOWSBytes trims SP/HTAB from both ends.
 func trimOWSBytes(b []byte) []byte {
 	for len(b) > 0 && (b[0] == ' ' || b[0] == '\t') {
@@ -611,6 +1919,50 @@ func isPortStr(s string) bool {
 	return true
 }
 
+// isAuthority reports whether s is a host with an optional port, as in
+// RFC 3986 §3.2.2: a bracketed IPv6 address, or a name or IPv4 address of
+// letters, digits, '-', '.', '_' and '~', followed by ":" and the digits
+// of a port, if any; the port may be empty, as in "example.com:", which
+// RFC 3986 allows. Non-ASCII bytes are allowed in a name, for an
+// internationalized one as in RFC 3987.
+func isAuthority(s string) bool {
+	host := s
+	if s != "" && s[0] == '[' {
+		close := strings.IndexByte(s, ']')
+		if close < 0 {
+			return false
+		}
+		host = s[1:close]
+		if strings.IndexByte(host, ':') < 0 {
+			return false
+		}
+		for i := 0; i < len(host); i++ {
+			c := host[i]
+			if !isHexDigit(c) && c != ':' && c != '.' {
+				return false
+			}
+		}
+		rest := s[close+1:]
+		return rest == "" || rest[0] == ':' && (rest == ":" || isPortStr(rest[1:]))
+	}
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		if port := s[i+1:]; port != "" && !isPortStr(port) {
+			return false
+		}
+		host = s[:i]
+	}
+	if host == "" {
+		return false
+	}
+	for i := 0; i < len(host); i++ {
+		c := host[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' || c >= utf8.RuneSelf) {
+			return false
+		}
+	}
+	return true
+}
+
 // isHostnameLike reports whether b looks like a bare hostname or host:port,
 // e.g. "example.com", "api.example.com:8080", "192.168.1.1", "localhost:8080".
 //
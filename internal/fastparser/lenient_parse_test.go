@@ -6,6 +6,7 @@ package fastparser
 //   - Parse(): pos >= length after skipping leading blank lines
 //   - normalizePathLenient(): userinfo (user:pass@) stripping in absolute URL
 //   - normalizePathLenient(): authority == "" (e.g. "https:///path")
+//   - normalizePathLenient(): double URLs and invalid authorities
 //   - parseHeadersLenient(): pos >= length without finding empty line
 //   - parseHeadersLenient(): bare non-hostname line with no colon → malformed
 //   - isHostnameLike(): port contains non-digit char (allDigits = false, break)
@@ -89,6 +90,54 @@ func TestLenientParse_AbsoluteURLNoAuthority(t *testing.T) {
 	}
 }
 
+// ── normalizePathLenient(): double URLs and invalid authorities ───────────
+
+func TestLenientParse_AbsoluteURLAuthority(t *testing.T) {
+	tests := []struct {
+		target, path, host, scheme string
+		rejected                   bool
+	}{
+		// A URL in the query belongs to the path.
+		{"http://proxy.internal/forward?target=http://real.example.com/api",
+			"/forward?target=http://real.example.com/api", "proxy.internal", "http", false},
+		// Two URLs run together: "a.comhttp:" is no authority.
+		{"http://a.comhttp://b.com/", "http://a.comhttp://b.com/", "", "", true},
+		{"http://a.com:http://b.com/", "http://a.com:http://b.com/", "", "", true},
+		{"http://exa$mple.com/x", "http://exa$mple.com/x", "", "", true},
+		{"http://[::1/x", "http://[::1/x", "", "", true},
+		// Valid absolute-form targets normalize as before.
+		{"http://example.com:8080/api", "/api", "example.com:8080", "http", false},
+		{"https://example.com", "/", "example.com", "https", false},
+		{"https://[::1]:8443/x", "/x", "[::1]:8443", "https", false},
+		{"http://my_host.local/x", "/x", "my_host.local", "http", false},
+		{"http://example.com?q=1", "/?q=1", "example.com", "http", false},
+		// An empty port is allowed (RFC 3986 §3.2.3).
+		{"http://a.com:/x", "/x", "a.com:", "http", false},
+		{"http://[::1]:/x", "/x", "[::1]:", "http", false},
+	}
+	for _, tt := range tests {
+		result := NewLenientParser([]byte("GET " + tt.target + " HTTP/1.1\r\n\r\n")).Parse()
+		req := result.Request
+		if req == nil {
+			t.Fatalf("%s: no request", tt.target)
+		}
+		var host string
+		for _, h := range req.Headers {
+			if strings.EqualFold(h.Key, "Host") {
+				host = h.Value
+			}
+		}
+		if req.Path != tt.path || host != tt.host || req.Scheme != tt.scheme {
+			t.Errorf("%s: path, Host, scheme = %q, %q, %q, want %q, %q, %q",
+				tt.target, req.Path, host, req.Scheme, tt.path, tt.host, tt.scheme)
+		}
+		warned := strings.Contains(strings.Join(result.Warnings, "\n"), "invalid authority")
+		if warned != tt.rejected {
+			t.Errorf("%s: invalid authority warning = %v, want %v (%v)", tt.target, warned, tt.rejected, result.Warnings)
+		}
+	}
+}
+
 // ── parseHeadersLenient(): pos >= length without empty line ───────────────
 
 func TestLenientParse_TruncatedHeaders(t *testing.T) {
@@ -0,0 +1,177 @@
+package fastparser
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// unquoteLiteral replaces input that is a string literal copied from
+// source code with the string it denotes, so that
+//
+//	"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+//
+// parses as the request it spells. It recognizes Go, Python and JavaScript
+// literals: double- or single-quoted, triple-quoted, backquoted, or bare
+// text holding \n escapes but no line breaks. Escapes are decoded as Go
+// does, plus the Python and JavaScript extras: \0, \u{...}, \$, \` and
+// line continuations; unknown escapes are kept. Input with real line
+// breaks is only unwrapped from triple quotes or backquotes, so a message
+// whose JSON body contains \" escapes is left alone. The literal must
+// decode to text starting with a start line. The input is rewritten, so
+// offsets in Stats refer to the decoded bytes.
+func (p *LenientParser) unquoteLiteral() {
+	inner, escaped := literalBody(bytes.TrimSpace(p.data[p.pos:]))
+	if inner == nil {
+		return
+	}
+	if escaped {
+		var ok bool
+		if inner, ok = unescapeLiteral(inner); !ok {
+			return
+		}
+	}
+	first := bytes.TrimLeft(inner, "\r\n")
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	if _, conf := detectStartLine(bytes.TrimRight(first, "\r")); conf == ConfidenceLow {
+		return
+	}
+	p.data, p.length, p.pos = inner, len(inner), 0
+	p.rewritten = true
+	p.addWarning(0, WarnStringLiteral, "input appeared to be a string literal, unescaped")
+}
+
+// literalBody returns the contents of the string literal data, or nil if
+// data is not one, and whether escapes in it are to be decoded.
+func literalBody(data []byte) (inner []byte, escaped bool) {
+	if len(data) > 2 && (data[0] == 'b' || data[0] == 'B') && (data[1] == '"' || data[1] == '\'') {
+		data = data[1:] // Python bytes literal
+	}
+	for _, triple := range []string{`"""`, `'''`} {
+		if len(data) >= 6 && bytes.HasPrefix(data, []byte(triple)) && bytes.HasSuffix(data, []byte(triple)) {
+			return data[3 : len(data)-3], true
+		}
+	}
+	multiline := bytes.IndexByte(data, '\n') >= 0
+	if len(data) >= 2 && data[0] == data[len(data)-1] {
+		switch q := data[0]; q {
+		case '"', '\'':
+			inner = data[1 : len(data)-1]
+			if multiline || unescapedQuote(inner, q) {
+				return nil, false
+			}
+			return inner, true
+		case '`':
+			// A Go raw string spans lines; a one-line JavaScript template
+			// literal carries its line breaks as escapes.
+			return data[1 : len(data)-1], !multiline
+		}
+	}
+	if !multiline && bytes.Contains(data, []byte(`\n`)) {
+		return data, true
+	}
+	return nil, false
+}
+
+// unescapedQuote reports whether s contains q not preceded by a backslash,
+// which would end a literal quoted with q.
+func unescapedQuote(s []byte, q byte) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeLiteral decodes the escapes in s; see unquoteLiteral. It reports
+// false for a malformed numeric escape.
+func unescapeLiteral(s []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'a':
+			out = append(out, '\a')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'v':
+			out = append(out, '\v')
+		case '\\', '"', '\'', '`', '$':
+			out = append(out, c)
+		case '\n':
+			// line continuation
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n, j := 0, i
+			for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+				n = n*8 + int(s[j]-'0')
+			}
+			if n > 0xff {
+				return nil, false
+			}
+			out = append(out, byte(n))
+			i = j - 1
+		case 'x':
+			n, ok := hexAt(s, i+1, 2)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, byte(n))
+			i += 2
+		case 'u', 'U':
+			digits, braced := 4, false
+			if c == 'U' {
+				digits = 8
+			} else if i+1 < len(s) && s[i+1] == '{' {
+				end := bytes.IndexByte(s[i+2:], '}')
+				if end < 1 {
+					return nil, false
+				}
+				digits, braced = end, true
+				i++
+			}
+			n, ok := hexAt(s, i+1, digits)
+			if !ok || n > utf8.MaxRune {
+				return nil, false
+			}
+			out = utf8.AppendRune(out, rune(n))
+			i += digits
+			if braced {
+				i++
+			}
+		default:
+			out = append(out, '\\', c)
+		}
+	}
+	return out, true
+}
+
+// hexAt parses the n hex digits at s[i:].
+func hexAt(s []byte, i, n int) (uint64, bool) {
+	if n > 8 || i+n > len(s) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(string(s[i:i+n]), 16, 32)
+	return v, err == nil
+}
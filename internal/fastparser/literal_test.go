@@ -0,0 +1,82 @@
+package fastparser
+
+import (
+	"strings"
+	"testing"
+)
+
+const literalWarning = "input appeared to be a string literal, unescaped"
+
+func TestLenient_StringLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		body  string
+	}{
+		{"Go double-quoted", `"GET /api HTTP/1.1\r\nHost: example.com\r\n\r\n"`, ""},
+		{"Go with body", `"POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Length: 12\r\n\r\n{\"id\":\"42\"}\t"`, "{\"id\":\"42\"}\t"},
+		{"single-quoted", `'GET /api HTTP/1.1\nHost: example.com\n\n'`, ""},
+		{"Python bytes", `b'GET /api HTTP/1.1\r\nHost: example.com\r\n\r\n'`, ""},
+		{"bare escapes", `GET /api HTTP/1.1\r\nHost: example.com\r\n\r\n`, ""},
+		{"JS template", "`GET /api HTTP/1.1\\nHost: example.com\\nX-Tag: \\u{1F600} \\x41\\$\\n\\n`", ""},
+		{"surrounding space", "  \"GET /api HTTP/1.1\\nHost: example.com\\n\\n\"\n", ""},
+		{"Python triple-quoted", "\"\"\"\n    GET /api HTTP/1.1\n    Host: example.com\n\n\"\"\"", ""},
+		{"Python triple with escapes", "'''GET /api HTTP/1.1\\r\nHost: example.com\\r\n\\r\n'''", ""},
+		{"Go raw string", "`GET /api HTTP/1.1\nHost: example.com\n\n`", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewLenientParser([]byte(tt.input)).Parse()
+			req := result.Request
+			if req == nil {
+				t.Fatalf("Request is nil, warnings %q", result.Warnings)
+			}
+			if req.Method != "GET" && req.Method != "POST" || req.Path != "/api" || getHeader(req.Headers, "Host") != "example.com" {
+				t.Errorf("request = %s %s, Host %q", req.Method, req.Path, getHeader(req.Headers, "Host"))
+			}
+			if string(req.Body) != tt.body {
+				t.Errorf("Body = %q, want %q", req.Body, tt.body)
+			}
+			if len(result.Warnings) == 0 || result.Warnings[0] != literalWarning {
+				t.Errorf("Warnings = %q, want %q first", result.Warnings, literalWarning)
+			}
+		})
+	}
+}
+
+func TestLenient_StringLiteralEscapes(t *testing.T) {
+	result := NewLenientParser([]byte("`GET /api HTTP/1.1\\nX-Tag: \\u{1F600} \\x41\\$ \\101\\U0001F600 \\q\\n\\n`")).Parse()
+	if result.Request == nil {
+		t.Fatal("Request is nil")
+	}
+	if got, want := getHeader(result.Request.Headers, "X-Tag"), "\U0001F600 A$ A\U0001F600 \\q"; got != want {
+		t.Errorf("X-Tag = %q, want %q", got, want)
+	}
+}
+
+func TestLenient_StringLiteralNotTriggered(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"JSON body with escapes", "POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Length: 25\r\n\r\n{\"msg\":\"say \\\"hi\\\"\\n\"}"},
+		{"multi-line quoted", "\"GET /api HTTP/1.1\nHost: example.com\n\n\""},
+		{"quote inside", `"GET /api HTTP/1.1\n" + "Host: example.com\n\n"`},
+		{"not a message", `"hello\nworld"`},
+		{"bad escape", `"GET /api HTTP/1.1\nX-A: \xZZ\n\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewLenientParser([]byte(tt.input)).Parse()
+			for _, w := range result.Warnings {
+				if w == literalWarning {
+					t.Errorf("input unescaped as a string literal; warnings %q", result.Warnings)
+				}
+			}
+		})
+	}
+	json := NewLenientParser([]byte("POST /api HTTP/1.1\r\nContent-Length: 10\r\n\r\n{\"a\":\"\\n\"}")).Parse()
+	if body := string(json.Request.Body); !strings.Contains(body, `\n`) {
+		t.Errorf("Body = %q, escapes decoded", body)
+	}
+}
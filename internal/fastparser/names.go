@@ -0,0 +1,83 @@
+package fastparser
+
+// Standard header field names in canonical form, as interned by the
+// parsers. pkg/http exports the same set.
+const (
+	HeaderAccept             = "Accept"
+	HeaderAcceptCharset      = "Accept-Charset"
+	HeaderAcceptEncoding     = "Accept-Encoding"
+	HeaderAcceptLanguage     = "Accept-Language"
+	HeaderAcceptRanges       = "Accept-Ranges"
+	HeaderAge                = "Age"
+	HeaderAllow              = "Allow"
+	HeaderAuthorization      = "Authorization"
+	HeaderCacheControl       = "Cache-Control"
+	HeaderConnection         = "Connection"
+	HeaderContentDisposition = "Content-Disposition"
+	HeaderContentEncoding    = "Content-Encoding"
+	HeaderContentLanguage    = "Content-Language"
+	HeaderContentLength      = "Content-Length"
+	HeaderContentLocation    = "Content-Location"
+	HeaderContentRange       = "Content-Range"
+	HeaderContentType        = "Content-Type"
+	HeaderCookie             = "Cookie"
+	HeaderDate               = "Date"
+	HeaderETag               = "ETag"
+	HeaderExpect             = "Expect"
+	HeaderExpires            = "Expires"
+	HeaderFrom               = "From"
+	HeaderHost               = "Host"
+	HeaderIfMatch            = "If-Match"
+	HeaderIfModifiedSince    = "If-Modified-Since"
+	HeaderIfNoneMatch        = "If-None-Match"
+	HeaderIfRange            = "If-Range"
+	HeaderIfUnmodifiedSince  = "If-Unmodified-Since"
+	HeaderLastModified       = "Last-Modified"
+	HeaderLocation           = "Location"
+	HeaderMaxForwards        = "Max-Forwards"
+	HeaderOrigin             = "Origin"
+	HeaderPragma             = "Pragma"
+	HeaderProxyAuthenticate  = "Proxy-Authenticate"
+	HeaderProxyAuthorization = "Proxy-Authorization"
+	HeaderRange              = "Range"
+	HeaderReferer            = "Referer"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderServer             = "Server"
+	HeaderSetCookie          = "Set-Cookie"
+	HeaderTE                 = "TE"
+	HeaderTrailer            = "Trailer"
+	HeaderTransferEncoding   = "Transfer-Encoding"
+	HeaderUpgrade            = "Upgrade"
+	HeaderUserAgent          = "User-Agent"
+	HeaderVary               = "Vary"
+	HeaderVia                = "Via"
+	HeaderWarning            = "Warning"
+	HeaderWWWAuthenticate    = "WWW-Authenticate"
+	HeaderXForwardedFor      = "X-Forwarded-For"
+	HeaderXForwardedHost     = "X-Forwarded-Host"
+	HeaderXForwardedProto    = "X-Forwarded-Proto"
+	HeaderXRequestID         = "X-Request-ID"
+	HeaderXRealIP            = "X-Real-IP"
+)
+
+// Request methods: those of RFC 9110 and RFC 5789 (PATCH), then WebDAV's
+// (RFC 4918).
+const (
+	MethodGet     = "GET"
+	MethodHead    = "HEAD"
+	MethodPost    = "POST"
+	MethodPut     = "PUT"
+	MethodPatch   = "PATCH"
+	MethodDelete  = "DELETE"
+	MethodConnect = "CONNECT"
+	MethodOptions = "OPTIONS"
+	MethodTrace   = "TRACE"
+
+	MethodPropfind  = "PROPFIND"
+	MethodProppatch = "PROPPATCH"
+	MethodMkcol     = "MKCOL"
+	MethodCopy      = "COPY"
+	MethodMove      = "MOVE"
+	MethodLock      = "LOCK"
+	MethodUnlock    = "UNLOCK"
+)
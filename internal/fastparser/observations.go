@@ -0,0 +1,114 @@
+package fastparser
+
+// LineEnding identifies the line terminator used in a message's head. The
+// zero value means no line ending was seen.
+type LineEnding int
+
+// Line endings.
+const (
+	LineEndingCRLF LineEnding = iota + 1
+	LineEndingLF
+	LineEndingCR
+)
+
+// HeaderCase is a guess at the capitalization convention of a message's
+// header names. The zero value means there were no names to judge.
+type HeaderCase int
+
+// Header name styles.
+const (
+	HeaderCaseCanonical HeaderCase = iota + 1 // Content-Type
+	HeaderCaseLower                           // content-type
+	HeaderCaseUpper                           // CONTENT-TYPE
+	HeaderCaseMixed                           // names disagree or follow no one style
+)
+
+// FormatObservations records how the head of a leniently parsed message
+// deviated from canonical form. The counters are filled as the parser
+// meets each deviation; the input is not scanned again for them.
+type FormatObservations struct {
+	LineEnding       LineEnding // the first line ending of the head
+	MixedLineEndings bool       // the head used more than one kind
+
+	ObsFoldCount               int // continuation lines folded into a header
+	WhitespaceBeforeColonCount int // header names followed by spaces or tabs
+	StrayBlankLines            int // blank lines skipped before the headers
+	LeadingBlankLines          int // empty lines skipped before the start line
+	IndentBytes                int // common indentation stripped from each line
+	HadBOM                     bool
+
+	HeaderCase HeaderCase
+}
+
+// noteEnding records one line ending of the head.
+func (o *FormatObservations) noteEnding(e LineEnding) {
+	switch o.LineEnding {
+	case 0:
+		o.LineEnding = e
+	case e:
+	default:
+		o.MixedLineEndings = true
+	}
+}
+
+// caseTally records the styles of the header names of a message as they
+// are read, so that names the parser adds itself are left out.
+type caseTally [HeaderCaseMixed + 1]bool
+
+// note records the style of name.
+func (t *caseTally) note(name string) {
+	if c := nameCase(name); c != 0 {
+		t[c] = true
+	}
+}
+
+// guess returns the naming style of the names noted. All-uppercase names
+// such as "TE" or "DNT" fit the canonical style too, so they only decide
+// the guess when every name is uppercase.
+func (t caseTally) guess() HeaderCase {
+	seen := t
+	if seen[HeaderCaseCanonical] || seen[HeaderCaseLower] || seen[HeaderCaseMixed] {
+		seen[HeaderCaseUpper] = false
+	}
+	guess := HeaderCase(0)
+	for c := HeaderCaseCanonical; c <= HeaderCaseMixed; c++ {
+		if !seen[c] {
+			continue
+		}
+		if guess != 0 {
+			return HeaderCaseMixed
+		}
+		guess = c
+	}
+	return guess
+}
+
+// nameCase classifies one header name, or returns 0 if it has no letters.
+func nameCase(name string) HeaderCase {
+	lower, upper, canonical := false, false, true
+	start := true
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z':
+			lower = true
+			if start {
+				canonical = false
+			}
+		case 'A' <= c && c <= 'Z':
+			upper = true
+		}
+		start = c == '-'
+	}
+	switch {
+	case !lower && !upper:
+		return 0
+	case !upper:
+		return HeaderCaseLower
+	case !lower:
+		return HeaderCaseUpper
+	case canonical:
+		return HeaderCaseCanonical
+	}
+	return HeaderCaseMixed
+}
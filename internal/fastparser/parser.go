@@ -6,6 +6,9 @@ import (
 	"bytes"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Request represents a parsed HTTP request.
@@ -16,6 +19,11 @@ type Request struct {
 	Scheme  string // "https", "http", or "" — populated from absolute-form targets
 	Headers []Header
 	Body    []byte
+
+	// RawBody and RawHeaders keep the encoded body and the header section
+	// as received when decoding changed them; see Limits.KeepRawBody.
+	RawBody    []byte
+	RawHeaders []Header
 }
 
 // Response represents a parsed HTTP response.
@@ -25,6 +33,8 @@ type Response struct {
 	Reason     string
 	Headers    []Header
 	Body       []byte
+	RawBody    []byte
+	RawHeaders []Header
 }
 
 // Header is a key-value pair.
@@ -33,12 +43,124 @@ type Header struct {
 	Value string
 }
 
+// Stats records how many bytes each part of a parsed message occupied in
+// the input. TotalBytes is StartLineBytes + HeaderBytes + BodyBytes, so the
+// message spans data[StartOffset : StartOffset+TotalBytes].
+type Stats struct {
+	StartOffset    int // offset of the start line, past leading blank lines or interim responses
+	StartLineBytes int // the start line, including its line ending
+	HeaderBytes    int // the header field lines and the blank line ending them
+	HeaderCount    int // header fields parsed; an obs-folded field counts once
+	BodyBytes      int // the body as framed on the wire, chunk framing and trailers included
+	TotalBytes     int
+
+	// DecodedBodyBytes is the body length once chunked framing is removed;
+	// it equals BodyBytes for a body that is not chunked.
+	DecodedBodyBytes int
+
+	// LargestHeader names the header with the longest value, the first of
+	// them on a tie, and LargestHeaderBytes is that value's length as
+	// received. Both are zero for a message without headers.
+	LargestHeader      string
+	LargestHeaderBytes int
+
+	// UsedBareLF reports that a line of the head ended in LF alone. Only
+	// the strict parser sets it; the lenient parser describes line endings
+	// in ParseResult.Observations.
+	UsedBareLF bool
+
+	// LeadingBlankLines counts the empty lines skipped before the start
+	// line, as RFC 9112 §2.2 allows. Only the strict parser sets it; the
+	// lenient parser counts them in ParseResult.Observations.
+	LeadingBlankLines int
+
+	// HeaderEndOffset is the offset of the blank line ending the header
+	// section and BodyOffset that of the first byte of the body, the first
+	// chunk-size line of a chunked one, both from the start of the input.
+	// A message without a body has BodyOffset at the end of its head. When
+	// the lenient parser infers a body that no blank line introduced, both
+	// are where it decided the body began; when the header section never
+	// ended, or the lenient parser rewrote the input first (to transcode,
+	// dedent or unquote it, or undo doubled CRs), both are -1.
+	HeaderEndOffset int
+	BodyOffset      int
+}
+
+// Limits holds optional restrictions enforced by the strict parser.
+// The zero value imposes none.
+type Limits struct {
+	// RestrictMethods, when non-empty, is the case-sensitive allow-list of
+	// request methods; any other method is rejected.
+	RestrictMethods []string
+
+	// KeepRawBody keeps a copy of a chunked body's wire bytes, trailers
+	// included, in RawBody and of the header section before chunked
+	// normalization in RawHeaders.
+	KeepRawBody bool
+
+	// MaxHeaderValueBytes, when positive, rejects a header whose value,
+	// obs-folded lines joined, is longer.
+	MaxHeaderValueBytes int
+
+	// MaxFoldedLines, when positive, rejects a header with more obs-fold
+	// continuation lines.
+	MaxFoldedLines int
+
+	// RequireCRLF rejects a line of the head or of chunked framing that
+	// ends in LF alone, and a CR not followed by LF in the head.
+	RequireCRLF bool
+
+	// DecodeTransferCodings undoes the transfer codings applied before
+	// chunked; without it they stay listed in Transfer-Encoding.
+	DecodeTransferCodings bool
+
+	// MaxDecodedBodyBytes, when positive, rejects a body whose transfer
+	// codings decode to more bytes.
+	MaxDecodedBodyBytes int
+
+	// Trace, when non-nil, is called as each phase of the parse completes.
+	Trace *ParserTrace
+}
+
+// ParserTrace holds the hooks the strict parser calls, in order, as it
+// finishes the start line, the header section and the body of a message.
+// Any hook may be nil. Hooks are not called for a phase that fails.
+type ParserTrace struct {
+	OnStartLine   func(info PhaseInfo)
+	OnHeadersDone func(info PhaseInfo)
+	OnBodyDone    func(info PhaseInfo)
+}
+
+// PhaseInfo describes a parse phase: the input bytes from Start to End it
+// consumed and the time it took.
+type PhaseInfo struct {
+	Start    int
+	End      int
+	Duration time.Duration
+}
+
 // Parser implements a zero-allocation HTTP/1.1 parser that scans bytes directly.
 type Parser struct {
 	data   []byte
 	pos    int
 	length int
 	line   int // 1-indexed line number for error reporting
+	limits Limits
+	stats  Stats
+
+	// head is the start line and header section, converted to a string
+	// once, at headBase in data; field values and the request target are
+	// sliced from it instead of being allocated one by one.
+	head     string
+	headBase int
+	headerN  int // header lines in head, to size the headers slice
+
+	largestKey   []byte // the name of the longest header value scanned
+	largestBytes int
+	bareLF       int // offset of the first head line ended by LF alone, or -1
+	leadingBlank int // empty lines skipped before the start line
+
+	phaseStart time.Time // when the phase being traced began
 }
 
 // NewParser creates a new fast parser for the given data.
@@ -51,6 +173,13 @@ func NewParser(data []byte) *Parser {
 	}
 }
 
+// NewParserWithLimits creates a new fast parser that enforces limits.
+func NewParserWithLimits(data []byte, limits Limits) *Parser {
+	p := NewParser(data)
+	p.limits = limits
+	return p
+}
+
 // initParser initializes a parser in-place (stack-friendly, avoids heap alloc).
 func initParser(p *Parser, data []byte) {
 	p.data = data
@@ -59,55 +188,305 @@ func initParser(p *Parser, data []byte) {
 	p.line = 1
 }
 
+// Stats returns the byte statistics of the last message parsed.
+func (p *Parser) Stats() Stats {
+	return p.stats
+}
+
+// keepRaw copies the wire body from bodyStart to p.pos and the headers,
+// which normalizeChunkedHeaders is about to rewrite in place.
+func (p *Parser) keepRaw(bodyStart int, headers []Header) ([]byte, []Header) {
+	raw := make([]byte, p.pos-bodyStart)
+	copy(raw, p.data[bodyStart:p.pos])
+	return raw, append([]Header(nil), headers...)
+}
+
+// measure records the statistics of a message whose header section starts
+// at headerStart and whose body starts at bodyStart and ends at p.pos.
+func (p *Parser) measure(start, headerStart, bodyStart, headerCount int, body []byte) {
+	headerEnd, bodyOffset := blankLineStart(p.data, bodyStart), bodyStart
+	if headerEnd < 0 {
+		bodyOffset = -1
+	}
+	p.stats = Stats{
+		StartOffset:      start,
+		StartLineBytes:   headerStart - start,
+		HeaderBytes:      bodyStart - headerStart,
+		HeaderCount:      headerCount,
+		BodyBytes:        p.pos - bodyStart,
+		TotalBytes:       p.pos - start,
+		DecodedBodyBytes: len(body),
+
+		LargestHeaderBytes: p.largestBytes,
+		UsedBareLF:         p.bareLF >= 0,
+		LeadingBlankLines:  p.leadingBlank,
+		HeaderEndOffset:    headerEnd,
+		BodyOffset:         bodyOffset,
+	}
+	if p.largestKey != nil {
+		p.stats.LargestHeader = p.headString(p.largestKey)
+	}
+}
+
+// blankLineStart returns the offset of the blank line that ends at end,
+// CRLF or a bare LF, or -1 if the line ending at end is not blank or the
+// input ended before one.
+func blankLineStart(data []byte, end int) int {
+	if end < 1 || data[end-1] != '\n' {
+		return -1
+	}
+	start := end - 1
+	if start >= 1 && data[start-1] == '\r' {
+		start--
+	}
+	if start > 0 && data[start-1] != '\n' {
+		return -1
+	}
+	return start
+}
+
+// phaseDone calls hook, if set, for the traced phase from start to end
+// and starts timing the next.
+func (p *Parser) phaseDone(hook func(PhaseInfo), start, end int) {
+	now := time.Now()
+	if hook != nil {
+		hook(PhaseInfo{Start: start, End: end, Duration: now.Sub(p.phaseStart)})
+	}
+	p.phaseStart = now
+}
+
+// loadHead converts the message head starting at p.pos, up to the blank
+// line that ends the header section or the end of data, to a string in a
+// single allocation and counts its header lines and notes the first line
+// ended by LF alone. Strings sliced from it keep the whole head reachable,
+// which is no more than the headers were.
+func (p *Parser) loadHead() {
+	end, n := p.pos, -1 // the start line is not a header
+	p.bareLF = -1
+	for end < p.length {
+		i := bytes.IndexByte(p.data[end:], '\n')
+		if i < 0 {
+			end = p.length
+			n++
+			break
+		}
+		if nl := end + i; p.bareLF < 0 && (nl == p.pos || p.data[nl-1] != '\r') {
+			p.bareLF = nl
+		}
+		end += i + 1
+		n++
+		if end < p.length && p.data[end] == '\n' {
+			if p.bareLF < 0 {
+				p.bareLF = end
+			}
+			break
+		}
+		if end+1 < p.length && p.data[end] == '\r' && p.data[end+1] == '\n' {
+			break
+		}
+	}
+	p.head, p.headBase, p.headerN = string(p.data[p.pos:end]), p.pos, max(n, 0)
+}
+
+// skipBlankLines steps over the empty lines before a start line, which
+// RFC 9112 §2.2 lets a recipient ignore, such as the stray CRLF some
+// clients send between pipelined requests. Under RequireCRLF a line that
+// is LF alone is not skipped, so that requireCRLF reports it.
+func (p *Parser) skipBlankLines() {
+	p.leadingBlank = 0
+	for {
+		switch {
+		case p.pos+1 < p.length && p.data[p.pos] == '\r' && p.data[p.pos+1] == '\n':
+			p.pos += 2
+		case p.pos < p.length && p.data[p.pos] == '\n' && !p.limits.RequireCRLF:
+			p.pos++
+		default:
+			return
+		}
+		p.line++
+		p.leadingBlank++
+	}
+}
+
+// requireCRLF returns an error naming the first line of the head that
+// ends in LF alone or holds a CR not followed by LF, the blank line that
+// ends the head included.
+func (p *Parser) requireCRLF() error {
+	head := p.data[p.headBase : p.headBase+len(p.head)]
+	bad, what := p.bareLF, "line ends in LF without CR"
+	for i := 0; i < len(head); i++ {
+		if head[i] == '\r' && (i+1 == len(head) || head[i+1] != '\n') {
+			if off := p.headBase + i; bad < 0 || off < bad {
+				bad, what = off, "bare CR not followed by LF"
+			}
+			break
+		}
+	}
+	if bad < 0 {
+		return nil
+	}
+	return p.lineEndingError(p.headBase, bad, what)
+}
+
+// requireChunkCRLF returns an error naming the first line of the chunked
+// framing from bodyStart to p.pos that ends in LF alone.
+func (p *Parser) requireChunkCRLF(bodyStart int) error {
+	off := bareChunkLine(p.data[bodyStart:p.pos])
+	if off < 0 {
+		return nil
+	}
+	return p.lineEndingError(bodyStart, bodyStart+off, "chunked framing line ends in LF without CR")
+}
+
+// lineEndingError reports the line ending at offset bad, counting lines
+// from the line at offset from, which is line p.line.
+func (p *Parser) lineEndingError(from, bad int, what string) error {
+	p.line += bytes.Count(p.data[from:bad], []byte("\n"))
+	return WithKind(p.errorf("%s", what), ErrBareLineEnding)
+}
+
+// headString returns b, a subslice of data, as a string sliced from head
+// when it lies within it, and as a fresh copy otherwise.
+func (p *Parser) headString(b []byte) string {
+	off := cap(p.data) - cap(b) - p.headBase
+	if off < 0 || off+len(b) > len(p.head) {
+		return string(b)
+	}
+	return p.head[off : off+len(b)]
+}
+
 // ParseRequest parses an HTTP request message.
 func (p *Parser) ParseRequest() (*Request, error) {
-	method, path, version, err := p.parseRequestLine()
+	p.skipBlankLines()
+	start := p.pos
+	if p.limits.Trace != nil {
+		p.phaseStart = time.Now()
+	}
+	p.loadHead()
+	if p.limits.RequireCRLF {
+		if err := p.requireCRLF(); err != nil {
+			return nil, err
+		}
+	}
+	method, target, version, err := p.parseRequestLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.limits.RestrictMethods) > 0 && !containsString(p.limits.RestrictMethods, method) {
+		return nil, p.errorf("method %s is not allowed", quoteInput(method))
+	}
+
+	path, scheme, authority, err := p.parseRequestTarget(method, target)
 	if err != nil {
 		return nil, err
 	}
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnStartLine, start, p.pos)
+	}
 
+	headerStart := p.pos
 	headers, err := p.parseHeaders()
 	if err != nil {
 		return nil, err
 	}
+	bodyStart, headerCount := p.pos, len(headers)
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnHeadersDone, headerStart, bodyStart)
+	}
+
+	if headers, err = p.reconcileHost(headers, scheme, authority); err != nil {
+		return nil, err
+	}
 
 	wasChunked := isChunked(headers)
 	body, err := p.parseBody(headers)
 	if err != nil {
 		return nil, err
 	}
+	var rawBody []byte
+	var rawHeaders []Header
 	if wasChunked {
-		headers = normalizeChunkedHeaders(headers, len(body))
+		if p.limits.RequireCRLF {
+			if err := p.requireChunkCRLF(bodyStart); err != nil {
+				return nil, err
+			}
+		}
+		if p.limits.KeepRawBody {
+			rawBody, rawHeaders = p.keepRaw(bodyStart, headers)
+		}
+		headers = normalizeChunkedHeaders(headers, len(body), p.limits.DecodeTransferCodings)
+	}
+	p.measure(start, headerStart, bodyStart, headerCount, body)
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnBodyDone, bodyStart, p.pos)
 	}
 
 	return &Request{
 		Method:  method,
 		Path:    path,
 		Version: version,
+		Scheme:  scheme,
 		Headers: headers,
 		Body:    body,
+
+		RawBody:    rawBody,
+		RawHeaders: rawHeaders,
 	}, nil
 }
 
 // ParseResponse parses an HTTP response message.
 func (p *Parser) ParseResponse() (*Response, error) {
+	p.skipBlankLines()
+	start := p.pos
+	if p.limits.Trace != nil {
+		p.phaseStart = time.Now()
+	}
+	p.loadHead()
+	if p.limits.RequireCRLF {
+		if err := p.requireCRLF(); err != nil {
+			return nil, err
+		}
+	}
 	version, statusCode, reason, err := p.parseStatusLine()
 	if err != nil {
 		return nil, err
 	}
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnStartLine, start, p.pos)
+	}
 
+	headerStart := p.pos
 	headers, err := p.parseHeaders()
 	if err != nil {
 		return nil, err
 	}
+	bodyStart, headerCount := p.pos, len(headers)
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnHeadersDone, headerStart, bodyStart)
+	}
 
 	wasChunked := isChunked(headers)
 	body, err := p.parseBody(headers)
 	if err != nil {
 		return nil, err
 	}
+	var rawBody []byte
+	var rawHeaders []Header
 	if wasChunked {
-		headers = normalizeChunkedHeaders(headers, len(body))
+		if p.limits.RequireCRLF {
+			if err := p.requireChunkCRLF(bodyStart); err != nil {
+				return nil, err
+			}
+		}
+		if p.limits.KeepRawBody {
+			rawBody, rawHeaders = p.keepRaw(bodyStart, headers)
+		}
+		headers = normalizeChunkedHeaders(headers, len(body), p.limits.DecodeTransferCodings)
+	}
+	p.measure(start, headerStart, bodyStart, headerCount, body)
+	if p.limits.Trace != nil {
+		p.phaseDone(p.limits.Trace.OnBodyDone, bodyStart, p.pos)
 	}
 
 	return &Response{
@@ -116,6 +495,8 @@ func (p *Parser) ParseResponse() (*Response, error) {
 		Reason:     reason,
 		Headers:    headers,
 		Body:       body,
+		RawBody:    rawBody,
+		RawHeaders: rawHeaders,
 	}, nil
 }
 
@@ -123,13 +504,13 @@ func (p *Parser) ParseResponse() (*Response, error) {
 func (p *Parser) parseRequestLine() (method, path, version string, err error) {
 	line, err := p.readLine()
 	if err != nil {
-		return "", "", "", p.errorf("missing request line")
+		return "", "", "", p.failf(ErrMalformedStartLine, "missing request line")
 	}
 
 	// Find first SP
 	sp1 := bytes.IndexByte(line, ' ')
 	if sp1 < 0 {
-		return "", "", "", p.errorf("malformed request line: no method separator")
+		return "", "", "", p.failf(ErrMalformedStartLine, "malformed request line: no method separator")
 	}
 	method = internMethod(line[:sp1])
 
@@ -138,32 +519,179 @@ func (p *Parser) parseRequestLine() (method, path, version string, err error) {
 	// Find second SP (separating path from version)
 	sp2 := bytes.IndexByte(rest, ' ')
 	if sp2 < 0 {
-		return "", "", "", p.errorf("malformed request line: no version separator")
+		return "", "", "", p.failf(ErrMalformedStartLine, "malformed request line: no version separator")
 	}
-	path = string(rest[:sp2])
+	path = p.headString(rest[:sp2])
 	version = internVersion(rest[sp2+1:])
 
 	if method == "" {
-		return "", "", "", p.errorf("empty request method")
+		return "", "", "", p.failf(ErrMalformedStartLine, "empty request method")
 	}
 	if path == "" {
-		return "", "", "", p.errorf("empty request path")
+		return "", "", "", p.failf(ErrMalformedStartLine, "empty request path")
 	}
 
 	return method, path, version, nil
 }
 
+// parseRequestTarget classifies the request-target per RFC 9112 §3.2 and
+// returns the normalized path along with the scheme and authority when the
+// target was in absolute-form.
+//
+//	origin-form:    "/api?q=1"                   → path="/api?q=1"
+//	absolute-form:  "http://example.com/api?q=1" → path="/api?q=1", scheme="http", authority="example.com"
+//	authority-form: "example.com:443" (CONNECT)  → path="example.com:443"
+//	asterisk-form:  "*" (OPTIONS)                → path="*"
+func (p *Parser) parseRequestTarget(method, target string) (path, scheme, authority string, err error) {
+	if method == MethodConnect {
+		if !isAuthorityForm(target) {
+			return "", "", "", p.failf(ErrMalformedStartLine, "CONNECT request-target must be authority-form (host:port): %s", excerpt(RedactUserinfo(target)))
+		}
+		return target, "", "", nil
+	}
+
+	switch target[0] {
+	case '/':
+		return target, "", "", nil
+	case '*':
+		if len(target) == 1 {
+			return target, "", "", nil
+		}
+	}
+
+	scheme, rest, ok := splitScheme(target)
+	if !ok {
+		return "", "", "", p.failf(ErrMalformedStartLine, "invalid request-target: %s", excerpt(RedactUserinfo(target)))
+	}
+
+	authority = rest
+	path = "/"
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '/' || rest[i] == '?' {
+			authority = rest[:i]
+			if rest[i] == '/' {
+				path = rest[i:]
+			} else {
+				path = "/" + rest[i:]
+			}
+			break
+		}
+	}
+
+	if authority == "" {
+		return "", "", "", p.failf(ErrMalformedStartLine, "absolute-form request-target has empty authority: %s", excerpt(target))
+	}
+	if strings.IndexByte(authority, '@') >= 0 {
+		return "", "", "", p.failf(ErrMalformedStartLine, "absolute-form request-target must not contain userinfo: %s", excerpt(RedactUserinfo(target)))
+	}
+
+	return path, scheme, authority, nil
+}
+
+// reconcileHost drops repeated Host headers, rejecting differing ones, and,
+// for an absolute-form target, reconciles Host with the target's authority.
+func (p *Parser) reconcileHost(headers []Header, scheme, authority string) ([]Header, error) {
+	headers, first, other := dedupeHost(headers)
+	if other != "" {
+		return nil, p.errorf("multiple Host headers %s and %s", quoteInput(first), quoteInput(other))
+	}
+	if scheme != "" {
+		return p.applyTargetAuthority(headers, authority)
+	}
+	return headers, nil
+}
+
+// applyTargetAuthority reconciles the Host header with the authority of an
+// absolute-form request-target. A missing Host is synthesized from the
+// authority; a Host that names a different authority is rejected.
+func (p *Parser) applyTargetAuthority(headers []Header, authority string) ([]Header, error) {
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderHost) {
+			if !eqFold(h.Value, authority) {
+				return nil, p.errorf("Host header %s does not match request-target authority %s", quoteInput(h.Value), quoteInput(authority))
+			}
+			return headers, nil
+		}
+	}
+	out := make([]Header, 0, len(headers)+1)
+	out = append(out, Header{Key: HeaderHost, Value: authority})
+	return append(out, headers...), nil
+}
+
+// dedupeHost drops the Host headers after the first. When one of them
+// names a different authority, first and other are the first two
+// differing values; repeats of the same value, compared case-insensitively,
+// are simply removed. headers is returned as is when it has at most one
+// Host.
+func dedupeHost(headers []Header) (out []Header, first, other string) {
+	hosts := 0
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderHost) {
+			hosts++
+		}
+	}
+	if hosts < 2 {
+		return headers, "", ""
+	}
+	out = make([]Header, 0, len(headers)-hosts+1)
+	seen := false
+	for _, h := range headers {
+		if eqFold(h.Key, HeaderHost) {
+			if seen {
+				if other == "" && !eqFold(h.Value, first) {
+					other = h.Value
+				}
+				continue
+			}
+			seen, first = true, h.Value
+		}
+		out = append(out, h)
+	}
+	return out, first, other
+}
+
+// splitScheme splits an absolute-form target into its lowercased scheme and
+// the remainder after "://". Only the http and https schemes are recognized.
+func splitScheme(target string) (scheme, rest string, ok bool) {
+	switch {
+	case len(target) >= 8 && eqFold(target[:8], "https://"):
+		return "https", target[8:], true
+	case len(target) >= 7 && eqFold(target[:7], "http://"):
+		return "http", target[7:], true
+	}
+	return "", "", false
+}
+
+// isAuthorityForm reports whether target is "host:port" with a non-empty
+// host and an all-digit port. IPv6 literals must be bracketed.
+func isAuthorityForm(target string) bool {
+	colon := strings.LastIndexByte(target, ':')
+	if colon <= 0 || colon == len(target)-1 {
+		return false
+	}
+	host, port := target[:colon], target[colon+1:]
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	if host[0] == '[' {
+		return host[len(host)-1] == ']'
+	}
+	return !strings.ContainsAny(host, "/?#@:[]")
+}
+
 // parseStatusLine parses "VERSION SP STATUS SP REASON CRLF".
 func (p *Parser) parseStatusLine() (version string, statusCode int, reason string, err error) {
 	line, err := p.readLine()
 	if err != nil {
-		return "", 0, "", p.errorf("missing status line")
+		return "", 0, "", p.failf(ErrMalformedStartLine, "missing status line")
 	}
 
 	// Find first SP
 	sp1 := bytes.IndexByte(line, ' ')
 	if sp1 < 0 {
-		return "", 0, "", p.errorf("malformed status line: no version separator")
+		return "", 0, "", p.failf(ErrMalformedStartLine, "malformed status line: no version separator")
 	}
 	version = internVersion(line[:sp1])
 
@@ -175,94 +703,147 @@ func (p *Parser) parseStatusLine() (version string, statusCode int, reason strin
 		// Allow status line with no reason phrase: "HTTP/1.1 200"
 		code, convErr := strconv.Atoi(string(rest))
 		if convErr != nil {
-			return "", 0, "", p.errorf("invalid status code: %s", string(rest))
+			return "", 0, "", p.failf(ErrInvalidStatusCode, "invalid status code: %s", excerpt(string(rest)))
 		}
 		return version, code, "", nil
 	}
 
 	code, convErr := strconv.Atoi(string(rest[:sp2]))
 	if convErr != nil {
-		return "", 0, "", p.errorf("invalid status code: %s", string(rest[:sp2]))
+		return "", 0, "", p.failf(ErrInvalidStatusCode, "invalid status code: %s", excerpt(string(rest[:sp2])))
 	}
-	reason = internReason(rest[sp2+1:])
+	reason = p.headString(rest[sp2+1:])
 
 	return version, code, reason, nil
 }
 
 // parseHeaders parses header lines until empty line (CRLF CRLF).
-// Pre-allocates the headers slice to avoid growth allocations.
+// Pre-allocates the headers slice, sized by loadHead, to avoid growth
+// allocations.
 func (p *Parser) parseHeaders() ([]Header, error) {
-	headers := make([]Header, 0, 8)
+	headers := make([]Header, 0, p.headerN)
+	err := p.scanHeaders(func(key, value []byte, folded bool) {
+		if folded {
+			headers = append(headers, Header{Key: internHeaderName(key), Value: string(value)})
+		} else {
+			headers = append(headers, Header{Key: p.headString(key), Value: p.headString(value)})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
 
+// scanHeaders reads header lines up to and including the blank line that
+// ends the section, calling visit with each field's name and trimmed
+// value. Both alias data, except that the value of an obs-folded field,
+// reported with folded set, is joined into a new buffer.
+func (p *Parser) scanHeaders(visit func(key, value []byte, folded bool)) error {
+	p.largestKey, p.largestBytes = nil, 0
 	for {
 		if p.pos >= p.length {
 			// End of data without empty line — headers section is complete
-			return headers, nil
+			return nil
 		}
 
 		// Check for empty line (end of headers)
 		if p.pos < p.length && p.data[p.pos] == '\r' && p.pos+1 < p.length && p.data[p.pos+1] == '\n' {
 			p.pos += 2
 			p.line++
-			return headers, nil
+			return nil
 		}
 		if p.pos < p.length && p.data[p.pos] == '\n' {
 			p.pos++
 			p.line++
-			return headers, nil
+			return nil
 		}
 
 		line, err := p.readLine()
 		if err != nil {
-			return headers, nil
+			return nil
 		}
 
 		// Handle obs-fold (continuation line starting with SP/HTAB)
-		for p.pos < p.length && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		folded := false
+		for n := 0; p.pos < p.length && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t'); n++ {
+			if max := p.limits.MaxFoldedLines; max > 0 && n == max {
+				name, _, _ := bytes.Cut(line, []byte(":"))
+				return p.errorf("header %s has more than %d continuation lines", quoteInput(string(name)), max)
+			}
 			cont, contErr := p.readLine()
 			if contErr != nil {
 				break
 			}
-			// Replace obs-fold with single SP
-			line = append(line, ' ')
+			// Replace obs-fold with single SP. The first append copies
+			// line out of data, which must not be written to.
+			line = append(line[:len(line):len(line)], ' ')
 			line = append(line, bytes.TrimLeft(cont, " \t")...)
+			folded = true
 		}
 
 		// Parse "Key: Value"
 		colon := bytes.IndexByte(line, ':')
 		if colon < 0 {
-			return nil, p.errorf("malformed header line (no colon): %s", string(line))
+			return p.failf(ErrMalformedHeader, "malformed header line (no colon): %s", excerpt(RedactHeaderLine(string(line))))
 		}
 
 		keyBytes := line[:colon]
 
 		// RFC 9112: no whitespace between field-name and colon
 		if colon > 0 && (line[colon-1] == ' ' || line[colon-1] == '\t') {
-			return nil, p.errorf("whitespace before colon in header name: %s", string(keyBytes))
+			return WithKind(p.errorf("whitespace before colon in header name: %s", excerpt(string(keyBytes))), ErrWhitespaceBeforeColon, ErrMalformedHeader)
 		}
 
-		key := internHeaderName(keyBytes)
-		value := string(trimOWS(line[colon+1:]))
-		headers = append(headers, Header{Key: key, Value: value})
+		value := trimOWS(line[colon+1:])
+		if max := p.limits.MaxHeaderValueBytes; max > 0 && len(value) > max {
+			return p.errorf("header %s value is %d bytes, over the limit of %d", quoteInput(string(keyBytes)), len(value), max)
+		}
+		if p.largestKey == nil || len(value) > p.largestBytes {
+			p.largestKey, p.largestBytes = keyBytes, len(value)
+		}
+		visit(keyBytes, value, folded)
 	}
 }
 
 // parseBody determines and reads the message body.
 // Body length determination per RFC 9112:
-// 1. Transfer-Encoding: chunked → parse chunk frames
-// 2. Content-Length → read exactly N bytes
-// 3. Neither → remaining bytes (connection-close semantics)
+//  1. Transfer-Encoding: chunked → parse chunk frames, then, with
+//     Limits.DecodeTransferCodings, undo the transfer codings applied
+//     before chunked; chunked must come last
+//  2. Content-Length → read exactly N bytes
+//  3. Neither → remaining bytes (connection-close semantics)
 func (p *Parser) parseBody(headers []Header) ([]byte, error) {
 	// Check for chunked transfer encoding
 	if isChunked(headers) {
-		return Dechunk(p.data[p.pos:])
+		codings := transferCodings(headers)
+		if err := checkChunkedLast(codings); err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		data := p.data[p.pos:]
+		size, end, err := walkChunks(data, nil)
+		if err != nil {
+			return nil, err
+		}
+		var body []byte
+		if size > 0 {
+			body = make([]byte, 0, size)
+			walkChunks(data, func(chunk []byte) { body = append(body, chunk...) })
+		}
+		p.pos += trailerEnd(data, end)
+		if len(codings) > 1 && p.limits.DecodeTransferCodings {
+			if body, _, err = decodeTransferCodings(body, codings[:len(codings)-1], p.limits.MaxDecodedBodyBytes); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		}
+		return body, nil
 	}
 
 	// Check for Content-Length
 	cl := getContentLength(headers)
 	if cl >= 0 {
 		if p.pos+int(cl) > p.length {
-			return nil, p.errorf("body truncated: expected %d bytes but only %d available", cl, p.length-p.pos)
+			return nil, p.failf(ErrBodyTruncated, "body truncated: expected %d bytes but only %d available", cl, p.length-p.pos)
 		}
 		body := make([]byte, cl)
 		copy(body, p.data[p.pos:p.pos+int(cl)])
@@ -323,7 +904,7 @@ func trimOWS(b []byte) []byte {
 // isChunked checks if headers contain Transfer-Encoding: chunked.
 func isChunked(headers []Header) bool {
 	for _, h := range headers {
-		if eqFold(h.Key, "Transfer-Encoding") {
+		if eqFold(h.Key, HeaderTransferEncoding) {
 			if containsFold(h.Value, "chunked") {
 				return true
 			}
@@ -332,27 +913,26 @@ func isChunked(headers []Header) bool {
 	return false
 }
 
-// normalizeChunkedHeaders removes Transfer-Encoding after chunked decoding and
-// sets Content-Length to the decoded body length.
+// normalizeChunkedHeaders sets Content-Length to the decoded body length
+// and removes chunked from Transfer-Encoding, or the whole header when
+// decoded says the transfer codings before chunked were undone too.
 //
 // Chunked encoding is a transport-level framing mechanism. Once the body is
 // fully decoded, the struct should reflect the logical message: a known-length
 // body with Content-Length, not a chunked stream. This makes the struct
 // self-consistent for re-marshaling (e.g., in HTTP client/server use).
-func normalizeChunkedHeaders(headers []Header, bodyLen int) []Header {
+func normalizeChunkedHeaders(headers []Header, bodyLen int, decoded bool) []Header {
 	out := headers[:0:len(headers)]
 	out = out[:0]
 	hasContentLength := false
 	for _, h := range headers {
-		if eqFold(h.Key, "Transfer-Encoding") {
-			// Strip "chunked" from the value; drop the header if nothing remains.
-			stripped := stripChunked(h.Value)
-			if stripped != "" {
+		if eqFold(h.Key, HeaderTransferEncoding) {
+			if stripped := stripChunked(h.Value); stripped != "" && !decoded {
 				out = append(out, Header{Key: h.Key, Value: stripped})
 			}
 			continue
 		}
-		if eqFold(h.Key, "Content-Length") {
+		if eqFold(h.Key, HeaderContentLength) {
 			// Replace with the decoded body length.
 			out = append(out, Header{Key: h.Key, Value: strconv.Itoa(bodyLen)})
 			hasContentLength = true
@@ -361,7 +941,7 @@ func normalizeChunkedHeaders(headers []Header, bodyLen int) []Header {
 		out = append(out, h)
 	}
 	if !hasContentLength {
-		out = append(out, Header{Key: "Content-Length", Value: strconv.Itoa(bodyLen)})
+		out = append(out, Header{Key: HeaderContentLength, Value: strconv.Itoa(bodyLen)})
 	}
 	return out
 }
@@ -416,7 +996,7 @@ func trimString(s string) string {
 // getContentLength returns the Content-Length value, or -1 if absent/invalid.
 func getContentLength(headers []Header) int64 {
 	for _, h := range headers {
-		if eqFold(h.Key, "Content-Length") {
+		if eqFold(h.Key, HeaderContentLength) {
 			v := bytes.TrimSpace([]byte(h.Value))
 			n, err := strconv.ParseInt(string(v), 10, 64)
 			if err != nil {
@@ -428,6 +1008,16 @@ func getContentLength(headers []Header) int64 {
 	return -1
 }
 
+// containsString reports whether list contains s (case-sensitive).
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // eqFold is a fast ASCII case-insensitive string comparison.
 func eqFold(a, b string) bool {
 	if len(a) != len(b) {
@@ -462,6 +1052,54 @@ func containsFold(haystack, needle string) bool {
 	return false
 }
 
+// maxExcerpt is how many bytes of escaped input an error or warning
+// quotes before cutting it short with "...".
+const maxExcerpt = 120
+
+// excerpt prepares input for a %s verb in an error or warning: control
+// characters and invalid UTF-8 become \x00-style escapes and the result is
+// cut at maxExcerpt bytes, so binary data cannot flood the message.
+func excerpt(s string) string {
+	return escapeInput(s, false)
+}
+
+// quoteInput is excerpt for input shown in double quotes, in place of %q;
+// it also escapes '"' and '\'.
+func quoteInput(s string) string {
+	return `"` + escapeInput(s, true) + `"`
+}
+
+func escapeInput(s string, quoted bool) string {
+	plain := len(s) <= maxExcerpt
+	for i := 0; plain && i < len(s); i++ {
+		c := s[i]
+		plain = c >= ' ' && c < 0x7f && !(quoted && (c == '"' || c == '\\'))
+	}
+	if plain {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		var esc string
+		switch {
+		case r < ' ' || r == 0x7f || (r == utf8.RuneError && size == 1):
+			esc = fmt.Sprintf("\\x%02x", s[i])
+		case quoted && (r == '"' || r == '\\'):
+			esc = "\\" + string(r)
+		default:
+			esc = s[i : i+size]
+		}
+		if b.Len()+len(esc) > maxExcerpt {
+			b.WriteString("...")
+			break
+		}
+		b.WriteString(esc)
+		i += size
+	}
+	return b.String()
+}
+
 func (p *Parser) errorf(format string, args ...interface{}) error {
 	msg := fmt.Sprintf(format, args...)
 	return fmt.Errorf("http: parse error at line %d: %s", p.line, msg)
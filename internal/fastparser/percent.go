@@ -0,0 +1,136 @@
+package fastparser
+
+import "strings"
+
+const upperHex = "0123456789ABCDEF"
+
+// isUnreservedByte reports whether c is an RFC 3986 unreserved character.
+func isUnreservedByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// isPathByte reports whether c may appear unencoded in a path segment or
+// as its separator: a pchar of RFC 3986 §3.3 other than '%', or '/'.
+func isPathByte(c byte) bool {
+	return isUnreservedByte(c) || strings.IndexByte("!$&'()*+,;=:@/", c) >= 0
+}
+
+// PercentEncode encodes every byte of s but the RFC 3986 unreserved
+// characters as %XX, as curl's --data-urlencode does.
+func PercentEncode(s string) string {
+	return percentEncode(s, isUnreservedByte, false)
+}
+
+// PercentEncodePath encodes the bytes of s that may not appear in a URL
+// path, leaving '/', the other path characters and well-formed %XX escapes
+// alone, so that encoding an encoded path changes nothing.
+func PercentEncodePath(s string) string {
+	return percentEncode(s, isPathByte, true)
+}
+
+func percentEncode(s string, keep func(byte) bool, keepEscapes bool) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if !keep(s[i]) && !(keepEscapes && isEscapeAt(s, i)) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 2*n)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if keep(c) || keepEscapes && isEscapeAt(s, i) {
+			b.WriteByte(c)
+			continue
+		}
+		writeEscape(&b, c)
+	}
+	return b.String()
+}
+
+// writeEscape writes c to b as %XX with uppercase hex digits.
+func writeEscape(b *strings.Builder, c byte) {
+	b.WriteByte('%')
+	b.WriteByte(upperHex[c>>4])
+	b.WriteByte(upperHex[c&0xf])
+}
+
+// isEscapeAt reports whether s[i:] starts with a %XX escape.
+func isEscapeAt(s string, i int) bool {
+	return s[i] == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2])
+}
+
+// NormalizeEscapes decodes the %XX escapes of RFC 3986 unreserved
+// characters in s and uppercases the hex digits of the others, the
+// percent-encoding normalization of RFC 3986 §6.2.2.2. At a '%' not
+// starting an escape it stops, and bad is its offset; bad is -1 when s
+// was normalized in full.
+func NormalizeEscapes(s string) (normalized string, bad int) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, -1
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if !isEscapeAt(s, i) {
+			return "", i
+		}
+		if c := unhexDigit(s[i+1])<<4 | unhexDigit(s[i+2]); isUnreservedByte(c) {
+			b.WriteByte(c)
+		} else {
+			writeEscape(&b, c)
+		}
+		i += 2
+	}
+	return b.String(), -1
+}
+
+// PercentDecode decodes the %XX escapes in s, and with plus each '+' as a
+// space, as in form bodies. A '%' not starting an escape is kept when
+// lenient; otherwise decoding stops there and bad is its offset. bad is -1
+// when s decoded in full.
+func PercentDecode(s string, plus, lenient bool) (decoded string, bad int) {
+	if strings.IndexByte(s, '%') < 0 && (!plus || strings.IndexByte(s, '+') < 0) {
+		return s, -1
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '+' && plus:
+			b.WriteByte(' ')
+		case c != '%':
+			b.WriteByte(c)
+		case isEscapeAt(s, i):
+			b.WriteByte(unhexDigit(s[i+1])<<4 | unhexDigit(s[i+2]))
+			i += 2
+		case lenient:
+			b.WriteByte(c)
+		default:
+			return "", i
+		}
+	}
+	return b.String(), -1
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhexDigit(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	}
+	return c - 'a' + 10
+}
@@ -0,0 +1,56 @@
+package fastparser
+
+import "strings"
+
+// redactedValue stands in for a credential in errors and warnings.
+const redactedValue = "[redacted]"
+
+// IsSensitiveHeader reports whether the field named name carries a
+// credential, so that its value must not be echoed in an error or warning:
+// Authorization, Proxy-Authorization, Cookie, Set-Cookie or X-API-Key, in
+// any case.
+func IsSensitiveHeader(name string) bool {
+	for _, s := range [...]string{HeaderAuthorization, HeaderProxyAuthorization, HeaderCookie, HeaderSetCookie, "X-API-Key"} {
+		if strings.EqualFold(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaderLine returns a header line for an error or warning, with
+// everything after the name replaced by "[redacted]" when the name is a
+// sensitive one. The name runs to the first byte outside the token
+// grammar, so mangled lines such as "Authorization Bearer abc" and
+// "Cookie;sid=abc" are redacted as well.
+func RedactHeaderLine(line string) string {
+	name := strings.TrimLeft(line, " \t")
+	end := 0
+	for end < len(name) && isTchar(name[end]) {
+		end++
+	}
+	if end == len(name) || !IsSensitiveHeader(name[:end]) {
+		return line
+	}
+	return line[:len(line)-len(name)+end] + " " + redactedValue
+}
+
+// RedactUserinfo returns a request-target for an error or warning, with
+// the userinfo of its authority, if any, replaced by "[redacted]":
+// "http://u:pass@h/" becomes "http://[redacted]@h/". A target without a
+// scheme is taken to start with its authority, as in authority-form.
+func RedactUserinfo(target string) string {
+	start := 0
+	if i := strings.Index(target, "://"); i >= 0 {
+		start = i + 3
+	}
+	end := len(target)
+	if i := strings.IndexAny(target[start:], "/?#"); i >= 0 {
+		end = start + i
+	}
+	at := strings.LastIndexByte(target[start:end], '@')
+	if at < 0 {
+		return target
+	}
+	return target[:start] + redactedValue + target[start+at:]
+}
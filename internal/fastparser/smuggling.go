@@ -0,0 +1,297 @@
+package fastparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity ranks a finding. Values are mirrored by pkg/http.Severity.
+type Severity int
+
+// Severity levels, from least to most serious.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// Smuggling issue codes reported by AnalyzeSmuggling.
+const (
+	SmuggleCLTE              = "cl-te-conflict"
+	SmuggleTEObfuscated      = "te-obfuscated"
+	SmuggleTECasing          = "te-casing"
+	SmuggleTEFolded          = "te-folded"
+	SmuggleCLConflict        = "cl-conflict"
+	SmuggleCLDuplicate       = "cl-duplicate"
+	SmuggleCLInvalid         = "cl-invalid"
+	SmuggleSpaceBeforeColon  = "space-before-colon"
+	SmuggleChunkLeadingZeros = "chunk-leading-zeros"
+	SmuggleChunkAmbiguous    = "chunk-size-ambiguous"
+	SmuggleChunkOversize     = "chunk-size-oversize"
+	SmuggleEmbeddedRequest   = "embedded-request"
+)
+
+// SmugglingIssue is a single request-smuggling indicator.
+type SmugglingIssue struct {
+	Code     string
+	Severity Severity
+	Offset   int // byte offset into the analyzed data
+	Message  string
+}
+
+// knownCodings are the transfer-codings registered with IANA plus the
+// legacy x- aliases; anything else in Transfer-Encoding is suspicious.
+var knownCodings = map[string]bool{
+	"chunked": true, "compress": true, "deflate": true, "gzip": true,
+	"identity": true, "x-compress": true, "x-gzip": true,
+}
+
+// smuggleScan carries state across the header lines of one message.
+type smuggleScan struct {
+	issues []SmugglingIssue
+
+	teOffset, clOffset int // offset of first TE / CL header, -1 if absent
+	chunked            bool
+	clValue            string
+	lastWasTE          bool
+}
+
+func (s *smuggleScan) add(code string, sev Severity, off int, format string, args ...interface{}) {
+	s.issues = append(s.issues, SmugglingIssue{Code: code, Severity: sev, Offset: off, Message: fmt.Sprintf(format, args...)})
+}
+
+// AnalyzeSmuggling scans a raw HTTP/1.x message for framing ambiguities
+// that front-end and back-end servers are known to resolve differently.
+// It works on the wire bytes, reading lines the way the lenient parser does
+// (CRLF or bare LF), and never modifies data.
+func AnalyzeSmuggling(data []byte) []SmugglingIssue {
+	s := &smuggleScan{teOffset: -1, clOffset: -1}
+
+	pos := nextLine(data, 0) // skip the start line
+	for pos < len(data) {
+		off := pos
+		end := findLineEnd(data, pos)
+		if end < 0 {
+			end = len(data)
+		}
+		line := string(data[pos:end])
+		pos = skipLineEnding(data, end)
+		if line == "" {
+			break
+		}
+		s.headerLine(line, off)
+	}
+
+	if s.teOffset >= 0 && s.clOffset >= 0 {
+		off := s.teOffset
+		if s.clOffset > off {
+			off = s.clOffset
+		}
+		s.add(SmuggleCLTE, SeverityError, off,
+			"both Transfer-Encoding and Content-Length are present; servers that disagree on which wins can be desynchronized (CL.TE / TE.CL)")
+	}
+
+	if pos < len(data) {
+		if s.chunked {
+			s.scanChunks(data, pos)
+		}
+		s.scanEmbeddedRequests(data, pos)
+	}
+	return s.issues
+}
+
+// headerLine inspects one raw header line starting at off.
+func (s *smuggleScan) headerLine(line string, off int) {
+	if line[0] == ' ' || line[0] == '\t' {
+		// obs-fold continuation: a folded TE, or a TE hidden in the
+		// continuation of another header, is seen by some servers and not
+		// by others.
+		wasTE := s.lastWasTE
+		s.lastWasTE = false
+		if wasTE {
+			s.add(SmuggleTEFolded, SeverityError, off, "Transfer-Encoding value continues on a folded line")
+			return
+		}
+		if name, _, ok := strings.Cut(trimString(line), ":"); ok && isTEName(trimString(name)) {
+			s.add(SmuggleTEFolded, SeverityError, off, "Transfer-Encoding appears inside a folded continuation line")
+		}
+		return
+	}
+	s.lastWasTE = false
+
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		if isTEName(trimString(line)) {
+			s.add(SmuggleTEObfuscated, SeverityError, off, "Transfer-Encoding name is split from its value across lines")
+		}
+		return
+	}
+	rawName := line[:colon]
+	name := strings.TrimRight(rawName, " \t")
+	rawValue := line[colon+1:]
+	value := trimString(rawValue)
+
+	isTE, isCL := isTEName(name), eqFold(name, "Content-Length")
+	if !isTE && !isCL {
+		return
+	}
+	if name != rawName {
+		s.add(SmuggleSpaceBeforeColon, SeverityError, off+len(name),
+			"whitespace between %q and the colon; RFC 9112 §5.1 requires rejecting such messages", name)
+	}
+	if isTE {
+		s.lastWasTE = true
+		s.transferEncoding(name, rawValue, value, off)
+		return
+	}
+	s.contentLength(value, off)
+}
+
+func (s *smuggleScan) transferEncoding(name, rawValue, value string, off int) {
+	if s.teOffset < 0 {
+		s.teOffset = off
+	}
+	switch {
+	case !eqFold(name, "Transfer-Encoding"):
+		s.add(SmuggleTEObfuscated, SeverityError, off, "header %q imitates Transfer-Encoding", name)
+		return
+	case name != "Transfer-Encoding" && name != "transfer-encoding":
+		s.add(SmuggleTECasing, SeverityInfo, off, "unusual Transfer-Encoding name casing %q", name)
+	}
+
+	if strings.HasPrefix(rawValue, "\t") || strings.HasSuffix(rawValue, " ") || strings.HasSuffix(rawValue, "\t") {
+		s.add(SmuggleTEObfuscated, SeverityWarning, off, "Transfer-Encoding value %q has unusual surrounding whitespace", rawValue)
+	}
+	if value != strings.ToLower(value) {
+		s.add(SmuggleTECasing, SeverityInfo, off, "Transfer-Encoding value %q is not lowercase", value)
+	}
+
+	codings := splitComma(strings.ToLower(value))
+	for _, c := range codings {
+		c = trimString(c)
+		if knownCodings[c] {
+			continue
+		}
+		if strings.Contains(c, "chunked") {
+			s.add(SmuggleTEObfuscated, SeverityError, off, "transfer-coding %q resembles chunked but is not", c)
+		} else {
+			s.add(SmuggleTEObfuscated, SeverityWarning, off, "unknown transfer-coding %q", c)
+		}
+	}
+	last := trimString(codings[len(codings)-1])
+	if last == "chunked" {
+		s.chunked = true
+		return
+	}
+	for _, c := range codings {
+		if trimString(c) == "chunked" {
+			s.add(SmuggleTEObfuscated, SeverityError, off, "chunked is not the final transfer-coding")
+			break
+		}
+	}
+}
+
+func (s *smuggleScan) contentLength(value string, off int) {
+	for _, v := range splitComma(value) {
+		v = trimString(v)
+		if v == "" || strings.Trim(v, "0123456789") != "" {
+			s.add(SmuggleCLInvalid, SeverityError, off, "Content-Length %q is not a non-negative integer", v)
+			continue
+		}
+		switch {
+		case s.clOffset < 0:
+			s.clOffset, s.clValue = off, v
+		case v != s.clValue:
+			s.add(SmuggleCLConflict, SeverityError, off, "conflicting Content-Length values %s and %s", s.clValue, v)
+		default:
+			s.add(SmuggleCLDuplicate, SeverityInfo, off, "repeated Content-Length %s", v)
+		}
+	}
+}
+
+// scanChunks walks the chunk-size lines of a chunked body starting at pos.
+func (s *smuggleScan) scanChunks(data []byte, pos int) {
+	for pos < len(data) {
+		end := findLineEnd(data, pos)
+		if end < 0 {
+			return
+		}
+		line := string(data[pos:end])
+		sizeStr, ext, hasExt := strings.Cut(line, ";")
+		sizeStr = trimString(sizeStr)
+
+		if first, _, ok := strings.Cut(sizeStr, " "); ok {
+			s.add(SmuggleChunkAmbiguous, SeverityError, pos, "chunk-size line %q holds more than one value", line)
+			sizeStr = first
+		}
+		if hasExt {
+			if e := trimString(ext); e != "" && isHexDigit(e[0]) {
+				s.add(SmuggleChunkAmbiguous, SeverityWarning, pos, "chunk extension %q looks like a second chunk size", ext)
+			}
+		}
+		if len(sizeStr) > 1 && sizeStr[0] == '0' {
+			s.add(SmuggleChunkLeadingZeros, SeverityWarning, pos, "chunk size %q has leading zeros", sizeStr)
+		}
+
+		size, err := parseChunkSizeLine([]byte(sizeStr))
+		if err != nil {
+			if sizeStr != "" && strings.Trim(sizeStr, "0123456789abcdefABCDEF") == "" {
+				s.add(SmuggleChunkOversize, SeverityError, pos, "chunk size %q does not fit in an integer", sizeStr)
+			}
+			return
+		}
+		if size == 0 {
+			return
+		}
+		pos = skipLineEnding(data, end) + size
+		pos = skipLineEnding(data, pos)
+	}
+}
+
+// scanEmbeddedRequests reports body lines that look like a request line.
+func (s *smuggleScan) scanEmbeddedRequests(data []byte, pos int) {
+	for pos < len(data) {
+		end := findLineEnd(data, pos)
+		if end < 0 {
+			end = len(data)
+		}
+		if line := string(data[pos:end]); looksLikeRequestLine(line) {
+			s.add(SmuggleEmbeddedRequest, SeverityError, pos, "body contains what looks like a second request: %q", line)
+		}
+		if end == len(data) {
+			return
+		}
+		pos = skipLineEnding(data, end)
+	}
+}
+
+// looksLikeRequestLine matches "METHOD SP target SP HTTP/".
+func looksLikeRequestLine(line string) bool {
+	method, rest, ok := strings.Cut(line, " ")
+	if !ok || method == "" || len(method) > 20 {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		if method[i] < 'A' || method[i] > 'Z' {
+			return false
+		}
+	}
+	target, version, ok := strings.Cut(rest, " ")
+	return ok && target != "" && strings.HasPrefix(version, "HTTP/")
+}
+
+// isTEName matches Transfer-Encoding case-insensitively, also treating an
+// underscore in place of the hyphen as a match since some proxies
+// normalize it.
+func isTEName(name string) bool {
+	return eqFold(strings.ReplaceAll(name, "_", "-"), "Transfer-Encoding")
+}
+
+// nextLine returns the offset just past the line starting at pos.
+func nextLine(data []byte, pos int) int {
+	end := findLineEnd(data, pos)
+	if end < 0 {
+		return len(data)
+	}
+	return skipLineEnding(data, end)
+}
@@ -0,0 +1,170 @@
+package fastparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+// PortSwigger "HTTP request smuggling" research payloads.
+const (
+	pocCLTE = "POST / HTTP/1.1\r\n" +
+		"Host: vulnerable-website.com\r\n" +
+		"Content-Length: 13\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"0\r\n" +
+		"\r\n" +
+		"SMUGGLED"
+
+	pocTECL = "POST / HTTP/1.1\r\n" +
+		"Host: vulnerable-website.com\r\n" +
+		"Content-Length: 3\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"8\r\n" +
+		"SMUGGLED\r\n" +
+		"0\r\n" +
+		"\r\n"
+
+	pocEmbedded = "POST / HTTP/1.1\r\n" +
+		"Host: vulnerable-website.com\r\n" +
+		"Content-Length: 35\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"0\r\n" +
+		"\r\n" +
+		"GET /404 HTTP/1.1\r\n" +
+		"X-Ignore: X"
+)
+
+// findIssue returns the first issue with code, or nil.
+func findIssue(issues []SmugglingIssue, code string) *SmugglingIssue {
+	for i := range issues {
+		if issues[i].Code == code {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestAnalyzeSmuggling_PortSwiggerPoCs(t *testing.T) {
+	for name, poc := range map[string]string{"CL.TE": pocCLTE, "TE.CL": pocTECL} {
+		issues := AnalyzeSmuggling([]byte(poc))
+		is := findIssue(issues, SmuggleCLTE)
+		if is == nil {
+			t.Errorf("%s: no %s issue in %+v", name, SmuggleCLTE, issues)
+			continue
+		}
+		if is.Severity != SeverityError {
+			t.Errorf("%s: severity = %d, want SeverityError", name, is.Severity)
+		}
+		if want := bytes.Index([]byte(poc), []byte("Transfer-Encoding")); is.Offset != want {
+			t.Errorf("%s: offset = %d, want %d", name, is.Offset, want)
+		}
+	}
+}
+
+func TestAnalyzeSmuggling_EmbeddedRequest(t *testing.T) {
+	issues := AnalyzeSmuggling([]byte(pocEmbedded))
+	is := findIssue(issues, SmuggleEmbeddedRequest)
+	if is == nil {
+		t.Fatalf("no %s issue in %+v", SmuggleEmbeddedRequest, issues)
+	}
+	if want := bytes.Index([]byte(pocEmbedded), []byte("GET /404")); is.Offset != want {
+		t.Errorf("offset = %d, want %d", is.Offset, want)
+	}
+}
+
+// TestAnalyzeSmuggling_TEObfuscation covers the TE.TE variants from the
+// PortSwigger research.
+func TestAnalyzeSmuggling_TEObfuscation(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		code    string
+	}{
+		{"xchunked", "Transfer-Encoding: xchunked\r\n", SmuggleTEObfuscated},
+		{"trailing space", "Transfer-Encoding: chunked \r\n", SmuggleTEObfuscated},
+		{"tab before value", "Transfer-Encoding:\tchunked\r\n", SmuggleTEObfuscated},
+		{"unknown coding", "Transfer-Encoding: chunked\r\nTransfer-encoding: x\r\n", SmuggleTEObfuscated},
+		{"chunked not last", "Transfer-Encoding: chunked, identity\r\n", SmuggleTEObfuscated},
+		{"underscore name", "Transfer_Encoding: chunked\r\n", SmuggleTEObfuscated},
+		{"split across lines", "Transfer-Encoding\r\n: chunked\r\n", SmuggleTEObfuscated},
+		{"space before colon", "Transfer-Encoding : chunked\r\n", SmuggleSpaceBeforeColon},
+		{"name casing", "TrAnSfEr-EnCoDiNg: chunked\r\n", SmuggleTECasing},
+		{"value casing", "Transfer-Encoding: Chunked\r\n", SmuggleTECasing},
+		{"folded value", "Transfer-Encoding:\r\n chunked\r\n", SmuggleTEFolded},
+		{"hidden in fold", "X: X\r\n Transfer-Encoding: chunked\r\n", SmuggleTEFolded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := "POST / HTTP/1.1\r\nHost: x\r\n" + tt.headers + "\r\n"
+			if findIssue(AnalyzeSmuggling([]byte(msg)), tt.code) == nil {
+				t.Errorf("expected %s for %q; got %+v", tt.code, tt.headers, AnalyzeSmuggling([]byte(msg)))
+			}
+		})
+	}
+}
+
+func TestAnalyzeSmuggling_ContentLength(t *testing.T) {
+	tests := []struct {
+		headers string
+		code    string
+	}{
+		{"Content-Length: 5\r\nContent-Length: 6\r\n", SmuggleCLConflict},
+		{"Content-Length: 5, 6\r\n", SmuggleCLConflict},
+		{"Content-Length: 5\r\nContent-Length: 5\r\n", SmuggleCLDuplicate},
+		{"Content-Length: +5\r\n", SmuggleCLInvalid},
+		{"Content-Length : 5\r\n", SmuggleSpaceBeforeColon},
+	}
+	for _, tt := range tests {
+		msg := "POST / HTTP/1.1\r\nHost: x\r\n" + tt.headers + "\r\nhello"
+		if findIssue(AnalyzeSmuggling([]byte(msg)), tt.code) == nil {
+			t.Errorf("expected %s for %q; got %+v", tt.code, tt.headers, AnalyzeSmuggling([]byte(msg)))
+		}
+	}
+}
+
+func TestAnalyzeSmuggling_ChunkSizeLines(t *testing.T) {
+	tests := []struct {
+		body string
+		code string
+	}{
+		{"005\r\nhello\r\n0\r\n\r\n", SmuggleChunkLeadingZeros},
+		{"5 6\r\nhello\r\n0\r\n\r\n", SmuggleChunkAmbiguous},
+		{"5;6\r\nhello\r\n0\r\n\r\n", SmuggleChunkAmbiguous},
+		{"5\r\nhello\r\n00\r\n\r\n", SmuggleChunkLeadingZeros},
+	}
+	for _, tt := range tests {
+		msg := "POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n" + tt.body
+		if findIssue(AnalyzeSmuggling([]byte(msg)), tt.code) == nil {
+			t.Errorf("expected %s for body %q; got %+v", tt.code, tt.body, AnalyzeSmuggling([]byte(msg)))
+		}
+	}
+}
+
+// TestAnalyzeSmuggling_ChunkSizeOverflow is a regression test: a chunk
+// size past the range of int used to overflow into a negative offset.
+func TestAnalyzeSmuggling_ChunkSizeOverflow(t *testing.T) {
+	for _, size := range []string{"8000000000000000", "ffffffffffffffffffff"} {
+		msg := "POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n" + size + "\r\nhello\r\n0\r\n\r\n"
+		issue := findIssue(AnalyzeSmuggling([]byte(msg)), SmuggleChunkOversize)
+		if issue == nil || issue.Severity != SeverityError {
+			t.Errorf("size %s: issues = %+v", size, AnalyzeSmuggling([]byte(msg)))
+		}
+	}
+}
+
+func TestAnalyzeSmuggling_Clean(t *testing.T) {
+	for _, msg := range []string{
+		"GET / HTTP/1.1\r\nHost: x\r\n\r\n",
+		"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\n\r\nhello",
+		"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip, chunked\r\n\r\n5;name=v\r\nhello\r\n0\r\n\r\n",
+		"HTTP/1.1 200 OK\r\ntransfer-encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n",
+		"",
+	} {
+		if issues := AnalyzeSmuggling([]byte(msg)); len(issues) != 0 {
+			t.Errorf("AnalyzeSmuggling(%q) = %+v, want none", msg, issues)
+		}
+	}
+}
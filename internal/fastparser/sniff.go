@@ -0,0 +1,171 @@
+package fastparser
+
+import (
+	"bytes"
+	"strings"
+)
+
+// sniffLen is how many leading bytes of a body SniffContentType inspects,
+// as in the WHATWG MIME Sniffing Standard.
+const sniffLen = 512
+
+// magicTypes are the binary formats SniffContentType recognizes by their
+// leading bytes.
+var magicTypes = []struct {
+	prefix    string
+	mediaType string
+}{
+	{"\x89PNG\r\n\x1a\n", "image/png"},
+	{"\xff\xd8\xff", "image/jpeg"},
+	{"GIF87a", "image/gif"},
+	{"GIF89a", "image/gif"},
+	{"%PDF-", "application/pdf"},
+	{"PK\x03\x04", "application/zip"},
+	{"\x1f\x8b\x08", "application/gzip"},
+}
+
+// htmlTags are the tags, lowercased and without their "<", a body sniffed
+// as HTML may start with. A tag must be followed by a space or '>'.
+var htmlTags = []string{
+	"!doctype html", "html", "head", "body", "script", "iframe", "h1",
+	"div", "font", "table", "a", "style", "title", "b", "br", "p", "!--",
+}
+
+// SniffContentType returns the media type the first 512 bytes of body
+// evidently hold, following a practical subset of the WHATWG MIME Sniffing
+// Standard: the binary formats of magicTypes by their signatures, then,
+// after leading whitespace, "text/html", "text/xml" and "application/json"
+// by their markup, and otherwise "text/plain" or "application/octet-stream"
+// depending on whether control bytes occur. It returns "" for an empty
+// body.
+func SniffContentType(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > sniffLen {
+		body = body[:sniffLen]
+	}
+	for _, m := range magicTypes {
+		if bytes.HasPrefix(body, []byte(m.prefix)) {
+			return m.mediaType
+		}
+	}
+	text := bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	text = bytes.TrimLeft(text, " \t\r\n\f")
+	switch {
+	case bytes.HasPrefix(text, []byte("<?xml")):
+		return "text/xml"
+	case isHTMLStart(text):
+		return "text/html"
+	case isJSONStart(text):
+		return "application/json"
+	}
+	for _, c := range body {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != 0x1b || c == 0x7f {
+			return "application/octet-stream"
+		}
+	}
+	return "text/plain"
+}
+
+// isHTMLStart reports whether b starts with one of htmlTags.
+func isHTMLStart(b []byte) bool {
+	if len(b) < 2 || b[0] != '<' {
+		return false
+	}
+	for _, tag := range htmlTags {
+		n := 1 + len(tag)
+		if len(b) > n && strings.EqualFold(string(b[1:n]), tag) && (b[n] == ' ' || b[n] == '>') {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSONStart reports whether b starts like a JSON object or array: '{'
+// followed by a member name or '}', or '[' followed by a value or ']'.
+// Only the start is checked, so a body cut at 512 bytes still qualifies.
+func isJSONStart(b []byte) bool {
+	if len(b) == 0 || b[0] != '{' && b[0] != '[' {
+		return false
+	}
+	rest := bytes.TrimLeft(b[1:], " \t\r\n")
+	if len(rest) == 0 {
+		return false
+	}
+	if b[0] == '{' {
+		return rest[0] == '"' || rest[0] == '}'
+	}
+	return strings.IndexByte(`{["-0123456789tfn]`, rest[0]) >= 0
+}
+
+// ContentTypeMismatch reports whether sniffed, a SniffContentType result,
+// contradicts declared, a lowercased media type without parameters. A
+// generic declaration such as application/octet-stream, or text sniffed
+// under a type that is not known to be binary, is not a contradiction.
+func ContentTypeMismatch(declared, sniffed string) bool {
+	if declared == "" || sniffed == "" || declared == "application/octet-stream" {
+		return false
+	}
+	switch sniffed {
+	case "text/plain":
+		return isBinaryMediaType(declared)
+	case "application/octet-stream":
+		return isTextMediaType(declared)
+	case "application/json":
+		return !isJSONMediaType(declared)
+	case "text/html":
+		return declared != "text/html" && declared != "application/xhtml+xml"
+	case "text/xml":
+		return !isXMLMediaType(declared) && declared != "text/html"
+	}
+	// A binary format.
+	if declared == sniffed || sniffAliases[declared] == sniffed {
+		return false
+	}
+	// Many formats, such as .docx and .jar files, are zip archives.
+	if sniffed == "application/zip" && strings.HasPrefix(declared, "application/") && !isTextMediaType(declared) {
+		return false
+	}
+	return true
+}
+
+// sniffAliases maps nonstandard names of the magicTypes formats to the
+// name SniffContentType returns.
+var sniffAliases = map[string]string{
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"application/x-gzip":           "application/gzip",
+	"application/x-zip-compressed": "application/zip",
+	"application/x-pdf":            "application/pdf",
+}
+
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isXMLMediaType(mt string) bool {
+	return strings.HasSuffix(mt, "/xml") || strings.HasSuffix(mt, "+xml")
+}
+
+// isTextMediaType reports whether mt is a type whose bodies are text.
+func isTextMediaType(mt string) bool {
+	return strings.HasPrefix(mt, "text/") || isJSONMediaType(mt) || isXMLMediaType(mt) ||
+		mt == "application/javascript" || mt == "application/x-www-form-urlencoded"
+}
+
+// isBinaryMediaType reports whether mt is a type whose bodies are never
+// text.
+func isBinaryMediaType(mt string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mt, prefix) && mt != "image/svg+xml" {
+			return true
+		}
+	}
+	for _, m := range magicTypes {
+		if mt == m.mediaType {
+			return true
+		}
+	}
+	return sniffAliases[mt] != ""
+}
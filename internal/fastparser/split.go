@@ -0,0 +1,203 @@
+package fastparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrIncomplete is returned by SplitMessages when the data ends inside a
+// message.
+var ErrIncomplete = errors.New("http: incomplete message")
+
+// SplitMessages cuts a captured stream of requests (server false) or
+// responses (server true) into messages using framing rules only: the
+// start line, the header section, and Content-Length, chunked coding or,
+// for a response, the end of the data. Empty lines between messages are
+// skipped. Only a message whose framing is explicit and fully present is
+// followed by another: a response read to the end of the data is never
+// split, however much of its body looks like a message, and a message
+// with conflicting Content-Length values or, for a request, a
+// Transfer-Encoding not ending in chunked stops the split with an error.
+//
+// methods[i], when given, is the method of the request the i-th final
+// response answers, so that a HEAD response's Content-Length is not read
+// as a body and a 2xx answer to CONNECT ends the HTTP stream; interim 1xx
+// responses take no slot. Splitting also stops after a 101 response, as
+// what follows belongs to another protocol.
+//
+// A message cut short by the end of data is returned last, with
+// ErrIncomplete. Malformed framing stops the split with an error naming
+// the message's offset, after the messages before it.
+func SplitMessages(data []byte, server bool, methods []string) ([][]byte, error) {
+	var msgs [][]byte
+	final := 0
+	for pos := 0; ; {
+		for pos < len(data) && (data[pos] == '\r' || data[pos] == '\n') {
+			pos++
+		}
+		if pos == len(data) {
+			return msgs, nil
+		}
+		n, code, err := frameMessage(data[pos:], server, methodAt(methods, final))
+		if err == ErrIncomplete {
+			return append(msgs, data[pos:]), ErrIncomplete
+		}
+		if err != nil {
+			return msgs, fmt.Errorf("%w (message at offset %d)", err, pos)
+		}
+		msgs = append(msgs, data[pos:pos+n])
+		pos += n
+		if server && code >= 200 {
+			final++
+		}
+		if code == 101 || code >= 200 && code < 300 && methodAt(methods, final-1) == "CONNECT" {
+			return msgs, nil
+		}
+	}
+}
+
+func methodAt(methods []string, i int) string {
+	if i < len(methods) {
+		return methods[i]
+	}
+	return ""
+}
+
+// frameMessage returns the length of the message at the start of data and,
+// for a response, its status code. method is the request method a response
+// answers, or "".
+func frameMessage(data []byte, server bool, method string) (n, code int, err error) {
+	lineEnd := findLineEnd(data, 0)
+	if lineEnd < 0 {
+		return 0, 0, ErrIncomplete
+	}
+	startLine := data[:lineEnd]
+	if server {
+		if code, err = statusCodeOf(startLine); err != nil {
+			return 0, 0, err
+		}
+	} else if bytes.HasPrefix(startLine, []byte("HTTP/")) || bytes.IndexByte(startLine, ' ') <= 0 {
+		return 0, 0, WithKind(fmt.Errorf("http: expected a request line, got %s", excerpt(string(startLine))), ErrMalformedStartLine)
+	}
+
+	contentLength, chunked, coded := -1, false, false
+	pos := skipLineEnding(data, lineEnd)
+	for {
+		lineEnd = findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return 0, 0, ErrIncomplete
+		}
+		line := data[pos:lineEnd]
+		pos = skipLineEnding(data, lineEnd)
+		if len(line) == 0 {
+			break
+		}
+		colon := bytes.IndexByte(line, ':')
+		if colon < 0 {
+			continue
+		}
+		name, value := line[:colon], bytes.Trim(line[colon+1:], " \t")
+		switch {
+		case eqFoldBytes(name, "Content-Length"):
+			v, err := strconv.Atoi(string(value))
+			if err != nil || v < 0 {
+				return 0, 0, fmt.Errorf("http: invalid Content-Length %s", excerpt(string(value)))
+			}
+			if contentLength >= 0 && v != contentLength {
+				return 0, 0, fmt.Errorf("http: conflicting Content-Length values %d and %d", contentLength, v)
+			}
+			contentLength = v
+		case eqFoldBytes(name, "Transfer-Encoding"):
+			codings := bytes.Split(value, []byte(","))
+			chunked = eqFoldBytes(bytes.Trim(codings[len(codings)-1], " \t"), "chunked")
+			coded = true
+		}
+	}
+	// Transfer-Encoding overrides Content-Length (RFC 9112 §6.3). Without
+	// chunked last, a response runs to the end of the stream and a
+	// request cannot be framed at all.
+	if coded && !chunked {
+		if !server {
+			return 0, 0, fmt.Errorf("http: request Transfer-Encoding does not end in chunked")
+		}
+		contentLength = -1
+	}
+
+	switch {
+	case server && (code < 200 || code == 204 || code == 304 || method == "HEAD"):
+		return pos, code, nil
+	case chunked:
+		bodyLen, err := chunkedLength(data[pos:])
+		if err != nil {
+			return 0, 0, err
+		}
+		return pos + bodyLen, code, nil
+	case contentLength >= 0:
+		if contentLength > len(data)-pos {
+			return 0, 0, ErrIncomplete
+		}
+		return pos + contentLength, code, nil
+	case server:
+		return len(data), code, nil // read to the end of the stream
+	}
+	return pos, code, nil
+}
+
+// statusCodeOf parses the status code of a status line.
+func statusCodeOf(line []byte) (int, error) {
+	fields := bytes.Fields(line)
+	if len(fields) < 2 || !bytes.HasPrefix(fields[0], []byte("HTTP/")) || len(fields[1]) != 3 {
+		return 0, WithKind(fmt.Errorf("http: expected a status line, got %s", excerpt(string(line))), ErrMalformedStartLine)
+	}
+	code, err := strconv.Atoi(string(fields[1]))
+	if err != nil || code < 100 {
+		return 0, WithKind(fmt.Errorf("http: invalid status code %s", excerpt(string(fields[1]))), ErrInvalidStatusCode)
+	}
+	return code, nil
+}
+
+// chunkedLength returns the length of the chunked body at the start of
+// data, trailer section and final blank line included, or ErrIncomplete
+// if data ends first.
+func chunkedLength(data []byte) (int, error) {
+	pos := 0
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return 0, ErrIncomplete
+		}
+		size, err := parseChunkSizeLine(data[pos:lineEnd])
+		if err != nil {
+			return 0, err
+		}
+		pos = skipLineEnding(data, lineEnd)
+		if size == 0 {
+			break
+		}
+		if size > len(data)-pos {
+			return 0, ErrIncomplete
+		}
+		pos += size
+		switch {
+		case pos == len(data) || data[pos] == '\r' && pos+1 == len(data):
+			return 0, ErrIncomplete
+		case data[pos] == '\n' || data[pos] == '\r' && data[pos+1] == '\n':
+			pos = skipLineEnding(data, pos)
+		default:
+			return 0, chunkErrorf(false, "expected CRLF after chunk data, got %q", data[pos])
+		}
+	}
+	for {
+		lineEnd := findLineEnd(data, pos)
+		if lineEnd < 0 {
+			return 0, ErrIncomplete
+		}
+		empty := lineEnd == pos
+		pos = skipLineEnding(data, lineEnd)
+		if empty {
+			return pos, nil
+		}
+	}
+}
@@ -3,6 +3,8 @@ package fastparser
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"unicode/utf8"
 )
 
 // UnmarshalRequest parses data as an HTTP request.
@@ -21,6 +23,151 @@ func UnmarshalResponse(data []byte) (*Response, error) {
 	return p.ParseResponse()
 }
 
+// ParseRequestFunc parses data as an HTTP request without building a
+// Request, calling onHeader with each header field and onBody with the
+// body. Both receive slices aliasing data, so nothing is allocated per
+// field; only an obs-folded value is joined into a new buffer. Returning
+// false from onHeader stops the header callbacks, and from onBody the body
+// callbacks, but not the parse: the Host and framing headers are still
+// checked and the body still validated, so malformed input reports the
+// same errors as UnmarshalRequest. A Content-Length body is passed to
+// onBody once and a chunked body one chunk at a time. Either callback may
+// be nil.
+func ParseRequestFunc(data []byte, onHeader func(key, value []byte) bool, onBody func(chunk []byte) bool) error {
+	var p Parser
+	initParser(&p, data)
+	p.skipBlankLines()
+	method, target, _, err := p.parseRequestLine()
+	if err != nil {
+		return err
+	}
+	_, scheme, authority, err := p.parseRequestTarget(method, target)
+	if err != nil {
+		return err
+	}
+
+	// The common case, one Host and at most a plain "chunked", is checked
+	// as the headers go by. Anything more is checked as ParseRequest
+	// does, on the header list parsed a second time.
+	headerStart, headerLine := p.pos, p.line
+	chunked, cl, clSeen := false, int64(-1), false
+	hosts, codings, multiCoding := 0, 0, false
+	err = p.scanHeaders(func(key, value []byte, _ bool) {
+		switch {
+		case eqFoldBytes(key, HeaderHost):
+			hosts++
+		case eqFoldBytes(key, HeaderTransferEncoding):
+			codings++
+			multiCoding = multiCoding || bytes.IndexByte(value, ',') >= 0
+			chunked = chunked || containsFold(string(value), "chunked")
+		case eqFoldBytes(key, HeaderContentLength) && !clSeen:
+			clSeen = true
+			if n, err := strconv.ParseInt(string(bytes.TrimSpace(value)), 10, 64); err == nil {
+				cl = n
+			}
+		}
+		if onHeader != nil && !onHeader(key, value) {
+			onHeader = nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if hosts > 1 || scheme != "" || codings > 1 || multiCoding {
+		bodyStart, bodyLine := p.pos, p.line
+		p.pos, p.line = headerStart, headerLine
+		headers, err := p.parseHeaders()
+		if err != nil {
+			return err
+		}
+		p.pos, p.line = bodyStart, bodyLine
+		if headers, err = p.reconcileHost(headers, scheme, authority); err != nil {
+			return err
+		}
+		if chunked = isChunked(headers); chunked {
+			if err := checkChunkedLast(transferCodings(headers)); err != nil {
+				return p.errorf("%v", err)
+			}
+		}
+		cl = getContentLength(headers)
+	}
+
+	deliver := func(chunk []byte) {
+		if onBody != nil && !onBody(chunk) {
+			onBody = nil
+		}
+	}
+	switch rest := p.data[p.pos:]; {
+	case chunked:
+		_, _, err = walkChunks(rest, deliver)
+		return err
+	case cl >= 0:
+		if cl > int64(len(rest)) {
+			return p.failf(ErrBodyTruncated, "body truncated: expected %d bytes but only %d available", cl, len(rest))
+		}
+		if cl > 0 {
+			deliver(rest[:cl])
+		}
+	case len(rest) > 0:
+		deliver(rest)
+	}
+	return nil
+}
+
+// eqFoldBytes is eqFold for a byte slice and a string.
+func eqFoldBytes(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		cb, cs := b[i], s[i]
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if 'A' <= cs && cs <= 'Z' {
+			cs += 'a' - 'A'
+		}
+		if cb != cs {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalRequestWithLimits parses data as an HTTP request, enforcing limits.
+func UnmarshalRequestWithLimits(data []byte, limits Limits) (*Request, error) {
+	var p Parser
+	initParser(&p, data)
+	p.limits = limits
+	return p.ParseRequest()
+}
+
+// UnmarshalResponseWithLimits parses data as an HTTP response, enforcing limits.
+func UnmarshalResponseWithLimits(data []byte, limits Limits) (*Response, error) {
+	var p Parser
+	initParser(&p, data)
+	p.limits = limits
+	return p.ParseResponse()
+}
+
+// UnmarshalRequestWithStats parses data as an HTTP request and also returns
+// its byte statistics.
+func UnmarshalRequestWithStats(data []byte) (*Request, Stats, error) {
+	var p Parser
+	initParser(&p, data)
+	req, err := p.ParseRequest()
+	return req, p.stats, err
+}
+
+// UnmarshalResponseWithStats parses data as an HTTP response and also
+// returns its byte statistics.
+func UnmarshalResponseWithStats(data []byte) (*Response, Stats, error) {
+	var p Parser
+	initParser(&p, data)
+	resp, err := p.ParseResponse()
+	return resp, p.stats, err
+}
+
 // Unmarshal auto-detects whether data is a request or response and parses it.
 // If data starts with "HTTP/" it is treated as a response; otherwise a request.
 func Unmarshal(data []byte) (interface{}, error) {
@@ -30,9 +177,72 @@ func Unmarshal(data []byte) (interface{}, error) {
 	return UnmarshalRequest(data)
 }
 
+// MessageKind classifies input by what kind of protocol data it appears to be.
+type MessageKind int
+
+// Message kinds returned by DetectMessageKind.
+const (
+	KindRequest MessageKind = iota
+	KindResponse
+	KindHTTP2Preface
+	KindTLS
+	KindUnknownBinary
+)
+
+// http2Preface is the client connection preface of RFC 9113 §3.4.
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// binarySniffLen is how many leading bytes DetectMessageKind inspects when
+// deciding whether input is binary.
+const binarySniffLen = 64
+
+// DetectMessageKind classifies data by its leading bytes:
+//
+//   - the exact HTTP/2 connection preface → KindHTTP2Preface
+//   - a TLS handshake record header (0x16 0x03) → KindTLS
+//   - "HTTP/" → KindResponse
+//   - more than 10% control or invalid UTF-8 bytes among the first 64 → KindUnknownBinary
+//   - anything else → KindRequest
+func DetectMessageKind(data []byte) MessageKind {
+	switch {
+	case bytes.HasPrefix(data, http2Preface):
+		return KindHTTP2Preface
+	case len(data) >= 2 && data[0] == 0x16 && data[1] == 0x03:
+		return KindTLS
+	case bytes.HasPrefix(data, []byte("HTTP/")):
+		return KindResponse
+	case isMostlyBinary(data):
+		return KindUnknownBinary
+	}
+	return KindRequest
+}
+
+// isMostlyBinary reports whether more than 10% of the first binarySniffLen
+// bytes of data are control characters (other than CR, LF and HTAB) or
+// not valid UTF-8, so that a request line such as "GET /日本語 HTTP/1.1"
+// is text. A character cut off at the end of the window counts as valid.
+func isMostlyBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	nonPrintable := 0
+	for i := 0; i < len(data) && utf8.FullRune(data[i:]); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1,
+			r < 0x20 && r != '\r' && r != '\n' && r != '\t',
+			r >= 0x7f && r <= 0x9f:
+			nonPrintable += size
+		}
+		i += size
+	}
+	return nonPrintable*10 > len(data)
+}
+
 // DetectMessageType returns "request" or "response" based on the data prefix.
+// It is kept for compatibility; use DetectMessageKind for binary detection.
 func DetectMessageType(data []byte) string {
-	if bytes.HasPrefix(data, []byte("HTTP/")) {
+	if DetectMessageKind(data) == KindResponse {
 		return "response"
 	}
 	return "request"
@@ -0,0 +1,136 @@
+package http
+
+import "strings"
+
+// Sources reported in AnyResult.Source.
+const (
+	SourceCurl         = "curl"
+	SourceHTTPRequest  = "http-request"
+	SourceHTTPResponse = "http-response"
+	SourceUnknown      = "unknown"
+)
+
+// AnyResult is the result of ParseAny.
+type AnyResult struct {
+	// ParseResult is the result of the parser the input was routed to:
+	// ParseCurl for SourceCurl, UnmarshalLenient otherwise. Its Warnings
+	// are the merged list described on ParseAny.
+	*ParseResult
+
+	// Source names what the input was recognized as: SourceCurl,
+	// SourceHTTPRequest, SourceHTTPResponse or SourceUnknown.
+	Source string
+
+	// SourceConfidence grades the routing decision. A leading "curl" word
+	// is high confidence and a command starting with a flag or URL is
+	// medium; for HTTP input it is the lenient parser's detection
+	// confidence. It is a field of its own so as not to hide
+	// ParseResult.Confidence, which ParseCurl leaves at its zero value.
+	SourceConfidence Confidence
+}
+
+// ParseAny parses a pasted blob that may be a curl command, a raw HTTP
+// request or a raw HTTP response, as an editor or command-line tool
+// receives it. Blank lines and '#' comment lines before the first content
+// line are skipped for detection, and the input is routed by that line:
+//
+//   - a leading "curl" word, a leading flag ("-X POST ...") or a leading
+//     URL ("https://...", "api.example.com/v1") goes to ParseCurl;
+//   - anything else goes to UnmarshalLenient, and Source follows its
+//     DetectedAs. Input the lenient parser can only classify with low
+//     confidence, and binary, TLS or HTTP/2 input, is SourceUnknown.
+//
+// A bare URL is ambiguous: it is a valid curl command and not an HTTP
+// message, so it is parsed as curl and a warning notes the ambiguity.
+// Warnings starts with any such routing notes, followed by the chosen
+// parser's warnings. ParseAny never returns nil.
+func ParseAny(input string) *AnyResult {
+	line := firstContentLine(input)
+	if line == "" {
+		return &AnyResult{
+			ParseResult:      &ParseResult{Warnings: []string{"empty input"}, Partial: true},
+			Source:           SourceUnknown,
+			SourceConfidence: ConfidenceLow,
+		}
+	}
+
+	first := strings.Fields(line)[0]
+	var notes []string
+	conf := ConfidenceLow
+	switch {
+	case strings.EqualFold(first, "curl"):
+		conf = ConfidenceHigh
+	case strings.HasPrefix(first, "-"):
+		conf = ConfidenceMedium
+	case looksLikeURL(first):
+		conf = ConfidenceMedium
+		if !strings.ContainsAny(strings.TrimSpace(input), " \t\r\n") {
+			notes = append(notes, "ambiguous input: a bare URL could be a curl command or a request-target; parsed as curl")
+		}
+	}
+	if conf != ConfidenceLow {
+		res := ParseCurl(strings.TrimPrefix(input, "\ufeff"))
+		res.Warnings = append(notes, res.Warnings...)
+		return &AnyResult{ParseResult: res, Source: SourceCurl, SourceConfidence: conf}
+	}
+
+	data := []byte(input)
+	res := UnmarshalLenient(data)
+	out := &AnyResult{ParseResult: res, Source: SourceUnknown, SourceConfidence: res.Confidence}
+	switch err := notHTTP1Error(data); {
+	case err != nil:
+		res.Warnings = append([]string{strings.TrimPrefix(err.Error(), "http: ")}, res.Warnings...)
+	case res.Confidence == ConfidenceLow:
+		res.Warnings = append([]string{"input is not recognizably a curl command or an HTTP message"}, res.Warnings...)
+	case res.DetectedAs == MessageResponse:
+		out.Source = SourceHTTPResponse
+	default:
+		out.Source = SourceHTTPRequest
+	}
+	return out
+}
+
+// firstContentLine returns the first line of input that is neither blank
+// nor a '#' comment, trimmed of surrounding whitespace and a leading byte
+// order mark.
+func firstContentLine(input string) string {
+	input = strings.TrimPrefix(input, "\ufeff")
+	for input != "" {
+		var line string
+		line, input, _ = strings.Cut(input, "\n")
+		if line = strings.TrimSpace(line); line != "" && line[0] != '#' {
+			return line
+		}
+	}
+	return ""
+}
+
+// looksLikeURL reports whether s starts like a URL curl would accept: an
+// http or https scheme, or a dotted hostname (or localhost) with an
+// optional port and path.
+func looksLikeURL(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return true
+	}
+	host, _, _ := strings.Cut(lower, "/")
+	if name, port, ok := strings.Cut(host, ":"); ok {
+		if port == "" || strings.Trim(port, "0123456789") != "" {
+			return false
+		}
+		host = name
+	}
+	if host == "localhost" {
+		return true
+	}
+	if !strings.Contains(host, ".") || host[0] == '.' || host[len(host)-1] == '.' {
+		return false
+	}
+	for i := 0; i < len(host); i++ {
+		c := host[i]
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAny_Seeds(t *testing.T) {
+	for _, seed := range requestSeeds {
+		if r := ParseAny(string(seed)); r.Source != SourceHTTPRequest || r.Request == nil {
+			t.Errorf("%q: Source = %q, want %q", seed, r.Source, SourceHTTPRequest)
+		}
+	}
+	for _, seed := range responseSeeds {
+		if r := ParseAny(string(seed)); r.Source != SourceHTTPResponse || r.Response == nil {
+			t.Errorf("%q: Source = %q, want %q", seed, r.Source, SourceHTTPResponse)
+		}
+	}
+}
+
+func TestParseAny(t *testing.T) {
+	tests := []struct {
+		input      string
+		source     string
+		confidence Confidence
+		warning    string // a substring of Warnings[0], or "" for no warnings
+	}{
+		{"curl https://api.example.com/users", SourceCurl, ConfidenceHigh, ""},
+		{"\n# list users\nCURL -s https://api.example.com/users\n", SourceCurl, ConfidenceHigh, ""},
+		{"-X DELETE https://api.example.com/item/1", SourceCurl, ConfidenceMedium, ""},
+		{"api.example.com/v1/ping -H 'Accept: */*'", SourceCurl, ConfidenceMedium, ""},
+		{"https://api.example.com/ping", SourceCurl, ConfidenceMedium, "ambiguous input"},
+		{"localhost:8080/health\n", SourceCurl, ConfidenceMedium, "ambiguous input"},
+		{"\ufeffGET /x HTTP/1.1\r\nHost: example.com\r\n\r\n", SourceHTTPRequest, ConfidenceHigh, "byte order mark"},
+		{"get /x\nHost: example.com\n\n", SourceHTTPRequest, ConfidenceMedium, "missing HTTP version"},
+		{"HTTP/1.1 404\r\n\r\n", SourceHTTPResponse, ConfidenceHigh, ""},
+		{`"HTTP/1.1 204 No Content\r\n\r\n"`, SourceHTTPResponse, ConfidenceHigh, "string literal"},
+		{"hello there, world", SourceUnknown, ConfidenceLow, "not recognizably"},
+		{"\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03", SourceUnknown, ConfidenceLow, "TLS handshake"},
+		{"  \n\t\n", SourceUnknown, ConfidenceLow, "empty input"},
+	}
+	for _, tt := range tests {
+		r := ParseAny(tt.input)
+		if r == nil || r.ParseResult == nil {
+			t.Fatalf("%q: ParseAny returned nil", tt.input)
+		}
+		if r.Source != tt.source || r.SourceConfidence != tt.confidence {
+			t.Errorf("%q: Source, SourceConfidence = %q, %v; want %q, %v", tt.input, r.Source, r.SourceConfidence, tt.source, tt.confidence)
+		}
+		switch {
+		case tt.warning == "" && len(r.Warnings) != 0:
+			t.Errorf("%q: Warnings = %q, want none", tt.input, r.Warnings)
+		case tt.warning != "" && (len(r.Warnings) == 0 || !strings.Contains(r.Warnings[0], tt.warning)):
+			t.Errorf("%q: Warnings = %q, want the first to contain %q", tt.input, r.Warnings, tt.warning)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchOptions tunes ParseBatch and ParseBatchFunc.
+type BatchOptions struct {
+	// Workers is how many messages are parsed at once. Zero or negative
+	// means runtime.GOMAXPROCS(0).
+	Workers int
+
+	// Timeout bounds the time spent on one message; zero means no limit.
+	// A message that runs over gets a result with Partial set and a
+	// "parse timed out" warning in place of what the parser found. The
+	// parser cannot be interrupted, so it finishes in the background and
+	// its result is discarded. At most Workers such parses run on in the
+	// background; a worker that times out while that many do waits for
+	// one to finish before taking another input. Parses still running
+	// when ParseBatch returns are not waited for.
+	Timeout time.Duration
+
+	// Lenient is passed to UnmarshalLenientWithOptions for every message.
+	Lenient LenientOptions
+}
+
+// BatchSummary totals the results of ParseBatch or ParseBatchFunc.
+type BatchSummary struct {
+	Messages  int // inputs parsed
+	Requests  int // results with a Request
+	Responses int // results with a Response
+	Partial   int // results with Partial set, including timeouts
+	TimedOut  int // inputs that ran over BatchOptions.Timeout
+	Warnings  int // warnings raised, including those aggregation suppressed
+
+	// WarningsByKind counts the warnings by kind, keyed by the label the
+	// lenient parser uses when it summarizes suppressed repeats, such as
+	// "malformed header (no colon)". Timeouts are not counted here.
+	WarningsByKind map[string]int
+}
+
+func (s *BatchSummary) add(r *ParseResult, counts map[string]int, timedOut bool) {
+	s.Messages++
+	if r.Request != nil {
+		s.Requests++
+	}
+	if r.Response != nil {
+		s.Responses++
+	}
+	if r.Partial {
+		s.Partial++
+	}
+	if timedOut {
+		s.TimedOut++
+	}
+	for kind, n := range counts {
+		if s.WarningsByKind == nil {
+			s.WarningsByKind = make(map[string]int)
+		}
+		s.WarningsByKind[kind] += n
+		s.Warnings += n
+	}
+}
+
+// ParseBatch parses each input with UnmarshalLenientWithOptions, spread
+// over opts.Workers goroutines, and returns the results in input order
+// with a summary of them. Each result is the one UnmarshalLenient gives
+// for that input alone, except for inputs that run over opts.Timeout.
+// Messages share no mutable state: the interning tables are read-only,
+// or copied on write by RegisterMethods.
+func ParseBatch(inputs [][]byte, opts BatchOptions) ([]*ParseResult, BatchSummary) {
+	results := make([]*ParseResult, len(inputs))
+	i := 0
+	next := func() ([]byte, bool) {
+		if i == len(inputs) {
+			return nil, false
+		}
+		i++
+		return inputs[i-1], true
+	}
+	summary := ParseBatchFunc(next, opts, func(index int, r *ParseResult) { results[index] = r })
+	return results, summary
+}
+
+// ParseBatchFunc is ParseBatch for inputs too many to hold their results
+// at once. It calls next for each input until next reports false, and fn
+// with each result and the index of its input, in input order. next is
+// called from a goroutine of its own and fn from the caller's; while fn
+// runs, parsing continues but at most 2*Workers results wait to be
+// delivered.
+func ParseBatchFunc(next func() ([]byte, bool), opts BatchOptions, fn func(index int, r *ParseResult)) BatchSummary {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type job struct {
+		index int
+		data  []byte
+	}
+	type parsed struct {
+		index    int
+		result   *ParseResult
+		counts   map[string]int
+		timedOut bool
+	}
+
+	jobs := make(chan job)
+	done := make(chan parsed, workers)
+	// stragglers bounds the timed-out parses still running.
+	stragglers := make(chan struct{}, workers)
+	// slots bounds the inputs handed out but not yet delivered to fn.
+	slots := make(chan struct{}, 2*workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r, counts, timedOut := parseBatchMessage(j.data, opts, stragglers)
+				done <- parsed{j.index, r, counts, timedOut}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; ; i++ {
+			data, ok := next()
+			if !ok {
+				break
+			}
+			slots <- struct{}{}
+			jobs <- job{i, data}
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	var summary BatchSummary
+	pending := make(map[int]parsed)
+	want := 0
+	for p := range done {
+		pending[p.index] = p
+		for {
+			p, ok := pending[want]
+			if !ok {
+				break
+			}
+			delete(pending, want)
+			summary.add(p.result, p.counts, p.timedOut)
+			fn(want, p.result)
+			want++
+			<-slots
+		}
+	}
+	return summary
+}
+
+// parseBatchMessage parses data as UnmarshalLenientWithOptions does,
+// giving up after opts.Timeout. A parse it gives up on holds a slot of
+// stragglers until it finishes; when none is free, parseBatchMessage
+// waits for one.
+func parseBatchMessage(data []byte, opts BatchOptions, stragglers chan struct{}) (*ParseResult, map[string]int, bool) {
+	if opts.Timeout <= 0 {
+		r, counts := batchParse(data, opts.Lenient)
+		return r, counts, false
+	}
+	type parsed struct {
+		result *ParseResult
+		counts map[string]int
+	}
+	ch := make(chan parsed, 1)
+	finished := make(chan struct{})
+	parse := batchParse
+	go func() {
+		r, counts := parse(data, opts.Lenient)
+		ch <- parsed{r, counts}
+		close(finished)
+	}()
+	timer := time.NewTimer(opts.Timeout)
+	defer timer.Stop()
+	select {
+	case p := <-ch:
+		return p.result, p.counts, false
+	case <-timer.C:
+		select {
+		case stragglers <- struct{}{}:
+			go func() {
+				<-finished
+				<-stragglers
+			}()
+		case <-finished:
+		}
+		return &ParseResult{
+			Partial:  true,
+			Warnings: []string{fmt.Sprintf("parse timed out after %v", opts.Timeout)},
+			Raw:      data,
+		}, nil, true
+	}
+}
+
+// batchParse parses one message of a batch and counts its warnings by
+// kind. Tests replace it to inject a slow parse.
+var batchParse = unmarshalLenient
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchInputs returns n captures of assorted shapes: requests, responses,
+// truncated bodies and malformed headers.
+func batchInputs(n int) [][]byte {
+	inputs := make([][]byte, n)
+	for i := range inputs {
+		switch i % 4 {
+		case 0:
+			inputs[i] = []byte(fmt.Sprintf("GET /items/%d HTTP/1.1\r\nHost: example.com\r\n\r\n", i))
+		case 1:
+			inputs[i] = []byte(fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%d", len(fmt.Sprint(i)), i))
+		case 2:
+			inputs[i] = []byte(fmt.Sprintf("POST /items HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\nid=%d", i))
+		default:
+			inputs[i] = []byte(fmt.Sprintf("GET /%d HTTP/1.1\r\nHost: example.com\r\nno colon here\r\nneither here\r\n\r\n", i))
+		}
+	}
+	return inputs
+}
+
+func TestParseBatch_MatchesSerial(t *testing.T) {
+	inputs := batchInputs(1000)
+	results, summary := ParseBatch(inputs, BatchOptions{Workers: 8})
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results, want %d", len(results), len(inputs))
+	}
+	var want BatchSummary
+	for i, in := range inputs {
+		serial := UnmarshalLenient(in)
+		if !reflect.DeepEqual(results[i], serial) {
+			t.Fatalf("result %d differs from UnmarshalLenient:\n got %+v\nwant %+v", i, results[i], serial)
+		}
+		want.Messages++
+		if serial.Request != nil {
+			want.Requests++
+		}
+		if serial.Response != nil {
+			want.Responses++
+		}
+		if serial.Partial {
+			want.Partial++
+		}
+	}
+	if summary.Messages != want.Messages || summary.Requests != want.Requests ||
+		summary.Responses != want.Responses || summary.Partial != want.Partial || summary.TimedOut != 0 {
+		t.Errorf("summary = %+v, want counts %+v", summary, want)
+	}
+	if got := summary.WarningsByKind["malformed header (no colon)"]; got != 500 {
+		t.Errorf("malformed header warnings = %d, want 500", got)
+	}
+	total := 0
+	for _, n := range summary.WarningsByKind {
+		total += n
+	}
+	if summary.Warnings != total {
+		t.Errorf("Warnings = %d, want the sum of WarningsByKind, %d", summary.Warnings, total)
+	}
+}
+
+func TestParseBatchFunc_Order(t *testing.T) {
+	inputs := batchInputs(200)
+	i := 0
+	next := func() ([]byte, bool) {
+		if i == len(inputs) {
+			return nil, false
+		}
+		i++
+		return inputs[i-1], true
+	}
+	want := 0
+	summary := ParseBatchFunc(next, BatchOptions{Workers: 4}, func(index int, r *ParseResult) {
+		if index != want {
+			t.Fatalf("got index %d, want %d", index, want)
+		}
+		if string(r.Raw) != string(inputs[index]) {
+			t.Fatalf("result %d is for another input", index)
+		}
+		want++
+	})
+	if want != len(inputs) || summary.Messages != len(inputs) {
+		t.Errorf("delivered %d results, summary %d, want %d", want, summary.Messages, len(inputs))
+	}
+}
+
+func TestParseBatch_Empty(t *testing.T) {
+	results, summary := ParseBatch(nil, BatchOptions{})
+	if len(results) != 0 || summary.Messages != 0 || summary.WarningsByKind != nil {
+		t.Errorf("ParseBatch(nil) = %v, %+v", results, summary)
+	}
+}
+
+func TestParseBatch_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	parse := batchParse
+	batchParse = func(data []byte, opts LenientOptions) (*ParseResult, map[string]int) {
+		if strings.Contains(string(data), "slow") {
+			<-release
+		}
+		return parse(data, opts)
+	}
+	defer func() { batchParse = parse }()
+
+	inputs := [][]byte{
+		[]byte("GET /a HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("GET /slow HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("GET /b HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+	}
+	results, summary := ParseBatch(inputs, BatchOptions{Workers: 2, Timeout: 50 * time.Millisecond})
+	if results[0].Request == nil || results[2].Request == nil {
+		t.Errorf("the fast inputs were not parsed: %+v", results)
+	}
+	r := results[1]
+	if r.Request != nil || !r.Partial || len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "timed out") {
+		t.Errorf("timed-out result = %+v", r)
+	}
+	if summary.TimedOut != 1 || summary.Partial != 1 || summary.Requests != 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestParseBatch_TimeoutStragglers(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	running, most := 0, 0
+	parse := batchParse
+	batchParse = func(data []byte, opts LenientOptions) (*ParseResult, map[string]int) {
+		if strings.Contains(string(data), "slow") {
+			mu.Lock()
+			running++
+			most = max(most, running)
+			mu.Unlock()
+			<-release
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}
+		return parse(data, opts)
+	}
+	defer func() { batchParse = parse }()
+
+	inputs := make([][]byte, 6)
+	for i := range inputs {
+		inputs[i] = []byte("GET /slow HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	}
+	finished := make(chan BatchSummary)
+	go func() {
+		_, summary := ParseBatch(inputs, BatchOptions{Workers: 1, Timeout: 10 * time.Millisecond})
+		finished <- summary
+	}()
+	select {
+	case <-finished:
+		t.Fatal("ParseBatch returned with more stragglers than workers")
+	case <-time.After(200 * time.Millisecond):
+	}
+	mu.Lock()
+	if most != 2 {
+		t.Errorf("%d slow parses ran at once, want 2: one straggler and one timing out", most)
+	}
+	mu.Unlock()
+	close(release)
+	if summary := <-finished; summary.Messages != len(inputs) || summary.TimedOut < 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
@@ -0,0 +1,451 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDeltaSeconds is the value delta-seconds saturate at (RFC 9111 §1.2.2).
+const maxDeltaSeconds = 2147483648
+
+// CacheControl holds the directives of a Cache-Control header
+// (RFC 9111 §5.2). Integer directives are in seconds and are -1 when the
+// directive is absent or its value is invalid.
+type CacheControl struct {
+	MaxAge               int
+	SMaxAge              int
+	StaleWhileRevalidate int
+
+	NoCache        bool
+	NoStore        bool
+	Private        bool
+	Public         bool
+	MustRevalidate bool
+
+	// Extensions holds any other directive, keyed by lowercase name. The
+	// value is unquoted; valueless directives map to "".
+	Extensions map[string]string
+}
+
+// ParseCacheControl parses a Cache-Control field value. Directive names are
+// case-insensitive and values may be tokens or quoted strings. When a
+// directive appears more than once the first occurrence wins. To parse a
+// response with several Cache-Control lines, join them with ",".
+func ParseCacheControl(value string) CacheControl {
+	cc := CacheControl{MaxAge: -1, SMaxAge: -1, StaleWhileRevalidate: -1}
+	seen := make(map[string]bool)
+	for _, d := range splitDirectives(value) {
+		if d.name == "" || seen[d.name] {
+			continue
+		}
+		seen[d.name] = true
+		switch d.name {
+		case "max-age":
+			cc.MaxAge = parseDeltaSeconds(d.value)
+		case "s-maxage":
+			cc.SMaxAge = parseDeltaSeconds(d.value)
+		case "stale-while-revalidate":
+			cc.StaleWhileRevalidate = parseDeltaSeconds(d.value)
+		case "no-cache":
+			cc.NoCache = true
+		case "no-store":
+			cc.NoStore = true
+		case "private":
+			cc.Private = true
+		case "public":
+			cc.Public = true
+		case "must-revalidate":
+			cc.MustRevalidate = true
+		default:
+			if cc.Extensions == nil {
+				cc.Extensions = make(map[string]string)
+			}
+			cc.Extensions[d.name] = d.value
+		}
+	}
+	return cc
+}
+
+type directive struct {
+	name, value string
+}
+
+// splitDirectives splits a comma-separated list of name[=value] directives,
+// honoring quoted strings so that commas inside quotes do not split.
+func splitDirectives(s string) []directive {
+	var out []directive
+	for i := 0; i < len(s); {
+		for i < len(s) && (s[i] == ',' || s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] != ',' && s[i] != '=' {
+			i++
+		}
+		d := directive{name: strings.ToLower(strings.TrimSpace(s[start:i]))}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+				i++
+			}
+			if i < len(s) && s[i] == '"' {
+				var b strings.Builder
+				for i++; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					b.WriteByte(s[i])
+				}
+				i++ // closing quote
+				d.value = b.String()
+				for i < len(s) && s[i] != ',' {
+					i++
+				}
+			} else {
+				start = i
+				for i < len(s) && s[i] != ',' {
+					i++
+				}
+				d.value = strings.TrimSpace(s[start:i])
+			}
+		}
+		if d.name != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// parseDeltaSeconds parses a non-negative integer number of seconds,
+// saturating at 2^31. It returns -1 if s is not a valid delta-seconds.
+func parseDeltaSeconds(s string) int {
+	if s == "" {
+		return -1
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return -1
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n > maxDeltaSeconds {
+		return maxDeltaSeconds
+	}
+	return int(n)
+}
+
+// ETagMatch reports whether etag matches the If-None-Match (or If-Match)
+// field value ifNoneMatch. With weak set, the weak comparison of
+// RFC 9110 §8.8.3.2 is used (W/ prefixes are ignored); otherwise the strong
+// comparison requires both tags to be strong and identical. A "*" list
+// matches any non-empty etag.
+func ETagMatch(etag, ifNoneMatch string, weak bool) bool {
+	etag = strings.TrimSpace(etag)
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etagWeak, etagOpaque := splitETag(etag)
+	if etagWeak && !weak {
+		return false
+	}
+	for _, tag := range splitETagList(ifNoneMatch) {
+		w, opaque := splitETag(tag)
+		if w && !weak {
+			continue
+		}
+		if opaque == etagOpaque {
+			return true
+		}
+	}
+	return false
+}
+
+// splitETag separates the weak indicator from the opaque-tag.
+func splitETag(tag string) (weak bool, opaque string) {
+	if strings.HasPrefix(tag, "W/") {
+		return true, tag[2:]
+	}
+	return false, tag
+}
+
+// splitETagList splits a comma-separated entity-tag list. Commas are legal
+// inside opaque-tags, so quoted sections are kept intact.
+func splitETagList(s string) []string {
+	var out []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				if t := strings.TrimSpace(s[start:i]); t != "" {
+					out = append(out, t)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if t := strings.TrimSpace(s[start:]); t != "" {
+		out = append(out, t)
+	}
+	return out
+}
+
+// CacheKey returns a stable cache key for the request: the method, the
+// lowercased Host, and the path with its query parameters sorted, e.g.
+// "GET example.com/search?a=1&b=2". Any fragment is dropped.
+func (r *Request) CacheKey() string {
+	path := r.Path
+	if i := strings.IndexByte(path, '#'); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		params := strings.Split(path[i+1:], "&")
+		sort.Strings(params)
+		path = path[:i+1] + strings.Join(params, "&")
+	}
+	return r.Method + " " + strings.ToLower(r.Headers.Get("Host")) + path
+}
+
+// FreshnessLifetime computes the response's freshness lifetime using the
+// precedence of RFC 9111 §4.2.1: s-maxage, then max-age, then Expires minus
+// Date (or minus now when Date is absent). An invalid Expires yields a
+// zero lifetime. ok is false when none of these are present; heuristic
+// freshness is left to the caller.
+func FreshnessLifetime(resp *Response) (lifetime time.Duration, ok bool) {
+	return freshnessLifetime(resp, time.Now())
+}
+
+func freshnessLifetime(resp *Response, now time.Time) (time.Duration, bool) {
+	cc := ParseCacheControl(strings.Join(resp.Headers.Values("Cache-Control"), ","))
+	if cc.SMaxAge >= 0 {
+		return time.Duration(cc.SMaxAge) * time.Second, true
+	}
+	if cc.MaxAge >= 0 {
+		return time.Duration(cc.MaxAge) * time.Second, true
+	}
+	if !resp.Headers.Has("Expires") {
+		return 0, false
+	}
+	expires, ok := resp.Expires()
+	if !ok {
+		return 0, true
+	}
+	if date, ok := resp.Date(); ok {
+		now = date
+	}
+	if d := expires.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// VaryHeaders returns the field names listed by the response's Vary
+// headers, lowercased and without duplicates, in order of first
+// appearance. If any member is "*", the response varies on more than the
+// request's headers and VaryHeaders returns just ["*"].
+func (r *Response) VaryHeaders() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, v := range r.Headers.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "*" {
+				return []string{"*"}
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// SecondaryCacheKey returns a stable key for the request's values of the
+// headers named in vary, as returned by VaryHeaders, to store a response
+// alongside its CacheKey (RFC 9111 §4.1). Two requests get the same key
+// when a cache may serve one's stored response to the other. Each header
+// contributes a "name: value" line, sorted by name, with its field lines
+// combined and list members trimmed; Accept-Encoding members are
+// lowercased and sorted, and Accept-Language is lowercased. A header the
+// request lacks contributes its bare name, so it matches only another
+// request without it. Vary "*" matches no request, so the result is "".
+func SecondaryCacheKey(req *Request, vary []string) string {
+	names := make([]string, 0, len(vary))
+	seen := make(map[string]bool)
+	for _, name := range vary {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "*" {
+			return ""
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		values := req.Headers.Values(name)
+		if len(values) == 0 {
+			lines[i] = name
+			continue
+		}
+		lines[i] = name + ": " + normalizeVaryValue(name, values)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeVaryValue combines the field lines of the request header name
+// into the form SecondaryCacheKey compares.
+func normalizeVaryValue(name string, values []string) string {
+	var members []string
+	for _, v := range values {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+	}
+	switch name {
+	case "accept-encoding":
+		for i, m := range members {
+			members[i] = strings.ToLower(strings.ReplaceAll(m, " ", ""))
+		}
+		sort.Strings(members)
+	case "accept-language":
+		for i, m := range members {
+			members[i] = strings.ToLower(strings.ReplaceAll(m, " ", ""))
+		}
+	}
+	return strings.Join(members, ",")
+}
+
+// heuristicallyCacheable lists the status codes a cache may store without
+// explicit freshness information (RFC 9110 §15.1).
+var heuristicallyCacheable = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true,
+	308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// ResponseIsCacheable reports whether a shared cache may store resp as the
+// response to req, applying RFC 9111 §3: the method must be GET or HEAD,
+// the status code final and between 200 and 599, and no-store absent from
+// both messages. private forbids storing, and a request with Authorization
+// needs public, s-maxage or must-revalidate in the response (§3.5).
+// Finally the response needs explicit freshness (max-age, s-maxage,
+// Expires or public) or a status code that is cacheable by default.
+// When it is not cacheable, the reasons say why, e.g.
+// "response has Cache-Control: no-store". A nil req or resp is not
+// cacheable.
+func ResponseIsCacheable(req *Request, resp *Response) (bool, []string) {
+	switch {
+	case req == nil:
+		return false, []string{"no request"}
+	case resp == nil:
+		return false, []string{"no response"}
+	}
+	var reasons []string
+	if req.Method != MethodGet && req.Method != MethodHead {
+		reasons = append(reasons, "method "+req.Method+" is not cacheable")
+	}
+	switch code := resp.StatusCode; {
+	case code >= 100 && code < 200:
+		reasons = append(reasons, "status "+strconv.Itoa(code)+" is not final")
+	case code < 200 || code > 599:
+		reasons = append(reasons, "status "+strconv.Itoa(code)+" is not understood")
+	}
+	reqCC := ParseCacheControl(strings.Join(req.Headers.Values("Cache-Control"), ","))
+	if reqCC.NoStore {
+		reasons = append(reasons, "request has Cache-Control: no-store")
+	}
+	cc := ParseCacheControl(strings.Join(resp.Headers.Values("Cache-Control"), ","))
+	if cc.NoStore {
+		reasons = append(reasons, "response has Cache-Control: no-store")
+	}
+	if cc.Private {
+		reasons = append(reasons, "response has Cache-Control: private")
+	}
+	if req.Headers.Has("Authorization") && !cc.Public && cc.SMaxAge < 0 && !cc.MustRevalidate {
+		reasons = append(reasons, "request has Authorization and the response lacks public, s-maxage and must-revalidate")
+	}
+	explicit := cc.Public || cc.MaxAge >= 0 || cc.SMaxAge >= 0 || resp.Headers.Has("Expires")
+	if !explicit && !heuristicallyCacheable[resp.StatusCode] {
+		reasons = append(reasons, "response has no explicit freshness and status "+strconv.Itoa(resp.StatusCode)+" is not cacheable by default")
+	}
+	return len(reasons) == 0, reasons
+}
+
+// StrongETag returns a strong entity tag for body: the first 128 bits of
+// its SHA-256 digest, hex-encoded and quoted, so identical bodies get
+// identical tags.
+func StrongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// WeakETag returns a weak entity tag derived from seed as StrongETag
+// derives one from a body, so WeakETag("v1") is
+// W/"3bfc269594ef649228e9a74bab00f042". Seed it with a version or
+// revision for a representation whose bytes may change without its
+// meaning changing.
+func WeakETag(seed string) string {
+	return "W/" + StrongETag([]byte(seed))
+}
+
+// notModifiedDropped lists the representation metadata a 304 response
+// omits (RFC 9110 §15.4.5); its Content-Length would otherwise have to be
+// that of the body it stands for.
+var notModifiedDropped = []string{
+	HeaderContentLength, HeaderTransferEncoding, HeaderContentType,
+	HeaderContentEncoding, HeaderContentLanguage, HeaderContentRange,
+}
+
+// SetValidators sets the ETag header of r to StrongETag(body) and, unless
+// modTime is zero, Last-Modified to modTime as an IMF-fixdate, replacing
+// any r already has. If r is a 2xx response to a GET or HEAD req, the
+// preconditions of req are then evaluated in the order of RFC 9110
+// §13.2.2: If-None-Match, when present, decides alone by weak comparison,
+// and only without it does If-Modified-Since count, matching when modTime,
+// to the second, is no later than it. A match turns r into 304 Not
+// Modified: the body is cleared and Content-Length, Transfer-Encoding and
+// the Content-Type, Content-Encoding, Content-Language and Content-Range
+// headers are removed, while ETag, Last-Modified, Cache-Control, Expires,
+// Vary and any other header are kept. SetValidators reports whether r
+// became 304; req may be nil, in which case it only sets the validators.
+func (r *Response) SetValidators(req *Request, body []byte, modTime time.Time) bool {
+	etag := StrongETag(body)
+	r.Headers.Set(HeaderETag, etag)
+	if !modTime.IsZero() {
+		r.Headers.Set(HeaderLastModified, FormatHTTPDate(modTime))
+	}
+	if req == nil || (req.Method != MethodGet && req.Method != MethodHead) ||
+		r.StatusCode < 200 || r.StatusCode > 299 || !notModified(req, etag, modTime) {
+		return false
+	}
+	r.StatusCode, r.Reason = 304, "Not Modified"
+	r.Body, r.RawBody = nil, nil
+	for _, name := range notModifiedDropped {
+		r.Headers.Del(name)
+	}
+	return true
+}
+
+// notModified reports whether the If-None-Match or, failing that, the
+// If-Modified-Since precondition of req calls for a 304.
+func notModified(req *Request, etag string, modTime time.Time) bool {
+	if inm := req.Headers.Values(HeaderIfNoneMatch); len(inm) > 0 {
+		return ETagMatch(etag, strings.Join(inm, ","), true)
+	}
+	since, ok := req.IfModifiedSince()
+	return ok && !modTime.IsZero() && !modTime.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,376 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	cc := ParseCacheControl(`public, max-age=3600, s-maxage="600", must-revalidate`)
+	if !cc.Public || !cc.MustRevalidate || cc.Private || cc.NoStore || cc.NoCache {
+		t.Errorf("flags = %+v", cc)
+	}
+	if cc.MaxAge != 3600 {
+		t.Errorf("MaxAge = %d, want 3600", cc.MaxAge)
+	}
+	if cc.SMaxAge != 600 {
+		t.Errorf("SMaxAge = %d, want 600", cc.SMaxAge)
+	}
+	if cc.StaleWhileRevalidate != -1 {
+		t.Errorf("StaleWhileRevalidate = %d, want -1", cc.StaleWhileRevalidate)
+	}
+	if cc.Extensions != nil {
+		t.Errorf("Extensions = %v, want nil", cc.Extensions)
+	}
+}
+
+// TestParseCacheControl_Extensions uses the extension examples from
+// RFC 9111 §5.2.3.
+func TestParseCacheControl_Extensions(t *testing.T) {
+	cc := ParseCacheControl(`private, community="UCI", Foo=`)
+	if !cc.Private {
+		t.Error("Private = false, want true")
+	}
+	if got := cc.Extensions["community"]; got != "UCI" {
+		t.Errorf(`Extensions["community"] = %q, want "UCI"`, got)
+	}
+	if got, ok := cc.Extensions["foo"]; !ok || got != "" {
+		t.Errorf(`Extensions["foo"] = %q, %v; want "", true`, got, ok)
+	}
+}
+
+func TestParseCacheControl_QuotedComma(t *testing.T) {
+	cc := ParseCacheControl(`no-cache="Set-Cookie, Authorization", max-age=0, x="a\"b"`)
+	if !cc.NoCache {
+		t.Error("NoCache = false, want true")
+	}
+	if cc.MaxAge != 0 {
+		t.Errorf("MaxAge = %d, want 0", cc.MaxAge)
+	}
+	if got := cc.Extensions["x"]; got != `a"b` {
+		t.Errorf(`Extensions["x"] = %q, want %q`, got, `a"b`)
+	}
+	if _, ok := cc.Extensions["authorization"]; ok {
+		t.Error("quoted comma split the no-cache field list")
+	}
+}
+
+func TestParseCacheControl_Values(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"max-age=60, max-age=120", 60},
+		{"MAX-AGE=5", 5},
+		{"max-age=-1", -1},
+		{"max-age=abc", -1},
+		{"max-age", -1},
+		{"max-age=99999999999999999999", maxDeltaSeconds},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := ParseCacheControl(tt.value).MaxAge; got != tt.want {
+			t.Errorf("ParseCacheControl(%q).MaxAge = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestETagMatch covers the weak/strong comparison table of RFC 9110 §8.8.3.2.
+func TestETagMatch(t *testing.T) {
+	tests := []struct {
+		etag, list   string
+		strong, weak bool
+	}{
+		{`W/"1"`, `W/"1"`, false, true},
+		{`W/"1"`, `W/"2"`, false, false},
+		{`W/"1"`, `"1"`, false, true},
+		{`"1"`, `"1"`, true, true},
+		{`"1"`, `"2", "3", "1"`, true, true},
+		{`"a,b"`, `"x", "a,b"`, true, true},
+		{`"1"`, `*`, true, true},
+		{``, `*`, false, false},
+		{`"1"`, ``, false, false},
+	}
+	for _, tt := range tests {
+		if got := ETagMatch(tt.etag, tt.list, false); got != tt.strong {
+			t.Errorf("ETagMatch(%s, %s, strong) = %v, want %v", tt.etag, tt.list, got, tt.strong)
+		}
+		if got := ETagMatch(tt.etag, tt.list, true); got != tt.weak {
+			t.Errorf("ETagMatch(%s, %s, weak) = %v, want %v", tt.etag, tt.list, got, tt.weak)
+		}
+	}
+}
+
+func TestRequest_CacheKey(t *testing.T) {
+	a := &Request{Method: "GET", Path: "/search?q=go&a=1#top", Headers: Headers{{Key: "Host", Value: "Example.com"}}}
+	b := &Request{Method: "GET", Path: "/search?a=1&q=go", Headers: Headers{{Key: "host", Value: "example.com"}}}
+	if a.CacheKey() != b.CacheKey() {
+		t.Errorf("CacheKey() %q != %q", a.CacheKey(), b.CacheKey())
+	}
+	if want := "GET example.com/search?a=1&q=go"; a.CacheKey() != want {
+		t.Errorf("CacheKey() = %q, want %q", a.CacheKey(), want)
+	}
+	c := &Request{Method: "HEAD", Path: "/search?a=1&q=go", Headers: b.Headers}
+	if c.CacheKey() == b.CacheKey() {
+		t.Error("CacheKey() ignores method")
+	}
+}
+
+func TestFreshnessLifetime(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers Headers
+		want    time.Duration
+		ok      bool
+	}{
+		{"s-maxage wins", Headers{{Key: "Cache-Control", Value: "max-age=60, s-maxage=30"}}, 30 * time.Second, true},
+		{"max-age over Expires", Headers{
+			{Key: "Cache-Control", Value: "max-age=60"},
+			{Key: "Expires", Value: "Thu, 01 Jan 2026 01:00:00 GMT"},
+		}, time.Minute, true},
+		{"split Cache-Control lines", Headers{
+			{Key: "Cache-Control", Value: "public"},
+			{Key: "Cache-Control", Value: "max-age=10"},
+		}, 10 * time.Second, true},
+		{"Expires minus Date", Headers{
+			{Key: "Date", Value: "Thu, 01 Jan 2026 00:30:00 GMT"},
+			{Key: "Expires", Value: "Thu, 01 Jan 2026 01:00:00 GMT"},
+		}, 30 * time.Minute, true},
+		{"Expires minus now", Headers{{Key: "Expires", Value: "Thu, 01 Jan 2026 01:00:00 GMT"}}, time.Hour, true},
+		{"invalid Expires", Headers{{Key: "Expires", Value: "0"}}, 0, true},
+		{"empty Expires", Headers{{Key: "Expires", Value: ""}}, 0, true},
+		{"none", Headers{{Key: "Cache-Control", Value: "public"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := freshnessLifetime(&Response{Headers: tt.headers}, dateNow)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("FreshnessLifetime() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResponse_VaryHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers Headers
+		want    []string
+	}{
+		{"none", nil, nil},
+		{"list", Headers{{Key: "Vary", Value: "Accept-Encoding, accept-language"}}, []string{"accept-encoding", "accept-language"}},
+		{"deduped across lines", Headers{
+			{Key: "Vary", Value: "Accept-Encoding"},
+			{Key: "vary", Value: "Origin,accept-encoding,"},
+		}, []string{"accept-encoding", "origin"}},
+		{"star", Headers{{Key: "Vary", Value: "Accept-Encoding, *"}}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		if got := (&Response{Headers: tt.headers}).VaryHeaders(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: VaryHeaders() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSecondaryCacheKey(t *testing.T) {
+	vary := []string{"accept-language", "accept-encoding"}
+	key := func(headers Headers) string {
+		return SecondaryCacheKey(&Request{Method: "GET", Path: "/", Headers: headers}, vary)
+	}
+
+	a := key(Headers{{Key: "Accept-Encoding", Value: "gzip, br"}, {Key: "Accept-Language", Value: "en-US"}})
+	if want := "accept-encoding: br,gzip\naccept-language: en-us"; a != want {
+		t.Errorf("key = %q, want %q", a, want)
+	}
+	same := []Headers{
+		{{Key: "accept-language", Value: "EN-us"}, {Key: "accept-encoding", Value: "BR,gzip"}},
+		{{Key: "Accept-Encoding", Value: "br"}, {Key: "Accept-Encoding", Value: "gzip"}, {Key: "Accept-Language", Value: "en-US"}},
+	}
+	for _, h := range same {
+		if got := key(h); got != a {
+			t.Errorf("%q: key = %q, want %q", h, got, a)
+		}
+	}
+	different := []Headers{
+		{{Key: "Accept-Encoding", Value: "gzip"}, {Key: "Accept-Language", Value: "en-US"}},
+		{{Key: "Accept-Encoding", Value: "gzip, br"}},
+		{{Key: "Accept-Encoding", Value: "gzip, br"}, {Key: "Accept-Language", Value: ""}},
+	}
+	for _, h := range different {
+		if got := key(h); got == a {
+			t.Errorf("%q: key = %q, want it to differ", h, got)
+		}
+	}
+	if got := key(Headers{{Key: "Accept-Encoding", Value: "gzip, br"}}); got != "accept-encoding: br,gzip\naccept-language" {
+		t.Errorf("absent header: key = %q", got)
+	}
+
+	// RFC 9111 §4.1: Vary: * always fails to match.
+	if got := SecondaryCacheKey(&Request{Method: "GET"}, []string{"*"}); got != "" {
+		t.Errorf("Vary *: key = %q, want empty", got)
+	}
+}
+
+func TestResponseIsCacheable(t *testing.T) {
+	get := &Request{Method: "GET", Path: "/", Headers: Headers{{Key: "Host", Value: "example.com"}}}
+	authed := &Request{Method: "GET", Path: "/", Headers: Headers{{Key: "Host", Value: "example.com"}, {Key: "Authorization", Value: "Bearer t"}}}
+	tests := []struct {
+		name    string
+		req     *Request
+		resp    *Response
+		reasons []string
+	}{
+		{"200", get, &Response{StatusCode: 200}, nil},
+		{"Vary star is storable", get, &Response{StatusCode: 200, Headers: Headers{{Key: "Vary", Value: "*"}}}, nil},
+		{"POST", &Request{Method: "POST", Path: "/"}, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "max-age=60"}}},
+			[]string{"method POST is not cacheable"}},
+		{"interim", get, &Response{StatusCode: 103, Headers: Headers{{Key: "Cache-Control", Value: "max-age=60"}}},
+			[]string{"status 103 is not final"}},
+		{"unknown status", get, &Response{StatusCode: 642, Headers: Headers{{Key: "Cache-Control", Value: "max-age=60"}}},
+			[]string{"status 642 is not understood"}},
+		{"no-store", get, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "max-age=60, no-store"}}},
+			[]string{"response has Cache-Control: no-store"}},
+		{"request no-store", &Request{Method: "GET", Headers: Headers{{Key: "Cache-Control", Value: "no-store"}}}, &Response{StatusCode: 200},
+			[]string{"request has Cache-Control: no-store"}},
+		{"private", get, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "private, max-age=60"}}},
+			[]string{"response has Cache-Control: private"}},
+		{"Authorization", authed, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "max-age=60"}}},
+			[]string{"request has Authorization and the response lacks public, s-maxage and must-revalidate"}},
+		{"Authorization public", authed, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "public, max-age=60"}}}, nil},
+		{"Authorization s-maxage", authed, &Response{StatusCode: 200, Headers: Headers{{Key: "Cache-Control", Value: "s-maxage=60"}}}, nil},
+		{"302 without freshness", get, &Response{StatusCode: 302},
+			[]string{"response has no explicit freshness and status 302 is not cacheable by default"}},
+		{"302 with Expires", get, &Response{StatusCode: 302, Headers: Headers{{Key: "Expires", Value: "Thu, 01 Jan 2026 01:00:00 GMT"}}}, nil},
+		{"404", get, &Response{StatusCode: 404}, nil},
+		{"nil request", nil, &Response{StatusCode: 200}, []string{"no request"}},
+		{"nil response", get, nil, []string{"no response"}},
+	}
+	for _, tt := range tests {
+		ok, reasons := ResponseIsCacheable(tt.req, tt.resp)
+		if ok != (tt.reasons == nil) || !reflect.DeepEqual(reasons, tt.reasons) {
+			t.Errorf("%s: ResponseIsCacheable() = %v, %q; want %q", tt.name, ok, reasons, tt.reasons)
+		}
+	}
+}
+
+func TestStrongETag(t *testing.T) {
+	a, b := StrongETag([]byte("hello")), StrongETag([]byte("hello"))
+	if a != b || len(a) != 34 || a[0] != '"' || a[33] != '"' {
+		t.Errorf("StrongETag = %s, %s", a, b)
+	}
+	if StrongETag([]byte("hellp")) == a {
+		t.Error("different bodies got the same tag")
+	}
+	if w := WeakETag("v1"); w != `W/"3bfc269594ef649228e9a74bab00f042"` {
+		t.Errorf("WeakETag = %s", w)
+	}
+}
+
+// validatorResponse returns a 200 with a body and the headers SetValidators
+// keeps or drops on a 304.
+func validatorResponse(body []byte) *Response {
+	return &Response{
+		Version:    "HTTP/1.1",
+		StatusCode: 200,
+		Reason:     "OK",
+		Headers: Headers{
+			{Key: "Content-Type", Value: "text/plain"},
+			{Key: "Content-Length", Value: "5"},
+			{Key: "Cache-Control", Value: "max-age=60"},
+			{Key: "Expires", Value: "Thu, 01 Jan 2026 00:00:00 GMT"},
+			{Key: "Vary", Value: "Accept-Encoding"},
+		},
+		Body: body,
+	}
+}
+
+func TestSetValidators_Fresh(t *testing.T) {
+	body := []byte("hello")
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	resp := validatorResponse(body)
+	req := &Request{Method: "GET", Path: "/", Version: "HTTP/1.1"}
+	if resp.SetValidators(req, body, modTime) {
+		t.Fatal("unconditional request turned 304")
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "hello" || resp.Headers.Get("Content-Length") != "5" {
+		t.Errorf("response = %+v", resp)
+	}
+	if got := resp.Headers.Get("ETag"); got != StrongETag(body) {
+		t.Errorf("ETag = %q", got)
+	}
+	if got := resp.Headers.Get("Last-Modified"); got != "Sat, 01 Mar 2025 12:00:00 GMT" {
+		t.Errorf("Last-Modified = %q", got)
+	}
+}
+
+func TestSetValidators_ETagMatch(t *testing.T) {
+	body := []byte("hello")
+	resp := validatorResponse(body)
+	req := &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: Headers{
+		{Key: "If-None-Match", Value: `"other", W/` + StrongETag(body)},
+		// If-None-Match takes precedence, so a date before modTime is ignored.
+		{Key: "If-Modified-Since", Value: "Sat, 01 Jan 2000 00:00:00 GMT"},
+	}}
+	if !resp.SetValidators(req, body, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatal("matching If-None-Match did not turn 304")
+	}
+	if resp.StatusCode != 304 || resp.Reason != "Not Modified" || resp.Body != nil {
+		t.Errorf("response = %+v", resp)
+	}
+	for _, name := range []string{"ETag", "Last-Modified", "Cache-Control", "Expires", "Vary"} {
+		if !resp.Headers.Has(name) {
+			t.Errorf("304 lost %s", name)
+		}
+	}
+	for _, name := range []string{"Content-Length", "Content-Type"} {
+		if resp.Headers.Has(name) {
+			t.Errorf("304 kept %s", name)
+		}
+	}
+	if findings := LintExchange(req, resp); len(findings) != 0 {
+		t.Errorf("LintExchange = %+v", findings)
+	}
+}
+
+func TestSetValidators_ETagMismatch(t *testing.T) {
+	body := []byte("hello")
+	resp := validatorResponse(body)
+	req := &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: Headers{
+		{Key: "If-None-Match", Value: `"stale"`},
+		// Not modified since, but If-None-Match alone decides.
+		{Key: "If-Modified-Since", Value: "Sun, 01 Jun 2025 00:00:00 GMT"},
+	}}
+	if resp.SetValidators(req, body, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)) || resp.StatusCode != 200 {
+		t.Errorf("non-matching If-None-Match: response = %+v", resp)
+	}
+}
+
+func TestSetValidators_Head(t *testing.T) {
+	body := []byte("hello")
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	cases := []struct {
+		since string
+		want  bool
+	}{
+		{"Sat, 01 Mar 2025 12:00:00 GMT", true}, // modTime is compared to the second
+		{"Sun, 01 Jun 2025 00:00:00 GMT", true},
+		{"Sat, 01 Mar 2025 11:59:59 GMT", false},
+		{"not a date", false},
+	}
+	for _, c := range cases {
+		resp := validatorResponse(nil)
+		req := &Request{Method: "HEAD", Path: "/", Version: "HTTP/1.1", Headers: Headers{
+			{Key: "If-Modified-Since", Value: c.since},
+		}}
+		if got := resp.SetValidators(req, body, modTime); got != c.want || (resp.StatusCode == 304) != c.want {
+			t.Errorf("If-Modified-Since %q: SetValidators = %v, status %d", c.since, got, resp.StatusCode)
+		}
+	}
+	// Other methods and non-2xx responses are never converted.
+	resp := validatorResponse(body)
+	post := &Request{Method: "POST", Path: "/", Version: "HTTP/1.1", Headers: Headers{{Key: "If-None-Match", Value: "*"}}}
+	if resp.SetValidators(post, body, modTime) || resp.SetValidators(nil, body, modTime) {
+		t.Error("POST or nil request turned 304")
+	}
+}
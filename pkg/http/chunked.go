@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// DechunkBytes decodes a chunked body (RFC 9112 §7.1) at the start of data.
+// It returns the payload, the trailer fields, and the bytes following the
+// body's final blank line — the start of the next message in a pipelined
+// capture. Chunk extensions are ignored and bare LF line endings accepted,
+// as by Unmarshal. Data that ends before the final blank line is an error.
+func DechunkBytes(data []byte) (body []byte, trailers Headers, rest []byte, err error) {
+	body, t, n, err := fastparser.ParseChunked(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return body, headersFromInternal(t), data[n:], nil
+}
+
+// ChunkBytes encodes body with chunked transfer coding, in chunks of at
+// most chunkSize bytes (the whole body in one chunk if chunkSize ≤ 0),
+// followed by the last chunk and the final CRLF.
+func ChunkBytes(body []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(body) + 16)
+	cw := NewChunkWriter(&buf, chunkSize)
+	cw.Write(body)
+	cw.Close()
+	return buf.Bytes()
+}
+
+// DechunkReader decodes a chunked body from an io.Reader. It accepts the
+// same input as DechunkBytes; Read returns io.EOF once the trailer section
+// has been consumed.
+type DechunkReader struct {
+	cr *fastparser.ChunkedReader
+}
+
+// NewDechunkReader returns a DechunkReader reading a chunked body from r.
+// Pass a *bufio.Reader to keep the bytes after the body readable from it;
+// any other reader is buffered and may be read past the body's end.
+func NewDechunkReader(r io.Reader) *DechunkReader {
+	return &DechunkReader{cr: fastparser.NewChunkedReader(r)}
+}
+
+// Read reads decoded payload bytes.
+func (d *DechunkReader) Read(p []byte) (int, error) {
+	return d.cr.Read(p)
+}
+
+// Trailers returns the trailer fields. They are available once Read has
+// returned io.EOF.
+func (d *DechunkReader) Trailers() Headers {
+	return headersFromInternal(d.cr.Trailers)
+}
+
+// ChunkWriter encodes what is written to it with chunked transfer coding.
+// Writes are buffered into chunks of chunkSize bytes; with a chunkSize ≤ 0
+// each non-empty Write becomes one chunk. Close writes any buffered data,
+// the last chunk, Trailers, and the final CRLF. It does not close the
+// underlying writer.
+type ChunkWriter struct {
+	// Trailers are written as the trailer section by Close. Callers
+	// normally announce them with a Trailer header.
+	Trailers Headers
+
+	w      io.Writer
+	size   int
+	buf    []byte
+	closed bool
+}
+
+// NewChunkWriter returns a ChunkWriter writing to w.
+func NewChunkWriter(w io.Writer, chunkSize int) *ChunkWriter {
+	return &ChunkWriter{w: w, size: chunkSize}
+}
+
+// Write encodes p, emitting each full chunk as soon as it is available.
+func (cw *ChunkWriter) Write(p []byte) (int, error) {
+	if cw.closed {
+		return 0, fmt.Errorf("http: write to closed ChunkWriter")
+	}
+	if cw.size <= 0 {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return len(p), cw.writeChunk(p)
+	}
+	n := len(p)
+	if len(cw.buf) > 0 {
+		fill := cw.size - len(cw.buf)
+		if fill > len(p) {
+			fill = len(p)
+		}
+		cw.buf = append(cw.buf, p[:fill]...)
+		p = p[fill:]
+		if len(cw.buf) < cw.size {
+			return n, nil
+		}
+		if err := cw.writeChunk(cw.buf); err != nil {
+			return 0, err
+		}
+		cw.buf = cw.buf[:0]
+	}
+	for len(p) >= cw.size {
+		if err := cw.writeChunk(p[:cw.size]); err != nil {
+			return 0, err
+		}
+		p = p[cw.size:]
+	}
+	cw.buf = append(cw.buf, p...)
+	return n, nil
+}
+
+// Close flushes buffered data and writes the end of the chunked body.
+// Calling Close again has no effect.
+func (cw *ChunkWriter) Close() error {
+	if cw.closed {
+		return nil
+	}
+	cw.closed = true
+	if len(cw.buf) > 0 {
+		if err := cw.writeChunk(cw.buf); err != nil {
+			return err
+		}
+		cw.buf = nil
+	}
+	end := []byte("0\r\n")
+	for _, h := range cw.Trailers {
+		end = append(end, h.Key...)
+		end = append(end, ": "...)
+		end = append(end, h.Value...)
+		end = append(end, "\r\n"...)
+	}
+	end = append(end, "\r\n"...)
+	_, err := cw.w.Write(end)
+	return err
+}
+
+func (cw *ChunkWriter) writeChunk(data []byte) error {
+	var head [18]byte
+	line := append(strconv.AppendInt(head[:0], int64(len(data)), 16), '\r', '\n')
+	if _, err := cw.w.Write(line); err != nil {
+		return err
+	}
+	if _, err := cw.w.Write(data); err != nil {
+		return err
+	}
+	_, err := cw.w.Write([]byte("\r\n"))
+	return err
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chunkedInputs covers the inputs of the internal Dechunk and Decoder
+// chunked tests, plus chunk extensions, trailers and pipelined data.
+var chunkedInputs = []string{
+	"5\r\nHello\r\n0\r\n\r\n",
+	"5\r\nHello\r\n6\r\n World\r\n0\r\n\r\n",
+	"A\r\n0123456789\r\n0\r\n\r\n",
+	"5;ext=val\r\nHello\r\n0\r\n\r\n",
+	"0\r\n\r\n",
+	"ZZ\r\nHello\r\n0\r\n\r\n",
+	"A\r\nHello\r\n",
+	"5\nHello\n0\n\n",
+	"000000001\r\nX\r\n0\r\n\r\n",
+	"5\r\nHello!!",
+	"5",
+	"",
+	"5\r\nHel",
+	"5\r\nHello\r\n",
+	// Chunk extensions.
+	"5;name=\"quoted;value\"\r\nHello\r\n0;last\r\n\r\n",
+	"5 ; a=b;c\r\nHello\r\n0\r\n\r\n",
+	"5;\r\nHello\r\n0\r\n\r\n",
+	// Trailers.
+	"5\r\nHello\r\n0\r\nExpires: never\r\nX-Checksum:  abc \r\n\r\n",
+	"0\r\nX-Only: trailer\r\n\r\n",
+	"5\r\nHello\r\n0\r\nno colon\r\n\r\n",
+	"5\r\nHello\r\n0\r\n: empty name\r\n\r\n",
+	"5\r\nHello\r\n0\r\nX-Cut: off",
+	"5\r\nHello\r\n0\r\n",
+	// Pipelined: the next message follows the body.
+	"5\r\nHello\r\n0\r\n\r\nGET /next HTTP/1.1\r\n\r\n",
+	"3\r\nabc\r\n0\r\nX-T: 1\r\n\r\nHTTP/1.1 200 OK\r\n",
+	// Malformed framing.
+	"5\r\nHelloXX0\r\n\r\n",
+	"5\r\nHello\r0\r\n\r\n",
+	"ffffffffffffffffff\r\nX\r\n0\r\n\r\n",
+	"7fffffffffffffff\r\nX\r\n0\r\n\r\n",
+	"-1\r\nX\r\n0\r\n\r\n",
+}
+
+// TestDechunk_BytesAgreesWithReader decodes every input both ways.
+func TestDechunk_BytesAgreesWithReader(t *testing.T) {
+	for _, in := range chunkedInputs {
+		body, trailers, rest, err := DechunkBytes([]byte(in))
+
+		br := bufio.NewReader(strings.NewReader(in))
+		dr := NewDechunkReader(br)
+		streamBody, streamErr := io.ReadAll(dr)
+		streamRest, _ := io.ReadAll(br)
+
+		if (err == nil) != (streamErr == nil) {
+			t.Errorf("%q: DechunkBytes err = %v, reader err = %v", in, err, streamErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(body, streamBody) {
+			t.Errorf("%q: body = %q, reader body = %q", in, body, streamBody)
+		}
+		if !reflect.DeepEqual(trailers, dr.Trailers()) {
+			t.Errorf("%q: trailers = %v, reader trailers = %v", in, trailers, dr.Trailers())
+		}
+		if !bytes.Equal(rest, streamRest) {
+			t.Errorf("%q: rest = %q, reader rest = %q", in, rest, streamRest)
+		}
+	}
+}
+
+func TestDechunkBytes(t *testing.T) {
+	in := "5;ext=1\r\nHello\r\n6\r\n World\r\n0\r\nExpires: never\r\nX-Checksum:  abc \r\n\r\nGET / HTTP/1.1\r\n\r\n"
+	body, trailers, rest, err := DechunkBytes([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "Hello World" {
+		t.Errorf("body = %q, want %q", body, "Hello World")
+	}
+	want := Headers{{Key: "Expires", Value: "never"}, {Key: "X-Checksum", Value: "abc"}}
+	if !reflect.DeepEqual(trailers, want) {
+		t.Errorf("trailers = %v, want %v", trailers, want)
+	}
+	if string(rest) != "GET / HTTP/1.1\r\n\r\n" {
+		t.Errorf("rest = %q, want the pipelined request", rest)
+	}
+
+	body, trailers, rest, err = DechunkBytes([]byte("0\r\n\r\n"))
+	if err != nil || body != nil || trailers != nil || len(rest) != 0 {
+		t.Errorf("empty body = %q, %v, %q, %v; want all empty", body, trailers, rest, err)
+	}
+}
+
+func TestDechunkBytes_Errors(t *testing.T) {
+	for _, in := range []string{
+		"5\r\nHello\r\n0\r\n",                  // no final blank line
+		"5\r\nHello\r\n0\r\nno colon\r\n\r\n",  // malformed trailer
+		"ffffffffffffffffff\r\nX\r\n0\r\n\r\n", // size overflows
+		"7fffffffffffffff\r\nX\r\n0\r\n\r\n",   // size exceeds the data
+		"10000000000000000\r\n\r\n",            // wraps to zero
+	} {
+		if _, _, _, err := DechunkBytes([]byte(in)); err == nil {
+			t.Errorf("DechunkBytes(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestChunkBytes(t *testing.T) {
+	tests := []struct {
+		body      string
+		chunkSize int
+		want      string
+	}{
+		{"Hello World", 5, "5\r\nHello\r\n5\r\n Worl\r\n1\r\nd\r\n0\r\n\r\n"},
+		{"Hello", 0, "5\r\nHello\r\n0\r\n\r\n"},
+		{"", 4, "0\r\n\r\n"},
+		{strings.Repeat("x", 26), 100, "1a\r\n" + strings.Repeat("x", 26) + "\r\n0\r\n\r\n"},
+	}
+	for _, tt := range tests {
+		if got := string(ChunkBytes([]byte(tt.body), tt.chunkSize)); got != tt.want {
+			t.Errorf("ChunkBytes(%q, %d) = %q, want %q", tt.body, tt.chunkSize, got, tt.want)
+		}
+	}
+}
+
+func TestChunkWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewChunkWriter(&buf, 4)
+	for _, s := range []string{"ab", "cdef", "", "ghijklmno"} {
+		if n, err := cw.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	cw.Trailers = Headers{{Key: "X-Checksum", Value: "abc"}}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "4\r\nabcd\r\n4\r\nefgh\r\n4\r\nijkl\r\n3\r\nmno\r\n0\r\nX-Checksum: abc\r\n\r\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+	if _, err := cw.Write([]byte("x")); err == nil {
+		t.Error("Write after Close succeeded, want error")
+	}
+	if err := cw.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+
+	body, trailers, _, err := DechunkBytes(buf.Bytes())
+	if err != nil || string(body) != "abcdefghijklmno" || trailers.Get("X-Checksum") != "abc" {
+		t.Errorf("decoded = %q, %v, %v", body, trailers, err)
+	}
+}
+
+func TestChunkWriter_Unbuffered(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewChunkWriter(&buf, 0)
+	cw.Write([]byte("Hello"))
+	cw.Write(nil)
+	cw.Write([]byte(" World"))
+	cw.Close()
+	if want := "5\r\nHello\r\n6\r\n World\r\n0\r\n\r\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestChunk_RoundTrip checks that every decodable input survives
+// re-encoding at several chunk sizes.
+func TestChunk_RoundTrip(t *testing.T) {
+	for _, in := range chunkedInputs {
+		body, _, _, err := DechunkBytes([]byte(in))
+		if err != nil {
+			continue
+		}
+		for _, size := range []int{0, 1, 3, 64} {
+			got, _, rest, err := DechunkBytes(ChunkBytes(body, size))
+			if err != nil || !bytes.Equal(got, body) || len(rest) != 0 {
+				t.Errorf("%q size %d: round trip = %q, rest %q, err %v", in, size, got, rest, err)
+			}
+		}
+	}
+}
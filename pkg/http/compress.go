@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// MarshalCompressed returns the wire-format encoding of resp with its body
+// compressed with encoding, "gzip" or "deflate" (the zlib format RFC 9110
+// §8.4.1.2 names deflate). Content-Encoding is set, Content-Length is
+// recomputed, and a chunked Transfer-Encoding is dropped so the output is
+// length-delimited; resp itself is not modified. It returns an error for
+// other encodings and for a response that already has a Content-Encoding
+// other than identity, rather than compressing twice.
+func MarshalCompressed(resp *Response, encoding string) ([]byte, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("http: MarshalCompressed(nil)")
+	}
+	if ce := strings.TrimSpace(resp.Headers.Get("Content-Encoding")); ce != "" && !strings.EqualFold(ce, "identity") {
+		return nil, fmt.Errorf("http: response already has Content-Encoding %q", ce)
+	}
+	body, err := encodeContent(resp.Body, encoding)
+	if err != nil {
+		return nil, err
+	}
+	out := *resp
+	out.Body = body
+	out.Headers = resp.Headers.Clone()
+	out.Headers.Del("Transfer-Encoding")
+	out.Headers.Set("Content-Encoding", strings.ToLower(encoding))
+	out.Headers.Set("Content-Length", strconv.Itoa(len(body)))
+	return Marshal(&out)
+}
+
+// DecodeBody undoes the response's Content-Encoding in place: each listed
+// coding is removed in reverse order of application, Content-Encoding is
+// deleted, and Content-Length, if present, is updated to the decoded
+// length. gzip (and x-gzip), deflate (zlib, or raw DEFLATE as some servers
+// send it) and identity are understood. On error the response is left
+// unchanged.
+func (r *Response) DecodeBody() error {
+	codings := r.Headers.Values("Content-Encoding")
+	if len(codings) == 0 {
+		return nil
+	}
+	var list []string
+	for _, v := range codings {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				list = append(list, c)
+			}
+		}
+	}
+	body := r.Body
+	for i := len(list) - 1; i >= 0; i-- {
+		var err error
+		if body, err = decodeContent(body, list[i]); err != nil {
+			return err
+		}
+	}
+	r.Body = body
+	r.Headers.Del("Content-Encoding")
+	if r.Headers.Has("Content-Length") {
+		r.Headers.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return nil
+}
+
+// encodeContent compresses data with a content coding.
+func encodeContent(data []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("http: unsupported content encoding %q (want gzip or deflate)", encoding)
+	}
+	w.Write(data) // writes to a bytes.Buffer cannot fail
+	w.Close()
+	return buf.Bytes(), nil
+}
+
+// decodeContent reverses one content coding.
+func decodeContent(data []byte, coding string) ([]byte, error) {
+	out, err := fastparser.DecodeCoding(data, coding, "content coding", 0)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	return out, nil
+}
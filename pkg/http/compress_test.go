@@ -0,0 +1,113 @@
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"strings"
+	"testing"
+)
+
+func TestMarshalCompressed_RoundTrip(t *testing.T) {
+	binary := make([]byte, 4096)
+	for i := range binary {
+		binary[i] = byte(i * 7919 >> 3)
+	}
+	bodies := map[string][]byte{
+		"text":   []byte(strings.Repeat(`{"id":1,"name":"alice","tags":["a","b"]}`+"\n", 200)),
+		"binary": binary,
+		"empty":  nil,
+	}
+	for name, body := range bodies {
+		for _, enc := range []string{"gzip", "deflate", "GZIP"} {
+			resp := &Response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Headers: Headers{
+				{Key: "Content-Type", Value: "application/octet-stream"},
+				{Key: "Content-Length", Value: "999"},
+			}, Body: body}
+			data, err := MarshalCompressed(resp, enc)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", name, enc, err)
+			}
+			if !bytes.Equal(resp.Body, body) || len(resp.Headers) != 2 || resp.Headers.Get("Content-Length") != "999" {
+				t.Errorf("%s/%s: MarshalCompressed modified the response", name, enc)
+			}
+
+			parsed, err := UnmarshalResponse(data)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", name, enc, err)
+			}
+			if got := parsed.Headers.Get("Content-Encoding"); got != strings.ToLower(enc) {
+				t.Errorf("%s/%s: Content-Encoding = %q", name, enc, got)
+			}
+			if parsed.Headers.ContentLength() != int64(len(parsed.Body)) {
+				t.Errorf("%s/%s: Content-Length = %d, body is %d bytes", name, enc, parsed.Headers.ContentLength(), len(parsed.Body))
+			}
+			if err := parsed.DecodeBody(); err != nil {
+				t.Fatalf("%s/%s: DecodeBody: %v", name, enc, err)
+			}
+			if !bytes.Equal(parsed.Body, body) && !(len(body) == 0 && len(parsed.Body) == 0) {
+				t.Errorf("%s/%s: decoded body differs from the original", name, enc)
+			}
+			if parsed.Headers.Get("Content-Encoding") != "" || parsed.Headers.ContentLength() != int64(len(body)) {
+				t.Errorf("%s/%s: headers after DecodeBody = %v", name, enc, parsed.Headers)
+			}
+		}
+	}
+}
+
+func TestMarshalCompressed_Errors(t *testing.T) {
+	resp := &Response{StatusCode: 200, Headers: Headers{{Key: "Content-Encoding", Value: "br"}}, Body: []byte("x")}
+	if _, err := MarshalCompressed(resp, "gzip"); err == nil || !strings.Contains(err.Error(), "already") {
+		t.Errorf("double compression: err = %v", err)
+	}
+	resp.Headers = nil
+	if _, err := MarshalCompressed(resp, "br"); err == nil || !strings.Contains(err.Error(), `"br"`) {
+		t.Errorf("unknown encoding: err = %v", err)
+	}
+	if _, err := MarshalCompressed(nil, "gzip"); err == nil {
+		t.Error("nil response: succeeded, want error")
+	}
+
+	// A chunked response becomes length-delimited.
+	resp.Headers = Headers{{Key: "Transfer-Encoding", Value: "chunked"}, {Key: "Content-Encoding", Value: "identity"}}
+	data, err := MarshalCompressed(resp, "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := UnmarshalResponse(data); err != nil || parsed.Headers.IsChunked() {
+		t.Errorf("parsed = %+v, %v; want Content-Length framing", parsed, err)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	// Raw DEFLATE without the zlib wrapper, as some servers send it.
+	var raw bytes.Buffer
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write([]byte("hello, deflate"))
+	fw.Close()
+	resp := &Response{Headers: Headers{{Key: "Content-Encoding", Value: "deflate"}}, Body: raw.Bytes()}
+	if err := resp.DecodeBody(); err != nil || string(resp.Body) != "hello, deflate" {
+		t.Errorf("raw deflate: body %q, err %v", resp.Body, err)
+	}
+
+	// Stacked codings are removed last-applied first.
+	gz, _ := encodeContent([]byte("stacked"), "gzip")
+	both, _ := encodeContent(gz, "deflate")
+	resp = &Response{Headers: Headers{{Key: "Content-Encoding", Value: "gzip, identity"}, {Key: "Content-Encoding", Value: "deflate"}}, Body: both}
+	if err := resp.DecodeBody(); err != nil || string(resp.Body) != "stacked" {
+		t.Errorf("stacked: body %q, err %v", resp.Body, err)
+	}
+
+	// Undecodable bodies leave the response unchanged.
+	for _, ce := range []string{"gzip", "br"} {
+		resp = &Response{Headers: Headers{{Key: "Content-Encoding", Value: ce}}, Body: []byte("not compressed")}
+		if err := resp.DecodeBody(); err == nil || string(resp.Body) != "not compressed" || resp.Headers.Get("Content-Encoding") != ce {
+			t.Errorf("%s: err = %v, body %q; want an error and no change", ce, err, resp.Body)
+		}
+	}
+
+	// No Content-Encoding is a no-op.
+	resp = &Response{Body: []byte("plain")}
+	if err := resp.DecodeBody(); err != nil || string(resp.Body) != "plain" {
+		t.Errorf("plain: body %q, err %v", resp.Body, err)
+	}
+}
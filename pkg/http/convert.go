@@ -1,41 +1,267 @@
 package http
 
 import (
+	"fmt"
+
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-http/internal/fastparser"
 	"github.com/shapestone/shape-http/internal/parser"
 )
 
+// NodeType is the kind of message an AST node describes, as NodeKind
+// reports it.
+type NodeType int
+
+// Node types.
+const (
+	UnknownNode  NodeType = iota // not a message node, or ParseLenient's "unknown"
+	RequestNode                  // "type": "request"
+	ResponseNode                 // "type": "response"
+)
+
+// String returns "request", "response" or "unknown".
+func (t NodeType) String() string {
+	switch t {
+	case RequestNode:
+		return "request"
+	case ResponseNode:
+		return "response"
+	}
+	return "unknown"
+}
+
+// NodeKind reports the kind of message node describes, from its "type"
+// property.
+//
+// The nodes Parse, ParseReader, ParseLenient, RequestToNode and
+// ResponseToNode produce, and Render accepts, have a fixed schema. A
+// message is an *ast.ObjectNode with these properties, each value an
+// *ast.LiteralNode holding a string unless noted:
+//
+//	type        "request", "response", or "unknown" from ParseLenient
+//	method      requests only
+//	path        requests only
+//	scheme      requests only; absent when empty
+//	version     both
+//	statusCode  responses only; an int64
+//	reason      responses only
+//	headers     both; an *ast.ArrayDataNode of *ast.ObjectNode, each with
+//	            "key" and "value" properties, in wire order
+//	body        both; absent when there is no body
+//
+// Properties are not added to this schema within a major version.
+func NodeKind(node ast.SchemaNode) NodeType {
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		return UnknownNode
+	}
+	lit, ok := obj.Properties()["type"].(*ast.LiteralNode)
+	if !ok {
+		return UnknownNode
+	}
+	switch lit.Value() {
+	case "request":
+		return RequestNode
+	case "response":
+		return ResponseNode
+	}
+	return UnknownNode
+}
+
 // NodeToRequest converts an AST ObjectNode (as produced by Parse or ParseLenient)
-// to a Request. Returns an error if node is not an ObjectNode.
+// to a Request. Returns an error if node is not an ObjectNode or is a
+// response node.
 func NodeToRequest(node ast.SchemaNode) (*Request, error) {
+	if NodeKind(node) == ResponseNode {
+		return nil, fmt.Errorf("http: expected a request node, got a response node")
+	}
 	fpReq, err := parser.NodeToRequest(node)
 	if err != nil {
 		return nil, err
 	}
-	return &Request{
-		Method:  fpReq.Method,
-		Path:    fpReq.Path,
-		Version: fpReq.Version,
-		Scheme:  fpReq.Scheme,
-		Headers: convertHeaders(fpReq.Headers),
-		Body:    fpReq.Body,
-	}, nil
+	return requestFromInternal(fpReq), nil
 }
 
 // NodeToResponse converts an AST ObjectNode (as produced by Parse or ParseLenient)
-// to a Response. Returns an error if node is not an ObjectNode.
+// to a Response. Returns an error if node is not an ObjectNode or is a
+// request node.
 func NodeToResponse(node ast.SchemaNode) (*Response, error) {
+	if NodeKind(node) == RequestNode {
+		return nil, fmt.Errorf("http: expected a response node, got a request node")
+	}
 	fpResp, err := parser.NodeToResponse(node)
 	if err != nil {
 		return nil, err
 	}
-	return &Response{
-		Version:    fpResp.Version,
-		StatusCode: fpResp.StatusCode,
-		Reason:     fpResp.Reason,
-		Headers:    convertHeaders(fpResp.Headers),
-		Body:       fpResp.Body,
-	}, nil
+	return responseFromInternal(fpResp), nil
+}
+
+// requestFromInternal, responseFromInternal and resultFromInternal are the
+// only places parser results become public types; TestFromInternal_AllFields
+// fails if a field of the internal types is not carried over. Stats,
+// InvalidHeaderName and the curl connection entries are converted
+// directly, which stops compiling when the two sides' fields differ.
+func requestFromInternal(req *fastparser.Request) *Request {
+	if req == nil {
+		return nil
+	}
+	r := &Request{}
+	fillRequest(r, req)
+	return r
+}
+
+func fillRequest(target *Request, req *fastparser.Request) {
+	target.Method = req.Method
+	target.Path = req.Path
+	target.Version = req.Version
+	target.Scheme = req.Scheme
+	target.Headers = headersFromInternal(req.Headers)
+	target.Body = req.Body
+	target.RawBody = req.RawBody
+	target.RawHeaders = headersFromInternal(req.RawHeaders)
+}
+
+func responseFromInternal(resp *fastparser.Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	r := &Response{}
+	fillResponse(r, resp)
+	return r
+}
+
+func fillResponse(target *Response, resp *fastparser.Response) {
+	target.Version = resp.Version
+	target.StatusCode = resp.StatusCode
+	target.Reason = resp.Reason
+	target.Headers = headersFromInternal(resp.Headers)
+	target.Body = resp.Body
+	target.RawBody = resp.RawBody
+	target.RawHeaders = headersFromInternal(resp.RawHeaders)
+}
+
+func resultFromInternal(res *fastparser.ParseResult) *ParseResult {
+	req := requestFromInternal(res.Request)
+	return &ParseResult{
+		Request:    req,
+		Response:   responseFromInternal(res.Response),
+		Warnings:   res.Warnings,
+		Partial:    res.Partial,
+		Complete:   res.Complete,
+		URL:        res.URL,
+		Requests:   requestsFromInternal(res.Requests, res.Request, req),
+		DetectedAs: MessageType(res.DetectedAs),
+		Confidence: Confidence(res.Confidence),
+		Verdict:    CurlVerdict(res.Verdict),
+
+		Informational: responsesFromInternal(res.Informational),
+		Stats:         Stats(res.Stats),
+		ClientHints:   clientHintsFromInternal(res.ClientHints),
+		Observations:  observationsFromInternal(res.Observations),
+
+		InvalidHeaderNames: invalidNamesFromInternal(res.InvalidHeaderNames),
+	}
+}
+
+func invalidNamesFromInternal(ns []fastparser.InvalidHeaderName) []InvalidHeaderName {
+	if ns == nil {
+		return nil
+	}
+	out := make([]InvalidHeaderName, len(ns))
+	for i, n := range ns {
+		out[i] = InvalidHeaderName(n)
+	}
+	return out
+}
+
+func clientHintsFromInternal(h fastparser.ClientHints) ClientHints {
+	return ClientHints{
+		BodyFile:    h.BodyFile,
+		Authority:   h.Authority,
+		AuthScheme:  h.AuthScheme,
+		Credentials: h.Credentials,
+		AWSSigV4:    h.AWSSigV4,
+		Resolve:     resolveFromInternal(h.Resolve),
+		ConnectTo:   connectToFromInternal(h.ConnectTo),
+	}
+}
+
+func resolveFromInternal(es []fastparser.ResolveEntry) []ResolveEntry {
+	if es == nil {
+		return nil
+	}
+	out := make([]ResolveEntry, len(es))
+	for i, e := range es {
+		out[i] = ResolveEntry(e)
+	}
+	return out
+}
+
+func connectToFromInternal(es []fastparser.ConnectToEntry) []ConnectToEntry {
+	if es == nil {
+		return nil
+	}
+	out := make([]ConnectToEntry, len(es))
+	for i, e := range es {
+		out[i] = ConnectToEntry(e)
+	}
+	return out
+}
+
+func observationsFromInternal(o fastparser.FormatObservations) FormatObservations {
+	return FormatObservations{
+		LineEnding:                 LineEnding(o.LineEnding),
+		MixedLineEndings:           o.MixedLineEndings,
+		ObsFoldCount:               o.ObsFoldCount,
+		WhitespaceBeforeColonCount: o.WhitespaceBeforeColonCount,
+		StrayBlankLines:            o.StrayBlankLines,
+		LeadingBlankLines:          o.LeadingBlankLines,
+		IndentBytes:                o.IndentBytes,
+		HadBOM:                     o.HadBOM,
+		HeaderCase:                 HeaderCase(o.HeaderCase),
+	}
+}
+
+// requestsFromInternal converts the glob requests of a curl result. The
+// first is the result's Request, which req already converts, so that it
+// stays the same pointer.
+func requestsFromInternal(rs []*fastparser.Request, first *fastparser.Request, req *Request) []*Request {
+	if rs == nil {
+		return nil
+	}
+	out := make([]*Request, len(rs))
+	for i, r := range rs {
+		if r == first {
+			out[i] = req
+		} else {
+			out[i] = requestFromInternal(r)
+		}
+	}
+	return out
+}
+
+func responsesFromInternal(rs []*fastparser.Response) []*Response {
+	if rs == nil {
+		return nil
+	}
+	out := make([]*Response, len(rs))
+	for i, r := range rs {
+		out[i] = responseFromInternal(r)
+	}
+	return out
+}
+
+// headersFromInternal copies the parser's headers. An empty list becomes
+// nil.
+func headersFromInternal(h []fastparser.Header) Headers {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(Headers, len(h))
+	for i, hdr := range h {
+		out[i] = Header(hdr)
+	}
+	return out
 }
 
 var zeroPos = ast.Position{}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-http/internal/fastparser"
 )
 
 func TestRequestToNode_AndBack(t *testing.T) {
@@ -226,3 +230,231 @@ func TestRequestToNode_BodyAbsentWhenNil(t *testing.T) {
 		t.Error("body key present in AST but Body was nil")
 	}
 }
+
+// ── Internal conversion ─────────────────────────────────────────────────────
+
+// TestFromInternal_AllFields fills every field of the parser's result types
+// with a distinct value and checks each one survives conversion under the
+// same name, so a field added to fastparser cannot be silently dropped.
+func TestFromInternal_AllFields(t *testing.T) {
+	var in fastparser.ParseResult
+	n := 0
+	fillDistinct(reflect.ValueOf(&in).Elem(), &n)
+
+	out := resultFromInternal(&in)
+	assertSameFields(t, "ParseResult", reflect.ValueOf(in), reflect.ValueOf(*out))
+
+	var req Request
+	fillRequest(&req, in.Request)
+	assertSameFields(t, "Request", reflect.ValueOf(*in.Request), reflect.ValueOf(req))
+
+	var resp Response
+	fillResponse(&resp, in.Response)
+	assertSameFields(t, "Response", reflect.ValueOf(*in.Response), reflect.ValueOf(resp))
+}
+
+func TestFromInternal_CopiesHeaders(t *testing.T) {
+	in := &fastparser.Request{Headers: []fastparser.Header{{Key: "Host", Value: "a"}}}
+	out := requestFromInternal(in)
+	out.Headers[0].Value = "b"
+	if in.Headers[0].Value != "a" {
+		t.Error("changing the public headers changed the parser's")
+	}
+	if got := requestFromInternal(&fastparser.Request{Headers: []fastparser.Header{}}).Headers; got != nil {
+		t.Errorf("empty headers = %#v, want nil", got)
+	}
+	if requestFromInternal(nil) != nil || responseFromInternal(nil) != nil {
+		t.Error("nil internal message should convert to nil")
+	}
+}
+
+// fillDistinct sets every settable field reachable from v to a non-zero
+// value unique within one call tree.
+func fillDistinct(v reflect.Value, n *int) {
+	*n++
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("v%d", *n))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(*n))
+	case reflect.Uint8:
+		v.SetUint(uint64(*n % 256))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			fillDistinct(v.Index(i), n)
+		}
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillDistinct(v.Elem(), n)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fillDistinct(v.Field(i), n)
+		}
+	default:
+		panic("fillDistinct: unhandled kind " + v.Kind().String())
+	}
+}
+
+// assertSameFields checks that every field of in has an equal counterpart
+// of the same name in out, comparing named integer types by value.
+func assertSameFields(t *testing.T, path string, in, out reflect.Value) {
+	t.Helper()
+	switch in.Kind() {
+	case reflect.Struct:
+		for i := 0; i < in.NumField(); i++ {
+			name := in.Type().Field(i).Name
+			f := out.FieldByName(name)
+			if !f.IsValid() {
+				t.Errorf("%s.%s has no public counterpart", path, name)
+				continue
+			}
+			assertSameFields(t, path+"."+name, in.Field(i), f)
+		}
+	case reflect.Ptr:
+		if in.IsNil() != out.IsNil() {
+			t.Errorf("%s: nil = %v, want %v", path, out.IsNil(), in.IsNil())
+			return
+		}
+		if !in.IsNil() {
+			assertSameFields(t, path, in.Elem(), out.Elem())
+		}
+	case reflect.Slice:
+		if in.Type().Elem().Kind() == reflect.Uint8 {
+			if !bytes.Equal(in.Bytes(), out.Bytes()) {
+				t.Errorf("%s = %q, want %q", path, out.Bytes(), in.Bytes())
+			}
+			return
+		}
+		if in.Len() != out.Len() {
+			t.Errorf("%s: len = %d, want %d", path, out.Len(), in.Len())
+			return
+		}
+		for i := 0; i < in.Len(); i++ {
+			assertSameFields(t, fmt.Sprintf("%s[%d]", path, i), in.Index(i), out.Index(i))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if in.Int() != out.Int() {
+			t.Errorf("%s = %d, want %d", path, out.Int(), in.Int())
+		}
+	default:
+		if in.Interface() != out.Interface() {
+			t.Errorf("%s = %v, want %v", path, out.Interface(), in.Interface())
+		}
+	}
+}
+
+func TestNodeKind(t *testing.T) {
+	unknown, _, _ := ParseLenient("")
+	tests := []struct {
+		node ast.SchemaNode
+		want NodeType
+	}{
+		{RequestToNode(&Request{Method: "GET", Path: "/"}), RequestNode},
+		{ResponseToNode(&Response{StatusCode: 204}), ResponseNode},
+		{unknown, UnknownNode},
+		{ast.NewLiteralNode("request", zeroPos), UnknownNode},
+		{ast.NewObjectNode(map[string]ast.SchemaNode{}, zeroPos), UnknownNode},
+	}
+	for i, tt := range tests {
+		if got := NodeKind(tt.node); got != tt.want {
+			t.Errorf("%d: NodeKind() = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestNodeToRequest_ResponseNode(t *testing.T) {
+	if _, err := NodeToRequest(ResponseToNode(&Response{StatusCode: 200})); err == nil {
+		t.Error("NodeToRequest(response node) = nil error, want error")
+	}
+	if _, err := NodeToResponse(RequestToNode(&Request{Method: "GET"})); err == nil {
+		t.Error("NodeToResponse(request node) = nil error, want error")
+	}
+}
+
+// TestNodeSchema fails if a parser emits a property, or a value node type,
+// that the schema documented on NodeKind does not list.
+func TestNodeSchema(t *testing.T) {
+	schema := map[NodeType]map[string]string{
+		RequestNode: {
+			"type": "string", "method": "string", "path": "string", "scheme": "string",
+			"version": "string", "headers": "headers", "body": "string",
+		},
+		ResponseNode: {
+			"type": "string", "version": "string", "statusCode": "int64",
+			"reason": "string", "headers": "headers", "body": "string",
+		},
+		UnknownNode: {"type": "string"},
+	}
+	check := func(name string, node ast.SchemaNode) {
+		obj, ok := node.(*ast.ObjectNode)
+		if !ok {
+			t.Errorf("%s: got %T, want *ast.ObjectNode", name, node)
+			return
+		}
+		allowed := schema[NodeKind(node)]
+		for prop, value := range obj.Properties() {
+			want, ok := allowed[prop]
+			if !ok {
+				t.Errorf("%s: undocumented property %q", name, prop)
+				continue
+			}
+			if want == "headers" {
+				checkHeadersSchema(t, name, value)
+				continue
+			}
+			lit, ok := value.(*ast.LiteralNode)
+			if !ok {
+				t.Errorf("%s: %q is %T, want *ast.LiteralNode", name, prop, value)
+				continue
+			}
+			if got := fmt.Sprintf("%T", lit.Value()); got != want {
+				t.Errorf("%s: %q holds %s, want %s", name, prop, got, want)
+			}
+		}
+	}
+
+	seeds := append(append([][]byte{}, requestSeeds...), responseSeeds...)
+	for i, seed := range seeds {
+		if node, err := Parse(string(seed)); err == nil {
+			check(fmt.Sprintf("Parse(seed %d)", i), node)
+		}
+		node, _, _ := ParseLenient(string(seed))
+		check(fmt.Sprintf("ParseLenient(seed %d)", i), node)
+		if r := UnmarshalLenient(seed); r.Request != nil {
+			check(fmt.Sprintf("RequestToNode(seed %d)", i), RequestToNode(r.Request))
+		} else if r.Response != nil {
+			check(fmt.Sprintf("ResponseToNode(seed %d)", i), ResponseToNode(r.Response))
+		}
+	}
+	node, _, _ := ParseLenient("\x00garbage")
+	check("ParseLenient(garbage)", node)
+}
+
+func checkHeadersSchema(t *testing.T, name string, node ast.SchemaNode) {
+	t.Helper()
+	arr, ok := node.(*ast.ArrayDataNode)
+	if !ok {
+		t.Errorf("%s: headers is %T, want *ast.ArrayDataNode", name, node)
+		return
+	}
+	for _, elem := range arr.Elements() {
+		obj, ok := elem.(*ast.ObjectNode)
+		if !ok {
+			t.Errorf("%s: header is %T, want *ast.ObjectNode", name, elem)
+			continue
+		}
+		for prop, value := range obj.Properties() {
+			if prop != "key" && prop != "value" {
+				t.Errorf("%s: undocumented header property %q", name, prop)
+			}
+			if lit, ok := value.(*ast.LiteralNode); !ok {
+				t.Errorf("%s: header %q is %T, want *ast.LiteralNode", name, prop, value)
+			} else if _, ok := lit.Value().(string); !ok {
+				t.Errorf("%s: header %q holds %T, want string", name, prop, lit.Value())
+			}
+		}
+	}
+}
@@ -5,13 +5,18 @@ import "github.com/shapestone/shape-http/internal/fastparser"
 // ParseCurl parses a curl command string and returns a ParseResult with
 // best-effort extraction, matching the output format of UnmarshalLenient.
 //
-// The returned ParseResult always has Request set (never Response) because
+// Request is set unless Verdict is NotCurl, and Response never is, because
 // curl only issues requests. Warnings contains human-readable descriptions
 // of any issues or unsupported flags encountered. Partial is true when the
-// command could not be fully parsed (e.g., missing URL).
+// command could not be fully parsed (e.g., missing URL). Verdict tells the
+// cases apart: CurlComplete, CurlPartial for a curl command missing its URL
+// or failing to tokenize, and NotCurl for empty input or prose with no
+// flag or URL.
 //
 // The leading "curl" word is optional — commands pasted without it (starting
-// directly with a flag or URL) are accepted.
+// directly with a flag or URL) are accepted. Parsing stops at the first
+// unquoted pipe, redirection or command list operator, as in "| jq ." or
+// "2>/dev/null", with a warning naming the ignored tail.
 //
 // # Authentication
 //
@@ -23,25 +28,50 @@ import "github.com/shapestone/shape-http/internal/fastparser"
 //	-H "Authorization: Bearer <token>"   → passed through as-is
 //	-H "Authorization: OAuth ..."        → passed through as-is
 //	-H "X-API-Key: <key>"               → passed through as-is
-//	--digest                → silently ignored (digest challenge cannot be simulated)
+//	--oauth2-bearer <token> → Authorization: Bearer <token>
+//	--digest / --ntlm / --negotiate [-u user:pass]
+//	                        → no Authorization header, with a warning; the
+//	                          scheme and credentials go to ClientHints
+//	--aws-sigv4 <provider> -u key:secret
+//	                        → likewise, with the provider in ClientHints.AWSSigV4
+//	--basic                 → back to Basic for -u
 //	--cert / --key          → silently ignored (TLS options, no file access)
 //
+// An explicit -H "Authorization: ..." always wins over these.
+//
 // # Supported flags
 //
 //	-X / --request          HTTP method
-//	-H / --header           Request header (repeatable)
+//	-H / --header           Request header (repeatable); @file reads headers, see Uploads
 //	-d / --data             Request body
 //	--data-raw              Request body (no special @file handling)
 //	--data-binary           Request body (as-is)
 //	-F / --form             multipart/form-data field (repeatable)
 //	--data-urlencode        URL-encoded form field (repeatable)
+//	--json                  JSON body; sets Content-Type and Accept to application/json
 //	-u / --user             Basic Auth → Authorization: Basic <base64>
 //	-b / --cookie           Cookie header value → Cookie: <value>
 //	-I / --head             Set method to HEAD
-//	--http2                 Set version to HTTP/2
+//	-r / --range            Range: bytes=<range> ("0-499", "500-", "-500", lists)
+//	-T / --upload-file      PUT the named file; see Uploads
+//	--http2 / --http2-prior-knowledge
+//	                        Set version to HTTP/2
 //	--http3                 Set version to HTTP/3
 //	--http1.0               Set version to HTTP/1.0
 //	--http1.1               Set version to HTTP/1.1
+//	--resolve / --connect-to
+//	                        ClientHints.Resolve / ConnectTo (repeatable); the
+//	                        request, Host included, is unchanged
+//
+// An HTTP/2 or HTTP/3 request, from --http2, --http2-prior-knowledge or
+// --http3, gets a warning for each connection-specific header such as
+// Connection, which those versions forbid; ToHTTP1 rewrites the request
+// for Marshal.
+//
+// Repeated -d and --data-urlencode values make one body, joined with "&"
+// in command-line order, so -d a=1 --data-urlencode "b=x y" sends
+// "a=1&b=x%20y". curl refuses -F with either; ParseCurl keeps the form and
+// drops the -d data with a warning.
 //
 // # Compound short flags
 //
@@ -57,11 +87,88 @@ import "github.com/shapestone/shape-http/internal/fastparser"
 //	-i / --include, -O, -o / --output, -A / --user-agent,
 //	and other display/behaviour flags that do not affect the request.
 //
+// -C / --continue-at is skipped with a warning rather than an unknown-flag
+// warning: the resume offset it implies depends on a local file.
+//
+// # Uploads
+//
+// -T file makes the request a PUT (unless -X says otherwise) and, when the
+// URL path ends in "/", appends the file's base name to it as curl does.
+// ParseCurl reads no files, so the body is left empty with a warning and
+// ParseResult.ClientHints.BodyFile names the file; ParseCurlWithOptions
+// with a CurlOptions.FileReader reads it into the body instead. "-T -"
+// (stdin) always leaves the body empty, with BodyFile "-".
+//
+// -H @file adds the headers listed in a file, one "Name: value" per line;
+// blank lines and lines starting with '#' are skipped, as are lines that
+// are not a header, each with a warning. Without a FileReader the file is
+// not read and no headers are added, with a warning; "-H @-" (stdin) is
+// always skipped.
+//
 // # URL fragments
 //
 // Fragments (#section) are stripped from the URL before building the
 // request path because they are never sent over the wire.
 //
+// # Dot segments
+//
+// As curl does, "." and ".." segments are removed from the URL path by
+// RFC 3986 §5.2.4, with a warning showing the path before and after, so
+// that https://example.com/a/../b requests "/b". A ".." above the root is
+// dropped. The query and encoded dots such as "%2e%2e" are left alone;
+// --path-as-is sends the path as written.
+//
+// # Internationalized hosts
+//
+// A URL host with non-ASCII characters, such as https://bücher.example/,
+// is converted with ToASCIIHost for the Host header and ParseResult.URL,
+// here "xn--bcher-kva.example", with a warning. A host ToASCIIHost
+// rejects, such as one with an emoji, is sent as is with a warning. An
+// ASCII host is lowercased, so https://Example.COM/ sends "example.com".
+//
+// # Host override
+//
+// The Host header comes from the URL unless -H "Host: ..." sets one. curl
+// still connects to the URL's authority then, so ParseResult.URL keeps it
+// and ClientHints.Authority records it when it differs from the Host sent.
+//
+// # Unquoted URLs
+//
+// A URL pasted without quotes may be split at a literal space, as in
+// curl https://example.com/search?q=hello world. When a stray argument
+// directly follows a URL that has a query string or ends mid-word, the two
+// are joined with "%20" and a warning. In an unquoted URL the characters
+// '{', '}', '|', space and '"', which a request-target cannot contain, are
+// percent-encoded with a warning listing them. Quoted URLs are used as
+// written.
+//
+// # URL globbing
+//
+// Like curl, ParseCurl expands the globbing patterns of a URL: {a,b,c}
+// sets and [1-10], [001-100], [a-z] or [1-100:10] ranges, so that
+//
+//	curl 'https://example.com/archive[2000-2001]/part{a,b}.html'
+//
+// builds four requests in ParseResult.Requests, Request the first, with a
+// warning giving the count curl would send. At most
+// CurlOptions.MaxGlobRequests are built. A backslash makes a bracket,
+// brace or comma literal, a lone {name} placeholder and an IPv6 literal
+// are not patterns, and -g / --globoff turns globbing off.
+//
+// # Unbalanced quotes
+//
+// The shell idiom for a single quote inside a single-quoted argument, which
+// closes the quotes, escapes the quote and reopens them, is understood:
+//
+//	curl -d '{"name":"O'\''Brien"}' https://example.com/
+//
+// sends {"name":"O'Brien"}. When the quotes of a command do not balance,
+// two repairs are tried, each with a warning: a single quote between two
+// letters inside a single-quoted argument, as in the unescaped
+// -d '{"name":"O'Brien"}', is read as an apostrophe; failing that, a
+// single quote left open with no quote characters after it is closed at
+// the end of the command. Otherwise the result is Partial.
+//
 // # Multi-line commands
 //
 // Lines ending with a backslash (\) are joined before parsing, so commands
@@ -75,6 +182,34 @@ import "github.com/shapestone/shape-http/internal/fastparser"
 // (e.g. "---"). This means commands pasted from README files or API docs
 // together with their surrounding commentary parse correctly.
 //
+// # CONNECT
+//
+// With -X CONNECT the request-target is authority-form: Path is set to the
+// URL's host:port (port defaulted from the scheme) rather than its path.
+//
+// # Body checks
+//
+// When the Content-Type is application/json (or a +json type, including
+// via --json) a body that is not valid JSON — single quotes, trailing
+// commas, unquoted keys — produces a warning naming the syntax error and
+// its byte offset, followed by the offending line with a caret under it:
+//
+//	body is not valid JSON for Content-Type application/json: invalid character '}' looking for beginning of object key string at offset 8
+//	  {"a": 1,}
+//	          ^
+//
+// Likewise, an application/x-www-form-urlencoded body containing a byte
+// that must be percent-encoded (space, quotes, braces, a stray '%', ...)
+// is reported. The body is sent as written either way.
+//
+// # Reconstructed URL
+//
+// ParseResult.URL holds the absolute URL curl would request: scheme
+// (defaulting to http), host with IPv6 brackets kept and the scheme's
+// default port omitted, then path and query. Userinfo is removed, having
+// become an Authorization header, and the fragment is dropped. URL is empty
+// when the command names no host.
+//
 // # URLs without a scheme
 //
 // If the URL has no "http://" or "https://" prefix the host is still
@@ -82,23 +217,99 @@ import "github.com/shapestone/shape-http/internal/fastparser"
 // "localhost:8080/path", "192.168.0.50/path" all produce the correct
 // Host header and path).
 func ParseCurl(cmd string) *ParseResult {
+	m := currentMetrics()
+	start := m.now()
 	internal := fastparser.ParseCurl(cmd)
 
-	result := &ParseResult{
-		Warnings: internal.Warnings,
-		Partial:  internal.Partial,
-	}
+	result := resultFromInternal(internal)
+	m.curl(start, len(cmd), result)
+	return result
+}
+
+// CurlOptions configures ParseCurlWithOptions.
+type CurlOptions struct {
+	// FileReader, when non-nil, is called to read the local files a
+	// command names, such as a -T upload; it might be os.ReadFile, or a
+	// lookup in an archive of captured files. Files are not read without
+	// it.
+	FileReader func(name string) ([]byte, error)
+
+	// BoundarySeed, when non-zero, seeds the boundary of the body built
+	// from -F fields, for reproducible output in tests. Without it the
+	// seed is derived from the fields: the boundary looks random but a
+	// command always gives the same one. Either way it is checked against
+	// the field values and regenerated if one contains it.
+	BoundarySeed int64
 
-	if internal.Request != nil {
-		result.Request = &Request{
-			Method:  internal.Request.Method,
-			Path:    internal.Request.Path,
-			Version: internal.Request.Version,
-			Scheme:  internal.Request.Scheme,
-			Headers: convertHeaders(internal.Request.Headers),
-			Body:    internal.Request.Body,
-		}
-	}
+	// MaxGlobRequests caps how many requests are built into
+	// ParseResult.Requests for a URL with globbing patterns; 0 means
+	// DefaultMaxGlobRequests. A warning gives the count curl would send
+	// either way.
+	MaxGlobRequests int
+}
 
+// DefaultMaxGlobRequests is the number of requests ParseCurl builds from
+// a globbed URL when CurlOptions.MaxGlobRequests is zero.
+const DefaultMaxGlobRequests = fastparser.DefaultMaxGlobRequests
+
+// ParseCurlWithOptions is ParseCurl with explicit options.
+func ParseCurlWithOptions(cmd string, opts CurlOptions) *ParseResult {
+	m := currentMetrics()
+	start := m.now()
+	internal := fastparser.ParseCurlWithOptions(cmd, fastparser.CurlOptions{
+		FileReader:      opts.FileReader,
+		BoundarySeed:    opts.BoundarySeed,
+		MaxGlobRequests: opts.MaxGlobRequests,
+	})
+
+	result := resultFromInternal(internal)
+	m.curl(start, len(cmd), result)
 	return result
 }
+
+// ClientHints records what a curl command asks of the client beyond the
+// request itself. It is set by ParseCurl only.
+type ClientHints struct {
+	// BodyFile names the file a -T upload body comes from ("-" for stdin)
+	// when it was not read, leaving the request body empty.
+	BodyFile string
+
+	// Authority is the URL's host[:port], which curl connects to, when an
+	// explicit -H "Host: ..." sends a different one.
+	Authority string
+
+	// AuthScheme names the authentication curl negotiates itself:
+	// "digest", "ntlm", "negotiate" or "aws-sigv4". The request carries no
+	// Authorization header for it; Credentials holds the user:password
+	// from -u or the URL, and AWSSigV4 the --aws-sigv4 provider string
+	// (such as "aws:amz:us-east-1:s3").
+	AuthScheme  string
+	Credentials string
+	AWSSigV4    string
+
+	// Resolve and ConnectTo hold the --resolve and --connect-to entries,
+	// in order, which change the address curl connects to without
+	// changing the request.
+	Resolve   []ResolveEntry
+	ConnectTo []ConnectToEntry
+}
+
+// ResolveEntry is one address of a --resolve host:port:addr[,addr...]
+// argument: connections to Host ("*" for any host) on Port go to Address,
+// an IP address without brackets.
+type ResolveEntry struct {
+	Host    string
+	Port    int
+	Address string
+}
+
+// ConnectToEntry is a --connect-to HOST1:PORT1:HOST2:PORT2 argument:
+// connections to Host on Port go to ToHost on ToPort instead. An empty
+// host or a zero port matches any, or for ToHost and ToPort keeps the
+// original. IPv6 addresses are given without brackets.
+type ConnectToEntry struct {
+	Host   string
+	Port   int
+	ToHost string
+	ToPort int
+}
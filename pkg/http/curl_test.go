@@ -1,6 +1,7 @@
 package http
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -180,3 +181,263 @@ func TestParseCurl_PublicAPI_MatchesLenient(t *testing.T) {
 		t.Errorf("Content-Type mismatch: ParseCurl=%q, Lenient=%q", cr.Headers.Get("Content-Type"), lr.Headers.Get("Content-Type"))
 	}
 }
+
+func TestParseCurl_PublicAPI_Verdict(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want CurlVerdict
+	}{
+		{"Thanks for the report! I'll look into why the upload failed on Tuesday and get back to you.", NotCurl},
+		{"please send me the logs", NotCurl},
+		{"", NotCurl},
+		{"curl -X POST", CurlPartial},
+		{`curl -H "X-Unclosed: quote https://example.com`, CurlPartial},
+		{"curl", CurlPartial},
+		{"-X POST -d 'a=1'", CurlPartial},
+		{"curl https://example.com/docs", CurlComplete},
+		{"-sS example.com/docs", CurlComplete},
+		{"curl --bogus-flag https://example.com/", CurlComplete},
+	}
+	for _, tt := range tests {
+		result := ParseCurl(tt.cmd)
+		if result.Verdict != tt.want {
+			t.Errorf("ParseCurl(%q).Verdict = %v, want %v; warnings %q", tt.cmd, result.Verdict, tt.want, result.Warnings)
+		}
+		if result.Partial != (result.Verdict != CurlComplete) {
+			t.Errorf("ParseCurl(%q): Partial = %v with Verdict %v", tt.cmd, result.Partial, result.Verdict)
+		}
+		if tt.want == NotCurl && (result.Request != nil || len(result.Warnings) != 1) {
+			t.Errorf("ParseCurl(%q) = %+v, want no request and one warning", tt.cmd, result)
+		}
+	}
+	if v := UnmarshalLenient([]byte("GET / HTTP/1.1\r\n\r\n")).Verdict; v != 0 {
+		t.Errorf("lenient Verdict = %v, want none", v)
+	}
+}
+
+// An explicit, empty Content-Length header is kept as given rather than
+// joined by an automatic one.
+func TestParseCurl_EmptyContentLengthKept(t *testing.T) {
+	result := ParseCurl(`curl -H 'Content-Length:' -d 'abc' http://example.com/`)
+	if result.Request == nil {
+		t.Fatal("Request is nil")
+	}
+	if got := result.Request.Headers.Values("Content-Length"); len(got) != 1 || got[0] != "" {
+		t.Errorf("Content-Length values = %q, want one empty value", got)
+	}
+}
+
+// formValues parses a -F request body back into its part values.
+func formValues(t *testing.T, r *ParseResult) []string {
+	t.Helper()
+	if r.Request == nil {
+		t.Fatal("Request is nil")
+	}
+	m, err := ParseMultipartBody(r.Request.Body, r.Request.Headers.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMultipartBody: %v", err)
+	}
+	var values []string
+	for _, p := range m.Parts {
+		values = append(values, string(p.Body))
+	}
+	return values
+}
+
+func TestParseCurl_FormBoundary(t *testing.T) {
+	// The boundary that was once always used must not end a part early.
+	r := ParseCurl(`curl -F 'a=--ShapeHttpFormBoundary--' -F 'b=two' https://example.com/`)
+	if got := formValues(t, r); len(got) != 2 || got[0] != "--ShapeHttpFormBoundary--" || got[1] != "two" {
+		t.Errorf("values = %q", got)
+	}
+
+	opts := CurlOptions{BoundarySeed: 42}
+	first := ParseCurlWithOptions(`curl -F 'a=1' https://example.com/`, opts)
+	again := ParseCurlWithOptions(`curl -F 'a=1' https://example.com/`, opts)
+	ct := first.Request.Headers.Get("Content-Type")
+	if again.Request.Headers.Get("Content-Type") != ct || string(again.Request.Body) != string(first.Request.Body) {
+		t.Errorf("seed 42 gave %q, then %q", ct, again.Request.Headers.Get("Content-Type"))
+	}
+	if other := ParseCurlWithOptions(`curl -F 'a=1' https://example.com/`, CurlOptions{BoundarySeed: 43}); other.Request.Headers.Get("Content-Type") == ct {
+		t.Errorf("seeds 42 and 43 gave the same boundary")
+	}
+
+	// A value containing the boundary the seed gives first gets another.
+	boundary := strings.TrimPrefix(ct, "multipart/form-data; boundary=")
+	value := "x--" + boundary + "--"
+	r = ParseCurlWithOptions(`curl -F 'a=`+value+`' https://example.com/`, opts)
+	if r.Request.Headers.Get("Content-Type") == ct {
+		t.Errorf("boundary %s was kept though a value contains it", boundary)
+	}
+	if got := formValues(t, r); len(got) != 1 || got[0] != value {
+		t.Errorf("values = %q, want [%q]", got, value)
+	}
+}
+
+func TestParseCurl_URLGlobbing(t *testing.T) {
+	tests := []struct {
+		cmd         string
+		total       int
+		first, last string // scheme://Host+Path of the first and last request
+	}{
+		{`curl 'http://site.{one,two,three}.com'`, 3, "http://site.one.com/", "http://site.three.com/"},
+		{`curl 'https://example.com/archive[1996-1999]/vol[1-4]/part{a,b,c}.html'`, 48,
+			"https://example.com/archive1996/vol1/parta.html", "https://example.com/archive1999/vol4/partc.html"},
+		{`curl 'https://example.com/file[1-100].txt'`, 100, "https://example.com/file1.txt", "https://example.com/file100.txt"},
+		{`curl 'https://example.com/file[001-100].txt'`, 100, "https://example.com/file001.txt", "https://example.com/file100.txt"},
+		{`curl 'https://example.com/file[a-z].txt'`, 26, "https://example.com/filea.txt", "https://example.com/filez.txt"},
+		{`curl 'https://example.com/file[1-100:10].txt'`, 10, "https://example.com/file1.txt", "https://example.com/file91.txt"},
+		{`curl 'https://example.com/file[a-z:2].txt'`, 13, "https://example.com/filea.txt", "https://example.com/filey.txt"},
+		{`curl -X POST -d x=1 'https://example.com/{users,groups}?page=[1-2]'`, 4, "https://example.com/users?page=1", "https://example.com/groups?page=2"},
+	}
+	for _, tt := range tests {
+		r := ParseCurl(tt.cmd)
+		if len(r.Requests) != tt.total || r.Request != r.Requests[0] {
+			t.Errorf("%s: %d requests, want %d with Request first", tt.cmd, len(r.Requests), tt.total)
+			continue
+		}
+		url := func(req *Request) string { return req.Scheme + "://" + req.Headers.Get("Host") + req.Path }
+		if got := url(r.Requests[0]); got != tt.first {
+			t.Errorf("%s: first = %s, want %s", tt.cmd, got, tt.first)
+		}
+		if got := url(r.Requests[len(r.Requests)-1]); got != tt.last {
+			t.Errorf("%s: last = %s, want %s", tt.cmd, got, tt.last)
+		}
+		want := "URL contains globbing patterns; curl would issue " + strconv.Itoa(tt.total) + " requests"
+		if len(r.Warnings) != 1 || r.Warnings[0] != want || r.Verdict != CurlComplete {
+			t.Errorf("%s: warnings %q, verdict %v", tt.cmd, r.Warnings, r.Verdict)
+		}
+	}
+
+	// Every URL of the glob is normalized as the first one is.
+	r := ParseCurl(`curl 'http://{Bücher,API}.Example/a/../{x,z}'`)
+	var got []string
+	for _, req := range r.Requests {
+		got = append(got, req.Headers.Get("Host")+req.Path)
+	}
+	if want := "xn--bcher-kva.example/x xn--bcher-kva.example/z api.example/x api.example/z"; strings.Join(got, " ") != want {
+		t.Errorf("normalized glob URLs = %q, want %q", got, want)
+	}
+
+	r = ParseCurl(`curl -X POST -d x=1 'https://example.com/{users,groups}'`)
+	if req := r.Requests[1]; req.Method != "POST" || string(req.Body) != "x=1" || req.Headers.Get("Content-Length") != "3" {
+		t.Errorf("second request = %s %s %q, want the method and body repeated", req.Method, req.Path, req.Body)
+	}
+}
+
+func TestParseCurl_URLGlobbingLimit(t *testing.T) {
+	r := ParseCurlWithOptions(`curl 'https://example.com/img[0001-1000].png'`, CurlOptions{MaxGlobRequests: 5})
+	if len(r.Requests) != 5 || r.Requests[4].Path != "/img0005.png" {
+		t.Fatalf("got %d requests, want the first 5", len(r.Requests))
+	}
+	want := "URL contains globbing patterns; curl would issue 1000 requests, only the first 5 are built"
+	if len(r.Warnings) != 1 || r.Warnings[0] != want {
+		t.Errorf("Warnings = %q, want %q", r.Warnings, want)
+	}
+	if r := ParseCurl(`curl 'https://example.com/[1-1000]/[1-1000]'`); len(r.Requests) != DefaultMaxGlobRequests {
+		t.Errorf("default limit: %d requests, want %d", len(r.Requests), DefaultMaxGlobRequests)
+	}
+
+	// A count too large to compute is given as a lower bound.
+	r = ParseCurlWithOptions(`curl 'https://example.com/[1-999999999]/[1-999999999]'`, CurlOptions{MaxGlobRequests: 2})
+	want = "URL contains globbing patterns; curl would issue more than 1099511627776 requests, only the first 2 are built"
+	if len(r.Requests) != 2 || len(r.Warnings) != 1 || r.Warnings[0] != want {
+		t.Errorf("saturated: %d requests, Warnings = %q, want %q", len(r.Requests), r.Warnings, want)
+	}
+}
+
+func TestParseCurl_URLGlobbingLiteral(t *testing.T) {
+	tests := []struct{ cmd, path string }{
+		{`curl -g 'https://example.com/search?filter[name]=x&ids[1-2]=y&f={a,b}'`, "/search?filter[name]=x&ids[1-2]=y&f={a,b}"},
+		{`curl --globoff 'https://example.com/[1-3]'`, "/[1-3]"},
+		{`curl 'https://example.com/search?filter[name]=x&list[]=1'`, "/search?filter[name]=x&list[]=1"},
+		{`curl 'https://example.com/\[1-3\]/\{a\,b\}'`, "/[1-3]/{a,b}"},
+		{`curl 'http://[::1]:8080/items/{id}'`, "/items/{id}"},
+		{`curl 'https://example.com/[3-1]'`, "/[3-1]"},
+	}
+	for _, tt := range tests {
+		r := ParseCurl(tt.cmd)
+		if r.Requests != nil || r.Request == nil || r.Request.Path != tt.path || len(r.Warnings) != 0 {
+			t.Errorf("%s: Path %q, %d glob requests, warnings %q; want %q alone", tt.cmd, r.Request.Path, len(r.Requests), r.Warnings, tt.path)
+		}
+	}
+}
+
+func TestParseCurl_DataAndURLEncodeCombined(t *testing.T) {
+	r := ParseCurl(`curl https://example.com/f -d a=1 --data-urlencode "b=x y" -d c=3`)
+	if r.Request == nil || string(r.Request.Body) != "a=1&b=x%20y&c=3" {
+		t.Fatalf("Request = %+v", r.Request)
+	}
+	if ct := r.Request.Headers.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if r.Request.Method != "POST" || len(r.Warnings) != 0 {
+		t.Errorf("Method %q, warnings %q", r.Request.Method, r.Warnings)
+	}
+}
+
+func TestParseCurl_FormWithData(t *testing.T) {
+	r := ParseCurl(`curl https://example.com/f -d a=1 -F name=alice --data-urlencode "b=x y"`)
+	if r.Request == nil {
+		t.Fatal("no request")
+	}
+	body := string(r.Request.Body)
+	if !strings.Contains(body, "alice") || strings.Contains(body, "a=1") || strings.Contains(body, "x%20y") {
+		t.Errorf("body = %q, want only the -F form", body)
+	}
+	if !strings.HasPrefix(r.Request.Headers.Get("Content-Type"), "multipart/form-data; ") {
+		t.Errorf("Content-Type = %q", r.Request.Headers.Get("Content-Type"))
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "-F cannot be combined with -d and --data-urlencode,") ||
+		!strings.Contains(r.Warnings[0], "the -d and --data-urlencode data was dropped") {
+		t.Errorf("Warnings = %q", r.Warnings)
+	}
+
+	// The warning names only the options given.
+	r = ParseCurl(`curl https://example.com/f -F name=alice --data-urlencode "b=x y"`)
+	if len(r.Warnings) != 1 || strings.Contains(r.Warnings[0], "-d ") || !strings.Contains(r.Warnings[0], "the --data-urlencode data was dropped") {
+		t.Errorf("--data-urlencode only: Warnings = %q", r.Warnings)
+	}
+}
+
+func TestParseCurl_DotSegments(t *testing.T) {
+	tests := []struct{ cmd, path, url string }{
+		{`curl https://example.com/a/../b`, "/b", "https://example.com/b"},
+		{`curl https://example.com/a/./b/../c/`, "/a/c/", "https://example.com/a/c/"},
+		{`curl 'https://example.com/a/b/..?next=../x/./y'`, "/a/?next=../x/./y", "https://example.com/a/?next=../x/./y"},
+		{`curl https://example.com/../../etc/passwd`, "/etc/passwd", "https://example.com/etc/passwd"},
+		{`curl https://example.com/a/..`, "/", "https://example.com/"},
+	}
+	for _, tt := range tests {
+		r := ParseCurl(tt.cmd)
+		if r.Request == nil || r.Request.Path != tt.path || r.URL != tt.url {
+			t.Errorf("%s: Path %q, URL %q; want %q, %q", tt.cmd, r.Request.Path, r.URL, tt.path, tt.url)
+			continue
+		}
+		if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "--path-as-is") || !strings.Contains(r.Warnings[0], strconv.Quote(tt.path)) {
+			t.Errorf("%s: warnings %q, want one naming the new path", tt.cmd, r.Warnings)
+		}
+	}
+}
+
+func TestParseCurl_DotSegmentsKept(t *testing.T) {
+	tests := []struct{ cmd, path string }{
+		{`curl --path-as-is https://example.com/a/../b`, "/a/../b"},
+		{`curl --path-as-is 'https://example.com/../../etc/passwd'`, "/../../etc/passwd"},
+		{`curl https://example.com/a/%2e%2e/b`, "/a/%2e%2e/b"},
+		{`curl https://example.com/a/%2E./b`, "/a/%2E./b"},
+		{`curl https://example.com/v1.2/file.tar.gz`, "/v1.2/file.tar.gz"},
+		{`curl https://example.com/a/...b/..c`, "/a/...b/..c"},
+	}
+	for _, tt := range tests {
+		r := ParseCurl(tt.cmd)
+		if r.Request == nil || r.Request.Path != tt.path || len(r.Warnings) != 0 {
+			t.Errorf("%s: Path %q, warnings %q; want %q alone", tt.cmd, r.Request.Path, r.Warnings, tt.path)
+		}
+	}
+
+	r := ParseCurl(`curl 'https://example.com/x/../page[1-2]'`)
+	if len(r.Requests) != 2 || r.Requests[1].Path != "/page2" {
+		t.Errorf("globbed requests = %+v, want dot segments removed from each", r.Requests)
+	}
+}
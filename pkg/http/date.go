@@ -0,0 +1,144 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormat is the IMF-fixdate layout of RFC 9110 §5.6.7, the preferred
+// format for HTTP dates. Times must be in UTC for the "GMT" suffix to hold.
+const TimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// Obsolete date layouts that recipients must still accept (RFC 9110 §5.6.7).
+const (
+	rfc850Format  = "Monday, 02-Jan-06 15:04:05 GMT"
+	asctimeFormat = "Mon Jan _2 15:04:05 2006"
+)
+
+// ParseHTTPDate parses an HTTP-date in any of the three formats RFC 9110
+// requires recipients to accept:
+//
+//	IMF-fixdate: Sun, 06 Nov 1994 08:49:37 GMT
+//	RFC 850:     Sunday, 06-Nov-94 08:49:37 GMT
+//	asctime:     Sun Nov  6 08:49:37 1994
+//
+// Two-digit RFC 850 years are resolved per RFC 9110: a year that would be
+// more than 50 years in the future is taken to be in the past century.
+// The returned time is in UTC.
+func ParseHTTPDate(value string) (time.Time, error) {
+	return parseHTTPDate(value, time.Now(), false)
+}
+
+// ParseHTTPDateLenient is ParseHTTPDate that additionally accepts ISO 8601
+// timestamps (RFC 3339, e.g. "1994-11-06T08:49:37Z"), which some servers
+// emit even though they are not valid HTTP-dates.
+func ParseHTTPDateLenient(value string) (time.Time, error) {
+	return parseHTTPDate(value, time.Now(), true)
+}
+
+// FormatHTTPDate formats t as an IMF-fixdate in GMT, e.g.
+// "Sun, 06 Nov 1994 08:49:37 GMT".
+func FormatHTTPDate(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+func parseHTTPDate(value string, now time.Time, lenient bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(TimeFormat, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(rfc850Format, value); err == nil {
+		return resolveTwoDigitYear(t, now), nil
+	}
+	if t, err := time.Parse(asctimeFormat, value); err == nil {
+		return t, nil
+	}
+	if lenient {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("http: invalid HTTP-date %q", value)
+}
+
+// resolveTwoDigitYear places a two-digit year in the current century unless
+// that is more than 50 years ahead of now, in which case the previous
+// century is used (RFC 9110 §5.6.7).
+func resolveTwoDigitYear(t, now time.Time) time.Time {
+	century := now.UTC().Year() / 100 * 100
+	year := century + t.Year()%100
+	if year > now.UTC().Year()+50 {
+		year -= 100
+	}
+	return t.AddDate(year-t.Year(), 0, 0)
+}
+
+// headerDate parses the named header as an HTTP-date.
+func headerDate(h Headers, key string) (time.Time, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := ParseHTTPDate(v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Date returns the parsed Date header. ok is false if the header is absent
+// or not a valid HTTP-date.
+func (r *Response) Date() (t time.Time, ok bool) {
+	return headerDate(r.Headers, "Date")
+}
+
+// LastModified returns the parsed Last-Modified header. ok is false if the
+// header is absent or not a valid HTTP-date.
+func (r *Response) LastModified() (t time.Time, ok bool) {
+	return headerDate(r.Headers, "Last-Modified")
+}
+
+// Expires returns the parsed Expires header. ok is false if the header is
+// absent or invalid; note that RFC 9111 §5.3 treats an invalid Expires
+// (such as "0") as a time in the past.
+func (r *Response) Expires() (t time.Time, ok bool) {
+	return headerDate(r.Headers, "Expires")
+}
+
+// IfModifiedSince returns the parsed If-Modified-Since header. ok is false
+// if the header is absent or not a valid HTTP-date.
+func (r *Request) IfModifiedSince() (t time.Time, ok bool) {
+	return headerDate(r.Headers, "If-Modified-Since")
+}
+
+// RetryAfter returns the delay indicated by the Retry-After header, which
+// may be either delta-seconds ("120") or an HTTP-date. A date is measured
+// from the response's Date header when present, otherwise from now, and a
+// date in the past yields zero. ok is false if the header is absent or
+// invalid.
+func (r *Response) RetryAfter() (d time.Duration, ok bool) {
+	return r.retryAfter(time.Now())
+}
+
+func (r *Response) retryAfter(now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(r.Headers.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := parseHTTPDate(v, now, false)
+	if err != nil {
+		return 0, false
+	}
+	if date, ok := r.Date(); ok {
+		now = date
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
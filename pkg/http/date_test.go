@@ -0,0 +1,150 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+var dateNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseHTTPDate_Formats(t *testing.T) {
+	want := time.Date(1994, 11, 6, 8, 49, 37, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"IMF-fixdate", "Sun, 06 Nov 1994 08:49:37 GMT"},
+		{"RFC 850", "Sunday, 06-Nov-94 08:49:37 GMT"},
+		{"asctime", "Sun Nov  6 08:49:37 1994"},
+		{"surrounding whitespace", "  Sun, 06 Nov 1994 08:49:37 GMT "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHTTPDate(tt.value, dateNow, false)
+			if err != nil {
+				t.Fatalf("parseHTTPDate(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("parseHTTPDate(%q) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+}
+
+// TestParseHTTPDate_TwoDigitYear checks the RFC 9110 rule: a two-digit year
+// more than 50 years in the future belongs to the previous century.
+func TestParseHTTPDate_TwoDigitYear(t *testing.T) {
+	tests := []struct {
+		value string
+		year  int
+	}{
+		{"Thursday, 01-Jan-26 00:00:00 GMT", 2026},
+		{"Friday, 01-Jan-70 00:00:00 GMT", 2070},
+		{"Saturday, 01-Jan-76 00:00:00 GMT", 2076},
+		{"Sunday, 01-Jan-77 00:00:00 GMT", 1977},
+		{"Sunday, 06-Nov-94 08:49:37 GMT", 1994},
+		{"Monday, 01-Jan-01 00:00:00 GMT", 2001},
+	}
+	for _, tt := range tests {
+		got, err := parseHTTPDate(tt.value, dateNow, false)
+		if err != nil {
+			t.Errorf("parseHTTPDate(%q) error = %v", tt.value, err)
+			continue
+		}
+		if got.Year() != tt.year {
+			t.Errorf("parseHTTPDate(%q) year = %d, want %d", tt.value, got.Year(), tt.year)
+		}
+	}
+}
+
+func TestParseHTTPDate_Invalid(t *testing.T) {
+	for _, v := range []string{"", "0", "-1", "yesterday", "1994-11-06T08:49:37Z", "Sun, 06 Nov 1994 08:49:37 PST"} {
+		if _, err := ParseHTTPDate(v); err == nil {
+			t.Errorf("ParseHTTPDate(%q) expected error", v)
+		}
+	}
+}
+
+func TestParseHTTPDateLenient_ISO8601(t *testing.T) {
+	want := time.Date(1994, 11, 6, 8, 49, 37, 0, time.UTC)
+	for _, v := range []string{"1994-11-06T08:49:37Z", "1994-11-06T09:49:37+01:00", "Sun, 06 Nov 1994 08:49:37 GMT"} {
+		got, err := ParseHTTPDateLenient(v)
+		if err != nil {
+			t.Errorf("ParseHTTPDateLenient(%q) error = %v", v, err)
+			continue
+		}
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("ParseHTTPDateLenient(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestFormatHTTPDate(t *testing.T) {
+	in := time.Date(1994, 11, 6, 9, 49, 37, 0, time.FixedZone("CET", 3600))
+	if got, want := FormatHTTPDate(in), "Sun, 06 Nov 1994 08:49:37 GMT"; got != want {
+		t.Errorf("FormatHTTPDate() = %q, want %q", got, want)
+	}
+	back, err := ParseHTTPDate(FormatHTTPDate(in))
+	if err != nil || !back.Equal(in) {
+		t.Errorf("round trip = %v, %v; want %v", back, err, in)
+	}
+}
+
+func TestDateAccessors(t *testing.T) {
+	want := time.Date(1994, 11, 6, 8, 49, 37, 0, time.UTC)
+	resp := &Response{Headers: Headers{
+		{Key: "Date", Value: "Sun, 06 Nov 1994 08:49:37 GMT"},
+		{Key: "Last-Modified", Value: "Sunday, 06-Nov-94 08:49:37 GMT"},
+		{Key: "Expires", Value: "0"},
+	}}
+	if got, ok := resp.Date(); !ok || !got.Equal(want) {
+		t.Errorf("Date() = %v, %v; want %v, true", got, ok, want)
+	}
+	if got, ok := resp.LastModified(); !ok || !got.Equal(want) {
+		t.Errorf("LastModified() = %v, %v; want %v, true", got, ok, want)
+	}
+	if _, ok := resp.Expires(); ok {
+		t.Error("Expires() ok = true for invalid value, want false")
+	}
+	if _, ok := (&Response{}).Date(); ok {
+		t.Error("Date() ok = true for absent header, want false")
+	}
+
+	req := &Request{Headers: Headers{{Key: "If-Modified-Since", Value: "Sun Nov  6 08:49:37 1994"}}}
+	if got, ok := req.IfModifiedSince(); !ok || !got.Equal(want) {
+		t.Errorf("IfModifiedSince() = %v, %v; want %v, true", got, ok, want)
+	}
+	if _, ok := (&Request{}).IfModifiedSince(); ok {
+		t.Error("IfModifiedSince() ok = true for absent header, want false")
+	}
+}
+
+func TestResponse_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers Headers
+		want    time.Duration
+		ok      bool
+	}{
+		{"delta-seconds", Headers{{Key: "Retry-After", Value: "120"}}, 120 * time.Second, true},
+		{"zero", Headers{{Key: "Retry-After", Value: "0"}}, 0, true},
+		{"date from now", Headers{{Key: "Retry-After", Value: "Thu, 01 Jan 2026 00:02:00 GMT"}}, 2 * time.Minute, true},
+		{"date from Date header", Headers{
+			{Key: "Date", Value: "Thu, 01 Jan 2026 00:01:00 GMT"},
+			{Key: "Retry-After", Value: "Thu, 01 Jan 2026 00:02:00 GMT"},
+		}, time.Minute, true},
+		{"date in past", Headers{{Key: "Retry-After", Value: "Sun, 06 Nov 1994 08:49:37 GMT"}}, 0, true},
+		{"absent", nil, 0, false},
+		{"negative", Headers{{Key: "Retry-After", Value: "-5"}}, 0, false},
+		{"garbage", Headers{{Key: "Retry-After", Value: "soon"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &Response{Headers: tt.headers}
+			got, ok := resp.retryAfter(dateNow)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("RetryAfter() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -3,28 +3,184 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
 )
 
+// DefaultMaxHeaderBytes is the default limit on the size of the start line
+// plus header section read by a Decoder. It matches net/http's default.
+const DefaultMaxHeaderBytes = 1 << 20
+
+// DefaultMaxBodyBytes is the default limit on the size of a body read by a
+// Decoder, the same as DefaultMaxStdBodyBytes.
+const DefaultMaxBodyBytes = 32 << 20
+
 // Decoder reads HTTP messages from an input stream in HTTP/1.1 wire format.
+// Empty lines before a start line, such as a stray CRLF between pipelined
+// requests, are skipped as RFC 9112 §2.2 allows.
 // A single Decoder is not safe for concurrent use; create one per goroutine
 // or serialize access externally.
 type Decoder struct {
-	r *bufio.Reader
+	src            io.Reader
+	r              *bufio.Reader
+	maxHeaderBytes int
+	maxBodyBytes   int
+	headerBudget   int   // bytes left for the current header section; <0 means unlimited
+	err            error // sticky error after a context cancellation
+
+	trace      *ParserTrace
+	off        int       // bytes of the stream consumed so far
+	phaseStart time.Time // when the current traced phase began
 }
 
 // NewDecoder returns a new decoder that reads from r.
 // The decoder uses buffered reading for efficient parsing.
 func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: bufio.NewReader(r)}
+	return &Decoder{src: r, r: bufio.NewReader(r), maxHeaderBytes: DefaultMaxHeaderBytes, maxBodyBytes: DefaultMaxBodyBytes}
+}
+
+// SetMaxHeaderBytes limits the number of bytes read for the start line and
+// header section of each message. A header section that never terminates
+// produces an error once the limit is exceeded instead of buffering without
+// bound. n <= 0 removes the limit. The default is DefaultMaxHeaderBytes.
+func (dec *Decoder) SetMaxHeaderBytes(n int) {
+	dec.maxHeaderBytes = n
+}
+
+// SetMaxBodyBytes limits the size of each message body, whether framed by
+// Content-Length, chunked coding or the end of the stream. A larger body
+// is an error, reported before it is buffered where the framing gives its
+// size. n <= 0 removes the limit. The default is DefaultMaxBodyBytes.
+func (dec *Decoder) SetMaxBodyBytes(n int) {
+	dec.maxBodyBytes = n
+}
+
+// SetTrace sets hooks called as the decoder finishes the start line, the
+// header section and the body of each message, as ParserLimits.Trace does
+// for the strict parser. PhaseInfo offsets count from the start of the
+// stream, so they keep growing across messages. A nil t, the default,
+// removes the hooks, and the decoder then does not read the clock.
+func (dec *Decoder) SetTrace(t *ParserTrace) {
+	dec.trace = t
+}
+
+// phaseDone calls hook, if set, with the phase from start to the current
+// offset, and starts timing the next phase.
+func (dec *Decoder) phaseDone(hook func(PhaseInfo), start int) {
+	now := time.Now()
+	if hook != nil {
+		hook(PhaseInfo{Start: start, End: dec.off, Duration: now.Sub(dec.phaseStart)})
+	}
+	dec.phaseStart = now
+}
+
+// bodyTooLarge reports whether n bytes of body exceed the limit.
+func (dec *Decoder) bodyTooLarge(n int64) bool {
+	return dec.maxBodyBytes > 0 && n > int64(dec.maxBodyBytes)
+}
+
+// errBodyTooLarge is the error for a body over the limit.
+func (dec *Decoder) errBodyTooLarge() error {
+	return fmt.Errorf("http: decode: body exceeds %d bytes", dec.maxBodyBytes)
+}
+
+// readDeadliner is implemented by readers such as net.Conn whose blocking
+// reads can be interrupted by a deadline.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// DecodeRequestContext is like DecodeRequest but returns ctx.Err() promptly
+// when ctx is canceled or its deadline passes, even if the underlying reader
+// is blocked.
+//
+// If the reader implements SetReadDeadline (e.g. net.Conn), the context's
+// deadline is applied to it and cancellation interrupts the pending read;
+// the read deadline is cleared before returning. Otherwise the read runs in
+// a separate goroutine that is abandoned on cancellation.
+//
+// A context error leaves the stream positioned mid-message, so every later
+// call on the Decoder returns the same error.
+func (dec *Decoder) DecodeRequestContext(ctx context.Context) (*Request, error) {
+	req := &Request{}
+	if err := dec.withContext(ctx, func() error { return dec.decodeRequest(req) }); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// DecodeResponseContext is like DecodeResponse but honours ctx cancellation
+// and deadlines. See DecodeRequestContext for details.
+func (dec *Decoder) DecodeResponseContext(ctx context.Context) (*Response, error) {
+	resp := &Response{}
+	if err := dec.withContext(ctx, func() error { return dec.decodeResponse(resp) }); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withContext runs fn, aborting it when ctx is done.
+func (dec *Decoder) withContext(ctx context.Context, fn func() error) error {
+	if dec.err != nil {
+		return dec.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if rd, ok := dec.src.(readDeadliner); ok {
+		if d, ok := ctx.Deadline(); ok {
+			_ = rd.SetReadDeadline(d)
+		}
+		stop := context.AfterFunc(ctx, func() {
+			// A deadline in the past unblocks any pending Read immediately.
+			_ = rd.SetReadDeadline(time.Unix(1, 0))
+		})
+		err := fn()
+		stop()
+		_ = rd.SetReadDeadline(time.Time{})
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			dec.err = ctxErr
+			return ctxErr
+		}
+		if d, ok := ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) && !time.Now().Before(d) {
+			dec.err = context.DeadlineExceeded
+			return dec.err
+		}
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		dec.err = ctx.Err()
+		return dec.err
+	}
 }
 
 // Decode reads the next HTTP message and stores it in v.
 // v must be a *Request or *Response.
 func (dec *Decoder) Decode(v interface{}) error {
+	if dec.err != nil {
+		return dec.err
+	}
+	if err := dec.skipEmptyLines(); err != nil {
+		return err
+	}
 	// Peek to determine message type
 	prefix, err := dec.r.Peek(5)
 	if err != nil {
@@ -33,24 +189,28 @@ func (dec *Decoder) Decode(v interface{}) error {
 
 	isResponse := bytes.HasPrefix(prefix, []byte("HTTP/"))
 
+	m := currentMetrics()
 	switch target := v.(type) {
 	case *Request:
 		if isResponse {
-			return fmt.Errorf("http: data appears to be a response but target is *Request")
+			return m.failed("decode", -1, fmt.Errorf("http: data appears to be a response but target is *Request"))
 		}
 		return dec.decodeRequest(target)
 	case *Response:
 		if !isResponse {
-			return fmt.Errorf("http: data appears to be a request but target is *Response")
+			return m.failed("decode", -1, fmt.Errorf("http: data appears to be a request but target is *Response"))
 		}
 		return dec.decodeResponse(target)
 	default:
-		return fmt.Errorf("http: Decode unsupported type %T", v)
+		return m.failed("decode", -1, fastparser.WithKind(fmt.Errorf("http: Decode unsupported type %T", v), ErrUnsupportedType))
 	}
 }
 
 // DecodeRequest reads the next HTTP request from the stream.
 func (dec *Decoder) DecodeRequest() (*Request, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
 	req := &Request{}
 	if err := dec.decodeRequest(req); err != nil {
 		return nil, err
@@ -58,8 +218,13 @@ func (dec *Decoder) DecodeRequest() (*Request, error) {
 	return req, nil
 }
 
-// DecodeResponse reads the next HTTP response from the stream.
+// DecodeResponse reads the next HTTP response from the stream. A response
+// framed by neither Content-Length nor chunked coding is read to the end of
+// the stream, and 1xx, 204 and 304 responses have no body.
 func (dec *Decoder) DecodeResponse() (*Response, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
 	resp := &Response{}
 	if err := dec.decodeResponse(resp); err != nil {
 		return nil, err
@@ -67,20 +232,39 @@ func (dec *Decoder) DecodeResponse() (*Response, error) {
 	return resp, nil
 }
 
-func (dec *Decoder) decodeRequest(req *Request) error {
+func (dec *Decoder) decodeRequest(req *Request) (err error) {
+	m := currentMetrics()
+	start := m.now()
+	defer func() { m.outcome("decode", start, -1, err) }()
+	dec.startHeaderSection()
+	if err := dec.skipEmptyLines(); err != nil {
+		return err
+	}
+	lineStart := dec.off
+	if dec.trace != nil {
+		dec.phaseStart = time.Now()
+	}
+
 	// Read request line
 	line, err := dec.readLine()
 	if err != nil {
+		if err == io.EOF {
+			m = nil // the stream ended between messages; nothing was decoded
+		}
 		return fmt.Errorf("http: decode request: %w", err)
 	}
 
 	parts := strings.SplitN(line, " ", 3)
 	if len(parts) < 3 {
-		return fmt.Errorf("http: decode request: malformed request line: %q", line)
+		return fastparser.WithKind(fmt.Errorf("http: decode request: malformed request line: %q", line), ErrMalformedStartLine)
 	}
 	req.Method = parts[0]
 	req.Path = parts[1]
 	req.Version = parts[2]
+	headerStart := dec.off
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnStartLine, lineStart)
+	}
 
 	// Read headers
 	headers, err := dec.readHeaders()
@@ -88,38 +272,64 @@ func (dec *Decoder) decodeRequest(req *Request) error {
 		return err
 	}
 	req.Headers = headers
+	bodyStart := dec.off
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnHeadersDone, headerStart)
+	}
 
 	// Read body
-	body, err := dec.readBody(headers)
+	body, err := dec.readBody(headers, false)
 	if err != nil {
 		return err
 	}
 	req.Body = body
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnBodyDone, bodyStart)
+	}
 
 	return nil
 }
 
-func (dec *Decoder) decodeResponse(resp *Response) error {
+func (dec *Decoder) decodeResponse(resp *Response) (err error) {
+	m := currentMetrics()
+	start := m.now()
+	defer func() { m.outcome("decode", start, -1, err) }()
+	dec.startHeaderSection()
+	if err := dec.skipEmptyLines(); err != nil {
+		return err
+	}
+	lineStart := dec.off
+	if dec.trace != nil {
+		dec.phaseStart = time.Now()
+	}
+
 	// Read status line
 	line, err := dec.readLine()
 	if err != nil {
+		if err == io.EOF {
+			m = nil // the stream ended between messages; nothing was decoded
+		}
 		return fmt.Errorf("http: decode response: %w", err)
 	}
 
 	parts := strings.SplitN(line, " ", 3)
 	if len(parts) < 2 {
-		return fmt.Errorf("http: decode response: malformed status line: %q", line)
+		return fastparser.WithKind(fmt.Errorf("http: decode response: malformed status line: %q", line), ErrMalformedStartLine)
 	}
 
 	resp.Version = parts[0]
 	code, convErr := strconv.Atoi(parts[1])
 	if convErr != nil {
-		return fmt.Errorf("http: decode response: invalid status code: %q", parts[1])
+		return fastparser.WithKind(fmt.Errorf("http: decode response: invalid status code: %q", parts[1]), ErrInvalidStatusCode)
 	}
 	resp.StatusCode = code
 	if len(parts) >= 3 {
 		resp.Reason = parts[2]
 	}
+	headerStart := dec.off
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnStartLine, lineStart)
+	}
 
 	// Read headers
 	headers, err := dec.readHeaders()
@@ -127,26 +337,87 @@ func (dec *Decoder) decodeResponse(resp *Response) error {
 		return err
 	}
 	resp.Headers = headers
+	bodyStart := dec.off
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnHeadersDone, headerStart)
+	}
 
-	// Read body
-	body, err := dec.readBody(headers)
-	if err != nil {
-		return err
+	// Read body; 1xx, 204 and 304 responses have none whatever their headers say
+	if code >= 200 && code != 204 && code != 304 {
+		body, err := dec.readBody(headers, true)
+		if err != nil {
+			return err
+		}
+		resp.Body = body
+	}
+	if dec.trace != nil {
+		dec.phaseDone(dec.trace.OnBodyDone, bodyStart)
 	}
-	resp.Body = body
 
 	return nil
 }
 
+// skipEmptyLines discards the CRLF or LF lines before a start line, which
+// RFC 9112 §2.2 lets a recipient ignore, such as the stray CRLF some
+// clients send between pipelined requests. It reads at most
+// maxHeaderBytes of them, so a stream of nothing else ends in an error
+// rather than being read forever.
+func (dec *Decoder) skipEmptyLines() error {
+	skipped := 0
+	for {
+		b, _ := dec.r.Peek(2)
+		switch {
+		case len(b) > 0 && b[0] == '\n':
+			dec.r.Discard(1)
+			skipped++
+			dec.off++
+		case len(b) == 2 && b[0] == '\r' && b[1] == '\n':
+			dec.r.Discard(2)
+			skipped += 2
+			dec.off += 2
+		default:
+			// Anything else, end of stream included, is for the start line.
+			return nil
+		}
+		if dec.maxHeaderBytes > 0 && skipped > dec.maxHeaderBytes {
+			return fmt.Errorf("http: decode: more than %d bytes of empty lines before the start line", dec.maxHeaderBytes)
+		}
+	}
+}
+
+// startHeaderSection resets the header byte budget for a new message.
+func (dec *Decoder) startHeaderSection() {
+	dec.headerBudget = dec.maxHeaderBytes
+	if dec.headerBudget <= 0 {
+		dec.headerBudget = -1
+	}
+}
+
 // readLine reads a line from the buffered reader, stripping CRLF or LF.
+// While a header section is being read, bytes are charged against the
+// header budget and an error is returned once it is exhausted.
 func (dec *Decoder) readLine() (string, error) {
-	line, err := dec.r.ReadString('\n')
-	if err != nil && len(line) == 0 {
-		return "", err
+	var buf []byte
+	for {
+		frag, err := dec.r.ReadSlice('\n')
+		dec.off += len(frag)
+		if dec.headerBudget >= 0 {
+			if len(frag) > dec.headerBudget {
+				return "", fmt.Errorf("http: decode: header section exceeds %d bytes", dec.maxHeaderBytes)
+			}
+			dec.headerBudget -= len(frag)
+		}
+		buf = append(buf, frag...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && len(buf) == 0 {
+			return "", err
+		}
+		break
 	}
 	// Strip trailing \r\n or \n
-	line = strings.TrimRight(line, "\r\n")
-	return line, nil
+	return strings.TrimRight(string(buf), "\r\n"), nil
 }
 
 // readHeaders reads header lines until an empty line.
@@ -161,13 +432,14 @@ func (dec *Decoder) readHeaders() (Headers, error) {
 
 		// Empty line = end of headers
 		if line == "" {
+			dec.headerBudget = -1
 			return headers, nil
 		}
 
 		// Parse "Key: Value"
 		colon := strings.IndexByte(line, ':')
 		if colon < 0 {
-			return nil, fmt.Errorf("http: decode: malformed header line: %q", line)
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode: malformed header line: %q", fastparser.RedactHeaderLine(line)), ErrMalformedHeader)
 		}
 
 		key := line[:colon]
@@ -176,31 +448,75 @@ func (dec *Decoder) readHeaders() (Headers, error) {
 	}
 }
 
-// readBody reads the message body based on headers.
-func (dec *Decoder) readBody(headers Headers) ([]byte, error) {
-	// Check Content-Length
-	cl := headers.ContentLength()
-	if cl > 0 {
+// readBody reads the message body based on headers: chunked when the
+// Transfer-Encoding fields, joined, end in chunked, which overrides
+// Content-Length, else Content-Length bytes. An unframed response (toEOF) runs to the end of
+// the stream, as RFC 9112 §6.3 requires, so that nothing in its body is
+// read as a further message; an unframed request has no body.
+func (dec *Decoder) readBody(headers Headers, toEOF bool) ([]byte, error) {
+	if fields := headers.Values("Transfer-Encoding"); len(fields) > 0 {
+		te := strings.Join(fields, ", ")
+		codings := strings.Split(te, ",")
+		if strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked") {
+			return dec.readChunkedBody()
+		}
+		if !toEOF {
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode: request Transfer-Encoding %q does not end in chunked", te), ErrMalformedHeader)
+		}
+		return dec.readToEOF()
+	}
+
+	if v, ok := headers.Lookup("Content-Length"); ok {
+		cl := headers.ContentLength()
+		if cl < 0 {
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode: invalid Content-Length %q", v), ErrMalformedHeader)
+		}
+		for _, other := range headers.Values("Content-Length") {
+			if strings.TrimSpace(other) != strings.TrimSpace(v) {
+				return nil, fastparser.WithKind(fmt.Errorf("http: decode: conflicting Content-Length values %q and %q", v, other), ErrMalformedHeader)
+			}
+		}
+		if cl == 0 {
+			return nil, nil
+		}
+		if dec.bodyTooLarge(cl) {
+			return nil, dec.errBodyTooLarge()
+		}
 		body := make([]byte, cl)
-		_, err := io.ReadFull(dec.r, body)
+		n, err := io.ReadFull(dec.r, body)
+		dec.off += n
 		if err != nil {
-			return nil, fmt.Errorf("http: decode body: %w", err)
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode body: %w", err), ErrBodyTruncated)
 		}
 		return body, nil
 	}
-	if cl == 0 {
-		return nil, nil
-	}
 
-	// Check chunked
-	if headers.IsChunked() {
-		return dec.readChunkedBody()
+	if toEOF {
+		return dec.readToEOF()
 	}
-
-	// No Content-Length, not chunked — no body for streaming decoder
 	return nil, nil
 }
 
+// readToEOF reads the rest of the stream as a body, nil if it is empty.
+func (dec *Decoder) readToEOF() ([]byte, error) {
+	src := io.Reader(dec.r)
+	if dec.maxBodyBytes > 0 {
+		src = io.LimitReader(dec.r, int64(dec.maxBodyBytes)+1)
+	}
+	body, err := io.ReadAll(src)
+	dec.off += len(body)
+	if err != nil {
+		return nil, fmt.Errorf("http: decode body: %w", err)
+	}
+	if dec.bodyTooLarge(int64(len(body))) {
+		return nil, dec.errBodyTooLarge()
+	}
+	if len(body) == 0 {
+		return nil, nil
+	}
+	return body, nil
+}
+
 // readChunkedBody reads a chunked transfer-encoded body from the stream.
 func (dec *Decoder) readChunkedBody() ([]byte, error) {
 	var result []byte
@@ -209,7 +525,7 @@ func (dec *Decoder) readChunkedBody() ([]byte, error) {
 		// Read chunk size line
 		sizeLine, err := dec.readLine()
 		if err != nil {
-			return nil, fmt.Errorf("http: decode chunked: %w", err)
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode chunked: %w", err), ErrInvalidChunk, ErrBodyTruncated)
 		}
 
 		// Strip chunk extension
@@ -220,25 +536,40 @@ func (dec *Decoder) readChunkedBody() ([]byte, error) {
 
 		size, err := strconv.ParseInt(sizeLine, 16, 64)
 		if err != nil {
-			return nil, fmt.Errorf("http: decode chunked: invalid chunk size %q: %w", sizeLine, err)
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode chunked: invalid chunk size %q: %w", sizeLine, err), ErrInvalidChunk)
 		}
 
 		if size == 0 {
-			// Read trailing CRLF
-			dec.readLine()
+			// Skip the trailer section through its blank line
+			for {
+				line, err := dec.readLine()
+				if err != nil {
+					return nil, fastparser.WithKind(fmt.Errorf("http: decode chunked: %w", err), ErrInvalidChunk, ErrBodyTruncated)
+				}
+				if line == "" {
+					break
+				}
+			}
 			break
 		}
 
+		if dec.bodyTooLarge(int64(len(result)) + size) {
+			return nil, dec.errBodyTooLarge()
+		}
+
 		// Read chunk data
 		chunk := make([]byte, size)
-		_, err = io.ReadFull(dec.r, chunk)
+		n, err := io.ReadFull(dec.r, chunk)
+		dec.off += n
 		if err != nil {
-			return nil, fmt.Errorf("http: decode chunked: %w", err)
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode chunked: %w", err), ErrInvalidChunk, ErrBodyTruncated)
 		}
 		result = append(result, chunk...)
 
 		// Read trailing CRLF after chunk data
-		dec.readLine()
+		if line, err := dec.readLine(); err != nil || line != "" {
+			return nil, fastparser.WithKind(fmt.Errorf("http: decode chunked: expected CRLF after chunk data"), ErrInvalidChunk)
+		}
 	}
 
 	if len(result) == 0 {
@@ -2,8 +2,15 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDecoder_Request(t *testing.T) {
@@ -214,6 +221,28 @@ func TestDecoder_RequestChunkedBody(t *testing.T) {
 	}
 }
 
+func TestDecoder_RequestChunkedInSecondField(t *testing.T) {
+	// Transfer-Encoding split over two fields is one list: gzip, chunked.
+	data := "POST / HTTP/1.1\r\nTransfer-Encoding: gzip\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n" +
+		"GET /next HTTP/1.1\r\n\r\n"
+	dec := NewDecoder(bytes.NewReader([]byte(data)))
+
+	req, err := dec.DecodeRequest()
+	if err != nil {
+		t.Fatalf("DecodeRequest() error = %v", err)
+	}
+	if string(req.Body) != "hello" {
+		t.Errorf("Body = %q, want hello", string(req.Body))
+	}
+	next, err := dec.DecodeRequest()
+	if err != nil {
+		t.Fatalf("second DecodeRequest() error = %v", err)
+	}
+	if next.Path != "/next" {
+		t.Errorf("second Path = %q, want /next", next.Path)
+	}
+}
+
 func TestDecoder_ChunkedBodyTruncated(t *testing.T) {
 	// Chunked body that is truncated mid-stream
 	data := "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhel"
@@ -407,3 +436,287 @@ func TestDecodeResponse_ReadLineError(t *testing.T) {
 		t.Error("decodeResponse() = nil, want error when reader fails immediately")
 	}
 }
+
+// stallingPipe returns a client conn whose peer writes partial, then blocks.
+func stallingPipe(t *testing.T, partial string) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		_, _ = server.Write([]byte(partial))
+		// Never write the rest; close when the test ends.
+	}()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client
+}
+
+func TestDecoder_DecodeRequestContext_Deadline(t *testing.T) {
+	conn := stallingPipe(t, "GET /api HTTP/1.1\r\nHost: exa")
+	dec := NewDecoder(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := dec.DecodeRequestContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DecodeRequestContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("DecodeRequestContext() took %v, want prompt return", elapsed)
+	}
+
+	// The decoder is left mid-message; later calls keep failing.
+	if _, err := dec.DecodeRequest(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DecodeRequest() after deadline error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDecoder_DecodeResponseContext_Cancel(t *testing.T) {
+	conn := stallingPipe(t, "HTTP/1.1 200 OK\r\nContent-")
+	dec := NewDecoder(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := dec.DecodeResponseContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecodeResponseContext() error = %v, want context.Canceled", err)
+	}
+}
+
+// blockingReader blocks in Read until unblock is closed; it has no deadline support.
+type blockingReader struct {
+	data    []byte
+	unblock chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	if len(r.data) > 0 {
+		n := copy(p, r.data)
+		r.data = r.data[n:]
+		return n, nil
+	}
+	<-r.unblock
+	return 0, io.EOF
+}
+
+func TestDecoder_DecodeRequestContext_NoDeadlineSupport(t *testing.T) {
+	r := &blockingReader{data: []byte("GET / HTTP/1.1\r\nHost: ex"), unblock: make(chan struct{})}
+	defer close(r.unblock)
+	dec := NewDecoder(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := dec.DecodeRequestContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DecodeRequestContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDecoder_DecodeRequestContext_Success(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		_, _ = server.Write([]byte("POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\nhello"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := NewDecoder(client).DecodeRequestContext(ctx)
+	if err != nil {
+		t.Fatalf("DecodeRequestContext() error = %v", err)
+	}
+	if req.Method != "POST" || string(req.Body) != "hello" {
+		t.Errorf("got %s %q, want POST hello", req.Method, req.Body)
+	}
+}
+
+func TestDecoder_DecodeRequestContext_AlreadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dec := NewDecoder(bytes.NewReader([]byte("GET / HTTP/1.1\r\n\r\n")))
+	if _, err := dec.DecodeRequestContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("DecodeRequestContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestDecoder_MaxHeaderBytes(t *testing.T) {
+	data := "GET / HTTP/1.1\r\nX-Big: " + strings.Repeat("a", 200) + "\r\n\r\n"
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.SetMaxHeaderBytes(100)
+	if _, err := dec.DecodeRequest(); err == nil || !strings.Contains(err.Error(), "exceeds 100 bytes") {
+		t.Errorf("DecodeRequest() error = %v, want header size error", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(data))
+	dec.SetMaxHeaderBytes(0)
+	if _, err := dec.DecodeRequest(); err != nil {
+		t.Errorf("DecodeRequest() with no limit error = %v", err)
+	}
+}
+
+func TestDecoder_MaxHeaderBytes_BodyNotCounted(t *testing.T) {
+	body := strings.Repeat("b", 500)
+	data := "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n1f4\r\n" + body + "\r\n0\r\n\r\n"
+	dec := NewDecoder(strings.NewReader(data))
+	dec.SetMaxHeaderBytes(64)
+	req, err := dec.DecodeRequest()
+	if err != nil {
+		t.Fatalf("DecodeRequest() error = %v", err)
+	}
+	if string(req.Body) != body {
+		t.Errorf("len(Body) = %d, want 500", len(req.Body))
+	}
+}
+
+func TestDecoder_MaxHeaderBytes_NeverEndingHeaders(t *testing.T) {
+	// A header section that never terminates must fail once the default
+	// limit is crossed rather than buffering the whole stream.
+	r := io.MultiReader(strings.NewReader("GET / HTTP/1.1\r\n"), &repeatReader{line: []byte("X-A: b\r\n")})
+	if _, err := NewDecoder(r).DecodeRequest(); err == nil {
+		t.Fatal("DecodeRequest() error = nil, want header size error")
+	}
+}
+
+func TestDecoder_MaxBodyBytes(t *testing.T) {
+	body := strings.Repeat("b", 200)
+	tests := []struct {
+		name   string
+		data   string
+		decode func(*Decoder) error
+	}{
+		{"content-length", "POST / HTTP/1.1\r\nContent-Length: 200\r\n\r\n" + body, decodeReq},
+		{"chunked", "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n64\r\n" + body[:100] + "\r\n64\r\n" + body[100:] + "\r\n0\r\n\r\n", decodeReq},
+		{"to eof", "HTTP/1.1 200 OK\r\n\r\n" + body, decodeResp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.data))
+			dec.SetMaxBodyBytes(150)
+			if err := tt.decode(dec); err == nil || !strings.Contains(err.Error(), "body exceeds 150 bytes") {
+				t.Errorf("decode error = %v, want body size error", err)
+			}
+
+			dec = NewDecoder(strings.NewReader(tt.data))
+			dec.SetMaxBodyBytes(200)
+			if err := tt.decode(dec); err != nil {
+				t.Errorf("decode at the limit error = %v", err)
+			}
+
+			dec = NewDecoder(strings.NewReader(tt.data))
+			dec.SetMaxBodyBytes(0)
+			if err := tt.decode(dec); err != nil {
+				t.Errorf("decode with no limit error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_MaxBodyBytes_EndlessBody(t *testing.T) {
+	// A response read to the end of a stream that never ends must fail once
+	// the default limit is crossed rather than buffering without bound.
+	r := io.MultiReader(strings.NewReader("HTTP/1.1 200 OK\r\n\r\n"), &repeatReader{line: []byte("data\r\n")})
+	if _, err := NewDecoder(r).DecodeResponse(); err == nil || !strings.Contains(err.Error(), "body exceeds") {
+		t.Fatalf("DecodeResponse() error = %v, want body size error", err)
+	}
+}
+
+func TestDecoder_Trace(t *testing.T) {
+	var infos [][2]int
+	hook := func(info PhaseInfo) {
+		if info.Duration < 0 {
+			t.Errorf("Duration = %v", info.Duration)
+		}
+		infos = append(infos, [2]int{info.Start, info.End})
+	}
+	stream := "\r\n" + string(chunkedResponse) + string(chunkedResponse)
+	dec := NewDecoder(strings.NewReader(stream))
+	dec.SetTrace(&ParserTrace{OnStartLine: hook, OnHeadersDone: hook, OnBodyDone: hook})
+	for i := 0; i < 2; i++ {
+		if _, err := dec.DecodeResponse(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Offsets run on from one message to the next, past the leading CRLF.
+	n := len(chunkedResponse)
+	want := [][2]int{{2, 19}, {19, 49}, {49, 2 + n}, {2 + n, 19 + n}, {19 + n, 49 + n}, {49 + n, 2 + 2*n}}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("phases = %v, want %v", infos, want)
+	}
+
+	// A request whose headers fail never reaches the body phase.
+	infos = nil
+	dec = NewDecoder(strings.NewReader("GET / HTTP/1.1\r\nBad Header\r\n\r\n"))
+	dec.SetTrace(&ParserTrace{OnBodyDone: hook})
+	if _, err := dec.DecodeRequest(); err == nil {
+		t.Fatal("error = nil, want the malformed header reported")
+	}
+	if len(infos) != 0 {
+		t.Errorf("phases = %v, want none", infos)
+	}
+}
+
+func decodeReq(dec *Decoder) error {
+	_, err := dec.DecodeRequest()
+	return err
+}
+
+func decodeResp(dec *Decoder) error {
+	_, err := dec.DecodeResponse()
+	return err
+}
+
+// repeatReader yields line forever.
+type repeatReader struct {
+	line []byte
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n+len(r.line) <= len(p) {
+		n += copy(p[n:], r.line)
+	}
+	return n, nil
+}
+
+func TestDecoder_LeadingEmptyLines(t *testing.T) {
+	for _, lead := range []string{"\r\n", "\r\n\r\n\r\n", "\n"} {
+		dec := NewDecoder(strings.NewReader(lead + "GET /a HTTP/1.1\r\nHost: example.com\r\n\r\n" +
+			lead + "GET /b HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+		for _, path := range []string{"/a", "/b"} {
+			req, err := dec.DecodeRequest()
+			if err != nil || req.Path != path {
+				t.Fatalf("%q: DecodeRequest = %+v, %v; want %s", lead, req, err, path)
+			}
+		}
+
+		var resp Response
+		dec = NewDecoder(strings.NewReader(lead + "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+		if err := dec.Decode(&resp); err != nil || resp.StatusCode != 200 || string(resp.Body) != "ok" {
+			t.Errorf("%q: Decode response = %+v, %v", lead, resp, err)
+		}
+	}
+}
+
+func TestDecoder_OnlyEmptyLines(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("\r\n\r\n\r\n"))
+	if _, err := dec.DecodeRequest(); !errors.Is(err, io.EOF) {
+		t.Errorf("DecodeRequest error = %v, want EOF", err)
+	}
+	dec = NewDecoder(strings.NewReader("\r\n\n"))
+	if err := dec.Decode(&Response{}); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode error = %v, want EOF", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(strings.Repeat("\r\n", 1000) + "GET / HTTP/1.1\r\n\r\n"))
+	dec.SetMaxHeaderBytes(100)
+	if _, err := dec.DecodeRequest(); err == nil || errors.Is(err, io.EOF) || !strings.Contains(err.Error(), "empty lines") {
+		t.Errorf("DecodeRequest error = %v, want the empty line limit", err)
+	}
+}
@@ -6,10 +6,10 @@ import "strconv"
 // It appends "METHOD PATH VERSION\r\n" followed by headers and body.
 func appendRequest(buf []byte, req *Request) ([]byte, error) {
 	if req.Method == "" {
-		return nil, &ParseError{Message: "request method is empty"}
+		return buf, &ParseError{Message: "request method is empty"}
 	}
 	if req.Path == "" {
-		return nil, &ParseError{Message: "request path is empty"}
+		return buf, &ParseError{Message: "request path is empty"}
 	}
 
 	version := req.Version
@@ -20,8 +20,9 @@ func appendRequest(buf []byte, req *Request) ([]byte, error) {
 	buf = appendRequestLine(buf, req.Method, req.Path, version)
 	buf = appendHeaders(buf, req.Headers)
 
-	// Auto-set Content-Length if body present and header absent
-	if len(req.Body) > 0 && req.Headers.Get("Content-Length") == "" && !req.Headers.IsChunked() {
+	// Auto-set Content-Length if body present and header absent; an empty
+	// Content-Length counts as present, so it is not sent twice.
+	if len(req.Body) > 0 && !req.Headers.Has("Content-Length") && !req.Headers.IsChunked() {
 		buf = append(buf, "Content-Length: "...)
 		buf = strconv.AppendInt(buf, int64(len(req.Body)), 10)
 		buf = appendCRLF(buf)
@@ -46,8 +47,9 @@ func appendResponse(buf []byte, resp *Response) []byte {
 	buf = appendStatusLine(buf, version, resp.StatusCode, resp.Reason)
 	buf = appendHeaders(buf, resp.Headers)
 
-	// Auto-set Content-Length if body present and header absent
-	if len(resp.Body) > 0 && resp.Headers.Get("Content-Length") == "" && !resp.Headers.IsChunked() {
+	// Auto-set Content-Length if body present and header absent; an empty
+	// Content-Length counts as present, so it is not sent twice.
+	if len(resp.Body) > 0 && !resp.Headers.Has("Content-Length") && !resp.Headers.IsChunked() {
 		buf = append(buf, "Content-Length: "...)
 		buf = strconv.AppendInt(buf, int64(len(resp.Body)), 10)
 		buf = appendCRLF(buf)
@@ -71,3 +73,50 @@ func appendHeaders(buf []byte, headers Headers) []byte {
 	}
 	return buf
 }
+
+// requestWireSize returns the length appendRequest would add to a buffer.
+// It must change whenever appendRequest does.
+func requestWireSize(req *Request) int {
+	version := req.Version
+	if version == "" {
+		version = "HTTP/1.1"
+	}
+	n := len(req.Method) + 1 + len(req.Path) + 1 + len(version) + 2
+	return n + bodyWireSize(req.Headers, req.Body)
+}
+
+// responseWireSize returns the length appendResponse would add to a
+// buffer. It must change whenever appendResponse does.
+func responseWireSize(resp *Response) int {
+	version := resp.Version
+	if version == "" {
+		version = "HTTP/1.1"
+	}
+	n := len(version) + 1 + decimalLen(resp.StatusCode) + 1 + len(resp.Reason) + 2
+	return n + bodyWireSize(resp.Headers, resp.Body)
+}
+
+// bodyWireSize returns the length of the header section, the blank line
+// and the body that follow the start line.
+func bodyWireSize(headers Headers, body []byte) int {
+	n := 0
+	for _, h := range headers {
+		n += len(h.Key) + 2 + len(h.Value) + 2
+	}
+	if len(body) > 0 && !headers.Has("Content-Length") && !headers.IsChunked() {
+		n += len("Content-Length: ") + decimalLen(len(body)) + 2
+	}
+	return n + 2 + len(body)
+}
+
+// decimalLen returns the length of strconv.Itoa(v).
+func decimalLen(v int) int {
+	n := 1
+	if v < 0 {
+		n++
+	}
+	for ; v >= 10 || v <= -10; v /= 10 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,264 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// EqualOptions controls how Equal compares messages and Hash summarizes
+// them. The zero value compares headers as an unordered multiset with
+// case-insensitive names and bodies byte for byte. RawBody and RawHeaders
+// are never compared.
+type EqualOptions struct {
+	HeaderOrder        bool     // headers must appear in the same order
+	CaseSensitiveNames bool     // "Accept" and "accept" are different headers
+	IgnoreHeaders      []string // names left out, matched case-insensitively
+
+	// JSONBody compares bodies that are both valid JSON by value, so key
+	// order and insignificant whitespace do not matter. Other bodies are
+	// compared byte for byte.
+	JSONBody bool
+}
+
+// Equal reports whether r and other are the same message under opts:
+// method, target, scheme and version are compared exactly, then headers
+// and body as opts says. Two nil requests are equal.
+func (r *Request) Equal(other *Request, opts EqualOptions) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Method == other.Method && r.Path == other.Path && r.Scheme == other.Scheme &&
+		r.Version == other.Version &&
+		headersEqual(r.Headers, other.Headers, &opts) && bodiesEqual(r.Body, other.Body, &opts)
+}
+
+// Hash returns a 64-bit hash of r consistent with Equal: requests that
+// are Equal under opts have the same Hash. It allocates nothing unless
+// opts.JSONBody has a JSON body to canonicalize.
+func (r *Request) Hash(opts EqualOptions) uint64 {
+	if r == nil {
+		return fnvOffset
+	}
+	h := newFNV()
+	h.str(r.Method, false)
+	h.str(r.Path, false)
+	h.str(r.Scheme, false)
+	h.str(r.Version, false)
+	h.mix(headersHash(r.Headers, &opts))
+	h.mix(bodyHash(r.Body, &opts))
+	return uint64(h)
+}
+
+// Equal reports whether r and other are the same message under opts:
+// version, status code and reason are compared exactly, then headers and
+// body as opts says. Two nil responses are equal.
+func (r *Response) Equal(other *Response, opts EqualOptions) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Version == other.Version && r.StatusCode == other.StatusCode && r.Reason == other.Reason &&
+		headersEqual(r.Headers, other.Headers, &opts) && bodiesEqual(r.Body, other.Body, &opts)
+}
+
+// Hash returns a 64-bit hash of r consistent with Equal; see Request.Hash.
+func (r *Response) Hash(opts EqualOptions) uint64 {
+	if r == nil {
+		return fnvOffset
+	}
+	h := newFNV()
+	h.str(r.Version, false)
+	h.mix(uint64(r.StatusCode))
+	h.str(r.Reason, false)
+	h.mix(headersHash(r.Headers, &opts))
+	h.mix(bodyHash(r.Body, &opts))
+	return uint64(h)
+}
+
+func (o *EqualOptions) ignored(name string) bool {
+	for _, n := range o.IgnoreHeaders {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *EqualOptions) sameHeader(a, b Header) bool {
+	if o.CaseSensitiveNames {
+		return a.Key == b.Key && a.Value == b.Value
+	}
+	return asciiEqualFold(a.Key, b.Key) && a.Value == b.Value
+}
+
+// asciiEqualFold is strings.EqualFold limited to ASCII letters, matching
+// the folding Hash applies.
+func asciiEqualFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+func headersEqual(a, b Headers, o *EqualOptions) bool {
+	if o.HeaderOrder {
+		i, j := 0, 0
+		for {
+			for i < len(a) && o.ignored(a[i].Key) {
+				i++
+			}
+			for j < len(b) && o.ignored(b[j].Key) {
+				j++
+			}
+			if i == len(a) || j == len(b) {
+				return i == len(a) && j == len(b)
+			}
+			if !o.sameHeader(a[i], b[j]) {
+				return false
+			}
+			i, j = i+1, j+1
+		}
+	}
+
+	// As multisets: every kept header of a pairs with a distinct one of b.
+	used := make([]bool, len(b))
+	n := 0
+	for _, ha := range a {
+		if o.ignored(ha.Key) {
+			continue
+		}
+		n++
+		found := false
+		for j, hb := range b {
+			if !used[j] && o.sameHeader(ha, hb) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, hb := range b {
+		if !o.ignored(hb.Key) {
+			n--
+		}
+	}
+	return n == 0
+}
+
+// headersHash hashes the kept headers in order, or, when order does not
+// matter, sums the hashes of the individual headers so any permutation
+// gives the same result.
+func headersHash(headers Headers, o *EqualOptions) uint64 {
+	seq := newFNV()
+	var sum uint64
+	for _, h := range headers {
+		if o.ignored(h.Key) {
+			continue
+		}
+		if o.HeaderOrder {
+			seq.str(h.Key, !o.CaseSensitiveNames)
+			seq.str(h.Value, false)
+			continue
+		}
+		one := newFNV()
+		one.str(h.Key, !o.CaseSensitiveNames)
+		one.str(h.Value, false)
+		sum += uint64(one)
+	}
+	if o.HeaderOrder {
+		return uint64(seq)
+	}
+	return sum
+}
+
+func bodiesEqual(a, b []byte, o *EqualOptions) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if !o.JSONBody {
+		return false
+	}
+	ca, okA := canonicalJSON(a)
+	cb, okB := canonicalJSON(b)
+	return okA && okB && bytes.Equal(ca, cb)
+}
+
+func bodyHash(body []byte, o *EqualOptions) uint64 {
+	if o.JSONBody {
+		if c, ok := canonicalJSON(body); ok {
+			body = c
+		}
+	}
+	h := newFNV()
+	for _, c := range body {
+		h.byte(c)
+	}
+	return uint64(h)
+}
+
+// canonicalJSON re-encodes a JSON body with sorted object keys and no
+// insignificant whitespace; numbers keep their literal form.
+func canonicalJSON(body []byte) ([]byte, bool) {
+	if len(body) == 0 || !json.Valid(body) {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// fnv is a streaming 64-bit FNV-1a hash over strings and bytes, so fields
+// can be hashed, case-folded if need be, without converting them.
+type fnv uint64
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+func newFNV() fnv { return fnvOffset }
+
+func (h *fnv) byte(c byte) {
+	*h = (*h ^ fnv(c)) * fnvPrime
+}
+
+// str hashes s, ASCII-lowercased if fold, followed by a 0xff terminator
+// that no UTF-8 text contains, so adjacent fields cannot run together.
+func (h *fnv) str(s string, fold bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if fold && 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		h.byte(c)
+	}
+	h.byte(0xff)
+}
+
+func (h *fnv) mix(v uint64) {
+	for i := 0; i < 8; i++ {
+		h.byte(byte(v >> (8 * i)))
+	}
+}
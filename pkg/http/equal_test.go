@@ -0,0 +1,120 @@
+package http
+
+import "testing"
+
+func TestRequestEqual(t *testing.T) {
+	base := &Request{Method: "POST", Path: "/items", Version: "HTTP/1.1", Headers: Headers{
+		{Key: "Host", Value: "example.com"},
+		{Key: "Content-Type", Value: "application/json"},
+		{Key: "X-Request-ID", Value: "abc"},
+	}, Body: []byte(`{"a":1,"b":[true,null]}`)}
+
+	reordered := *base
+	reordered.Headers = Headers{base.Headers[2], {Key: "content-type", Value: "application/json"}, base.Headers[0]}
+	otherID := *base
+	otherID.Headers = Headers{base.Headers[0], base.Headers[1], {Key: "X-Request-ID", Value: "xyz"}}
+	spacedJSON := *base
+	spacedJSON.Body = []byte("{ \"b\": [true, null],\n  \"a\": 1 }")
+
+	tests := []struct {
+		name  string
+		other *Request
+		opts  EqualOptions
+		want  bool
+	}{
+		{"identical", base, EqualOptions{}, true},
+		{"reordered, names folded", &reordered, EqualOptions{}, true},
+		{"reordered, order significant", &reordered, EqualOptions{HeaderOrder: true}, false},
+		{"name case significant", &reordered, EqualOptions{CaseSensitiveNames: true}, false},
+		{"different request ID", &otherID, EqualOptions{}, false},
+		{"request ID ignored", &otherID, EqualOptions{IgnoreHeaders: []string{"x-request-id"}}, true},
+		{"request ID ignored, ordered", &otherID, EqualOptions{HeaderOrder: true, IgnoreHeaders: []string{"X-Request-Id"}}, true},
+		{"JSON body, raw", &spacedJSON, EqualOptions{}, false},
+		{"JSON body, canonical", &spacedJSON, EqualOptions{JSONBody: true}, true},
+		{"extra header", &Request{Method: "POST", Path: "/items", Version: "HTTP/1.1", Headers: append(base.Headers.Clone(), Header{Key: "Host", Value: "example.com"}), Body: base.Body}, EqualOptions{}, false},
+		{"nil", nil, EqualOptions{}, false},
+	}
+	for _, tt := range tests {
+		if got := base.Equal(tt.other, tt.opts); got != tt.want {
+			t.Errorf("%s: Equal = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.want && base.Hash(tt.opts) != tt.other.Hash(tt.opts) {
+			t.Errorf("%s: equal requests hash differently", tt.name)
+		}
+	}
+}
+
+func TestResponseEqual(t *testing.T) {
+	a := &Response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Headers: Headers{{Key: "Date", Value: "Mon"}, {Key: "ETag", Value: `"1"`}}}
+	b := &Response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Headers: Headers{{Key: "etag", Value: `"1"`}, {Key: "Date", Value: "Tue"}}}
+	opts := EqualOptions{IgnoreHeaders: []string{"Date"}}
+	if !a.Equal(b, opts) || a.Hash(opts) != b.Hash(opts) {
+		t.Errorf("Equal = %v, hashes %x %x; want equal", a.Equal(b, opts), a.Hash(opts), b.Hash(opts))
+	}
+	b.StatusCode = 304
+	if a.Equal(b, opts) || a.Hash(opts) == b.Hash(opts) {
+		t.Error("different status codes compare equal")
+	}
+	if !(*Response)(nil).Equal(nil, opts) {
+		t.Error("nil responses are not equal")
+	}
+}
+
+// TestHash_SeedCollisions checks that the seed corpora, parsed, hash to
+// distinct values unless the messages are Equal.
+func TestHash_SeedCollisions(t *testing.T) {
+	for _, opts := range []EqualOptions{{}, {HeaderOrder: true}, {JSONBody: true}} {
+		reqs := make(map[uint64]*Request)
+		for _, seed := range requestSeeds {
+			req, err := UnmarshalRequest(seed)
+			if err != nil {
+				continue
+			}
+			h := req.Hash(opts)
+			if prev, ok := reqs[h]; ok && !prev.Equal(req, opts) {
+				t.Errorf("%+v: hash collision between %q and %q", opts, prev, req)
+			}
+			reqs[h] = req
+		}
+		resps := make(map[uint64]*Response)
+		for _, seed := range responseSeeds {
+			resp, err := UnmarshalResponse(seed)
+			if err != nil {
+				continue
+			}
+			h := resp.Hash(opts)
+			if prev, ok := resps[h]; ok && !prev.Equal(resp, opts) {
+				t.Errorf("%+v: hash collision between %q and %q", opts, prev, resp)
+			}
+			resps[h] = resp
+		}
+		if len(reqs) < 2 || len(resps) < 2 {
+			t.Fatalf("%+v: only %d request and %d response hashes", opts, len(reqs), len(resps))
+		}
+	}
+}
+
+func TestHash_NoAllocs(t *testing.T) {
+	req, err := UnmarshalRequest(simpleRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := EqualOptions{IgnoreHeaders: []string{"User-Agent"}}
+	if allocs := testing.AllocsPerRun(100, func() { req.Hash(opts) }); allocs != 0 {
+		t.Errorf("Hash allocates %v times, want 0", allocs)
+	}
+}
+
+// BenchmarkRequestHash should report well over 1M hashes/s for a small GET.
+func BenchmarkRequestHash(b *testing.B) {
+	req, err := UnmarshalRequest(simpleRequest)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req.Hash(EqualOptions{})
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "hashes/s")
+}
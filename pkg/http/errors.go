@@ -1,6 +1,44 @@
 package http
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// Categories of parse failure. The errors returned by Unmarshal, the
+// parsers and Decoder keep their descriptive messages, and errors.Is
+// reports which of these categories they fall in:
+//
+//	if errors.Is(err, http.ErrBodyTruncated) {
+//	    // wait for more data
+//	}
+//
+// An error may be in more than one category: a truncated chunked body is
+// both ErrInvalidChunk and ErrBodyTruncated.
+var (
+	// ErrMalformedStartLine is a request or status line that cannot be
+	// parsed, including an invalid request-target.
+	ErrMalformedStartLine = fastparser.ErrMalformedStartLine
+	// ErrMalformedHeader is a header line that cannot be parsed.
+	ErrMalformedHeader = fastparser.ErrMalformedHeader
+	// ErrWhitespaceBeforeColon is a header name followed by whitespace,
+	// which RFC 9112 §5.1 requires rejecting. It is also ErrMalformedHeader.
+	ErrWhitespaceBeforeColon = fastparser.ErrWhitespaceBeforeColon
+	// ErrBareLineEnding is a line ending in LF alone, or a CR without LF,
+	// rejected under ParserLimits.RequireCRLF.
+	ErrBareLineEnding = fastparser.ErrBareLineEnding
+	// ErrBodyTruncated is a body shorter than its framing promises.
+	ErrBodyTruncated = fastparser.ErrBodyTruncated
+	// ErrInvalidChunk is a chunked body that does not follow the chunked
+	// format.
+	ErrInvalidChunk = fastparser.ErrInvalidChunk
+	// ErrInvalidStatusCode is a status line whose code is not a number.
+	ErrInvalidStatusCode = fastparser.ErrInvalidStatusCode
+	// ErrUnsupportedType is a target passed to Unmarshal or Decode that is
+	// neither *Request nor *Response.
+	ErrUnsupportedType = fastparser.ErrUnsupportedType
+)
 
 // ParseError represents an error that occurred during HTTP message parsing.
 // It is a terminal error type — it does not wrap another error, so Unwrap
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorCategories(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() error
+		want  []error
+	}{
+		{"request line", func() error { _, err := UnmarshalRequest([]byte("GET\r\n\r\n")); return err },
+			[]error{ErrMalformedStartLine}},
+		{"request-target", func() error {
+			_, err := UnmarshalRequest([]byte("GET http://user@x/ HTTP/1.1\r\nHost: x\r\n\r\n"))
+			return err
+		},
+			[]error{ErrMalformedStartLine}},
+		{"status line", func() error { _, err := UnmarshalResponse([]byte("HTTP/1.1\r\n\r\n")); return err },
+			[]error{ErrMalformedStartLine}},
+		{"status code", func() error { _, err := UnmarshalResponse([]byte("HTTP/1.1 abc OK\r\n\r\n")); return err },
+			[]error{ErrInvalidStatusCode}},
+		{"header", func() error { _, err := UnmarshalRequest([]byte("GET / HTTP/1.1\r\nHost x\r\n\r\n")); return err },
+			[]error{ErrMalformedHeader}},
+		{"whitespace before colon", func() error {
+			_, err := UnmarshalRequest([]byte("GET / HTTP/1.1\r\nHost : x\r\n\r\n"))
+			return err
+		}, []error{ErrWhitespaceBeforeColon, ErrMalformedHeader}},
+		{"body", func() error {
+			_, err := UnmarshalRequest([]byte("POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 10\r\n\r\nshort"))
+			return err
+		}, []error{ErrBodyTruncated}},
+		{"chunk size", func() error {
+			_, err := UnmarshalResponse([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n"))
+			return err
+		}, []error{ErrInvalidChunk}},
+		{"chunk data", func() error {
+			_, err := UnmarshalResponse([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n10\r\nabc"))
+			return err
+		}, []error{ErrInvalidChunk, ErrBodyTruncated}},
+		{"dechunk", func() error { _, _, _, err := DechunkBytes([]byte("5\r\nab")); return err },
+			[]error{ErrInvalidChunk, ErrBodyTruncated}},
+		{"split", func() error { _, err := SplitMessages([]byte("HTTP/1.1 abc OK\r\n\r\n"), RoleServer); return err },
+			[]error{ErrInvalidStatusCode}},
+		{"unmarshal type", func() error { return Unmarshal([]byte("GET / HTTP/1.1\r\n\r\n"), new(string)) },
+			[]error{ErrUnsupportedType}},
+		{"decode type", func() error { return NewDecoder(strings.NewReader("GET / HTTP/1.1\r\n\r\n")).Decode(new(string)) },
+			[]error{ErrUnsupportedType}},
+		{"decode request line", func() error { _, err := NewDecoder(strings.NewReader("GET\r\n\r\n")).DecodeRequest(); return err },
+			[]error{ErrMalformedStartLine}},
+		{"decode status code", func() error {
+			_, err := NewDecoder(strings.NewReader("HTTP/1.1 abc OK\r\n\r\n")).DecodeResponse()
+			return err
+		}, []error{ErrInvalidStatusCode}},
+		{"decode header", func() error {
+			_, err := NewDecoder(strings.NewReader("GET / HTTP/1.1\r\nHost x\r\n\r\n")).DecodeRequest()
+			return err
+		}, []error{ErrMalformedHeader}},
+		{"decode body", func() error {
+			_, err := NewDecoder(strings.NewReader("POST / HTTP/1.1\r\nContent-Length: 10\r\n\r\nshort")).DecodeRequest()
+			return err
+		}, []error{ErrBodyTruncated}},
+		{"decode chunk size", func() error {
+			_, err := NewDecoder(strings.NewReader("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n")).DecodeResponse()
+			return err
+		}, []error{ErrInvalidChunk}},
+	}
+	categories := []error{ErrMalformedStartLine, ErrMalformedHeader, ErrWhitespaceBeforeColon,
+		ErrBodyTruncated, ErrInvalidChunk, ErrInvalidStatusCode, ErrUnsupportedType}
+	for _, tt := range tests {
+		err := tt.parse()
+		if err == nil {
+			t.Errorf("%s: err = nil", tt.name)
+			continue
+		}
+		for _, c := range categories {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == c
+			}
+			if errors.Is(err, c) != want {
+				t.Errorf("%s: errors.Is(%q, %q) = %v, want %v", tt.name, err, c, !want, want)
+			}
+		}
+	}
+}
+
+func TestErrorCategories_MessageUnchanged(t *testing.T) {
+	_, err := UnmarshalRequest([]byte("GET / HTTP/1.1\r\nHost : x\r\n\r\n"))
+	want := "http: parse error at line 3: whitespace before colon in header name: Host "
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
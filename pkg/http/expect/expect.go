@@ -0,0 +1,232 @@
+// Package expect checks a parsed response against the expectations of a
+// contract test: its status class, Content-Type, headers and values in a
+// JSON body.
+//
+//	errs := expect.Response(resp).
+//		Status2xx().
+//		ContentType("application/json").
+//		JSONPath("$.data.id", expect.NotEmpty).
+//		HeaderPresent("ETag").
+//		Check()
+//
+// Check runs every expectation and returns all the failures, each naming
+// the actual value, so one run reports everything that is wrong.
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// Expectation collects expectations about a response; see Response.
+type Expectation struct {
+	resp   *http.Response
+	checks []func(*state) error
+}
+
+// state is what Check computes once and the checks share.
+type state struct {
+	decoded bool
+	body    interface{}
+	err     error
+}
+
+// Response starts a list of expectations about resp.
+func Response(resp *http.Response) *Expectation {
+	return &Expectation{resp: resp}
+}
+
+func (e *Expectation) add(check func(*state) error) *Expectation {
+	e.checks = append(e.checks, check)
+	return e
+}
+
+// Status expects the status code to be code.
+func (e *Expectation) Status(code int) *Expectation {
+	return e.add(func(*state) error {
+		if e.resp.StatusCode != code {
+			return fmt.Errorf("status %d, want %d", e.resp.StatusCode, code)
+		}
+		return nil
+	})
+}
+
+// StatusClass expects the status code to be in the class whose first
+// digit is class, such as 2 for 200 to 299.
+func (e *Expectation) StatusClass(class int) *Expectation {
+	return e.add(func(*state) error {
+		if e.resp.StatusCode/100 != class {
+			return fmt.Errorf("status %d, want %dxx", e.resp.StatusCode, class)
+		}
+		return nil
+	})
+}
+
+// Status2xx expects a successful status.
+func (e *Expectation) Status2xx() *Expectation { return e.StatusClass(2) }
+
+// Status3xx expects a redirection status.
+func (e *Expectation) Status3xx() *Expectation { return e.StatusClass(3) }
+
+// Status4xx expects a client error status.
+func (e *Expectation) Status4xx() *Expectation { return e.StatusClass(4) }
+
+// Status5xx expects a server error status.
+func (e *Expectation) Status5xx() *Expectation { return e.StatusClass(5) }
+
+// ContentType expects the Content-Type header to name mediaType, compared
+// case-insensitively and ignoring parameters such as charset.
+func (e *Expectation) ContentType(mediaType string) *Expectation {
+	return e.add(func(*state) error {
+		ct := e.resp.Headers.Get("Content-Type")
+		if ct == "" {
+			return fmt.Errorf("Content-Type absent, want %s", mediaType)
+		}
+		got, _, err := mime.ParseMediaType(ct)
+		if err != nil || !strings.EqualFold(got, mediaType) {
+			return fmt.Errorf("Content-Type %q, want %s", ct, mediaType)
+		}
+		return nil
+	})
+}
+
+// HeaderPresent expects the response to have a header named name.
+func (e *Expectation) HeaderPresent(name string) *Expectation {
+	return e.add(func(*state) error {
+		if len(e.resp.Headers.Values(name)) == 0 {
+			return fmt.Errorf("header %s absent", name)
+		}
+		return nil
+	})
+}
+
+// Header expects the first header named name to have the given value.
+func (e *Expectation) Header(name, value string) *Expectation {
+	return e.add(func(*state) error {
+		values := e.resp.Headers.Values(name)
+		switch {
+		case len(values) == 0:
+			return fmt.Errorf("header %s absent, want %q", name, value)
+		case values[0] != value:
+			return fmt.Errorf("header %s is %q, want %q", name, values[0], value)
+		}
+		return nil
+	})
+}
+
+// JSONPath expects the body to be JSON with a value at path that m
+// accepts. A path is "$" followed by child accessors: ".name", "['name']"
+// or "[index]" for arrays; filters and wildcards are not supported.
+func (e *Expectation) JSONPath(path string, m Matcher) *Expectation {
+	return e.add(func(s *state) error {
+		steps, err := parsePath(path)
+		if err != nil {
+			return err
+		}
+		if !s.decoded {
+			s.decoded = true
+			if err := json.Unmarshal(e.resp.Body, &s.body); err != nil {
+				s.err = fmt.Errorf("body is not JSON: %v", err)
+			}
+		}
+		if s.err != nil {
+			return fmt.Errorf("%s: %v", path, s.err)
+		}
+		v, err := lookup(s.body, steps)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err := m(v); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// Check runs the expectations in the order they were added and returns
+// the failures, or nil if all were met.
+func (e *Expectation) Check() []error {
+	if e.resp == nil {
+		return []error{fmt.Errorf("no response")}
+	}
+	var s state
+	var errs []error
+	for _, check := range e.checks {
+		if err := check(&s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// A Matcher checks a value decoded from JSON: nil, bool, float64, string,
+// []interface{} or map[string]interface{}. It returns an error describing
+// the value when it does not match.
+type Matcher func(v interface{}) error
+
+// Exists accepts any value, so JSONPath only checks that the path exists.
+func Exists(interface{}) error { return nil }
+
+// NotEmpty accepts any value except null, false, 0, "" and an empty array
+// or object.
+func NotEmpty(v interface{}) error {
+	empty := false
+	switch v := v.(type) {
+	case nil:
+		empty = true
+	case bool:
+		empty = !v
+	case float64:
+		empty = v == 0
+	case string:
+		empty = v == ""
+	case []interface{}:
+		empty = len(v) == 0
+	case map[string]interface{}:
+		empty = len(v) == 0
+	}
+	if empty {
+		return fmt.Errorf("got %s, want a non-empty value", encode(v))
+	}
+	return nil
+}
+
+// Equals returns a Matcher that accepts values equal to want once want is
+// encoded to JSON and decoded, so that Equals(1) matches the number 1.
+func Equals(want interface{}) Matcher {
+	data, err := json.Marshal(want)
+	var norm interface{}
+	if err == nil {
+		err = json.Unmarshal(data, &norm)
+	}
+	return func(v interface{}) error {
+		if err != nil {
+			return fmt.Errorf("cannot compare with %v: %v", want, err)
+		}
+		if !reflect.DeepEqual(v, norm) {
+			return fmt.Errorf("got %s, want %s", encode(v), data)
+		}
+		return nil
+	}
+}
+
+// maxEncoded bounds the length of a value quoted in a message.
+const maxEncoded = 64
+
+// encode returns v as compact JSON for messages, shortened to maxEncoded
+// bytes.
+func encode(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > maxEncoded {
+		return string(data[:maxEncoded]) + "..."
+	}
+	return string(data)
+}
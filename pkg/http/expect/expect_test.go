@@ -0,0 +1,135 @@
+package expect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		Version:    "HTTP/1.1",
+		StatusCode: status,
+		Headers: http.Headers{
+			{Key: "Content-Type", Value: "application/json; charset=utf-8"},
+			{Key: "ETag", Value: `"v1"`},
+		},
+		Body: []byte(body),
+	}
+}
+
+func TestCheck_Pass(t *testing.T) {
+	resp := jsonResponse(200, `{"data":{"id":"42","tags":["a","b"],"count":3,"ok":true}}`)
+	errs := Response(resp).
+		Status2xx().
+		Status(200).
+		ContentType("Application/JSON").
+		HeaderPresent("etag").
+		Header("ETag", `"v1"`).
+		JSONPath("$.data.id", NotEmpty).
+		JSONPath("$.data.id", Equals("42")).
+		JSONPath("$.data.count", Equals(3)).
+		JSONPath("$['data'].ok", Equals(true)).
+		JSONPath("$.data.tags", Equals([]string{"a", "b"})).
+		JSONPath("$.data.tags[1]", Exists).
+		Check()
+	if errs != nil {
+		t.Errorf("Check = %v, want nil", errs)
+	}
+}
+
+func TestCheck_AllFailuresReported(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 503,
+		Headers:    http.Headers{{Key: "Content-Type", Value: "text/html"}},
+		Body:       []byte(`{"data":{"id":""}}`),
+	}
+	errs := Response(resp).
+		Status2xx().
+		ContentType("application/json").
+		HeaderPresent("ETag").
+		Header("Retry-After", "10").
+		JSONPath("$.data.id", NotEmpty).
+		JSONPath("$.data.name", Exists).
+		Check()
+	want := []string{
+		"status 503, want 2xx",
+		`Content-Type "text/html", want application/json`,
+		"header ETag absent",
+		`header Retry-After absent, want "10"`,
+		`$.data.id: got "", want a non-empty value`,
+		`$.data.name: no member "name"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Check = %v, want %d errors", errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestJSONPath_Arrays(t *testing.T) {
+	resp := jsonResponse(200, `{"items":[{"id":1},{"id":2,"sub":[[10,20]]}]}`)
+	tests := []struct {
+		path    string
+		m       Matcher
+		wantErr string // "" if the expectation holds
+	}{
+		{"$.items[0].id", Equals(1), ""},
+		{"$.items[1].sub[0][1]", Equals(20), ""},
+		{`$.items[1]["sub"][0]`, Equals([]int{10, 20}), ""},
+		{"$.items", NotEmpty, ""},
+		{"$.items[0].id", Equals(2), "$.items[0].id: got 1, want 2"},
+		{"$.items[2]", Exists, "$.items[2]: no value at [2]: array has 2 elements"},
+		{"$.items.id", Exists, `$.items.id: no value at "id": [{"id":1},{"id":2,"sub":[[10,20]]}] is not an object`},
+		{"$.items[0][0]", Exists, `$.items[0][0]: no value at [0]: {"id":1} is not an array`},
+		{"$.items[*]", Exists, `invalid JSONPath "$.items[*]": unsupported accessor [*]`},
+		{"items[0]", Exists, `invalid JSONPath "items[0]": must start with $`},
+		{"$.items[0", Exists, `invalid JSONPath "$.items[0": unclosed [`},
+		{"$..id", Exists, `invalid JSONPath "$..id": empty member name`},
+	}
+	for _, tt := range tests {
+		errs := Response(resp).JSONPath(tt.path, tt.m).Check()
+		switch {
+		case tt.wantErr == "" && errs != nil:
+			t.Errorf("%s: Check = %v, want nil", tt.path, errs)
+		case tt.wantErr != "" && (len(errs) != 1 || errs[0].Error() != tt.wantErr):
+			t.Errorf("%s: Check = %v, want [%s]", tt.path, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestJSONPath_NonJSONBody(t *testing.T) {
+	resp := &http.Response{StatusCode: 200, Body: []byte("<html>oops</html>")}
+	errs := Response(resp).JSONPath("$.data.id", NotEmpty).JSONPath("$.ok", Exists).Status2xx().Check()
+	if len(errs) != 2 {
+		t.Fatalf("Check = %v, want 2 errors", errs)
+	}
+	for i, path := range []string{"$.data.id", "$.ok"} {
+		if msg := errs[i].Error(); !strings.HasPrefix(msg, path+": body is not JSON: invalid character '<'") {
+			t.Errorf("error %d = %q", i, msg)
+		}
+	}
+}
+
+func TestNotEmpty(t *testing.T) {
+	for _, body := range []string{`null`, `false`, `0`, `""`, `[]`, `{}`} {
+		if errs := Response(jsonResponse(200, body)).JSONPath("$", NotEmpty).Check(); len(errs) != 1 {
+			t.Errorf("%s: Check = %v, want one error", body, errs)
+		}
+	}
+	for _, body := range []string{`true`, `-1`, `"x"`, `[null]`, `{"a":null}`} {
+		if errs := Response(jsonResponse(200, body)).JSONPath("$", NotEmpty).Check(); errs != nil {
+			t.Errorf("%s: Check = %v, want nil", body, errs)
+		}
+	}
+}
+
+func TestCheck_NilResponse(t *testing.T) {
+	if errs := Response(nil).Status2xx().Check(); len(errs) != 1 || errs[0].Error() != "no response" {
+		t.Errorf("Check = %v", errs)
+	}
+}
@@ -0,0 +1,86 @@
+package expect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one child accessor of a JSONPath: an object member or, when
+// array is set, an array index.
+type step struct {
+	key   string
+	index int
+	array bool
+}
+
+func (s step) String() string {
+	if s.array {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return strconv.Quote(s.key)
+}
+
+// parsePath splits a JSONPath such as "$.data.items[0]['id']" into steps.
+func parsePath(path string) ([]step, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+	var steps []step
+	for rest := path[1:]; rest != ""; {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty member name", path)
+			}
+			steps = append(steps, step{key: rest[1 : 1+end]})
+			rest = rest[1+end:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed [", path)
+			}
+			inner := rest[1:end]
+			if n := len(inner); n >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[n-1] == inner[0] {
+				steps = append(steps, step{key: inner[1 : n-1]})
+			} else if i, err := strconv.Atoi(inner); err == nil && i >= 0 {
+				steps = append(steps, step{index: i, array: true})
+			} else {
+				return nil, fmt.Errorf("invalid JSONPath %q: unsupported accessor [%s]", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// lookup follows steps from v.
+func lookup(v interface{}, steps []step) (interface{}, error) {
+	for _, s := range steps {
+		if s.array {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("no value at %s: %s is not an array", s, encode(v))
+			}
+			if s.index >= len(arr) {
+				return nil, fmt.Errorf("no value at %s: array has %d elements", s, len(arr))
+			}
+			v = arr[s.index]
+			continue
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no value at %s: %s is not an object", s, encode(v))
+		}
+		if v, ok = obj[s.key]; !ok {
+			return nil, fmt.Errorf("no member %s", s)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,109 @@
+// Package expvarmetrics publishes the parse counters and timings of
+// shape-http through the standard library's expvar package, and so at
+// /debug/vars of a program serving expvar's handler:
+//
+//	http.SetMetrics(expvarmetrics.New("shapehttp"))
+//
+// Counters appear as integers under their names, such as
+// "strict.success", and each timing, such as "strict.duration", as an
+// object with the number of observations and the 50th and 99th
+// percentiles, in nanoseconds, of the most recent Window of them.
+package expvarmetrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// Window is how many of the latest observations of a timing its
+// percentiles are computed over.
+const Window = 1024
+
+// Metrics implements http.Metrics on an expvar.Map.
+type Metrics struct {
+	vars *expvar.Map
+
+	mu      sync.Mutex
+	timings map[string]*timing
+}
+
+var _ http.Metrics = (*Metrics)(nil)
+
+// New returns Metrics publishing under name. Like expvar.Publish, it
+// panics if name is already in use.
+func New(name string) *Metrics {
+	return &Metrics{vars: expvar.NewMap(name), timings: make(map[string]*timing)}
+}
+
+// CounterAdd adds delta to the counter name.
+func (m *Metrics) CounterAdd(name string, delta int64) {
+	m.vars.Add(name, delta)
+}
+
+// ObserveDuration records d as an observation of the timing name.
+func (m *Metrics) ObserveDuration(name string, d time.Duration) {
+	m.mu.Lock()
+	t, ok := m.timings[name]
+	if !ok {
+		t = &timing{}
+		m.timings[name] = t
+	}
+	t.observe(d)
+	m.mu.Unlock()
+	if !ok {
+		m.vars.Set(name, expvar.Func(func() interface{} { return m.summary(t) }))
+	}
+}
+
+// Summary is what a timing publishes.
+type Summary struct {
+	Count int64 `json:"count"`
+	P50   int64 `json:"p50"`
+	P99   int64 `json:"p99"`
+}
+
+// Timing returns the summary of the timing name, zero if it has no
+// observations.
+func (m *Metrics) Timing(name string) Summary {
+	m.mu.Lock()
+	t := m.timings[name]
+	m.mu.Unlock()
+	if t == nil {
+		return Summary{}
+	}
+	return m.summary(t)
+}
+
+func (m *Metrics) summary(t *timing) Summary {
+	m.mu.Lock()
+	samples := append([]time.Duration(nil), t.samples...)
+	count := t.count
+	m.mu.Unlock()
+	if len(samples) == 0 {
+		return Summary{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	at := func(p int) int64 { return int64(samples[(len(samples)-1)*p/100]) }
+	return Summary{Count: count, P50: at(50), P99: at(99)}
+}
+
+// timing keeps the latest Window observations in a ring.
+type timing struct {
+	samples []time.Duration
+	next    int
+	count   int64
+}
+
+func (t *timing) observe(d time.Duration) {
+	t.count++
+	if len(t.samples) < Window {
+		t.samples = append(t.samples, d)
+		return
+	}
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % Window
+}
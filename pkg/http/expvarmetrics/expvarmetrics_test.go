@@ -0,0 +1,46 @@
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New("expvarmetrics_test")
+	http.SetMetrics(m)
+	defer http.SetMetrics(nil)
+
+	http.UnmarshalRequest([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	http.UnmarshalRequest([]byte("nonsense"))
+
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(expvar.Get("expvarmetrics_test").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if string(vars["strict.success"]) != "1" || string(vars["strict.failure"]) != "1" {
+		t.Errorf("vars = %s", expvar.Get("expvarmetrics_test"))
+	}
+	var s Summary
+	if err := json.Unmarshal(vars["strict.duration"], &s); err != nil || s.Count != 2 || s.P50 <= 0 {
+		t.Errorf("strict.duration = %s (%v)", vars["strict.duration"], err)
+	}
+}
+
+func TestTiming_Percentiles(t *testing.T) {
+	m := New("expvarmetrics_percentiles")
+	for i := 1; i <= Window+100; i++ {
+		m.ObserveDuration("d", time.Duration(i))
+	}
+	s := m.Timing("d")
+	// The window holds observations 101 to Window+100.
+	if s.Count != Window+100 || s.P50 != 101+(Window-1)*50/100 || s.P99 != 101+(Window-1)*99/100 {
+		t.Errorf("Timing = %+v", s)
+	}
+	if got := m.Timing("missing"); got != (Summary{}) {
+		t.Errorf("Timing(missing) = %+v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// formMediaType is the media type FormValues accepts and SetFormBody sets.
+const formMediaType = "application/x-www-form-urlencoded"
+
+// FormValues parses the body of an application/x-www-form-urlencoded
+// request. Keys and values are percent-decoded with "+" read as a space;
+// repeated keys keep every value in body order, and empty pairs, such as
+// one left by a trailing "&", are skipped. A charset parameter on the
+// Content-Type is allowed, but the decoded bytes are returned as they are.
+//
+// It returns an error if the Content-Type is missing or another media
+// type, or if the body has an invalid percent escape; the error gives the
+// escape's byte offset in the body.
+func (r *Request) FormValues() (map[string][]string, error) {
+	ct := r.Headers.Get("Content-Type")
+	if ct == "" {
+		return nil, fmt.Errorf("http: no Content-Type header")
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, fmt.Errorf("http: invalid Content-Type %q: %w", ct, err)
+	}
+	if mediaType != formMediaType {
+		return nil, fmt.Errorf("http: Content-Type %q is not %s", mediaType, formMediaType)
+	}
+	return parseForm(string(r.Body))
+}
+
+// SetFormBody replaces the body with values encoded as
+// application/x-www-form-urlencoded, keys in sorted order and each key's
+// values in slice order. It sets Content-Type and Content-Length and
+// removes Transfer-Encoding, which would conflict with the new length.
+func (r *Request) SetFormBody(values map[string][]string) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	r.Body = []byte(b.String())
+	r.Headers.Del("Transfer-Encoding")
+	r.Headers.Set("Content-Type", formMediaType)
+	r.Headers.Set("Content-Length", strconv.Itoa(len(r.Body)))
+}
+
+// parseForm decodes a urlencoded body, tracking offsets so an invalid
+// escape can be reported by position.
+func parseForm(body string) (map[string][]string, error) {
+	values := make(map[string][]string)
+	for pos := 0; pos <= len(body); {
+		end := strings.IndexByte(body[pos:], '&')
+		if end < 0 {
+			end = len(body)
+		} else {
+			end += pos
+		}
+		pair := body[pos:end]
+		if pair != "" {
+			k, v, _ := strings.Cut(pair, "=")
+			key, err := formUnescape(k, pos)
+			if err != nil {
+				return nil, err
+			}
+			value, err := formUnescape(v, pos+len(k)+1)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = append(values[key], value)
+		}
+		pos = end + 1
+	}
+	return values, nil
+}
+
+// formUnescape decodes s, which starts at offset in the body.
+func formUnescape(s string, offset int) (string, error) {
+	decoded, bad := fastparser.PercentDecode(s, true, false)
+	if bad >= 0 {
+		return "", fmt.Errorf("http: invalid percent escape %q at offset %d of form body", escapeAt(s, bad), offset+bad)
+	}
+	return decoded, nil
+}
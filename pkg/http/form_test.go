@@ -0,0 +1,120 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormValues(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string // a curl command, or "" to use body
+		body string
+		ct   string
+		want map[string][]string
+	}{
+		{
+			name: "TestCurlRW_27 body",
+			cmd:  `curl -X POST https://api.example.com/auth -H "Content-Type: application/x-www-form-urlencoded" -d 'grant_type=client_credentials&client_id=xxx&client_secret=yyy'`,
+			want: map[string][]string{"grant_type": {"client_credentials"}, "client_id": {"xxx"}, "client_secret": {"yyy"}},
+		},
+		{
+			name: "TestCurlRW_44 body",
+			cmd: `curl -X POST https://auth.example.com/oauth/token ` +
+				`-H "Content-Type: application/x-www-form-urlencoded" ` +
+				`-d "grant_type=password&username=user@example.com&password=pass&client_id=client123"`,
+			want: map[string][]string{"grant_type": {"password"}, "username": {"user@example.com"}, "password": {"pass"}, "client_id": {"client123"}},
+		},
+		{
+			name: "encoded ampersand in a value",
+			body: "q=fish+%26+chips&note=a%3Db%26c",
+			ct:   "application/x-www-form-urlencoded",
+			want: map[string][]string{"q": {"fish & chips"}, "note": {"a=b&c"}},
+		},
+		{
+			name: "duplicates, empty values and trailing ampersand",
+			body: "To=%2B15551234567&MediaUrl=a&MediaUrl=b&flag&empty=&",
+			ct:   "application/x-www-form-urlencoded; charset=UTF-8",
+			want: map[string][]string{"To": {"+15551234567"}, "MediaUrl": {"a", "b"}, "flag": {""}, "empty": {""}},
+		},
+		{
+			name: "empty body",
+			ct:   "Application/X-WWW-Form-Urlencoded",
+			want: map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		req := &Request{Method: "POST", Body: []byte(tt.body), Headers: Headers{{Key: "Content-Type", Value: tt.ct}}}
+		if tt.cmd != "" {
+			if req = ParseCurl(tt.cmd).Request; req == nil {
+				t.Fatalf("%s: ParseCurl returned no request", tt.name)
+			}
+		}
+		got, err := req.FormValues()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FormValues() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormValues_Errors(t *testing.T) {
+	tests := []struct {
+		body, ct, want string
+	}{
+		{"a=1&b=%zz", formMediaType, "offset 6"},
+		{"a=1&%4", formMediaType, "offset 4"},
+		{"a=100%", formMediaType, "offset 5"},
+		{"a=1", "", "no Content-Type"},
+		{"a=1", "application/json", "not application/x-www-form-urlencoded"},
+		{"a=1", "application/x-www-form-urlencoded; =", "invalid Content-Type"},
+	}
+	for _, tt := range tests {
+		req := &Request{Body: []byte(tt.body)}
+		if tt.ct != "" {
+			req.Headers.Set("Content-Type", tt.ct)
+		}
+		_, err := req.FormValues()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("FormValues(%q, %q) err = %v, want it to contain %q", tt.body, tt.ct, err, tt.want)
+		}
+	}
+}
+
+func TestSetFormBody(t *testing.T) {
+	req := &Request{Method: "POST", Path: "/token", Headers: Headers{
+		{Key: "Host", Value: "auth.example.com"},
+		{Key: "Content-Type", Value: "application/json"},
+		{Key: "Transfer-Encoding", Value: "chunked"},
+	}}
+	values := map[string][]string{
+		"scope":      {"read write"},
+		"grant_type": {"client_credentials"},
+		"redirect":   {"https://app.example.com/cb?a=1&b=2"},
+		"tag":        {"x", "y"},
+	}
+	req.SetFormBody(values)
+
+	want := "grant_type=client_credentials&redirect=https%3A%2F%2Fapp.example.com%2Fcb%3Fa%3D1%26b%3D2&scope=read+write&tag=x&tag=y"
+	if string(req.Body) != want {
+		t.Errorf("Body = %q, want %q", req.Body, want)
+	}
+	if got := req.Headers.Get("Content-Type"); got != formMediaType {
+		t.Errorf("Content-Type = %q, want %q", got, formMediaType)
+	}
+	if got := req.Headers.ContentLength(); got != int64(len(want)) {
+		t.Errorf("Content-Length = %d, want %d", got, len(want))
+	}
+	if req.Headers.IsChunked() {
+		t.Error("Transfer-Encoding was kept")
+	}
+
+	got, err := req.FormValues()
+	if err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("FormValues() = %v, %v; want %v", got, err, values)
+	}
+}
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedElement is one hop of a Forwarded header (RFC 7239 §4): the
+// parameters a single proxy added. Values are unquoted. For and By are
+// nodes such as "192.0.2.43", "[2001:db8:cafe::17]:4711", "unknown" or an
+// obfuscated identifier like "_hidden".
+type ForwardedElement struct {
+	For   string
+	By    string
+	Host  string
+	Proto string
+
+	// Extensions holds any other parameter, keyed by lowercase name.
+	Extensions map[string]string
+}
+
+// ForAddr returns the IP address of the For node, without its port, and
+// whether For holds one; "unknown" and obfuscated identifiers do not.
+func (e ForwardedElement) ForAddr() (netip.Addr, bool) {
+	return nodeAddr(e.For)
+}
+
+// ParseForwarded parses a Forwarded field value (RFC 7239 §4) into its
+// elements, left-most (the original client) first. Elements are separated
+// by commas and parameters by semicolons; parameter names are
+// case-insensitive and values are tokens or quoted strings, as an IPv6
+// node must be. Empty elements are skipped. A parameter without "=", an
+// unquoted value that is not a token, an unterminated quoted string or a
+// parameter repeated within one element is an error. To parse several
+// Forwarded lines, join them with ",".
+func ParseForwarded(value string) ([]ForwardedElement, error) {
+	var out []ForwardedElement
+	var elem ForwardedElement
+	seen := make(map[string]bool)
+	empty := true
+	flush := func() {
+		if !empty {
+			out = append(out, elem)
+		}
+		elem, empty = ForwardedElement{}, true
+		seen = make(map[string]bool)
+	}
+
+	for i := 0; ; {
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) {
+			break
+		}
+		if c := value[i]; c == ',' || c == ';' {
+			if c == ',' {
+				flush()
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(value) && value[i] != '=' && value[i] != ';' && value[i] != ',' {
+			i++
+		}
+		name := strings.ToLower(strings.TrimRight(value[start:i], " \t"))
+		if i == len(value) || value[i] != '=' || !isToken(name) {
+			return nil, fmt.Errorf("http: invalid Forwarded parameter %q at offset %d", value[start:i], start)
+		}
+		i++ // '='
+
+		var v string
+		if i < len(value) && value[i] == '"' {
+			var b strings.Builder
+			closed := false
+			for i++; i < len(value); i++ {
+				if value[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("http: unterminated quoted string in Forwarded parameter %s", name)
+			}
+			v = b.String()
+		} else {
+			vs := i
+			for i < len(value) && value[i] != ';' && value[i] != ',' && value[i] != ' ' && value[i] != '\t' {
+				i++
+			}
+			if v = value[vs:i]; !isToken(v) {
+				return nil, fmt.Errorf("http: Forwarded parameter %s has value %q, which must be quoted", name, v)
+			}
+		}
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i < len(value) && value[i] != ';' && value[i] != ',' {
+			return nil, fmt.Errorf("http: unexpected %q after Forwarded parameter %s at offset %d", value[i], name, i)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("http: Forwarded parameter %s repeated in one element", name)
+		}
+		seen[name], empty = true, false
+		switch name {
+		case "for":
+			elem.For = v
+		case "by":
+			elem.By = v
+		case "host":
+			elem.Host = v
+		case "proto":
+			elem.Proto = v
+		default:
+			if elem.Extensions == nil {
+				elem.Extensions = make(map[string]string)
+			}
+			elem.Extensions[name] = v
+		}
+	}
+	flush()
+	return out, nil
+}
+
+// ForwardedChain returns the request's proxy chain, left-most (the
+// original client) first. It is parsed from the Forwarded headers when
+// present, or nil if they do not parse. Otherwise it is synthesized from
+// X-Forwarded-For, one element per address, with IPv6 addresses in
+// brackets as Forwarded writes them. X-Forwarded-Proto and
+// X-Forwarded-Host fill Proto and Host: element by element when they list
+// as many values as there are addresses, else on the first element only.
+func (r *Request) ForwardedChain() []ForwardedElement {
+	chain, _ := r.forwardedChain()
+	return chain
+}
+
+func (r *Request) forwardedChain() ([]ForwardedElement, error) {
+	if fwd := r.Headers.Values("Forwarded"); len(fwd) > 0 {
+		return ParseForwarded(strings.Join(fwd, ","))
+	}
+
+	addrs := splitList(r.Headers.Values("X-Forwarded-For"))
+	protos := splitList(r.Headers.Values("X-Forwarded-Proto"))
+	hosts := splitList(r.Headers.Values("X-Forwarded-Host"))
+	n := len(addrs)
+	if n == 0 && (len(protos) > 0 || len(hosts) > 0) {
+		n = 1
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	chain := make([]ForwardedElement, n)
+	for i, a := range addrs {
+		if strings.Count(a, ":") > 1 && !strings.HasPrefix(a, "[") {
+			a = "[" + a + "]"
+		}
+		chain[i].For = a
+	}
+	for i, p := range protos {
+		if len(protos) == n || i == 0 {
+			chain[i].Proto = p
+		}
+	}
+	for i, h := range hosts {
+		if len(hosts) == n || i == 0 {
+			chain[i].Host = h
+		}
+	}
+	return chain, nil
+}
+
+// ClientIP returns the address of the client as seen by the last proxy
+// that is not trusted: walking ForwardedChain from the right, it skips
+// addresses inside trustedProxies and returns the first one outside them.
+// Entries to the left of that one were supplied by the client and cannot
+// be trusted. If every address is trusted the left-most is returned.
+//
+// It returns an error if the request has no forwarding headers, if the
+// Forwarded header does not parse, or if the walk reaches a node that is
+// not an IP address ("unknown" or an obfuscated identifier) before finding
+// an untrusted one.
+func (r *Request) ClientIP(trustedProxies []netip.Prefix) (netip.Addr, error) {
+	chain, err := r.forwardedChain()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if len(chain) == 0 {
+		return netip.Addr{}, fmt.Errorf("http: request has no Forwarded or X-Forwarded-For header")
+	}
+	var addr netip.Addr
+	for i := len(chain) - 1; i >= 0; i-- {
+		a, ok := chain[i].ForAddr()
+		if !ok {
+			return netip.Addr{}, fmt.Errorf("http: forwarded hop %d has no usable address (for=%q)", i, chain[i].For)
+		}
+		if addr = a; !prefixesContain(trustedProxies, a) {
+			return a, nil
+		}
+	}
+	return addr, nil
+}
+
+func prefixesContain(prefixes []netip.Prefix, a netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAddr parses the address of a Forwarded node (RFC 7239 §6): an IPv4
+// address or bracketed IPv6 address with an optional port. IPv4-mapped
+// IPv6 addresses are unmapped. Bare IPv6, as some proxies write in
+// X-Forwarded-For, is accepted too.
+func nodeAddr(node string) (netip.Addr, bool) {
+	host := node
+	if strings.HasPrefix(node, "[") {
+		end := strings.IndexByte(node, ']')
+		if end < 0 {
+			return netip.Addr{}, false
+		}
+		host = node[1:end]
+	} else if strings.Count(node, ":") == 1 {
+		host, _, _ = strings.Cut(node, ":")
+	}
+	a, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return a.Unmap(), true
+}
+
+// splitList splits comma-separated header values into trimmed, non-empty
+// elements.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// isToken reports whether s is a non-empty RFC 9110 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0 {
+			continue
+		}
+		return false
+	}
+	return true
+}
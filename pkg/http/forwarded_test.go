@@ -0,0 +1,174 @@
+package http
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseForwarded_RFCExamples(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []ForwardedElement
+	}{
+		// RFC 7239 §4.
+		{`for="_gazonk"`, []ForwardedElement{{For: "_gazonk"}}},
+		{`For="[2001:db8:cafe::17]:4711"`, []ForwardedElement{{For: "[2001:db8:cafe::17]:4711"}}},
+		{`for=192.0.2.60;proto=http;by=203.0.113.43`, []ForwardedElement{{For: "192.0.2.60", Proto: "http", By: "203.0.113.43"}}},
+		{`for=192.0.2.43, for=198.51.100.17`, []ForwardedElement{{For: "192.0.2.43"}, {For: "198.51.100.17"}}},
+		// RFC 7239 §7.1.
+		{`for=192.0.2.43,for="[2001:db8:cafe::17]",for=unknown`, []ForwardedElement{{For: "192.0.2.43"}, {For: "[2001:db8:cafe::17]"}, {For: "unknown"}}},
+		// RFC 7239 §6.3, obfuscated identifiers.
+		{`for=_hidden, for=_SEVKISEK`, []ForwardedElement{{For: "_hidden"}, {For: "_SEVKISEK"}}},
+		// Extensions, escapes, host and ignorable whitespace and empties.
+		{` , for=192.0.2.1 ; host="example.com:8080";secret="a\"b" , `, []ForwardedElement{{For: "192.0.2.1", Host: "example.com:8080", Extensions: map[string]string{"secret": `a"b`}}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseForwarded(tt.value)
+		if err != nil {
+			t.Errorf("ParseForwarded(%q): %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseForwarded(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseForwarded_Errors(t *testing.T) {
+	for _, value := range []string{
+		`for`,
+		`for=192.0.2.1;proto`,
+		`=192.0.2.1`,
+		`for=[2001:db8::1]`,
+		`for="192.0.2.1`,
+		`for=192.0.2.1;for=192.0.2.2`,
+		`for="192.0.2.1"x`,
+		`for=a b`,
+	} {
+		if got, err := ParseForwarded(value); err == nil {
+			t.Errorf("ParseForwarded(%q) = %+v, want error", value, got)
+		}
+	}
+}
+
+func TestForwardedElement_ForAddr(t *testing.T) {
+	tests := []struct {
+		node, want string
+	}{
+		{"192.0.2.43", "192.0.2.43"},
+		{"192.0.2.43:47011", "192.0.2.43"},
+		{"[2001:db8:cafe::17]:4711", "2001:db8:cafe::17"},
+		{"[2001:db8:cafe::17]", "2001:db8:cafe::17"},
+		{"[::ffff:192.0.2.1]", "192.0.2.1"},
+		{"unknown", ""},
+		{"_hidden", ""},
+		{"[2001:db8::1", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if a, ok := (ForwardedElement{For: tt.node}).ForAddr(); ok {
+			got = a.String()
+		}
+		if got != tt.want {
+			t.Errorf("ForAddr(%q) = %q, want %q", tt.node, got, tt.want)
+		}
+	}
+}
+
+func TestForwardedChain(t *testing.T) {
+	// RFC 7239 §7.4: X-Forwarded-For maps to for= pairs, IPv6 quoted.
+	req := &Request{Headers: Headers{
+		{Key: "X-Forwarded-For", Value: "192.0.2.43, 2001:db8:cafe::17"},
+		{Key: "X-Forwarded-For", Value: "198.51.100.7"},
+		{Key: "X-Forwarded-Proto", Value: "https"},
+		{Key: "X-Forwarded-Host", Value: "shop.example.com"},
+	}}
+	want := []ForwardedElement{
+		{For: "192.0.2.43", Proto: "https", Host: "shop.example.com"},
+		{For: "[2001:db8:cafe::17]"},
+		{For: "198.51.100.7"},
+	}
+	if got := req.ForwardedChain(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ForwardedChain() = %+v, want %+v", got, want)
+	}
+
+	// Per-hop protocols when the counts match.
+	req.Headers.Set("X-Forwarded-Proto", "https, http, http")
+	if got := req.ForwardedChain(); got[1].Proto != "http" || got[0].Proto != "https" {
+		t.Errorf("per-hop Proto = %+v", got)
+	}
+
+	// Forwarded wins over the legacy headers.
+	req.Headers.Add("Forwarded", "for=192.0.2.60;proto=http")
+	req.Headers.Add("Forwarded", "for=203.0.113.9")
+	want = []ForwardedElement{{For: "192.0.2.60", Proto: "http"}, {For: "203.0.113.9"}}
+	if got := req.ForwardedChain(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ForwardedChain() = %+v, want %+v", got, want)
+	}
+
+	if got := (&Request{}).ForwardedChain(); got != nil {
+		t.Errorf("no headers: ForwardedChain() = %+v, want nil", got)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:ffff::/48"),
+	}
+	tests := []struct {
+		name    string
+		headers Headers
+		want    string // "" for an error
+	}{
+		{
+			// The client claims to be 127.0.0.1; only the address the
+			// trusted proxies saw can be believed.
+			"spoofed left-most X-Forwarded-For",
+			Headers{{Key: "X-Forwarded-For", Value: "127.0.0.1, 198.51.100.23, 10.1.2.3, 10.0.0.1"}},
+			"198.51.100.23",
+		},
+		{
+			"Forwarded with IPv6 proxy",
+			Headers{{Key: "Forwarded", Value: `for=203.0.113.5, for="[2001:db8:ffff::1]:443"`}},
+			"203.0.113.5",
+		},
+		{
+			"all trusted",
+			Headers{{Key: "X-Forwarded-For", Value: "10.9.9.9, 10.0.0.1"}},
+			"10.9.9.9",
+		},
+		{
+			"no trusted proxies",
+			Headers{{Key: "X-Forwarded-For", Value: "192.0.2.1, 198.51.100.2"}},
+			"198.51.100.2",
+		},
+		{
+			"unknown hop before an untrusted one",
+			Headers{{Key: "Forwarded", Value: "for=192.0.2.1, for=unknown, for=10.0.0.1"}},
+			"",
+		},
+		{
+			"obfuscated hop left of the client is never reached",
+			Headers{{Key: "Forwarded", Value: "for=_hidden, for=192.0.2.1, for=10.0.0.1"}},
+			"192.0.2.1",
+		},
+		{"malformed Forwarded", Headers{{Key: "Forwarded", Value: "for="}, {Key: "X-Forwarded-For", Value: "192.0.2.1"}}, ""},
+		{"no headers", nil, ""},
+	}
+	for _, tt := range tests {
+		prefixes := trusted
+		if strings.HasPrefix(tt.name, "no trusted") {
+			prefixes = nil
+		}
+		got, err := (&Request{Headers: tt.headers}).ClientIP(prefixes)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%s: ClientIP() = %s, want error", tt.name, got)
+		case tt.want != "" && (err != nil || got.String() != tt.want):
+			t.Errorf("%s: ClientIP() = %s, %v; want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -2,42 +2,16 @@ package http
 
 import (
 	"bytes"
-	"strings"
 	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http/httptest"
 )
 
 // Seed corpora for requests and responses used across multiple fuzz targets.
-
-var requestSeeds = [][]byte{
-	[]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
-	[]byte("POST /api/users HTTP/1.1\r\nHost: api.example.com\r\nContent-Type: application/json\r\nContent-Length: 15\r\n\r\n{\"name\":\"alice\"}"),
-	[]byte("PUT /resource/1 HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer token123\r\nContent-Length: 4\r\n\r\ndata"),
-	[]byte("DELETE /item/42 HTTP/1.1\r\nHost: example.com\r\n\r\n"),
-	[]byte("HEAD /status HTTP/1.1\r\nHost: example.com\r\n\r\n"),
-	[]byte("OPTIONS * HTTP/1.1\r\nHost: example.com\r\n\r\n"),
-	[]byte("GET /path?q=hello+world&page=2 HTTP/1.1\r\nHost: example.com\r\nAccept: text/html,application/json\r\nAccept-Encoding: gzip, deflate\r\nConnection: keep-alive\r\n\r\n"),
-	[]byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n6\r\nworld!\r\n0\r\n\r\n"),
-	// Edge cases
-	[]byte("GET / HTTP/1.0\r\n\r\n"),
-	[]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Empty:\r\n\r\n"),
-	[]byte("GET / HTTP/1.1\r\nHost: example.com\r\nCookie: a=1; b=2; c=3\r\n\r\n"),
-	[]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 0\r\n\r\n"),
-}
-
-var responseSeeds = [][]byte{
-	[]byte("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello"),
-	[]byte("HTTP/1.1 404 Not Found\r\nContent-Type: application/json\r\nContent-Length: 14\r\n\r\n{\"error\":\"gone\"}"),
-	[]byte("HTTP/1.1 204 No Content\r\n\r\n"),
-	[]byte("HTTP/1.1 301 Moved Permanently\r\nLocation: https://example.com/\r\nContent-Length: 0\r\n\r\n"),
-	[]byte("HTTP/1.1 500 Internal Server Error\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\noops!"),
-	[]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n6\r\nworld!\r\n0\r\n\r\n"),
-	[]byte("HTTP/1.1 200 OK\r\nSet-Cookie: session=abc123; Path=/; HttpOnly\r\nContent-Length: 2\r\n\r\nok"),
-	[]byte("HTTP/1.1 100 Continue\r\n\r\n"),
-	// Edge cases
-	[]byte("HTTP/1.0 200 OK\r\nContent-Length: 0\r\n\r\n"),
-	[]byte("HTTP/1.1 200 OK\r\n\r\n"),
-	[]byte("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nX-Custom-Header: value with spaces\r\nContent-Length: 6\r\n\r\n<html>"),
-}
+var (
+	requestSeeds  = httptest.RequestSeeds()
+	responseSeeds = httptest.ResponseSeeds()
+)
 
 // FuzzUnmarshalRequest fuzzes the request parser.
 // The invariant: never panic regardless of input.
@@ -89,6 +63,20 @@ func FuzzUnmarshalResponse(f *testing.F) {
 	})
 }
 
+// FuzzWireSize builds messages from fuzzed fields.
+// The invariant: WireSize equals the length of Marshal's output.
+func FuzzWireSize(f *testing.F) {
+	f.Add("GET", "/", "", 200, "OK", "Host", "example.com", []byte(""))
+	f.Add("POST", "/items", "HTTP/1.0", 201, "", "Content-Length", "4", []byte("body"))
+	f.Add("PUT", "*", "HTTP/1.1", -42, "Weird Reason", "Transfer-Encoding", "gzip, chunked", []byte("0\r\n\r\n"))
+
+	f.Fuzz(func(t *testing.T, method, path, version string, code int, reason, key, value string, body []byte) {
+		headers := Headers{{Key: key, Value: value}}
+		checkWireSize(t, &Request{Method: method, Path: path, Version: version, Headers: headers, Body: body})
+		checkWireSize(t, &Response{Version: version, StatusCode: code, Reason: reason, Headers: headers, Body: body})
+	})
+}
+
 // FuzzUnmarshal fuzzes the auto-detecting Unmarshal function.
 func FuzzUnmarshal(f *testing.F) {
 	for _, seed := range requestSeeds {
@@ -319,53 +307,9 @@ func FuzzParse(f *testing.F) {
 //   - ParseCurl never returns nil
 //   - When result.Partial is false, result.Request must be non-nil
 func FuzzParseCurl(f *testing.F) {
-	// Seed corpus: valid curl commands covering all flag types
-	f.Add(`curl https://api.example.com/users`)
-	f.Add(`curl -X POST https://api.example.com/users -H "Content-Type: application/json" -d '{"name":"Alice"}'`)
-	f.Add(`curl -X PUT https://api.example.com/users/1 -H "Authorization: Bearer tok" -d '{"active":false}'`)
-	f.Add(`curl -X DELETE https://api.example.com/users/42`)
-	f.Add(`curl -X PATCH https://api.example.com/users/7 -d '{"email":"new@x.com"}'`)
-	f.Add(`curl -I https://api.example.com/health`)
-	f.Add(`curl -u admin:secret https://api.example.com/admin`)
-	f.Add(`curl -H "Authorization: Bearer eyJ.payload.sig" https://api.example.com/me`)
-	f.Add(`curl -v -s -k -L --compressed https://api.example.com/`)
-	f.Add(`curl --http2 https://api.example.com/h2`)
-	f.Add(`curl --http3 https://api.example.com/h3`)
-	f.Add(`curl -F "name=Alice" -F "role=admin" https://api.example.com/profile`)
-	f.Add(`curl --data-urlencode "q=hello world" https://api.example.com/search`)
-	f.Add(`curl -X POST https://api.example.com/form -d 'a=1' -d 'b=2'`)
-	f.Add(`curl -o /tmp/out.json https://api.example.com/export`)
-	f.Add(`curl -A "TestAgent/1.0" https://api.example.com/`)
-	f.Add("curl -X POST \\\n  https://api.example.com/users \\\n  -H \"Content-Type: application/json\" \\\n  -d '{\"name\":\"Bob\"}'")
-	f.Add(`curl --data-raw '{"query":"{ me { id } }"}' https://api.example.com/graphql`)
-	f.Add(`curl --data-binary '{"b":true}' https://api.example.com/raw`)
-	f.Add(`https://api.example.com/no-curl-prefix`)
-	f.Add(`curl http://localhost:3000/api/health`)
-	f.Add(`curl http://127.0.0.1:8080/v1/data`)
-	f.Add(`curl -X POST https://api.example.com/ -H "Content-Type: application/x-www-form-urlencoded" -d 'grant_type=cc&client_id=x'`)
-	// Edge / pathological inputs
-	f.Add(``)
-	f.Add(`curl`)
-	f.Add(`curl -X`)
-	f.Add(`curl -H`)
-	f.Add(`curl -d`)
-	f.Add(`curl -X POST`)
-	f.Add(`curl -u`)
-	f.Add(`curl -F`)
-	f.Add(`curl --unknown-flag https://x.com/`)
-	f.Add(`curl -H "bad header no colon" https://x.com/`)
-	f.Add(`curl -d @/etc/passwd https://x.com/`)
-	f.Add(`curl -F "file=@/tmp/big.bin" https://x.com/`)
-	f.Add(`curl "https://x.com/path with spaces"`)
-	f.Add("curl -H \"unclosed")
-	f.Add(`curl 'unclosed`)
-	f.Add(`curl -X POST -X GET https://x.com/`) // conflicting -X flags
-	f.Add(`curl ` + strings.Repeat("-H \"X-H: v\" ", 50) + `https://x.com/`)
-	f.Add(`curl https://x.com/` + strings.Repeat("a", 4096))
-	f.Add(`curl -d '` + strings.Repeat("x", 65536) + `' https://x.com/`)
-	f.Add("curl \x00 https://x.com/")
-	f.Add("curl \xff\xfe https://x.com/")
-	f.Add(`curl --http2 --http3 https://x.com/`) // conflicting version flags
+	for _, seed := range httptest.CurlSeeds() {
+		f.Add(seed)
+	}
 
 	f.Fuzz(func(t *testing.T, cmd string) {
 		defer func() {
@@ -392,8 +336,10 @@ func FuzzParseCurl(f *testing.F) {
 			t.Errorf("ParseCurl(%q): Request.Method is empty; warnings=%v", cmd, result.Warnings)
 		}
 
-		// Invariant: if Request is set, path must start with /
-		if result.Request != nil && result.Request.Path != "" && result.Request.Path[0] != '/' {
+		// Invariant: if Request is set, path must start with / (except CONNECT,
+		// whose request-target is authority-form)
+		if result.Request != nil && result.Request.Method != "CONNECT" &&
+			result.Request.Path != "" && result.Request.Path[0] != '/' {
 			t.Errorf("ParseCurl(%q): Request.Path %q does not start with /", cmd, result.Request.Path)
 		}
 	})
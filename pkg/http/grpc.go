@@ -0,0 +1,148 @@
+package http
+
+import (
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Flag bits of a gRPC length-prefixed message.
+const (
+	GRPCFlagCompressed byte = 0x01 // payload compressed with grpc-encoding
+	GRPCFlagEndStream  byte = 0x02 // Connect end-of-stream message (JSON)
+	GRPCFlagTrailer    byte = 0x80 // gRPC-Web trailer frame
+)
+
+// grpcFrameHeaderLen is the flag byte plus the 4-byte big-endian length.
+const grpcFrameHeaderLen = 5
+
+// GRPCFrame is one length-prefixed message of a gRPC, gRPC-Web or Connect
+// streaming body. For a gRPC-Web trailer frame Trailers holds the fields
+// decoded from Data.
+type GRPCFrame struct {
+	Flags    byte
+	Data     []byte
+	Trailers Headers
+}
+
+// IsTrailer reports whether f is a gRPC-Web trailer frame.
+func (f GRPCFrame) IsTrailer() bool { return f.Flags&GRPCFlagTrailer != 0 }
+
+// GRPCWebFrames splits the response body into its length-prefixed frames.
+// The Content-Type must be application/grpc, application/grpc-web or
+// application/connect, optionally with a "+proto"-style suffix;
+// grpc-web-text bodies are base64 and not accepted. A trailer frame's
+// "key: value" lines are decoded into its Trailers. If the body ends
+// inside a frame, the complete frames before it are returned with an error
+// giving the offset of the truncated one.
+func (r *Response) GRPCWebFrames() ([]GRPCFrame, error) {
+	return grpcFrames(r.Headers, r.Body)
+}
+
+// GRPCWebFrames is Response.GRPCWebFrames for a request body.
+func (r *Request) GRPCWebFrames() ([]GRPCFrame, error) {
+	return grpcFrames(r.Headers, r.Body)
+}
+
+// GRPCStatus returns the response's grpc-status code and percent-decoded
+// grpc-message, taken from the headers of a trailers-only response or
+// else from the body's trailer frame. ok is false if neither has a valid
+// grpc-status.
+func (r *Response) GRPCStatus() (code int, message string, ok bool) {
+	trailers := r.Headers
+	if trailers.Get("grpc-status") == "" {
+		frames, _ := r.GRPCWebFrames()
+		trailers = nil
+		for _, f := range frames {
+			if f.IsTrailer() {
+				trailers = f.Trailers
+			}
+		}
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(trailers.Get("grpc-status")))
+	if err != nil || code < 0 {
+		return 0, "", false
+	}
+	message = trailers.Get("grpc-message")
+	if m, err := url.PathUnescape(message); err == nil {
+		message = m
+	}
+	return code, message, true
+}
+
+// BuildGRPCWebBody encodes frames as a length-prefixed body. A frame with
+// Trailers and no Data is written as a trailer frame of its Trailers, one
+// "key: value\r\n" line each, with GRPCFlagTrailer set.
+func BuildGRPCWebBody(frames []GRPCFrame) []byte {
+	var out []byte
+	for _, f := range frames {
+		data, flags := f.Data, f.Flags
+		if len(data) == 0 && len(f.Trailers) > 0 {
+			var b strings.Builder
+			for _, h := range f.Trailers {
+				b.WriteString(h.Key + ": " + h.Value + "\r\n")
+			}
+			data, flags = []byte(b.String()), flags|GRPCFlagTrailer
+		}
+		out = append(out, flags)
+		out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+		out = append(out, data...)
+	}
+	return out
+}
+
+func grpcFrames(headers Headers, body []byte) ([]GRPCFrame, error) {
+	ct := headers.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, fmt.Errorf("http: invalid Content-Type %q: %w", ct, err)
+	}
+	base, _, _ := strings.Cut(mediaType, "+")
+	switch base {
+	case "application/grpc", "application/grpc-web", "application/connect":
+	default:
+		return nil, fmt.Errorf("http: Content-Type %q is not a gRPC or Connect streaming type", mediaType)
+	}
+
+	var frames []GRPCFrame
+	for off := 0; off < len(body); {
+		if len(body)-off < grpcFrameHeaderLen {
+			return frames, fmt.Errorf("http: gRPC frame at offset %d truncated in its %d-byte prefix", off, grpcFrameHeaderLen)
+		}
+		n := binary.BigEndian.Uint32(body[off+1:])
+		start := off + grpcFrameHeaderLen
+		if uint64(n) > uint64(len(body)-start) {
+			return frames, fmt.Errorf("http: gRPC frame at offset %d declares %d bytes but only %d remain", off, n, len(body)-start)
+		}
+		f := GRPCFrame{Flags: body[off], Data: body[start : start+int(n)]}
+		if f.IsTrailer() {
+			if f.Trailers, err = parseGRPCTrailers(f.Data); err != nil {
+				return frames, fmt.Errorf("http: gRPC trailer frame at offset %d: %w", off, err)
+			}
+		}
+		frames = append(frames, f)
+		off = start + int(n)
+	}
+	return frames, nil
+}
+
+// parseGRPCTrailers decodes the CRLF-separated "key: value" lines of a
+// gRPC-Web trailer frame.
+func parseGRPCTrailers(data []byte) (Headers, error) {
+	var h Headers
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !isToken(key) {
+			return nil, fmt.Errorf("malformed trailer line %q", line)
+		}
+		h = append(h, Header{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return h, nil
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func grpcResponse(contentType string, body []byte) *Response {
+	return &Response{Version: "HTTP/1.1", StatusCode: 200, Headers: Headers{{Key: "Content-Type", Value: contentType}}, Body: body}
+}
+
+func TestGRPCWebFrames(t *testing.T) {
+	// Two messages and a trailer frame, built by hand.
+	body := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x03, 0x0a, 0x01, 'a',
+		0x01, 0x00, 0x00, 0x00, 0x00,
+		0x80, 0x00, 0x00, 0x00, 0x32,
+	}
+	body = append(body, "grpc-status: 5\r\ngrpc-message: user%20not%20found\r\n"...)
+	resp := grpcResponse("application/grpc-web+proto", body)
+
+	frames, err := resp.GRPCWebFrames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("len(frames) = %d, want 3", len(frames))
+	}
+	if frames[0].Flags != 0 || !bytes.Equal(frames[0].Data, []byte{0x0a, 0x01, 'a'}) {
+		t.Errorf("frames[0] = %+v", frames[0])
+	}
+	if frames[1].Flags != GRPCFlagCompressed || len(frames[1].Data) != 0 {
+		t.Errorf("frames[1] = %+v", frames[1])
+	}
+	if !frames[2].IsTrailer() || frames[2].Trailers.Get("grpc-status") != "5" {
+		t.Errorf("frames[2] = %+v", frames[2])
+	}
+	if code, msg, ok := resp.GRPCStatus(); !ok || code != 5 || msg != "user not found" {
+		t.Errorf("GRPCStatus() = %d, %q, %v; want 5, \"user not found\", true", code, msg, ok)
+	}
+
+	if got := BuildGRPCWebBody(frames[:2]); !bytes.Equal(got, body[:13]) {
+		t.Errorf("BuildGRPCWebBody = %x, want %x", got, body[:13])
+	}
+	rebuilt := BuildGRPCWebBody([]GRPCFrame{frames[0], frames[1], {Trailers: frames[2].Trailers}})
+	if !bytes.Equal(rebuilt, body) {
+		t.Errorf("BuildGRPCWebBody = %q, want %q", rebuilt, body)
+	}
+}
+
+func TestGRPCWebFrames_TrailerOnly(t *testing.T) {
+	body := BuildGRPCWebBody([]GRPCFrame{{Trailers: Headers{{Key: "grpc-status", Value: "0"}}}})
+	frames, err := grpcResponse("application/grpc-web", body).GRPCWebFrames()
+	if err != nil || len(frames) != 1 || !frames[0].IsTrailer() {
+		t.Fatalf("frames = %+v, err = %v", frames, err)
+	}
+
+	// An empty trailer frame and a trailers-only response in headers.
+	frames, err = grpcResponse("application/grpc-web", []byte{0x80, 0, 0, 0, 0}).GRPCWebFrames()
+	if err != nil || len(frames) != 1 || frames[0].Trailers != nil {
+		t.Errorf("empty trailer: frames = %+v, err = %v", frames, err)
+	}
+	resp := grpcResponse("application/grpc", nil)
+	resp.Headers.Add("grpc-status", "16")
+	if code, _, ok := resp.GRPCStatus(); !ok || code != 16 {
+		t.Errorf("trailers-only GRPCStatus() = %d, %v", code, ok)
+	}
+	if _, _, ok := grpcResponse("application/grpc", nil).GRPCStatus(); ok {
+		t.Error("no status: ok = true")
+	}
+}
+
+func TestGRPCWebFrames_Truncated(t *testing.T) {
+	whole := []byte{0x00, 0x00, 0x00, 0x00, 0x02, 'h', 'i'}
+	tests := []struct {
+		body []byte
+		want string
+	}{
+		{append(whole, 0x00, 0x00, 0x00), "offset 7 truncated"},
+		{append(whole, 0x00, 0x00, 0x00, 0x00, 0x09, 'x'), "offset 7 declares 9 bytes but only 1 remain"},
+	}
+	for _, tt := range tests {
+		req := &Request{Headers: Headers{{Key: "Content-Type", Value: "application/connect+json"}}, Body: tt.body}
+		frames, err := req.GRPCWebFrames()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("err = %v, want it to contain %q", err, tt.want)
+		}
+		if len(frames) != 1 || string(frames[0].Data) != "hi" {
+			t.Errorf("frames = %+v, want the one complete frame", frames)
+		}
+	}
+}
+
+func TestGRPCWebFrames_ContentType(t *testing.T) {
+	for _, ct := range []string{"application/json", "application/grpc-web-text", ""} {
+		if _, err := grpcResponse(ct, nil).GRPCWebFrames(); err == nil {
+			t.Errorf("Content-Type %q: want error", ct)
+		}
+	}
+	bad := append([]byte{0x80, 0, 0, 0, 8}, "no colon"...)
+	if _, err := grpcResponse("application/grpc-web", bad).GRPCWebFrames(); err == nil || !strings.Contains(err.Error(), "malformed trailer") {
+		t.Errorf("malformed trailer: err = %v", err)
+	}
+}
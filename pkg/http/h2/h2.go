@@ -0,0 +1,320 @@
+// Package h2 decodes captured cleartext HTTP/2 (h2c) traffic, RFC 9113.
+// It is read-only: ParseFrames splits the bytes one side of a connection
+// sent into frames, decoding their header blocks with HPACK, and
+// AssembleRequests rebuilds the requests a client sent from its frames.
+//
+//	frames, err := h2.ParseFrames(clientBytes)
+//	reqs, err := h2.AssembleRequests(frames)
+package h2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// Preface is the connection preface a client sends before its first
+// frame.
+const Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// FrameType is the type of a frame, RFC 9113 §6.
+type FrameType uint8
+
+// Frame types.
+const (
+	FrameData         FrameType = 0x0
+	FrameHeaders      FrameType = 0x1
+	FramePriority     FrameType = 0x2
+	FrameRSTStream    FrameType = 0x3
+	FrameSettings     FrameType = 0x4
+	FramePushPromise  FrameType = 0x5
+	FramePing         FrameType = 0x6
+	FrameGoAway       FrameType = 0x7
+	FrameWindowUpdate FrameType = 0x8
+	FrameContinuation FrameType = 0x9
+)
+
+var frameTypeNames = [...]string{
+	"DATA", "HEADERS", "PRIORITY", "RST_STREAM", "SETTINGS",
+	"PUSH_PROMISE", "PING", "GOAWAY", "WINDOW_UPDATE", "CONTINUATION",
+}
+
+// String returns the type's name, such as "HEADERS", or "UNKNOWN_0x%x".
+func (t FrameType) String() string {
+	if int(t) < len(frameTypeNames) {
+		return frameTypeNames[t]
+	}
+	return fmt.Sprintf("UNKNOWN_0x%x", uint8(t))
+}
+
+// Flags are a frame's flags. Their meaning depends on the frame type.
+type Flags uint8
+
+// Frame flags.
+const (
+	FlagEndStream  Flags = 0x1  // DATA, HEADERS
+	FlagAck        Flags = 0x1  // SETTINGS, PING
+	FlagEndHeaders Flags = 0x4  // HEADERS, CONTINUATION
+	FlagPadded     Flags = 0x8  // DATA, HEADERS, PUSH_PROMISE
+	FlagPriority   Flags = 0x20 // HEADERS
+)
+
+// Has reports whether all of want are set.
+func (f Flags) Has(want Flags) bool { return f&want == want }
+
+// Setting is one parameter of a SETTINGS frame.
+type Setting struct {
+	ID    uint16
+	Value uint32
+}
+
+// Frame is one decoded frame. The fields after Payload are set only for
+// the frame types they describe.
+type Frame struct {
+	Type     FrameType
+	Flags    Flags
+	StreamID uint32
+	Payload  []byte // the payload as sent, padding included
+
+	// Headers holds the decoded header block of a HEADERS or PUSH_PROMISE
+	// frame, including the CONTINUATION frames that follow it, in the order
+	// sent. PromisedStreamID is the stream a PUSH_PROMISE frame reserves.
+	Headers          http.Headers
+	PromisedStreamID uint32
+
+	// Data is a DATA frame's payload without padding.
+	Data []byte
+
+	// Settings lists a SETTINGS frame's parameters.
+	Settings []Setting
+
+	// ErrorCode is the error code of an RST_STREAM or GOAWAY frame, and
+	// LastStreamID and DebugData the rest of a GOAWAY frame.
+	ErrorCode    uint32
+	LastStreamID uint32
+	DebugData    []byte
+
+	// Increment is a WINDOW_UPDATE frame's window size increment.
+	Increment uint32
+}
+
+// frameHeaderLen is the length of the header before each frame's payload.
+const frameHeaderLen = 9
+
+// ParseFrames decodes the frames in data, the bytes one side of an h2c
+// connection sent, after the connection preface if it starts with one.
+// Header blocks are decoded in order, sharing one HPACK dynamic table, so
+// data must start at the beginning of the connection. Frames of unknown
+// type keep only their raw Payload. On error ParseFrames returns the frames
+// decoded before the one that failed.
+func ParseFrames(data []byte) ([]Frame, error) {
+	pos := 0
+	if bytes.HasPrefix(data, []byte(Preface)) {
+		pos = len(Preface)
+	}
+	dec := newDecoder()
+	var frames []Frame
+	var block []byte // header block awaiting CONTINUATION frames
+	headersAt := -1  // index in frames of the HEADERS frame block belongs to
+	for pos < len(data) {
+		if len(data)-pos < frameHeaderLen {
+			return decoded(frames, headersAt), fmt.Errorf("h2: frame header truncated at offset %d", pos)
+		}
+		h := data[pos : pos+frameHeaderLen]
+		length := int(h[0])<<16 | int(h[1])<<8 | int(h[2])
+		f := Frame{
+			Type:     FrameType(h[3]),
+			Flags:    Flags(h[4]),
+			StreamID: binary.BigEndian.Uint32(h[5:]) & 0x7fffffff,
+		}
+		if len(data)-pos-frameHeaderLen < length {
+			return decoded(frames, headersAt), fmt.Errorf("h2: %s frame at offset %d truncated: length %d, %d bytes available",
+				f.Type, pos, length, len(data)-pos-frameHeaderLen)
+		}
+		f.Payload = data[pos+frameHeaderLen : pos+frameHeaderLen+length]
+		if headersAt >= 0 && f.Type != FrameContinuation {
+			return decoded(frames, headersAt), fmt.Errorf("h2: %s frame at offset %d interrupts a header block", f.Type, pos)
+		}
+		if err := decodePayload(&f); err != nil {
+			return decoded(frames, headersAt), fmt.Errorf("h2: %s frame at offset %d: %v", f.Type, pos, err)
+		}
+		switch f.Type {
+		case FrameHeaders, FramePushPromise:
+			block, _ = unpad(f) // checked by decodePayload
+			if f.Type == FramePushPromise {
+				block = block[4:]
+			} else if f.Flags.Has(FlagPriority) {
+				block = block[5:]
+			}
+			headersAt = len(frames)
+		case FrameContinuation:
+			if headersAt < 0 {
+				return decoded(frames, headersAt), fmt.Errorf("h2: CONTINUATION frame at offset %d without a header block", pos)
+			}
+			block = append(block[:len(block):len(block)], f.Payload...)
+		}
+		frames = append(frames, f)
+		if headersAt >= 0 && f.Flags.Has(FlagEndHeaders) {
+			headers, err := dec.decode(block)
+			if err != nil {
+				return decoded(frames, headersAt), fmt.Errorf("h2: header block on stream %d: %v", f.StreamID, err)
+			}
+			frames[headersAt].Headers = headers
+			block, headersAt = nil, -1
+		}
+		pos += frameHeaderLen + length
+	}
+	if headersAt >= 0 {
+		return decoded(frames, headersAt), fmt.Errorf("h2: header block on stream %d has no END_HEADERS", frames[headersAt].StreamID)
+	}
+	return frames, nil
+}
+
+// decoded drops the frames from headersAt on, whose header block has not
+// been decoded, if headersAt is not -1.
+func decoded(frames []Frame, headersAt int) []Frame {
+	if headersAt >= 0 {
+		return frames[:headersAt]
+	}
+	return frames
+}
+
+// decodePayload sets f's type-specific fields from its payload, apart from
+// Headers, which ParseFrames decodes once the block is complete.
+func decodePayload(f *Frame) error {
+	p := f.Payload
+	switch f.Type {
+	case FrameData:
+		data, err := unpad(*f)
+		if err != nil {
+			return err
+		}
+		f.Data = data
+	case FrameHeaders:
+		block, err := unpad(*f)
+		if err != nil {
+			return err
+		}
+		if f.Flags.Has(FlagPriority) && len(block) < 5 {
+			return fmt.Errorf("payload of %d bytes too short for its priority", len(p))
+		}
+	case FramePushPromise:
+		block, err := unpad(*f)
+		if err != nil {
+			return err
+		}
+		if len(block) < 4 {
+			return fmt.Errorf("payload of %d bytes too short for a stream ID", len(p))
+		}
+		f.PromisedStreamID = binary.BigEndian.Uint32(block) & 0x7fffffff
+	case FrameSettings:
+		if len(p)%6 != 0 {
+			return fmt.Errorf("payload length %d is not a multiple of 6", len(p))
+		}
+		for ; len(p) > 0; p = p[6:] {
+			f.Settings = append(f.Settings, Setting{
+				ID:    binary.BigEndian.Uint16(p),
+				Value: binary.BigEndian.Uint32(p[2:]),
+			})
+		}
+	case FrameRSTStream:
+		if len(p) != 4 {
+			return fmt.Errorf("payload length %d, want 4", len(p))
+		}
+		f.ErrorCode = binary.BigEndian.Uint32(p)
+	case FrameGoAway:
+		if len(p) < 8 {
+			return fmt.Errorf("payload length %d, want at least 8", len(p))
+		}
+		f.LastStreamID = binary.BigEndian.Uint32(p) & 0x7fffffff
+		f.ErrorCode = binary.BigEndian.Uint32(p[4:])
+		f.DebugData = p[8:]
+	case FrameWindowUpdate:
+		if len(p) != 4 {
+			return fmt.Errorf("payload length %d, want 4", len(p))
+		}
+		f.Increment = binary.BigEndian.Uint32(p) & 0x7fffffff
+	}
+	return nil
+}
+
+// unpad returns the payload of a DATA, HEADERS or PUSH_PROMISE frame f without its
+// padding, RFC 9113 §6.1.
+func unpad(f Frame) ([]byte, error) {
+	p := f.Payload
+	if !f.Flags.Has(FlagPadded) {
+		return p, nil
+	}
+	if len(p) == 0 || int(p[0]) > len(p)-1 {
+		return nil, fmt.Errorf("padding exceeds payload of %d bytes", len(p))
+	}
+	return p[1 : len(p)-int(p[0])], nil
+}
+
+// AssembleRequests rebuilds the requests in frames, the frames a client
+// sent, from each stream's HEADERS and DATA frames, in the order the
+// streams opened. The :method and :path pseudo-headers become Method and
+// Path, :scheme becomes Scheme and :authority a Host header in front of
+// the regular headers; Version is "HTTP/2". Trailers sent in a second
+// HEADERS frame are appended to Headers. A request needs :method; streams
+// that have not ended yet are returned as far as they got.
+func AssembleRequests(frames []Frame) ([]*http.Request, error) {
+	var reqs []*http.Request
+	streams := make(map[uint32]*http.Request)
+	for _, f := range frames {
+		switch f.Type {
+		case FrameHeaders:
+			if req := streams[f.StreamID]; req != nil {
+				req.Headers = append(req.Headers, f.Headers...)
+				continue
+			}
+			req, err := newRequest(f)
+			if err != nil {
+				return reqs, err
+			}
+			streams[f.StreamID] = req
+			reqs = append(reqs, req)
+		case FrameData:
+			req := streams[f.StreamID]
+			if req == nil {
+				return reqs, fmt.Errorf("h2: DATA frame on stream %d before its HEADERS", f.StreamID)
+			}
+			req.Body = append(req.Body, f.Data...)
+		}
+	}
+	return reqs, nil
+}
+
+// newRequest starts the request that the HEADERS frame f opens.
+func newRequest(f Frame) (*http.Request, error) {
+	req := &http.Request{Version: "HTTP/2"}
+	var host string
+	for i, h := range f.Headers {
+		if len(h.Key) == 0 || h.Key[0] != ':' {
+			req.Headers = append(req.Headers, f.Headers[i:]...)
+			break
+		}
+		switch h.Key {
+		case ":method":
+			req.Method = h.Value
+		case ":path":
+			req.Path = h.Value
+		case ":scheme":
+			req.Scheme = h.Value
+		case ":authority":
+			host = h.Value
+		default:
+			return nil, fmt.Errorf("h2: stream %d: unknown pseudo-header %s", f.StreamID, strconv.Quote(h.Key))
+		}
+	}
+	if req.Method == "" {
+		return nil, fmt.Errorf("h2: stream %d: request has no :method", f.StreamID)
+	}
+	if host != "" && !req.Headers.Has("Host") {
+		req.Headers = append(http.Headers{{Key: "Host", Value: host}}, req.Headers...)
+	}
+	return req, nil
+}
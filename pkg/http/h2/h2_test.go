@@ -0,0 +1,219 @@
+package h2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// curlPost is what curl 7.88.1 sent for
+//
+//	curl --http2-prior-knowledge -H 'X-Trace-Id: abc123' \
+//	    -H 'Content-Type: application/json' -d '{"name":"widget"}' \
+//	    http://127.0.0.1:18083/api/items
+//
+// up to its SETTINGS acknowledgement: the preface, SETTINGS, WINDOW_UPDATE,
+// HEADERS, DATA and SETTINGS with ACK.
+const curlPost = Preface +
+	"\x00\x00\x12\x04\x00\x00\x00\x00\x00\x00\x03\x00\x00\x00\x64\x00\x04\x02\x00\x00\x00\x00\x02\x00\x00\x00\x00" +
+	"\x00\x00\x04\x08\x00\x00\x00\x00\x00\x01\xff\x00\x01" +
+	"\x00\x00\x47\x01\x04\x00\x00\x00\x01\x83\x04\x87\x60\x75\x99\x83\x24\xb4\xa3\x86\x41\x8b\x08\x9d\x5c\x0b\x81" +
+	"\x70\xdc\x0b\xc0\x79\x9f\x7a\x88\x25\xb6\x50\xc3\xab\xbc\xf2\xe1\x53\x03\x2a\x2f\x2a\x40\x87\xf2\xb2\x6c\x19" +
+	"\x0a\xb1\xa4\x84\x1c\x64\x08\x99\x5f\x8b\x1d\x75\xd0\x62\x0d\x26\x3d\x4c\x74\x41\xea\x0f\x0d\x02\x31\x37" +
+	"\x00\x00\x11\x00\x01\x00\x00\x00\x01" + `{"name":"widget"}` +
+	"\x00\x00\x00\x04\x01\x00\x00\x00\x00"
+
+// curlPostResponse is the test server's side of the same exchange.
+const curlPostResponse = "\x00\x00\x00\x04\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x04\x01\x00\x00\x00\x00" +
+	"\x00\x00\x01\x01\x05\x00\x00\x00\x01\x89"
+
+func TestParseFrames_Curl(t *testing.T) {
+	frames, err := ParseFrames([]byte(curlPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, f := range frames {
+		types = append(types, f.Type.String())
+	}
+	if got := strings.Join(types, " "); got != "SETTINGS WINDOW_UPDATE HEADERS DATA SETTINGS" {
+		t.Fatalf("frames = %s", got)
+	}
+	wantSettings := []Setting{{ID: 3, Value: 100}, {ID: 4, Value: 0x2000000}, {ID: 2, Value: 0}}
+	if !reflect.DeepEqual(frames[0].Settings, wantSettings) {
+		t.Errorf("Settings = %v, want %v", frames[0].Settings, wantSettings)
+	}
+	if frames[1].Increment != 0x1ff0001 || frames[1].StreamID != 0 {
+		t.Errorf("WINDOW_UPDATE = %+v", frames[1])
+	}
+	h := frames[2]
+	if h.StreamID != 1 || !h.Flags.Has(FlagEndHeaders) || h.Flags.Has(FlagEndStream) {
+		t.Errorf("HEADERS stream %d flags %#x", h.StreamID, h.Flags)
+	}
+	wantHeaders := http.Headers{
+		{Key: ":method", Value: "POST"},
+		{Key: ":path", Value: "/api/items"},
+		{Key: ":scheme", Value: "http"},
+		{Key: ":authority", Value: "127.0.0.1:18083"},
+		{Key: "user-agent", Value: "curl/7.88.1"},
+		{Key: "accept", Value: "*/*"},
+		{Key: "x-trace-id", Value: "abc123"},
+		{Key: "content-type", Value: "application/json"},
+		{Key: "content-length", Value: "17"},
+	}
+	if !reflect.DeepEqual(h.Headers, wantHeaders) {
+		t.Errorf("Headers = %v\nwant %v", h.Headers, wantHeaders)
+	}
+	if d := frames[3]; string(d.Data) != `{"name":"widget"}` || !d.Flags.Has(FlagEndStream) {
+		t.Errorf("DATA = %q, flags %#x", d.Data, d.Flags)
+	}
+	if !frames[4].Flags.Has(FlagAck) {
+		t.Errorf("last SETTINGS flags %#x, want ACK", frames[4].Flags)
+	}
+
+	frames, err = ParseFrames([]byte(curlPostResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 || !reflect.DeepEqual(frames[2].Headers, http.Headers{{Key: ":status", Value: "204"}}) {
+		t.Errorf("response frames = %+v", frames)
+	}
+}
+
+func TestAssembleRequests_Curl(t *testing.T) {
+	frames, err := ParseFrames([]byte(curlPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs, err := AssembleRequests(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &http.Request{
+		Method:  "POST",
+		Path:    "/api/items",
+		Version: "HTTP/2",
+		Scheme:  "http",
+		Headers: http.Headers{
+			{Key: "Host", Value: "127.0.0.1:18083"},
+			{Key: "user-agent", Value: "curl/7.88.1"},
+			{Key: "accept", Value: "*/*"},
+			{Key: "x-trace-id", Value: "abc123"},
+			{Key: "content-type", Value: "application/json"},
+			{Key: "content-length", Value: "17"},
+		},
+		Body: []byte(`{"name":"widget"}`),
+	}
+	if len(reqs) != 1 || !reflect.DeepEqual(reqs[0], want) {
+		t.Errorf("AssembleRequests = %+v, want %+v", reqs, want)
+	}
+}
+
+// frame encodes a frame header and payload.
+func frame(typ FrameType, flags Flags, stream uint32, payload string) string {
+	n := len(payload)
+	return string([]byte{byte(n >> 16), byte(n >> 8), byte(n), byte(typ), byte(flags),
+		byte(stream >> 24), byte(stream >> 16), byte(stream >> 8), byte(stream)}) + payload
+}
+
+func TestParseFrames_Payloads(t *testing.T) {
+	data := frame(FrameHeaders, FlagPadded|FlagPriority, 3, "\x02\x00\x00\x00\x01\x10\x82\x84\x00\x00") +
+		frame(FrameContinuation, FlagEndHeaders, 3, "\x40\x03key\x05value") +
+		frame(FrameData, FlagPadded|FlagEndStream, 3, "\x03abcXYZ") +
+		frame(FrameRSTStream, 0, 3, "\x00\x00\x00\x08") +
+		frame(FrameGoAway, 0, 0, "\x00\x00\x00\x03\x00\x00\x00\x02bye") +
+		frame(0xfa, 0x3, 5, "raw")
+	frames, err := ParseFrames([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 6 {
+		t.Fatalf("got %d frames, want 6", len(frames))
+	}
+	wantHeaders := http.Headers{{Key: ":method", Value: "GET"}, {Key: ":path", Value: "/"}, {Key: "key", Value: "value"}}
+	if !reflect.DeepEqual(frames[0].Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", frames[0].Headers, wantHeaders)
+	}
+	if string(frames[2].Data) != "abc" {
+		t.Errorf("Data = %q, want abc", frames[2].Data)
+	}
+	if frames[3].ErrorCode != 8 {
+		t.Errorf("RST_STREAM ErrorCode = %d, want 8", frames[3].ErrorCode)
+	}
+	if g := frames[4]; g.LastStreamID != 3 || g.ErrorCode != 2 || string(g.DebugData) != "bye" {
+		t.Errorf("GOAWAY = %+v", g)
+	}
+	if u := frames[5]; u.Type.String() != "UNKNOWN_0xfa" || u.Flags != 0x3 || u.StreamID != 5 || string(u.Payload) != "raw" {
+		t.Errorf("unknown frame = %+v", u)
+	}
+}
+
+func TestParseFrames_Errors(t *testing.T) {
+	headers := frame(FrameHeaders, FlagEndHeaders, 1, "\x82\x84")
+	tests := []struct {
+		data    string
+		frames  int // frames returned with the error
+		wantErr string
+	}{
+		{headers + "\x00\x00", 1, "h2: frame header truncated at offset 11"},
+		{headers + frame(FrameData, 0, 1, "abcd")[:11], 1, "h2: DATA frame at offset 11 truncated: length 4, 2 bytes available"},
+		{frame(FrameSettings, 0, 0, "\x00\x03\x00"), 0, "h2: SETTINGS frame at offset 0: payload length 3 is not a multiple of 6"},
+		{frame(FrameData, FlagPadded, 1, "\x05ab"), 0, "h2: DATA frame at offset 0: padding exceeds payload of 3 bytes"},
+		{frame(FrameHeaders, 0, 1, "\x82") + frame(FrameData, 0, 1, "x"), 0, "h2: DATA frame at offset 10 interrupts a header block"},
+		{frame(FrameContinuation, FlagEndHeaders, 1, "\x82"), 0, "h2: CONTINUATION frame at offset 0 without a header block"},
+		{frame(FrameHeaders, 0, 1, "\x82"), 0, "h2: header block on stream 1 has no END_HEADERS"},
+		{headers + frame(FrameHeaders, FlagEndHeaders, 3, "\xc0"), 1, "h2: header block on stream 3: header table index 64 out of range"},
+	}
+	for _, tt := range tests {
+		frames, err := ParseFrames([]byte(tt.data))
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ParseFrames(%q) err = %v, want %s", tt.data, err, tt.wantErr)
+		}
+		if len(frames) != tt.frames {
+			t.Errorf("ParseFrames(%q) returned %d frames, want %d", tt.data, len(frames), tt.frames)
+		}
+	}
+}
+
+func TestAssembleRequests(t *testing.T) {
+	frames, err := ParseFrames([]byte(
+		frame(FrameHeaders, FlagEndHeaders, 1, "\x83\x86\x44\x06/a?x=1\x41\x07example") +
+			frame(FrameHeaders, FlagEndHeaders|FlagEndStream, 3, "\x82\x86\x84\xbe") +
+			frame(FrameData, 0, 1, "part1,") +
+			frame(FrameData, 0, 1, "part2") +
+			frame(FrameHeaders, FlagEndHeaders|FlagEndStream, 1, "\x00\x07trailer\x01t")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs, err := AssembleRequests(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	if r := reqs[0]; r.Method != "POST" || r.Path != "/a?x=1" || string(r.Body) != "part1,part2" ||
+		!reflect.DeepEqual(r.Headers, http.Headers{{Key: "Host", Value: "example"}, {Key: "trailer", Value: "t"}}) {
+		t.Errorf("request 1 = %+v", r)
+	}
+	if r := reqs[1]; r.Method != "GET" || r.Path != "/" || r.Headers.Get("Host") != "example" {
+		t.Errorf("request 3 = %+v", r)
+	}
+
+	for data, want := range map[string]string{
+		frame(FrameData, 0, 1, "x"):                        "h2: DATA frame on stream 1 before its HEADERS",
+		frame(FrameHeaders, FlagEndHeaders, 1, "\x84"):     "h2: stream 1: request has no :method",
+		frame(FrameHeaders, FlagEndHeaders, 1, "\x88\x82"): `h2: stream 1: unknown pseudo-header ":status"`,
+	} {
+		frames, err := ParseFrames([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := AssembleRequests(frames); err == nil || err.Error() != want {
+			t.Errorf("AssembleRequests(%q) err = %v, want %s", data, err, want)
+		}
+	}
+}
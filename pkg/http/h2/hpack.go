@@ -0,0 +1,188 @@
+package h2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// field is a header table entry.
+type field struct {
+	name, value string
+}
+
+// size is the entry's size for the table size limit, RFC 7541 §4.1.
+func (f field) size() int { return len(f.name) + len(f.value) + 32 }
+
+// defaultTableSize is the initial dynamic table size limit,
+// SETTINGS_HEADER_TABLE_SIZE's default.
+const defaultTableSize = 4096
+
+// decoder decodes the header blocks sent in one direction of a connection,
+// which share a dynamic table.
+type decoder struct {
+	dynamic []field // newest first
+	size    int
+	maxSize int
+}
+
+func newDecoder() *decoder {
+	return &decoder{maxSize: defaultTableSize}
+}
+
+var errTruncatedBlock = errors.New("truncated header block")
+
+// decode decodes a complete header block into its fields.
+func (d *decoder) decode(block []byte) (http.Headers, error) {
+	var headers http.Headers
+	for len(block) > 0 {
+		b := block[0]
+		switch {
+		case b&0x80 != 0: // indexed field, §6.1
+			i, rest, err := readInt(block, 7)
+			if err != nil {
+				return nil, err
+			}
+			f, err := d.at(i)
+			if err != nil {
+				return nil, err
+			}
+			headers = append(headers, http.Header{Key: f.name, Value: f.value})
+			block = rest
+		case b&0xc0 == 0x40: // literal with incremental indexing, §6.2.1
+			f, rest, err := d.literal(block, 6)
+			if err != nil {
+				return nil, err
+			}
+			d.add(f)
+			headers = append(headers, http.Header{Key: f.name, Value: f.value})
+			block = rest
+		case b&0xe0 == 0x20: // dynamic table size update, §6.3
+			n, rest, err := readInt(block, 5)
+			if err != nil {
+				return nil, err
+			}
+			d.maxSize = int(n)
+			d.evict()
+			block = rest
+		default: // literal without indexing or never indexed, §6.2.2-3
+			f, rest, err := d.literal(block, 4)
+			if err != nil {
+				return nil, err
+			}
+			headers = append(headers, http.Header{Key: f.name, Value: f.value})
+			block = rest
+		}
+	}
+	return headers, nil
+}
+
+// at returns the field at index i of the combined static and dynamic
+// table, §2.3.3.
+func (d *decoder) at(i uint64) (field, error) {
+	switch {
+	case i == 0:
+		return field{}, errors.New("header table index 0")
+	case i <= uint64(len(staticTable)):
+		return staticTable[i-1], nil
+	case i-uint64(len(staticTable)) <= uint64(len(d.dynamic)):
+		return d.dynamic[i-uint64(len(staticTable))-1], nil
+	}
+	return field{}, fmt.Errorf("header table index %d out of range", i)
+}
+
+// literal reads a literal field whose name index has an n-bit prefix.
+func (d *decoder) literal(block []byte, n uint) (field, []byte, error) {
+	i, rest, err := readInt(block, n)
+	if err != nil {
+		return field{}, nil, err
+	}
+	var f field
+	if i > 0 {
+		named, err := d.at(i)
+		if err != nil {
+			return field{}, nil, err
+		}
+		f.name = named.name
+	} else if f.name, rest, err = readString(rest); err != nil {
+		return field{}, nil, err
+	}
+	if f.value, rest, err = readString(rest); err != nil {
+		return field{}, nil, err
+	}
+	return f, rest, nil
+}
+
+// add inserts f at the front of the dynamic table, evicting old entries to
+// stay within the size limit, §4.4.
+func (d *decoder) add(f field) {
+	if f.size() > d.maxSize {
+		d.dynamic, d.size = d.dynamic[:0], 0
+		return
+	}
+	d.dynamic = append(d.dynamic, field{})
+	copy(d.dynamic[1:], d.dynamic)
+	d.dynamic[0] = f
+	d.size += f.size()
+	d.evict()
+}
+
+func (d *decoder) evict() {
+	for d.size > d.maxSize && len(d.dynamic) > 0 {
+		d.size -= d.dynamic[len(d.dynamic)-1].size()
+		d.dynamic = d.dynamic[:len(d.dynamic)-1]
+	}
+}
+
+// readInt reads an integer with an n-bit prefix, §5.1.
+func readInt(p []byte, n uint) (uint64, []byte, error) {
+	if len(p) == 0 {
+		return 0, nil, errTruncatedBlock
+	}
+	max := uint64(1)<<n - 1
+	i := uint64(p[0]) & max
+	p = p[1:]
+	if i < max {
+		return i, p, nil
+	}
+	for shift := uint(0); ; shift += 7 {
+		if len(p) == 0 {
+			return 0, nil, errTruncatedBlock
+		}
+		if shift > 56 {
+			return 0, nil, errors.New("header block integer overflow")
+		}
+		b := p[0]
+		p = p[1:]
+		i += uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return i, p, nil
+		}
+	}
+}
+
+// readString reads a string literal, Huffman-coded if its high bit is
+// set, §5.2.
+func readString(p []byte) (string, []byte, error) {
+	if len(p) == 0 {
+		return "", nil, errTruncatedBlock
+	}
+	huffman := p[0]&0x80 != 0
+	n, rest, err := readInt(p, 7)
+	if err != nil {
+		return "", nil, err
+	}
+	if n > uint64(len(rest)) {
+		return "", nil, errTruncatedBlock
+	}
+	s, rest := rest[:n], rest[n:]
+	if !huffman {
+		return string(s), rest, nil
+	}
+	decoded, err := huffmanDecode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	return decoded, rest, nil
+}
@@ -0,0 +1,85 @@
+package h2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http"
+)
+
+// The request examples with Huffman coding from RFC 7541 Appendix C.4,
+// decoded in order with one dynamic table.
+func TestDecoder_RFC7541Requests(t *testing.T) {
+	tests := []struct {
+		block string
+		want  http.Headers
+		size  int
+	}{
+		{
+			"\x82\x86\x84\x41\x8c\xf1\xe3\xc2\xe5\xf2\x3a\x6b\xa0\xab\x90\xf4\xff",
+			http.Headers{{Key: ":method", Value: "GET"}, {Key: ":scheme", Value: "http"}, {Key: ":path", Value: "/"},
+				{Key: ":authority", Value: "www.example.com"}},
+			57,
+		},
+		{
+			"\x82\x86\x84\xbe\x58\x86\xa8\xeb\x10\x64\x9c\xbf",
+			http.Headers{{Key: ":method", Value: "GET"}, {Key: ":scheme", Value: "http"}, {Key: ":path", Value: "/"},
+				{Key: ":authority", Value: "www.example.com"}, {Key: "cache-control", Value: "no-cache"}},
+			110,
+		},
+		{
+			"\x82\x87\x85\xbf\x40\x88\x25\xa8\x49\xe9\x5b\xa9\x7d\x7f\x89\x25\xa8\x49\xe9\x5b\xb8\xe8\xb4\xbf",
+			http.Headers{{Key: ":method", Value: "GET"}, {Key: ":scheme", Value: "https"}, {Key: ":path", Value: "/index.html"},
+				{Key: ":authority", Value: "www.example.com"}, {Key: "custom-key", Value: "custom-value"}},
+			164,
+		},
+	}
+	d := newDecoder()
+	for i, tt := range tests {
+		got, err := d.decode([]byte(tt.block))
+		if err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("block %d = %v, want %v", i, got, tt.want)
+		}
+		if d.size != tt.size {
+			t.Errorf("block %d: table size = %d, want %d", i, d.size, tt.size)
+		}
+	}
+}
+
+func TestDecoder_Eviction(t *testing.T) {
+	d := newDecoder()
+	// Limit the table to 48 bytes, room for one 34-byte entry, then add
+	// a: b and c: d.
+	got, err := d.decode([]byte("\x3f\x11\x40\x01a\x01b\x40\x01c\x01d\xbe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := http.Headers{{Key: "a", Value: "b"}, {Key: "c", Value: "d"}, {Key: "c", Value: "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decode = %v, want %v", got, want)
+	}
+	if _, err := d.decode([]byte("\xbf")); err == nil || err.Error() != "header table index 63 out of range" {
+		t.Errorf("index 63: err = %v", err)
+	}
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		block, want string
+	}{
+		{"\x80", "header table index 0"},
+		{"\xff\x80", "truncated header block"},
+		{"\x40\x05ab", "truncated header block"},
+		{"\x04\x81\x00", "invalid Huffman-coded string"},             // padding of zeros
+		{"\x04\x84\xff\xff\xff\xff", "invalid Huffman-coded string"}, // EOS
+	}
+	for _, tt := range tests {
+		_, err := newDecoder().decode([]byte(tt.block))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("decode(%q) err = %v, want %s", tt.block, err, tt.want)
+		}
+	}
+}
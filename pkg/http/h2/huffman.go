@@ -0,0 +1,64 @@
+package h2
+
+import (
+	"errors"
+	"sync"
+)
+
+// huffmanNode is a node of the Huffman decoding tree: a leaf holding sym,
+// or an inner node with both children set.
+type huffmanNode struct {
+	children [2]*huffmanNode
+	sym      byte
+}
+
+var (
+	huffmanOnce sync.Once
+	huffmanRoot *huffmanNode
+)
+
+func buildHuffmanTree() {
+	huffmanRoot = &huffmanNode{}
+	for sym, code := range huffmanCodes {
+		n := huffmanRoot
+		for bit := int(huffmanCodeLen[sym]) - 1; bit >= 0; bit-- {
+			b := code >> uint(bit) & 1
+			if n.children[b] == nil {
+				n.children[b] = &huffmanNode{}
+			}
+			n = n.children[b]
+		}
+		n.sym = byte(sym)
+	}
+}
+
+var errHuffman = errors.New("invalid Huffman-coded string")
+
+// huffmanDecode decodes a Huffman-coded string literal, RFC 7541 §5.2. The
+// padding after the last symbol must be fewer than 8 one bits.
+func huffmanDecode(p []byte) (string, error) {
+	huffmanOnce.Do(buildHuffmanTree)
+	out := make([]byte, 0, len(p)*8/5)
+	n := huffmanRoot
+	depth, ones := 0, true
+	for _, c := range p {
+		for bit := 7; bit >= 0; bit-- {
+			b := c >> uint(bit) & 1
+			n = n.children[b]
+			if n == nil {
+				// Only EOS, 30 one bits, leads off the tree.
+				return "", errHuffman
+			}
+			depth++
+			ones = ones && b == 1
+			if n.children[0] == nil {
+				out = append(out, n.sym)
+				n, depth, ones = huffmanRoot, 0, true
+			}
+		}
+	}
+	if depth > 7 || !ones {
+		return "", errHuffman
+	}
+	return string(out), nil
+}
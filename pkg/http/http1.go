@@ -0,0 +1,32 @@
+package http
+
+import "strings"
+
+// ToHTTP1 returns a copy of req rewritten as HTTP/1.1, the only wire format
+// Marshal produces, and the version req was written for. A request from
+// ParseCurl with --http2 or --http3, or one read from HTTP/2 frames, can
+// then be marshaled and replayed over HTTP/1.1 while version keeps what
+// the client intended. An :authority pseudo-header becomes the Host header
+// unless there is one, and the other pseudo-headers, which duplicate
+// Method, Path and Scheme, are dropped. Connection-specific headers such as
+// Connection are kept, since HTTP/1.1 allows them. req is not modified; a
+// request that is already HTTP/1.x is returned as a copy with its version.
+func ToHTTP1(req *Request) (out *Request, version string) {
+	c := *req
+	c.Headers = make(Headers, 0, len(req.Headers))
+	authority := ""
+	for _, h := range req.Headers {
+		if !strings.HasPrefix(h.Key, ":") {
+			c.Headers = append(c.Headers, h)
+		} else if h.Key == ":authority" && authority == "" {
+			authority = h.Value
+		}
+	}
+	if authority != "" && !c.Headers.Has(HeaderHost) {
+		c.Headers = append(Headers{{Key: HeaderHost, Value: authority}}, c.Headers...)
+	}
+	if !strings.HasPrefix(req.Version, "HTTP/1.") {
+		c.Version = "HTTP/1.1"
+	}
+	return &c, req.Version
+}
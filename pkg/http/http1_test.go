@@ -0,0 +1,46 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToHTTP1(t *testing.T) {
+	req := &Request{
+		Method:  "GET",
+		Path:    "/items",
+		Version: "HTTP/2",
+		Scheme:  "https",
+		Headers: Headers{
+			{Key: ":method", Value: "GET"},
+			{Key: ":authority", Value: "api.example.com"},
+			{Key: ":path", Value: "/items"},
+			{Key: "accept", Value: "*/*"},
+		},
+	}
+	out, version := ToHTTP1(req)
+	if version != "HTTP/2" || out.Version != "HTTP/1.1" {
+		t.Errorf("Version = %q, intended %q, want HTTP/1.1 and HTTP/2", out.Version, version)
+	}
+	want := Headers{{Key: "Host", Value: "api.example.com"}, {Key: "accept", Value: "*/*"}}
+	if !reflect.DeepEqual(out.Headers, want) {
+		t.Errorf("Headers = %q, want %q", out.Headers, want)
+	}
+	if req.Version != "HTTP/2" || len(req.Headers) != 4 {
+		t.Errorf("req was modified: %+v", req)
+	}
+	wire, err := Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "GET /items HTTP/1.1\r\nHost: api.example.com\r\naccept: */*\r\n\r\n"; string(wire) != want {
+		t.Errorf("Marshal = %q, want %q", wire, want)
+	}
+
+	// A Host header wins over :authority, and HTTP/1.0 is kept.
+	req = &Request{Method: "GET", Path: "/", Version: "HTTP/1.0", Headers: Headers{{Key: "Host", Value: "a.example"}, {Key: ":authority", Value: "b.example"}}}
+	out, version = ToHTTP1(req)
+	if version != "HTTP/1.0" || out.Version != "HTTP/1.0" || !reflect.DeepEqual(out.Headers, Headers{{Key: "Host", Value: "a.example"}}) {
+		t.Errorf("HTTP/1.0: got %q %q, intended %q", out.Version, out.Headers, version)
+	}
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HTTPFileOptions configures ParseHTTPFileWithOptions.
+type HTTPFileOptions struct {
+	// Variables supplies values for {{name}} placeholders, like an
+	// environment does in the editors. Variables defined in the file with
+	// "@name = value" override them.
+	Variables map[string]string
+
+	// FileReader, when non-nil, is called to read the file a "< path"
+	// body line names. Without it the body is left empty and a warning
+	// names the file.
+	FileReader func(name string) ([]byte, error)
+}
+
+// ParseHTTPFile parses the requests in a .http or .rest file, the format of
+// the VS Code REST Client and JetBrains HTTP Client:
+//
+//	@host = api.example.com
+//
+//	### Create a user
+//	# @name create
+//	POST https://{{host}}/users
+//	Content-Type: application/json
+//	// sent on every request
+//
+//	< ./user.json
+//
+// Requests are separated by lines starting with "###". Lines starting with
+// "#" or "//" are comments before the request line and among the headers,
+// and are skipped without a warning; in the body they are body text.
+// "@name = value" lines before a request define variables for the
+// requests after them. Each request is parsed with UnmarshalLenient after
+// its {{name}} placeholders are replaced; a placeholder with no value is
+// left as written, with a warning. A body consisting of "< path" is read
+// with HTTPFileOptions.FileReader. Warnings give line numbers in data.
+// Blocks holding only comments are skipped; it returns an error if data
+// holds no request.
+func ParseHTTPFile(data []byte) ([]*ParseResult, error) {
+	return ParseHTTPFileWithOptions(data, HTTPFileOptions{})
+}
+
+// ParseHTTPFileWithOptions is ParseHTTPFile with explicit options.
+func ParseHTTPFileWithOptions(data []byte, opts HTTPFileOptions) ([]*ParseResult, error) {
+	vars := make(map[string]string, len(opts.Variables))
+	for name, value := range opts.Variables {
+		vars[name] = value
+	}
+	f := &httpFile{vars: vars, opts: opts}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	start := 0
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "###") {
+			f.block(lines[start:i], start+1)
+			start = i + 1
+		}
+	}
+	f.block(lines[start:], start+1)
+	if len(f.results) == 0 {
+		return nil, fmt.Errorf("http: no requests in .http file")
+	}
+	return f.results, nil
+}
+
+// httpFile holds the state of ParseHTTPFileWithOptions across blocks.
+type httpFile struct {
+	vars    map[string]string
+	opts    HTTPFileOptions
+	results []*ParseResult
+}
+
+// block parses the request in lines, which start at line first of the
+// file, if there is one.
+func (f *httpFile) block(lines []string, first int) {
+	var (
+		msg      strings.Builder
+		lineAt   []int // file line of each line written to msg
+		warnings []string
+		unknown  = make(map[string]bool)
+	)
+	write := func(s string, at int) {
+		msg.WriteString(f.substitute(s, at, unknown, &warnings))
+		lineAt = append(lineAt, at)
+	}
+
+	i := 0
+	// Comments, blank lines and variable definitions before the request.
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "" || isHTTPFileComment(line):
+			continue
+		case line[0] == '@':
+			if name, value, ok := strings.Cut(line[1:], "="); ok {
+				f.vars[strings.TrimSpace(name)] = f.substitute(strings.TrimSpace(value), first+i, unknown, &warnings)
+				continue
+			}
+		}
+		break
+	}
+	if i == len(lines) {
+		return
+	}
+	eol := "\n"
+	if strings.HasSuffix(lines[i], "\r\n") {
+		eol = "\r\n"
+	}
+	write(lines[i], first+i)
+
+	// Headers, with comments dropped. The block's end also ends them.
+	ended := false
+	for i++; i < len(lines) && !ended; i++ {
+		line := strings.TrimSpace(lines[i])
+		if isHTTPFileComment(line) {
+			continue
+		}
+		write(lines[i], first+i)
+		ended = line == ""
+	}
+	if !ended {
+		if !strings.HasSuffix(msg.String(), "\n") {
+			msg.WriteString(eol)
+		}
+		msg.WriteString(eol)
+	}
+
+	// The body, without the blank lines before the next separator.
+	body := lines[i:]
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	if len(body) > 0 {
+		body[len(body)-1] = strings.TrimRight(body[len(body)-1], "\r\n")
+	}
+	if len(body) == 1 && strings.HasPrefix(body[0], "< ") {
+		msg.Write(f.readBody(strings.TrimSpace(body[0][2:]), first+i, &warnings))
+	} else {
+		for j, line := range body {
+			write(line, first+i+j)
+		}
+	}
+
+	result := UnmarshalLenient([]byte(msg.String()))
+	for _, w := range result.Warnings {
+		warnings = append(warnings, renumberWarning(w, lineAt))
+	}
+	result.Warnings = warnings
+	f.results = append(f.results, result)
+}
+
+// isHTTPFileComment reports whether the trimmed line is a comment.
+func isHTTPFileComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+// substitute replaces the {{name}} placeholders in s, from line at of the
+// file, with their values, warning once per block about each name that
+// has none.
+func (f *httpFile) substitute(s string, at int, unknown map[string]bool, warnings *[]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		open := strings.Index(s, "{{")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(s[open+2:], "}}")
+		if end < 0 {
+			break
+		}
+		name := strings.TrimSpace(s[open+2 : open+2+end])
+		b.WriteString(s[:open])
+		if value, ok := f.vars[name]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[open : open+end+4])
+			if !unknown[name] {
+				unknown[name] = true
+				*warnings = append(*warnings, fmt.Sprintf("line %d: variable %s is not defined, left as written", at, strconv.Quote(name)))
+			}
+		}
+		s = s[open+end+4:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// readBody returns the contents of the body file name, named on line at,
+// or nil with a warning.
+func (f *httpFile) readBody(name string, at int, warnings *[]string) []byte {
+	if f.opts.FileReader == nil {
+		*warnings = append(*warnings, fmt.Sprintf("line %d: body file %s not read, body left empty", at, strconv.Quote(name)))
+		return nil
+	}
+	data, err := f.opts.FileReader(name)
+	if err != nil {
+		*warnings = append(*warnings, fmt.Sprintf("line %d: body file %s: %v, body left empty", at, strconv.Quote(name), err))
+		return nil
+	}
+	return data
+}
+
+// renumberWarning rewrites the "line N: " prefix of a warning about the
+// message built from the file lines lineAt to the line in the file.
+func renumberWarning(w string, lineAt []int) string {
+	rest, ok := strings.CutPrefix(w, "line ")
+	if !ok {
+		return w
+	}
+	num, msg, ok := strings.Cut(rest, ": ")
+	n, err := strconv.Atoi(num)
+	if !ok || err != nil || n < 1 || n > len(lineAt) {
+		return w
+	}
+	return "line " + strconv.Itoa(lineAt[n-1]) + ": " + msg
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const usersHTTPFile = `# Users API, run from the editor.
+@host = api.example.com
+@base = https://{{host}}/v1
+
+### List users
+# @name list
+GET {{base}}/users?limit=10 HTTP/1.1
+Accept: application/json
+// Authorization: Bearer old-token
+Authorization: Bearer {{token}}
+
+###
+
+### Create a user
+POST {{base}}/users HTTP/1.1
+Content-Type: application/json
+# X-Debug: 1
+
+{
+  "name": "Ada",
+  "# not a comment": true
+}
+
+### Upload an avatar
+PUT {{base}}/users/1/avatar HTTP/1.1
+Content-Type: image/png
+
+< ./avatar.png
+`
+
+func TestParseHTTPFile(t *testing.T) {
+	results, err := ParseHTTPFileWithOptions([]byte(usersHTTPFile), HTTPFileOptions{
+		Variables: map[string]string{"token": "t0k3n", "host": "overridden.example.com"},
+		FileReader: func(name string) ([]byte, error) {
+			if name != "./avatar.png" {
+				return nil, errors.New("no such file")
+			}
+			return []byte("\x89PNG"), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	list := results[0].Request
+	if list.Method != "GET" || list.Path != "/v1/users?limit=10" {
+		t.Errorf("list: %s %s, want GET /v1/users?limit=10", list.Method, list.Path)
+	}
+	wantHeaders := Headers{
+		{Key: "Host", Value: "api.example.com"},
+		{Key: "Accept", Value: "application/json"},
+		{Key: "Authorization", Value: "Bearer t0k3n"},
+	}
+	if !reflect.DeepEqual(list.Headers, wantHeaders) {
+		t.Errorf("list: Headers = %q, want %q", list.Headers, wantHeaders)
+	}
+	wantWarnings := []string{`line 7: absolute-form request-target: extracted Host "api.example.com", using path "/v1/users?limit=10"`}
+	if !reflect.DeepEqual(results[0].Warnings, wantWarnings) {
+		t.Errorf("list: Warnings = %q, want %q", results[0].Warnings, wantWarnings)
+	}
+
+	create := results[1].Request
+	if create.Method != "POST" || create.Headers.Has("X-Debug") {
+		t.Errorf("create: %s with headers %q, want POST without X-Debug", create.Method, create.Headers)
+	}
+	if want := "{\n  \"name\": \"Ada\",\n  \"# not a comment\": true\n}"; string(create.Body) != want {
+		t.Errorf("create: Body = %q, want %q", create.Body, want)
+	}
+
+	upload := results[2].Request
+	if string(upload.Body) != "\x89PNG" || upload.Headers.Get("Content-Type") != "image/png" {
+		t.Errorf("upload: Body = %q, Content-Type %q", upload.Body, upload.Headers.Get("Content-Type"))
+	}
+	for i, r := range results {
+		if r.Partial {
+			t.Errorf("request %d is partial: %q", i, r.Warnings)
+		}
+	}
+}
+
+func TestParseHTTPFile_Unresolved(t *testing.T) {
+	results, err := ParseHTTPFile([]byte(usersHTTPFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[0].Request.Headers.Get("Authorization"); got != "Bearer {{token}}" {
+		t.Errorf("Authorization = %q, want the placeholder kept", got)
+	}
+	if w := results[0].Warnings; len(w) == 0 || w[0] != `line 10: variable "token" is not defined, left as written` {
+		t.Errorf("Warnings = %q, want the undefined variable first", w)
+	}
+	upload := results[2]
+	if len(upload.Request.Body) != 0 {
+		t.Errorf("upload: Body = %q, want empty", upload.Request.Body)
+	}
+	if w := upload.Warnings; len(w) == 0 || w[0] != `line 28: body file "./avatar.png" not read, body left empty` {
+		t.Errorf("upload: Warnings = %q", w)
+	}
+}
+
+func TestParseHTTPFile_NoRequests(t *testing.T) {
+	for _, in := range []string{"", "# only a comment\n###\n// and another\n"} {
+		if results, err := ParseHTTPFile([]byte(in)); err == nil {
+			t.Errorf("%q: got %d results, want an error", in, len(results))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package httptest exposes the seed corpora and mutation operators the
+// shape-http fuzz and mutation tests use, so that a program embedding the
+// parser can run the same inputs through its own wrappers:
+//
+//	for mask := 0; mask < 256; mask++ {
+//		input := httptest.ApplyMutations(httptest.BaseRequest, uint8(mask))
+//		result := http.UnmarshalLenient([]byte(input))
+//		...
+//	}
+//
+// The corpora are fixed, so tests built on them are deterministic. Each
+// call returns a fresh copy the caller may modify.
+package httptest
+
+import "strings"
+
+// RequestSeeds returns well-formed HTTP/1.x requests covering the common
+// methods, bodies framed by Content-Length and chunked encoding, and edge
+// cases such as an empty header value.
+func RequestSeeds() [][]byte {
+	return [][]byte{
+		[]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("POST /api/users HTTP/1.1\r\nHost: api.example.com\r\nContent-Type: application/json\r\nContent-Length: 15\r\n\r\n{\"name\":\"alice\"}"),
+		[]byte("PUT /resource/1 HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer token123\r\nContent-Length: 4\r\n\r\ndata"),
+		[]byte("DELETE /item/42 HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("HEAD /status HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("OPTIONS * HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		[]byte("GET /path?q=hello+world&page=2 HTTP/1.1\r\nHost: example.com\r\nAccept: text/html,application/json\r\nAccept-Encoding: gzip, deflate\r\nConnection: keep-alive\r\n\r\n"),
+		[]byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n6\r\nworld!\r\n0\r\n\r\n"),
+		// Edge cases
+		[]byte("GET / HTTP/1.0\r\n\r\n"),
+		[]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Empty:\r\n\r\n"),
+		[]byte("GET / HTTP/1.1\r\nHost: example.com\r\nCookie: a=1; b=2; c=3\r\n\r\n"),
+		[]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 0\r\n\r\n"),
+	}
+}
+
+// ResponseSeeds returns well-formed HTTP/1.x responses covering common
+// status codes, chunked and empty bodies, and an interim 100 Continue.
+func ResponseSeeds() [][]byte {
+	return [][]byte{
+		[]byte("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello"),
+		[]byte("HTTP/1.1 404 Not Found\r\nContent-Type: application/json\r\nContent-Length: 14\r\n\r\n{\"error\":\"gone\"}"),
+		[]byte("HTTP/1.1 204 No Content\r\n\r\n"),
+		[]byte("HTTP/1.1 301 Moved Permanently\r\nLocation: https://example.com/\r\nContent-Length: 0\r\n\r\n"),
+		[]byte("HTTP/1.1 500 Internal Server Error\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\noops!"),
+		[]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n6\r\nworld!\r\n0\r\n\r\n"),
+		[]byte("HTTP/1.1 200 OK\r\nSet-Cookie: session=abc123; Path=/; HttpOnly\r\nContent-Length: 2\r\n\r\nok"),
+		[]byte("HTTP/1.1 100 Continue\r\n\r\n"),
+		// Edge cases
+		[]byte("HTTP/1.0 200 OK\r\nContent-Length: 0\r\n\r\n"),
+		[]byte("HTTP/1.1 200 OK\r\n\r\n"),
+		[]byte("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nX-Custom-Header: value with spaces\r\nContent-Length: 6\r\n\r\n<html>"),
+	}
+}
+
+// CurlSeeds returns curl commands covering every flag ParseCurl handles,
+// followed by malformed and pathological commands.
+func CurlSeeds() []string {
+	return []string{
+		`curl https://api.example.com/users`,
+		`curl -X POST https://api.example.com/users -H "Content-Type: application/json" -d '{"name":"Alice"}'`,
+		`curl -X PUT https://api.example.com/users/1 -H "Authorization: Bearer tok" -d '{"active":false}'`,
+		`curl -X DELETE https://api.example.com/users/42`,
+		`curl -X PATCH https://api.example.com/users/7 -d '{"email":"new@x.com"}'`,
+		`curl -I https://api.example.com/health`,
+		`curl -u admin:secret https://api.example.com/admin`,
+		`curl -H "Authorization: Bearer eyJ.payload.sig" https://api.example.com/me`,
+		`curl -v -s -k -L --compressed https://api.example.com/`,
+		`curl --http2 https://api.example.com/h2`,
+		`curl --http3 https://api.example.com/h3`,
+		`curl -F "name=Alice" -F "role=admin" https://api.example.com/profile`,
+		`curl --data-urlencode "q=hello world" https://api.example.com/search`,
+		`curl -X POST https://api.example.com/form -d 'a=1' -d 'b=2'`,
+		`curl -o /tmp/out.json https://api.example.com/export`,
+		`curl -A "TestAgent/1.0" https://api.example.com/`,
+		"curl -X POST \\\n  https://api.example.com/users \\\n  -H \"Content-Type: application/json\" \\\n  -d '{\"name\":\"Bob\"}'",
+		`curl --data-raw '{"query":"{ me { id } }"}' https://api.example.com/graphql`,
+		`curl --data-binary '{"b":true}' https://api.example.com/raw`,
+		`https://api.example.com/no-curl-prefix`,
+		`curl http://localhost:3000/api/health`,
+		`curl http://127.0.0.1:8080/v1/data`,
+		`curl -X POST https://api.example.com/ -H "Content-Type: application/x-www-form-urlencoded" -d 'grant_type=cc&client_id=x'`,
+		// Edge / pathological inputs
+		``,
+		`curl`,
+		`curl -X`,
+		`curl -H`,
+		`curl -d`,
+		`curl -X POST`,
+		`curl -u`,
+		`curl -F`,
+		`curl --unknown-flag https://x.com/`,
+		`curl -H "bad header no colon" https://x.com/`,
+		`curl -d @/etc/passwd https://x.com/`,
+		`curl -F "file=@/tmp/big.bin" https://x.com/`,
+		`curl "https://x.com/path with spaces"`,
+		"curl -H \"unclosed",
+		`curl 'unclosed`,
+		`curl -X POST -X GET https://x.com/`, // conflicting -X flags
+		`curl ` + strings.Repeat("-H \"X-H: v\" ", 50) + `https://x.com/`,
+		`curl https://x.com/` + strings.Repeat("a", 4096),
+		`curl -d '` + strings.Repeat("x", 65536) + `' https://x.com/`,
+		"curl \x00 https://x.com/",
+		"curl \xff\xfe https://x.com/",
+		`curl --http2 --http3 https://x.com/`, // conflicting version flags
+	}
+}
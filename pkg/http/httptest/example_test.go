@@ -0,0 +1,37 @@
+package httptest_test
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-http/pkg/http"
+	"github.com/shapestone/shape-http/pkg/http/httptest"
+)
+
+// parseCaptured is a toy wrapper of the kind an embedding program writes
+// around UnmarshalLenient: it reports the method of a captured request, or
+// "" when none could be recovered.
+func parseCaptured(data []byte) string {
+	result := http.UnmarshalLenient(data)
+	if result.Request == nil {
+		return ""
+	}
+	return result.Request.Method
+}
+
+// Every combination of mutations of a baseline still yields its method.
+func Example() {
+	failures := 0
+	for mask := 0; mask < 1<<len(httptest.MutationOperators()); mask++ {
+		input := httptest.ApplyMutations(httptest.BaseRequest, uint8(mask))
+		if parseCaptured([]byte(input)) != "GET" {
+			failures++
+		}
+	}
+	for _, seed := range httptest.RequestSeeds() {
+		if parseCaptured(seed) == "" {
+			failures++
+		}
+	}
+	fmt.Println("failures:", failures)
+	// Output: failures: 0
+}
@@ -0,0 +1,169 @@
+package httptest
+
+import "strings"
+
+// The mutation operators each take a well-formed HTTP message and return a
+// damaged copy of the kind the lenient parser repairs or reports. They are
+// pure functions. An operator whose target is absent from the input, such
+// as a request-only mutation applied to a response, returns it unchanged,
+// which lets a fuzz target apply any combination to any message. Some
+// operators look for the text of BaseRequest and BaseResponse, such as the
+// path "/api/users" or the status code 200, and change nothing in other
+// messages.
+
+// BaseRequest and BaseResponse are well-formed messages that parse without
+// warnings, and that every mutation operator changes.
+const (
+	BaseRequest  = "GET /api/users HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\n\r\ntest"
+	BaseResponse = "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nHello"
+)
+
+// RemoveVersion removes the HTTP version token from a request line.
+//
+//	"GET /api/users HTTP/1.1\r\n..." → "GET /api/users\r\n..."
+func RemoveVersion(s string) string {
+	return strings.Replace(s, " HTTP/1.1\r\n", "\r\n", 1)
+}
+
+// RemovePath removes the path token from a request line, leaving the
+// version as the second token — lenient parser treats it as a missing version.
+//
+//	"GET /api/users HTTP/1.1\r\n..." → "GET HTTP/1.1\r\n..."
+func RemovePath(s string) string {
+	return strings.Replace(s, " /api/users", "", 1)
+}
+
+// InvalidStatusCode replaces the numeric status code with a non-numeric token.
+//
+//	"HTTP/1.1 200 OK\r\n..." → "HTTP/1.1 abc OK\r\n..."
+func InvalidStatusCode(s string) string {
+	return strings.Replace(s, " 200 ", " abc ", 1)
+}
+
+// RemoveStatusCode removes the status code token so that the reason phrase
+// shifts into the code position — Atoi on "OK" fails → invalid status code.
+//
+//	"HTTP/1.1 200 OK\r\n..." → "HTTP/1.1 OK\r\n..."
+func RemoveStatusCode(s string) string {
+	return strings.Replace(s, " 200", "", 1)
+}
+
+// TruncateBody cuts the body to half its length, leaving Content-Length
+// unchanged — this creates a deliberate mismatch that the lenient parser
+// must detect and flag.
+func TruncateBody(s string) string {
+	idx := strings.Index(s, "\r\n\r\n")
+	if idx == -1 {
+		if len(s) > 1 {
+			return s[:len(s)/2]
+		}
+		return s
+	}
+	body := s[idx+4:]
+	if len(body) == 0 {
+		return s
+	}
+	return s[:idx+4] + body[:len(body)/2]
+}
+
+// CorruptFirstHeader removes the colon from the first header line, turning
+// a valid "Key: Value" into an invalid "Key Value" that has no separator.
+func CorruptFirstHeader(s string) string {
+	first := strings.Index(s, "\r\n")
+	if first == -1 {
+		return s
+	}
+	rest := s[first+2:]
+	end := strings.Index(rest, "\r\n")
+	if end == -1 {
+		return s
+	}
+	corrupted := strings.Replace(rest[:end], ":", "", 1)
+	return s[:first+2] + corrupted + rest[end:]
+}
+
+// AddSpaceBeforeColon inserts a space before the colon in the first header,
+// producing "Host : example.com" — invalid per RFC 9112 but accepted leniently.
+func AddSpaceBeforeColon(s string) string {
+	first := strings.Index(s, "\r\n")
+	if first == -1 {
+		return s
+	}
+	rest := s[first+2:]
+	end := strings.Index(rest, "\r\n")
+	if end == -1 {
+		return s
+	}
+	header := rest[:end]
+	colon := strings.Index(header, ":")
+	if colon == -1 {
+		return s
+	}
+	return s[:first+2] + header[:colon] + " " + header[colon:] + rest[end:]
+}
+
+// BareLineFeed replaces all CRLF line endings with bare LF (\n only).
+func BareLineFeed(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// AddGarbageHeader inserts a line without a colon just before the blank-line
+// separator, so it lands between valid headers and the body.
+func AddGarbageHeader(s string) string {
+	idx := strings.Index(s, "\r\n\r\n")
+	if idx == -1 {
+		return s + "\r\nX-Garbage No Colon Here"
+	}
+	return s[:idx] + "\r\nX-Garbage No Colon Here" + s[idx:]
+}
+
+// RemoveBlankLine removes the blank-line separator between headers and body,
+// causing the body to be parsed as a (malformed) header line.
+func RemoveBlankLine(s string) string {
+	return strings.Replace(s, "\r\n\r\n", "\r\n", 1)
+}
+
+// DoubledCR doubles the carriage return of every CRLF, as pasting from
+// some Windows terminals does.
+//
+//	"GET /api/users HTTP/1.1\r\n..." → "GET /api/users HTTP/1.1\r\r\n..."
+func DoubledCR(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\r\r\n")
+}
+
+// StrayCR moves the carriage return of each header line ending to the
+// start of the next line, so CRLF is followed by a lone CR mid-stream.
+//
+//	"Host: example.com\r\nContent-Length: 4" → "Host: example.com\r\n\rContent-Length: 4"
+func StrayCR(s string) string {
+	head, body, _ := strings.Cut(s, "\r\n\r\n")
+	return strings.ReplaceAll(head, "\r\n", "\r\n\r") + "\r\n\r\n" + body
+}
+
+// MutationOperators returns the operators ApplyMutations selects from, in
+// bit order: RemoveVersion, RemovePath, TruncateBody, CorruptFirstHeader,
+// AddSpaceBeforeColon, BareLineFeed, AddGarbageHeader and RemoveBlankLine.
+func MutationOperators() []func(string) string {
+	return []func(string) string{
+		RemoveVersion,       // bit 0
+		RemovePath,          // bit 1
+		TruncateBody,        // bit 2
+		CorruptFirstHeader,  // bit 3
+		AddSpaceBeforeColon, // bit 4
+		BareLineFeed,        // bit 5
+		AddGarbageHeader,    // bit 6
+		RemoveBlankLine,     // bit 7
+	}
+}
+
+// ApplyMutations applies the operators of MutationOperators whose bits are
+// set in mask to s, in bit order, so the 256 masks enumerate every
+// combination.
+func ApplyMutations(s string, mask uint8) string {
+	for i, op := range MutationOperators() {
+		if mask&(1<<uint(i)) != 0 {
+			s = op(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package httptest
+
+import "testing"
+
+func TestMutationOperators_ChangeBaselines(t *testing.T) {
+	// Every operator changes at least one of the baselines.
+	for i, op := range MutationOperators() {
+		if op(BaseRequest) == BaseRequest && op(BaseResponse) == BaseResponse {
+			t.Errorf("operator %d changes neither baseline", i)
+		}
+	}
+	for _, op := range []func(string) string{InvalidStatusCode, RemoveStatusCode, DoubledCR, StrayCR} {
+		if op(BaseResponse) == BaseResponse {
+			t.Errorf("%q unchanged", op(BaseResponse))
+		}
+	}
+}
+
+func TestApplyMutations(t *testing.T) {
+	if got := ApplyMutations(BaseRequest, 0); got != BaseRequest {
+		t.Errorf("mask 0 = %q, want the input", got)
+	}
+	want := BareLineFeed(RemoveVersion(BaseRequest))
+	if got := ApplyMutations(BaseRequest, 1<<0|1<<5); got != want {
+		t.Errorf("mask 0x21 = %q, want %q", got, want)
+	}
+}
+
+func TestSeeds_AreCopies(t *testing.T) {
+	RequestSeeds()[0][0] = 'X'
+	if RequestSeeds()[0][0] != 'G' {
+		t.Error("RequestSeeds shares its backing arrays between calls")
+	}
+	if len(ResponseSeeds()) == 0 || len(CurlSeeds()) == 0 {
+		t.Error("empty corpus")
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+import "github.com/shapestone/shape-http/internal/fastparser"
+
+// ToASCIIHost converts host, optionally followed by ":port", to the form
+// its Host header takes on the wire: labels lowercased, and each label
+// with non-ASCII characters Punycode-encoded with the "xn--" prefix of
+// RFC 5890:
+//
+//	ToASCIIHost("Bücher.example:8080") == "xn--bcher-kva.example:8080"
+//
+// Labels already in ASCII, "xn--" ones included, are only lowercased, and
+// a bracketed IPv6 address is returned lowercased.
+//
+// The mapping is a documented subset of UTS #46 lookup processing,
+// nontransitional, so "ß" is kept rather than mapped to "ss": labels are
+// split at "." and at the ideographic and fullwidth full stops, characters
+// are lowercased with Unicode's simple case mapping, and a label with
+// non-ASCII characters may hold only letters, combining marks, digits and
+// '-', not at its start or end.
+// The input is not normalized, so it should be in NFC, as typed text
+// almost always is; a label evidently decomposed, such as "bu\u0308cher"
+// with a combining diaeresis, is an error rather than encoded as a
+// different name. Emoji and other symbols, which IDNA2008 disallows,
+// are an error rather than converted, like punctuation such as "?" and
+// labels over 63 bytes once encoded.
+func ToASCIIHost(host string) (string, error) {
+	return fastparser.ToASCIIHost(host)
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToASCIIHost(t *testing.T) {
+	tests := []struct{ host, want string }{
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"Bücher.Example:8080", "xn--bcher-kva.example:8080"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"faß.de", "xn--fa-hia.de"}, // nontransitional: ß is kept
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+		{"bücher。example", "xn--bcher-kva.example"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"XN--Bcher-KVA.Example", "xn--bcher-kva.example"},
+		{"API.Example.COM", "api.example.com"},
+		{"example.com.", "example.com."},
+		{"[2001:DB8::1]:443", "[2001:db8::1]:443"},
+	}
+	for _, tt := range tests {
+		got, err := ToASCIIHost(tt.host)
+		if err != nil || got != tt.want {
+			t.Errorf("ToASCIIHost(%q) = %q, %v, want %q", tt.host, got, err, tt.want)
+		}
+	}
+}
+
+func TestToASCIIHost_Errors(t *testing.T) {
+	for _, host := range []string{"😀.example", "bücher..example", "-bücher.example", "wür?.de", "\xff.example", "bu\u0308cher.de", "\u1112\u1161n.kr"} {
+		if got, err := ToASCIIHost(host); err == nil || !strings.HasPrefix(err.Error(), "http: ") {
+			t.Errorf("ToASCIIHost(%q) = %q, %v, want an error", host, got, err)
+		}
+	}
+}
+
+func TestParseCurl_IDNHost(t *testing.T) {
+	r := ParseCurl(`curl https://bücher.example/path`)
+	if r.Request == nil || r.Request.Headers.Get("Host") != "xn--bcher-kva.example" {
+		t.Fatalf("Request = %+v", r.Request)
+	}
+	if r.URL != "https://xn--bcher-kva.example/path" {
+		t.Errorf("URL = %q", r.URL)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], `"xn--bcher-kva.example"`) {
+		t.Errorf("Warnings = %q, want one noting the conversion", r.Warnings)
+	}
+
+	r = ParseCurl(`curl https://😀.example/`)
+	if r.Request == nil || r.Request.Headers.Get("Host") != "😀.example" {
+		t.Fatalf("emoji host: Request = %+v", r.Request)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "sent as is") {
+		t.Errorf("emoji host: Warnings = %q", r.Warnings)
+	}
+
+	r = ParseCurl(`curl http://Example.COM/`)
+	if r.Request.Headers.Get("Host") != "example.com" || r.URL != "http://example.com/" || len(r.Warnings) != 0 {
+		t.Errorf("mixed-case host: Host %q, URL %q, warnings %q", r.Request.Headers.Get("Host"), r.URL, r.Warnings)
+	}
+
+	r = ParseCurl(`curl https://xn--bcher-kva.example/`)
+	if r.Request.Headers.Get("Host") != "xn--bcher-kva.example" || len(r.Warnings) != 0 {
+		t.Errorf("punycode host: Host %q, warnings %q", r.Request.Headers.Get("Host"), r.Warnings)
+	}
+}
+
+func TestUnmarshalLenient_ConvertIDNHosts(t *testing.T) {
+	opts := LenientOptions{ConvertIDNHosts: true}
+	tests := []struct{ input, host string }{
+		{"GET http://bücher.example/x HTTP/1.1\r\n\r\n", "xn--bcher-kva.example"},
+		{"GET /x HTTP/1.1\r\nHost: München.de:8080\r\n\r\n", "xn--mnchen-3ya.de:8080"},
+		{"GET /x HTTP/1.1\r\nHost: Example.COM\r\n\r\n", "example.com"},
+		{"GET http://Example.COM:8080/x HTTP/1.1\r\n\r\n", "example.com:8080"},
+		{"GET /x HTTP/1.1\r\nHost: 😀.example\r\n\r\n", "😀.example"},
+	}
+	for _, tt := range tests {
+		r := UnmarshalLenientWithOptions([]byte(tt.input), opts)
+		if got := r.Request.Headers.Get("Host"); got != tt.host {
+			t.Errorf("%q: Host = %q, want %q (%q)", tt.input, got, tt.host, r.Warnings)
+		}
+	}
+
+	r := UnmarshalLenient([]byte("GET /x HTTP/1.1\r\nHost: bücher.example\r\n\r\n"))
+	if got := r.Request.Headers.Get("Host"); got != "bücher.example" {
+		t.Errorf("without ConvertIDNHosts: Host = %q", got)
+	}
+}
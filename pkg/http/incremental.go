@@ -0,0 +1,45 @@
+package http
+
+import "github.com/shapestone/shape-http/internal/fastparser"
+
+// IncrementalParser parses one message leniently while its bytes arrive,
+// as in an editor that parses on every keystroke. Each Feed returns what
+// UnmarshalLenient would for all the bytes fed so far, without redoing
+// work that new bytes cannot change: once the header section has ended,
+// the start line and headers are not parsed again, and a chunked body is
+// validated only past its last complete chunk, so feeding a body piece
+// by piece costs time linear in its size.
+//
+// Bodies in the results alias the parser's buffer, which Feed only
+// appends to; they stay valid after Reset. An IncrementalParser is not
+// safe for concurrent use.
+type IncrementalParser struct {
+	p *fastparser.IncrementalParser
+}
+
+// NewIncrementalParser returns an IncrementalParser with the defaults of
+// UnmarshalLenient.
+func NewIncrementalParser() *IncrementalParser {
+	return NewIncrementalParserWithOptions(LenientOptions{})
+}
+
+// NewIncrementalParserWithOptions returns an IncrementalParser that parses
+// as UnmarshalLenientWithOptions does with opts. BodyNoCopy is implied.
+func NewIncrementalParserWithOptions(opts LenientOptions) *IncrementalParser {
+	return &IncrementalParser{p: fastparser.NewIncrementalParser(lenientOptionsToInternal(opts))}
+}
+
+// Feed appends data to the message and returns the result of parsing
+// everything fed since the parser was created or Reset. Complete in the
+// result reports that the message has arrived in full.
+func (ip *IncrementalParser) Feed(data []byte) *ParseResult {
+	result := resultFromInternal(ip.p.Feed(data))
+	result.Raw = ip.p.Buffered()
+	return result
+}
+
+// Reset discards everything fed so far, readying the parser for a new
+// message.
+func (ip *IncrementalParser) Reset() {
+	ip.p.Reset()
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// feedAll feeds data to a new IncrementalParser n bytes at a time, checking
+// every result against UnmarshalLenient of the same prefix.
+func feedAll(t *testing.T, data []byte, n int) (*IncrementalParser, *ParseResult) {
+	t.Helper()
+	ip := NewIncrementalParser()
+	var got *ParseResult
+	for i := 0; i < len(data); i += n {
+		end := i + n
+		if end > len(data) {
+			end = len(data)
+		}
+		got = ip.Feed(data[i:end])
+		if want := UnmarshalLenient(data[:end]); !reflect.DeepEqual(got, want) {
+			t.Fatalf("after %d of %d bytes of %q:\n got %+v\nwant %+v", end, len(data), data, got, want)
+		}
+	}
+	return ip, got
+}
+
+func TestIncrementalParser_Seeds(t *testing.T) {
+	for _, n := range []int{1, 5, 16} {
+		for _, seed := range requestSeeds {
+			feedAll(t, seed, n)
+		}
+		for _, seed := range responseSeeds {
+			feedAll(t, seed, n)
+		}
+	}
+}
+
+func TestIncrementalParser_Complete(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"GET / HTTP/1.1\r\nHost: a\r\n", false},
+		{"GET / HTTP/1.1\r\nHost: a\r\n\r\n", true},
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 4\r\n\r\nab", false},
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 4\r\n\r\nabcd", true},
+		{"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nab\r\n0\r\n", false},
+		{"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nab\r\n0\r\nX-T: 1\r\n\r\n", true},
+		{"HTTP/1.1 200 OK\r\nServer: x\r\n\r\nuntil close", false},
+		{"HTTP/1.1 204 No Content\r\nServer: x\r\n\r\n", true},
+		{"HTTP/1.1 100 Continue\r\n\r\n", false},
+	}
+	for _, tt := range tests {
+		ip := NewIncrementalParser()
+		if got := ip.Feed([]byte(tt.data)); got.Complete != tt.want {
+			t.Errorf("Feed(%q).Complete = %v, want %v", tt.data, got.Complete, tt.want)
+		}
+		if got := UnmarshalLenient([]byte(tt.data)); got.Complete != tt.want {
+			t.Errorf("UnmarshalLenient(%q).Complete = %v, want %v", tt.data, got.Complete, tt.want)
+		}
+	}
+}
+
+// TestIncrementalParser_LinearWork feeds large bodies 5 bytes at a time and
+// checks the bytes scanned grow linearly, where re-parsing everything on
+// each feed would scan about len²/10.
+func TestIncrementalParser_LinearWork(t *testing.T) {
+	payload := strings.Repeat("0123456789abcdef", 1024)
+	var chunked bytes.Buffer
+	for i := 0; i < len(payload); i += 100 {
+		end := i + 100
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunked.WriteString(strconv.FormatInt(int64(end-i), 16) + "\r\n" + payload[i:end] + "\r\n")
+	}
+	chunked.WriteString("0\r\n\r\n")
+
+	for name, msg := range map[string]string{
+		"content-length": "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: " + strconv.Itoa(len(payload)) + "\r\n\r\n" + payload,
+		"chunked":        "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" + chunked.String(),
+		"until close":    "HTTP/1.1 200 OK\r\nServer: x\r\n\r\n" + payload,
+	} {
+		ip := NewIncrementalParser()
+		var got *ParseResult
+		for i := 0; i < len(msg); i += 5 {
+			end := i + 5
+			if end > len(msg) {
+				end = len(msg)
+			}
+			got = ip.Feed([]byte(msg[i:end]))
+		}
+		if want := UnmarshalLenient([]byte(msg)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: result differs from UnmarshalLenient:\n got %+v\nwant %+v", name, got.Warnings, want.Warnings)
+		}
+		if work := ip.p.Work(); work > 4*len(msg) {
+			t.Errorf("%s: scanned %d bytes for a %d-byte message, want at most %d", name, work, len(msg), 4*len(msg))
+		}
+	}
+}
+
+func TestIncrementalParser_Reset(t *testing.T) {
+	ip, first := feedAll(t, requestSeeds[1], 3)
+	if !first.Complete {
+		t.Fatalf("first message not Complete: %+v", first)
+	}
+	ip.Reset()
+	got := ip.Feed([]byte("GET /next HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	if got.Request == nil || got.Request.Path != "/next" || !got.Complete {
+		t.Errorf("after Reset: %+v", got.Request)
+	}
+	if string(first.Request.Body) != `{"name":"alice"}` {
+		t.Errorf("earlier body changed to %q", first.Request.Body)
+	}
+}
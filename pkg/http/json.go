@@ -0,0 +1,400 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// jsonMessage is the JSON form of a Request or Response. Body and RawBody
+// are pointers so that a nil body, which is omitted, stays apart from an
+// empty one.
+type jsonMessage struct {
+	Type        string       `json:"type"`
+	Method      string       `json:"method,omitempty"`
+	Path        string       `json:"path,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Scheme      string       `json:"scheme,omitempty"`
+	Status      int          `json:"status,omitempty"`
+	Reason      string       `json:"reason,omitempty"`
+	Headers     []jsonHeader `json:"headers"`
+	Body        *string      `json:"body,omitempty"`
+	Encoding    string       `json:"encoding,omitempty"`
+	RawHeaders  []jsonHeader `json:"raw_headers,omitempty"`
+	RawBody     *string      `json:"raw_body,omitempty"`
+	RawEncoding string       `json:"raw_encoding,omitempty"`
+}
+
+// jsonHeader is a header field; Encoding is "base64" for a name or value
+// that is not valid UTF-8, which are then both base64.
+type jsonHeader struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// jsonInvalidName is an InvalidHeaderName; Encoding is "base64" for a
+// name that is not valid UTF-8. Sanitized is always ASCII.
+type jsonInvalidName struct {
+	Name      string `json:"name"`
+	Sanitized string `json:"sanitized"`
+	Encoding  string `json:"encoding,omitempty"`
+}
+
+// jsonStats is Stats with JSON names; the conversion between the two
+// stops compiling when Stats gains a field this does not have.
+type jsonStats struct {
+	StartOffset        int    `json:"start_offset"`
+	StartLineBytes     int    `json:"start_line_bytes"`
+	HeaderBytes        int    `json:"header_bytes"`
+	HeaderCount        int    `json:"header_count"`
+	BodyBytes          int    `json:"body_bytes"`
+	TotalBytes         int    `json:"total_bytes"`
+	DecodedBodyBytes   int    `json:"decoded_body_bytes"`
+	LargestHeader      string `json:"largest_header,omitempty"`
+	LargestHeaderBytes int    `json:"largest_header_bytes"`
+	UsedBareLF         bool   `json:"used_bare_lf"`
+	LeadingBlankLines  int    `json:"leading_blank_lines"`
+	HeaderEndOffset    int    `json:"header_end_offset"`
+	BodyOffset         int    `json:"body_offset"`
+}
+
+type jsonResult struct {
+	jsonMessage
+	Informational []jsonMessage `json:"informational,omitempty"`
+	Requests      []jsonMessage `json:"requests,omitempty"`
+	URL           string        `json:"url,omitempty"`
+	Warnings      []string      `json:"warnings,omitempty"`
+	Partial       bool          `json:"partial"`
+	Complete      bool          `json:"complete"`
+	Stats         *jsonStats    `json:"stats,omitempty"`
+	DetectedAs    string        `json:"detected_as,omitempty"`
+	Confidence    string        `json:"confidence,omitempty"`
+	Verdict       string        `json:"verdict,omitempty"`
+
+	InvalidHeaderNames []jsonInvalidName `json:"invalid_header_names,omitempty"`
+}
+
+// MarshalJSON encodes r as one JSON object with a stable schema:
+//
+//	{
+//	  "type": "request",
+//	  "method": "POST", "path": "/api", "version": "HTTP/1.1",
+//	  "headers": [{"name": "Host", "value": "example.com"}, ...],
+//	  "body": "{\"a\":1}",
+//	  "warnings": ["..."], "partial": false, "complete": true,
+//	  "stats": {"start_offset": 0, "header_bytes": 40, ...},
+//	  "detected_as": "request", "confidence": "high"
+//	}
+//
+// "type" is "request", "response", or "none" when r holds neither. A
+// response has "status" and "reason" in place of "method", "path" and
+// "scheme". Headers are an array of name and value pairs in their order
+// in the message, duplicates included.
+//
+// A body that is valid UTF-8 is a string; any other is base64 with
+// "encoding": "base64" beside it, and a nil body is left out. A header
+// whose name or value is not UTF-8 has both in base64 with "encoding":
+// "base64" in its pair. In the other strings, such as the path, invalid
+// bytes become U+FFFD. RawHeaders and RawBody become "raw_headers" and
+// "raw_body", with "raw_encoding".
+//
+// Informational responses and the Requests of a curl glob are arrays of
+// such message objects under "informational" and "requests". "url",
+// "stats", "confidence", "verdict" and "invalid_header_names", an array
+// of name and sanitized pairs, appear when set, and "detected_as"
+// whenever r holds a message or a confidence, so a strict result keeps
+// it too. Raw, ClientHints and Observations are not encoded. Keys are
+// added, never renamed, as the result type grows.
+func (r *ParseResult) MarshalJSON() ([]byte, error) {
+	out := jsonResult{
+		jsonMessage: jsonMessage{Type: "none", Headers: []jsonHeader{}},
+		URL:         r.URL,
+		Warnings:    r.Warnings,
+		Partial:     r.Partial,
+		Complete:    r.Complete,
+	}
+	switch {
+	case r.Request != nil:
+		out.jsonMessage = requestToJSON(r.Request)
+	case r.Response != nil:
+		out.jsonMessage = responseToJSON(r.Response)
+	}
+	for _, resp := range r.Informational {
+		out.Informational = append(out.Informational, responseToJSON(resp))
+	}
+	for _, req := range r.Requests {
+		out.Requests = append(out.Requests, requestToJSON(req))
+	}
+	if r.Stats != (Stats{}) {
+		stats := jsonStats(r.Stats)
+		out.Stats = &stats
+	}
+	if r.DetectedAs != 0 || r.Request != nil || r.Response != nil || r.Confidence != 0 {
+		out.DetectedAs = r.DetectedAs.String()
+	}
+	if r.Confidence != 0 {
+		out.Confidence = r.Confidence.String()
+	}
+	if r.Verdict != 0 {
+		out.Verdict = r.Verdict.String()
+	}
+	for _, n := range r.InvalidHeaderNames {
+		j := jsonInvalidName{Name: n.Name, Sanitized: n.Sanitized}
+		if !utf8.ValidString(n.Name) {
+			j.Name, j.Encoding = base64.StdEncoding.EncodeToString([]byte(n.Name)), "base64"
+		}
+		out.InvalidHeaderNames = append(out.InvalidHeaderNames, j)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the object MarshalJSON produces into r, replacing
+// its contents. Raw, ClientHints and Observations are left zero.
+func (r *ParseResult) UnmarshalJSON(data []byte) error {
+	var in jsonResult
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	result := ParseResult{
+		URL:      in.URL,
+		Warnings: in.Warnings,
+		Partial:  in.Partial,
+		Complete: in.Complete,
+	}
+	var err error
+	switch in.Type {
+	case "request":
+		result.Request, err = requestFromJSON(in.jsonMessage)
+	case "response":
+		result.Response, err = responseFromJSON(in.jsonMessage)
+	case "none", "":
+	default:
+		err = fmt.Errorf("http: unknown message type %q", in.Type)
+	}
+	if err != nil {
+		return err
+	}
+	for _, m := range in.Informational {
+		resp, err := responseFromJSON(m)
+		if err != nil {
+			return err
+		}
+		result.Informational = append(result.Informational, resp)
+	}
+	for _, m := range in.Requests {
+		req, err := requestFromJSON(m)
+		if err != nil {
+			return err
+		}
+		result.Requests = append(result.Requests, req)
+	}
+	if in.Stats != nil {
+		result.Stats = Stats(*in.Stats)
+	}
+	if in.DetectedAs != "" {
+		if result.DetectedAs, err = messageTypeFromJSON(in.DetectedAs); err != nil {
+			return err
+		}
+	}
+	if in.Confidence != "" {
+		if result.Confidence, err = confidenceFromJSON(in.Confidence); err != nil {
+			return err
+		}
+	}
+	if in.Verdict != "" {
+		if result.Verdict, err = verdictFromJSON(in.Verdict); err != nil {
+			return err
+		}
+	}
+	for _, j := range in.InvalidHeaderNames {
+		name, err := bytesFromJSON(&j.Name, j.Encoding)
+		if err != nil {
+			return err
+		}
+		result.InvalidHeaderNames = append(result.InvalidHeaderNames, InvalidHeaderName{Name: string(name), Sanitized: j.Sanitized})
+	}
+	*r = result
+	return nil
+}
+
+func requestToJSON(req *Request) jsonMessage {
+	m := jsonMessage{
+		Type:    "request",
+		Method:  req.Method,
+		Path:    req.Path,
+		Version: req.Version,
+		Scheme:  req.Scheme,
+	}
+	m.setFields(req.Headers, req.Body, req.RawHeaders, req.RawBody)
+	return m
+}
+
+func responseToJSON(resp *Response) jsonMessage {
+	m := jsonMessage{
+		Type:    "response",
+		Version: resp.Version,
+		Status:  resp.StatusCode,
+		Reason:  resp.Reason,
+	}
+	m.setFields(resp.Headers, resp.Body, resp.RawHeaders, resp.RawBody)
+	return m
+}
+
+func (m *jsonMessage) setFields(headers Headers, body []byte, rawHeaders Headers, rawBody []byte) {
+	m.Headers = headersToJSON(headers)
+	if rawHeaders != nil {
+		m.RawHeaders = headersToJSON(rawHeaders)
+	}
+	m.Body, m.Encoding = bytesToJSON(body)
+	m.RawBody, m.RawEncoding = bytesToJSON(rawBody)
+}
+
+func requestFromJSON(m jsonMessage) (*Request, error) {
+	if m.Type != "request" {
+		return nil, fmt.Errorf("http: message of type %q where a request is expected", m.Type)
+	}
+	req := &Request{
+		Method:  m.Method,
+		Path:    m.Path,
+		Version: m.Version,
+		Scheme:  m.Scheme,
+	}
+	var err error
+	if req.Headers, req.RawHeaders, err = headersPairFromJSON(m); err != nil {
+		return nil, err
+	}
+	if req.Body, err = bytesFromJSON(m.Body, m.Encoding); err != nil {
+		return nil, err
+	}
+	if req.RawBody, err = bytesFromJSON(m.RawBody, m.RawEncoding); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func responseFromJSON(m jsonMessage) (*Response, error) {
+	if m.Type != "response" {
+		return nil, fmt.Errorf("http: message of type %q where a response is expected", m.Type)
+	}
+	resp := &Response{
+		Version:    m.Version,
+		StatusCode: m.Status,
+		Reason:     m.Reason,
+	}
+	var err error
+	if resp.Headers, resp.RawHeaders, err = headersPairFromJSON(m); err != nil {
+		return nil, err
+	}
+	if resp.Body, err = bytesFromJSON(m.Body, m.Encoding); err != nil {
+		return nil, err
+	}
+	if resp.RawBody, err = bytesFromJSON(m.RawBody, m.RawEncoding); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func headersPairFromJSON(m jsonMessage) (headers, raw Headers, err error) {
+	if headers, err = headersFromJSON(m.Headers); err != nil {
+		return nil, nil, err
+	}
+	if raw, err = headersFromJSON(m.RawHeaders); err != nil {
+		return nil, nil, err
+	}
+	return headers, raw, nil
+}
+
+func headersToJSON(headers Headers) []jsonHeader {
+	out := make([]jsonHeader, len(headers))
+	for i, h := range headers {
+		if utf8.ValidString(h.Key) && utf8.ValidString(h.Value) {
+			out[i] = jsonHeader{Name: h.Key, Value: h.Value}
+			continue
+		}
+		out[i] = jsonHeader{
+			Name:     base64.StdEncoding.EncodeToString([]byte(h.Key)),
+			Value:    base64.StdEncoding.EncodeToString([]byte(h.Value)),
+			Encoding: "base64",
+		}
+	}
+	return out
+}
+
+// headersFromJSON returns nil for no headers, as the parsers do.
+func headersFromJSON(headers []jsonHeader) (Headers, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	out := make(Headers, len(headers))
+	for i, h := range headers {
+		name, err := bytesFromJSON(&h.Name, h.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		value, err := bytesFromJSON(&h.Value, h.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Header{Key: string(name), Value: string(value)}
+	}
+	return out, nil
+}
+
+// bytesToJSON returns body as a string, base64 with encoding "base64"
+// unless it is valid UTF-8, or nil for a nil body.
+func bytesToJSON(body []byte) (*string, string) {
+	if body == nil {
+		return nil, ""
+	}
+	if utf8.Valid(body) {
+		s := string(body)
+		return &s, ""
+	}
+	s := base64.StdEncoding.EncodeToString(body)
+	return &s, "base64"
+}
+
+func bytesFromJSON(body *string, encoding string) ([]byte, error) {
+	switch {
+	case body == nil:
+		return nil, nil
+	case encoding == "":
+		return []byte(*body), nil
+	case encoding == "base64":
+		b, err := base64.StdEncoding.DecodeString(*body)
+		if err != nil {
+			return nil, fmt.Errorf("http: base64 field: %v", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("http: unknown encoding %q", encoding)
+}
+
+func messageTypeFromJSON(s string) (MessageType, error) {
+	for _, t := range []MessageType{MessageRequest, MessageResponse, MessageHTTP2Preface, MessageTLS, MessageUnknownBinary} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("http: unknown message type %q", s)
+}
+
+func confidenceFromJSON(s string) (Confidence, error) {
+	for _, c := range []Confidence{ConfidenceLow, ConfidenceMedium, ConfidenceHigh} {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("http: unknown confidence %q", s)
+}
+
+func verdictFromJSON(s string) (CurlVerdict, error) {
+	for _, v := range []CurlVerdict{CurlComplete, CurlPartial, NotCurl} {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("http: unknown curl verdict %q", s)
+}
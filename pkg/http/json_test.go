@@ -0,0 +1,128 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http/httptest"
+)
+
+// jsonRoundTrip encodes r and decodes it back.
+func jsonRoundTrip(t *testing.T, r *ParseResult) (*ParseResult, []byte) {
+	t.Helper()
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got ParseResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", data, err)
+	}
+	return &got, data
+}
+
+func TestParseResult_JSONRoundTrip(t *testing.T) {
+	var results []*ParseResult
+	for _, seed := range append(append([][]byte{}, requestSeeds...), responseSeeds...) {
+		results = append(results, UnmarshalLenient(seed))
+	}
+	for _, cmd := range httptest.CurlSeeds() {
+		results = append(results, ParseCurl(cmd))
+	}
+	results = append(results, UnmarshalLenient(nil))
+	// A strict result has DetectedAs without a Confidence.
+	for _, seed := range [][]byte{requestSeeds[0], responseSeeds[0]} {
+		r, err := ParseWithLevel(seed, Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	for _, r := range results {
+		want := *r
+		want.Raw, want.ClientHints, want.Observations = nil, ClientHints{}, FormatObservations{}
+		got, data := jsonRoundTrip(t, r)
+		if !reflect.DeepEqual(got, &want) {
+			t.Errorf("round trip of %s:\n got %+v\nwant %+v", data, got, &want)
+		}
+	}
+}
+
+func TestParseResult_JSONDetectedAs(t *testing.T) {
+	r, err := ParseWithLevel([]byte("HTTP/1.1 204 No Content\r\n\r\n"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, data := jsonRoundTrip(t, r)
+	if !strings.Contains(string(data), `"detected_as":"response"`) || strings.Contains(string(data), `"confidence"`) {
+		t.Errorf("JSON %s, want detected_as without confidence", data)
+	}
+	if got.DetectedAs != MessageResponse || got.Confidence != 0 {
+		t.Errorf("DetectedAs, Confidence = %v, %v", got.DetectedAs, got.Confidence)
+	}
+}
+
+func TestParseResult_JSONHeaders(t *testing.T) {
+	r := UnmarshalLenient([]byte("HTTP/1.1 200 OK\r\nSet-Cookie: a=1\r\nContent-Length: 0\r\nSet-Cookie: b=2\r\n\r\n"))
+	got, data := jsonRoundTrip(t, r)
+	want := `"headers":[{"name":"Set-Cookie","value":"a=1"},{"name":"Content-Length","value":"0"},{"name":"Set-Cookie","value":"b=2"}]`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("JSON %s does not contain %s", data, want)
+	}
+	if !strings.Contains(string(data), `"type":"response","version":"HTTP/1.1","status":200,"reason":"OK"`) {
+		t.Errorf("JSON %s lacks the status line fields", data)
+	}
+	if !reflect.DeepEqual(got.Response.Headers, r.Response.Headers) {
+		t.Errorf("headers = %v, want %v", got.Response.Headers, r.Response.Headers)
+	}
+}
+
+func TestParseResult_JSONBinaryBody(t *testing.T) {
+	body := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe, '\r', '\n'}
+	r := &ParseResult{Request: &Request{Method: "PUT", Path: "/img", Version: "HTTP/1.1", Body: body}, Complete: true}
+	got, data := jsonRoundTrip(t, r)
+	if !strings.Contains(string(data), `"encoding":"base64"`) {
+		t.Errorf("JSON %s does not mark the body as base64", data)
+	}
+	if !bytes.Equal(got.Request.Body, body) {
+		t.Errorf("body = %q, want %q", got.Request.Body, body)
+	}
+
+	r.Request.Body = []byte("héllo")
+	got, data = jsonRoundTrip(t, r)
+	if !strings.Contains(string(data), `"body":"héllo"`) || strings.Contains(string(data), "base64") {
+		t.Errorf("UTF-8 body encoded as %s", data)
+	}
+	if string(got.Request.Body) != "héllo" {
+		t.Errorf("body = %q", got.Request.Body)
+	}
+}
+
+func TestParseResult_UnmarshalJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"type":"trailer"}`,
+		`{"type":"request","body":"!!","encoding":"base64"}`,
+		`{"type":"request","body":"x","encoding":"gzip"}`,
+		`{"type":"none","confidence":"certain"}`,
+		`{"type":"none","informational":[{"type":"request"}]}`,
+	} {
+		var r ParseResult
+		if err := json.Unmarshal([]byte(data), &r); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", data)
+		}
+	}
+}
+
+func TestParseResult_JSONInvalidHeaderNames(t *testing.T) {
+	r := UnmarshalLenient([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-\xff: a\r\nRésumé: b\r\n\r\n"))
+	if len(r.InvalidHeaderNames) != 2 {
+		t.Fatalf("InvalidHeaderNames = %+v", r.InvalidHeaderNames)
+	}
+	got, data := jsonRoundTrip(t, r)
+	if !reflect.DeepEqual(got.InvalidHeaderNames, r.InvalidHeaderNames) {
+		t.Errorf("InvalidHeaderNames = %+v, want %+v (%s)", got.InvalidHeaderNames, r.InvalidHeaderNames, data)
+	}
+}
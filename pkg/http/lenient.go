@@ -1,6 +1,8 @@
 package http
 
 import (
+	"fmt"
+
 	"github.com/shapestone/shape-core/pkg/ast"
 	"github.com/shapestone/shape-http/internal/fastparser"
 )
@@ -14,6 +16,14 @@ import (
 // descriptions of any issues encountered. Partial is true if the message
 // was incomplete or truncated.
 //
+// # Detection
+//
+// DetectedAs and Confidence report the classification. A recognizable
+// status line or request line is High confidence; when confidence is Low
+// the input is parsed as both a request and a response, the interpretation
+// with fewer structural problems is kept (the request on a tie), and a
+// warning notes the choice.
+//
 // # Authentication
 //
 // Authentication headers are parsed as ordinary HTTP headers and are available
@@ -33,50 +43,204 @@ import (
 //     result.Request.Headers.Get("Host").
 //   - Bare LF line endings in addition to CRLF.
 //   - Missing HTTP version (defaults to "HTTP/1.1").
+//   - Interim 1xx responses (such as 100 Continue or 103 Early Hints)
+//     followed by further responses: they are collected, headers intact, in
+//     result.Informational and result.Response is the final response. A 1xx
+//     with nothing after it stays in Response with a warning.
+//   - A message pasted as a Go, Python or JavaScript string literal, such
+//     as "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n": the quotes are
+//     removed and the escapes decoded, with a warning. Input with real line
+//     breaks is only unwrapped from triple quotes or backquotes, so escapes
+//     in an ordinary message's body are left alone.
+//   - A body pasted without the blank line before it, when the header
+//     line where it starts is a JSON object or array, XML or HTML markup,
+//     or a name=value&... form line, and an earlier Content-Type announces
+//     that kind of body. The rest of the input becomes the body, a missing
+//     Content-Length is set to its length, and a "missing blank line
+//     before body, inferred body start" warning is added.
+//   - HTTP/2 and HTTP/3 messages, as browser developer tools copy them.
+//     Each connection-specific header those versions forbid (Connection,
+//     Keep-Alive, Proxy-Connection, Transfer-Encoding, Upgrade, and TE
+//     other than "trailers") is kept with a warning.
+//
+// Repeated warnings are aggregated with the defaults described on
+// LenientOptions; use UnmarshalLenientWithOptions to change them.
 func UnmarshalLenient(data []byte) *ParseResult {
-	lp := fastparser.NewLenientParser(data)
-	internal := lp.Parse()
+	return UnmarshalLenientWithOptions(data, LenientOptions{})
+}
 
-	result := &ParseResult{
-		Warnings: internal.Warnings,
-		Partial:  internal.Partial,
-	}
+// Default warning aggregation limits applied when the corresponding
+// LenientOptions field is zero.
+const (
+	DefaultMaxRepeatedWarnings = fastparser.DefaultMaxRepeatedWarnings
+	DefaultMaxWarnings         = fastparser.DefaultMaxWarnings
+)
 
-	if internal.Request != nil {
-		result.Request = &Request{
-			Method:  internal.Request.Method,
-			Path:    internal.Request.Path,
-			Version: internal.Request.Version,
-			Scheme:  internal.Request.Scheme,
-			Headers: convertHeaders(internal.Request.Headers),
-			Body:    internal.Request.Body,
-		}
-		// Check if body was incomplete
-		for _, w := range internal.Warnings {
-			if w == "message body is incomplete" {
-				result.Partial = true
-			}
-		}
-	}
+// DefaultMaxFoldedLines is the cap on continuation lines joined to one
+// header applied when LenientOptions.MaxFoldedLines is zero.
+const DefaultMaxFoldedLines = fastparser.DefaultMaxFoldedLines
 
-	if internal.Response != nil {
-		result.Response = &Response{
-			Version:    internal.Response.Version,
-			StatusCode: internal.Response.StatusCode,
-			Reason:     internal.Response.Reason,
-			Headers:    convertHeaders(internal.Response.Headers),
-			Body:       internal.Response.Body,
-		}
-		for _, w := range internal.Warnings {
-			if w == "message body is incomplete" {
-				result.Partial = true
-			}
-		}
-	}
+// LenientOptions configures UnmarshalLenientWithOptions. The zero value
+// selects the defaults used by UnmarshalLenient.
+type LenientOptions struct {
+	// MaxRepeatedWarnings is the number of warnings of the same kind kept
+	// before further occurrences are collapsed into one summary entry such as
+	// "malformed header (no colon): 9,981 further occurrences suppressed".
+	// Zero means DefaultMaxRepeatedWarnings; negative disables aggregation.
+	MaxRepeatedWarnings int
+
+	// MaxWarnings caps the number of entries in ParseResult.Warnings. When
+	// the cap is hit a final "warning limit reached" entry is appended.
+	// Zero means DefaultMaxWarnings; negative disables the cap.
+	MaxWarnings int
+
+	// MaxBodyBytes, when positive, keeps only the first MaxBodyBytes bytes
+	// of the body (after chunked decoding). A longer body is cut, Partial is
+	// set, and a warning such as "body truncated from 209715200 to 1048576
+	// bytes by MaxBodyBytes" records the original size. Zero means no limit.
+	MaxBodyBytes int
+
+	// BodyNoCopy makes the body a subslice of data rather than a copy
+	// whenever the payload is contiguous in the input: always for an
+	// unchunked body, and for a chunked one that arrived in a single chunk.
+	// Set it only if data is not modified or reused while the result is in
+	// use.
+	BodyNoCopy bool
+
+	// KeepRawBody keeps a chunked body that decoded cleanly in its wire
+	// form in RawBody, as a copy unless BodyNoCopy is set, and in full
+	// regardless of MaxBodyBytes. The lenient parser leaves Headers as
+	// received, so RawHeaders stays nil.
+	KeepRawBody bool
+
+	// JoinWrappedHeaders repairs header values hard-wrapped by an email
+	// client or chat app with no leading whitespace on the continuation,
+	// such as a Bearer token split across two lines. A line with no colon
+	// is appended to the previous header's value, even if it also reads as
+	// a bare hostname, with a "joined wrapped header value" warning, when
+	// it consists only of token and base64 characters, or when the
+	// previous line is at least 40 bytes, as long as any header line so
+	// far, and no shorter than the continuation. A line that reads as
+	// prose (three or more plain words) is never joined, nor is anything
+	// joined onto Host. The pieces are concatenated directly, or with one
+	// space after a value ending in ";" or ",". Off by default.
+	JoinWrappedHeaders bool
+
+	// MaxHeaderValueBytes, when positive, limits each header value, with
+	// obs-folded and, under JoinWrappedHeaders, wrapped lines joined, to
+	// that many bytes; other headers parse normally. MaxHeaderValueAction
+	// says what happens to a longer one: TruncateHeaderValue, the default,
+	// keeps the first MaxHeaderValueBytes bytes followed by
+	// "...[truncated N bytes]", and DropHeader removes the header. Either way a warning names the header and its size. The
+	// limit does not apply to Content-Length and Transfer-Encoding, so body
+	// framing is unaffected. Zero means no limit.
+	MaxHeaderValueBytes  int
+	MaxHeaderValueAction HeaderValueAction
+
+	// MaxFoldedLines caps the obs-fold continuation lines, lines starting
+	// with a space or tab, joined to one header value. Past the cap a
+	// warning gives the number of continuation lines and the rest are
+	// skipped, each reported as a malformed header, subject to the warning
+	// aggregation; none becomes a header of its own. This bounds the work a
+	// crafted header with 100,000 continuation lines causes. Zero means
+	// DefaultMaxFoldedLines; negative disables the cap.
+	MaxFoldedLines int
+
+	// SniffBodies adds a "Content-Type is X but the body looks like Y"
+	// warning when SniffContentType finds the body holds another type than
+	// its Content-Type declares, such as a proxy's HTML error page served
+	// as application/json. Bodies with a Content-Encoding other than
+	// identity, or a transfer coding before chunked that was not decoded,
+	// are not sniffed. Off by default.
+	SniffBodies bool
 
+	// ConvertIDNHosts converts the Host of a request, whether sent as a
+	// header or taken from an absolute-form request-target, to its ASCII
+	// form with ToASCIIHost when it holds non-ASCII characters, as in
+	// "bücher.example" to "xn--bcher-kva.example", with a warning. A host
+	// ToASCIIHost rejects is kept with a warning. An ASCII host is only
+	// lowercased, without a warning. Off by default.
+	ConvertIDNHosts bool
+
+	// DecodeTransferCodings undoes gzip, deflate and identity transfer
+	// codings applied before chunked in a complete chunked body and
+	// rewrites Transfer-Encoding to list what remains. A coding it cannot
+	// undo, or one that decodes to more than MaxBodyBytes bytes when that
+	// is positive, is left applied with a warning. Off by default, when
+	// the body is only dechunked and Transfer-Encoding is left as received.
+	DecodeTransferCodings bool
+}
+
+// HeaderValueAction is what the lenient parser does with a header value
+// over LenientOptions.MaxHeaderValueBytes.
+type HeaderValueAction int
+
+// Header value actions.
+const (
+	TruncateHeaderValue HeaderValueAction = HeaderValueAction(fastparser.TruncateHeaderValue) // keep the first MaxHeaderValueBytes bytes
+	DropHeader          HeaderValueAction = HeaderValueAction(fastparser.DropHeader)          // drop the header
+)
+
+// UnmarshalLenientWithOptions is UnmarshalLenient with explicit options.
+func UnmarshalLenientWithOptions(data []byte, opts LenientOptions) *ParseResult {
+	result, _ := unmarshalLenient(data, opts)
 	return result
 }
 
+// unmarshalLenient is UnmarshalLenientWithOptions that also counts the
+// warnings by kind.
+func unmarshalLenient(data []byte, opts LenientOptions) (*ParseResult, map[string]int) {
+	m := currentMetrics()
+	start := m.now()
+	lp := fastparser.NewLenientParserWithOptions(data, lenientOptionsToInternal(opts))
+	result := resultFromInternal(lp.Parse())
+	result.Raw = data
+	counts := lp.WarningCounts()
+	m.lenient(start, len(data), result, counts)
+	return result, counts
+}
+
+func lenientOptionsToInternal(opts LenientOptions) fastparser.LenientOptions {
+	return fastparser.LenientOptions{
+		MaxRepeatedWarnings: opts.MaxRepeatedWarnings,
+		MaxWarnings:         opts.MaxWarnings,
+		MaxBodyBytes:        opts.MaxBodyBytes,
+		BodyNoCopy:          opts.BodyNoCopy,
+		KeepRawBody:         opts.KeepRawBody,
+		JoinWrappedHeaders:  opts.JoinWrappedHeaders,
+
+		MaxHeaderValueBytes:   opts.MaxHeaderValueBytes,
+		MaxHeaderValueAction:  fastparser.HeaderValueAction(opts.MaxHeaderValueAction),
+		MaxFoldedLines:        opts.MaxFoldedLines,
+		SniffBodies:           opts.SniffBodies,
+		DecodeTransferCodings: opts.DecodeTransferCodings,
+		ConvertIDNHosts:       opts.ConvertIDNHosts,
+	}
+}
+
+// CanonicalizeMessage parses data leniently and marshals the message it
+// finds back out in canonical form: CRLF line endings, headers unfolded
+// and without stray whitespace. The returned observations describe what
+// differed in the input. It fails if no request or response could be
+// extracted or the message cannot be marshaled.
+func CanonicalizeMessage(data []byte) ([]byte, *FormatObservations, error) {
+	result := UnmarshalLenient(data)
+	var out []byte
+	var err error
+	switch {
+	case result.Request != nil:
+		out, err = Marshal(result.Request)
+	case result.Response != nil:
+		out, err = Marshal(result.Response)
+	default:
+		return nil, &result.Observations, fmt.Errorf("http: no message found to canonicalize")
+	}
+	if err != nil {
+		return nil, &result.Observations, err
+	}
+	return out, &result.Observations, nil
+}
+
 // ParseLenient is the AST path equivalent of UnmarshalLenient.
 // It returns an AST node (ObjectNode), a list of warnings, and an error.
 // The error is only non-nil for truly unrecoverable situations (e.g., nil input
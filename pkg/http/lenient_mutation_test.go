@@ -3,150 +3,10 @@ package http
 import (
 	"strings"
 	"testing"
-)
 
-// Canonical baselines — both are well-formed and parse with zero warnings.
-const (
-	baseGoodRequest  = "GET /api/users HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\n\r\ntest"
-	baseGoodResponse = "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nHello"
+	"github.com/shapestone/shape-http/pkg/http/httptest"
 )
 
-// ── Mutation operators ─────────────────────────────────────────────────────
-//
-// Each operator takes a well-formed HTTP message string and returns a mutated
-// version. Operators are pure functions. An operator that targets content
-// absent in the input (e.g. a request-only mutation on a response) is a no-op,
-// which is intentional — the fuzz layer relies on this.
-
-// mutRemoveVersion removes the HTTP version token from a request line.
-//
-//	"GET /api/users HTTP/1.1\r\n..." → "GET /api/users\r\n..."
-func mutRemoveVersion(s string) string {
-	return strings.Replace(s, " HTTP/1.1\r\n", "\r\n", 1)
-}
-
-// mutRemovePath removes the path token from a request line, leaving the
-// version as the second token — lenient parser treats it as a missing version.
-//
-//	"GET /api/users HTTP/1.1\r\n..." → "GET HTTP/1.1\r\n..."
-func mutRemovePath(s string) string {
-	return strings.Replace(s, " /api/users", "", 1)
-}
-
-// mutInvalidStatusCode replaces the numeric status code with a non-numeric token.
-//
-//	"HTTP/1.1 200 OK\r\n..." → "HTTP/1.1 abc OK\r\n..."
-func mutInvalidStatusCode(s string) string {
-	return strings.Replace(s, " 200 ", " abc ", 1)
-}
-
-// mutRemoveStatusCode removes the status code token so that the reason phrase
-// shifts into the code position — Atoi on "OK" fails → invalid status code.
-//
-//	"HTTP/1.1 200 OK\r\n..." → "HTTP/1.1 OK\r\n..."
-func mutRemoveStatusCode(s string) string {
-	return strings.Replace(s, " 200", "", 1)
-}
-
-// mutTruncateBody cuts the body to half its length, leaving Content-Length
-// unchanged — this creates a deliberate mismatch that the lenient parser
-// must detect and flag.
-func mutTruncateBody(s string) string {
-	idx := strings.Index(s, "\r\n\r\n")
-	if idx == -1 {
-		if len(s) > 1 {
-			return s[:len(s)/2]
-		}
-		return s
-	}
-	body := s[idx+4:]
-	if len(body) == 0 {
-		return s
-	}
-	return s[:idx+4] + body[:len(body)/2]
-}
-
-// mutCorruptFirstHeader removes the colon from the first header line, turning
-// a valid "Key: Value" into an invalid "Key Value" that has no separator.
-func mutCorruptFirstHeader(s string) string {
-	first := strings.Index(s, "\r\n")
-	if first == -1 {
-		return s
-	}
-	rest := s[first+2:]
-	end := strings.Index(rest, "\r\n")
-	if end == -1 {
-		return s
-	}
-	corrupted := strings.Replace(rest[:end], ":", "", 1)
-	return s[:first+2] + corrupted + rest[end:]
-}
-
-// mutAddSpaceBeforeColon inserts a space before the colon in the first header,
-// producing "Host : example.com" — invalid per RFC 9112 but accepted leniently.
-func mutAddSpaceBeforeColon(s string) string {
-	first := strings.Index(s, "\r\n")
-	if first == -1 {
-		return s
-	}
-	rest := s[first+2:]
-	end := strings.Index(rest, "\r\n")
-	if end == -1 {
-		return s
-	}
-	header := rest[:end]
-	colon := strings.Index(header, ":")
-	if colon == -1 {
-		return s
-	}
-	return s[:first+2] + header[:colon] + " " + header[colon:] + rest[end:]
-}
-
-// mutBareLineFeed replaces all CRLF line endings with bare LF (\n only).
-func mutBareLineFeed(s string) string {
-	return strings.ReplaceAll(s, "\r\n", "\n")
-}
-
-// mutAddGarbageHeader inserts a line without a colon just before the blank-line
-// separator, so it lands between valid headers and the body.
-func mutAddGarbageHeader(s string) string {
-	idx := strings.Index(s, "\r\n\r\n")
-	if idx == -1 {
-		return s + "\r\nX-Garbage No Colon Here"
-	}
-	return s[:idx] + "\r\nX-Garbage No Colon Here" + s[idx:]
-}
-
-// mutRemoveBlankLine removes the blank-line separator between headers and body,
-// causing the body to be parsed as a (malformed) header line.
-func mutRemoveBlankLine(s string) string {
-	return strings.Replace(s, "\r\n\r\n", "\r\n", 1)
-}
-
-// ── Shared bitmask dispatch (used by the fuzz target) ─────────────────────
-
-var mutationOps = []func(string) string{
-	mutRemoveVersion,       // bit 0
-	mutRemovePath,          // bit 1
-	mutTruncateBody,        // bit 2
-	mutCorruptFirstHeader,  // bit 3
-	mutAddSpaceBeforeColon, // bit 4
-	mutBareLineFeed,        // bit 5
-	mutAddGarbageHeader,    // bit 6
-	mutRemoveBlankLine,     // bit 7
-}
-
-// applyMutations applies the operators selected by the bitmask in order.
-// Operators that don't match any content in s are no-ops.
-func applyMutations(s string, mask uint8) string {
-	for i, op := range mutationOps {
-		if mask&(1<<uint(i)) != 0 {
-			s = op(s)
-		}
-	}
-	return s
-}
-
 // ── Test helper ───────────────────────────────────────────────────────────
 
 func hasWarningSubstr(result *ParseResult, substr string) bool {
@@ -173,7 +33,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		// ── Missing parts ─────────────────────────────────────────────────
 		{
 			name:        "remove version",
-			apply:       mutRemoveVersion,
+			apply:       httptest.RemoveVersion,
 			wantWarning: "missing HTTP version",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -192,7 +52,7 @@ func TestLenientMutations_Request(t *testing.T) {
 			// two tokens remain — lenient treats the second as path and warns
 			// about the missing version.
 			name:        "remove path",
-			apply:       mutRemovePath,
+			apply:       httptest.RemovePath,
 			wantWarning: "missing HTTP version",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -205,7 +65,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		},
 		{
 			name:        "truncate body",
-			apply:       mutTruncateBody,
+			apply:       httptest.TruncateBody,
 			wantWarning: "Content-Length declared",
 			wantPartial: true,
 			check: func(t *testing.T, r *ParseResult) {
@@ -221,7 +81,7 @@ func TestLenientMutations_Request(t *testing.T) {
 			// Without the blank-line separator the body is parsed as a header;
 			// "test" has no colon → malformed header warning.
 			name:        "remove blank line separator",
-			apply:       mutRemoveBlankLine,
+			apply:       httptest.RemoveBlankLine,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -233,7 +93,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		// ── Modified parts ────────────────────────────────────────────────
 		{
 			name:        "corrupt first header (remove colon)",
-			apply:       mutCorruptFirstHeader,
+			apply:       httptest.CorruptFirstHeader,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -247,7 +107,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		},
 		{
 			name:        "whitespace before colon in first header",
-			apply:       mutAddSpaceBeforeColon,
+			apply:       httptest.AddSpaceBeforeColon,
 			wantWarning: "whitespace before colon",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -263,7 +123,7 @@ func TestLenientMutations_Request(t *testing.T) {
 			// Bare LF is accepted without any warning — it is the most common
 			// real-world deviation from strict RFC 9112 line endings.
 			name:  "bare LF line endings",
-			apply: mutBareLineFeed,
+			apply: httptest.BareLineFeed,
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
 					t.Fatal("expected request")
@@ -280,7 +140,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		// ── Added invalid parts ───────────────────────────────────────────
 		{
 			name:        "garbage header (no colon)",
-			apply:       mutAddGarbageHeader,
+			apply:       httptest.AddGarbageHeader,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -297,7 +157,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		{
 			name: "remove version + garbage header",
 			apply: func(s string) string {
-				return mutAddGarbageHeader(mutRemoveVersion(s))
+				return httptest.AddGarbageHeader(httptest.RemoveVersion(s))
 			},
 			wantWarning: "missing HTTP version",
 			check: func(t *testing.T, r *ParseResult) {
@@ -315,7 +175,7 @@ func TestLenientMutations_Request(t *testing.T) {
 		{
 			name: "corrupt header + truncate body",
 			apply: func(s string) string {
-				return mutTruncateBody(mutCorruptFirstHeader(s))
+				return httptest.TruncateBody(httptest.CorruptFirstHeader(s))
 			},
 			wantWarning: "malformed header",
 			wantPartial: true,
@@ -327,11 +187,11 @@ func TestLenientMutations_Request(t *testing.T) {
 		},
 		{
 			// Apply bare LF last so the blank-line separator is still findable
-			// when mutAddGarbageHeader and mutTruncateBody run, preserving the
+			// when httptest.AddGarbageHeader and httptest.TruncateBody run, preserving the
 			// Content-Length mismatch that drives Partial=true.
 			name: "bare LF + garbage header + truncate body",
 			apply: func(s string) string {
-				return mutBareLineFeed(mutTruncateBody(mutAddGarbageHeader(s)))
+				return httptest.BareLineFeed(httptest.TruncateBody(httptest.AddGarbageHeader(s)))
 			},
 			wantWarning: "malformed header",
 			wantPartial: true,
@@ -346,7 +206,7 @@ func TestLenientMutations_Request(t *testing.T) {
 			// return something coherent rather than panic or return nil.
 			name: "all mutations combined",
 			apply: func(s string) string {
-				return applyMutations(s, 0xFF)
+				return httptest.ApplyMutations(s, 0xFF)
 			},
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Request == nil {
@@ -358,7 +218,7 @@ func TestLenientMutations_Request(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			input := tt.apply(baseGoodRequest)
+			input := tt.apply(httptest.BaseRequest)
 			result := UnmarshalLenient([]byte(input))
 
 			if result == nil {
@@ -392,7 +252,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		// ── Missing parts ─────────────────────────────────────────────────
 		{
 			name:        "truncate body",
-			apply:       mutTruncateBody,
+			apply:       httptest.TruncateBody,
 			wantWarning: "Content-Length declared",
 			wantPartial: true,
 			check: func(t *testing.T, r *ParseResult) {
@@ -406,7 +266,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		},
 		{
 			name:        "remove blank line separator",
-			apply:       mutRemoveBlankLine,
+			apply:       httptest.RemoveBlankLine,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -418,7 +278,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		// ── Modified parts ────────────────────────────────────────────────
 		{
 			name:        "invalid status code (non-numeric token)",
-			apply:       mutInvalidStatusCode,
+			apply:       httptest.InvalidStatusCode,
 			wantWarning: "invalid status code",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -437,7 +297,7 @@ func TestLenientMutations_Response(t *testing.T) {
 			// Removing the code shifts "OK" into the code position;
 			// Atoi("OK") fails → invalid status code warning.
 			name:        "remove status code",
-			apply:       mutRemoveStatusCode,
+			apply:       httptest.RemoveStatusCode,
 			wantWarning: "invalid status code",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -450,7 +310,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		},
 		{
 			name:        "corrupt first header (remove colon)",
-			apply:       mutCorruptFirstHeader,
+			apply:       httptest.CorruptFirstHeader,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -464,7 +324,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		},
 		{
 			name:        "whitespace before colon in first header",
-			apply:       mutAddSpaceBeforeColon,
+			apply:       httptest.AddSpaceBeforeColon,
 			wantWarning: "whitespace before colon",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -477,7 +337,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		},
 		{
 			name:  "bare LF line endings",
-			apply: mutBareLineFeed,
+			apply: httptest.BareLineFeed,
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
 					t.Fatal("expected response")
@@ -491,7 +351,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		// ── Added invalid parts ───────────────────────────────────────────
 		{
 			name:        "garbage header (no colon)",
-			apply:       mutAddGarbageHeader,
+			apply:       httptest.AddGarbageHeader,
 			wantWarning: "malformed header",
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -507,7 +367,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		{
 			name: "invalid status code + garbage header",
 			apply: func(s string) string {
-				return mutAddGarbageHeader(mutInvalidStatusCode(s))
+				return httptest.AddGarbageHeader(httptest.InvalidStatusCode(s))
 			},
 			wantWarning: "invalid status code",
 			check: func(t *testing.T, r *ParseResult) {
@@ -525,7 +385,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		{
 			name: "corrupt header + truncate body",
 			apply: func(s string) string {
-				return mutTruncateBody(mutCorruptFirstHeader(s))
+				return httptest.TruncateBody(httptest.CorruptFirstHeader(s))
 			},
 			wantWarning: "malformed header",
 			wantPartial: true,
@@ -538,7 +398,7 @@ func TestLenientMutations_Response(t *testing.T) {
 		{
 			name: "all mutations combined",
 			apply: func(s string) string {
-				return applyMutations(s, 0xFF)
+				return httptest.ApplyMutations(s, 0xFF)
 			},
 			check: func(t *testing.T, r *ParseResult) {
 				if r.Response == nil {
@@ -550,7 +410,7 @@ func TestLenientMutations_Response(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			input := tt.apply(baseGoodResponse)
+			input := tt.apply(httptest.BaseResponse)
 			result := UnmarshalLenient([]byte(input))
 
 			if result == nil {
@@ -569,6 +429,80 @@ func TestLenientMutations_Response(t *testing.T) {
 	}
 }
 
+// ── Doubled carriage returns ──────────────────────────────────────────────
+
+// TestLenientMutations_DoubledCR checks that line-ending artifacts from
+// copy-paste parse exactly like the clean baselines, with one warning.
+func TestLenientMutations_DoubledCR(t *testing.T) {
+	for _, base := range []string{httptest.BaseRequest, httptest.BaseResponse} {
+		want := UnmarshalLenient([]byte(base))
+		for name, mut := range map[string]func(string) string{"doubled": httptest.DoubledCR, "stray": httptest.StrayCR} {
+			got := UnmarshalLenient([]byte(mut(base)))
+			if got.Partial || len(got.Warnings) != 1 || got.Warnings[0] != "normalized doubled carriage returns" {
+				t.Errorf("%s %.8q: Partial = %v, warnings = %q", name, base, got.Partial, got.Warnings)
+			}
+			switch {
+			case want.Request != nil:
+				if got.Request == nil || !got.Request.Equal(want.Request, EqualOptions{HeaderOrder: true, CaseSensitiveNames: true}) {
+					t.Errorf("%s: Request = %+v, want %+v", name, got.Request, want.Request)
+				}
+			case got.Response == nil || !got.Response.Equal(want.Response, EqualOptions{HeaderOrder: true, CaseSensitiveNames: true}):
+				t.Errorf("%s: Response = %+v, want %+v", name, got.Response, want.Response)
+			}
+		}
+	}
+
+	// The body is not rewritten.
+	in := "POST /notes HTTP/1.1\r\r\nHost: example.com\r\r\nContent-Length: 6\r\r\n\r\r\na\r\r\nb\n"
+	r := UnmarshalLenient([]byte(in))
+	if r.Request == nil || string(r.Request.Body) != "a\r\r\nb\n" || r.Request.Headers.Get("Host") != "example.com" {
+		t.Errorf("Request = %+v", r.Request)
+	}
+}
+
+// ── Format observations ───────────────────────────────────────────────────
+
+func TestLenientMutations_Observations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  FormatObservations
+	}{
+		{"baseline request", httptest.BaseRequest, FormatObservations{LineEnding: LineEndingCRLF, HeaderCase: HeaderCaseCanonical}},
+		{"bare LF", httptest.BareLineFeed(httptest.BaseResponse), FormatObservations{LineEnding: LineEndingLF, HeaderCase: HeaderCaseCanonical}},
+		{"space before colon", httptest.AddSpaceBeforeColon(httptest.BaseRequest), FormatObservations{LineEnding: LineEndingCRLF, WhitespaceBeforeColonCount: 1, HeaderCase: HeaderCaseCanonical}},
+		{"mixed endings", strings.Replace(httptest.BaseRequest, "\r\n", "\n", 1), FormatObservations{LineEnding: LineEndingLF, MixedLineEndings: true, HeaderCase: HeaderCaseCanonical}},
+		{"obs-fold", "GET / HTTP/1.1\r\nHost: example.com\r\nX-Long: a\r\n b\r\n\tc\r\n\r\n", FormatObservations{LineEnding: LineEndingCRLF, ObsFoldCount: 2, HeaderCase: HeaderCaseCanonical}},
+		{"stray blank line", "GET / HTTP/1.1\r\n\r\nhost: example.com\r\naccept: */*\r\n\r\n", FormatObservations{LineEnding: LineEndingCRLF, StrayBlankLines: 1, HeaderCase: HeaderCaseLower}},
+		{"BOM and indentation", "\xef\xbb\xbf  GET / HTTP/1.1\n  HOST: example.com\n  TE: trailers\n\n", FormatObservations{LineEnding: LineEndingLF, HadBOM: true, IndentBytes: 2, HeaderCase: HeaderCaseUpper}},
+		// The Host made from the bare hostname and the inferred
+		// Content-Length are the parser's names, not the client's.
+		{"bare hostname", "POST / HTTP/1.1\r\nexample.com\r\naccept: */*\r\ncontent-type: application/json\r\n{\"a\":1}", FormatObservations{LineEnding: LineEndingCRLF, HeaderCase: HeaderCaseLower}},
+		{"mixed case", "HTTP/1.1 204 No Content\r\ncontent-type: text/plain\r\nETag: \"1\"\r\n\r\n", FormatObservations{LineEnding: LineEndingCRLF, HeaderCase: HeaderCaseMixed}},
+	}
+	for _, tt := range tests {
+		if got := UnmarshalLenient([]byte(tt.input)).Observations; got != tt.want {
+			t.Errorf("%s: Observations = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizeMessage(t *testing.T) {
+	out, obs, err := CanonicalizeMessage([]byte(httptest.BareLineFeed(httptest.AddSpaceBeforeColon(httptest.BaseRequest))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != httptest.BaseRequest {
+		t.Errorf("CanonicalizeMessage = %q, want %q", out, httptest.BaseRequest)
+	}
+	if obs.LineEnding != LineEndingLF || obs.WhitespaceBeforeColonCount != 1 {
+		t.Errorf("observations = %+v", obs)
+	}
+	if _, _, err := CanonicalizeMessage(nil); err == nil {
+		t.Error("empty input: want error")
+	}
+}
+
 // ── Fuzz: randomized mutation combinations ────────────────────────────────
 //
 // FuzzLenientMutations applies random combinations of mutation operators to
@@ -582,28 +516,28 @@ func TestLenientMutations_Response(t *testing.T) {
 // might not think to test.
 func FuzzLenientMutations(f *testing.F) {
 	// Seed: baselines with no mutations applied.
-	f.Add(baseGoodRequest, uint8(0))
-	f.Add(baseGoodResponse, uint8(0))
+	f.Add(httptest.BaseRequest, uint8(0))
+	f.Add(httptest.BaseResponse, uint8(0))
 
 	// Seed: each single mutation on each baseline.
 	for i := 0; i < 8; i++ {
 		mask := uint8(1 << uint(i))
-		f.Add(baseGoodRequest, mask)
-		f.Add(baseGoodResponse, mask)
+		f.Add(httptest.BaseRequest, mask)
+		f.Add(httptest.BaseResponse, mask)
 	}
 
 	// Seed: all mutations combined.
-	f.Add(baseGoodRequest, uint8(0xFF))
-	f.Add(baseGoodResponse, uint8(0xFF))
+	f.Add(httptest.BaseRequest, uint8(0xFF))
+	f.Add(httptest.BaseResponse, uint8(0xFF))
 
 	// Seed: a few interesting hand-picked combinations.
-	f.Add(baseGoodRequest, uint8(0b00000101))  // remove version + truncate body
-	f.Add(baseGoodRequest, uint8(0b10001000))  // corrupt header + remove blank line
-	f.Add(baseGoodResponse, uint8(0b01000100)) // truncate body + garbage header
-	f.Add(baseGoodResponse, uint8(0b00010010)) // remove status code + corrupt header
+	f.Add(httptest.BaseRequest, uint8(0b00000101))  // remove version + truncate body
+	f.Add(httptest.BaseRequest, uint8(0b10001000))  // corrupt header + remove blank line
+	f.Add(httptest.BaseResponse, uint8(0b01000100)) // truncate body + garbage header
+	f.Add(httptest.BaseResponse, uint8(0b00010010)) // remove status code + corrupt header
 
 	f.Fuzz(func(t *testing.T, base string, mutationMask uint8) {
-		input := applyMutations(base, mutationMask)
+		input := httptest.ApplyMutations(base, mutationMask)
 		result := UnmarshalLenient([]byte(input))
 
 		// Invariant 1: never returns nil — the lenient API must always
@@ -1,8 +1,13 @@
 package http
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/shapestone/shape-http/pkg/http/httptest"
 )
 
 func TestUnmarshalLenient_ValidRequest(t *testing.T) {
@@ -465,3 +470,432 @@ func TestUnmarshalLenient_Scheme_BareAuthority_NoScheme(t *testing.T) {
 		}
 	}
 }
+
+// TestUnmarshalLenientWithOptions_WarningLimits verifies that the public
+// options reach the lenient parser and that defaults apply to UnmarshalLenient.
+func TestUnmarshalLenientWithOptions_WarningLimits(t *testing.T) {
+	data := []byte("GET / HTTP/1.1\r\n" + strings.Repeat("no colon here\r\n", 5000))
+
+	result := UnmarshalLenient(data)
+	if len(result.Warnings) != DefaultMaxRepeatedWarnings+1 {
+		t.Errorf("default: len(Warnings) = %d, want %d", len(result.Warnings), DefaultMaxRepeatedWarnings+1)
+	}
+
+	result = UnmarshalLenientWithOptions(data, LenientOptions{MaxRepeatedWarnings: -1, MaxWarnings: 100})
+	if len(result.Warnings) != 101 {
+		t.Errorf("capped: len(Warnings) = %d, want 101", len(result.Warnings))
+	}
+	if last := result.Warnings[len(result.Warnings)-1]; !strings.HasPrefix(last, "warning limit reached") {
+		t.Errorf("last warning = %q, want warning limit entry", last)
+	}
+}
+
+// TestUnmarshalLenientWithOptions_Body verifies that the body options reach
+// the lenient parser.
+func TestUnmarshalLenientWithOptions_Body(t *testing.T) {
+	data := []byte("POST / HTTP/1.1\r\nContent-Length: 10\r\n\r\n0123456789")
+
+	result := UnmarshalLenientWithOptions(data, LenientOptions{MaxBodyBytes: 4, BodyNoCopy: true})
+	if string(result.Request.Body) != "0123" || !result.Partial {
+		t.Errorf("Body = %q Partial = %v, want \"0123\" and partial", result.Request.Body, result.Partial)
+	}
+	want := "body truncated from 10 to 4 bytes by MaxBodyBytes"
+	if len(result.Warnings) != 1 || result.Warnings[0] != want {
+		t.Errorf("Warnings = %q, want [%q]", result.Warnings, want)
+	}
+	if &result.Request.Body[0] != &data[len(data)-10] {
+		t.Error("BodyNoCopy: body does not alias the input")
+	}
+}
+
+// bigCookieRequest carries a 1 MB Cookie between two ordinary headers and a
+// Content-Length body.
+var bigCookieRequest = "POST /login HTTP/1.1\r\nHost: example.com\r\nCookie: " +
+	strings.Repeat("c", 1<<20) + "\r\nContent-Length: 5\r\nAccept: */*\r\n\r\nhello"
+
+func TestUnmarshalLenientWithOptions_MaxHeaderValueBytes(t *testing.T) {
+	truncated := strings.Repeat("c", 64) + "...[truncated " + strconv.Itoa(1<<20-64) + " bytes]"
+	tests := []struct {
+		action  HeaderValueAction
+		headers Headers
+		warning string
+	}{
+		{TruncateHeaderValue,
+			Headers{{Key: "Host", Value: "example.com"}, {Key: "Cookie", Value: truncated}, {Key: "Content-Length", Value: "5"}, {Key: "Accept", Value: "*/*"}},
+			`header "Cookie" value is 1048576 bytes, over the limit of 64; truncated`},
+		{DropHeader,
+			Headers{{Key: "Host", Value: "example.com"}, {Key: "Content-Length", Value: "5"}, {Key: "Accept", Value: "*/*"}},
+			`header "Cookie" value is 1048576 bytes, over the limit of 64; dropped`},
+	}
+	for _, tt := range tests {
+		result := UnmarshalLenientWithOptions([]byte(bigCookieRequest), LenientOptions{MaxHeaderValueBytes: 64, MaxHeaderValueAction: tt.action})
+		req := result.Request
+		if req == nil {
+			t.Fatalf("action %d: Request is nil", tt.action)
+		}
+		if !reflect.DeepEqual(req.Headers, tt.headers) {
+			t.Errorf("action %d: Headers = %q, want %q", tt.action, req.Headers, tt.headers)
+		}
+		if string(req.Body) != "hello" || result.Partial {
+			t.Errorf("action %d: Body = %q Partial = %v, want \"hello\", complete", tt.action, req.Body, result.Partial)
+		}
+		if len(result.Warnings) != 1 || !strings.HasSuffix(result.Warnings[0], tt.warning) {
+			t.Errorf("action %d: Warnings = %q, want one ending %q", tt.action, result.Warnings, tt.warning)
+		}
+		if result.Stats.LargestHeader != "Cookie" || result.Stats.LargestHeaderBytes != 1<<20 {
+			t.Errorf("action %d: largest header %q, %d bytes, want Cookie, %d", tt.action,
+				result.Stats.LargestHeader, result.Stats.LargestHeaderBytes, 1<<20)
+		}
+	}
+
+	// Framing headers are exempt from the limit.
+	data := "POST / HTTP/1.1\r\nContent-Length: 0000000005\r\n\r\nhello"
+	result := UnmarshalLenientWithOptions([]byte(data), LenientOptions{MaxHeaderValueBytes: 4})
+	if string(result.Request.Body) != "hello" || len(result.Warnings) != 0 {
+		t.Errorf("Content-Length: Body = %q Warnings = %q, want \"hello\" and none", result.Request.Body, result.Warnings)
+	}
+}
+
+func TestUnmarshalLenientWithOptions_MaxFoldedLines(t *testing.T) {
+	data := "GET / HTTP/1.1\r\nHost: example.com\r\nX-Fold: a\r\n" + strings.Repeat(" b\r\n", 50000) + "Accept: */*\r\n\r\n"
+	done := make(chan *ParseResult, 1)
+	go func() { done <- UnmarshalLenient([]byte(data)) }()
+	var result *ParseResult
+	select {
+	case result = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("UnmarshalLenient did not finish in 5s")
+	}
+	want := `line 3: header "X-Fold" has 50000 continuation lines, over the limit of 100; the rest are skipped`
+	if len(result.Warnings) == 0 || result.Warnings[0] != want {
+		t.Fatalf("Warnings = %.200q, want %q first", result.Warnings, want)
+	}
+	if n := len(result.Warnings); n > DefaultMaxRepeatedWarnings+2 {
+		t.Errorf("%d warnings, want the malformed lines aggregated", n)
+	}
+	if got := result.Request.Headers.Get("X-Fold"); got != "a"+strings.Repeat(" b", DefaultMaxFoldedLines) {
+		t.Errorf("X-Fold is %d bytes, want %d continuation lines joined", len(got), DefaultMaxFoldedLines)
+	}
+	if result.Request.Headers.Get("Accept") != "*/*" {
+		t.Errorf("Headers = %.200q, want Accept after the fold", result.Request.Headers)
+	}
+
+	// A continuation line past the cap never becomes a header, whatever it
+	// holds.
+	injected := "GET / HTTP/1.1\r\nHost: example.com\r\nX-Long: v\r\n" + strings.Repeat(" b\r\n", DefaultMaxFoldedLines) +
+		" X-Evil: injected\r\n Authorization: Bearer forged\r\n\r\n"
+	result = UnmarshalLenient([]byte(injected))
+	if h := result.Request.Headers; len(h) != 2 || h.Has("X-Evil") || h.Has("Authorization") {
+		t.Errorf("Headers = %.200q, want only Host and X-Long", h)
+	}
+	for _, w := range result.Warnings {
+		if strings.Contains(w, "forged") {
+			t.Errorf("warning %q echoes the credential", w)
+		}
+	}
+	if n := len(result.Warnings); n != 3 {
+		t.Errorf("Warnings = %q, want the long fold and two skipped lines", result.Warnings)
+	}
+
+	// An ordinary fold is unaffected, and a negative cap joins everything.
+	result = UnmarshalLenient([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Fold: a\r\n b\r\n\tc\r\n\r\n"))
+	if got := result.Request.Headers.Get("X-Fold"); got != "a b c" || len(result.Warnings) != 0 {
+		t.Errorf("3-line fold: X-Fold = %q, Warnings = %q", got, result.Warnings)
+	}
+	result = UnmarshalLenientWithOptions([]byte(data), LenientOptions{MaxFoldedLines: -1})
+	if got := result.Request.Headers.Get("X-Fold"); len(got) != 1+2*50000 || len(result.Warnings) != 0 {
+		t.Errorf("no cap: X-Fold is %d bytes, Warnings = %.200q", len(got), result.Warnings)
+	}
+}
+
+func TestUnmarshalLenientWithOptions_SniffBodies(t *testing.T) {
+	data := "HTTP/1.1 502 Bad Gateway\r\nContent-Type: application/json\r\nContent-Length: 24\r\n\r\n<html><body>502</body>\r\n"
+	result := UnmarshalLenientWithOptions([]byte(data), LenientOptions{SniffBodies: true})
+	want := []string{"line 5: Content-Type is application/json but the body looks like text/html"}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+	if result := UnmarshalLenient([]byte(data)); len(result.Warnings) != 0 {
+		t.Errorf("without SniffBodies: Warnings = %q, want none", result.Warnings)
+	}
+
+	// An encoded body is not sniffed.
+	gz := "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Encoding: gzip\r\nContent-Length: 4\r\n\r\n\x1f\x8b\x08\x00"
+	if result := UnmarshalLenientWithOptions([]byte(gz), LenientOptions{SniffBodies: true}); len(result.Warnings) != 0 {
+		t.Errorf("gzip: Warnings = %q, want none", result.Warnings)
+	}
+	// Nor is one whose gzip transfer coding was left undecoded.
+	te := "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nTransfer-Encoding: gzip, chunked\r\n\r\n4\r\n\x1f\x8b\x08\x00\r\n0\r\n\r\n"
+	if result := UnmarshalLenientWithOptions([]byte(te), LenientOptions{SniffBodies: true}); len(result.Warnings) != 0 {
+		t.Errorf("gzip transfer coding: Warnings = %q, want none", result.Warnings)
+	}
+}
+
+// ── Detection confidence ───────────────────────────────────────────────────
+
+func TestUnmarshalLenient_Confidence_Seeds(t *testing.T) {
+	for _, seed := range requestSeeds {
+		r := UnmarshalLenient(seed)
+		if r.Confidence != ConfidenceHigh || r.DetectedAs != MessageRequest {
+			t.Errorf("%q: DetectedAs=%v Confidence=%v, want request/high", seed, r.DetectedAs, r.Confidence)
+		}
+	}
+	for _, seed := range responseSeeds {
+		r := UnmarshalLenient(seed)
+		if r.Confidence != ConfidenceHigh || r.DetectedAs != MessageResponse {
+			t.Errorf("%q: DetectedAs=%v Confidence=%v, want response/high", seed, r.DetectedAs, r.Confidence)
+		}
+	}
+}
+
+func TestUnmarshalLenient_Confidence_Garbage(t *testing.T) {
+	for _, in := range []string{"not http at all !!!", "AAAAAAAAAAAAAAAA", "hello world\n\n"} {
+		r := UnmarshalLenient([]byte(in))
+		if r.Confidence != ConfidenceLow {
+			t.Errorf("%q: Confidence = %v, want low", in, r.Confidence)
+		}
+		if r.Request == nil {
+			t.Errorf("%q: Request = nil, want the request interpretation", in)
+		}
+		found := false
+		for _, w := range r.Warnings {
+			if strings.Contains(w, "low-confidence detection: parsed as request") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%q: no detection warning in %v", in, r.Warnings)
+		}
+	}
+}
+
+func TestUnmarshalLenient_Confidence_Medium(t *testing.T) {
+	tests := []struct {
+		in   string
+		kind MessageType
+	}{
+		{"get /lower HTTP/1.1\r\n\r\n", MessageRequest},
+		{"GET localhost:8080/api\r\n\r\n", MessageRequest},
+		{"PROPFIND /dav HTTP/1.1\r\n\r\n", MessageRequest},
+		{"HTTP/1.1\r\n\r\n", MessageResponse},
+	}
+	for _, tt := range tests {
+		r := UnmarshalLenient([]byte(tt.in))
+		if r.Confidence != ConfidenceMedium || r.DetectedAs != tt.kind {
+			t.Errorf("%q: DetectedAs=%v Confidence=%v, want %v/medium", tt.in, r.DetectedAs, r.Confidence, tt.kind)
+		}
+	}
+}
+
+func TestConfidence_String(t *testing.T) {
+	for c, want := range map[Confidence]string{0: "none", ConfidenceLow: "low", ConfidenceMedium: "medium", ConfidenceHigh: "high"} {
+		if got := c.String(); got != want {
+			t.Errorf("Confidence(%d).String() = %q, want %q", int(c), got, want)
+		}
+	}
+}
+
+// ── Interim responses ──────────────────────────────────────────────────────
+
+func TestUnmarshalLenient_Informational(t *testing.T) {
+	data := []byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 201 Created\r\nContent-Length: 2\r\n\r\nok")
+	result := UnmarshalLenient(data)
+	if result.Response == nil || result.Response.StatusCode != 201 || string(result.Response.Body) != "ok" {
+		t.Fatalf("Response = %+v, want 201 with body ok", result.Response)
+	}
+	if len(result.Informational) != 1 || result.Informational[0].StatusCode != 100 {
+		t.Errorf("Informational = %+v, want one 100 Continue", result.Informational)
+	}
+}
+
+// ── Byte statistics ────────────────────────────────────────────────────────
+
+const chunkedStatsResponse = "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n" +
+	"5\r\nHello\r\n6\r\n World\r\n0\r\nX-T: 1\r\n\r\n"
+
+var statsTests = []struct {
+	name  string
+	input string
+	want  Stats
+}{
+	{"request", httptest.BaseRequest, Stats{StartLineBytes: 25, HeaderBytes: 40, HeaderCount: 2, BodyBytes: 4, TotalBytes: 69, DecodedBodyBytes: 4,
+		LargestHeader: "Host", LargestHeaderBytes: 11, HeaderEndOffset: 63, BodyOffset: 65}},
+	{"response", httptest.BaseResponse, Stats{StartLineBytes: 17, HeaderBytes: 47, HeaderCount: 2, BodyBytes: 5, TotalBytes: 69, DecodedBodyBytes: 5,
+		LargestHeader: "Content-Type", LargestHeaderBytes: 10, HeaderEndOffset: 62, BodyOffset: 64}},
+	{"chunked", chunkedStatsResponse, Stats{StartLineBytes: 17, HeaderBytes: 30, HeaderCount: 1, BodyBytes: 34, TotalBytes: 81, DecodedBodyBytes: 11,
+		LargestHeader: "Transfer-Encoding", LargestHeaderBytes: 7, HeaderEndOffset: 45, BodyOffset: 47}},
+}
+
+func TestUnmarshalWithStats(t *testing.T) {
+	for _, tt := range statsTests {
+		var got Stats
+		var err error
+		if tt.name == "request" {
+			_, got, err = UnmarshalRequestWithStats([]byte(tt.input))
+		} else {
+			_, got, err = UnmarshalResponseWithStats([]byte(tt.input))
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Stats = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Bytes after a Content-Length body belong to no message field.
+	_, got, _ := UnmarshalRequestWithStats([]byte(httptest.BaseRequest + "GET / HTTP/1.1\r\n\r\n"))
+	if got.TotalBytes != 69 {
+		t.Errorf("pipelined: TotalBytes = %d, want 69", got.TotalBytes)
+	}
+}
+
+func TestUnmarshalLenient_Stats(t *testing.T) {
+	for _, tt := range statsTests {
+		if got := UnmarshalLenient([]byte(tt.input)).Stats; got != tt.want {
+			t.Errorf("%s: Stats = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalLenient_Stats_Partial(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Stats
+	}{
+		{"truncated body", httptest.TruncateBody(httptest.BaseRequest),
+			Stats{StartLineBytes: 25, HeaderBytes: 40, HeaderCount: 2, BodyBytes: 2, TotalBytes: 67, DecodedBodyBytes: 2,
+				LargestHeader: "Host", LargestHeaderBytes: 11, HeaderEndOffset: 63, BodyOffset: 65}},
+		{"cut in headers", httptest.BaseResponse[:30],
+			Stats{StartLineBytes: 17, HeaderBytes: 13, HeaderCount: 1, TotalBytes: 30,
+				LargestHeader: "Content-Type", HeaderEndOffset: -1, BodyOffset: -1}},
+		{"leading blank lines", "\r\n\r\n" + httptest.BaseResponse,
+			Stats{StartOffset: 4, StartLineBytes: 17, HeaderBytes: 47, HeaderCount: 2, BodyBytes: 5, TotalBytes: 69, DecodedBodyBytes: 5,
+				LargestHeader: "Content-Type", LargestHeaderBytes: 10, HeaderEndOffset: 66, BodyOffset: 68}},
+		{"after interim", "HTTP/1.1 100 Continue\r\n\r\n" + httptest.BaseResponse,
+			Stats{StartOffset: 25, StartLineBytes: 17, HeaderBytes: 47, HeaderCount: 2, BodyBytes: 5, TotalBytes: 69, DecodedBodyBytes: 5,
+				LargestHeader: "Content-Type", LargestHeaderBytes: 10, HeaderEndOffset: 87, BodyOffset: 89}},
+		{"broken chunks", "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHel",
+			Stats{StartLineBytes: 17, HeaderBytes: 30, HeaderCount: 1, BodyBytes: 6, TotalBytes: 53, DecodedBodyBytes: 6,
+				LargestHeader: "Transfer-Encoding", LargestHeaderBytes: 7, HeaderEndOffset: 45, BodyOffset: 47}},
+	}
+	for _, tt := range tests {
+		if got := UnmarshalLenient([]byte(tt.input)).Stats; got != tt.want {
+			t.Errorf("%s: Stats = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStats_BodyOffset(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		headerEnd int
+		body      int
+	}{
+		{"CRLF", "POST /a HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nhi", 46, 48},
+		{"LF", "POST /a HTTP/1.1\nHost: x\nContent-Length: 2\n\nhi", 43, 44},
+		{"chunked", chunkedStatsResponse, 45, 47},
+		{"headers only", "GET /a HTTP/1.1\r\nHost: x\r\n\r\n", 26, 28},
+		{"no blank line", "GET /a HTTP/1.1\r\nHost: x\r\n", -1, -1},
+		{"cut in a header", "GET /a HTTP/1.1\r\nHost: x", -1, -1},
+	}
+	for _, tt := range tests {
+		var strict Stats
+		var err error
+		if strings.HasPrefix(tt.input, "HTTP/") {
+			_, strict, err = UnmarshalResponseWithStats([]byte(tt.input))
+		} else {
+			_, strict, err = UnmarshalRequestWithStats([]byte(tt.input))
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		lenient := UnmarshalLenient([]byte(tt.input)).Stats
+		for _, got := range []Stats{strict, lenient} {
+			if got.HeaderEndOffset != tt.headerEnd || got.BodyOffset != tt.body {
+				t.Errorf("%s: HeaderEndOffset, BodyOffset = %d, %d, want %d, %d",
+					tt.name, got.HeaderEndOffset, got.BodyOffset, tt.headerEnd, tt.body)
+			}
+		}
+	}
+}
+
+func TestUnmarshalLenient_BodyOffset_Recovered(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		headerEnd int
+		body      int
+	}{
+		{"stray blank line", "POST /a HTTP/1.1\r\n\r\nHost: x\r\nContent-Length: 2\r\n\r\nhi", 48, 50},
+		{"inferred body", "POST /a HTTP/1.1\r\nContent-Type: application/json\r\n{\"a\":1}", 50, 50},
+		{"cut in headers", "GET /a HTTP/1.1\r\nHost: x", -1, -1},
+		{"empty", "", -1, -1},
+		// Offsets in rewritten input would not locate the body in the
+		// bytes given, so none are reported.
+		{"indented", "    POST /a HTTP/1.1\n    Host: x\n    Content-Length: 2\n\n    hi", -1, -1},
+		{"doubled CR", "POST /a HTTP/1.1\r\r\nHost: x\r\r\nContent-Length: 2\r\r\n\r\nhi", -1, -1},
+		{"string literal", `"POST /a HTTP/1.1\r\nContent-Length: 2\r\n\r\nhi"`, -1, -1},
+	}
+	for _, tt := range tests {
+		got := UnmarshalLenient([]byte(tt.input)).Stats
+		if got.HeaderEndOffset != tt.headerEnd || got.BodyOffset != tt.body {
+			t.Errorf("%s: HeaderEndOffset, BodyOffset = %d, %d, want %d, %d",
+				tt.name, got.HeaderEndOffset, got.BodyOffset, tt.headerEnd, tt.body)
+		}
+	}
+}
+
+// An explicit, empty Host header counts as present, so the authority of an
+// absolute-form request-target is not injected.
+func TestUnmarshalLenient_EmptyHostKept(t *testing.T) {
+	result := UnmarshalLenient([]byte("GET http://example.com/ HTTP/1.1\r\nHost:\r\n\r\n"))
+	if result.Request == nil {
+		t.Fatal("Request is nil")
+	}
+	if got := result.Request.Headers.Values("Host"); len(got) != 1 || got[0] != "" {
+		t.Errorf("Host values = %q, want one empty value", got)
+	}
+}
+
+func TestUnmarshalLenient_InvalidHeaderNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		key       string
+		sanitized string
+		warning   string
+	}{
+		{"non-ASCII", "Résumé-Token: x", "Résumé-Token", "R-sum-Token", `"R\xc3\xa9sum\xc3\xa9-Token"`},
+		{"control", "X-Key\x01: v", "X-Key\x01", "X-Key", `"X-Key\x01"`},
+		{"space", "X-Custom Header: v", "X-Custom Header", "X-Custom-Header", `"X-Custom\x20Header"`},
+		{"empty", ": value", "", "", "empty header name"},
+		{"credential", "Authorization Bearer s3cr3t: x", "Authorization Bearer s3cr3t", "Authorization-Bearer-s3cr3t", `"Authorization [redacted]"`},
+	}
+	for _, tt := range tests {
+		r := UnmarshalLenient([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n" + tt.line + "\r\n\r\n"))
+		if r.Request == nil || len(r.Request.Headers) != 2 || r.Request.Headers[1].Key != tt.key {
+			t.Errorf("%s: headers = %+v, want %q kept", tt.name, r.Request, tt.key)
+			continue
+		}
+		want := []InvalidHeaderName{{Name: tt.key, Sanitized: tt.sanitized}}
+		if !reflect.DeepEqual(r.InvalidHeaderNames, want) {
+			t.Errorf("%s: InvalidHeaderNames = %+v, want %+v", tt.name, r.InvalidHeaderNames, want)
+		}
+		if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], tt.warning) {
+			t.Errorf("%s: warnings = %q, want one containing %s", tt.name, r.Warnings, tt.warning)
+		}
+		if strings.Contains(strings.Join(r.Warnings, "\n"), "s3cr3t") {
+			t.Errorf("%s: warnings %q echo the credential", tt.name, r.Warnings)
+		}
+	}
+
+	r := UnmarshalLenient([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n!#$%&'*+.^_|~: legal\r\n\r\n"))
+	if len(r.Warnings) != 0 || r.InvalidHeaderNames != nil {
+		t.Errorf("token name: warnings = %q, InvalidHeaderNames = %+v", r.Warnings, r.InvalidHeaderNames)
+	}
+}
@@ -0,0 +1,205 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// WarningCode names a kind of lenient warning. Its value is the label the
+// lenient parser uses when it summarizes suppressed repeats, such as
+// "malformed header (no colon)", and the key of BatchSummary.WarningsByKind.
+type WarningCode string
+
+// Warning codes raised by the lenient parser.
+const (
+	WarnEmptyInput            WarningCode = WarningCode(fastparser.WarnEmptyInput)
+	WarnNoStartLine           WarningCode = WarningCode(fastparser.WarnNoStartLine)
+	WarnBodyIncomplete        WarningCode = WarningCode(fastparser.WarnBodyIncomplete)
+	WarnRequestLine           WarningCode = WarningCode(fastparser.WarnRequestLine)
+	WarnTargetNormalized      WarningCode = WarningCode(fastparser.WarnTargetNormalized)
+	WarnStatusLine            WarningCode = WarningCode(fastparser.WarnStatusLine)
+	WarnStrayBlankLine        WarningCode = WarningCode(fastparser.WarnStrayBlankLine)
+	WarnImplicitHost          WarningCode = WarningCode(fastparser.WarnImplicitHost)
+	WarnMalformedHeader       WarningCode = WarningCode(fastparser.WarnMalformedHeader)
+	WarnWhitespaceBeforeColon WarningCode = WarningCode(fastparser.WarnWhitespaceBeforeColon)
+	WarnChunkedError          WarningCode = WarningCode(fastparser.WarnChunkedError)
+	WarnContentLengthMismatch WarningCode = WarningCode(fastparser.WarnContentLengthMismatch)
+	WarnAmbiguousType         WarningCode = WarningCode(fastparser.WarnAmbiguousType)
+	WarnBodyTruncated         WarningCode = WarningCode(fastparser.WarnBodyTruncated) // cut at LenientOptions.MaxBodyBytes
+	WarnInterimOnly           WarningCode = WarningCode(fastparser.WarnInterimOnly)
+	WarnByteOrderMark         WarningCode = WarningCode(fastparser.WarnByteOrderMark)
+	WarnIndented              WarningCode = WarningCode(fastparser.WarnIndented)
+	WarnWrappedHeader         WarningCode = WarningCode(fastparser.WarnWrappedHeader)
+	WarnDoubledCR             WarningCode = WarningCode(fastparser.WarnDoubledCR)
+	WarnDuplicateHost         WarningCode = WarningCode(fastparser.WarnDuplicateHost)
+	WarnTransferCoding        WarningCode = WarningCode(fastparser.WarnTransferCoding)
+	WarnStringLiteral         WarningCode = WarningCode(fastparser.WarnStringLiteral)
+	WarnLongHeaderValue       WarningCode = WarningCode(fastparser.WarnLongHeaderValue)
+	WarnInferredBody          WarningCode = WarningCode(fastparser.WarnInferredBody)
+	WarnContentTypeMismatch   WarningCode = WarningCode(fastparser.WarnContentTypeMismatch)
+	WarnConnectionHeader      WarningCode = WarningCode(fastparser.WarnConnectionHeader)
+	WarnLongFold              WarningCode = WarningCode(fastparser.WarnLongFold)
+	WarnIDNHost               WarningCode = WarningCode(fastparser.WarnIDNHost)
+	WarnInvalidHeaderName     WarningCode = WarningCode(fastparser.WarnInvalidHeaderName)
+	WarnContentLengthWithTE   WarningCode = WarningCode(fastparser.WarnContentLengthWithTE)
+	WarnConflictingLength     WarningCode = WarningCode(fastparser.WarnConflictingLength)
+	WarnInvalidContentLength  WarningCode = WarningCode(fastparser.WarnInvalidContentLength)
+)
+
+// StrictnessLevel selects how forgiving ParseWithLevel is.
+type StrictnessLevel int
+
+// Strictness levels, from least to most forgiving.
+const (
+	// Strict parses with the strict parser, as Unmarshal does, and fails
+	// on the first deviation from RFC 9112.
+	Strict StrictnessLevel = iota + 1
+
+	// Standard parses leniently and fails if a warning of a code that
+	// StandardPolicy makes an error was raised: repairs of cosmetic damage
+	// such as bare LF line endings, folded headers or a byte order mark
+	// pass, while a message whose framing or structure is broken fails.
+	Standard
+
+	// Lenient parses with UnmarshalLenient and never fails.
+	Lenient
+)
+
+func (l StrictnessLevel) String() string {
+	switch l {
+	case Strict:
+		return "strict"
+	case Standard:
+		return "standard"
+	case Lenient:
+		return "lenient"
+	default:
+		return "none"
+	}
+}
+
+// LevelPolicy maps warning codes to how serious a lenient parse that
+// raised them is. ParseWithPolicy fails when any code it maps to
+// SeverityError was raised; codes it leaves out are not errors.
+type LevelPolicy map[WarningCode]Severity
+
+// StandardPolicy returns the policy of the Standard level, a new map each
+// time, so a caller may adjust it and pass it to ParseWithPolicy. Broken
+// framing (a body shorter than its framing calls for, a bad chunk, an
+// undecoded transfer coding, an invalid or conflicting Content-Length, or
+// one sent with Transfer-Encoding), an unusable start line, a header line
+// that is not a field, conflicting Host headers, a body with no blank line
+// before it, an over-long fold and input that is not clearly one message
+// are errors; everything else is a warning, including a body longer than
+// its Content-Length, whose excess the strict parser leaves unread too.
+func StandardPolicy() LevelPolicy {
+	policy := LevelPolicy{
+		WarnEmptyInput:           SeverityError,
+		WarnNoStartLine:          SeverityError,
+		WarnBodyIncomplete:       SeverityError,
+		WarnRequestLine:          SeverityError,
+		WarnStatusLine:           SeverityError,
+		WarnMalformedHeader:      SeverityError,
+		WarnChunkedError:         SeverityError,
+		WarnAmbiguousType:        SeverityError,
+		WarnInterimOnly:          SeverityError,
+		WarnDuplicateHost:        SeverityError,
+		WarnTransferCoding:       SeverityError,
+		WarnInferredBody:         SeverityError,
+		WarnLongFold:             SeverityError,
+		WarnContentLengthWithTE:  SeverityError,
+		WarnConflictingLength:    SeverityError,
+		WarnInvalidContentLength: SeverityError,
+	}
+	for _, code := range []WarningCode{
+		WarnTargetNormalized, WarnStrayBlankLine, WarnImplicitHost,
+		WarnWhitespaceBeforeColon, WarnBodyTruncated, WarnByteOrderMark,
+		WarnIndented, WarnWrappedHeader, WarnDoubledCR, WarnStringLiteral,
+		WarnLongHeaderValue, WarnContentLengthMismatch, WarnContentTypeMismatch,
+		WarnConnectionHeader, WarnIDNHost, WarnInvalidHeaderName,
+	} {
+		policy[code] = SeverityWarning
+	}
+	return policy
+}
+
+// ParseWithLevel parses data as a request or a response at level. Strict
+// fills Request or Response, Stats and DetectedAs of the result, with
+// Complete set; the other levels give what UnmarshalLenient does. At
+// Standard the result comes back with the error, so a caller can still
+// see what was parsed and the warnings that failed it.
+func ParseWithLevel(data []byte, level StrictnessLevel) (*ParseResult, error) {
+	switch level {
+	case Strict:
+		return parseStrict(data)
+	case Standard:
+		return ParseWithPolicy(data, StandardPolicy())
+	case Lenient:
+		result, _ := unmarshalLenient(data, LenientOptions{})
+		return result, nil
+	default:
+		return nil, fmt.Errorf("http: unknown strictness level %d", int(level))
+	}
+}
+
+// ParseWithPolicy parses data leniently and fails if a warning of a code
+// policy maps to SeverityError was raised, naming those codes in the
+// error. The error is in ErrMalformedStartLine, ErrMalformedHeader,
+// ErrBodyTruncated or ErrInvalidChunk for the codes that match them. The
+// result is returned with the error.
+func ParseWithPolicy(data []byte, policy LevelPolicy) (*ParseResult, error) {
+	result, counts := unmarshalLenient(data, LenientOptions{})
+	var fatal []string
+	var kinds []error
+	for label := range counts {
+		code := WarningCode(label)
+		if policy[code] < SeverityError {
+			continue
+		}
+		fatal = append(fatal, label)
+		if kind := warningErrorKind(code); kind != nil {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(fatal) == 0 {
+		return result, nil
+	}
+	sort.Strings(fatal)
+	err := fmt.Errorf("http: message rejected by policy: %s", strings.Join(fatal, ", "))
+	return result, fastparser.WithKind(err, kinds...)
+}
+
+// warningErrorKind returns the error category of a warning code, or nil.
+func warningErrorKind(code WarningCode) error {
+	switch code {
+	case WarnEmptyInput, WarnNoStartLine, WarnRequestLine, WarnStatusLine:
+		return ErrMalformedStartLine
+	case WarnMalformedHeader, WarnContentLengthWithTE, WarnConflictingLength, WarnInvalidContentLength:
+		return ErrMalformedHeader
+	case WarnBodyIncomplete:
+		return ErrBodyTruncated
+	case WarnChunkedError:
+		return ErrInvalidChunk
+	}
+	return nil
+}
+
+func parseStrict(data []byte) (*ParseResult, error) {
+	result := &ParseResult{Raw: data, Complete: true}
+	var err error
+	if bytes.HasPrefix(bytes.TrimLeft(data, "\r\n"), []byte("HTTP/")) {
+		result.DetectedAs = MessageResponse
+		result.Response, result.Stats, err = UnmarshalResponseWithStats(data)
+	} else {
+		result.DetectedAs = MessageRequest
+		result.Request, result.Stats, err = UnmarshalRequestWithStats(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
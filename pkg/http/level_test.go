@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseWithLevel_BareLF(t *testing.T) {
+	data := []byte("GET /a HTTP/1.1\nHost: example.com\n\n")
+	if _, err := ParseWithLevel(data, Standard); err != nil {
+		t.Errorf("Standard: %v", err)
+	}
+	if _, err := ParseWithLevel(data, Lenient); err != nil {
+		t.Errorf("Lenient: %v", err)
+	}
+}
+
+func TestParseWithLevel_TruncatedBody(t *testing.T) {
+	data := []byte("POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\nshort")
+	if _, err := ParseWithLevel(data, Strict); err == nil {
+		t.Error("Strict accepted a truncated body")
+	}
+	r, err := ParseWithLevel(data, Standard)
+	if err == nil {
+		t.Fatal("Standard accepted a truncated body")
+	}
+	if !errors.Is(err, ErrBodyTruncated) {
+		t.Errorf("Standard error %v is not ErrBodyTruncated", err)
+	}
+	if r == nil || r.Request == nil || !r.Partial {
+		t.Errorf("Standard result = %+v, want the partial request", r)
+	}
+	if r, err := ParseWithLevel(data, Lenient); err != nil || r.Request == nil {
+		t.Errorf("Lenient = %+v, %v", r, err)
+	}
+}
+
+func TestParseWithLevel_Seeds(t *testing.T) {
+	for _, seed := range requestSeeds {
+		for _, level := range []StrictnessLevel{Strict, Standard, Lenient} {
+			r, err := ParseWithLevel(seed, level)
+			if err != nil {
+				t.Errorf("%s: %q: %v", level, seed, err)
+				continue
+			}
+			if r.Request == nil {
+				t.Errorf("%s: %q: no request", level, seed)
+			}
+		}
+	}
+}
+
+func TestParseWithLevel_Strict(t *testing.T) {
+	r, err := ParseWithLevel([]byte("HTTP/1.1 204 No Content\r\n\r\n"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Response == nil || r.Response.StatusCode != 204 || r.DetectedAs != MessageResponse || !r.Complete {
+		t.Errorf("result = %+v", r)
+	}
+	if _, err := ParseWithLevel([]byte("GET /a HTTP/1.1\r\n\r\n"), StrictnessLevel(0)); err == nil {
+		t.Error("level 0 accepted")
+	}
+}
+
+func TestParseWithPolicy_Custom(t *testing.T) {
+	data := []byte("GET /a HTTP/1.1\r\nHost: example.com\r\nno colon here\r\n\r\n")
+	_, err := ParseWithLevel(data, Standard)
+	if !errors.Is(err, ErrMalformedHeader) || !strings.Contains(err.Error(), string(WarnMalformedHeader)) {
+		t.Fatalf("Standard error = %v", err)
+	}
+	policy := StandardPolicy()
+	policy[WarnMalformedHeader] = SeverityWarning
+	if _, err := ParseWithPolicy(data, policy); err != nil {
+		t.Errorf("relaxed policy: %v", err)
+	}
+	if StandardPolicy()[WarnMalformedHeader] != SeverityError {
+		t.Error("changing a returned policy changed StandardPolicy")
+	}
+}
+
+func TestParseWithLevel_FramingConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		code  WarningCode
+	}{
+		{"CL+TE", "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 3\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n", WarnContentLengthWithTE},
+		{"conflicting CL", "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 3\r\nContent-Length: 5\r\n\r\nabc", WarnConflictingLength},
+		{"invalid CL", "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: abc\r\n\r\nabc", WarnInvalidContentLength},
+	}
+	for _, tt := range tests {
+		r, err := ParseWithLevel([]byte(tt.input), Standard)
+		if !errors.Is(err, ErrMalformedHeader) || !strings.Contains(err.Error(), string(tt.code)) {
+			t.Errorf("%s: Standard error = %v, want %q", tt.name, err, tt.code)
+		}
+		if r == nil || r.Request == nil || string(r.Request.Body) != "abc" {
+			t.Errorf("%s: Standard result = %+v", tt.name, r)
+		}
+		if _, err := ParseWithLevel([]byte(tt.input), Lenient); err != nil {
+			t.Errorf("%s: Lenient: %v", tt.name, err)
+		}
+	}
+
+	// Repeated equal values are not a conflict.
+	data := []byte("POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 3\r\nContent-Length: 3\r\n\r\nabc")
+	if _, err := ParseWithLevel(data, Standard); err != nil {
+		t.Errorf("repeated Content-Length: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"time"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// ParserLimits holds optional restrictions enforced by the strict parser.
+// The zero value imposes none, matching UnmarshalRequest and UnmarshalResponse.
+type ParserLimits struct {
+	// RestrictMethods, when non-empty, is the case-sensitive allow-list of
+	// request methods. A request using any other method is rejected with an
+	// error naming the method.
+	RestrictMethods []string
+
+	// KeepRawBody keeps a chunked message's encoded body in RawBody and its
+	// headers before chunked normalization in RawHeaders, for byte-faithful
+	// replay with MarshalOptions.UseRawBody. It is off by default because
+	// the copy doubles the memory a chunked body takes.
+	KeepRawBody bool
+
+	// MaxHeaderValueBytes, when positive, rejects a message with a header
+	// value, obs-folded lines joined, longer than that many bytes, with an
+	// error naming the header. See LenientOptions.MaxHeaderValueBytes for
+	// the lenient equivalent.
+	MaxHeaderValueBytes int
+
+	// MaxFoldedLines, when positive, rejects a message with a header
+	// continued over more obs-fold lines than that, with an error naming
+	// the header. See LenientOptions.MaxFoldedLines for the lenient
+	// equivalent.
+	MaxFoldedLines int
+
+	// RequireCRLF rejects a message with a line of the start line, header
+	// section or chunked framing that ends in LF alone, or with a CR not
+	// followed by LF in the start line or headers, with an error naming
+	// the line that also matches ErrBareLineEnding. RFC 9112 §2.2 lets a
+	// recipient accept bare LF, and by default the parser does; a gateway
+	// guarding against request smuggling may not want to.
+	// Stats.UsedBareLF reports the line endings of a message parsed
+	// without it.
+	RequireCRLF bool
+
+	// DecodeTransferCodings undoes gzip (and x-gzip), deflate and identity
+	// transfer codings applied before chunked and drops Transfer-Encoding;
+	// another coding there is an error. Without it, the default, the body
+	// is only dechunked and Transfer-Encoding keeps the codings before
+	// chunked, as in "Transfer-Encoding: gzip", for the caller to undo.
+	DecodeTransferCodings bool
+
+	// MaxDecodedBodyBytes, when positive, rejects a message whose transfer
+	// codings decode to more than that many bytes, without decoding more
+	// than one byte past the limit, so that a small compressed body cannot
+	// expand into gigabytes. Zero means no limit; set it whenever
+	// DecodeTransferCodings parses untrusted input.
+	MaxDecodedBodyBytes int
+
+	// Trace, when non-nil, receives a PhaseInfo as the parser finishes the
+	// start line, the header section and the body, in that order, for
+	// profiling large messages. Without a Trace the parser does not read
+	// the clock. Decoder.SetTrace sets the same hooks for a Decoder.
+	Trace *ParserTrace
+}
+
+// ParserTrace holds the hooks ParserLimits.Trace calls: OnStartLine,
+// OnHeadersDone and OnBodyDone, each once per message parsed and in that
+// order. Any hook may be nil. A phase that fails calls no hook, so an
+// error after the headers leaves OnBodyDone uncalled.
+type ParserTrace struct {
+	OnStartLine   func(info PhaseInfo)
+	OnHeadersDone func(info PhaseInfo)
+	OnBodyDone    func(info PhaseInfo)
+}
+
+// PhaseInfo describes a completed parse phase: Start and End are the byte
+// offsets in the input of what it consumed, the line ending of the start
+// line and the blank line after the headers included, and Duration is the
+// time it took. The body phase covers the body as framed on the wire,
+// chunk framing and trailers included.
+type PhaseInfo struct {
+	Start    int
+	End      int
+	Duration time.Duration
+}
+
+// internal returns the parser's form of t, nil for a nil t.
+func (t *ParserTrace) internal() *fastparser.ParserTrace {
+	if t == nil {
+		return nil
+	}
+	return &fastparser.ParserTrace{
+		OnStartLine:   phaseHook(t.OnStartLine),
+		OnHeadersDone: phaseHook(t.OnHeadersDone),
+		OnBodyDone:    phaseHook(t.OnBodyDone),
+	}
+}
+
+// phaseHook adapts a ParserTrace hook to the parser's PhaseInfo.
+func phaseHook(fn func(PhaseInfo)) func(fastparser.PhaseInfo) {
+	if fn == nil {
+		return nil
+	}
+	return func(info fastparser.PhaseInfo) { fn(PhaseInfo(info)) }
+}
+
+func (l ParserLimits) internal() fastparser.Limits {
+	return fastparser.Limits{
+		RestrictMethods: l.RestrictMethods,
+		KeepRawBody:     l.KeepRawBody,
+
+		MaxHeaderValueBytes: l.MaxHeaderValueBytes,
+		MaxFoldedLines:      l.MaxFoldedLines,
+		RequireCRLF:         l.RequireCRLF,
+		Trace:               l.Trace.internal(),
+
+		DecodeTransferCodings: l.DecodeTransferCodings,
+		MaxDecodedBodyBytes:   l.MaxDecodedBodyBytes,
+	}
+}
+
+// UnmarshalRequestWithLimits is UnmarshalRequest with the restrictions in limits.
+func UnmarshalRequestWithLimits(data []byte, limits ParserLimits) (*Request, error) {
+	req := &Request{}
+	if err := unmarshalRequestWithLimits(data, req, limits.internal()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// UnmarshalResponseWithLimits is UnmarshalResponse with the restrictions in limits.
+func UnmarshalResponseWithLimits(data []byte, limits ParserLimits) (*Response, error) {
+	resp := &Response{}
+	if err := unmarshalResponseWithLimits(data, resp, limits.internal()); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RegisterMethods adds extension methods such as WebDAV's PROPFIND or a
+// custom PURGE to the parser's intern table, so that parsing them costs no
+// extra allocation. The standard methods are always registered.
+//
+// RegisterMethods is intended to be called from an init function. It is
+// safe to call concurrently with parsing, but each call copies the table.
+// Registration only affects allocation, never which methods are accepted;
+// use ParserLimits.RestrictMethods for that.
+func RegisterMethods(methods ...string) {
+	fastparser.RegisterMethods(methods...)
+}
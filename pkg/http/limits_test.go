@@ -0,0 +1,211 @@
+package http
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+func TestUnmarshalRequestWithLimits_RestrictMethods(t *testing.T) {
+	limits := ParserLimits{RestrictMethods: []string{"GET", "HEAD"}}
+
+	req, err := UnmarshalRequestWithLimits([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), limits)
+	if err != nil {
+		t.Fatalf("UnmarshalRequestWithLimits(GET) error = %v", err)
+	}
+	if req.Method != "GET" {
+		t.Errorf("Method = %q, want GET", req.Method)
+	}
+
+	for _, method := range []string{"POST", "PROPFIND", "get"} {
+		data := []byte(method + " / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+		_, err := UnmarshalRequestWithLimits(data, limits)
+		if err == nil {
+			t.Errorf("UnmarshalRequestWithLimits(%s) error = nil, want rejection", method)
+			continue
+		}
+		if !strings.Contains(err.Error(), `method "`+method+`" is not allowed`) {
+			t.Errorf("error = %q, want it to name method %q", err, method)
+		}
+	}
+}
+
+func TestUnmarshalRequestWithLimits_ZeroValue(t *testing.T) {
+	// No limits: extension methods parse as before.
+	req, err := UnmarshalRequestWithLimits([]byte("MKCOL /dav/new HTTP/1.1\r\nHost: example.com\r\n\r\n"), ParserLimits{})
+	if err != nil {
+		t.Fatalf("UnmarshalRequestWithLimits() error = %v", err)
+	}
+	if req.Method != "MKCOL" {
+		t.Errorf("Method = %q, want MKCOL", req.Method)
+	}
+}
+
+func TestUnmarshalResponseWithLimits(t *testing.T) {
+	resp, err := UnmarshalResponseWithLimits([]byte("HTTP/1.1 204 No Content\r\n\r\n"), ParserLimits{RestrictMethods: []string{"GET"}})
+	if err != nil {
+		t.Fatalf("UnmarshalResponseWithLimits() error = %v", err)
+	}
+	if resp.StatusCode != 204 {
+		t.Errorf("StatusCode = %d, want 204", resp.StatusCode)
+	}
+}
+
+func TestUnmarshalRequestWithLimits_MaxHeaderValueBytes(t *testing.T) {
+	_, err := UnmarshalRequestWithLimits([]byte(bigCookieRequest), ParserLimits{MaxHeaderValueBytes: 8 << 10})
+	if err == nil || !strings.Contains(err.Error(), `header "Cookie" value is 1048576 bytes, over the limit of 8192`) {
+		t.Errorf("error = %v, want it to name the Cookie header", err)
+	}
+
+	req, err := UnmarshalRequestWithLimits([]byte(bigCookieRequest), ParserLimits{MaxHeaderValueBytes: 2 << 20})
+	if err != nil {
+		t.Fatalf("under the limit: error = %v", err)
+	}
+	if string(req.Body) != "hello" {
+		t.Errorf("Body = %q, want hello", req.Body)
+	}
+
+	_, stats, err := UnmarshalRequestWithStats([]byte(bigCookieRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LargestHeader != "Cookie" || stats.LargestHeaderBytes != 1<<20 {
+		t.Errorf("largest header %q, %d bytes, want Cookie, %d", stats.LargestHeader, stats.LargestHeaderBytes, 1<<20)
+	}
+}
+
+func TestUnmarshalRequestWithLimits_MaxFoldedLines(t *testing.T) {
+	data := []byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Fold: a\r\n b\r\n c\r\n d\r\n\r\n")
+	_, err := UnmarshalRequestWithLimits(data, ParserLimits{MaxFoldedLines: 2})
+	if err == nil || !strings.Contains(err.Error(), `header "X-Fold" has more than 2 continuation lines`) {
+		t.Errorf("error = %v, want it to name X-Fold", err)
+	}
+	req, err := UnmarshalRequestWithLimits(data, ParserLimits{MaxFoldedLines: 3})
+	if err != nil {
+		t.Fatalf("at the limit: error = %v", err)
+	}
+	if got := req.Headers.Get("X-Fold"); got != "a b c d" {
+		t.Errorf("at the limit: X-Fold = %q, want \"a b c d\"", got)
+	}
+}
+
+func TestUnmarshalRequestWithLimits_RequireCRLF(t *testing.T) {
+	strict := ParserLimits{RequireCRLF: true}
+	rejected := []struct {
+		name, data, want string
+	}{
+		{"LF only", "GET / HTTP/1.1\nHost: example.com\n\n", "line 1: line ends in LF without CR"},
+		{"mixed", "GET / HTTP/1.1\r\nHost: example.com\r\nAccept: */*\n\r\n", "line 3: line ends in LF without CR"},
+		{"bare LF blank line", "GET / HTTP/1.1\r\nHost: example.com\r\n\n", "line 3: line ends in LF without CR"},
+		{"bare CR in a header", "GET / HTTP/1.1\r\nHost: example.com\r\nX-A: 1\rX-B: 2\r\n\r\n", "line 3: bare CR not followed by LF"},
+		{"chunk size line", "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\n\r\n", "line 7: chunked framing line ends in LF without CR"},
+		{"after chunk data", "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\n0\r\n\r\n", "line 6: chunked framing line ends in LF without CR"},
+	}
+	for _, tt := range rejected {
+		_, err := UnmarshalRequestWithLimits([]byte(tt.data), strict)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !errors.Is(err, ErrBareLineEnding) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+		if _, err := UnmarshalRequest([]byte(tt.data)); err != nil {
+			t.Errorf("%s: rejected without RequireCRLF: %v", tt.name, err)
+		}
+	}
+
+	crlf := "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"5\r\nhe\nlo\r\n0\r\nX-Sum: 1\r\n\r\n"
+	req, err := UnmarshalRequestWithLimits([]byte(crlf), strict)
+	if err != nil {
+		t.Fatalf("CRLF only: %v", err)
+	}
+	if string(req.Body) != "he\nlo" {
+		t.Errorf("CRLF only: Body = %q", req.Body)
+	}
+	resp := "HTTP/1.1 200 OK\nContent-Length: 2\n\nok"
+	if _, err := UnmarshalResponseWithLimits([]byte(resp), strict); !errors.Is(err, ErrBareLineEnding) {
+		t.Errorf("LF-only response: error = %v, want ErrBareLineEnding", err)
+	}
+}
+
+func TestStats_UsedBareLF(t *testing.T) {
+	for data, want := range map[string]bool{
+		"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n": false,
+		"GET / HTTP/1.1\nHost: example.com\n\n":       true,
+		"GET / HTTP/1.1\r\nHost: example.com\r\n\n":   true,
+	} {
+		_, stats, err := UnmarshalRequestWithStats([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.UsedBareLF != want {
+			t.Errorf("%q: UsedBareLF = %v, want %v", data, stats.UsedBareLF, want)
+		}
+	}
+}
+
+func TestUnmarshalResponseWithLimits_Trace(t *testing.T) {
+	var phases []string
+	var infos []PhaseInfo
+	hook := func(name string) func(PhaseInfo) {
+		return func(info PhaseInfo) {
+			phases = append(phases, name)
+			infos = append(infos, info)
+		}
+	}
+	trace := &ParserTrace{OnStartLine: hook("start line"), OnHeadersDone: hook("headers"), OnBodyDone: hook("body")}
+	resp, err := UnmarshalResponseWithLimits(chunkedResponse, ParserLimits{Trace: trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != "Hello, World!" {
+		t.Errorf("Body = %q", resp.Body)
+	}
+	if want := []string{"start line", "headers", "body"}; !reflect.DeepEqual(phases, want) {
+		t.Fatalf("phases = %q, want %q", phases, want)
+	}
+	// "HTTP/1.1 200 OK\r\n" is 17 bytes and the header section 30 more.
+	for i, want := range [][2]int{{0, 17}, {17, 47}, {47, len(chunkedResponse)}} {
+		if got := [2]int{infos[i].Start, infos[i].End}; got != want {
+			t.Errorf("%s: offsets %v, want %v", phases[i], got, want)
+		}
+		if infos[i].Duration < 0 {
+			t.Errorf("%s: Duration = %v", phases[i], infos[i].Duration)
+		}
+	}
+
+	// A request whose headers fail never reaches the body phase, and a
+	// nil hook is skipped.
+	phases = nil
+	trace.OnStartLine = nil
+	if _, err := UnmarshalRequestWithLimits([]byte("GET / HTTP/1.1\r\nBad Header\r\n\r\n"), ParserLimits{Trace: trace}); err == nil {
+		t.Fatal("error = nil, want the malformed header reported")
+	}
+	if len(phases) != 0 {
+		t.Errorf("phases = %q, want none", phases)
+	}
+}
+
+func TestRegisterMethods(t *testing.T) {
+	t.Cleanup(fastparser.SaveMethods())
+	RegisterMethods("LOCK", "")
+	data := []byte("LOCK /file HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	req, err := UnmarshalRequest(data)
+	if err != nil {
+		t.Fatalf("UnmarshalRequest() error = %v", err)
+	}
+	if req.Method != "LOCK" {
+		t.Errorf("Method = %q, want LOCK", req.Method)
+	}
+
+	unregistered := testing.AllocsPerRun(100, func() {
+		_, _ = UnmarshalRequest([]byte("UNLOCK /file HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	})
+	registered := testing.AllocsPerRun(100, func() {
+		_, _ = UnmarshalRequest(data)
+	})
+	if registered >= unregistered {
+		t.Errorf("allocs registered = %v, unregistered = %v; want registration to save an allocation", registered, unregistered)
+	}
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule IDs reported by LintMessage.
+const (
+	LintGETWithContentLength     = "get-content-length"         // Content-Length on a bodiless GET or HEAD
+	LintMissingHost              = "missing-host"               // HTTP/1.1 request without Host
+	LintMultipleHost             = "multiple-host"              // more than one Host header
+	LintMissingContentType       = "missing-content-type"       // request body without Content-Type
+	LintConnectionUnknownHeader  = "connection-unknown-header"  // Connection names a header that is absent
+	LintUpgradeWithoutConnection = "upgrade-without-connection" // Upgrade without Connection: upgrade
+	LintTEOnResponse             = "te-on-response"             // the TE request header on a response
+	LintResponseHeaderOnRequest  = "response-header-on-request" // Age or Expires on a request
+	LintLocationStatus           = "location-status"            // Location on a status other than 3xx or 201
+	LintChunkedHTTP10            = "chunked-http10"             // chunked Transfer-Encoding in HTTP/1.0
+)
+
+// Rule IDs reported by LintExchange.
+const (
+	LintBodyNotAllowed          = "body-not-allowed"            // a body on a 1xx, 204 or 304 response, or one to HEAD
+	LintSwitchingWithoutUpgrade = "switching-without-upgrade"   // 101 without Upgrade and Connection: upgrade
+	LintMethodNotAllowedNoAllow = "method-not-allowed-no-allow" // 405 without Allow
+	LintNotModifiedLength       = "not-modified-content-length" // Content-Length on a 304
+	LintConditionalNot304       = "conditional-not-304"         // 2xx to a GET or HEAD whose If-None-Match matches the ETag
+	LintConnectFraming          = "connect-framing"             // Content-Length or Transfer-Encoding on a 2xx to CONNECT
+)
+
+// LintFinding is one semantic problem found by LintMessage or
+// LintExchange.
+type LintFinding struct {
+	Rule       string   // one of the Lint* constants
+	Severity   Severity // SeverityError where RFC 9110 or 9112 requires rejection
+	Message    string   // what is wrong
+	Suggestion string   // how to fix it
+}
+
+// LintOptions configures LintMessageWithOptions.
+type LintOptions struct {
+	// Suppress lists rule IDs that are not reported.
+	Suppress []string
+}
+
+// LintMessage checks a parsed *Request or *Response for header semantics
+// that are syntactically valid but suspicious or wrong, such as a missing
+// Host on HTTP/1.1 or a Location header on a 200. A *ParseResult is linted
+// through its Request or Response, so the result of either parser can be
+// passed. Any other value, or a message with no findings, returns nil.
+//
+// Unlike Validate it looks at the message as parsed, not at the wire
+// bytes; see AnalyzeSmuggling for framing problems.
+func LintMessage(v interface{}) []LintFinding {
+	return LintMessageWithOptions(v, LintOptions{})
+}
+
+// LintMessageWithOptions is LintMessage with rules suppressed by opts.
+func LintMessageWithOptions(v interface{}, opts LintOptions) []LintFinding {
+	l := linter{opts: opts}
+	switch msg := v.(type) {
+	case *ParseResult:
+		if msg == nil {
+			return nil
+		}
+		if msg.Request != nil {
+			l.request(msg.Request)
+		} else if msg.Response != nil {
+			l.response(msg.Response)
+		}
+	case *Request:
+		if msg != nil {
+			l.request(msg)
+		}
+	case *Response:
+		if msg != nil {
+			l.response(msg)
+		}
+	}
+	return l.findings
+}
+
+// LintExchange checks resp as the answer to req, for rules that need
+// both messages, such as a 200 to a conditional GET whose If-None-Match
+// matches the ETag. It does not repeat the rules of LintMessage; lint
+// each message as well for those. A nil argument, or an exchange with no
+// findings, returns nil.
+func LintExchange(req *Request, resp *Response) []LintFinding {
+	return LintExchangeWithOptions(req, resp, LintOptions{})
+}
+
+// LintExchangeWithOptions is LintExchange with rules suppressed by opts.
+func LintExchangeWithOptions(req *Request, resp *Response, opts LintOptions) []LintFinding {
+	if req == nil || resp == nil {
+		return nil
+	}
+	l := linter{opts: opts}
+	l.exchange(req, resp)
+	return l.findings
+}
+
+type linter struct {
+	opts     LintOptions
+	findings []LintFinding
+}
+
+func (l *linter) report(rule string, sev Severity, msg, suggestion string) {
+	for _, s := range l.opts.Suppress {
+		if s == rule {
+			return
+		}
+	}
+	l.findings = append(l.findings, LintFinding{Rule: rule, Severity: sev, Message: msg, Suggestion: suggestion})
+}
+
+func (l *linter) request(r *Request) {
+	if (r.Method == "GET" || r.Method == "HEAD") && len(r.Body) == 0 && r.Headers.Get("Content-Length") != "" {
+		l.report(LintGETWithContentLength, SeverityWarning,
+			fmt.Sprintf("%s request has a Content-Length header but no body", r.Method),
+			"remove the Content-Length header")
+	}
+	switch hosts := len(r.Headers.Values("Host")); {
+	case hosts == 0 && r.Version == "HTTP/1.1":
+		l.report(LintMissingHost, SeverityError,
+			"HTTP/1.1 request has no Host header",
+			"add a Host header naming the target authority")
+	case hosts > 1:
+		l.report(LintMultipleHost, SeverityError,
+			fmt.Sprintf("request has %d Host headers", hosts),
+			"send exactly one Host header")
+	}
+	if len(r.Body) > 0 && r.Headers.Get("Content-Type") == "" {
+		l.report(LintMissingContentType, SeverityWarning,
+			"request has a body but no Content-Type header",
+			"add a Content-Type header describing the body")
+	}
+	for _, name := range []string{"Age", "Expires"} {
+		if r.Headers.Get(name) != "" {
+			l.report(LintResponseHeaderOnRequest, SeverityWarning,
+				fmt.Sprintf("%s is a response header but appears on a request", name),
+				fmt.Sprintf("remove the %s header", name))
+		}
+	}
+	l.common(r.Version, r.Headers)
+}
+
+func (l *linter) response(r *Response) {
+	if r.Headers.Get("TE") != "" {
+		l.report(LintTEOnResponse, SeverityWarning,
+			"TE is a request header but appears on a response",
+			"use Transfer-Encoding to describe the response body, or remove TE")
+	}
+	if r.Headers.Get("Location") != "" && r.StatusCode != 201 && (r.StatusCode < 300 || r.StatusCode > 399) {
+		l.report(LintLocationStatus, SeverityWarning,
+			fmt.Sprintf("Location header on a %d response is ignored by clients", r.StatusCode),
+			"use a 3xx status to redirect or 201 for a created resource")
+	}
+	l.common(r.Version, r.Headers)
+}
+
+// common applies the rules shared by requests and responses.
+func (l *linter) common(version string, headers Headers) {
+	connection := splitList(headers.Values("Connection"))
+	upgrade := false
+	for _, token := range connection {
+		switch strings.ToLower(token) {
+		case "close", "keep-alive":
+			continue
+		case "upgrade":
+			upgrade = true
+		}
+		if headers.Get(token) == "" {
+			l.report(LintConnectionUnknownHeader, SeverityInfo,
+				fmt.Sprintf("Connection lists %q but the message has no such header", token),
+				fmt.Sprintf("remove %q from Connection", token))
+		}
+	}
+	if headers.Get("Upgrade") != "" && !upgrade {
+		l.report(LintUpgradeWithoutConnection, SeverityWarning,
+			"Upgrade header without Connection: upgrade is ignored",
+			"add \"upgrade\" to the Connection header")
+	}
+	if version == "HTTP/1.0" && headers.IsChunked() {
+		l.report(LintChunkedHTTP10, SeverityError,
+			"chunked Transfer-Encoding is not defined for HTTP/1.0",
+			"send HTTP/1.1, or frame the body with Content-Length")
+	}
+}
+
+// exchange applies the rules that pair a response with its request.
+func (l *linter) exchange(req *Request, resp *Response) {
+	code := resp.StatusCode
+	switch {
+	case len(resp.Body) == 0:
+	case req.Method == "HEAD":
+		l.report(LintBodyNotAllowed, SeverityError,
+			fmt.Sprintf("response to HEAD has a %d-byte body", len(resp.Body)),
+			"send the headers a GET would get, without the body")
+	case code >= 100 && code <= 199 || code == 204 || code == 304:
+		l.report(LintBodyNotAllowed, SeverityError,
+			fmt.Sprintf("%d response has a %d-byte body", code, len(resp.Body)),
+			fmt.Sprintf("remove the body; a %d response ends with its header section", code))
+	}
+
+	if code == 101 && (resp.Headers.Get("Upgrade") == "" || !hasListToken(resp.Headers.Values("Connection"), "upgrade")) {
+		l.report(LintSwitchingWithoutUpgrade, SeverityError,
+			"101 response lacks Upgrade or Connection: upgrade",
+			"name the protocol switched to in Upgrade and add \"upgrade\" to Connection")
+	}
+	if code == 405 && resp.Headers.Get("Allow") == "" {
+		l.report(LintMethodNotAllowedNoAllow, SeverityWarning,
+			fmt.Sprintf("405 response to %s has no Allow header", req.Method),
+			"list the methods the resource supports in an Allow header")
+	}
+	if cl := resp.Headers.Get("Content-Length"); code == 304 && cl != "" {
+		l.report(LintNotModifiedLength, SeverityInfo,
+			fmt.Sprintf("304 response has Content-Length %s, which must equal that of the 200 response it stands for", cl),
+			"remove Content-Length unless it is the length of the selected representation")
+	}
+	if inm, etag := req.Headers.Get("If-None-Match"), resp.Headers.Get("ETag"); (req.Method == "GET" || req.Method == "HEAD") &&
+		code >= 200 && code <= 299 && inm != "" && ETagMatch(etag, inm, true) {
+		l.report(LintConditionalNot304, SeverityWarning,
+			fmt.Sprintf("%d response to a %s whose If-None-Match matches ETag %s", code, req.Method, etag),
+			"answer 304 Not Modified when If-None-Match matches the current ETag")
+	}
+	if req.Method == "CONNECT" && code >= 200 && code <= 299 {
+		for _, name := range []string{"Content-Length", "Transfer-Encoding"} {
+			if resp.Headers.Get(name) != "" {
+				l.report(LintConnectFraming, SeverityError,
+					fmt.Sprintf("%d response to CONNECT has a %s header", code, name),
+					fmt.Sprintf("remove %s; the connection becomes a tunnel", name))
+			}
+		}
+	}
+}
+
+// hasListToken reports whether the comma-separated values list token, in
+// any case.
+func hasListToken(values []string, token string) bool {
+	for _, e := range splitList(values) {
+		if strings.EqualFold(e, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package http
+
+import "testing"
+
+func TestLintMessage_Rules(t *testing.T) {
+	h := func(kv ...string) Headers {
+		var hs Headers
+		for i := 0; i < len(kv); i += 2 {
+			hs = append(hs, Header{Key: kv[i], Value: kv[i+1]})
+		}
+		return hs
+	}
+	host := []string{"Host", "example.com"}
+	tests := []struct {
+		rule string
+		msg  interface{}
+	}{
+		{LintGETWithContentLength, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h(append(host, "Content-Length", "0")...)}},
+		{LintMissingHost, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1"}},
+		{LintMultipleHost, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h(append(host, host...)...)}},
+		{LintMissingContentType, &Request{Method: "POST", Path: "/", Version: "HTTP/1.1", Headers: h(host...), Body: []byte("a=1")}},
+		{LintConnectionUnknownHeader, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h(append(host, "Connection", "keep-alive, X-Trace")...)}},
+		{LintUpgradeWithoutConnection, &Request{Method: "GET", Path: "/chat", Version: "HTTP/1.1", Headers: h(append(host, "Upgrade", "websocket")...)}},
+		{LintTEOnResponse, &Response{Version: "HTTP/1.1", StatusCode: 200, Headers: h("TE", "trailers")}},
+		{LintResponseHeaderOnRequest, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h(append(host, "Age", "60")...)}},
+		{LintLocationStatus, &Response{Version: "HTTP/1.1", StatusCode: 200, Headers: h("Location", "/next")}},
+		{LintChunkedHTTP10, &Response{Version: "HTTP/1.0", StatusCode: 200, Headers: h("Transfer-Encoding", "chunked")}},
+	}
+	for _, tt := range tests {
+		got := LintMessage(tt.msg)
+		if len(got) != 1 || got[0].Rule != tt.rule {
+			t.Errorf("%s: findings = %+v, want exactly that rule", tt.rule, got)
+			continue
+		}
+		if got[0].Message == "" || got[0].Suggestion == "" {
+			t.Errorf("%s: incomplete finding %+v", tt.rule, got[0])
+		}
+		if got := LintMessageWithOptions(tt.msg, LintOptions{Suppress: []string{tt.rule}}); got != nil {
+			t.Errorf("%s suppressed: findings = %+v, want none", tt.rule, got)
+		}
+	}
+}
+
+func TestLintMessage_Clean(t *testing.T) {
+	clean := []string{
+		"POST /items HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 2\r\nConnection: keep-alive\r\n\r\n{}",
+		"GET /chat HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n",
+		"HTTP/1.1 301 Moved Permanently\r\nLocation: /new\r\nContent-Length: 0\r\n\r\n",
+		"HTTP/1.1 201 Created\r\nLocation: /items/7\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+		"GET / HTTP/1.0\r\n\r\n",
+	}
+	for _, raw := range clean {
+		if got := LintMessage(UnmarshalLenient([]byte(raw))); got != nil {
+			t.Errorf("%.30q: findings = %+v, want none", raw, got)
+		}
+	}
+	if got := LintMessage("GET / HTTP/1.1"); got != nil {
+		t.Errorf("string: findings = %+v, want none", got)
+	}
+}
+
+func TestLintExchange_Rules(t *testing.T) {
+	h := func(kv ...string) Headers {
+		var hs Headers
+		for i := 0; i < len(kv); i += 2 {
+			hs = append(hs, Header{Key: kv[i], Value: kv[i+1]})
+		}
+		return hs
+	}
+	get := &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h("Host", "example.com")}
+	tests := []struct {
+		rule string
+		req  *Request
+		resp *Response
+	}{
+		{LintBodyNotAllowed, get, &Response{Version: "HTTP/1.1", StatusCode: 204, Body: []byte("x")}},
+		{LintBodyNotAllowed, get, &Response{Version: "HTTP/1.1", StatusCode: 304, Headers: h("ETag", `"a"`), Body: []byte("x")}},
+		{LintBodyNotAllowed, &Request{Method: "HEAD", Path: "/", Version: "HTTP/1.1"}, &Response{Version: "HTTP/1.1", StatusCode: 200, Body: []byte("hello")}},
+		{LintSwitchingWithoutUpgrade, get, &Response{Version: "HTTP/1.1", StatusCode: 101, Headers: h("Upgrade", "websocket")}},
+		{LintSwitchingWithoutUpgrade, get, &Response{Version: "HTTP/1.1", StatusCode: 101, Headers: h("Connection", "Upgrade")}},
+		{LintMethodNotAllowedNoAllow, &Request{Method: "DELETE", Path: "/", Version: "HTTP/1.1"}, &Response{Version: "HTTP/1.1", StatusCode: 405}},
+		{LintNotModifiedLength, get, &Response{Version: "HTTP/1.1", StatusCode: 304, Headers: h("Content-Length", "0")}},
+		{LintConditionalNot304, &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: h("If-None-Match", `W/"v1", "v2"`)},
+			&Response{Version: "HTTP/1.1", StatusCode: 200, Headers: h("ETag", `"v1"`, "Content-Length", "1"), Body: []byte("x")}},
+		{LintConnectFraming, &Request{Method: "CONNECT", Path: "example.com:443", Version: "HTTP/1.1"},
+			&Response{Version: "HTTP/1.1", StatusCode: 200, Headers: h("Content-Length", "0")}},
+	}
+	for _, tt := range tests {
+		got := LintExchange(tt.req, tt.resp)
+		if len(got) != 1 || got[0].Rule != tt.rule {
+			t.Errorf("%s: findings = %+v, want exactly that rule", tt.rule, got)
+			continue
+		}
+		if got[0].Message == "" || got[0].Suggestion == "" {
+			t.Errorf("%s: incomplete finding %+v", tt.rule, got[0])
+		}
+		if got := LintExchangeWithOptions(tt.req, tt.resp, LintOptions{Suppress: []string{tt.rule}}); got != nil {
+			t.Errorf("%s suppressed: findings = %+v, want none", tt.rule, got)
+		}
+	}
+}
+
+func TestLintExchange_Clean(t *testing.T) {
+	clean := [][2]string{
+		{"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello"},
+		{"GET / HTTP/1.1\r\nHost: example.com\r\nIf-None-Match: \"v1\"\r\n\r\n", "HTTP/1.1 304 Not Modified\r\nETag: \"v1\"\r\n\r\n"},
+		{"GET / HTTP/1.1\r\nHost: example.com\r\nIf-None-Match: \"v1\"\r\n\r\n", "HTTP/1.1 200 OK\r\nETag: \"v2\"\r\nContent-Length: 0\r\n\r\n"},
+		{"HEAD / HTTP/1.1\r\nHost: example.com\r\n\r\n", "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n"},
+		{"GET /chat HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n", "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"},
+		{"PUT / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 0\r\n\r\n", "HTTP/1.1 405 Method Not Allowed\r\nAllow: GET, HEAD\r\nContent-Length: 0\r\n\r\n"},
+		{"CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n", "HTTP/1.1 200 Connection Established\r\n\r\n"},
+	}
+	for _, pair := range clean {
+		req, resp := UnmarshalLenient([]byte(pair[0])).Request, UnmarshalLenient([]byte(pair[1])).Response
+		if req == nil || resp == nil {
+			t.Fatalf("%.30q: did not parse", pair)
+		}
+		if got := LintExchange(req, resp); got != nil {
+			t.Errorf("%.30q: findings = %+v, want none", pair[1], got)
+		}
+	}
+	if got := LintExchange(nil, &Response{StatusCode: 204, Body: []byte("x")}); got != nil {
+		t.Errorf("nil request: findings = %+v, want none", got)
+	}
+}
@@ -2,26 +2,34 @@ package http
 
 import (
 	"fmt"
-	"sync"
+	"strings"
 )
 
-// bufPool pools []byte slices for the encoder fast path.
-var bufPool = sync.Pool{
-	New: func() interface{} {
-		b := make([]byte, 0, 2048)
-		return &b
-	},
-}
-
 // Marshal returns the HTTP/1.1 wire-format encoding of v.
 //
 // v must be a *Request or *Response. If body is present and Content-Length
 // header is absent (and Transfer-Encoding is not chunked), Content-Length
 // is automatically set.
 //
-// Marshal uses a sync.Pool buffer internally for zero-alloc serialization.
+// # Output ordering
+//
+// The output is byte-stable for a given message:
+//
+//  1. the start line ("METHOD PATH VERSION" or "VERSION STATUS REASON"),
+//  2. every entry of Headers, in slice order, as "Key: Value",
+//  3. any headers Marshal adds itself (currently only Content-Length),
+//     always after the caller's headers,
+//  4. an empty line, then the body verbatim.
+//
+// Every line ends in a single "\r\n" and Marshal adds no whitespace beyond
+// the single SP after the status code that RFC 9112 requires even when
+// Reason is empty. Marshal never synthesizes Host; callers supply it.
+//
+// Marshal sizes its buffer with WireSize and so allocates once;
+// AppendRequest and AppendResponse write into a buffer the caller owns
+// instead.
 func Marshal(v interface{}) ([]byte, error) {
-	if v == nil {
+	if v == nil || v == (*Request)(nil) || v == (*Response)(nil) {
 		return nil, fmt.Errorf("http: Marshal(nil)")
 	}
 
@@ -30,29 +38,251 @@ func Marshal(v interface{}) ([]byte, error) {
 		return m.MarshalHTTP()
 	}
 
-	bp := bufPool.Get().(*[]byte)
-	buf := (*bp)[:0]
-
+	var buf []byte
 	var err error
 	switch msg := v.(type) {
 	case *Request:
-		buf, err = appendRequest(buf, msg)
-		if err != nil {
-			*bp = buf
-			bufPool.Put(bp)
-			return nil, err
-		}
+		buf, err = AppendRequest(make([]byte, 0, msg.WireSize()), msg)
 	case *Response:
-		buf = appendResponse(buf, msg)
+		buf, err = AppendResponse(make([]byte, 0, msg.WireSize()), msg)
 	default:
-		*bp = buf
-		bufPool.Put(bp)
-		return nil, fmt.Errorf("http: Marshal unsupported type %T (expected *Request or *Response)", v)
+		err = fmt.Errorf("http: Marshal unsupported type %T (expected *Request or *Response)", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// AppendRequest appends the wire-format encoding of req to dst, as Marshal
+// would produce it, and returns the extended buffer. It allocates only
+// when dst lacks the capacity, so a buffer reused across calls makes
+// serialization allocation-free. On error dst is returned unchanged.
+func AppendRequest(dst []byte, req *Request) ([]byte, error) {
+	if req == nil {
+		return dst, fmt.Errorf("http: AppendRequest(nil)")
+	}
+	return appendRequest(dst, req)
+}
+
+// AppendResponse appends the wire-format encoding of resp to dst; see
+// AppendRequest.
+func AppendResponse(dst []byte, resp *Response) ([]byte, error) {
+	if resp == nil {
+		return dst, fmt.Errorf("http: AppendResponse(nil)")
+	}
+	return appendResponse(dst, resp), nil
+}
+
+// WireSize returns the length of the encoding Marshal produces for r,
+// added Content-Length included, without building it. It is 0 for a nil
+// Request and meaningless for one Marshal rejects.
+func (r *Request) WireSize() int {
+	if r == nil {
+		return 0
+	}
+	return requestWireSize(r)
+}
+
+// WireSize returns the length of the encoding Marshal produces for r; see
+// Request.WireSize.
+func (r *Response) WireSize() int {
+	if r == nil {
+		return 0
+	}
+	return responseWireSize(r)
+}
+
+// MarshalOptions controls MarshalWithOptions.
+type MarshalOptions struct {
+	// UseRawBody writes a message that carries a RawBody with its original
+	// framing: RawBody replaces Body and, when set, RawHeaders replaces
+	// Headers. A message parsed with KeepRawBody then marshals to the
+	// bytes it was parsed from. Messages without a RawBody are unaffected.
+	UseRawBody bool
+
+	// MaxLineLength, when positive, limits header lines to that many bytes
+	// ("Name: value", not counting the CRLF). A longer value of a
+	// comma-separated list header is split between list elements into
+	// several lines with the same name, which RFC 9110 §5.3 makes
+	// equivalent to the single line, so Headers.Values and the parser see
+	// the same elements. A longer value of any other header, or a single
+	// list element that alone exceeds the limit, is an error: obs-fold is
+	// deprecated and recipients may reject or misread it.
+	MaxLineLength int
+
+	// ListHeaders names additional headers, matched case-insensitively,
+	// that MaxLineLength may split. Accept, Cache-Control, Via and the
+	// other list-valued headers of RFC 9110 are always splittable.
+	ListHeaders []string
+}
+
+// listHeaders are the comma-separated list headers that MaxLineLength may
+// split. Set-Cookie is absent on purpose: its values may contain commas.
+var listHeaders = map[string]bool{
+	"accept":                         true,
+	"accept-charset":                 true,
+	"accept-encoding":                true,
+	"accept-language":                true,
+	"accept-ranges":                  true,
+	"access-control-allow-headers":   true,
+	"access-control-allow-methods":   true,
+	"access-control-expose-headers":  true,
+	"access-control-request-headers": true,
+	"allow":                          true,
+	"cache-control":                  true,
+	"connection":                     true,
+	"content-encoding":               true,
+	"content-language":               true,
+	"forwarded":                      true,
+	"if-match":                       true,
+	"if-none-match":                  true,
+	"link":                           true,
+	"pragma":                         true,
+	"te":                             true,
+	"trailer":                        true,
+	"upgrade":                        true,
+	"vary":                           true,
+	"via":                            true,
+	"x-forwarded-for":                true,
+}
+
+// MarshalWithOptions is Marshal with explicit options.
+func MarshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	if opts.UseRawBody {
+		switch msg := v.(type) {
+		case *Request:
+			if msg != nil && msg.RawBody != nil {
+				raw := *msg
+				raw.Body, raw.Headers = msg.RawBody, rawFramingHeaders(msg.RawHeaders, msg.Headers)
+				v = &raw
+			}
+		case *Response:
+			if msg != nil && msg.RawBody != nil {
+				raw := *msg
+				raw.Body, raw.Headers = msg.RawBody, rawFramingHeaders(msg.RawHeaders, msg.Headers)
+				v = &raw
+			}
+		}
 	}
+	if opts.MaxLineLength > 0 {
+		switch msg := v.(type) {
+		case *Request:
+			if msg != nil {
+				headers, err := splitLongHeaders(msg.Headers, opts)
+				if err != nil {
+					return nil, err
+				}
+				folded := *msg
+				folded.Headers = headers
+				v = &folded
+			}
+		case *Response:
+			if msg != nil {
+				headers, err := splitLongHeaders(msg.Headers, opts)
+				if err != nil {
+					return nil, err
+				}
+				folded := *msg
+				folded.Headers = headers
+				v = &folded
+			}
+		}
+	}
+	return Marshal(v)
+}
 
-	result := make([]byte, len(buf))
-	copy(result, buf)
-	*bp = buf
-	bufPool.Put(bp)
-	return result, nil
+// splitLongHeaders applies opts.MaxLineLength to headers, returning
+// headers itself when every line fits.
+func splitLongHeaders(headers Headers, opts MarshalOptions) (Headers, error) {
+	limit := opts.MaxLineLength
+	var out Headers
+	for i, h := range headers {
+		if len(h.Key)+2+len(h.Value) <= limit {
+			if out != nil {
+				out = append(out, h)
+			}
+			continue
+		}
+		if !isListHeader(h.Key, opts.ListHeaders) {
+			return nil, fmt.Errorf("http: header %s is %d bytes, over MaxLineLength %d, and cannot be split", h.Key, len(h.Key)+2+len(h.Value), limit)
+		}
+		if out == nil {
+			out = append(make(Headers, 0, len(headers)+1), headers[:i]...)
+		}
+		line := ""
+		for _, elem := range splitListElements(h.Value) {
+			if len(h.Key)+2+len(elem) > limit {
+				return nil, fmt.Errorf("http: header %s has a %d-byte list element, over MaxLineLength %d", h.Key, len(elem), limit)
+			}
+			if line != "" && len(h.Key)+2+len(line)+2+len(elem) > limit {
+				out = append(out, Header{Key: h.Key, Value: line})
+				line = ""
+			}
+			if line != "" {
+				line += ", "
+			}
+			line += elem
+		}
+		if line != "" {
+			out = append(out, Header{Key: h.Key, Value: line})
+		}
+	}
+	if out == nil {
+		return headers, nil
+	}
+	return out, nil
+}
+
+func isListHeader(key string, extra []string) bool {
+	if listHeaders[strings.ToLower(key)] {
+		return true
+	}
+	for _, name := range extra {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitListElements splits a list header value at commas outside quoted
+// strings and outside the <URI-Reference> of a Link value (RFC 8288 §3),
+// trimming whitespace and dropping empty elements (RFC 9110 §5.6.1).
+func splitListElements(value string) []string {
+	var elems []string
+	start, quoted, inURI := 0, false, false
+	for i := 0; i <= len(value); i++ {
+		if i < len(value) {
+			switch c := value[i]; {
+			case inURI:
+				inURI = c != '>'
+				continue
+			case c == '\\' && quoted && i+1 < len(value):
+				i++
+				continue
+			case c == '"':
+				quoted = !quoted
+				continue
+			case c == '<' && !quoted:
+				inURI = true
+				continue
+			case c != ',' || quoted:
+				continue
+			}
+		}
+		if elem := strings.Trim(value[start:i], " \t"); elem != "" {
+			elems = append(elems, elem)
+		}
+		start = i + 1
+	}
+	return elems
+}
+
+// rawFramingHeaders returns raw when the parser recorded it, else headers.
+func rawFramingHeaders(raw, headers Headers) Headers {
+	if raw != nil {
+		return raw
+	}
+	return headers
 }
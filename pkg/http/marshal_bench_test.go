@@ -97,3 +97,47 @@ func BenchmarkMarshal_LargeHeaders(b *testing.B) {
 		}
 	}
 }
+
+// appendBenchRequest is a 20-header request with a 4 KB body.
+func appendBenchRequest() *Request {
+	headers := make(Headers, 20)
+	for i := range headers {
+		headers[i] = Header{
+			Key:   "X-Custom-Header-" + string(rune('A'+i)),
+			Value: "some-value-that-is-reasonably-long-for-benchmarking",
+		}
+	}
+	return &Request{
+		Method:  "POST",
+		Path:    "/api/upload",
+		Version: "HTTP/1.1",
+		Headers: headers,
+		Body:    make([]byte, 4096),
+	}
+}
+
+// BenchmarkMarshal_LargeRequest and BenchmarkAppendRequest_LargeRequest
+// show the copy Marshal makes that a reused AppendRequest buffer avoids.
+func BenchmarkMarshal_LargeRequest(b *testing.B) {
+	req := appendBenchRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendRequest_LargeRequest(b *testing.B) {
+	req := appendBenchRequest()
+	var buf []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = AppendRequest(buf[:0], req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -82,6 +84,25 @@ func TestMarshal_Request_WithExplicitContentLength(t *testing.T) {
 	}
 }
 
+func TestMarshal_EmptyContentLength(t *testing.T) {
+	// curl -H 'Content-Length:' gives a present but empty header; Marshal
+	// must not add a second one.
+	result := ParseCurl("curl -H 'Content-Length:' -d 'abc' http://example.com/")
+	if result.Request == nil {
+		t.Fatalf("ParseCurl: %q", result.Warnings)
+	}
+	for _, msg := range []interface{}{result.Request, &Response{StatusCode: 200, Headers: Headers{{Key: "Content-Length", Value: ""}}, Body: []byte("abc")}} {
+		data, err := Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(strings.ToLower(string(data)), "content-length:"); n != 1 {
+			t.Errorf("Marshal() = %q, want one Content-Length line", data)
+		}
+		checkWireSize(t, msg)
+	}
+}
+
 func TestMarshal_Request_DefaultVersion(t *testing.T) {
 	req := &Request{
 		Method: "GET",
@@ -218,9 +239,11 @@ func TestMarshal_Response_Chunked_NoAutoContentLength(t *testing.T) {
 }
 
 func TestMarshal_Nil(t *testing.T) {
-	_, err := Marshal(nil)
-	if err == nil {
-		t.Error("Marshal(nil) expected error")
+	for _, v := range []interface{}{nil, (*Request)(nil), (*Response)(nil)} {
+		_, err := Marshal(v)
+		if err == nil || err.Error() != "http: Marshal(nil)" {
+			t.Errorf("Marshal(%#v) error = %v, want http: Marshal(nil)", v, err)
+		}
 	}
 }
 
@@ -249,3 +272,323 @@ type mockMarshaler struct {
 func (m *mockMarshaler) MarshalHTTP() ([]byte, error) {
 	return m.data, nil
 }
+
+// ── Golden output ──────────────────────────────────────────────────────────
+
+// TestMarshal_Golden pins Marshal's exact bytes for each framing mode. Any
+// change that alters these bytes breaks the documented ordering contract
+// and must update the goldens deliberately.
+func TestMarshal_Golden(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  interface{}
+		want string
+	}{
+		{
+			name: "GET no body",
+			msg: &Request{Method: "GET", Path: "/", Headers: Headers{
+				{Key: "Host", Value: "example.com"},
+				{Key: "Accept", Value: "*/*"},
+			}},
+			want: "GET / HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"Accept: */*\r\n" +
+				"\r\n",
+		},
+		{
+			name: "POST auto Content-Length after caller headers",
+			msg: &Request{Method: "POST", Path: "/submit", Headers: Headers{
+				{Key: "Host", Value: "example.com"},
+				{Key: "Content-Type", Value: "application/json"},
+			}, Body: []byte(`{"a":1}`)},
+			want: "POST /submit HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"Content-Type: application/json\r\n" +
+				"Content-Length: 7\r\n" +
+				"\r\n" +
+				`{"a":1}`,
+		},
+		{
+			name: "POST explicit Content-Length keeps position",
+			msg: &Request{Method: "POST", Path: "/submit", Headers: Headers{
+				{Key: "Content-Length", Value: "2"},
+				{Key: "Host", Value: "example.com"},
+			}, Body: []byte("hi")},
+			want: "POST /submit HTTP/1.1\r\n" +
+				"Content-Length: 2\r\n" +
+				"Host: example.com\r\n" +
+				"\r\n" +
+				"hi",
+		},
+		{
+			name: "chunked response body passed through",
+			msg: &Response{StatusCode: 200, Reason: "OK", Headers: Headers{
+				{Key: "Transfer-Encoding", Value: "chunked"},
+			}, Body: []byte("5\r\nHello\r\n0\r\n\r\n")},
+			want: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nHello\r\n0\r\n\r\n",
+		},
+		{
+			name: "204 empty body",
+			msg:  &Response{StatusCode: 204, Reason: "No Content", Headers: Headers{{Key: "Date", Value: "Sun, 06 Nov 1994 08:49:37 GMT"}}},
+			want: "HTTP/1.1 204 No Content\r\n" +
+				"Date: Sun, 06 Nov 1994 08:49:37 GMT\r\n" +
+				"\r\n",
+		},
+		{
+			name: "empty reason keeps required SP",
+			msg:  &Response{StatusCode: 204},
+			want: "HTTP/1.1 204 \r\n\r\n",
+		},
+		{
+			name: "UTF-8 header value",
+			msg: &Response{StatusCode: 200, Reason: "OK", Headers: Headers{
+				{Key: "X-Greeting", Value: "héllo wörld ✓"},
+			}},
+			want: "HTTP/1.1 200 OK\r\n" +
+				"X-Greeting: héllo wörld ✓\r\n" +
+				"\r\n",
+		},
+		{
+			name: "empty Headers slice",
+			msg:  &Request{Method: "GET", Path: "/", Headers: Headers{}},
+			want: "GET / HTTP/1.1\r\n\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() =\n%q\nwant:\n%q", string(data), tt.want)
+			}
+		})
+	}
+}
+
+// ── MaxLineLength ───────────────────────────────────────────────────────────
+
+func TestMarshalWithOptions_MaxLineLength_SplitsLists(t *testing.T) {
+	// A 20 KB Accept value of 500 media ranges.
+	var ranges []string
+	for i := 0; i < 500; i++ {
+		ranges = append(ranges, fmt.Sprintf("application/vnd.example.v%d+json;q=0.%03d", i, 999-i))
+	}
+	accept := strings.Join(ranges, ", ")
+	if len(accept) < 20000 {
+		t.Fatalf("test value is %d bytes, want at least 20 KB", len(accept))
+	}
+	req := &Request{Method: "GET", Path: "/", Headers: Headers{
+		{Key: "Host", Value: "example.com"},
+		{Key: "Accept", Value: accept},
+		{Key: "X-Tags", Value: `a, "quoted, with comma", b`},
+		{Key: "X-Trace", Value: "short"},
+	}}
+
+	data, err := MarshalWithOptions(req, MarshalOptions{MaxLineLength: 8192, ListHeaders: []string{"x-tags"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\r\n\r\n"), "\r\n")
+	acceptLines := 0
+	for _, line := range lines {
+		if len(line) > 8192 {
+			t.Errorf("line of %d bytes exceeds the limit", len(line))
+		}
+		if strings.HasPrefix(line, "Accept: ") {
+			acceptLines++
+		}
+	}
+	if acceptLines != 3 {
+		t.Errorf("Accept split into %d lines, want 3", acceptLines)
+	}
+	if lines[len(lines)-1] != "X-Trace: short" {
+		t.Errorf("last line = %q; header order not kept", lines[len(lines)-1])
+	}
+	if len(req.Headers) != 4 {
+		t.Error("MarshalWithOptions modified the caller's headers")
+	}
+
+	parsed, err := UnmarshalRequest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(parsed.Headers.Values("Accept"), ", "); got != accept {
+		t.Error("rejoined Accept values differ from the original")
+	}
+
+	// A limit small enough to split X-Tags keeps the quoted element whole.
+	data, err = MarshalWithOptions(&Request{Method: "GET", Path: "/", Headers: Headers{req.Headers[2]}}, MarshalOptions{MaxLineLength: 30, ListHeaders: []string{"X-TAGS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET / HTTP/1.1\r\nX-Tags: a\r\nX-Tags: \"quoted, with comma\"\r\nX-Tags: b\r\n\r\n"
+	if string(data) != want {
+		t.Errorf("MarshalWithOptions =\n%q\nwant\n%q", data, want)
+	}
+
+	// A comma inside a Link <URI-Reference> does not end the element.
+	link := Header{Key: "Link", Value: `</a?x=1,2>; rel="next", </b>; rel="prev"`}
+	data, err = MarshalWithOptions(&Request{Method: "GET", Path: "/", Headers: Headers{link}}, MarshalOptions{MaxLineLength: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "GET / HTTP/1.1\r\nLink: </a?x=1,2>; rel=\"next\"\r\nLink: </b>; rel=\"prev\"\r\n\r\n"
+	if string(data) != want {
+		t.Errorf("MarshalWithOptions =\n%q\nwant\n%q", data, want)
+	}
+}
+
+func TestMarshalWithOptions_MaxLineLength_Unsplittable(t *testing.T) {
+	token := strings.Repeat("x", 9*1024)
+	resp := &Response{StatusCode: 200, Reason: "OK", Headers: Headers{{Key: "Authorization", Value: "Bearer " + token}}}
+	_, err := MarshalWithOptions(resp, MarshalOptions{MaxLineLength: 8192})
+	if err == nil || !strings.Contains(err.Error(), "Authorization") || !strings.Contains(err.Error(), "9238 bytes") {
+		t.Errorf("err = %v, want an error naming Authorization and its length", err)
+	}
+
+	// A single list element over the limit cannot be split either.
+	req := &Request{Method: "GET", Path: "/", Headers: Headers{{Key: "Accept", Value: "text/html, " + token}}}
+	if _, err := MarshalWithOptions(req, MarshalOptions{MaxLineLength: 8192}); err == nil {
+		t.Error("oversized list element: succeeded, want error")
+	}
+
+	// Without MaxLineLength nothing is checked.
+	if _, err := MarshalWithOptions(resp, MarshalOptions{}); err != nil {
+		t.Errorf("no limit: %v", err)
+	}
+}
+
+func TestAppendRequest(t *testing.T) {
+	req := &Request{Method: "POST", Path: "/items", Headers: Headers{{Key: "Host", Value: "example.com"}}, Body: []byte("hi")}
+	want, err := Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := []byte("prefix|")
+	got, err := AppendRequest(dst, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "prefix|"+string(want) {
+		t.Errorf("AppendRequest = %q, want %q", got, "prefix|"+string(want))
+	}
+
+	if got, err := AppendRequest(dst, &Request{Path: "/"}); err == nil || string(got) != "prefix|" {
+		t.Errorf("AppendRequest(empty method) = %q, %v; want dst unchanged and an error", got, err)
+	}
+	if _, err := AppendRequest(nil, nil); err == nil {
+		t.Error("AppendRequest(nil) expected error")
+	}
+}
+
+func TestAppendResponse(t *testing.T) {
+	resp := &Response{StatusCode: 204, Reason: "No Content"}
+	got, err := AppendResponse([]byte("x"), resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "xHTTP/1.1 204 No Content\r\n\r\n"; string(got) != want {
+		t.Errorf("AppendResponse = %q, want %q", got, want)
+	}
+	if _, err := AppendResponse(nil, nil); err == nil {
+		t.Error("AppendResponse(nil) expected error")
+	}
+}
+
+func TestAppendRequest_NoAllocs(t *testing.T) {
+	req := appendBenchRequest()
+	buf := make([]byte, 0, 8192)
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		if buf, err = AppendRequest(buf[:0], req); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("AppendRequest allocates %v times with a large enough buffer, want 0", allocs)
+	}
+	resp := &Response{StatusCode: 200, Reason: "OK", Headers: req.Headers, Body: req.Body}
+	allocs = testing.AllocsPerRun(100, func() {
+		buf, _ = AppendResponse(buf[:0], resp)
+	})
+	if allocs != 0 {
+		t.Errorf("AppendResponse allocates %v times with a large enough buffer, want 0", allocs)
+	}
+}
+
+// TestWireSize_Seeds checks WireSize against Marshal for every parsed seed.
+func TestWireSize_Seeds(t *testing.T) {
+	for _, seed := range requestSeeds {
+		result := UnmarshalLenient(seed)
+		if result.Request == nil {
+			continue
+		}
+		checkWireSize(t, result.Request)
+	}
+	for _, seed := range responseSeeds {
+		result := UnmarshalLenient(seed)
+		if result.Response == nil {
+			continue
+		}
+		checkWireSize(t, result.Response)
+	}
+}
+
+func TestWireSize(t *testing.T) {
+	tests := []interface{}{
+		&Request{Method: "GET", Path: "/"},
+		&Request{Method: "POST", Path: "/p", Version: "HTTP/1.0", Body: []byte("0123456789")},
+		&Request{Method: "POST", Path: "/p", Headers: Headers{{Key: "Content-Length", Value: "3"}}, Body: []byte("abc")},
+		&Request{Method: "POST", Path: "/p", Headers: Headers{{Key: "Transfer-Encoding", Value: "chunked"}}, Body: []byte("3\r\nabc\r\n0\r\n\r\n")},
+		&Response{StatusCode: 200},
+		&Response{StatusCode: 404, Reason: "Not Found", Body: make([]byte, 100000)},
+		&Response{StatusCode: -1, Version: "HTTP/2"},
+		&Response{StatusCode: 99999, Headers: Headers{{Key: "", Value: ""}}},
+	}
+	for _, msg := range tests {
+		checkWireSize(t, msg)
+	}
+	if n := (*Request)(nil).WireSize(); n != 0 {
+		t.Errorf("nil Request WireSize = %d, want 0", n)
+	}
+	if n := (*Response)(nil).WireSize(); n != 0 {
+		t.Errorf("nil Response WireSize = %d, want 0", n)
+	}
+}
+
+func TestMarshal_AllocatesOnce(t *testing.T) {
+	req := appendBenchRequest()
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := Marshal(req); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("Marshal allocates %v times, want 1", allocs)
+	}
+}
+
+// checkWireSize reports an error if msg's WireSize differs from the length
+// of its encoding, when Marshal accepts it.
+func checkWireSize(t *testing.T, msg interface{}) {
+	t.Helper()
+	data, err := Marshal(msg)
+	if err != nil {
+		return
+	}
+	var size int
+	switch m := msg.(type) {
+	case *Request:
+		size = m.WireSize()
+	case *Response:
+		size = m.WireSize()
+	}
+	if size != len(data) || cap(data) != len(data) {
+		t.Errorf("%q: WireSize = %d, Marshal len %d cap %d", data, size, len(data), cap(data))
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxForwards returns the request's Max-Forwards value (RFC 9110 §7.6.2),
+// the number of further times a TRACE or OPTIONS request may be forwarded.
+// ok is false if the header is absent or not a non-negative decimal
+// integer.
+func (r *Request) MaxForwards() (int, bool) {
+	v := strings.TrimSpace(r.Headers.Get("Max-Forwards"))
+	if v == "" || strings.TrimLeft(v, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// DecrementMaxForwards lowers Max-Forwards by one, as a proxy does before
+// forwarding the request. It fails when the value is 0, in which case the
+// proxy must answer the request itself, or when the header is present but
+// invalid. A request without Max-Forwards is left unchanged.
+func (r *Request) DecrementMaxForwards() error {
+	if r.Headers.Get("Max-Forwards") == "" {
+		return nil
+	}
+	n, ok := r.MaxForwards()
+	if !ok {
+		return fmt.Errorf("http: invalid Max-Forwards %q", r.Headers.Get("Max-Forwards"))
+	}
+	if n == 0 {
+		return fmt.Errorf("http: Max-Forwards is 0; the request must not be forwarded")
+	}
+	r.Headers.Set("Max-Forwards", strconv.Itoa(n-1))
+	return nil
+}
+
+// AllowedMethods returns the methods listed in the response's Allow
+// headers, uppercased, without duplicates and in order of first
+// appearance. It returns nil if there is no Allow header or it is empty.
+func (r *Response) AllowedMethods() []string {
+	var methods []string
+	for _, v := range r.Headers.Values("Allow") {
+		for _, m := range splitListElements(v) {
+			if m = strings.ToUpper(m); !containsString(methods, m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods
+}
+
+// SupportsMethod reports whether method, compared case-insensitively, is
+// one of the response's AllowedMethods.
+func (r *Response) SupportsMethod(method string) bool {
+	return containsString(r.AllowedMethods(), strings.ToUpper(method))
+}
+
+// containsString reports whether list contains s (case-sensitive).
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
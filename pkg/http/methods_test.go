@@ -0,0 +1,78 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequest_MaxForwards(t *testing.T) {
+	tests := []struct {
+		value string
+		n     int
+		ok    bool
+	}{
+		{"10", 10, true},
+		{" 3 ", 3, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"ten", 0, false},
+		{"-1", 0, false},
+		{"+5", 0, false},
+		{"99999999999999999999", 0, false},
+	}
+	for _, tt := range tests {
+		r := &Request{Method: "TRACE", Path: "/"}
+		if tt.value != "" {
+			r.Headers.Set("Max-Forwards", tt.value)
+		}
+		if n, ok := r.MaxForwards(); n != tt.n || ok != tt.ok {
+			t.Errorf("Max-Forwards %q: MaxForwards() = %d, %v; want %d, %v", tt.value, n, ok, tt.n, tt.ok)
+		}
+	}
+}
+
+func TestRequest_DecrementMaxForwards(t *testing.T) {
+	r := &Request{Method: "OPTIONS", Path: "*", Headers: Headers{{Key: "Max-Forwards", Value: "1"}}}
+	if err := r.DecrementMaxForwards(); err != nil || r.Headers.Get("Max-Forwards") != "0" {
+		t.Fatalf("err = %v, Max-Forwards = %q; want nil, 0", err, r.Headers.Get("Max-Forwards"))
+	}
+	if err := r.DecrementMaxForwards(); err == nil || r.Headers.Get("Max-Forwards") != "0" {
+		t.Errorf("at zero: err = %v, Max-Forwards = %q; want an error and 0 kept", err, r.Headers.Get("Max-Forwards"))
+	}
+
+	r.Headers.Set("Max-Forwards", "many")
+	if err := r.DecrementMaxForwards(); err == nil || !strings.Contains(err.Error(), `"many"`) {
+		t.Errorf("invalid: err = %v", err)
+	}
+	if err := (&Request{Method: "TRACE"}).DecrementMaxForwards(); err != nil {
+		t.Errorf("absent: err = %v, want nil", err)
+	}
+}
+
+func TestResponse_AllowedMethods(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+	}{
+		{[]string{"GET, POST,PUT"}, []string{"GET", "POST", "PUT"}},
+		{[]string{"GET,HEAD", " get ,  OPTIONS\t"}, []string{"GET", "HEAD", "OPTIONS"}},
+		{[]string{",, delete ,"}, []string{"DELETE"}},
+		{[]string{""}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		resp := &Response{StatusCode: 405}
+		for _, v := range tt.values {
+			resp.Headers.Add("Allow", v)
+		}
+		if got := resp.AllowedMethods(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Allow %q: AllowedMethods() = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+
+	resp := &Response{StatusCode: 200, Headers: Headers{{Key: "Allow", Value: "GET, POST,PUT"}}}
+	if !resp.SupportsMethod("post") || !resp.SupportsMethod("PUT") || resp.SupportsMethod("DELETE") {
+		t.Errorf("SupportsMethod: post %v, PUT %v, DELETE %v", resp.SupportsMethod("post"), resp.SupportsMethod("PUT"), resp.SupportsMethod("DELETE"))
+	}
+}
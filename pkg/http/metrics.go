@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives counters and timings from the parsing entry points
+// once installed with SetMetrics. Its methods may be called from many
+// goroutines at once. The names reported are:
+//
+//	strict.success, strict.failure    Unmarshal, UnmarshalRequest and the
+//	strict.failure.<category>         other strict parsers, per message
+//	strict.bytes, strict.duration
+//	lenient.parses, lenient.partial   UnmarshalLenient and ParseBatch
+//	lenient.warnings.<kind>
+//	lenient.bytes, lenient.duration
+//	curl.parses, curl.verdict.<verdict>
+//	curl.bytes, curl.duration         ParseCurl and ParseCurlWithOptions
+//	decode.success, decode.failure    Decoder, per message
+//	decode.failure.<category>, decode.duration
+//
+// A category is the first of the error categories the error is in, such
+// as malformed_header for ErrMalformedHeader, or other; a kind is the
+// label of a lenient warning kind and a verdict that of a CurlVerdict,
+// each lowercased with runs of other characters than letters and digits
+// made "_", as in lenient.warnings.malformed_header_no_colon. The bytes
+// counters add the length of the input; the Decoder reports no bytes.
+type Metrics interface {
+	CounterAdd(name string, delta int64)
+	ObserveDuration(name string, d time.Duration)
+}
+
+// metricsSink holds the installed Metrics; a nil *metricsSink reports
+// nothing, so the entry points pay one atomic load when none is set.
+type metricsSink struct{ m Metrics }
+
+var installedMetrics atomic.Pointer[metricsSink]
+
+// SetMetrics installs m to receive the counters and timings described on
+// Metrics from then on, for the whole package. SetMetrics(nil) turns
+// reporting off again, which is the default.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		installedMetrics.Store(nil)
+		return
+	}
+	installedMetrics.Store(&metricsSink{m})
+}
+
+func currentMetrics() *metricsSink { return installedMetrics.Load() }
+
+// now is time.Now when reporting, so that unreported parses skip the
+// clock.
+func (s *metricsSink) now() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// outcome reports a strict parse or decode of n bytes, the prefix naming
+// which, that started at start.
+func (s *metricsSink) outcome(prefix string, start time.Time, n int, err error) {
+	if s == nil {
+		return
+	}
+	if err == nil {
+		s.m.CounterAdd(prefix+".success", 1)
+	} else {
+		s.m.CounterAdd(prefix+".failure", 1)
+		s.m.CounterAdd(prefix+".failure."+errorCategory(err), 1)
+	}
+	if n >= 0 {
+		s.m.CounterAdd(prefix+".bytes", int64(n))
+	}
+	s.m.ObserveDuration(prefix+".duration", time.Since(start))
+}
+
+// failed reports err, returned before any parsing, like outcome, and
+// returns it.
+func (s *metricsSink) failed(prefix string, n int, err error) error {
+	s.outcome(prefix, s.now(), n, err)
+	return err
+}
+
+// lenient reports a lenient parse of n bytes and its warnings by kind.
+func (s *metricsSink) lenient(start time.Time, n int, r *ParseResult, counts map[string]int) {
+	if s == nil {
+		return
+	}
+	s.m.CounterAdd("lenient.parses", 1)
+	if r.Partial {
+		s.m.CounterAdd("lenient.partial", 1)
+	}
+	for kind, c := range counts {
+		s.m.CounterAdd("lenient.warnings."+metricName(kind), int64(c))
+	}
+	s.m.CounterAdd("lenient.bytes", int64(n))
+	s.m.ObserveDuration("lenient.duration", time.Since(start))
+}
+
+// curl reports a ParseCurl of an n-byte command.
+func (s *metricsSink) curl(start time.Time, n int, r *ParseResult) {
+	if s == nil {
+		return
+	}
+	s.m.CounterAdd("curl.parses", 1)
+	s.m.CounterAdd("curl.verdict."+metricName(r.Verdict.String()), 1)
+	s.m.CounterAdd("curl.bytes", int64(n))
+	s.m.ObserveDuration("curl.duration", time.Since(start))
+}
+
+// errorCategories name the error categories for metrics, the more
+// specific first.
+var errorCategories = []struct {
+	err  error
+	name string
+}{
+	{ErrBareLineEnding, "bare_line_ending"},
+	{ErrWhitespaceBeforeColon, "whitespace_before_colon"},
+	{ErrMalformedStartLine, "malformed_start_line"},
+	{ErrMalformedHeader, "malformed_header"},
+	{ErrBodyTruncated, "body_truncated"},
+	{ErrInvalidChunk, "invalid_chunk"},
+	{ErrInvalidStatusCode, "invalid_status_code"},
+	{ErrUnsupportedType, "unsupported_type"},
+}
+
+func errorCategory(err error) string {
+	for _, c := range errorCategories {
+		if errors.Is(err, c.err) {
+			return c.name
+		}
+	}
+	return "other"
+}
+
+// metricName lowercases s and turns each run of characters other than
+// ASCII letters and digits into one "_", trimming them at the ends.
+func metricName(s string) string {
+	var b strings.Builder
+	sep := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if 'a' <= c && c <= 'z' || '0' <= c && c <= '9' {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteByte(c)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
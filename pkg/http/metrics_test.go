@@ -0,0 +1,153 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMetrics records what the entry points report.
+type fakeMetrics struct {
+	mu        sync.Mutex
+	counters  map[string]int64
+	durations map[string]int
+}
+
+func (f *fakeMetrics) CounterAdd(name string, delta int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counters[name] += delta
+}
+
+func (f *fakeMetrics) ObserveDuration(name string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.durations[name]++
+}
+
+func withFakeMetrics(t *testing.T) *fakeMetrics {
+	f := &fakeMetrics{counters: make(map[string]int64), durations: make(map[string]int)}
+	SetMetrics(f)
+	t.Cleanup(func() { SetMetrics(nil) })
+	return f
+}
+
+func TestMetrics_Strict(t *testing.T) {
+	f := withFakeMetrics(t)
+	ok := "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	bad := "GET / HTTP/1.1\r\nHost: example.com\r\nno colon\r\n\r\n"
+	if _, err := UnmarshalRequest([]byte(ok)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalRequest([]byte(bad)); err == nil {
+		t.Fatal("want an error")
+	}
+	want := map[string]int64{
+		"strict.success":                  1,
+		"strict.failure":                  1,
+		"strict.failure.malformed_header": 1,
+		"strict.bytes":                    int64(len(ok) + len(bad)),
+	}
+	if !reflect.DeepEqual(f.counters, want) {
+		t.Errorf("counters = %v, want %v", f.counters, want)
+	}
+	if f.durations["strict.duration"] != 2 || len(f.durations) != 1 {
+		t.Errorf("durations = %v, want strict.duration twice", f.durations)
+	}
+}
+
+func TestMetrics_UnsupportedType(t *testing.T) {
+	f := withFakeMetrics(t)
+	data := []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	var s string
+	if err := Unmarshal(data, &s); err == nil {
+		t.Fatal("Unmarshal: want an error")
+	}
+	if err := NewDecoder(strings.NewReader(string(data))).Decode(&s); err == nil {
+		t.Fatal("Decode: want an error")
+	}
+	for _, name := range []string{"strict.failure", "strict.failure.unsupported_type", "decode.failure", "decode.failure.unsupported_type"} {
+		if f.counters[name] != 1 {
+			t.Errorf("%s = %d, want 1 (all: %v)", name, f.counters[name], f.counters)
+		}
+	}
+}
+
+func TestMetrics_Lenient(t *testing.T) {
+	f := withFakeMetrics(t)
+	input := "GET / HTTP/1.1\r\nHost: example.com\r\nbad one\r\nbad two\r\n\r\n"
+	r := UnmarshalLenient([]byte(input))
+	if len(r.Warnings) != 2 {
+		t.Fatalf("warnings = %q, want 2", r.Warnings)
+	}
+	want := map[string]int64{
+		"lenient.parses": 1,
+		"lenient.warnings.malformed_header_no_colon": 2,
+		"lenient.bytes": int64(len(input)),
+	}
+	if !reflect.DeepEqual(f.counters, want) {
+		t.Errorf("counters = %v, want %v", f.counters, want)
+	}
+	if f.durations["lenient.duration"] != 1 {
+		t.Errorf("durations = %v", f.durations)
+	}
+}
+
+func TestMetrics_CurlAndDecoder(t *testing.T) {
+	f := withFakeMetrics(t)
+	ParseCurl("curl https://example.com/")
+	ParseCurl("please send me the logs")
+	dec := NewDecoder(strings.NewReader("GET / HTTP/1.1\r\nHost: a\r\n\r\nGET / HTTP/1.1\r\nbad\r\n\r\n"))
+	if _, err := dec.DecodeRequest(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.DecodeRequest(); err == nil {
+		t.Fatal("want an error")
+	}
+	dec = NewDecoder(strings.NewReader(""))
+	if _, err := dec.DecodeRequest(); err == nil {
+		t.Fatal("want EOF")
+	}
+	for name, n := range map[string]int64{
+		"curl.parses":                     2,
+		"curl.verdict.complete":           1,
+		"curl.verdict.not_curl":           1,
+		"decode.success":                  1,
+		"decode.failure":                  1,
+		"decode.failure.malformed_header": 1,
+	} {
+		if f.counters[name] != n {
+			t.Errorf("%s = %d, want %d (all: %v)", name, f.counters[name], n, f.counters)
+		}
+	}
+	if _, ok := f.counters["decode.bytes"]; ok {
+		t.Errorf("decode.bytes reported")
+	}
+	if f.durations["decode.duration"] != 2 || f.durations["curl.duration"] != 2 {
+		t.Errorf("durations = %v", f.durations)
+	}
+}
+
+func TestMetrics_Off(t *testing.T) {
+	f := withFakeMetrics(t)
+	SetMetrics(nil)
+	UnmarshalLenient([]byte("GET / HTTP/1.1\r\n\r\n"))
+	if len(f.counters) != 0 {
+		t.Errorf("counters = %v after SetMetrics(nil)", f.counters)
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	for in, want := range map[string]string{
+		"malformed header (no colon)": "malformed_header_no_colon",
+		"Content-Length mismatch":     "content_length_mismatch",
+		"not-curl":                    "not_curl",
+		"":                            "",
+	} {
+		if got := metricName(in); got != want {
+			t.Errorf("metricName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// maxMultipartDepth bounds how deeply ParseMultipartBody follows nested
+// multipart parts.
+const maxMultipartDepth = 8
+
+// MultipartBody is a parsed multipart body (RFC 2046 §5.1). Parts that are
+// themselves multipart are parsed into Nested, so a body is a tree.
+type MultipartBody struct {
+	MediaType string // e.g. "multipart/related" or "multipart/mixed"
+	Boundary  string
+
+	// Start and Type are the multipart/related parameters of RFC 2387: the
+	// Content-ID of the root part and the root part's media type. Both are
+	// empty for other multipart types or when absent.
+	Start string
+	Type  string
+
+	Parts []*MultipartPart
+}
+
+// MultipartPart is one body part of a MultipartBody.
+type MultipartPart struct {
+	Headers Headers
+
+	// Body is the part as it appears in the message, still in its
+	// Content-Transfer-Encoding. DecodedBody is Body with base64 or
+	// quoted-printable decoded; for identity encodings (7bit, 8bit,
+	// binary, or none) it is Body.
+	Body        []byte
+	DecodedBody []byte
+
+	// Nested is the parsed body of a part whose Content-Type is
+	// multipart/*, or nil.
+	Nested *MultipartBody
+}
+
+// ContentID returns the part's Content-ID without its angle brackets.
+func (p *MultipartPart) ContentID() string {
+	return strings.Trim(strings.TrimSpace(p.Headers.Get("Content-ID")), "<>")
+}
+
+// Root returns the root part of a multipart/related body: the part whose
+// Content-ID matches Start, or the first part when Start is empty (RFC 2387
+// §3.2). It returns nil if there are no parts or no part matches.
+func (m *MultipartBody) Root() *MultipartPart {
+	if len(m.Parts) == 0 {
+		return nil
+	}
+	start := strings.Trim(m.Start, "<>")
+	if start == "" {
+		return m.Parts[0]
+	}
+	for _, p := range m.Parts {
+		if p.ContentID() == start {
+			return p
+		}
+	}
+	return nil
+}
+
+// ParseMultipartBody parses a multipart body given the message's
+// Content-Type. Parts whose own Content-Type is multipart/* are parsed
+// recursively, up to 8 levels deep. Each part's Content-Transfer-Encoding
+// is decoded into DecodedBody; an unknown encoding or undecodable data is
+// an error. Preamble and epilogue text are discarded, as are Content-Type
+// parameters other than boundary, start and type.
+func ParseMultipartBody(body []byte, contentType string) (*MultipartBody, error) {
+	return parseMultipart(body, contentType, 0)
+}
+
+func parseMultipart(body []byte, contentType string, depth int) (*MultipartBody, error) {
+	if depth >= maxMultipartDepth {
+		return nil, fmt.Errorf("http: multipart nesting exceeds %d levels", maxMultipartDepth)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("http: invalid Content-Type %q: %w", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("http: Content-Type %q is not multipart", mediaType)
+	}
+	m := &MultipartBody{MediaType: mediaType, Boundary: params["boundary"]}
+	if m.Boundary == "" {
+		return nil, fmt.Errorf("http: %s Content-Type has no boundary", mediaType)
+	}
+	if mediaType == "multipart/related" {
+		m.Start, m.Type = params["start"], params["type"]
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(body), m.Boundary)
+	for i := 0; ; i++ {
+		rp, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("http: %s part %d: %w", mediaType, i, err)
+		}
+		data, err := io.ReadAll(rp)
+		if err != nil {
+			return nil, fmt.Errorf("http: %s part %d: %w", mediaType, i, err)
+		}
+		p := &MultipartPart{Headers: headersFromMIME(rp.Header), Body: data}
+		if p.DecodedBody, err = decodeTransfer(data, p.Headers.Get("Content-Transfer-Encoding")); err != nil {
+			return nil, fmt.Errorf("http: %s part %d: %w", mediaType, i, err)
+		}
+		if ct := p.Headers.Get("Content-Type"); strings.HasPrefix(strings.ToLower(strings.TrimSpace(ct)), "multipart/") {
+			if p.Nested, err = parseMultipart(p.DecodedBody, ct, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		m.Parts = append(m.Parts, p)
+	}
+	return m, nil
+}
+
+// BuildMultipartBody encodes m, returning the body and the Content-Type
+// value carrying its boundary and, for multipart/related, its start and
+// type parameters. Bodies keep their boundaries; one left empty is
+// generated deterministically from seed, so equal seeds give equal output.
+//
+// A part with Nested is written from the nested tree, with its
+// Content-Type boundary updated to match. Other parts are written from
+// Body, or, when Body is nil, from DecodedBody encoded in the part's
+// Content-Transfer-Encoding. Part headers are written sorted by name, as
+// ParseMultipartBody returns them, so the output parses back into the same
+// tree except for the Body of nested parts, whose headers may have been
+// reordered; rebuilding that tree reproduces the output byte for byte.
+func BuildMultipartBody(m *MultipartBody, seed int64) (body []byte, contentType string, err error) {
+	return buildMultipart(m, rand.New(rand.NewSource(seed)))
+}
+
+func buildMultipart(m *MultipartBody, rng *rand.Rand) ([]byte, string, error) {
+	type encodedPart struct {
+		header textproto.MIMEHeader
+		body   []byte
+	}
+	parts := make([]encodedPart, len(m.Parts))
+	for i, p := range m.Parts {
+		headers := p.Headers
+		data := p.Body
+		switch {
+		case p.Nested != nil:
+			nested, ct, err := buildMultipart(p.Nested, rng)
+			if err != nil {
+				return nil, "", err
+			}
+			// The CRLF after the close delimiter would become part of
+			// the enclosing part's body.
+			data = bytes.TrimSuffix(nested, []byte("\r\n"))
+			if _, params, err := mime.ParseMediaType(headers.Get("Content-Type")); err != nil || params["boundary"] != p.Nested.Boundary || p.Nested.Boundary == "" {
+				headers = headers.Clone()
+				headers.Set("Content-Type", ct)
+			}
+		case data == nil && p.DecodedBody != nil:
+			var err error
+			if data, err = encodeTransfer(p.DecodedBody, headers.Get("Content-Transfer-Encoding")); err != nil {
+				return nil, "", fmt.Errorf("http: %s part %d: %w", m.MediaType, i, err)
+			}
+		}
+		parts[i] = encodedPart{header: mimeFromHeaders(headers), body: data}
+	}
+
+	boundary := m.Boundary
+	for boundary == "" {
+		boundary = fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64())
+		for _, p := range parts {
+			if bytes.Contains(p.body, []byte(boundary)) {
+				boundary = ""
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(boundary); err != nil {
+		return nil, "", fmt.Errorf("http: %s boundary %q: %w", m.MediaType, boundary, err)
+	}
+	for _, p := range parts {
+		pw, _ := w.CreatePart(p.header) // writes to a bytes.Buffer cannot fail
+		pw.Write(p.body)
+	}
+	w.Close()
+
+	params := map[string]string{"boundary": boundary}
+	if m.Start != "" {
+		params["start"] = m.Start
+	}
+	if m.Type != "" {
+		params["type"] = m.Type
+	}
+	mediaType := m.MediaType
+	if mediaType == "" {
+		mediaType = "multipart/mixed"
+	}
+	return buf.Bytes(), mime.FormatMediaType(mediaType, params), nil
+}
+
+// decodeTransfer decodes data from a Content-Transfer-Encoding (RFC 2045 §6).
+func decodeTransfer(data []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "7bit", "8bit", "binary":
+		return data, nil
+	case "base64":
+		clean := bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, data)
+		out := make([]byte, base64.StdEncoding.DecodedLen(len(clean)))
+		n, err := base64.StdEncoding.Decode(out, clean)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %w", err)
+		}
+		return out[:n], nil
+	case "quoted-printable":
+		out, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted-printable content: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Transfer-Encoding %q", encoding)
+	}
+}
+
+// encodeTransfer is the inverse of decodeTransfer. base64 output is
+// wrapped at 76 characters as RFC 2045 §6.8 requires.
+func encodeTransfer(data []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "7bit", "8bit", "binary":
+		return data, nil
+	case "base64":
+		enc := base64.StdEncoding.EncodeToString(data)
+		var buf bytes.Buffer
+		for len(enc) > 76 {
+			buf.WriteString(enc[:76])
+			buf.WriteString("\r\n")
+			enc = enc[76:]
+		}
+		buf.WriteString(enc)
+		return buf.Bytes(), nil
+	case "quoted-printable":
+		var buf bytes.Buffer
+		qw := quotedprintable.NewWriter(&buf)
+		qw.Write(data)
+		qw.Close()
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Transfer-Encoding %q", encoding)
+	}
+}
+
+// headersFromMIME converts a part's header map in sorted key order, as
+// multipart.Writer writes it.
+func headersFromMIME(h textproto.MIMEHeader) Headers {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out Headers
+	for _, k := range keys {
+		for _, v := range h[k] {
+			out = append(out, Header{Key: k, Value: v})
+		}
+	}
+	return out
+}
+
+func mimeFromHeaders(headers Headers) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader, len(headers))
+	for _, f := range headers {
+		h.Add(f.Key, f.Value)
+	}
+	return h
+}
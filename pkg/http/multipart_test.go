@@ -0,0 +1,211 @@
+package http
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// mtomContentType and mtomBody resemble a SOAP 1.2 MTOM message: an
+// XOP root part referencing a base64 attachment, plus a nested
+// multipart/alternative part with a quoted-printable HTML alternative.
+const mtomContentType = `multipart/related; type="application/xop+xml"; ` +
+	`start="<rootpart@example.org>"; start-info="application/soap+xml"; ` +
+	`boundary="uuid:0ca0e16e-feb1-426c-97d8-c4508ada5e82"`
+
+var mtomBody = strings.ReplaceAll(`--uuid:0ca0e16e-feb1-426c-97d8-c4508ada5e82
+Content-Type: application/xop+xml; charset=UTF-8; type="application/soap+xml"
+Content-Transfer-Encoding: 8bit
+Content-ID: <rootpart@example.org>
+
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><upload><data><xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:attachment1@example.org"/></data></upload></soap:Body></soap:Envelope>
+--uuid:0ca0e16e-feb1-426c-97d8-c4508ada5e82
+Content-Type: application/octet-stream
+Content-Transfer-Encoding: base64
+Content-ID: <attachment1@example.org>
+
+AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8gISIjJCUmJygpKissLS4vMDEy
+MzQ1Njc4OTo7PD0+P0BBQkNERUZHSElKS0xNTk9QUVJTVFVWV1hZWltcXV5f
+--uuid:0ca0e16e-feb1-426c-97d8-c4508ada5e82
+Content-Type: multipart/alternative; boundary="alt"
+Content-ID: <note@example.org>
+
+--alt
+Content-Type: text/plain
+
+Upload received.
+--alt
+Content-Type: text/html; charset=UTF-8
+Content-Transfer-Encoding: quoted-printable
+
+<p style=3D"color:green">Upload received =E2=9C=93</p>
+--alt--
+--uuid:0ca0e16e-feb1-426c-97d8-c4508ada5e82--
+`, "\n", "\r\n")
+
+func TestParseMultipartBody_MTOM(t *testing.T) {
+	m, err := ParseMultipartBody([]byte(mtomBody), mtomContentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != "multipart/related" || m.Type != "application/xop+xml" || m.Start != "<rootpart@example.org>" {
+		t.Errorf("MediaType, Type, Start = %q, %q, %q", m.MediaType, m.Type, m.Start)
+	}
+	if len(m.Parts) != 3 {
+		t.Fatalf("len(Parts) = %d, want 3", len(m.Parts))
+	}
+
+	root := m.Root()
+	if root != m.Parts[0] {
+		t.Fatalf("Root() = %v, want the first part", root)
+	}
+	if !bytes.Contains(root.DecodedBody, []byte(`href="cid:attachment1@example.org"`)) {
+		t.Errorf("root body = %q", root.DecodedBody)
+	}
+
+	attachment := m.Parts[1]
+	if attachment.ContentID() != "attachment1@example.org" {
+		t.Errorf("ContentID() = %q, want %q", attachment.ContentID(), "attachment1@example.org")
+	}
+	want := make([]byte, 96)
+	for i := range want {
+		want[i] = byte(i)
+	}
+	if !bytes.Equal(attachment.DecodedBody, want) {
+		t.Errorf("attachment DecodedBody = %x, want %x", attachment.DecodedBody, want)
+	}
+	if bytes.Equal(attachment.Body, attachment.DecodedBody) {
+		t.Error("attachment Body was decoded; want the base64 text")
+	}
+
+	alt := m.Parts[2].Nested
+	if alt == nil || alt.MediaType != "multipart/alternative" || len(alt.Parts) != 2 {
+		t.Fatalf("nested = %+v, want multipart/alternative with 2 parts", alt)
+	}
+	if got := string(alt.Parts[1].DecodedBody); got != `<p style="color:green">Upload received ✓</p>` {
+		t.Errorf("quoted-printable DecodedBody = %q", got)
+	}
+	if alt.Root() != alt.Parts[0] {
+		t.Error("Root() without start did not return the first part")
+	}
+}
+
+func TestBuildMultipartBody_RoundTrip(t *testing.T) {
+	m, err := ParseMultipartBody([]byte(mtomBody), mtomContentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ct, err := BuildMultipartBody(m, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseMultipartBody(body, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nested part headers come back sorted, so only the nested trees are
+	// compared for the third part.
+	if !reflect.DeepEqual(got.Parts[:2], m.Parts[:2]) || !reflect.DeepEqual(got.Parts[2].Nested, m.Parts[2].Nested) {
+		t.Errorf("round trip differs:\n got %s\nwant %s", body, mtomBody)
+	}
+	if got.Start != m.Start || got.Type != m.Type || got.Boundary != m.Boundary {
+		t.Errorf("parameters = %q, %q, %q; want %q, %q, %q", got.Start, got.Type, got.Boundary, m.Start, m.Type, m.Boundary)
+	}
+
+	again, ct2, err := BuildMultipartBody(got, 2)
+	if err != nil || !bytes.Equal(again, body) || ct2 != ct {
+		t.Errorf("rebuilding the parsed tree changed the output:\n%s\nwant\n%s", again, body)
+	}
+}
+
+func TestBuildMultipartBody_SeededBoundaries(t *testing.T) {
+	tree := func() *MultipartBody {
+		return &MultipartBody{
+			MediaType: "multipart/related",
+			Start:     "<root>",
+			Type:      "text/xml",
+			Parts: []*MultipartPart{
+				{Headers: Headers{{Key: "Content-Id", Value: "<root>"}, {Key: "Content-Type", Value: "text/xml"}}, Body: []byte("<a/>")},
+				{
+					Headers:     Headers{{Key: "Content-Transfer-Encoding", Value: "base64"}},
+					DecodedBody: bytes.Repeat([]byte{0xff, 0x00}, 100),
+				},
+				{
+					Headers: Headers{{Key: "Content-Type", Value: "multipart/mixed"}},
+					Nested: &MultipartBody{MediaType: "multipart/mixed", Parts: []*MultipartPart{
+						{Headers: Headers{{Key: "Content-Type", Value: "text/plain"}}, Body: []byte("inner")},
+					}},
+				},
+			},
+		}
+	}
+
+	body1, ct1, err := BuildMultipartBody(tree(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body2, ct2, _ := BuildMultipartBody(tree(), 42)
+	if !bytes.Equal(body1, body2) || ct1 != ct2 {
+		t.Error("equal seeds gave different output")
+	}
+	if body3, _, _ := BuildMultipartBody(tree(), 43); bytes.Equal(body1, body3) {
+		t.Error("different seeds gave the same output")
+	}
+
+	m, err := ParseMultipartBody(body1, ct1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Root() == nil || string(m.Root().DecodedBody) != "<a/>" {
+		t.Errorf("Root() = %+v", m.Root())
+	}
+	if !bytes.Equal(m.Parts[1].DecodedBody, bytes.Repeat([]byte{0xff, 0x00}, 100)) {
+		t.Errorf("base64 part DecodedBody = %x", m.Parts[1].DecodedBody)
+	}
+	for _, line := range strings.Split(string(m.Parts[1].Body), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line is %d characters, want at most 76", len(line))
+		}
+	}
+	if n := m.Parts[2].Nested; n == nil || n.Boundary == "" || n.Boundary == m.Boundary || string(n.Parts[0].Body) != "inner" {
+		t.Errorf("nested = %+v", n)
+	}
+}
+
+func TestParseMultipartBody_Invalid(t *testing.T) {
+	part := func(boundary, headers, data string) string {
+		return "--" + boundary + "\r\n" + headers + "\r\n\r\n" + data + "\r\n--" + boundary + "--\r\n"
+	}
+	const ct = "multipart/mixed; boundary=B"
+	tests := []struct {
+		name, body, ct string
+	}{
+		{"not multipart", part("B", "", "x"), "text/plain"},
+		{"no boundary", part("B", "", "x"), "multipart/mixed"},
+		{"bad base64", part("B", "Content-Transfer-Encoding: base64", "!!!"), ct},
+		{"unknown encoding", part("B", "Content-Transfer-Encoding: x-uuencode", "x"), ct},
+		{"nested without boundary", part("B", "Content-Type: multipart/mixed", "x"), ct},
+	}
+	for _, tt := range tests {
+		if _, err := ParseMultipartBody([]byte(tt.body), tt.ct); err == nil {
+			t.Errorf("%s: succeeded, want error", tt.name)
+		}
+	}
+
+	// Nesting beyond the limit is rejected.
+	body, inner := "leaf", ""
+	for i := 0; i <= maxMultipartDepth; i++ {
+		b := "B" + strconv.Itoa(i)
+		header := ""
+		if inner != "" {
+			header = "Content-Type: multipart/mixed; boundary=" + inner
+		}
+		body, inner = part(b, header, body), b
+	}
+	if _, err := ParseMultipartBody([]byte(body), "multipart/mixed; boundary="+inner); err == nil || !strings.Contains(err.Error(), "nesting") {
+		t.Errorf("deep nesting: err = %v, want nesting error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import "github.com/shapestone/shape-http/internal/fastparser"
+
+// Standard header field names in canonical form. Headers.Get and the other
+// lookups fold case, so these are for spelling, not matching: a misspelt
+// constant fails to compile where a misspelt string silently finds nothing.
+const (
+	HeaderAccept             = fastparser.HeaderAccept
+	HeaderAcceptCharset      = fastparser.HeaderAcceptCharset
+	HeaderAcceptEncoding     = fastparser.HeaderAcceptEncoding
+	HeaderAcceptLanguage     = fastparser.HeaderAcceptLanguage
+	HeaderAcceptRanges       = fastparser.HeaderAcceptRanges
+	HeaderAge                = fastparser.HeaderAge
+	HeaderAllow              = fastparser.HeaderAllow
+	HeaderAuthorization      = fastparser.HeaderAuthorization
+	HeaderCacheControl       = fastparser.HeaderCacheControl
+	HeaderConnection         = fastparser.HeaderConnection
+	HeaderContentDisposition = fastparser.HeaderContentDisposition
+	HeaderContentEncoding    = fastparser.HeaderContentEncoding
+	HeaderContentLanguage    = fastparser.HeaderContentLanguage
+	HeaderContentLength      = fastparser.HeaderContentLength
+	HeaderContentLocation    = fastparser.HeaderContentLocation
+	HeaderContentRange       = fastparser.HeaderContentRange
+	HeaderContentType        = fastparser.HeaderContentType
+	HeaderCookie             = fastparser.HeaderCookie
+	HeaderDate               = fastparser.HeaderDate
+	HeaderETag               = fastparser.HeaderETag
+	HeaderExpect             = fastparser.HeaderExpect
+	HeaderExpires            = fastparser.HeaderExpires
+	HeaderFrom               = fastparser.HeaderFrom
+	HeaderHost               = fastparser.HeaderHost
+	HeaderIfMatch            = fastparser.HeaderIfMatch
+	HeaderIfModifiedSince    = fastparser.HeaderIfModifiedSince
+	HeaderIfNoneMatch        = fastparser.HeaderIfNoneMatch
+	HeaderIfRange            = fastparser.HeaderIfRange
+	HeaderIfUnmodifiedSince  = fastparser.HeaderIfUnmodifiedSince
+	HeaderLastModified       = fastparser.HeaderLastModified
+	HeaderLocation           = fastparser.HeaderLocation
+	HeaderMaxForwards        = fastparser.HeaderMaxForwards
+	HeaderOrigin             = fastparser.HeaderOrigin
+	HeaderPragma             = fastparser.HeaderPragma
+	HeaderProxyAuthenticate  = fastparser.HeaderProxyAuthenticate
+	HeaderProxyAuthorization = fastparser.HeaderProxyAuthorization
+	HeaderRange              = fastparser.HeaderRange
+	HeaderReferer            = fastparser.HeaderReferer
+	HeaderRetryAfter         = fastparser.HeaderRetryAfter
+	HeaderServer             = fastparser.HeaderServer
+	HeaderSetCookie          = fastparser.HeaderSetCookie
+	HeaderTE                 = fastparser.HeaderTE
+	HeaderTrailer            = fastparser.HeaderTrailer
+	HeaderTransferEncoding   = fastparser.HeaderTransferEncoding
+	HeaderUpgrade            = fastparser.HeaderUpgrade
+	HeaderUserAgent          = fastparser.HeaderUserAgent
+	HeaderVary               = fastparser.HeaderVary
+	HeaderVia                = fastparser.HeaderVia
+	HeaderWarning            = fastparser.HeaderWarning
+	HeaderWWWAuthenticate    = fastparser.HeaderWWWAuthenticate
+	HeaderXForwardedFor      = fastparser.HeaderXForwardedFor
+	HeaderXForwardedHost     = fastparser.HeaderXForwardedHost
+	HeaderXForwardedProto    = fastparser.HeaderXForwardedProto
+	HeaderXRequestID         = fastparser.HeaderXRequestID
+	HeaderXRealIP            = fastparser.HeaderXRealIP
+)
+
+// Request methods: those of RFC 9110 and RFC 5789 (PATCH), then WebDAV's
+// (RFC 4918). Method names are case-sensitive.
+const (
+	MethodGet     = fastparser.MethodGet
+	MethodHead    = fastparser.MethodHead
+	MethodPost    = fastparser.MethodPost
+	MethodPut     = fastparser.MethodPut
+	MethodPatch   = fastparser.MethodPatch
+	MethodDelete  = fastparser.MethodDelete
+	MethodConnect = fastparser.MethodConnect
+	MethodOptions = fastparser.MethodOptions
+	MethodTrace   = fastparser.MethodTrace
+
+	MethodPropfind  = fastparser.MethodPropfind
+	MethodProppatch = fastparser.MethodProppatch
+	MethodMkcol     = fastparser.MethodMkcol
+	MethodCopy      = fastparser.MethodCopy
+	MethodMove      = fastparser.MethodMove
+	MethodLock      = fastparser.MethodLock
+	MethodUnlock    = fastparser.MethodUnlock
+)
+
+// IsValidMethod reports whether s is a syntactically valid method name,
+// an RFC 9110 token, such as "GET" or an extension method like "PURGE".
+func IsValidMethod(s string) bool {
+	return isToken(s)
+}
+
+// IsValidHeaderName reports whether s is a syntactically valid header
+// field name, an RFC 9110 token: "X-Custom_1" is valid, while "Bad Header"
+// and "Héader" are not.
+func IsValidHeaderName(s string) bool {
+	return isToken(s)
+}
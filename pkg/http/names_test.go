@@ -0,0 +1,40 @@
+package http
+
+import "testing"
+
+func TestIsValidHeaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{HeaderContentLength, true},
+		{HeaderWWWAuthenticate, true},
+		{"X-Custom_1", true},
+		{"x~!#$%&'*+.^`|", true},
+		{"", false},
+		{"Bad Header", false},
+		{"Héader", false},
+		{"Content-Type:", false},
+		{"X-Tab\t", false},
+		{"(comment)", false},
+		{`"quoted"`, false},
+	}
+	for _, tt := range tests {
+		if got := IsValidHeaderName(tt.name); got != tt.want {
+			t.Errorf("IsValidHeaderName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidMethod(t *testing.T) {
+	for _, m := range []string{MethodGet, MethodPatch, MethodPropfind, MethodUnlock, "PURGE", "M-SEARCH", "get"} {
+		if !IsValidMethod(m) {
+			t.Errorf("IsValidMethod(%q) = false, want true", m)
+		}
+	}
+	for _, m := range []string{"", "GET /", "PÖST", "GET\r\n", "{GET}"} {
+		if IsValidMethod(m) {
+			t.Errorf("IsValidMethod(%q) = true, want false", m)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncodingActionKind says what NegotiateEncoding decided to do with a
+// stored response body.
+type EncodingActionKind int
+
+// Encoding actions.
+const (
+	ServeAsIs  EncodingActionKind = iota + 1 // the stored encoding is acceptable
+	DecodeBody                               // remove the Content-Encoding and serve identity
+	ReEncode                                 // decode, then compress with EncodingAction.To
+)
+
+// String returns "serve as is", "decode body", "re-encode", or "none" for
+// the zero value.
+func (k EncodingActionKind) String() string {
+	switch k {
+	case ServeAsIs:
+		return "serve as is"
+	case DecodeBody:
+		return "decode body"
+	case ReEncode:
+		return "re-encode"
+	}
+	return "none"
+}
+
+// EncodingAction is the outcome of NegotiateEncoding.
+type EncodingAction struct {
+	Kind EncodingActionKind
+	To   string // the content coding to compress with, for ReEncode
+}
+
+// NegotiateEncoding decides how to serve the stored resp to a client that
+// sent req, following the Accept-Encoding rules of RFC 9110 §12.5.3. A
+// coding is acceptable if listed with a q-value above 0, or matched by
+// "*" when not listed; identity is acceptable unless "identity;q=0", or
+// "*;q=0" without an identity entry, forbids it. A request without
+// Accept-Encoding accepts any coding. When the stored Content-Encoding is
+// acceptable the action is ServeAsIs, even if the client prefers another
+// coding; otherwise DecodeBody if identity is acceptable, else ReEncode
+// with the acceptable coding of highest q-value that MarshalCompressed
+// supports. It returns an error for a malformed Accept-Encoding, for a
+// stored coding that must be removed but DecodeBody does not understand,
+// and when no acceptable encoding can be produced, for which a server
+// answers 406. EncodingAction.Apply carries out the action.
+func NegotiateEncoding(req *Request, resp *Response) (EncodingAction, error) {
+	if resp == nil {
+		return EncodingAction{}, fmt.Errorf("http: NegotiateEncoding: nil response")
+	}
+	var stored []string
+	for _, v := range resp.Headers.Values("Content-Encoding") {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.ToLower(strings.TrimSpace(c)); c != "" && c != "identity" {
+				stored = append(stored, c)
+			}
+		}
+	}
+	if req == nil || !req.Headers.Has("Accept-Encoding") {
+		return EncodingAction{Kind: ServeAsIs}, nil
+	}
+	accept, err := parseAcceptEncoding(strings.Join(req.Headers.Values("Accept-Encoding"), ","))
+	if err != nil {
+		return EncodingAction{}, err
+	}
+
+	asIs := true
+	for _, c := range stored {
+		asIs = asIs && accept.allows(c)
+	}
+	if len(stored) == 0 {
+		asIs = accept.allows("identity")
+	}
+	if asIs {
+		return EncodingAction{Kind: ServeAsIs}, nil
+	}
+	for _, c := range stored {
+		if c != "gzip" && c != "x-gzip" && c != "deflate" {
+			return EncodingAction{}, fmt.Errorf("http: stored Content-Encoding %s is not acceptable and cannot be decoded", strconv.Quote(c))
+		}
+	}
+	if len(stored) > 0 && accept.allows("identity") {
+		return EncodingAction{Kind: DecodeBody}, nil
+	}
+	best, bestQ := "", 0.0
+	for _, c := range []string{"gzip", "deflate"} {
+		if q := accept.q(c); q > bestQ || q == bestQ && q > 0 && accept.rank(c) < accept.rank(best) {
+			best, bestQ = c, q
+		}
+	}
+	if best == "" {
+		return EncodingAction{}, fmt.Errorf("http: no acceptable encoding for Accept-Encoding %s", strconv.Quote(req.Headers.Get("Accept-Encoding")))
+	}
+	return EncodingAction{Kind: ReEncode, To: best}, nil
+}
+
+// Apply returns the wire-format encoding of resp as the action serves
+// it: unchanged for ServeAsIs, with its Content-Encoding removed by
+// DecodeBody for DecodeBody, and decoded then compressed with
+// MarshalCompressed for ReEncode. resp itself is not modified.
+func (a EncodingAction) Apply(resp *Response) ([]byte, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("http: EncodingAction.Apply(nil)")
+	}
+	switch a.Kind {
+	case ServeAsIs:
+		return Marshal(resp)
+	case DecodeBody, ReEncode:
+		out := *resp
+		out.Headers = resp.Headers.Clone()
+		if err := out.DecodeBody(); err != nil {
+			return nil, err
+		}
+		if a.Kind == ReEncode {
+			return MarshalCompressed(&out, a.To)
+		}
+		return Marshal(&out)
+	}
+	return nil, fmt.Errorf("http: invalid encoding action %d", a.Kind)
+}
+
+// acceptEncoding is a parsed Accept-Encoding: the q-value of each listed
+// coding, lowercased, and the order they were listed in.
+type acceptEncoding struct {
+	qs    map[string]float64
+	order []string
+}
+
+// parseAcceptEncoding parses an Accept-Encoding field value. Empty list
+// members are skipped; x-gzip counts as gzip.
+func parseAcceptEncoding(v string) (acceptEncoding, error) {
+	a := acceptEncoding{qs: make(map[string]float64)}
+	for _, member := range strings.Split(v, ",") {
+		if strings.TrimSpace(member) == "" {
+			continue
+		}
+		params := strings.Split(member, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding != "*" && !isToken(coding) {
+			return acceptEncoding{}, fmt.Errorf("http: invalid Accept-Encoding coding %s", strconv.Quote(strings.TrimSpace(params[0])))
+		}
+		if coding == "x-gzip" {
+			coding = "gzip"
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			name, value, ok := strings.Cut(p, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				return acceptEncoding{}, fmt.Errorf("http: invalid Accept-Encoding parameter %s", strconv.Quote(strings.TrimSpace(p)))
+			}
+			var err error
+			if q, err = parseQValue(strings.TrimSpace(value)); err != nil {
+				return acceptEncoding{}, err
+			}
+		}
+		if _, dup := a.qs[coding]; !dup {
+			a.order = append(a.order, coding)
+			a.qs[coding] = q
+		}
+	}
+	return a, nil
+}
+
+// parseQValue parses a weight of RFC 9110 §12.4.2: 0 to 1 with at most
+// three decimals.
+func parseQValue(s string) (float64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	valid := len(frac) <= 3 && (whole == "0" || whole == "1" && strings.Trim(frac, "0") == "")
+	for i := 0; i < len(frac); i++ {
+		valid = valid && '0' <= frac[i] && frac[i] <= '9'
+	}
+	if !valid {
+		return 0, fmt.Errorf("http: invalid Accept-Encoding q-value %s", strconv.Quote(s))
+	}
+	q, _ := strconv.ParseFloat(s, 64)
+	return q, nil
+}
+
+// q returns the weight of coding: its own entry, else that of "*", else
+// 1 for identity and 0 for any other coding.
+func (a acceptEncoding) q(coding string) float64 {
+	if coding == "x-gzip" {
+		coding = "gzip"
+	}
+	if q, ok := a.qs[coding]; ok {
+		return q
+	}
+	if q, ok := a.qs["*"]; ok {
+		return q
+	}
+	if coding == "identity" {
+		return 1
+	}
+	return 0
+}
+
+func (a acceptEncoding) allows(coding string) bool { return a.q(coding) > 0 }
+
+// rank is the position coding was listed at, after every listed coding
+// when it was not, breaking ties between equal q-values.
+func (a acceptEncoding) rank(coding string) int {
+	for i, c := range a.order {
+		if c == coding {
+			return i
+		}
+	}
+	return len(a.order)
+}
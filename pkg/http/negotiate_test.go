@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func gzipResponse(t *testing.T, body string) *Response {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(body))
+	w.Close()
+	return &Response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Headers: Headers{
+		{Key: "Content-Type", Value: "text/plain"},
+		{Key: "Content-Encoding", Value: "gzip"},
+		{Key: "Content-Length", Value: strconv.Itoa(buf.Len())},
+	}, Body: buf.Bytes()}
+}
+
+func acceptingRequest(acceptEncoding ...string) *Request {
+	req := &Request{Method: "GET", Path: "/", Version: "HTTP/1.1", Headers: Headers{{Key: "Host", Value: "example.com"}}}
+	for _, v := range acceptEncoding {
+		req.Headers.Add("Accept-Encoding", v)
+	}
+	return req
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	identity := &Response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Body: []byte("hello")}
+	gzipped := gzipResponse(t, "hello")
+	brotli := &Response{Version: "HTTP/1.1", StatusCode: 200, Headers: Headers{{Key: "Content-Encoding", Value: "br"}}, Body: []byte{1}}
+	tests := []struct {
+		name   string
+		accept []string // nil for no Accept-Encoding header
+		resp   *Response
+		want   EncodingAction
+	}{
+		{"gzip stored, gzip accepted", []string{"gzip, deflate"}, gzipped, EncodingAction{Kind: ServeAsIs}},
+		{"gzip stored, x-gzip accepted", []string{"x-gzip"}, gzipped, EncodingAction{Kind: ServeAsIs}},
+		{"gzip stored, any accepted", []string{"*"}, gzipped, EncodingAction{Kind: ServeAsIs}},
+		{"gzip stored, no header", nil, gzipped, EncodingAction{Kind: ServeAsIs}},
+		{"gzip stored, identity only", []string{"identity"}, gzipped, EncodingAction{Kind: DecodeBody}},
+		{"gzip stored, empty header", []string{""}, gzipped, EncodingAction{Kind: DecodeBody}},
+		{"gzip stored, gzip refused", []string{"gzip;q=0, br"}, gzipped, EncodingAction{Kind: DecodeBody}},
+		{"gzip stored, deflate only", []string{"deflate, identity;q=0"}, gzipped, EncodingAction{Kind: ReEncode, To: "deflate"}},
+		{"gzip stored, deflate preferred", []string{"gzip;q=0", "*;q=0, deflate;q=0.5"}, gzipped, EncodingAction{Kind: ReEncode, To: "deflate"}},
+		{"identity stored, gzip preferred", []string{"gzip;q=1.0, identity;q=0.5"}, identity, EncodingAction{Kind: ServeAsIs}},
+		{"identity stored, identity forbidden", []string{"br, gzip;q=0.8, identity;q=0"}, identity, EncodingAction{Kind: ReEncode, To: "gzip"}},
+		{"identity stored, star forbids identity", []string{"*;q=0, deflate, gzip"}, identity, EncodingAction{Kind: ReEncode, To: "deflate"}},
+		{"identity stored, star allows identity back", []string{"*;q=0, identity"}, identity, EncodingAction{Kind: ServeAsIs}},
+		{"br stored, br accepted", []string{"br"}, brotli, EncodingAction{Kind: ServeAsIs}},
+	}
+	for _, tt := range tests {
+		got, err := NegotiateEncoding(acceptingRequest(tt.accept...), tt.resp)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v %q, want %v %q", tt.name, got.Kind, got.To, tt.want.Kind, tt.want.To)
+		}
+	}
+}
+
+func TestNegotiateEncoding_Errors(t *testing.T) {
+	identity := &Response{Version: "HTTP/1.1", StatusCode: 200, Body: []byte("hello")}
+	tests := []struct {
+		name   string
+		accept string
+		resp   *Response
+		want   string
+	}{
+		{"malformed q-value", "gzip;q=high", identity, "invalid Accept-Encoding q-value"},
+		{"q-value above 1", "gzip;q=1.5", identity, "invalid Accept-Encoding q-value"},
+		{"unknown parameter", "gzip;level=9", identity, "invalid Accept-Encoding parameter"},
+		{"invalid coding", "gz ip", identity, "invalid Accept-Encoding coding"},
+		{"nothing acceptable", "br, identity;q=0", identity, "no acceptable encoding"},
+		{"undecodable stored coding", "identity", &Response{Headers: Headers{{Key: "Content-Encoding", Value: "br"}}}, "cannot be decoded"},
+	}
+	for _, tt := range tests {
+		if _, err := NegotiateEncoding(acceptingRequest(tt.accept), tt.resp); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := NegotiateEncoding(acceptingRequest("gzip"), nil); err == nil {
+		t.Error("nil response: no error")
+	}
+}
+
+func TestEncodingAction_Apply(t *testing.T) {
+	stored := gzipResponse(t, "hello, world")
+	storedBody := append([]byte(nil), stored.Body...)
+	for _, accept := range []string{"gzip", "identity", "deflate;q=1, identity;q=0"} {
+		action, err := NegotiateEncoding(acceptingRequest(accept), stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := action.Apply(stored)
+		if err != nil {
+			t.Fatalf("%s: %v", accept, err)
+		}
+		resp, err := UnmarshalResponse(data)
+		if err != nil {
+			t.Fatalf("%s: %v", accept, err)
+		}
+		if action.Kind == DecodeBody && resp.Headers.Has("Content-Encoding") {
+			t.Errorf("%s: Content-Encoding %q left after decoding", accept, resp.Headers.Get("Content-Encoding"))
+		}
+		if action.Kind == ReEncode && resp.Headers.Get("Content-Encoding") != action.To {
+			t.Errorf("%s: Content-Encoding = %q, want %q", accept, resp.Headers.Get("Content-Encoding"), action.To)
+		}
+		if err := resp.DecodeBody(); err != nil || string(resp.Body) != "hello, world" {
+			t.Errorf("%s: body = %q, %v", accept, resp.Body, err)
+		}
+	}
+	if !bytes.Equal(stored.Body, storedBody) || stored.Headers.Get("Content-Encoding") != "gzip" {
+		t.Error("Apply modified the stored response")
+	}
+}
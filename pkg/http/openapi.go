@@ -0,0 +1,453 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// OpenAPIFormat selects the encoding produced by ToOpenAPIOperation.
+type OpenAPIFormat int
+
+// Output formats for ToOpenAPIOperation.
+const (
+	OpenAPIYAML OpenAPIFormat = iota // YAML (default)
+	OpenAPIJSON                      // indented JSON
+)
+
+// OpenAPIOptions configures ToOpenAPIOperation.
+type OpenAPIOptions struct {
+	// Format selects YAML (the default) or JSON output.
+	Format OpenAPIFormat
+
+	// PathTemplates are matched against the request path in order; the
+	// first template with the same number of segments whose literal
+	// segments match is used, and each "{name}" segment becomes a path
+	// parameter. With no match the literal path is used.
+	PathTemplates []string
+
+	// Headers lists request headers to document as header parameters.
+	// Accept, Content-Type and Authorization are skipped, since OpenAPI 3.0
+	// ignores header parameters with those names.
+	Headers []string
+
+	// Summary and OperationID are copied into the operation when set.
+	Summary     string
+	OperationID string
+}
+
+// ToOpenAPIOperation describes req as an OpenAPI 3.0 Paths fragment: a
+// single path (templated per opts.PathTemplates) holding one operation for
+// the request's method, ready to merge under a document's "paths" key.
+//
+// The operation lists path, query and selected header parameters, with a
+// schema type inferred from each example value. A request body becomes a
+// requestBody keyed by the Content-Type media type; JSON bodies are
+// included as an inline example along with a schema skeleton inferred from
+// the JSON value types, and other text bodies as a string example. Since
+// OpenAPI requires at least one response, a "default" response is added.
+//
+// Keys are emitted in a fixed order, so the output is stable for a given
+// request and options.
+func ToOpenAPIOperation(req *Request, opts OpenAPIOptions) ([]byte, error) {
+	if req == nil {
+		return nil, fmt.Errorf("http: ToOpenAPIOperation(nil)")
+	}
+	if req.Method == "" {
+		return nil, fmt.Errorf("http: ToOpenAPIOperation: request method is empty")
+	}
+	rawPath, rawQuery, _ := strings.Cut(req.Path, "?")
+	if !strings.HasPrefix(rawPath, "/") {
+		return nil, fmt.Errorf("http: ToOpenAPIOperation: path %q is not origin-form", req.Path)
+	}
+
+	op := &oaMap{}
+	if opts.Summary != "" {
+		op.set("summary", opts.Summary)
+	}
+	if opts.OperationID != "" {
+		op.set("operationId", opts.OperationID)
+	}
+
+	path, params := matchPathTemplate(rawPath, opts.PathTemplates)
+	params = append(params, queryParameters(rawQuery)...)
+	params = append(params, headerParameters(req.Headers, opts.Headers)...)
+	if len(params) > 0 {
+		op.set("parameters", params)
+	}
+
+	if len(req.Body) > 0 {
+		body, err := requestBodyObject(req)
+		if err != nil {
+			return nil, err
+		}
+		op.set("requestBody", body)
+	}
+
+	op.set("responses", (&oaMap{}).set("default", (&oaMap{}).set("description", "Default response")))
+
+	doc := (&oaMap{}).set(path, (&oaMap{}).set(strings.ToLower(req.Method), op))
+	if opts.Format == OpenAPIJSON {
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("http: ToOpenAPIOperation: %w", err)
+		}
+		return append(out, '\n'), nil
+	}
+	var buf bytes.Buffer
+	writeYAML(&buf, doc, 0)
+	return buf.Bytes(), nil
+}
+
+// matchPathTemplate returns the templated path and its path parameters.
+func matchPathTemplate(path string, templates []string) (string, []interface{}) {
+	segs := strings.Split(path, "/")
+	for _, tmpl := range templates {
+		tsegs := strings.Split(tmpl, "/")
+		if len(tsegs) != len(segs) {
+			continue
+		}
+		var params []interface{}
+		matched := true
+		for i, ts := range tsegs {
+			if strings.HasPrefix(ts, "{") && strings.HasSuffix(ts, "}") && len(ts) > 2 && segs[i] != "" {
+				v, err := url.PathUnescape(segs[i])
+				if err != nil {
+					v = segs[i]
+				}
+				params = append(params, parameterObject(ts[1:len(ts)-1], "path", v, true))
+				continue
+			}
+			if ts != segs[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return tmpl, params
+		}
+	}
+	return path, nil
+}
+
+// queryParameters documents each distinct query key using its first value.
+func queryParameters(rawQuery string) []interface{} {
+	if rawQuery == "" {
+		return nil
+	}
+	var params []interface{}
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		params = append(params, parameterObject(k, "query", v, false))
+	}
+	return params
+}
+
+func headerParameters(h Headers, names []string) []interface{} {
+	var params []interface{}
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "accept", "content-type", "authorization":
+			continue
+		}
+		if v := h.Get(name); v != "" {
+			params = append(params, parameterObject(name, "header", v, false))
+		}
+	}
+	return params
+}
+
+func parameterObject(name, in, example string, required bool) *oaMap {
+	m := (&oaMap{}).set("name", name).set("in", in)
+	if required {
+		m.set("required", true)
+	}
+	typ, ex := inferScalar(example)
+	return m.set("schema", (&oaMap{}).set("type", typ)).set("example", ex)
+}
+
+// inferScalar guesses a schema type for a textual value and returns the
+// example in that type.
+func inferScalar(s string) (string, interface{}) {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return "integer", json.Number(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+		return "number", json.Number(s)
+	}
+	if s == "true" || s == "false" {
+		return "boolean", s == "true"
+	}
+	return "string", s
+}
+
+func requestBodyObject(req *Request) (*oaMap, error) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(req.Headers.Get("Content-Type"), ";", 2)[0]))
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	media := &oaMap{}
+	switch {
+	case isJSONMediaType(mediaType) && json.Valid(req.Body):
+		value, err := decodeOrderedJSON(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http: ToOpenAPIOperation: %w", err)
+		}
+		media.set("schema", inferSchema(value)).set("example", value)
+	case utf8.Valid(req.Body):
+		media.set("schema", (&oaMap{}).set("type", "string")).set("example", string(req.Body))
+	default:
+		media.set("schema", (&oaMap{}).set("type", "string").set("format", "binary"))
+	}
+	return (&oaMap{}).set("content", (&oaMap{}).set(mediaType, media)), nil
+}
+
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// inferSchema builds an OpenAPI 3.0 schema skeleton from a decoded JSON
+// value. Arrays take their item schema from the first element.
+func inferSchema(v interface{}) *oaMap {
+	s := &oaMap{}
+	switch v := v.(type) {
+	case *oaMap:
+		s.set("type", "object")
+		if len(v.keys) > 0 {
+			props := &oaMap{}
+			for _, k := range v.keys {
+				props.set(k, inferSchema(v.vals[k]))
+			}
+			s.set("properties", props)
+		}
+	case []interface{}:
+		s.set("type", "array")
+		if len(v) > 0 {
+			s.set("items", inferSchema(v[0]))
+		} else {
+			s.set("items", &oaMap{})
+		}
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			s.set("type", "number")
+		} else {
+			s.set("type", "integer")
+		}
+	case string:
+		s.set("type", "string")
+	case bool:
+		s.set("type", "boolean")
+	case nil:
+		s.set("nullable", true)
+	}
+	return s
+}
+
+// decodeOrderedJSON decodes JSON keeping object key order, with objects as
+// *oaMap and numbers as json.Number.
+func decodeOrderedJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeJSONValue(dec)
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := &oaMap{}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.set(kt.(string), v)
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// oaMap is an insertion-ordered string-keyed map, so generated documents
+// have a stable key order in both JSON and YAML.
+type oaMap struct {
+	keys []string
+	vals map[string]interface{}
+}
+
+func (m *oaMap) set(k string, v interface{}) *oaMap {
+	if m.vals == nil {
+		m.vals = make(map[string]interface{})
+	}
+	if _, ok := m.vals[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.vals[k] = v
+	return m
+}
+
+// MarshalJSON encodes the map with its keys in insertion order.
+func (m *oaMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeYAML emits v as block-style YAML at the given indent.
+func writeYAML(w io.Writer, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case *oaMap:
+		for _, k := range v.keys {
+			fmt.Fprintf(w, "%s%s:", pad, yamlScalar(k))
+			writeYAMLValue(w, v.vals[k], indent)
+		}
+	case []interface{}:
+		for _, item := range v {
+			var buf bytes.Buffer
+			writeYAML(&buf, item, indent+2)
+			if isYAMLCollection(item) {
+				b := buf.Bytes()
+				fmt.Fprintf(w, "%s- %s", pad, b[indent+2:])
+			} else {
+				fmt.Fprintf(w, "%s- %s\n", pad, yamlScalar(item))
+			}
+		}
+	default:
+		fmt.Fprintf(w, "%s%s\n", pad, yamlScalar(v))
+	}
+}
+
+// writeYAMLValue writes the value of a mapping entry whose key has been
+// written at indent.
+func writeYAMLValue(w io.Writer, v interface{}, indent int) {
+	switch c := v.(type) {
+	case *oaMap:
+		if len(c.keys) == 0 {
+			io.WriteString(w, " {}\n")
+			return
+		}
+		io.WriteString(w, "\n")
+		writeYAML(w, c, indent+2)
+	case []interface{}:
+		if len(c) == 0 {
+			io.WriteString(w, " []\n")
+			return
+		}
+		io.WriteString(w, "\n")
+		writeYAML(w, c, indent+2)
+	default:
+		fmt.Fprintf(w, " %s\n", yamlScalar(v))
+	}
+}
+
+func isYAMLCollection(v interface{}) bool {
+	switch c := v.(type) {
+	case *oaMap:
+		return len(c.keys) > 0
+	case []interface{}:
+		return len(c) > 0
+	}
+	return false
+}
+
+// yamlReserved are plain scalars YAML would not read back as strings.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// yamlScalar renders a scalar. Strings that are not plainly safe are
+// double-quoted; Go's quoting escapes are a subset of YAML's.
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return string(v)
+	case string:
+		if isPlainYAML(v) {
+			return v
+		}
+		return strconv.Quote(v)
+	case *oaMap:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return strconv.Quote(fmt.Sprint(v))
+}
+
+func isPlainYAML(s string) bool {
+	if s == "" || yamlReserved[strings.ToLower(s)] {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		case '0' <= c && c <= '9', c == '-', c == '.', c == '/':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
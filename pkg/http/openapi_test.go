@@ -0,0 +1,198 @@
+package http
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const openAPICurl = `curl -X POST 'https://api.example.com/users/42/posts?draft=true&tag=go%20lang' ` +
+	`-H 'X-Request-ID: abc-123' -H 'Content-Type: application/json' ` +
+	`-d '{"title":"Hi","views":3,"tags":["a"],"meta":{"ok":true}}'`
+
+func TestToOpenAPIOperation_YAMLGolden(t *testing.T) {
+	req := &Request{Method: "GET", Path: "/users/7?verbose=1", Headers: Headers{
+		{Key: "Host", Value: "api.example.com"},
+		{Key: "X-Trace", Value: "on"},
+	}}
+	out, err := ToOpenAPIOperation(req, OpenAPIOptions{
+		PathTemplates: []string{"/users/{id}"},
+		Headers:       []string{"X-Trace", "Accept"},
+		Summary:       "Get a user",
+	})
+	if err != nil {
+		t.Fatalf("ToOpenAPIOperation() error = %v", err)
+	}
+	want := `"/users/{id}":
+  get:
+    summary: "Get a user"
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+        example: 7
+      - name: verbose
+        in: query
+        schema:
+          type: integer
+        example: 1
+      - name: X-Trace
+        in: header
+        schema:
+          type: string
+        example: "on"
+    responses:
+      default:
+        description: "Default response"
+`
+	if string(out) != want {
+		t.Errorf("ToOpenAPIOperation() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// TestToOpenAPIOperation_JSONStructure checks the JSON output against the
+// structural requirements of the OpenAPI 3.0 Paths, Operation, Parameter
+// and Request Body objects.
+func TestToOpenAPIOperation_JSONStructure(t *testing.T) {
+	req := ParseCurl(openAPICurl).Request
+	out, err := ToOpenAPIOperation(req, OpenAPIOptions{
+		Format:        OpenAPIJSON,
+		PathTemplates: []string{"/users/{id}", "/users/{id}/posts"},
+		Headers:       []string{"X-Request-ID", "Content-Type"},
+	})
+	if err != nil {
+		t.Fatalf("ToOpenAPIOperation() error = %v", err)
+	}
+
+	var paths map[string]map[string]struct {
+		Parameters []struct {
+			Name     string          `json:"name"`
+			In       string          `json:"in"`
+			Required bool            `json:"required"`
+			Schema   json.RawMessage `json:"schema"`
+			Example  interface{}     `json:"example"`
+		} `json:"parameters"`
+		RequestBody struct {
+			Content map[string]struct {
+				Schema  map[string]interface{} `json:"schema"`
+				Example map[string]interface{} `json:"example"`
+			} `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Description string `json:"description"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(out, &paths); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	item, ok := paths["/users/{id}/posts"]
+	if len(paths) != 1 || !ok {
+		t.Fatalf("paths = %v, want only /users/{id}/posts", paths)
+	}
+	op, ok := item["post"]
+	if len(item) != 1 || !ok {
+		t.Fatalf("path item operations = %v, want only post", item)
+	}
+
+	in := map[string]string{}
+	for _, p := range op.Parameters {
+		if p.Name == "" || p.Schema == nil {
+			t.Errorf("parameter %+v missing name or schema", p)
+		}
+		switch p.In {
+		case "path":
+			if !p.Required {
+				t.Errorf("path parameter %q must be required", p.Name)
+			}
+		case "query", "header", "cookie":
+		default:
+			t.Errorf("parameter %q has invalid in %q", p.Name, p.In)
+		}
+		in[p.Name] = p.In
+	}
+	want := map[string]string{"id": "path", "draft": "query", "tag": "query", "X-Request-ID": "header"}
+	if len(in) != len(want) {
+		t.Errorf("parameters = %v, want %v", in, want)
+	}
+	for name, loc := range want {
+		if in[name] != loc {
+			t.Errorf("parameter %q in = %q, want %q", name, in[name], loc)
+		}
+	}
+	for _, m := range regexp.MustCompile(`\{([^}]+)\}`).FindAllStringSubmatch("/users/{id}/posts", -1) {
+		if in[m[1]] != "path" {
+			t.Errorf("template variable %q has no path parameter", m[1])
+		}
+	}
+
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		t.Fatalf("requestBody content = %v, want application/json", op.RequestBody.Content)
+	}
+	if media.Schema["type"] != "object" {
+		t.Errorf("schema type = %v, want object", media.Schema["type"])
+	}
+	props, _ := media.Schema["properties"].(map[string]interface{})
+	for name, typ := range map[string]string{"title": "string", "views": "integer", "tags": "array", "meta": "object"} {
+		prop, _ := props[name].(map[string]interface{})
+		if prop["type"] != typ {
+			t.Errorf("properties.%s.type = %v, want %s", name, prop["type"], typ)
+		}
+	}
+	if media.Example["title"] != "Hi" {
+		t.Errorf("example = %v, want inline body", media.Example)
+	}
+
+	if len(op.Responses) == 0 || op.Responses["default"].Description == "" {
+		t.Errorf("responses = %v, want a described default response", op.Responses)
+	}
+}
+
+func TestToOpenAPIOperation_NonJSONBody(t *testing.T) {
+	tests := []struct {
+		name, ct string
+		body     []byte
+		want     string
+	}{
+		{"form", "application/x-www-form-urlencoded", []byte("a=1&b=2"), `example: "a=1&b=2"`},
+		{"binary", "", []byte{0xff, 0xfe, 0x00}, "format: binary"},
+		{"invalid JSON", "application/json", []byte("{oops"), `example: "{oops"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Method: "PUT", Path: "/upload", Body: tt.body}
+			if tt.ct != "" {
+				req.Headers = Headers{{Key: "Content-Type", Value: tt.ct + "; charset=utf-8"}}
+			}
+			out, err := ToOpenAPIOperation(req, OpenAPIOptions{})
+			if err != nil {
+				t.Fatalf("ToOpenAPIOperation() error = %v", err)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestToOpenAPIOperation_NoTemplateMatch(t *testing.T) {
+	req := &Request{Method: "DELETE", Path: "/items/5"}
+	out, err := ToOpenAPIOperation(req, OpenAPIOptions{PathTemplates: []string{"/users/{id}"}})
+	if err != nil {
+		t.Fatalf("ToOpenAPIOperation() error = %v", err)
+	}
+	if !strings.HasPrefix(string(out), "\"/items/5\":\n  delete:\n") {
+		t.Errorf("output =\n%s", out)
+	}
+}
+
+func TestToOpenAPIOperation_Errors(t *testing.T) {
+	for _, req := range []*Request{nil, {Path: "/"}, {Method: "OPTIONS", Path: "*"}} {
+		if _, err := ToOpenAPIOperation(req, OpenAPIOptions{}); err == nil {
+			t.Errorf("ToOpenAPIOperation(%+v) expected error", req)
+		}
+	}
+}
@@ -25,6 +25,8 @@ import (
 //	  "reason": "OK",
 //	  "headers": [{"key": "Content-Type", "value": "text/plain"}, ...],
 //	  "body": "..." }
+//
+// NodeKind documents the schema in full.
 func Parse(input string) (ast.SchemaNode, error) {
 	p := parser.NewParser([]byte(input))
 	return p.Parse()
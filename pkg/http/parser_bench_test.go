@@ -1,7 +1,11 @@
 package http
 
 import (
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
 )
 
 var simpleRequest = []byte("GET /api/users HTTP/1.1\r\nHost: example.com\r\nAccept: application/json\r\nUser-Agent: shape-http/1.0\r\n\r\n")
@@ -56,6 +60,21 @@ func BenchmarkUnmarshal_ChunkedResponse(b *testing.B) {
 	}
 }
 
+// BenchmarkUnmarshal_Trace measures the tracing hooks against
+// BenchmarkUnmarshal_ChunkedResponse, which parses without them.
+func BenchmarkUnmarshal_Trace(b *testing.B) {
+	phases := 0
+	count := func(PhaseInfo) { phases++ }
+	limits := ParserLimits{Trace: &ParserTrace{OnStartLine: count, OnHeadersDone: count, OnBodyDone: count}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalResponseWithLimits(chunkedResponse, limits); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkParse_SimpleRequest(b *testing.B) {
 	input := string(simpleRequest)
 	b.ReportAllocs()
@@ -102,3 +121,109 @@ func BenchmarkDetectMessageType(b *testing.B) {
 		DetectMessageType(simpleResponse)
 	}
 }
+
+// BenchmarkUnmarshalLenient_GarbageHeaders measures a large header section of
+// malformed lines. Allocations should track the warning cap, not the input size.
+func BenchmarkUnmarshalLenient_GarbageHeaders(b *testing.B) {
+	for _, lines := range []int{1000, 100000} {
+		data := []byte("GET / HTTP/1.1\r\n" + strings.Repeat("garbage line without any colon\r\n", lines))
+		b.Run(strconv.Itoa(lines), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result := UnmarshalLenient(data)
+				if len(result.Warnings) > DefaultMaxWarnings+1 {
+					b.Fatalf("len(Warnings) = %d", len(result.Warnings))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUnmarshal_ExtensionMethod compares an extension method before and
+// after RegisterMethods; registering saves the method string allocation.
+func BenchmarkUnmarshal_ExtensionMethod(b *testing.B) {
+	data := []byte("PROPFIND /dav/ HTTP/1.1\r\nHost: example.com\r\nDepth: 1\r\n\r\n")
+	b.Run("unregistered", func(b *testing.B) {
+		// REPORT is never registered, so it stands in for PROPFIND's "before".
+		before := []byte("REPORT /dav/ HTTP/1.1\r\nHost: example.com\r\nDepth: 1\r\n\r\n")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := UnmarshalRequest(before); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("registered", func(b *testing.B) {
+		b.Cleanup(fastparser.SaveMethods())
+		RegisterMethods("PROPFIND")
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := UnmarshalRequest(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkUnmarshal_Detect measures the Unmarshal wrapper, which checks
+// the target type and sniffs the message kind before parsing.
+func BenchmarkUnmarshal_Detect(b *testing.B) {
+	b.Run("request", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var req Request
+			if err := Unmarshal(simpleRequest, &req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("response", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp Response
+			if err := Unmarshal(simpleResponse, &resp); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUnmarshal_LargeBody(b *testing.B) {
+	const size = 1 << 20
+	chunk := strings.Repeat("x", 16<<10)
+	var chunked strings.Builder
+	chunked.WriteString("POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n")
+	for n := 0; n < size; n += len(chunk) {
+		chunked.WriteString(strconv.FormatInt(int64(len(chunk)), 16) + "\r\n" + chunk + "\r\n")
+	}
+	chunked.WriteString("0\r\n\r\n")
+	inputs := []struct {
+		name string
+		data []byte
+	}{
+		{"ContentLength", append([]byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: "+strconv.Itoa(size)+"\r\n\r\n"), make([]byte, size)...)},
+		{"Chunked", []byte(chunked.String())},
+	}
+	for _, in := range inputs {
+		b.Run(in.name, func(b *testing.B) {
+			b.SetBytes(int64(len(in.data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := UnmarshalRequest(in.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(in.name+"Lenient", func(b *testing.B) {
+			b.SetBytes(int64(len(in.data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if result := UnmarshalLenient(in.data); result.Request == nil {
+					b.Fatal("expected request")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineEditKind says how a LineEdit changes a line.
+type LineEditKind int
+
+// Line edit kinds.
+const (
+	LineAdded         LineEditKind = iota + 1 // a line only FixedWire has, such as an added Host header
+	LineRemoved                               // a line only Raw has, such as a garbage line
+	LineChanged                               // a line rewritten, such as a header with its spacing repaired
+	LineEndingChanged                         // the same line with another terminator, such as LF made CRLF
+)
+
+// String returns "added", "removed", "changed", "line ending changed", or
+// "none" for the zero value.
+func (k LineEditKind) String() string {
+	switch k {
+	case LineAdded:
+		return "added"
+	case LineRemoved:
+		return "removed"
+	case LineChanged:
+		return "changed"
+	case LineEndingChanged:
+		return "line ending changed"
+	}
+	return "none"
+}
+
+// LineEdit is one line-level difference between a ParseResult's Raw input
+// and its FixedWire. Lines are split after each "\n" and keep their
+// terminators.
+type LineEdit struct {
+	Kind LineEditKind
+
+	// Line is the 1-based line of Raw the edit applies to. A LineAdded
+	// edit inserts New before that line, or at the end when Line is one
+	// past the last line.
+	Line int
+
+	Old string // the line of Raw; "" for LineAdded
+	New string // the line of FixedWire; "" for LineRemoved
+}
+
+// FixedWire marshals the recovered Request or Response: the message as the
+// lenient parser understood it, in canonical form with the framing
+// Marshal derives. It fails if neither was recovered.
+func (r *ParseResult) FixedWire() ([]byte, error) {
+	switch {
+	case r.Request != nil:
+		return Marshal(r.Request)
+	case r.Response != nil:
+		return Marshal(r.Response)
+	}
+	return nil, fmt.Errorf("http: no message to marshal")
+}
+
+// Patch describes how FixedWire differs from Raw as line edits in Raw
+// order, for showing a reader what the lenient parser repaired. Lines are
+// matched ignoring their terminators, so a message whose line endings
+// were normalized yields LineEndingChanged edits rather than a rewrite of
+// every line. Applying the edits to Raw gives FixedWire exactly: walking
+// the lines of Raw, insert the LineAdded edits for a line before it, then
+// drop it if it is LineRemoved or replace it by New if it is LineChanged or
+// LineEndingChanged. Patch returns nil if FixedWire fails or nothing
+// changed. When the lines that differ are too many to match up, as in a
+// body of many thousand lines rewritten throughout, the edits still apply
+// but pair fewer lines as LineChanged.
+func (r *ParseResult) Patch() []LineEdit {
+	fixed, err := r.FixedWire()
+	if err != nil {
+		return nil
+	}
+	return lineEdits(splitLines(string(r.Raw)), splitLines(string(fixed)))
+}
+
+// maxDiffCells bounds the table lineEdits matches lines with.
+const maxDiffCells = 1 << 22
+
+// splitLines splits s after each "\n".
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineKey is a line without its terminator, including stray CRs before it.
+func lineKey(line string) string {
+	return strings.TrimRight(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+// lineEdits returns the edits turning the lines old into new, pairing the
+// lines of a longest common subsequence by lineKey.
+func lineEdits(old, new []string) []LineEdit {
+	pre := 0
+	for pre < len(old) && pre < len(new) && lineKey(old[pre]) == lineKey(new[pre]) {
+		pre++
+	}
+	suf := 0
+	for suf < len(old)-pre && suf < len(new)-pre && lineKey(old[len(old)-1-suf]) == lineKey(new[len(new)-1-suf]) {
+		suf++
+	}
+
+	// matches holds the index pairs of matched lines, in order.
+	var matches [][2]int
+	for i := 0; i < pre; i++ {
+		matches = append(matches, [2]int{i, i})
+	}
+	matches = append(matches, matchLines(old[pre:len(old)-suf], new[pre:len(new)-suf], pre)...)
+	for k := suf; k > 0; k-- {
+		matches = append(matches, [2]int{len(old) - k, len(new) - k})
+	}
+	matches = append(matches, [2]int{len(old), len(new)})
+
+	var edits []LineEdit
+	i, j := 0, 0
+	for _, m := range matches {
+		edits = appendHunk(edits, i, old[i:m[0]], new[j:m[1]])
+		if m[0] < len(old) && old[m[0]] != new[m[1]] {
+			edits = append(edits, LineEdit{Kind: LineEndingChanged, Line: m[0] + 1, Old: old[m[0]], New: new[m[1]]})
+		}
+		i, j = m[0]+1, m[1]+1
+	}
+	return edits
+}
+
+// matchLines returns the index pairs, offset by off, of a longest common
+// subsequence of old and new, or none if the table would exceed
+// maxDiffCells.
+func matchLines(old, new []string, off int) [][2]int {
+	n, m := len(old), len(new)
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		return nil
+	}
+	// lcs[i*(m+1)+j] is the length of the LCS of old[i:] and new[j:].
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch at := i*(m+1) + j; {
+			case lineKey(old[i]) == lineKey(new[j]):
+				lcs[at] = lcs[at+m+2] + 1
+			case lcs[at+m+1] >= lcs[at+1]:
+				lcs[at] = lcs[at+m+1]
+			default:
+				lcs[at] = lcs[at+1]
+			}
+		}
+	}
+	var matches [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		at := i*(m+1) + j
+		switch {
+		case lineKey(old[i]) == lineKey(new[j]):
+			matches = append(matches, [2]int{off + i, off + j})
+			i++
+			j++
+		case lcs[at+m+1] >= lcs[at+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// appendHunk appends the edits replacing dels, the lines of old from index
+// start, with adds. A deleted line is paired with the next added line as
+// LineChanged when both start with the same word, such as a method or a
+// header name; the rest are removed, or added after them.
+func appendHunk(edits []LineEdit, start int, dels, adds []string) []LineEdit {
+	a := 0
+	for d, del := range dels {
+		if a < len(adds) && lineLabel(del) == lineLabel(adds[a]) {
+			edits = append(edits, LineEdit{Kind: LineChanged, Line: start + d + 1, Old: del, New: adds[a]})
+			a++
+		} else {
+			edits = append(edits, LineEdit{Kind: LineRemoved, Line: start + d + 1, Old: del})
+		}
+	}
+	for _, add := range adds[a:] {
+		edits = append(edits, LineEdit{Kind: LineAdded, Line: start + len(dels) + 1, New: add})
+	}
+	return edits
+}
+
+// lineLabel is the lowercased first word of a line, up to a space or
+// colon: the method or version of a start line, or a header name.
+func lineLabel(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexAny(line, " \t:"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToLower(line)
+}
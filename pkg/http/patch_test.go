@@ -0,0 +1,106 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-http/pkg/http/httptest"
+)
+
+// applyLineEdits applies edits to raw as Patch documents.
+func applyLineEdits(raw []byte, edits []LineEdit) string {
+	var b strings.Builder
+	lines := splitLines(string(raw))
+	for i := 0; i <= len(lines); i++ {
+		replaced := false
+		for len(edits) > 0 && edits[0].Line == i+1 {
+			e := edits[0]
+			edits = edits[1:]
+			if e.Kind == LineAdded {
+				b.WriteString(e.New)
+				continue
+			}
+			b.WriteString(e.New) // "" for LineRemoved
+			replaced = true
+			break
+		}
+		if !replaced && i < len(lines) {
+			b.WriteString(lines[i])
+		}
+	}
+	return b.String()
+}
+
+func TestParseResult_Patch(t *testing.T) {
+	raw := []byte("GET /a\nHost: example.com\nthis is not a header\nAccept : */*\n\n")
+	result := UnmarshalLenient(raw)
+	if &result.Raw[0] != &raw[0] {
+		t.Error("Raw is not the input slice")
+	}
+	fixed, err := result.FixedWire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "GET /a HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\n\r\n"; string(fixed) != want {
+		t.Fatalf("FixedWire() = %q, want %q", fixed, want)
+	}
+	want := []LineEdit{
+		{Kind: LineChanged, Line: 1, Old: "GET /a\n", New: "GET /a HTTP/1.1\r\n"},
+		{Kind: LineEndingChanged, Line: 2, Old: "Host: example.com\n", New: "Host: example.com\r\n"},
+		{Kind: LineRemoved, Line: 3, Old: "this is not a header\n"},
+		{Kind: LineChanged, Line: 4, Old: "Accept : */*\n", New: "Accept: */*\r\n"},
+		{Kind: LineEndingChanged, Line: 5, Old: "\n", New: "\r\n"},
+	}
+	if got := result.Patch(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Patch() =\n%q\nwant\n%q", got, want)
+	}
+
+	// An added Host header is a LineAdded edit.
+	result = UnmarshalLenient([]byte("GET http://example.com/a HTTP/1.1\r\nAccept: */*\r\n\r\n"))
+	want = []LineEdit{
+		{Kind: LineChanged, Line: 1, Old: "GET http://example.com/a HTTP/1.1\r\n", New: "GET /a HTTP/1.1\r\n"},
+		{Kind: LineAdded, Line: 2, New: "Host: example.com\r\n"},
+	}
+	if got := result.Patch(); !reflect.DeepEqual(got, want) {
+		t.Errorf("absolute-form: Patch() = %q, want %q", got, want)
+	}
+
+	if got := UnmarshalLenient([]byte(httptest.BaseRequest)).Patch(); got != nil {
+		t.Errorf("canonical input: Patch() = %q, want nil", got)
+	}
+	if _, err := (&ParseResult{}).FixedWire(); err == nil {
+		t.Error("FixedWire() with no message: error = nil")
+	}
+}
+
+// Applying Patch to Raw must give FixedWire for every input of the mutation
+// corpus.
+func TestParseResult_Patch_Mutations(t *testing.T) {
+	for _, base := range []string{httptest.BaseRequest, httptest.BaseResponse} {
+		for mask := 0; mask < 1<<len(httptest.MutationOperators()); mask++ {
+			input := httptest.ApplyMutations(base, uint8(mask))
+			result := UnmarshalLenient([]byte(input))
+			fixed, err := result.FixedWire()
+			if err != nil {
+				continue
+			}
+			if got := applyLineEdits(result.Raw, result.Patch()); got != string(fixed) {
+				t.Errorf("mask %08b: applying Patch to %q gives\n%q, want\n%q", mask, input, got, fixed)
+			}
+		}
+	}
+}
+
+func TestLineEdits_TooManyLines(t *testing.T) {
+	old := splitLines(strings.Repeat("a 1\n", 3000))
+	new := splitLines(strings.Repeat("a 2\n", 3000) + "c\n")
+	edits := lineEdits(old, new)
+	if len(edits) != 3001 || edits[0].Kind != LineChanged || edits[3000].Kind != LineAdded {
+		t.Fatalf("got %d edits, want 3000 changed and 1 added", len(edits))
+	}
+	raw := []byte(strings.Join(old, ""))
+	if got := applyLineEdits(raw, edits); got != strings.Join(new, "") {
+		t.Error("applying the edits does not give the new lines")
+	}
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// ErrPathEscapesRoot is returned by NormalizePath when ".." segments would
+// climb above "/". Servers typically treat such targets as traversal
+// attempts.
+var ErrPathEscapesRoot = errors.New("http: path escapes root")
+
+// NormalizePath returns a canonical form of an origin-form request-target
+// for comparison, following RFC 3986 §6.2.2:
+//
+//   - percent-escapes of unreserved characters are decoded ("%7E" → "~"),
+//   - remaining escapes get uppercase hex digits ("%2f" → "%2F"); escaped
+//     slashes are never decoded, since that would change the path's segments,
+//   - empty segments are collapsed ("//" → "/"),
+//   - "." and ".." segments are resolved.
+//
+// The query is left as-is except that "%20" is rewritten to "+" so both
+// spellings of a space compare equal. Targets not starting with "/" (such
+// as "*" or an authority) are returned unchanged.
+//
+// It returns an error wrapping ErrPathEscapesRoot if ".." would climb above
+// the root, and an error for malformed percent-escapes. Parsing never calls
+// NormalizePath: Request.Path always holds the bytes seen on the wire.
+func NormalizePath(path string) (string, error) {
+	if path == "" {
+		return "/", nil
+	}
+	if path[0] != '/' {
+		return path, nil
+	}
+	rawPath, query, hasQuery := strings.Cut(path, "?")
+
+	decoded, bad := fastparser.NormalizeEscapes(rawPath)
+	if bad >= 0 {
+		return "", fmt.Errorf("http: invalid percent-escape in path %q", rawPath)
+	}
+	for strings.Contains(decoded, "//") {
+		decoded = strings.ReplaceAll(decoded, "//", "/")
+	}
+	resolved, aboveRoot := fastparser.RemoveDotSegments(decoded)
+	if aboveRoot {
+		return "", fmt.Errorf("%w: %q", ErrPathEscapesRoot, path)
+	}
+	if !hasQuery {
+		return resolved, nil
+	}
+	return resolved + "?" + strings.ReplaceAll(query, "%20", "+"), nil
+}
+
+// NormalizedPath returns NormalizePath(r.Path).
+func (r *Request) NormalizedPath() (string, error) {
+	return NormalizePath(r.Path)
+}
+
+// URL reconstructs the request's absolute URL from Scheme (http when
+// empty), the Host header and Path, normalized the same way as
+// ParseResult.URL from ParseCurl. For authority-form (CONNECT) and
+// asterisk-form targets the URL has no path. It returns an error if the
+// request has no Host header.
+func (r *Request) URL() (string, error) {
+	host := r.Headers.Get("Host")
+	if host == "" {
+		return "", fmt.Errorf("http: cannot build URL: request has no Host header")
+	}
+	path := r.Path
+	if !strings.HasPrefix(path, "/") {
+		path = ""
+	}
+	return fastparser.BuildURL(r.Scheme, host, path), nil
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/api/%7Euser", "/api/~user"},
+		{"/api/~user", "/api/~user"},
+		{"/%41%62%63-%5F%2E", "/Abc-_."},
+		{"/a%2fb", "/a%2Fb"},         // escaped slash stays escaped
+		{"/a%2Fb/../c", "/c"},        // %2F is part of one segment
+		{"/caf%c3%a9", "/caf%C3%A9"}, // UTF-8 escapes only get uppercased
+		{"/a%20b", "/a%20b"},
+		{"//a///b//", "/a/b/"},
+		{"/a/./b/../c", "/a/c"},
+		{"/a/b/..", "/a/"},
+		{"/a/.", "/a/"},
+		{"/.", "/"},
+		{"", "/"},
+		{"/search?q=a%20b&x=%7e", "/search?q=a+b&x=%7e"},
+		{"/a/%2E%2E/b?p=../x", "/b?p=../x"},
+		{"*", "*"},
+		{"example.com:443", "example.com:443"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePath(tt.in)
+		if err != nil {
+			t.Errorf("NormalizePath(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestNormalizePath_Traversal verifies that ".." climbing above the root is
+// reported, including when the dots are percent-encoded.
+func TestNormalizePath_Traversal(t *testing.T) {
+	for _, in := range []string{"/..", "/../etc/passwd", "/a/../../b", "/%2e%2e/etc", "/a/%2E%2E/%2e%2E/x"} {
+		_, err := NormalizePath(in)
+		if !errors.Is(err, ErrPathEscapesRoot) {
+			t.Errorf("NormalizePath(%q) error = %v, want ErrPathEscapesRoot", in, err)
+		}
+	}
+}
+
+func TestNormalizePath_InvalidEscape(t *testing.T) {
+	for _, in := range []string{"/a%", "/a%2", "/a%zz"} {
+		if _, err := NormalizePath(in); err == nil {
+			t.Errorf("NormalizePath(%q) expected error", in)
+		}
+	}
+}
+
+// TestUnmarshal_PathNotNormalized confirms parsing preserves wire bytes.
+func TestUnmarshal_PathNotNormalized(t *testing.T) {
+	req, err := UnmarshalRequest([]byte("GET /a/./%7euser HTTP/1.1\r\nHost: x\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("UnmarshalRequest() error = %v", err)
+	}
+	if req.Path != "/a/./%7euser" {
+		t.Errorf("Path = %q, want wire bytes", req.Path)
+	}
+	if got, _ := req.NormalizedPath(); got != "/a/~user" {
+		t.Errorf("NormalizedPath() = %q, want /a/~user", got)
+	}
+}
+
+func TestRequest_URL(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+		want string
+	}{
+		{"origin-form", &Request{Path: "/a?b=1", Headers: Headers{{Key: "Host", Value: "example.com"}}}, "http://example.com/a?b=1"},
+		{"scheme and default port", &Request{Scheme: "https", Path: "/", Headers: Headers{{Key: "Host", Value: "example.com:443"}}}, "https://example.com/"},
+		{"non-default port", &Request{Scheme: "https", Path: "/", Headers: Headers{{Key: "Host", Value: "example.com:8443"}}}, "https://example.com:8443/"},
+		{"IPv6", &Request{Path: "/x", Headers: Headers{{Key: "Host", Value: "[::1]:8080"}}}, "http://[::1]:8080/x"},
+		{"asterisk-form", &Request{Method: "OPTIONS", Path: "*", Headers: Headers{{Key: "Host", Value: "example.com"}}}, "http://example.com"},
+		{"authority-form", &Request{Method: "CONNECT", Path: "example.com:443", Headers: Headers{{Key: "Host", Value: "example.com:443"}}}, "http://example.com:443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.req.URL()
+			if err != nil {
+				t.Fatalf("URL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("URL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&Request{Path: "/"}).URL(); err == nil {
+		t.Error("URL() without Host expected error")
+	}
+}
+
+// TestRequest_URL_MatchesParseCurl checks both reconstructions agree.
+func TestRequest_URL_MatchesParseCurl(t *testing.T) {
+	for _, cmd := range []string{
+		`curl https://user:pw@example.com:443/a?b=1#c`,
+		`curl http://[::1]:8080/x`,
+		`curl localhost:3000/api`,
+	} {
+		result := ParseCurl(cmd)
+		got, err := result.Request.URL()
+		if err != nil {
+			t.Fatalf("%s: URL() error = %v", cmd, err)
+		}
+		if got != result.URL {
+			t.Errorf("%s: Request.URL() = %q, ParseResult.URL = %q", cmd, got, result.URL)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// PercentEncode encodes every byte of s except the RFC 3986 unreserved
+// characters, letters, digits and "-._~", as %XX with uppercase hex
+// digits, as curl's --data-urlencode does. A multibyte UTF-8 character
+// becomes one escape per byte: "é" is "%C3%A9".
+func PercentEncode(s string) string {
+	return fastparser.PercentEncode(s)
+}
+
+// PercentEncodePath encodes the bytes of s that may not appear in a URL
+// path. "/", the unreserved characters, the sub-delimiters "!$&'()*+,;="
+// and ":" and "@" are kept, and so are well-formed %XX escapes, so that a
+// path already encoded passes through unchanged:
+//
+//	PercentEncodePath("/a b/%41") == "/a%20b/%41"
+//
+// A '%' that does not start an escape is encoded as "%25".
+func PercentEncodePath(s string) string {
+	return fastparser.PercentEncodePath(s)
+}
+
+// PercentDecode decodes the %XX escapes in s. It returns an error for a
+// '%' not followed by two hex digits. "+" is left alone: it means a space
+// only in form bodies and queries, which FormDecode decodes. The result
+// holds the decoded bytes as is, so it need not be valid UTF-8.
+func PercentDecode(s string) (string, error) {
+	decoded, bad := fastparser.PercentDecode(s, false, false)
+	if bad >= 0 {
+		return "", fmt.Errorf("http: invalid percent escape %q at offset %d", escapeAt(s, bad), bad)
+	}
+	return decoded, nil
+}
+
+// PercentDecodeLenient is PercentDecode that keeps a '%' not followed by
+// two hex digits as written instead of failing, as browsers do.
+func PercentDecodeLenient(s string) string {
+	decoded, _ := fastparser.PercentDecode(s, false, true)
+	return decoded
+}
+
+// FormDecode decodes a name or value of an application/x-www-form-urlencoded
+// body or query string: PercentDecode, with each "+" decoded as a space.
+func FormDecode(s string) (string, error) {
+	decoded, bad := fastparser.PercentDecode(s, true, false)
+	if bad >= 0 {
+		return "", fmt.Errorf("http: invalid percent escape %q at offset %d", escapeAt(s, bad), bad)
+	}
+	return decoded, nil
+}
+
+// escapeAt returns the malformed escape at s[i:], at most three bytes.
+func escapeAt(s string, i int) string {
+	end := i + 3
+	if end > len(s) {
+		end = len(s)
+	}
+	return s[i:end]
+}
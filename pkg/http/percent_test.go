@@ -0,0 +1,117 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPercentEncode(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"abcXYZ019-._~", "abcXYZ019-._~"},
+		{"a b", "a%20b"},
+		{"a+b", "a%2Bb"},
+		{"/path?q=1&r=2#f", "%2Fpath%3Fq%3D1%26r%3D2%23f"},
+		{"100%", "100%25"},
+		{"%41", "%2541"},
+		{"é", "%C3%A9"},
+		{"日本", "%E6%97%A5%E6%9C%AC"},
+		{"😀", "%F0%9F%98%80"},
+		{"\x00\xff", "%00%FF"},
+	}
+	for _, tt := range tests {
+		if got := PercentEncode(tt.in); got != tt.want {
+			t.Errorf("PercentEncode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPercentEncodePath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"/users/42", "/users/42"},
+		{"/a b/c", "/a%20b/c"},
+		{"/a%20b", "/a%20b"},
+		{"/a%2fb", "/a%2fb"},
+		{"/100%", "/100%25"},
+		{"/%zz", "/%25zz"},
+		{"/%4", "/%254"},
+		{"/x?y#z", "/x%3Fy%23z"},
+		{"/!$&'()*+,;=:@", "/!$&'()*+,;=:@"},
+		{"/{id}|\"<>\\^`", "/%7Bid%7D%7C%22%3C%3E%5C%5E%60"},
+		{"/café/日本", "/caf%C3%A9/%E6%97%A5%E6%9C%AC"},
+	}
+	for _, tt := range tests {
+		got := PercentEncodePath(tt.in)
+		if got != tt.want {
+			t.Errorf("PercentEncodePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if again := PercentEncodePath(got); again != got {
+			t.Errorf("PercentEncodePath(%q) = %q, not idempotent", got, again)
+		}
+		if decoded := PercentDecodeLenient(got); !strings.Contains(tt.in, "%") && decoded != tt.in {
+			t.Errorf("PercentDecodeLenient(%q) = %q, want %q", got, decoded, tt.in)
+		}
+	}
+}
+
+func TestPercentDecode(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      string // "" for success
+		lenient  string
+	}{
+		{in: "", want: "", lenient: ""},
+		{in: "plain", want: "plain", lenient: "plain"},
+		{in: "a%20b", want: "a b", lenient: "a b"},
+		{in: "a+b", want: "a+b", lenient: "a+b"},
+		{in: "%2B", want: "+", lenient: "+"},
+		{in: "%c3%A9", want: "é", lenient: "é"},
+		{in: "%E6%97%A5%E6%9C%AC", want: "日本", lenient: "日本"},
+		{in: "%F0%9F%98%80", want: "😀", lenient: "😀"},
+		{in: "%FF", want: "\xff", lenient: "\xff"},
+		{in: "%2541", want: "%41", lenient: "%41"},
+		{in: "100%", err: `http: invalid percent escape "%" at offset 3`, lenient: "100%"},
+		{in: "%4", err: `http: invalid percent escape "%4" at offset 0`, lenient: "%4"},
+		{in: "a%zzb%41", err: `http: invalid percent escape "%zz" at offset 1`, lenient: "a%zzbA"},
+	}
+	for _, tt := range tests {
+		got, err := PercentDecode(tt.in)
+		switch {
+		case tt.err != "":
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("PercentDecode(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+		case err != nil || got != tt.want:
+			t.Errorf("PercentDecode(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+		if got := PercentDecodeLenient(tt.in); got != tt.lenient {
+			t.Errorf("PercentDecodeLenient(%q) = %q, want %q", tt.in, got, tt.lenient)
+		}
+	}
+}
+
+func TestPercentEncode_RoundTrip(t *testing.T) {
+	for _, s := range []string{"", "a b+c&d=e", "é日本😀", "%2541", "\x00\x7f\x80\xff"} {
+		if got, err := PercentDecode(PercentEncode(s)); err != nil || got != s {
+			t.Errorf("PercentDecode(PercentEncode(%q)) = %q, %v", s, got, err)
+		}
+	}
+}
+
+func TestFormDecode(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a+b", "a b"},
+		{"a%2Bb", "a+b"},
+		{"caf%C3%A9+cr%C3%A8me", "café crème"},
+		{"++", "  "},
+	}
+	for _, tt := range tests {
+		if got, err := FormDecode(tt.in); err != nil || got != tt.want {
+			t.Errorf("FormDecode(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := FormDecode("a+%g0"); err == nil || err.Error() != `http: invalid percent escape "%g0" at offset 2` {
+		t.Errorf("FormDecode(bad escape) error = %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ByteRange is one byte-range-spec of a Range header (RFC 9110 §14.1.2).
+// Positions are inclusive and zero-based.
+type ByteRange struct {
+	Start int64 // first byte position; -1 for a suffix range ("-500")
+	End   int64 // last byte position; -1 when omitted ("500-") or for a suffix range
+
+	// SuffixLength is the number of final bytes requested by a suffix
+	// range. It is only meaningful when Start is -1.
+	SuffixLength int64
+}
+
+// Resolve returns the absolute, inclusive positions r selects in a
+// representation of size bytes. ok is false if r is unsatisfiable for that
+// size (RFC 9110 §14.1.2): it starts at or beyond the end, or is a
+// zero-length suffix.
+func (r ByteRange) Resolve(size int64) (start, end int64, ok bool) {
+	if r.Start < 0 {
+		if r.SuffixLength <= 0 || size <= 0 {
+			return 0, 0, false
+		}
+		start = size - r.SuffixLength
+		if start < 0 {
+			start = 0
+		}
+		return start, size - 1, true
+	}
+	if r.Start >= size {
+		return 0, 0, false
+	}
+	end = r.End
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	return r.Start, end, true
+}
+
+// String formats r as a byte-range-spec, e.g. "0-499", "9500-" or "-500".
+func (r ByteRange) String() string {
+	switch {
+	case r.Start < 0:
+		return "-" + strconv.FormatInt(r.SuffixLength, 10)
+	case r.End < 0:
+		return strconv.FormatInt(r.Start, 10) + "-"
+	default:
+		return strconv.FormatInt(r.Start, 10) + "-" + strconv.FormatInt(r.End, 10)
+	}
+}
+
+// ParseRangeHeader parses a request Range header such as
+// "bytes=0-499, -500, 9500-". Only the bytes unit is supported. Ranges are
+// returned in header order without merging or reordering; a range whose
+// last position is before its first is an error.
+func ParseRangeHeader(value string) ([]ByteRange, error) {
+	unit, set, ok := strings.Cut(strings.TrimSpace(value), "=")
+	if !ok {
+		return nil, fmt.Errorf("http: invalid Range %q: missing '='", value)
+	}
+	if !strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+		return nil, fmt.Errorf("http: unsupported range unit %q", strings.TrimSpace(unit))
+	}
+
+	var ranges []ByteRange
+	for _, spec := range strings.Split(set, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue // RFC 9110 §5.6.1: empty list elements are ignored
+		}
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, fmt.Errorf("http: invalid byte range %q", spec)
+		}
+		if first == "" {
+			n, err := parseRangePos(last)
+			if err != nil {
+				return nil, fmt.Errorf("http: invalid suffix range %q: %w", spec, err)
+			}
+			ranges = append(ranges, ByteRange{Start: -1, End: -1, SuffixLength: n})
+			continue
+		}
+		start, err := parseRangePos(first)
+		if err != nil {
+			return nil, fmt.Errorf("http: invalid byte range %q: %w", spec, err)
+		}
+		r := ByteRange{Start: start, End: -1}
+		if last != "" {
+			if r.End, err = parseRangePos(last); err != nil {
+				return nil, fmt.Errorf("http: invalid byte range %q: %w", spec, err)
+			}
+			if r.End < start {
+				return nil, fmt.Errorf("http: invalid byte range %q: last position before first", spec)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("http: invalid Range %q: no ranges", value)
+	}
+	return ranges, nil
+}
+
+// ParseContentRange parses a Content-Range value (RFC 9110 §14.4):
+//
+//	bytes 0-1023/4096  → "bytes", 0, 1023, 4096
+//	bytes 0-1023/*     → "bytes", 0, 1023, -1 (length unknown)
+//	bytes */4096       → "bytes", -1, -1, 4096 (unsatisfied range)
+//
+// It is an error for start to exceed end, for end to reach a known
+// total, or for any value to be negative.
+func ParseContentRange(value string) (unit string, start, end, total int64, err error) {
+	unit, resp, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || unit == "" {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q", value)
+	}
+	rng, length, ok := strings.Cut(strings.TrimSpace(resp), "/")
+	if !ok {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: missing '/'", value)
+	}
+
+	total = -1
+	if length != "*" {
+		if total, err = parseRangePos(length); err != nil {
+			return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: complete length: %w", value, err)
+		}
+	}
+	if rng == "*" {
+		if total < 0 {
+			return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: unsatisfied range needs a complete length", value)
+		}
+		return unit, -1, -1, total, nil
+	}
+
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: missing '-'", value)
+	}
+	if start, err = parseRangePos(first); err != nil {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: first position: %w", value, err)
+	}
+	if end, err = parseRangePos(last); err != nil {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: last position: %w", value, err)
+	}
+	if start > end {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: first position after last", value)
+	}
+	if total >= 0 && end >= total {
+		return "", 0, 0, 0, fmt.Errorf("http: invalid Content-Range %q: last position not below complete length", value)
+	}
+	return unit, start, end, total, nil
+}
+
+// ContentRange parses the response's Content-Range header with
+// ParseContentRange. It returns an error if the header is absent.
+func (r *Response) ContentRange() (unit string, start, end, total int64, err error) {
+	v := r.Headers.Get("Content-Range")
+	if v == "" {
+		return "", 0, 0, 0, fmt.Errorf("http: no Content-Range header")
+	}
+	return ParseContentRange(v)
+}
+
+// Ranges parses the request's Range header with ParseRangeHeader. It
+// returns nil, nil if the header is absent.
+func (r *Request) Ranges() ([]ByteRange, error) {
+	v := r.Headers.Get("Range")
+	if v == "" {
+		return nil, nil
+	}
+	return ParseRangeHeader(v)
+}
+
+// parseRangePos parses a non-negative decimal position or length.
+func parseRangePos(s string) (int64, error) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a non-negative integer", s)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// RangePart is one part of a multipart/byteranges body: the bytes Start
+// through End (inclusive) of the representation.
+type RangePart struct {
+	Start, End  int64
+	ContentType string // the representation's media type; omitted if empty
+	Data        []byte
+}
+
+// BuildMultipartByteranges encodes parts as a multipart/byteranges body
+// (RFC 9110 §14.6) for a representation of total bytes (-1 if unknown).
+// contentType is the value for the response's Content-Type header,
+// carrying the generated boundary. Each part gets a Content-Range built
+// from its positions; callers are responsible for Data matching them.
+func BuildMultipartByteranges(parts []RangePart, total int64) (body []byte, contentType string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	length := "*"
+	if total >= 0 {
+		length = strconv.FormatInt(total, 10)
+	}
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+		if p.ContentType != "" {
+			h.Set("Content-Type", p.ContentType)
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", p.Start, p.End, length))
+		pw, _ := w.CreatePart(h) // writes to a bytes.Buffer cannot fail
+		pw.Write(p.Data)
+	}
+	w.Close()
+	return buf.Bytes(), "multipart/byteranges; boundary=" + w.Boundary()
+}
+
+// ParseMultipartByteranges decodes a multipart/byteranges body given the
+// response's Content-Type. Every part must carry a valid bytes
+// Content-Range whose length matches its data; total is the complete
+// length they share, or -1 if unknown.
+func ParseMultipartByteranges(body []byte, contentType string) (parts []RangePart, total int64, err error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, 0, fmt.Errorf("http: invalid Content-Type %q: %w", contentType, err)
+	}
+	if mediaType != "multipart/byteranges" {
+		return nil, 0, fmt.Errorf("http: Content-Type %q is not multipart/byteranges", mediaType)
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, 0, fmt.Errorf("http: multipart/byteranges Content-Type has no boundary")
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for i := 0; ; i++ {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: %w", i, err)
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: %w", i, err)
+		}
+		unit, start, end, length, err := ParseContentRange(p.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: %w", i, err)
+		}
+		if unit != "bytes" || start < 0 {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: Content-Range %q is not a satisfied byte range", i, p.Header.Get("Content-Range"))
+		}
+		if int64(len(data)) != end-start+1 {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: %d bytes of data for range %d-%d", i, len(data), start, end)
+		}
+		if i == 0 {
+			total = length
+		} else if length != total {
+			return nil, 0, fmt.Errorf("http: multipart/byteranges part %d: complete length %d differs from %d", i, length, total)
+		}
+		parts = append(parts, RangePart{Start: start, End: end, ContentType: p.Header.Get("Content-Type"), Data: data})
+	}
+	if len(parts) == 0 {
+		return nil, 0, fmt.Errorf("http: multipart/byteranges body has no parts")
+	}
+	return parts, total, nil
+}
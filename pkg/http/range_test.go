@@ -0,0 +1,240 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ── Range ───────────────────────────────────────────────────────────────────
+
+func TestParseRangeHeader(t *testing.T) {
+	// Examples from RFC 9110 §14.1.2.
+	tests := []struct {
+		value string
+		want  []ByteRange
+	}{
+		{"bytes=0-499", []ByteRange{{Start: 0, End: 499}}},
+		{"bytes=500-999", []ByteRange{{Start: 500, End: 999}}},
+		{"bytes=-500", []ByteRange{{Start: -1, End: -1, SuffixLength: 500}}},
+		{"bytes=9500-", []ByteRange{{Start: 9500, End: -1}}},
+		{"bytes=0-0,-1", []ByteRange{{Start: 0, End: 0}, {Start: -1, End: -1, SuffixLength: 1}}},
+		{"bytes= 0-999, 4500-5499, -1000", []ByteRange{{Start: 0, End: 999}, {Start: 4500, End: 5499}, {Start: -1, End: -1, SuffixLength: 1000}}},
+		{"bytes=500-600,601-999", []ByteRange{{Start: 500, End: 600}, {Start: 601, End: 999}}},
+		{"Bytes=1-2,", []ByteRange{{Start: 1, End: 2}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRangeHeader(tt.value)
+		if err != nil {
+			t.Errorf("ParseRangeHeader(%q) error: %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRangeHeader(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRangeHeader_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"0-499",
+		"items=0-5",
+		"bytes=",
+		"bytes=500-400",
+		"bytes=-",
+		"bytes=abc-",
+		"bytes=--5",
+		"bytes=5",
+		"bytes=1-+2",
+	} {
+		if got, err := ParseRangeHeader(value); err == nil {
+			t.Errorf("ParseRangeHeader(%q) = %+v, want error", value, got)
+		}
+	}
+}
+
+func TestByteRange_Resolve(t *testing.T) {
+	tests := []struct {
+		r          ByteRange
+		size       int64
+		start, end int64
+		ok         bool
+	}{
+		{ByteRange{Start: 0, End: 499}, 10000, 0, 499, true},
+		{ByteRange{Start: 9500, End: -1}, 10000, 9500, 9999, true},
+		{ByteRange{Start: -1, End: -1, SuffixLength: 500}, 10000, 9500, 9999, true},
+		{ByteRange{Start: -1, End: -1, SuffixLength: 500}, 100, 0, 99, true},
+		{ByteRange{Start: 0, End: 20000}, 10000, 0, 9999, true},
+		{ByteRange{Start: 10000, End: -1}, 10000, 0, 0, false},
+		{ByteRange{Start: -1, End: -1, SuffixLength: 0}, 10000, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := tt.r.Resolve(tt.size)
+		if start != tt.start || end != tt.end || ok != tt.ok {
+			t.Errorf("%v.Resolve(%d) = %d, %d, %v; want %d, %d, %v", tt.r, tt.size, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
+
+func TestByteRange_String(t *testing.T) {
+	got, err := ParseRangeHeader("bytes=0-499,9500-,-500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var specs []string
+	for _, r := range got {
+		specs = append(specs, r.String())
+	}
+	if s := strings.Join(specs, ","); s != "0-499,9500-,-500" {
+		t.Errorf("String = %q, want %q", s, "0-499,9500-,-500")
+	}
+}
+
+func TestRequest_Ranges(t *testing.T) {
+	req := &Request{Headers: Headers{{Key: "range", Value: "bytes=-500"}}}
+	got, err := req.Ranges()
+	if err != nil || len(got) != 1 || got[0].SuffixLength != 500 {
+		t.Errorf("Ranges() = %+v, %v", got, err)
+	}
+	if got, err := (&Request{}).Ranges(); got != nil || err != nil {
+		t.Errorf("no Range: Ranges() = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+// ── Content-Range ───────────────────────────────────────────────────────────
+
+func TestParseContentRange(t *testing.T) {
+	// Examples from RFC 9110 §14.4 and §15.3.7.
+	tests := []struct {
+		value             string
+		start, end, total int64
+	}{
+		{"bytes 42-1233/1234", 42, 1233, 1234},
+		{"bytes 42-1233/*", 42, 1233, -1},
+		{"bytes */1234", -1, -1, 1234},
+		{"bytes 21010-47021/47022", 21010, 47021, 47022},
+		{"bytes 0-1023/4096", 0, 1023, 4096},
+		{"bytes 0-0/1", 0, 0, 1},
+	}
+	for _, tt := range tests {
+		unit, start, end, total, err := ParseContentRange(tt.value)
+		if err != nil {
+			t.Errorf("ParseContentRange(%q) error: %v", tt.value, err)
+			continue
+		}
+		if unit != "bytes" || start != tt.start || end != tt.end || total != tt.total {
+			t.Errorf("ParseContentRange(%q) = %q, %d, %d, %d; want bytes, %d, %d, %d",
+				tt.value, unit, start, end, total, tt.start, tt.end, tt.total)
+		}
+	}
+}
+
+func TestParseContentRange_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"bytes",
+		"bytes 0-1023",
+		"bytes 1023-0/4096",              // start > end
+		"bytes 0-4096/4096",              // end >= total
+		"bytes -1-5/10",                  // negative
+		"bytes 0--5/10",                  // negative
+		"bytes 0-5/-10",                  // negative
+		"bytes */*",                      // unsatisfied needs a length
+		"bytes 0-5/abc",                  // not a number
+		"bytes 5/10",                     // missing '-'
+		"bytes 0-99999999999999999999/*", // overflows int64
+	} {
+		if _, _, _, _, err := ParseContentRange(value); err == nil {
+			t.Errorf("ParseContentRange(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestResponse_ContentRange(t *testing.T) {
+	resp := &Response{StatusCode: 206, Headers: Headers{{Key: "Content-Range", Value: "bytes 0-1023/4096"}}}
+	unit, start, end, total, err := resp.ContentRange()
+	if err != nil || unit != "bytes" || start != 0 || end != 1023 || total != 4096 {
+		t.Errorf("ContentRange() = %q, %d, %d, %d, %v", unit, start, end, total, err)
+	}
+	if _, _, _, _, err := (&Response{}).ContentRange(); err == nil {
+		t.Error("ContentRange() without header succeeded, want error")
+	}
+}
+
+// ── multipart/byteranges ────────────────────────────────────────────────────
+
+func TestParseMultipartByteranges_RFCExample(t *testing.T) {
+	// RFC 9110 §14.6, with the body lengths made to match the ranges.
+	body := "--THIS_STRING_SEPARATES\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Range: bytes 500-509/8000\r\n" +
+		"\r\n" +
+		"0123456789\r\n" +
+		"--THIS_STRING_SEPARATES\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Range: bytes 7000-7004/8000\r\n" +
+		"\r\n" +
+		"abcde\r\n" +
+		"--THIS_STRING_SEPARATES--\r\n"
+	parts, total, err := ParseMultipartByteranges([]byte(body), "multipart/byteranges; boundary=THIS_STRING_SEPARATES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RangePart{
+		{Start: 500, End: 509, ContentType: "application/pdf", Data: []byte("0123456789")},
+		{Start: 7000, End: 7004, ContentType: "application/pdf", Data: []byte("abcde")},
+	}
+	if total != 8000 || !reflect.DeepEqual(parts, want) {
+		t.Errorf("parts = %+v, total = %d; want %+v, 8000", parts, total, want)
+	}
+}
+
+func TestMultipartByteranges_RoundTrip(t *testing.T) {
+	parts := []RangePart{
+		{Start: 0, End: 4, ContentType: "text/plain", Data: []byte("hello")},
+		{Start: 10, End: 10, Data: []byte("!")},
+	}
+	body, ct := BuildMultipartByteranges(parts, 11)
+	if !strings.HasPrefix(ct, "multipart/byteranges; boundary=") {
+		t.Fatalf("contentType = %q", ct)
+	}
+	if !strings.Contains(string(body), "Content-Range: bytes 0-4/11\r\n") {
+		t.Errorf("body missing Content-Range: %q", body)
+	}
+	got, total, err := ParseMultipartByteranges(body, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 11 || !reflect.DeepEqual(got, parts) {
+		t.Errorf("round trip = %+v, %d; want %+v, 11", got, total, parts)
+	}
+
+	body, ct = BuildMultipartByteranges(parts[:1], -1)
+	if _, total, err := ParseMultipartByteranges(body, ct); err != nil || total != -1 {
+		t.Errorf("unknown length: total = %d, err = %v; want -1", total, err)
+	}
+}
+
+func TestParseMultipartByteranges_Invalid(t *testing.T) {
+	part := func(cr, data string) string {
+		return "--B\r\nContent-Range: " + cr + "\r\n\r\n" + data + "\r\n"
+	}
+	const ct = "multipart/byteranges; boundary=B"
+	tests := []struct {
+		name, body, ct string
+	}{
+		{"wrong media type", part("bytes 0-1/2", "ab") + "--B--\r\n", "multipart/mixed; boundary=B"},
+		{"no boundary", part("bytes 0-1/2", "ab") + "--B--\r\n", "multipart/byteranges"},
+		{"length mismatch", part("bytes 0-3/10", "ab") + "--B--\r\n", ct},
+		{"bad range", part("bytes 3-1/10", "ab") + "--B--\r\n", ct},
+		{"unsatisfied", part("bytes */10", "") + "--B--\r\n", ct},
+		{"totals differ", part("bytes 0-1/10", "ab") + part("bytes 2-3/11", "cd") + "--B--\r\n", ct},
+		{"no parts", "--B--\r\n", ct},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseMultipartByteranges([]byte(tt.body), tt.ct); err == nil {
+			t.Errorf("%s: succeeded, want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// IsSafe reports whether the request method is safe (RFC 9110 §9.2.1):
+// GET, HEAD, OPTIONS or TRACE. Method names are case-sensitive.
+func (r *Request) IsSafe() bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+// IsIdempotent reports whether the request method is idempotent (RFC 9110
+// §9.2.2): a safe method, PUT or DELETE.
+func (r *Request) IsIdempotent() bool {
+	return r.IsSafe() || r.Method == "PUT" || r.Method == "DELETE"
+}
+
+// ReplayVerdict grades how safely a captured request can be re-sent. The
+// zero value means no analysis took place.
+type ReplayVerdict int
+
+// Replay verdicts, from safest to least safe.
+const (
+	ReplaySafe        ReplayVerdict = iota + 1 // a safe method; replaying changes nothing
+	ReplayIdempotent                           // PUT or DELETE; replaying repeats the same effect
+	ReplaySafeWithKey                          // not idempotent, but an idempotency key lets the server deduplicate
+	ReplayUnsafe                               // replaying may repeat a side effect
+)
+
+// String returns the verdict's name: "safe", "idempotent",
+// "safe-with-key", "unsafe", or "none" for the zero value.
+func (v ReplayVerdict) String() string {
+	switch v {
+	case ReplaySafe:
+		return "safe"
+	case ReplayIdempotent:
+		return "idempotent"
+	case ReplaySafeWithKey:
+		return "safe-with-key"
+	case ReplayUnsafe:
+		return "unsafe"
+	}
+	return "none"
+}
+
+// ReplaySafety is the result of AnalyzeReplaySafety.
+type ReplaySafety struct {
+	Verdict ReplayVerdict
+
+	// WillFail is set when the request would be rejected if re-sent
+	// whatever its verdict, such as for an expired bearer token.
+	WillFail bool
+
+	// Reasons explains the verdict and WillFail, one sentence each.
+	Reasons []string
+}
+
+// idempotencyKeyHeaders upgrade a non-idempotent request to
+// ReplaySafeWithKey.
+var idempotencyKeyHeaders = []string{"Idempotency-Key", "X-Request-Id"}
+
+// AnalyzeReplaySafety classifies whether req can be re-sent. The verdict
+// starts from the method: ReplaySafe for IsSafe methods, ReplayIdempotent
+// for PUT and DELETE, ReplayUnsafe otherwise. An Idempotency-Key or
+// X-Request-Id header upgrades an unsafe request to ReplaySafeWithKey,
+// while a multipart/form-data body with file parts downgrades an
+// idempotent or keyed request to ReplayUnsafe, as re-sending repeats the
+// upload. A Bearer JWT whose exp claim is in the past sets WillFail; the
+// token's payload is decoded but its signature is not checked.
+func AnalyzeReplaySafety(req *Request) ReplaySafety {
+	return analyzeReplaySafety(req, time.Now())
+}
+
+func analyzeReplaySafety(req *Request, now time.Time) ReplaySafety {
+	var s ReplaySafety
+	switch {
+	case req.IsSafe():
+		s.Verdict = ReplaySafe
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%s is a safe method", req.Method))
+	case req.IsIdempotent():
+		s.Verdict = ReplayIdempotent
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%s is idempotent", req.Method))
+	default:
+		s.Verdict = ReplayUnsafe
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%s is not idempotent", req.Method))
+		for _, name := range idempotencyKeyHeaders {
+			if req.Headers.Get(name) != "" {
+				s.Verdict = ReplaySafeWithKey
+				s.Reasons = append(s.Reasons, fmt.Sprintf("the %s header lets the server deduplicate it", name))
+				break
+			}
+		}
+	}
+
+	if n := uploadedFiles(req); n > 0 && s.Verdict != ReplaySafe {
+		s.Verdict = ReplayUnsafe
+		s.Reasons = append(s.Reasons, fmt.Sprintf("the multipart body uploads %d file(s)", n))
+	}
+
+	if exp, ok := bearerExpiry(req.Headers.Get("Authorization")); ok && !now.Before(exp) {
+		s.WillFail = true
+		s.Reasons = append(s.Reasons, fmt.Sprintf("the bearer token expired at %s", exp.UTC().Format(time.RFC3339)))
+	}
+	return s
+}
+
+// uploadedFiles counts the parts of a multipart/form-data body that carry
+// a filename, or returns 0 for any other body.
+func uploadedFiles(req *Request) int {
+	ct := req.Headers.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(ct); err != nil || mediaType != "multipart/form-data" {
+		return 0
+	}
+	m, err := ParseMultipartBody(req.Body, ct)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, p := range m.Parts {
+		if _, params, err := mime.ParseMediaType(p.Headers.Get("Content-Disposition")); err == nil {
+			if _, ok := params["filename"]; ok {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// bearerExpiry returns the exp claim of a "Bearer <JWT>" credential,
+// decoding the payload segment without verifying the token.
+func bearerExpiry(auth string) (time.Time, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(auth), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return time.Time{}, false
+	}
+	segments := strings.Split(strings.TrimSpace(token), ".")
+	if len(segments) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segments[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
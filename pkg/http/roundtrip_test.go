@@ -81,3 +81,67 @@ func TestRoundTrip_Response(t *testing.T) {
 		})
 	}
 }
+
+const chunkedWireResponse = "HTTP/1.1 200 OK\r\n" +
+	"Transfer-Encoding: chunked\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Trailer: X-Checksum\r\n" +
+	"\r\n" +
+	"5;name=\"quoted;value\"\r\nHello\r\n" +
+	"6 ; a=b\r\n World\r\n" +
+	"0;last\r\nX-Checksum: abc\r\n\r\n"
+
+func TestRoundTrip_RawBody(t *testing.T) {
+	resp, err := UnmarshalResponseWithLimits([]byte(chunkedWireResponse), ParserLimits{KeepRawBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != "Hello World" {
+		t.Errorf("Body = %q, want %q", resp.Body, "Hello World")
+	}
+	if resp.Headers.IsChunked() {
+		t.Error("Headers still chunked; want them normalized")
+	}
+	got, err := MarshalWithOptions(resp, MarshalOptions{UseRawBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != chunkedWireResponse {
+		t.Errorf("strict replay =\n%q\nwant\n%q", got, chunkedWireResponse)
+	}
+
+	result := UnmarshalLenientWithOptions([]byte(chunkedWireResponse), LenientOptions{KeepRawBody: true})
+	if result.Response.RawHeaders != nil {
+		t.Errorf("lenient RawHeaders = %v, want nil", result.Response.RawHeaders)
+	}
+	got, err = MarshalWithOptions(result.Response, MarshalOptions{UseRawBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != chunkedWireResponse {
+		t.Errorf("lenient replay =\n%q\nwant\n%q", got, chunkedWireResponse)
+	}
+}
+
+func TestRawBody_OptIn(t *testing.T) {
+	resp, err := UnmarshalResponse([]byte(chunkedWireResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RawBody != nil || resp.RawHeaders != nil {
+		t.Errorf("RawBody = %q, RawHeaders = %v; want nil without KeepRawBody", resp.RawBody, resp.RawHeaders)
+	}
+	if r := UnmarshalLenient([]byte(chunkedWireResponse)); r.Response.RawBody != nil {
+		t.Errorf("lenient RawBody = %q, want nil without KeepRawBody", r.Response.RawBody)
+	}
+
+	// An identity body has no separate wire form.
+	plain := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nHello"
+	resp, _ = UnmarshalResponseWithLimits([]byte(plain), ParserLimits{KeepRawBody: true})
+	if resp.RawBody != nil {
+		t.Errorf("identity RawBody = %q, want nil", resp.RawBody)
+	}
+	if got, _ := MarshalWithOptions(resp, MarshalOptions{UseRawBody: true}); string(got) != plain {
+		t.Errorf("identity replay = %q, want %q", got, plain)
+	}
+}
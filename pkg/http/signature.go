@@ -0,0 +1,289 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSignatureMismatch is returned by VerifyHMACSignature when no
+// signature in the header matches the request.
+var ErrSignatureMismatch = errors.New("http: signature mismatch")
+
+// ErrSignatureExpired is returned by VerifyHMACSignature when a
+// timestamped signature is further than DefaultSignatureTolerance from the
+// current time.
+var ErrSignatureExpired = errors.New("http: signature timestamp outside tolerance")
+
+// DefaultSignatureTolerance is how far a Stripe-style signature timestamp
+// may be from the current time, the window Stripe's own libraries use.
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// CanonicalOptions selects what CanonicalString covers.
+type CanonicalOptions struct {
+	// SignedHeaders names the headers to include, matched
+	// case-insensitively. A listed header missing from the request is an
+	// error. Host is only included when listed.
+	SignedHeaders []string
+
+	// UnsignedPayload puts "UNSIGNED-PAYLOAD" in place of the body hash,
+	// for bodies that are streamed or too large to hash.
+	UnsignedPayload bool
+}
+
+// CanonicalString builds the canonical request string of AWS Signature
+// Version 4 for req, for use as HMAC input:
+//
+//	GET
+//	/documents%20and%20settings/
+//	Action=ListUsers&Version=2010-05-08
+//	host:iam.amazonaws.com
+//	x-amz-date:20150830T123600Z
+//
+//	host;x-amz-date
+//	e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//
+// The lines are the method; the path, normalized as by NormalizePath and
+// with every byte outside RFC 3986's unreserved set percent-encoded; the
+// query, decoded, re-encoded the same way and sorted by name and then
+// value; one "name:value" line per signed header, with lowercase names in
+// sorted order, values trimmed with inner runs of spaces collapsed, and
+// repeated headers joined by ","; the signed header names joined by ";";
+// and the lowercase hex SHA-256 of the body.
+//
+// The path is encoded once, as for S3, not twice as other AWS services
+// expect.
+func CanonicalString(req *Request, opts CanonicalOptions) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("http: CanonicalString(nil)")
+	}
+	rawPath, rawQuery, _ := strings.Cut(req.Path, "?")
+	path, err := canonicalPath(rawPath)
+	if err != nil {
+		return "", err
+	}
+	query, err := canonicalQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(opts.SignedHeaders))
+	for _, n := range opts.SignedHeaders {
+		names = append(names, strings.ToLower(strings.TrimSpace(n)))
+	}
+	sort.Strings(names)
+	names = dedupeSorted(names)
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte('\n')
+	b.WriteString(path)
+	b.WriteByte('\n')
+	b.WriteString(query)
+	b.WriteByte('\n')
+	for _, name := range names {
+		values := req.Headers.Values(name)
+		if len(values) == 0 {
+			return "", fmt.Errorf("http: signed header %q is missing", name)
+		}
+		for j, v := range values {
+			values[j] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(values, ","))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(names, ";"))
+	b.WriteByte('\n')
+	if opts.UnsignedPayload {
+		b.WriteString("UNSIGNED-PAYLOAD")
+	} else {
+		sum := sha256.Sum256(req.Body)
+		b.WriteString(hex.EncodeToString(sum[:]))
+	}
+	return b.String(), nil
+}
+
+func canonicalPath(raw string) (string, error) {
+	if raw == "" {
+		return "/", nil
+	}
+	if raw[0] != '/' {
+		return "", fmt.Errorf("http: CanonicalString: path %q is not origin-form", raw)
+	}
+	norm, err := NormalizePath(raw)
+	if err != nil {
+		return "", err
+	}
+	segments := strings.Split(norm, "/")
+	for i, s := range segments {
+		dec, err := url.PathUnescape(s)
+		if err != nil {
+			return "", fmt.Errorf("http: CanonicalString: %w", err)
+		}
+		segments[i] = PercentEncode(dec)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+func canonicalQuery(raw string) (string, error) {
+	type param struct{ name, value string }
+	var params []param
+	for _, kv := range strings.Split(raw, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			return "", fmt.Errorf("http: CanonicalString: query: %w", err)
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return "", fmt.Errorf("http: CanonicalString: query: %w", err)
+		}
+		params = append(params, param{PercentEncode(name), PercentEncode(value)})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].name != params[j].name {
+			return params[i].name < params[j].name
+		}
+		return params[i].value < params[j].value
+	})
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.name + "=" + p.value
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// dedupeSorted drops adjacent duplicates from a sorted slice.
+func dedupeSorted(s []string) []string {
+	out := s[:0:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// VerifyHMACSignature checks the webhook signature in req's header (such
+// as "Stripe-Signature" or "X-Hub-Signature-256") against an HMAC of the
+// body keyed with secret. algo names the hash: "sha256", "sha1" or
+// "sha512". Two formats are accepted:
+//
+//	sha256=757107ea...      GitHub: "algo=hex" over the body
+//	t=1492774577,v1=5257... Stripe: HMAC of "timestamp.body"; any v1 may match
+//
+// A timestamped signature must be within DefaultSignatureTolerance of the
+// current time, else the error is ErrSignatureExpired. A well-formed
+// signature that does not match gives ErrSignatureMismatch.
+func VerifyHMACSignature(req *Request, header string, secret []byte, algo string) error {
+	return verifyHMACSignature(req, header, secret, algo, time.Now())
+}
+
+func verifyHMACSignature(req *Request, header string, secret []byte, algo string, now time.Time) error {
+	newHash, err := hmacHash(algo)
+	if err != nil {
+		return err
+	}
+	value := strings.TrimSpace(req.Headers.Get(header))
+	if value == "" {
+		return fmt.Errorf("http: no %s header", header)
+	}
+
+	if prefix, sig, ok := strings.Cut(value, "="); ok && strings.EqualFold(prefix, algo) {
+		want, err := hex.DecodeString(sig)
+		if err != nil {
+			return fmt.Errorf("http: %s: invalid hex signature: %w", header, err)
+		}
+		if !SecureCompare(string(computeHMAC(newHash, secret, req.Body)), string(want)) {
+			return ErrSignatureMismatch
+		}
+		return nil
+	}
+
+	var timestamp string
+	var sigs [][]byte
+	for _, item := range strings.Split(value, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			if sig, err := hex.DecodeString(v); err == nil {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+	if timestamp == "" || len(sigs) == 0 {
+		return fmt.Errorf("http: %s: unrecognized signature format", header)
+	}
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("http: %s: invalid timestamp %q", header, timestamp)
+	}
+	if d := now.Sub(time.Unix(secs, 0)); d > DefaultSignatureTolerance || d < -DefaultSignatureTolerance {
+		return ErrSignatureExpired
+	}
+	payload := make([]byte, 0, len(timestamp)+1+len(req.Body))
+	payload = append(append(append(payload, timestamp...), '.'), req.Body...)
+	mac := string(computeHMAC(newHash, secret, payload))
+	matched := false
+	for _, sig := range sigs {
+		// Compare every candidate, so timing does not tell which matched.
+		matched = SecureCompare(mac, string(sig)) || matched
+	}
+	if !matched {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func hmacHash(algo string) (func() hash.Hash, error) {
+	switch strings.ToLower(algo) {
+	case "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("http: unsupported HMAC algorithm %q", algo)
+}
+
+func computeHMAC(newHash func() hash.Hash, secret, data []byte) []byte {
+	m := hmac.New(newHash, secret)
+	m.Write(data)
+	return m.Sum(nil)
+}
+
+// SecureCompare reports whether a and b are equal in time that depends
+// only on their lengths, not on where they first differ, for checking
+// secrets such as API keys, tokens and signatures.
+func SecureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// HeaderEqualsSecure reports whether r has exactly one key header and its
+// value equals expected, compared with SecureCompare. A missing or
+// repeated header never matches, so a client cannot pick among several
+// values.
+func (r *Request) HeaderEqualsSecure(key, expected string) bool {
+	values := r.Headers.Values(key)
+	return len(values) == 1 && SecureCompare(values[0], expected)
+}
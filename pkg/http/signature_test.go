@@ -0,0 +1,263 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ── CanonicalString ─────────────────────────────────────────────────────────
+
+func TestCanonicalString_AWSExample(t *testing.T) {
+	// The IAM ListUsers example from the AWS Signature Version 4 docs,
+	// whose canonical request hashes to f536975d....
+	req := &Request{
+		Method: "GET",
+		Path:   "/?Action=ListUsers&Version=2010-05-08",
+		Headers: Headers{
+			{Key: "Host", Value: "iam.amazonaws.com"},
+			{Key: "Content-Type", Value: "application/x-www-form-urlencoded; charset=utf-8"},
+			{Key: "X-Amz-Date", Value: "20150830T123600Z"},
+		},
+	}
+	got, err := CanonicalString(req, CanonicalOptions{SignedHeaders: []string{"Host", "Content-Type", "X-Amz-Date"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET\n/\nAction=ListUsers&Version=2010-05-08\n" +
+		"content-type:application/x-www-form-urlencoded; charset=utf-8\n" +
+		"host:iam.amazonaws.com\n" +
+		"x-amz-date:20150830T123600Z\n\n" +
+		"content-type;host;x-amz-date\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got != want {
+		t.Errorf("CanonicalString =\n%s\nwant\n%s", got, want)
+	}
+	sum := sha256.Sum256([]byte(got))
+	if h := hex.EncodeToString(sum[:]); h != "f536975d06c0309214f805bb90ccff089219ecd68b2577efef23edd43b7e1a59" {
+		t.Errorf("hash = %s, want the published f536975d...", h)
+	}
+}
+
+func TestCanonicalString_Normalization(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		opts CanonicalOptions
+		want string
+	}{
+		{
+			"path and query",
+			Request{Method: "GET", Path: "/a/./b/../c d/%7euser/$x?b=2&a=2&a=1&sp=a+b&flag"},
+			CanonicalOptions{UnsignedPayload: true},
+			"GET\n/a/c%20d/~user/%24x\na=1&a=2&b=2&flag=&sp=a%20b\n\n\nUNSIGNED-PAYLOAD",
+		},
+		{
+			"header values",
+			Request{Method: "POST", Path: "", Body: []byte("hi"), Headers: Headers{
+				{Key: "X-Multi", Value: "  one   two "},
+				{Key: "x-multi", Value: "three"},
+				{Key: "Host", Value: "example.com"},
+			}},
+			CanonicalOptions{SignedHeaders: []string{"x-multi", "HOST", "host"}},
+			"POST\n/\n\nhost:example.com\nx-multi:one two,three\n\nhost;x-multi\n" +
+				"8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4",
+		},
+		{
+			"escaped slash stays in its segment",
+			Request{Method: "GET", Path: "/a%2Fb"},
+			CanonicalOptions{UnsignedPayload: true},
+			"GET\n/a%2Fb\n\n\n\nUNSIGNED-PAYLOAD",
+		},
+	}
+	for _, tt := range tests {
+		got, err := CanonicalString(&tt.req, tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanonicalString =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalString_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+		opts CanonicalOptions
+	}{
+		{"nil", nil, CanonicalOptions{}},
+		{"missing header", &Request{Method: "GET", Path: "/"}, CanonicalOptions{SignedHeaders: []string{"Host"}}},
+		{"escapes root", &Request{Method: "GET", Path: "/../x"}, CanonicalOptions{}},
+		{"bad escape", &Request{Method: "GET", Path: "/?a=%zz"}, CanonicalOptions{}},
+		{"not origin-form", &Request{Method: "OPTIONS", Path: "*"}, CanonicalOptions{}},
+	}
+	for _, tt := range tests {
+		if _, err := CanonicalString(tt.req, tt.opts); err == nil {
+			t.Errorf("%s: succeeded, want error", tt.name)
+		}
+	}
+}
+
+// ── VerifyHMACSignature ─────────────────────────────────────────────────────
+
+func TestVerifyHMACSignature(t *testing.T) {
+	// GitHub's documented example secret and payload.
+	github := []byte("It's a Secret to Everybody")
+	// Stripe-format vector: HMAC-SHA256 of "1492774577." + body.
+	stripe := []byte("whsec_test_secret")
+	const stripeBody = `{"id":"evt_test","object":"event"}`
+	const stripeSig = "691252e266ce41cb94d709c84e9580d4172b117a510bbc81723f657d2cd5d215"
+	stripeTime := time.Unix(1492774577, 0)
+
+	tests := []struct {
+		name, header, value, body, algo string
+		secret                          []byte
+		now                             time.Time
+		want                            error
+	}{
+		{"github sha256", "X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", "Hello, World!", "sha256", github, time.Time{}, nil},
+		{"github sha1", "X-Hub-Signature", "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", "Hello, World!", "sha1", github, time.Time{}, nil},
+		{"github tampered body", "X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", "Hello, World?", "sha256", github, time.Time{}, ErrSignatureMismatch},
+		{"github wrong secret", "X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", "Hello, World!", "sha256", []byte("nope"), time.Time{}, ErrSignatureMismatch},
+		{"stripe", "Stripe-Signature", "t=1492774577,v1=" + stripeSig, stripeBody, "sha256", stripe, stripeTime.Add(time.Minute), nil},
+		{"stripe second v1 matches", "Stripe-Signature", "t=1492774577,v1=00ff,v0=abcd,v1=" + stripeSig, stripeBody, "sha256", stripe, stripeTime, nil},
+		{"stripe expired", "Stripe-Signature", "t=1492774577,v1=" + stripeSig, stripeBody, "sha256", stripe, stripeTime.Add(6 * time.Minute), ErrSignatureExpired},
+		{"stripe from the future", "Stripe-Signature", "t=1492774577,v1=" + stripeSig, stripeBody, "sha256", stripe, stripeTime.Add(-6 * time.Minute), ErrSignatureExpired},
+		{"stripe tampered body", "Stripe-Signature", "t=1492774577,v1=" + stripeSig, stripeBody + " ", "sha256", stripe, stripeTime, ErrSignatureMismatch},
+	}
+	for _, tt := range tests {
+		req := &Request{Method: "POST", Path: "/hook", Headers: Headers{{Key: tt.header, Value: tt.value}}, Body: []byte(tt.body)}
+		err := verifyHMACSignature(req, tt.header, tt.secret, tt.algo, tt.now)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyHMACSignature_Malformed(t *testing.T) {
+	tests := []struct {
+		name, value, algo string
+	}{
+		{"missing header", "", "sha256"},
+		{"bad algorithm", "sha256=00", "md5"},
+		{"bad hex", "sha256=zz", "sha256"},
+		{"other algorithm", "sha1=00", "sha256"},
+		{"no timestamp", "v1=00", "sha256"},
+		{"bad timestamp", "t=soon,v1=00", "sha256"},
+	}
+	for _, tt := range tests {
+		req := &Request{Headers: Headers{{Key: "X-Signature", Value: tt.value}}}
+		err := VerifyHMACSignature(req, "X-Signature", []byte("k"), tt.algo)
+		if err == nil || errors.Is(err, ErrSignatureMismatch) || errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("%s: err = %v, want a format error", tt.name, err)
+		} else if !strings.HasPrefix(err.Error(), "http: ") {
+			t.Errorf("%s: err = %q, want http: prefix", tt.name, err)
+		}
+	}
+}
+
+// ── SecureCompare ───────────────────────────────────────────────────────────
+
+func TestSecureCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", true},
+		{"sk_live_abc", "sk_live_abc", true},
+		{"sk_live_abc", "sk_live_abd", false},
+		{"sk_live_abc", "sk_live_ab", false},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := SecureCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("SecureCompare(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRequest_HeaderEqualsSecure(t *testing.T) {
+	req := &Request{Headers: Headers{{Key: "X-API-Key", Value: "k1"}, {Key: "X-Token", Value: "a"}, {Key: "X-Token", Value: "b"}}}
+	tests := []struct {
+		key, expected string
+		want          bool
+	}{
+		{"X-API-Key", "k1", true},
+		{"x-api-key", "k1", true},
+		{"X-API-Key", "k2", false},
+		{"X-API-Key", "", false},
+		{"X-Missing", "", false},
+		{"X-Token", "a", false}, // repeated header
+	}
+	for _, tt := range tests {
+		if got := req.HeaderEqualsSecure(tt.key, tt.expected); got != tt.want {
+			t.Errorf("HeaderEqualsSecure(%q, %q) = %v, want %v", tt.key, tt.expected, got, tt.want)
+		}
+	}
+}
+
+// TestCredentialsNotEchoed checks that a mangled credential header's value
+// appears in no warning or error of the parsers that quote the line.
+func TestCredentialsNotEchoed(t *testing.T) {
+	const token = "sk_live_51H8secret"
+	for _, line := range []string{
+		"Authorization Bearer " + token,
+		"authorization\tBearer " + token,
+		"Proxy-Authorization Basic " + token,
+		"Cookie session=" + token,
+		"X-API-Key " + token,
+		"Authorization=Bearer " + token,
+		"Cookie;sid=" + token,
+	} {
+		input := "GET / HTTP/1.1\r\nHost: example.com\r\n" + line + "\r\n\r\n"
+
+		result := UnmarshalLenient([]byte(input))
+		redacted := false
+		for _, w := range result.Warnings {
+			if strings.Contains(w, token) {
+				t.Errorf("%q: warning %q echoes the credential", line, w)
+			}
+			redacted = redacted || strings.Contains(w, "[redacted]")
+		}
+		if !redacted {
+			t.Errorf("%q: no redacted warning in %q", line, result.Warnings)
+		}
+
+		if _, err := UnmarshalRequest([]byte(input)); err == nil || strings.Contains(err.Error(), token) {
+			t.Errorf("%q: UnmarshalRequest error %v", line, err)
+		}
+		if _, err := NewDecoder(strings.NewReader(input)).DecodeRequest(); err == nil || strings.Contains(err.Error(), token) {
+			t.Errorf("%q: DecodeRequest error %v", line, err)
+		}
+	}
+
+	// Userinfo in an absolute-form target is redacted where the target is
+	// echoed.
+	for _, target := range []string{"http://u:" + token + "@h/", "http://u:" + token + "@a_b!/x"} {
+		input := "GET " + target + " HTTP/1.1\r\nHost: h\r\n\r\n"
+		if _, err := UnmarshalRequest([]byte(input)); err == nil || strings.Contains(err.Error(), token) || !strings.Contains(err.Error(), "[redacted]@") {
+			t.Errorf("%q: UnmarshalRequest error %v", target, err)
+		}
+		for _, w := range UnmarshalLenient([]byte(input)).Warnings {
+			if strings.Contains(w, token) {
+				t.Errorf("%q: warning %q echoes the credential", target, w)
+			}
+		}
+	}
+	warnings := UnmarshalLenient([]byte("GET http://u:" + token + "@a_b!/x HTTP/1.1\r\n\r\n")).Warnings
+	if !strings.Contains(strings.Join(warnings, "\n"), "http://[redacted]@a_b!/x") {
+		t.Errorf("warnings %q do not show the redacted target", warnings)
+	}
+
+	// Other malformed lines are still shown, for debugging.
+	result := UnmarshalLenient([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Trace abc123\r\n\r\n"))
+	if !strings.Contains(strings.Join(result.Warnings, "\n"), "X-Trace abc123") {
+		t.Errorf("warnings %q do not show the malformed line", result.Warnings)
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// Severity ranks a diagnostic finding.
+type Severity int
+
+// Severity levels, from least to most serious.
+const (
+	SeverityInfo    Severity = Severity(fastparser.SeverityInfo)
+	SeverityWarning Severity = Severity(fastparser.SeverityWarning)
+	SeverityError   Severity = Severity(fastparser.SeverityError)
+)
+
+// String returns "info", "warning" or "error".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Issue codes reported by AnalyzeSmuggling.
+const (
+	SmuggleCLTE              = fastparser.SmuggleCLTE              // both Transfer-Encoding and Content-Length
+	SmuggleTEObfuscated      = fastparser.SmuggleTEObfuscated      // "xchunked", "chunked " or a look-alike name
+	SmuggleTECasing          = fastparser.SmuggleTECasing          // non-canonical casing of TE name or value
+	SmuggleTEFolded          = fastparser.SmuggleTEFolded          // TE in or followed by an obs-fold line
+	SmuggleCLConflict        = fastparser.SmuggleCLConflict        // differing Content-Length values
+	SmuggleCLDuplicate       = fastparser.SmuggleCLDuplicate       // identical repeated Content-Length
+	SmuggleCLInvalid         = fastparser.SmuggleCLInvalid         // Content-Length that is not digits
+	SmuggleSpaceBeforeColon  = fastparser.SmuggleSpaceBeforeColon  // "Transfer-Encoding : chunked"
+	SmuggleChunkLeadingZeros = fastparser.SmuggleChunkLeadingZeros // chunk size "0005"
+	SmuggleChunkAmbiguous    = fastparser.SmuggleChunkAmbiguous    // second size in the size line or extension
+	SmuggleChunkOversize     = fastparser.SmuggleChunkOversize     // chunk size too large to represent
+	SmuggleEmbeddedRequest   = fastparser.SmuggleEmbeddedRequest   // a request line inside the body
+)
+
+// SmugglingIssue is one request-smuggling indicator found by
+// AnalyzeSmuggling.
+type SmugglingIssue struct {
+	Code     string   // one of the Smuggle* constants
+	Severity Severity // SeverityError for direct desync vectors
+	Offset   int      // byte offset into the analyzed data
+	Message  string   // human-readable explanation
+}
+
+// AnalyzeSmuggling inspects raw message bytes for indicators of HTTP
+// request smuggling: conflicting Content-Length and Transfer-Encoding
+// framing (CL.TE / TE.CL), obfuscated or folded Transfer-Encoding headers
+// (TE.TE), conflicting Content-Length values, whitespace before the colon
+// of a framing header, suspicious chunk-size lines, and a body carrying
+// what looks like a second request.
+//
+// It is a linting pass over the wire bytes, not a parser: it reports every
+// indicator rather than stopping at the first, and it neither modifies data
+// nor affects what Unmarshal or UnmarshalLenient return. A nil result means
+// no indicators were found.
+func AnalyzeSmuggling(data []byte) []SmugglingIssue {
+	internal := fastparser.AnalyzeSmuggling(data)
+	if len(internal) == 0 {
+		return nil
+	}
+	issues := make([]SmugglingIssue, len(internal))
+	for i, is := range internal {
+		issues[i] = SmugglingIssue{
+			Code:     is.Code,
+			Severity: Severity(is.Severity),
+			Offset:   is.Offset,
+			Message:  is.Message,
+		}
+	}
+	return issues
+}
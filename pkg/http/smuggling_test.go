@@ -0,0 +1,55 @@
+package http
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeSmuggling_PublicAPI(t *testing.T) {
+	data := []byte("POST / HTTP/1.1\r\n" +
+		"Host: vulnerable-website.com\r\n" +
+		"Content-Length: 13\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"0\r\n" +
+		"\r\n" +
+		"SMUGGLED")
+	issues := AnalyzeSmuggling(data)
+	if len(issues) != 1 {
+		t.Fatalf("issues = %+v, want exactly one", issues)
+	}
+	is := issues[0]
+	if is.Code != SmuggleCLTE || is.Severity != SeverityError || is.Message == "" {
+		t.Errorf("issue = %+v", is)
+	}
+	if want := bytes.Index(data, []byte("Transfer-Encoding")); is.Offset != want {
+		t.Errorf("Offset = %d, want %d", is.Offset, want)
+	}
+	if AnalyzeSmuggling([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n")) != nil {
+		t.Error("clean request produced issues")
+	}
+}
+
+// TestAnalyzeSmuggling_DoesNotAffectLenient confirms that analysis leaves
+// both the input and the lenient parser's output untouched.
+func TestAnalyzeSmuggling_DoesNotAffectLenient(t *testing.T) {
+	data := []byte("POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding : xchunked\r\nContent-Length: 3\r\n\r\nabc")
+	orig := append([]byte(nil), data...)
+	before := UnmarshalLenient(data)
+	AnalyzeSmuggling(data)
+	if !bytes.Equal(data, orig) {
+		t.Fatal("AnalyzeSmuggling modified its input")
+	}
+	if after := UnmarshalLenient(data); !reflect.DeepEqual(before, after) {
+		t.Errorf("UnmarshalLenient changed: %+v vs %+v", before, after)
+	}
+}
+
+func TestSeverity_String(t *testing.T) {
+	for s, want := range map[Severity]string{SeverityInfo: "info", SeverityWarning: "warning", SeverityError: "error", Severity(9): "Severity(9)"} {
+		if got := s.String(); got != want {
+			t.Errorf("Severity(%d).String() = %q, want %q", int(s), got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// SniffContentType returns the media type the first 512 bytes of body
+// evidently hold, following a practical subset of the WHATWG MIME Sniffing
+// Standard:
+//
+//   - PNG, JPEG, GIF, PDF, zip and gzip by their leading magic bytes, as
+//     "image/png", "image/jpeg", "image/gif", "application/pdf",
+//     "application/zip" and "application/gzip";
+//   - after any byte order mark and leading whitespace, "text/xml" for an
+//     XML declaration, "text/html" for a doctype, comment or common HTML
+//     tag, and "application/json" for the start of an object or array;
+//   - otherwise "text/plain", or "application/octet-stream" if control
+//     bytes occur.
+//
+// It returns "" for an empty body. Unlike net/http.DetectContentType it
+// recognizes JSON and returns media types without parameters.
+func SniffContentType(body []byte) string {
+	return fastparser.SniffContentType(body)
+}
+
+// ContentTypeMismatch compares the response's Content-Type with what its
+// body sniffs as. declared is the media type lowercased and without
+// parameters, and sniffed is SniffContentType(r.Body). mismatch is true
+// when sniffed contradicts declared: JSON under text/html or text/plain,
+// HTML under application/json, an image under another image type, binary
+// data under a text type, and so on. A generic declaration such as
+// application/octet-stream, and text under a type not known to be binary,
+// are not mismatches. A body with a Content-Encoding other than identity
+// is not sniffed, and there is no mismatch without a Content-Type or a
+// body.
+func (r *Response) ContentTypeMismatch() (declared, sniffed string, mismatch bool) {
+	return contentTypeMismatch(r.Headers, r.Body)
+}
+
+// ContentTypeMismatch compares the request's Content-Type with what its
+// body sniffs as, as Response.ContentTypeMismatch does.
+func (r *Request) ContentTypeMismatch() (declared, sniffed string, mismatch bool) {
+	return contentTypeMismatch(r.Headers, r.Body)
+}
+
+func contentTypeMismatch(headers Headers, body []byte) (declared, sniffed string, mismatch bool) {
+	declared, _, _ = strings.Cut(headers.Get("Content-Type"), ";")
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if ce := strings.TrimSpace(headers.Get("Content-Encoding")); ce != "" && !strings.EqualFold(ce, "identity") {
+		return declared, "", false
+	}
+	sniffed = fastparser.SniffContentType(body)
+	return declared, sniffed, fastparser.ContentTypeMismatch(declared, sniffed)
+}
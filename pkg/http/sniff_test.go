@@ -0,0 +1,94 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"", ""},
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png"},
+		{"\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg"},
+		{"GIF89a\x01\x00\x01\x00", "image/gif"},
+		{"%PDF-1.7\n", "application/pdf"},
+		{"PK\x03\x04\x14\x00", "application/zip"},
+		{"\x1f\x8b\x08\x00\x00\x00", "application/gzip"},
+		{"<?xml version=\"1.0\"?><a/>", "text/xml"},
+		{"\n  <!DOCTYPE html>\n<html>", "text/html"},
+		{"<HTML><HEAD>", "text/html"},
+		{"<p>hi</p>", "text/html"},
+		{"<pre>x</pre>", "text/plain"},
+		{`{"id": 1}`, "application/json"},
+		{"\xef\xbb\xbf [1, 2]", "application/json"},
+		{"[]", "application/json"},
+		{"{not json}", "text/plain"},
+		{"[link](x)", "text/plain"},
+		{"hello, world\n", "text/plain"},
+		{"caf\xc3\xa9", "text/plain"},
+		{"\x00\x01\x02\x03", "application/octet-stream"},
+		// Only the first 512 bytes count, so a JSON array cut short still
+		// sniffs as JSON and a late control byte is not seen.
+		{"[" + strings.Repeat(`"item", `, 200), "application/json"},
+		{strings.Repeat("a", 512) + "\x00", "text/plain"},
+	}
+	for _, tt := range tests {
+		if got := SniffContentType([]byte(tt.body)); got != tt.want {
+			t.Errorf("SniffContentType(%.20q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestContentTypeMismatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		sniffed     string
+		mismatch    bool
+	}{
+		{"HTML error page as JSON", "application/json; charset=utf-8", "<!DOCTYPE html><html><body>502 Bad Gateway</body></html>", "text/html", true},
+		{"JSON as text", "text/plain", `{"ok": true}`, "application/json", true},
+		{"JSON as HTML", "text/html", `[{"id": 1}]`, "application/json", true},
+		{"JSON as problem+json", "application/problem+json", `{"title": "x"}`, "application/json", false},
+		{"PNG as JPEG", "image/jpeg", "\x89PNG\r\n\x1a\n", "image/png", true},
+		{"JPEG as image/jpg", "image/jpg", "\xff\xd8\xff\xdb", "image/jpeg", false},
+		{"PNG as octet-stream", "application/octet-stream", "\x89PNG\r\n\x1a\n", "image/png", false},
+		{"PDF as JSON", "application/json", "%PDF-1.4", "application/pdf", true},
+		{"docx is a zip", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "PK\x03\x04", "application/zip", false},
+		{"binary as text", "text/csv", "\x00\x01\x02", "application/octet-stream", true},
+		{"text as PNG", "image/png", "not found", "text/plain", true},
+		{"text as YAML", "application/yaml", "key: value\n", "text/plain", false},
+		{"XML as XML", "application/atom+xml", "<?xml version=\"1.0\"?><feed/>", "text/xml", false},
+		{"empty body", "application/json", "", "", false},
+		{"no Content-Type", "", "<html>", "text/html", false},
+	}
+	for _, tt := range tests {
+		resp := &Response{StatusCode: 200, Body: []byte(tt.body)}
+		if tt.contentType != "" {
+			resp.Headers.Set("Content-Type", tt.contentType)
+		}
+		declared, sniffed, mismatch := resp.ContentTypeMismatch()
+		if want, _, _ := strings.Cut(tt.contentType, ";"); declared != want || sniffed != tt.sniffed || mismatch != tt.mismatch {
+			t.Errorf("%s: ContentTypeMismatch() = %q, %q, %v, want %q, %q, %v",
+				tt.name, declared, sniffed, mismatch, want, tt.sniffed, tt.mismatch)
+		}
+	}
+
+	req := &Request{Method: "POST", Path: "/", Body: []byte(`{"a": 1}`)}
+	req.Headers.Set("Content-Type", "Text/Plain")
+	if declared, sniffed, mismatch := req.ContentTypeMismatch(); declared != "text/plain" || sniffed != "application/json" || !mismatch {
+		t.Errorf("Request.ContentTypeMismatch() = %q, %q, %v, want text/plain, application/json, true", declared, sniffed, mismatch)
+	}
+
+	// A body with a Content-Encoding is not sniffed.
+	resp := &Response{StatusCode: 200, Body: []byte("\x1f\x8b\x08\x00")}
+	resp.Headers.Set("Content-Type", "application/json")
+	resp.Headers.Set("Content-Encoding", "gzip")
+	if _, sniffed, mismatch := resp.ContentTypeMismatch(); sniffed != "" || mismatch {
+		t.Errorf("gzip: ContentTypeMismatch() sniffed %q, mismatch %v, want neither", sniffed, mismatch)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// ErrIncomplete is returned with the messages of a stream that ends inside
+// a message; the incomplete message is the last one returned.
+var ErrIncomplete = fastparser.ErrIncomplete
+
+// Role says which side of a connection a captured stream came from.
+type Role int
+
+// Roles. RoleClient streams carry requests, RoleServer streams responses.
+const (
+	RoleClient Role = iota + 1
+	RoleServer
+)
+
+// String returns "client", "server", or "none" for the zero value.
+func (r Role) String() string {
+	switch r {
+	case RoleClient:
+		return "client"
+	case RoleServer:
+		return "server"
+	}
+	return "none"
+}
+
+// SplitMessages cuts a captured stream, such as one direction of a TCP
+// connection, into the bytes of each HTTP/1.x message without parsing
+// them. Boundaries follow the framing rules alone: the start line and
+// header section, then a Content-Length or chunked body; a server message
+// framed by neither runs to the end of data. Empty lines between messages
+// are skipped. A message is only followed by another when its framing is
+// explicit and complete, so a body holding a blank line and header-like
+// text, a fake start line or a "0\r\n\r\n" inside a Content-Length body
+// never yields an extra message; conflicting Content-Length values, and in
+// a request a Transfer-Encoding not ending in chunked, are errors.
+//
+// For RoleServer, methods optionally lists the methods of the requests in
+// order, so that a HEAD response with a Content-Length is known to have no
+// body and a 2xx answer to CONNECT ends the stream. Interim 1xx responses
+// do not consume a method, and the split stops after a 101 response since
+// the bytes after it are another protocol. Without methods every response
+// is framed as if answering GET.
+//
+// If data ends inside a message, the complete messages are returned
+// followed by the incomplete one, with ErrIncomplete. Malformed framing
+// returns the messages before the bad one and an error with its offset.
+func SplitMessages(data []byte, role Role, methods ...string) ([][]byte, error) {
+	switch role {
+	case RoleClient, RoleServer:
+	default:
+		return nil, fmt.Errorf("http: SplitMessages: invalid role %d", role)
+	}
+	return fastparser.SplitMessages(data, role == RoleServer, methods)
+}
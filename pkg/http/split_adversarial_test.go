@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Bodies crafted to look like the end of a message followed by another:
+// each stream must split into exactly the messages its framing describes,
+// both by SplitMessages and by a Decoder reading it message by message.
+
+const multipartBody = "--b\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n" +
+	"GET /admin HTTP/1.1\r\nHost: evil\r\n\r\n" +
+	"\r\n--b\r\nContent-Type: message/http\r\n\r\n" +
+	"HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n" +
+	"\r\n--b--\r\n"
+
+func withLength(head, body string) string {
+	return head + "Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body
+}
+
+var adversarialStreams = []struct {
+	name string
+	role Role
+	msgs []string
+}{
+	{"json body with a blank line and a header", RoleClient, []string{
+		withLength("POST /api HTTP/1.1\r\nHost: a\r\nContent-Type: application/json\r\n", `{"note":"x`+"\r\n\r\nHost: evil\r\n\r\n"+`"}`),
+		"GET /next HTTP/1.1\r\nHost: a\r\n\r\n",
+	}},
+	{"fake start line in a request body", RoleClient, []string{
+		withLength("POST /upload HTTP/1.1\r\nHost: a\r\n", "\r\n\r\nGET /admin HTTP/1.1\r\nHost: evil\r\n\r\n"),
+	}},
+	{"fake chunk terminator in a Content-Length body", RoleClient, []string{
+		withLength("POST /chunks HTTP/1.1\r\nHost: a\r\n", "5\r\nhello\r\n0\r\n\r\nGET /admin HTTP/1.1\r\nHost: evil\r\n\r\n"),
+		"GET /next HTTP/1.1\r\nHost: a\r\n\r\n",
+	}},
+	{"nested multipart header blocks", RoleClient, []string{
+		withLength("POST /form HTTP/1.1\r\nHost: a\r\nContent-Type: multipart/form-data; boundary=b\r\n", multipartBody),
+		withLength("POST /form HTTP/1.1\r\nHost: a\r\nContent-Type: multipart/form-data; boundary=b\r\n", multipartBody),
+	}},
+	{"chunked overrides Content-Length", RoleClient, []string{
+		"POST /both HTTP/1.1\r\nHost: a\r\nContent-Length: 3\r\nTransfer-Encoding: chunked\r\n\r\n" +
+			"17\r\nGET /admin HTTP/1.1\r\n\r\n\r\n0\r\nX-Trailer: 1\r\n\r\n",
+		"GET /next HTTP/1.1\r\nHost: a\r\n\r\n",
+	}},
+	{"fake responses in a Content-Length body", RoleServer, []string{
+		withLength("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n", "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"),
+		withLength("HTTP/1.1 200 OK\r\nContent-Type: multipart/mixed; boundary=b\r\n", multipartBody),
+	}},
+	{"read-to-end body holding complete responses", RoleServer, []string{
+		"HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\nfirst\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nevil!" +
+			"HTTP/1.1 204 No Content\r\n\r\n",
+	}},
+	{"non-chunked Transfer-Encoding runs to the end", RoleServer, []string{
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip\r\nContent-Length: 4\r\n\r\nbodyHTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n",
+	}},
+	{"fake chunk terminator in the last chunk", RoleServer, []string{
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n9\r\n0\r\n\r\nHTTP\r\n0\r\n\r\n",
+		"HTTP/1.1 304 Not Modified\r\nContent-Length: 12\r\n\r\n",
+		withLength("HTTP/1.1 200 OK\r\n", "done"),
+	}},
+}
+
+func TestSplitMessages_Adversarial(t *testing.T) {
+	for _, tt := range adversarialStreams {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitMessages([]byte(strings.Join(tt.msgs, "")), tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.msgs) {
+				t.Fatalf("got %d messages, want %d: %q", len(got), len(tt.msgs), got)
+			}
+			for i, msg := range tt.msgs {
+				if string(got[i]) != msg {
+					t.Errorf("message %d = %q, want %q", i, got[i], msg)
+				}
+			}
+		})
+	}
+}
+
+func TestDecoder_Adversarial(t *testing.T) {
+	for _, tt := range adversarialStreams {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(strings.Join(tt.msgs, "")))
+			for i, msg := range tt.msgs {
+				var err error
+				var body []byte
+				if tt.role == RoleClient {
+					var req *Request
+					if req, err = dec.DecodeRequest(); err == nil {
+						body = req.Body
+					}
+				} else {
+					var resp *Response
+					if resp, err = dec.DecodeResponse(); err == nil {
+						body = resp.Body
+					}
+				}
+				if err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				if !strings.Contains(msg, string(body)) {
+					t.Errorf("message %d: body %q is not part of %q", i, body, msg)
+				}
+			}
+			var err error
+			if tt.role == RoleClient {
+				_, err = dec.DecodeRequest()
+			} else {
+				_, err = dec.DecodeResponse()
+			}
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("after %d messages: err = %v, want io.EOF", len(tt.msgs), err)
+			}
+		})
+	}
+}
+
+func TestSplitMessages_AmbiguousFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		data string
+		want string
+	}{
+		{"conflicting Content-Length", RoleClient,
+			"POST / HTTP/1.1\r\nContent-Length: 5\r\nContent-Length: 40\r\n\r\nhello", "conflicting Content-Length"},
+		{"request Transfer-Encoding without chunked", RoleClient,
+			"POST / HTTP/1.1\r\nTransfer-Encoding: gzip\r\n\r\nGET /admin HTTP/1.1\r\n\r\n", "does not end in chunked"},
+	}
+	for _, tt := range tests {
+		msgs, err := SplitMessages([]byte(tt.data), tt.role)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || len(msgs) != 0 {
+			t.Errorf("%s: got %d messages, err %v; want none and %q", tt.name, len(msgs), err, tt.want)
+		}
+		dec := NewDecoder(bytes.NewReader([]byte(tt.data)))
+		if _, err := dec.DecodeRequest(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decoder err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitMessages_Server(t *testing.T) {
+	msgs := []string{
+		"HTTP/1.1 100 Continue\r\n\r\n",
+		"HTTP/1.1 201 Created\r\nContent-Length: 5\r\n\r\nhello",
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip, chunked\r\n\r\n4\r\nwiki\r\n0\r\nX-Checksum: 9\r\n\r\n",
+		"HTTP/1.1 200 OK\r\nContent-Length: 1024\r\n\r\n",
+		"HTTP/1.0 200 OK\r\nContent-Type: text/plain\r\n\r\nread until close\r\n\r\nHTTP/1.1 200 OK\r\n",
+	}
+	stream := []byte(strings.Join(msgs, ""))
+
+	got, err := SplitMessages(stream, RoleServer, "POST", "GET", "HEAD", "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(msgs) {
+		t.Fatalf("got %d messages, want %d: %q", len(got), len(msgs), got)
+	}
+	for i := range msgs {
+		if string(got[i]) != msgs[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], msgs[i])
+		}
+	}
+
+	// Without the methods the HEAD response's Content-Length is read as a
+	// body, swallowing the last response.
+	if got, err := SplitMessages(stream, RoleServer); err != ErrIncomplete || len(got) != 4 {
+		t.Errorf("no methods: %d messages, err %v; want 4 and ErrIncomplete", len(got), err)
+	}
+}
+
+func TestSplitMessages_Client(t *testing.T) {
+	msgs := []string{
+		"POST /upload HTTP/1.1\r\nHost: a\r\nContent-Length: 3\r\n\r\nabc",
+		"PUT /data HTTP/1.1\nHost: a\nTransfer-Encoding: chunked\n\n3\nxyz\n0\n\n",
+		"GET / HTTP/1.1\r\nHost: a\r\n\r\n",
+	}
+	tail := "POST /more HTTP/1.1\r\nContent-Length: 10\r\n\r\npart"
+	stream := []byte(msgs[0] + "\r\n" + msgs[1] + msgs[2] + tail)
+
+	got, err := SplitMessages(stream, RoleClient)
+	if !errors.Is(err, ErrIncomplete) {
+		t.Fatalf("err = %v, want ErrIncomplete", err)
+	}
+	if len(got) != 4 || string(got[3]) != tail {
+		t.Fatalf("got %q, want the three messages and the tail", got)
+	}
+	for i := range msgs {
+		if string(got[i]) != msgs[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], msgs[i])
+		}
+	}
+}
+
+func TestSplitMessages_Stops(t *testing.T) {
+	upgrade := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"
+	if got, err := SplitMessages([]byte(upgrade+"\x81\x05hello"), RoleServer); err != nil || len(got) != 1 || string(got[0]) != upgrade {
+		t.Errorf("101: got %q, err %v", got, err)
+	}
+	tunnel := "HTTP/1.1 200 Connection Established\r\n\r\n"
+	if got, err := SplitMessages([]byte(tunnel+"\x16\x03\x01"), RoleServer, "CONNECT"); err != nil || len(got) != 1 {
+		t.Errorf("CONNECT: got %q, err %v", got, err)
+	}
+
+	bad := []byte("GET / HTTP/1.1\r\n\r\nPOST / HTTP/1.1\r\nContent-Length: x\r\n\r\n")
+	got, err := SplitMessages(bad, RoleClient)
+	if err == nil || errors.Is(err, ErrIncomplete) || !strings.Contains(err.Error(), "offset 18") || len(got) != 1 {
+		t.Errorf("bad Content-Length: got %q, err %v", got, err)
+	}
+	if _, err := SplitMessages([]byte("GET / HTTP/1.1\r\n\r\n"), RoleServer); err == nil {
+		t.Error("request on a server stream: want error")
+	}
+	if _, err := SplitMessages(nil, 0); err == nil {
+		t.Error("zero Role: want error")
+	}
+}
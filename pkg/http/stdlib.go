@@ -0,0 +1,351 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
+)
+
+// DefaultMaxStdBodyBytes caps how much of a net/http body FromStdRequest
+// and FromStdResponse read.
+const DefaultMaxStdBodyBytes = 32 << 20
+
+// ToStdRequest converts req to a *net/http.Request suitable for an
+// http.Client.
+//
+// The URL is built from Scheme, the Host header and Path as by Request.URL;
+// without a Host header the URL holds only the path, as on a server-side
+// request. Headers are copied into an http.Header, which canonicalizes their
+// names ("x-api-key" becomes "X-Api-Key") and keeps repeated values. Host,
+// Content-Length and Transfer-Encoding move to the Host, ContentLength and
+// TransferEncoding fields, which is where net/http reads them when writing.
+//
+// With Transfer-Encoding: chunked, a chunk-framed Body (as Marshal writes
+// it) is decoded and ContentLength is -1 so net/http re-frames it; a Body
+// that does not decode is taken as the payload.
+func ToStdRequest(req *Request) (*nethttp.Request, error) {
+	if req == nil {
+		return nil, fmt.Errorf("http: ToStdRequest(nil)")
+	}
+	if req.Method == "" {
+		return nil, fmt.Errorf("http: ToStdRequest: request method is empty")
+	}
+
+	var u *url.URL
+	var err error
+	host := req.Headers.Get("Host")
+	switch {
+	case req.Method == "CONNECT" && !strings.HasPrefix(req.Path, "/"):
+		u = &url.URL{Host: req.Path}
+	case host != "":
+		var raw string
+		if raw, err = req.URL(); err == nil {
+			u, err = url.Parse(raw)
+		}
+	default:
+		u, err = url.ParseRequestURI(req.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("http: ToStdRequest: invalid URL: %w", err)
+	}
+
+	major, minor, err := stdProtoVersion(req.Version)
+	if err != nil {
+		return nil, fmt.Errorf("http: ToStdRequest: %w", err)
+	}
+	header, te := toStdHeader(req.Headers, "Host")
+	body, length := toStdBody(req.Body, te)
+
+	r := &nethttp.Request{
+		Method:           req.Method,
+		URL:              u,
+		Proto:            fmt.Sprintf("HTTP/%d.%d", major, minor),
+		ProtoMajor:       major,
+		ProtoMinor:       minor,
+		Header:           header,
+		Host:             host,
+		ContentLength:    length,
+		TransferEncoding: te,
+	}
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	} else {
+		r.Body = nethttp.NoBody
+		r.GetBody = func() (io.ReadCloser, error) { return nethttp.NoBody, nil }
+	}
+	return r, nil
+}
+
+// ToStdResponse converts resp to a *net/http.Response. Fields are populated
+// directly (Request is nil) with the same header and body handling as
+// ToStdRequest. ContentLength comes from a valid Content-Length header, else
+// the body length, or -1 when chunked.
+func ToStdResponse(resp *Response) (*nethttp.Response, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("http: ToStdResponse(nil)")
+	}
+	major, minor, err := stdProtoVersion(resp.Version)
+	if err != nil {
+		return nil, fmt.Errorf("http: ToStdResponse: %w", err)
+	}
+	header, te := toStdHeader(resp.Headers, "")
+	body, length := toStdBody(resp.Body, te)
+	if te == nil {
+		if cl := resp.Headers.ContentLength(); cl >= 0 {
+			length = cl
+			header.Set("Content-Length", strconv.FormatInt(cl, 10))
+		}
+	}
+
+	status := strconv.Itoa(resp.StatusCode)
+	if resp.Reason != "" {
+		status += " " + resp.Reason
+	}
+	r := &nethttp.Response{
+		Status:           status,
+		StatusCode:       resp.StatusCode,
+		Proto:            fmt.Sprintf("HTTP/%d.%d", major, minor),
+		ProtoMajor:       major,
+		ProtoMinor:       minor,
+		Header:           header,
+		ContentLength:    length,
+		TransferEncoding: te,
+		Body:             nethttp.NoBody,
+	}
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return r, nil
+}
+
+// FromStdRequest converts a *net/http.Request, reading at most
+// DefaultMaxStdBodyBytes of its body. See FromStdRequestWithLimit.
+func FromStdRequest(r *nethttp.Request) (*Request, error) {
+	return FromStdRequestWithLimit(r, DefaultMaxStdBodyBytes)
+}
+
+// FromStdRequestWithLimit converts a *net/http.Request, draining its body
+// and failing if it exceeds maxBodyBytes (≤ 0 means no limit). The drained
+// body is put back on r so the caller can still read it, in full even when
+// it was over the limit.
+//
+// Path is the URL's path and query in origin-form, as the strict parser
+// stores it (an absolute-form target's scheme goes to Scheme), or the URL's
+// host for CONNECT; r.RequestURI is used only when URL is nil. The Host
+// header comes first and takes r.Host, falling back to r.URL.Host, as
+// net/http does when sending. Other headers follow in sorted order, with
+// net/http's canonical names. The body is the decoded payload, so
+// "chunked" is dropped from Transfer-Encoding, any remaining codings are
+// kept, and Content-Length gives the payload length.
+func FromStdRequestWithLimit(r *nethttp.Request, maxBodyBytes int64) (*Request, error) {
+	if r == nil {
+		return nil, fmt.Errorf("http: FromStdRequest(nil)")
+	}
+	body, err := drainStdBody(&r.Body, maxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	path := r.RequestURI
+	if r.URL != nil {
+		if r.Method == "CONNECT" {
+			path = r.URL.Host
+		} else {
+			path = r.URL.RequestURI()
+		}
+	}
+	var scheme string
+	if r.URL != nil {
+		scheme = r.URL.Scheme
+	}
+
+	var headers Headers
+	host := r.Host
+	if host == "" && r.URL != nil {
+		host = r.URL.Host
+	}
+	if host != "" {
+		headers = append(headers, Header{Key: "Host", Value: host})
+	}
+	headers = append(headers, fromStdHeader(r.Header, r.TransferEncoding, body)...)
+
+	return &Request{
+		Method:  r.Method,
+		Path:    path,
+		Version: stdVersion(r.Proto),
+		Scheme:  scheme,
+		Headers: headers,
+		Body:    body,
+	}, nil
+}
+
+// FromStdResponse converts a *net/http.Response, reading at most
+// DefaultMaxStdBodyBytes of its body. See FromStdResponseWithLimit.
+func FromStdResponse(r *nethttp.Response) (*Response, error) {
+	return FromStdResponseWithLimit(r, DefaultMaxStdBodyBytes)
+}
+
+// FromStdResponseWithLimit converts a *net/http.Response with the body and
+// header handling of FromStdRequestWithLimit. Reason is taken from
+// r.Status without its leading status code.
+func FromStdResponseWithLimit(r *nethttp.Response, maxBodyBytes int64) (*Response, error) {
+	if r == nil {
+		return nil, fmt.Errorf("http: FromStdResponse(nil)")
+	}
+	body, err := drainStdBody(&r.Body, maxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	reason := r.Status
+	if code, rest, ok := strings.Cut(r.Status, " "); ok && code == strconv.Itoa(r.StatusCode) {
+		reason = rest
+	} else if reason == strconv.Itoa(r.StatusCode) {
+		reason = ""
+	}
+	return &Response{
+		Version:    stdVersion(r.Proto),
+		StatusCode: r.StatusCode,
+		Reason:     reason,
+		Headers:    fromStdHeader(r.Header, r.TransferEncoding, body),
+		Body:       body,
+	}, nil
+}
+
+// toStdHeader copies h into an http.Header, leaving out skip (when set),
+// Content-Length and Transfer-Encoding. The transfer-codings are returned
+// separately for the TransferEncoding field, or nil when there are none.
+func toStdHeader(h Headers, skip string) (nethttp.Header, []string) {
+	header := make(nethttp.Header, len(h))
+	var te []string
+	for _, f := range h {
+		switch {
+		case skip != "" && strings.EqualFold(f.Key, skip):
+		case strings.EqualFold(f.Key, "Content-Length"):
+		case strings.EqualFold(f.Key, "Transfer-Encoding"):
+			for _, c := range strings.Split(f.Value, ",") {
+				if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+					te = append(te, c)
+				}
+			}
+		default:
+			header.Add(f.Key, f.Value)
+		}
+	}
+	return header, te
+}
+
+// toStdBody returns the payload and ContentLength for a message body.
+func toStdBody(body []byte, te []string) ([]byte, int64) {
+	if len(te) > 0 && te[len(te)-1] == "chunked" {
+		if decoded, err := fastparser.Dechunk(body); err == nil {
+			body = decoded
+		}
+		return body, -1
+	}
+	if len(body) == 0 {
+		return nil, 0
+	}
+	return body, int64(len(body))
+}
+
+// fromStdHeader converts an http.Header, in sorted key order, describing a
+// fully read body: chunked is dropped from te and Content-Length reflects
+// the payload.
+func fromStdHeader(h nethttp.Header, te []string, body []byte) Headers {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out Headers
+	_, hadCL := h["Content-Length"]
+	for _, k := range keys {
+		if k == "Host" || k == "Content-Length" || k == "Transfer-Encoding" {
+			continue
+		}
+		for _, v := range h[k] {
+			out = append(out, Header{Key: k, Value: v})
+		}
+	}
+
+	var codings []string
+	for _, c := range te {
+		if !strings.EqualFold(c, "chunked") {
+			codings = append(codings, c)
+		}
+	}
+	if len(codings) > 0 {
+		out = append(out, Header{Key: "Transfer-Encoding", Value: strings.Join(codings, ", ")})
+	}
+	if len(body) > 0 || hadCL {
+		out = append(out, Header{Key: "Content-Length", Value: strconv.Itoa(len(body))})
+	}
+	return out
+}
+
+// drainStdBody reads *body fully, up to limit bytes, and replaces it with
+// a reader over the data read. A body over the limit is not consumed: *body
+// is replaced with one that yields the bytes read followed by the rest, and
+// closing it closes the original.
+func drainStdBody(body *io.ReadCloser, limit int64) ([]byte, error) {
+	if *body == nil || *body == nethttp.NoBody {
+		return nil, nil
+	}
+	src := io.Reader(*body)
+	if limit > 0 {
+		src = io.LimitReader(*body, limit+1)
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		(*body).Close()
+		return nil, fmt.Errorf("http: reading body: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		rest := *body
+		*body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), rest), rest}
+		return nil, fmt.Errorf("http: body exceeds %d bytes", limit)
+	}
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(data))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// stdProtoVersion parses an HTTP version, defaulting to HTTP/1.1.
+func stdProtoVersion(v string) (major, minor int, err error) {
+	if v == "" {
+		return 1, 1, nil
+	}
+	major, minor, ok := nethttp.ParseHTTPVersion(v)
+	if !ok {
+		if v == "HTTP/2" {
+			return 2, 0, nil
+		}
+		return 0, 0, fmt.Errorf("invalid HTTP version %q", v)
+	}
+	return major, minor, nil
+}
+
+// stdVersion maps a net/http Proto to a version string.
+func stdVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
@@ -0,0 +1,261 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToStdRequest_Fields(t *testing.T) {
+	req := &Request{
+		Method:  "POST",
+		Path:    "/api/items?q=1",
+		Version: "HTTP/1.1",
+		Scheme:  "https",
+		Headers: Headers{
+			{Key: "Host", Value: "example.com"},
+			{Key: "x-api-key", Value: "k"},
+			{Key: "Accept", Value: "text/html"},
+			{Key: "Accept", Value: "application/json"},
+			{Key: "Content-Length", Value: "5"},
+		},
+		Body: []byte("hello"),
+	}
+	r, err := ToStdRequest(req)
+	if err != nil {
+		t.Fatalf("ToStdRequest() error = %v", err)
+	}
+	if got := r.URL.String(); got != "https://example.com/api/items?q=1" {
+		t.Errorf("URL = %q", got)
+	}
+	if r.Host != "example.com" || r.Header.Get("Host") != "" {
+		t.Errorf("Host = %q, Header Host = %q", r.Host, r.Header.Get("Host"))
+	}
+	if got := r.Header["X-Api-Key"]; len(got) != 1 || got[0] != "k" {
+		t.Errorf("X-Api-Key = %v (keys are canonicalized)", got)
+	}
+	if got := r.Header.Values("Accept"); len(got) != 2 {
+		t.Errorf("Accept = %v, want both values", got)
+	}
+	if r.ContentLength != 5 || r.Header.Get("Content-Length") != "" {
+		t.Errorf("ContentLength = %d, header = %q", r.ContentLength, r.Header.Get("Content-Length"))
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != "hello" {
+		t.Errorf("Body = %q", body)
+	}
+	again, _ := r.GetBody()
+	if b, _ := io.ReadAll(again); string(b) != "hello" {
+		t.Errorf("GetBody = %q", b)
+	}
+}
+
+func TestToStdRequest_EdgeCases(t *testing.T) {
+	// nil body
+	r, err := ToStdRequest(&Request{Method: "GET", Path: "/", Headers: Headers{{Key: "Host", Value: "h"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Body != nethttp.NoBody || r.ContentLength != 0 {
+		t.Errorf("nil body: Body = %v, ContentLength = %d", r.Body, r.ContentLength)
+	}
+
+	// no Host: server-style URL
+	r, err = ToStdRequest(&Request{Method: "GET", Path: "/only/path?x=y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.URL.Host != "" || r.URL.RequestURI() != "/only/path?x=y" {
+		t.Errorf("URL = %v", r.URL)
+	}
+
+	// chunk-framed body is decoded
+	r, err = ToStdRequest(&Request{Method: "POST", Path: "/", Headers: Headers{
+		{Key: "Host", Value: "h"}, {Key: "Transfer-Encoding", Value: "chunked"},
+	}, Body: []byte("5\r\nhello\r\n0\r\n\r\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != "hello" || r.ContentLength != -1 || len(r.TransferEncoding) != 1 {
+		t.Errorf("chunked: body %q, ContentLength %d, TE %v", body, r.ContentLength, r.TransferEncoding)
+	}
+
+	// CONNECT keeps the authority
+	r, err = ToStdRequest(&Request{Method: "CONNECT", Path: "example.com:443", Headers: Headers{{Key: "Host", Value: "example.com:443"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.URL.Host != "example.com:443" {
+		t.Errorf("CONNECT URL = %v", r.URL)
+	}
+
+	for _, bad := range []*Request{nil, {Path: "/"}, {Method: "GET", Path: "/", Version: "SPDY/3"}} {
+		if _, err := ToStdRequest(bad); err == nil {
+			t.Errorf("ToStdRequest(%+v) expected error", bad)
+		}
+	}
+}
+
+func TestFromStdRequest_HostPrecedenceAndBody(t *testing.T) {
+	r := httptest.NewRequest("PUT", "http://url-host.example/path?a=1", strings.NewReader("payload"))
+	r.Host = "override.example"
+	r.Header.Set("X-Multi", "1")
+	r.Header.Add("X-Multi", "2")
+	r.Header.Set("Host", "ignored.example")
+
+	req, err := FromStdRequest(r)
+	if err != nil {
+		t.Fatalf("FromStdRequest() error = %v", err)
+	}
+	if req.Headers[0].Key != "Host" || req.Headers[0].Value != "override.example" {
+		t.Errorf("first header = %+v, want Host: override.example", req.Headers[0])
+	}
+	if len(req.Headers.Values("Host")) != 1 {
+		t.Errorf("Host values = %v, want one", req.Headers.Values("Host"))
+	}
+	if got := req.Headers.Values("X-Multi"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Errorf("X-Multi = %v", got)
+	}
+	if req.Path != "/path?a=1" || req.Scheme != "http" {
+		t.Errorf("Path = %q, Scheme = %q; want origin-form with scheme", req.Path, req.Scheme)
+	}
+	if string(req.Body) != "payload" || req.Headers.Get("Content-Length") != "7" {
+		t.Errorf("Body = %q, Content-Length = %q", req.Body, req.Headers.Get("Content-Length"))
+	}
+	// the drained body is restored
+	if b, _ := io.ReadAll(r.Body); string(b) != "payload" {
+		t.Errorf("r.Body after conversion = %q", b)
+	}
+}
+
+func TestFromStdRequestWithLimit(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	if _, err := FromStdRequestWithLimit(r, 4); err == nil || !strings.Contains(err.Error(), "exceeds 4 bytes") {
+		t.Errorf("error = %v, want size cap error", err)
+	}
+	// an over-limit body is left readable in full
+	if b, _ := io.ReadAll(r.Body); string(b) != "0123456789" {
+		t.Errorf("r.Body after size cap error = %q", b)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	if req, err := FromStdRequestWithLimit(r, 0); err != nil || len(req.Body) != 10 {
+		t.Errorf("unlimited: %v, %v", req, err)
+	}
+	if _, err := FromStdRequest(nil); err == nil {
+		t.Error("FromStdRequest(nil) expected error")
+	}
+}
+
+func TestToStdResponse_WriteParsesBack(t *testing.T) {
+	resp := &Response{StatusCode: 201, Reason: "Created", Headers: Headers{
+		{Key: "content-type", Value: "text/plain"},
+		{Key: "Set-Cookie", Value: "a=1"},
+		{Key: "Set-Cookie", Value: "b=2"},
+	}, Body: []byte("made")}
+	r, err := ToStdResponse(resp)
+	if err != nil {
+		t.Fatalf("ToStdResponse() error = %v", err)
+	}
+	if r.Status != "201 Created" || r.ContentLength != 4 {
+		t.Errorf("Status = %q, ContentLength = %d", r.Status, r.ContentLength)
+	}
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	back, err := UnmarshalResponse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("UnmarshalResponse() error = %v\n%s", err, buf.Bytes())
+	}
+	if back.StatusCode != 201 || string(back.Body) != "made" || len(back.Headers.Values("Set-Cookie")) != 2 {
+		t.Errorf("round trip = %+v", back)
+	}
+}
+
+func TestToStdResponse_Chunked(t *testing.T) {
+	resp := &Response{StatusCode: 200, Reason: "OK", Headers: Headers{{Key: "Transfer-Encoding", Value: "chunked"}},
+		Body: []byte("5\r\nhello\r\n0\r\n\r\n")}
+	r, err := ToStdResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	std, err := nethttp.ReadResponse(bufio.NewReader(&buf), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := FromStdResponse(std)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(back.Body) != "hello" || back.Headers.Get("Transfer-Encoding") != "" || back.Headers.Get("Content-Length") != "5" {
+		t.Errorf("round trip = %+v", back)
+	}
+}
+
+// TestStdInterop_HTTPTestServer sends a parsed request through a real
+// net/http client and server and converts the response back.
+func TestStdInterop_HTTPTestServer(t *testing.T) {
+	var seen *Request
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		var err error
+		if seen, err = FromStdRequest(r); err != nil {
+			t.Errorf("FromStdRequest() error = %v", err)
+		}
+		w.Header().Add("X-Echo", r.Header.Get("X-Custom"))
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.WriteHeader(nethttp.StatusAccepted)
+		w.Write(seen.Body)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	raw := "POST /submit?x=1&y=2 HTTP/1.1\r\n" +
+		"Host: " + host + "\r\n" +
+		"x-custom: shape\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Content-Length: 11\r\n" +
+		"\r\n" +
+		`{"ok":true}`
+	req, err := UnmarshalRequest([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdReq, err := ToStdRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdResp, err := srv.Client().Do(stdReq)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp, err := FromStdResponse(stdResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seen.Method != "POST" || seen.Path != "/submit?x=1&y=2" || seen.Headers.Get("Host") != host {
+		t.Errorf("server saw %+v", seen)
+	}
+	if seen.Headers.Get("X-Custom") != "shape" || string(seen.Body) != `{"ok":true}` {
+		t.Errorf("server headers/body = %+v / %q", seen.Headers, seen.Body)
+	}
+	if resp.StatusCode != 202 || resp.Reason != "Accepted" {
+		t.Errorf("status = %d %q", resp.StatusCode, resp.Reason)
+	}
+	if resp.Headers.Get("X-Echo") != "shape" || len(resp.Headers.Values("Set-Cookie")) != 2 {
+		t.Errorf("response headers = %+v", resp.Headers)
+	}
+	if string(resp.Body) != `{"ok":true}` || resp.Headers.Get("Content-Length") != "11" {
+		t.Errorf("response body = %q, Content-Length = %q", resp.Body, resp.Headers.Get("Content-Length"))
+	}
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Template is a request with {{name}} placeholders, as parsed by
+// ParseTemplate. Request holds the message as written, placeholders
+// included; Render produces a concrete request from it.
+type Template struct {
+	Request      *Request
+	Placeholders []Placeholder // in order of appearance: path, headers, body
+	Warnings     []string      // from parsing the template leniently
+
+	declared map[string]bool // nil: any well-formed name is a placeholder
+	escape   string
+}
+
+// Placeholder locates one {{name}} in a Template.
+type Placeholder struct {
+	Name   string
+	Part   string // "path", "header" or "body"
+	Header string // the header's name, for Part "header"
+	Offset int    // byte offset of "{{" in the path, header value or body
+}
+
+// TemplateOptions controls how ParseTemplateWithOptions recognizes
+// placeholders.
+type TemplateOptions struct {
+	// Variables, when non-empty, declares the placeholder names; {{...}}
+	// around any other name is left as literal text. Declare the names of
+	// a template whose body is JSON or another template language that
+	// uses double braces itself.
+	Variables []string
+
+	// EscapedDelimiter, when non-empty, is a sequence written in place of
+	// a literal "{{" that must not start a placeholder, such as `\{{`.
+	// Render replaces each occurrence with "{{".
+	EscapedDelimiter string
+}
+
+// ParseTemplate parses a request template such as
+//
+//	GET /users/{{userID}} HTTP/1.1
+//	Authorization: Bearer {{token}}
+//
+// leniently, recording each {{name}} placeholder in the path, header
+// values and body. A name starts with a letter or underscore and continues
+// with letters, digits, '_', '-' or '.'; "{{" followed by anything else,
+// as in a JSON body like {"a":{"b":1}}, is literal text. It returns an
+// error if data is not a request.
+func ParseTemplate(data []byte) (*Template, error) {
+	return ParseTemplateWithOptions(data, TemplateOptions{})
+}
+
+// ParseTemplateWithOptions is ParseTemplate with explicit options.
+func ParseTemplateWithOptions(data []byte, opts TemplateOptions) (*Template, error) {
+	result := UnmarshalLenient(data)
+	if result.Request == nil {
+		return nil, fmt.Errorf("http: template is not a request")
+	}
+	t := &Template{Request: result.Request, Warnings: result.Warnings, escape: opts.EscapedDelimiter}
+	if len(opts.Variables) > 0 {
+		t.declared = make(map[string]bool, len(opts.Variables))
+		for _, name := range opts.Variables {
+			t.declared[name] = true
+		}
+	}
+
+	record := func(part, header string) func(off int, name string) string {
+		return func(off int, name string) string {
+			t.Placeholders = append(t.Placeholders, Placeholder{Name: name, Part: part, Header: header, Offset: off})
+			return ""
+		}
+	}
+	t.expand(t.Request.Path, record("path", ""))
+	for _, h := range t.Request.Headers {
+		t.expand(h.Value, record("header", h.Key))
+	}
+	t.expand(string(t.Request.Body), record("body", ""))
+	return t, nil
+}
+
+// Render returns a new request with every placeholder replaced by its
+// value in vars and each escaped delimiter by "{{". Fields without
+// placeholders, such as Scheme, are copied from the template, but not
+// RawBody and RawHeaders. Content-Length is
+// recomputed when the template has a body or the header. It returns an
+// error naming every placeholder that vars does not provide.
+func (t *Template) Render(vars map[string]string) (*Request, error) {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, ph := range t.Placeholders {
+		if _, ok := vars[ph.Name]; !ok && !seen[ph.Name] {
+			seen[ph.Name] = true
+			missing = append(missing, ph.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("http: template variables not provided: %s", strings.Join(missing, ", "))
+	}
+
+	subst := func(_ int, name string) string { return vars[name] }
+	src := t.Request
+	req := new(Request)
+	*req = *src
+	req.Path = t.expand(src.Path, subst)
+	req.Headers = make(Headers, len(src.Headers))
+	req.Body = nil
+	// the wire form still holds the placeholders
+	req.RawBody, req.RawHeaders = nil, nil
+	for i, h := range src.Headers {
+		req.Headers[i] = Header{Key: h.Key, Value: t.expand(h.Value, subst)}
+	}
+	if len(src.Body) > 0 {
+		req.Body = []byte(t.expand(string(src.Body), subst))
+	}
+	if len(src.Body) > 0 || req.Headers.Has("Content-Length") {
+		req.Headers.Set("Content-Length", strconv.Itoa(len(req.Body)))
+	}
+	return req, nil
+}
+
+// expand scans s for placeholders and escaped delimiters, calling visit
+// with each placeholder's offset and name, and returns s with every
+// placeholder replaced by what visit returns and every escaped delimiter
+// by "{{".
+func (t *Template) expand(s string, visit func(off int, name string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if t.escape != "" && strings.HasPrefix(s[i:], t.escape) {
+			b.WriteString("{{")
+			i += len(t.escape)
+			continue
+		}
+		if strings.HasPrefix(s[i:], "{{") {
+			if end := strings.Index(s[i+2:], "}}"); end >= 0 {
+				name := s[i+2 : i+2+end]
+				if isPlaceholderName(name) && (t.declared == nil || t.declared[name]) {
+					b.WriteString(visit(i, name))
+					i += end + 4
+					continue
+				}
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// isPlaceholderName reports whether s can name a template variable.
+func isPlaceholderName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		case i > 0 && ('0' <= c && c <= '9' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
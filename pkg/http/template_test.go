@@ -0,0 +1,114 @@
+package http
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const userTemplate = "POST /users/{{userID}}/notes HTTP/1.1\r\n" +
+	"Host: api.example.com\r\n" +
+	"Authorization: Bearer {{token}}\r\n" +
+	"Content-Type: application/json\r\n" +
+	"Content-Length: 27\r\n" +
+	"\r\n" +
+	`{"text":"{{note}}","n":{}}`
+
+func TestTemplate_Render(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(userTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Placeholder{
+		{Name: "userID", Part: "path", Offset: 7},
+		{Name: "token", Part: "header", Header: "Authorization", Offset: 7},
+		{Name: "note", Part: "body", Offset: 9},
+	}
+	if !reflect.DeepEqual(tmpl.Placeholders, want) {
+		t.Errorf("Placeholders = %+v, want %+v", tmpl.Placeholders, want)
+	}
+
+	for _, vars := range []map[string]string{
+		{"userID": "42", "token": "abc.def", "note": "hi"},
+		{"userID": "alice-smith", "token": "eyJhbGciOiJIUzI1NiJ9.e30.sig", "note": "a much longer note"},
+	} {
+		req, err := tmpl.Render(vars)
+		if err != nil {
+			t.Fatalf("Render(%v): %v", vars, err)
+		}
+		if want := "/users/" + vars["userID"] + "/notes"; req.Path != want {
+			t.Errorf("Path = %q, want %q", req.Path, want)
+		}
+		if got, want := req.Headers.Get("Authorization"), "Bearer "+vars["token"]; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		wantBody := `{"text":"` + vars["note"] + `","n":{}}`
+		if string(req.Body) != wantBody {
+			t.Errorf("Body = %q, want %q", req.Body, wantBody)
+		}
+		if req.Headers.ContentLength() != int64(len(wantBody)) {
+			t.Errorf("Content-Length = %d, want %d", req.Headers.ContentLength(), len(wantBody))
+		}
+		if _, err := Marshal(req); err != nil {
+			t.Errorf("Marshal: %v", err)
+		}
+	}
+	if strings.Contains(tmpl.Request.Path, "42") {
+		t.Error("Render modified the template")
+	}
+}
+
+func TestTemplate_RenderKeepsScheme(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte("GET https://api.example.com/users/{{id}} HTTP/1.1\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := tmpl.Render(map[string]string{"id": "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Scheme != tmpl.Request.Scheme || req.Scheme == "" {
+		t.Errorf("Scheme = %q, want the template's %q", req.Scheme, tmpl.Request.Scheme)
+	}
+	if req.Method != "GET" || req.Version != "HTTP/1.1" || !strings.HasSuffix(req.Path, "/users/7") {
+		t.Errorf("Render() = %s %s %s", req.Method, req.Path, req.Version)
+	}
+}
+
+func TestTemplate_MissingVariables(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(userTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tmpl.Render(map[string]string{"token": "abc"})
+	if err == nil || !strings.Contains(err.Error(), "userID, note") {
+		t.Errorf("err = %v, want both userID and note listed", err)
+	}
+}
+
+func TestTemplate_Delimiters(t *testing.T) {
+	body := `{"tpl":"\{{user}}","m":{{"k":1}},"alias":"{{alias}}","who":"{{user}}"}`
+	data := []byte("POST /render HTTP/1.1\r\nContent-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body)
+
+	// Only declared names are placeholders; {{alias}} stays literal.
+	tmpl, err := ParseTemplateWithOptions(data, TemplateOptions{Variables: []string{"user"}, EscapedDelimiter: `\{{`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmpl.Placeholders) != 1 || tmpl.Placeholders[0].Name != "user" {
+		t.Fatalf("Placeholders = %+v, want the one unescaped {{user}}", tmpl.Placeholders)
+	}
+	req, err := tmpl.Render(map[string]string{"user": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"tpl":"{{user}}","m":{{"k":1}},"alias":"{{alias}}","who":"bob"}`
+	if string(req.Body) != want {
+		t.Errorf("Body = %q, want %q", req.Body, want)
+	}
+
+	if _, err := ParseTemplate([]byte("HTTP/1.1 200 OK\r\n\r\n")); err == nil {
+		t.Error("response template: want error")
+	}
+}
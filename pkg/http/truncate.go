@@ -0,0 +1,152 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TruncatedHeader is the header TruncateMessage adds to a message it cut
+// down, describing what was removed.
+const TruncatedHeader = "X-Shape-Truncated"
+
+// DefaultTruncateKeep lists the headers TruncateMessage keeps when it must
+// drop headers. Authorization is kept with its value replaced by
+// "REDACTED", so the log still shows that credentials were sent.
+var DefaultTruncateKeep = []string{"Host", "Content-Type", "Content-Length", "Authorization"}
+
+// TruncateOptions configures TruncateMessageWithOptions.
+type TruncateOptions struct {
+	// Keep lists the headers, matched case-insensitively, that are not
+	// dropped. Nil means DefaultTruncateKeep.
+	Keep []string
+}
+
+// TruncateMessage cuts the HTTP message in data down to at most maxBytes
+// while keeping it a valid message, for storing in logs. Data that already
+// fits is returned unchanged. Otherwise the message is parsed leniently and
+// re-marshaled with Content-Length framing, then
+//
+//  1. the body is cut short, with Content-Length updated and a
+//     "X-Shape-Truncated: body;original-length=N" header added, and, if
+//     the message still does not fit,
+//  2. Authorization is redacted and the headers not kept, see
+//     DefaultTruncateKeep, are dropped, last first, with
+//     "headers;dropped=K" added to the X-Shape-Truncated value.
+//
+// The result parses with the strict parser. It reports whether data was
+// changed; the result is nil if data holds no message or the start line
+// and kept headers alone do not fit.
+func TruncateMessage(data []byte, maxBytes int) ([]byte, bool) {
+	return TruncateMessageWithOptions(data, maxBytes, TruncateOptions{})
+}
+
+// TruncateMessageWithOptions is TruncateMessage with the kept headers set
+// by opts.
+func TruncateMessageWithOptions(data []byte, maxBytes int, opts TruncateOptions) ([]byte, bool) {
+	if len(data) <= maxBytes {
+		return data, false
+	}
+	if opts.Keep == nil {
+		opts.Keep = DefaultTruncateKeep
+	}
+	result := UnmarshalLenient(data)
+	var t truncation
+	switch {
+	case result.Request != nil:
+		req := *result.Request
+		t = truncation{headers: &req.Headers, body: &req.Body, size: req.WireSize,
+			marshal: func() ([]byte, error) { return Marshal(&req) }}
+	case result.Response != nil:
+		resp := *result.Response
+		t = truncation{headers: &resp.Headers, body: &resp.Body, size: resp.WireSize,
+			marshal: func() ([]byte, error) { return Marshal(&resp) }}
+	default:
+		return nil, true
+	}
+	if !t.fit(maxBytes, opts.Keep) {
+		return nil, true
+	}
+	out, err := t.marshal()
+	if err != nil {
+		return nil, true
+	}
+	return out, true
+}
+
+// truncation is a parsed message being cut down.
+type truncation struct {
+	headers *Headers
+	body    *[]byte
+	full    []byte // the body as parsed
+	size    func() int
+	marshal func() ([]byte, error)
+}
+
+// fit shrinks the message to maxBytes and reports whether it succeeded.
+func (t *truncation) fit(maxBytes int, keep []string) bool {
+	// Frame the decoded body with Content-Length alone.
+	t.full = *t.body
+	h := t.headers.Clone()
+	h.Del("Transfer-Encoding")
+	if len(t.full) > 0 || h.Has("Content-Length") {
+		h.Set("Content-Length", strconv.Itoa(len(t.full)))
+	}
+	*t.headers = h
+	if t.size() <= maxBytes {
+		return true
+	}
+
+	var marker []string
+	if original := len(t.full); original > 0 {
+		marker = append(marker, "body;original-length="+strconv.Itoa(original))
+		t.headers.Set(TruncatedHeader, marker[0])
+		t.setBody(0)
+		if room := maxBytes - t.size(); room >= 0 {
+			// Content-Length grows with the body, so back off until it fits.
+			n := room
+			if n > original {
+				n = original
+			}
+			for {
+				t.setBody(n)
+				if t.size() <= maxBytes {
+					return true
+				}
+				n -= t.size() - maxBytes
+			}
+		}
+		t.setBody(0)
+	}
+
+	for i := range *t.headers {
+		if asciiEqualFold((*t.headers)[i].Key, "Authorization") {
+			(*t.headers)[i].Value = "REDACTED"
+		}
+	}
+	dropped := 0
+	for i := len(*t.headers) - 1; i >= 0 && t.size() > maxBytes; i-- {
+		key := (*t.headers)[i].Key
+		if asciiEqualFold(key, TruncatedHeader) || asciiEqualFold(key, "Content-Length") || keeps(keep, key) {
+			continue
+		}
+		*t.headers = append((*t.headers)[:i], (*t.headers)[i+1:]...)
+		dropped++
+		t.headers.Set(TruncatedHeader, strings.Join(append(marker, "headers;dropped="+strconv.Itoa(dropped)), ", "))
+	}
+	return t.size() <= maxBytes
+}
+
+// setBody cuts the body to its first n bytes and updates Content-Length.
+func (t *truncation) setBody(n int) {
+	*t.body = t.full[:n:n]
+	t.headers.Set("Content-Length", strconv.Itoa(n))
+}
+
+func keeps(keep []string, key string) bool {
+	for _, k := range keep {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTruncateMessage_LargeBody(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 1<<20)
+	data := []byte(fmt.Sprintf("POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/octet-stream\r\nContent-Length: %d\r\n\r\n%s", len(body), body))
+
+	out, truncated := TruncateMessage(data, 4096)
+	if !truncated || len(out) > 4096 {
+		t.Fatalf("truncated = %v, len = %d; want true, at most 4096", truncated, len(out))
+	}
+	req, err := UnmarshalRequest(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if got := req.Headers.Get(TruncatedHeader); got != "body;original-length=10485760" {
+		t.Errorf("%s = %q", TruncatedHeader, got)
+	}
+	if req.Headers.Get("Content-Type") != "application/octet-stream" || req.Headers.Get("Host") != "example.com" {
+		t.Errorf("headers = %v", req.Headers)
+	}
+	if len(req.Body) < 3900 || !bytes.HasPrefix(body, req.Body) {
+		t.Errorf("body is %d bytes, want a prefix of the original using the room left", len(req.Body))
+	}
+	if len(out) != 4096 {
+		t.Errorf("len = %d, want exactly 4096", len(out))
+	}
+}
+
+func TestTruncateMessage_LargeHeaders(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("GET /api HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer secret-token\r\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "X-Trace-%d: %s\r\n", i, strings.Repeat("v", 50))
+	}
+	b.WriteString("Content-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello")
+	data := []byte(b.String())
+
+	out, truncated := TruncateMessage(data, 512)
+	if !truncated || len(out) > 512 {
+		t.Fatalf("truncated = %v, len = %d", truncated, len(out))
+	}
+	req, err := UnmarshalRequest(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v\n%s", err, out)
+	}
+	if got := req.Headers.Get("Authorization"); got != "REDACTED" {
+		t.Errorf("Authorization = %q, want REDACTED", got)
+	}
+	if req.Headers.Get("Host") != "example.com" || req.Headers.Get("Content-Type") != "text/plain" || len(req.Body) != 0 {
+		t.Errorf("headers = %v, body %q", req.Headers, req.Body)
+	}
+	marker := req.Headers.Get(TruncatedHeader)
+	if !strings.HasPrefix(marker, "body;original-length=5, headers;dropped=") {
+		t.Errorf("%s = %q", TruncatedHeader, marker)
+	}
+	// The headers kept come first, in their original order.
+	if req.Headers[2].Key != "X-Trace-0" {
+		t.Errorf("headers = %v, want the first X-Trace headers kept", req.Headers)
+	}
+
+	// Nothing fits below the start line and the kept headers.
+	if out, truncated := TruncateMessage(data, 40); out != nil || !truncated {
+		t.Errorf("TruncateMessage(40) = %q, %v; want nil, true", out, truncated)
+	}
+	// A custom keep-list.
+	out, _ = TruncateMessageWithOptions(data, 512, TruncateOptions{Keep: []string{"X-Trace-99"}})
+	req, err = UnmarshalRequest(out)
+	if err != nil || req.Headers.Get("X-Trace-99") == "" || req.Headers.Get("Content-Type") != "" {
+		t.Errorf("custom keep: err %v, headers %v", err, req.Headers)
+	}
+}
+
+func TestTruncateMessage_Fits(t *testing.T) {
+	// Input under the limit comes back as is, even when it is not strict.
+	for _, data := range [][]byte{simpleRequest, []byte("GET / HTTP/1.1\nHost: x\n\n")} {
+		out, truncated := TruncateMessage(data, len(data))
+		if truncated || !bytes.Equal(out, data) || &out[0] != &data[0] {
+			t.Errorf("TruncateMessage(%q) = %q, %v; want the input", data, out, truncated)
+		}
+	}
+}
+
+func TestTruncateMessage_Chunked(t *testing.T) {
+	data := []byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\nX-Big: " + strings.Repeat("b", 200) + "\r\n\r\n" +
+		"400\r\n" + strings.Repeat("x", 1024) + "\r\n0\r\n\r\n")
+	out, truncated := TruncateMessage(data, 300)
+	resp, err := UnmarshalResponse(out)
+	if !truncated || err != nil || len(out) > 300 {
+		t.Fatalf("truncated %v, len %d, err %v", truncated, len(out), err)
+	}
+	if resp.Headers.Get("Transfer-Encoding") != "" || resp.Headers.Get(TruncatedHeader) != "body;original-length=1024" {
+		t.Errorf("headers = %v", resp.Headers)
+	}
+}
+
+// TestTruncateMessage_Seeds checks that every seed, cut to a range of
+// sizes, fits and parses strictly.
+func TestTruncateMessage_Seeds(t *testing.T) {
+	seeds := append(append([][]byte(nil), requestSeeds...), responseSeeds...)
+	for _, seed := range seeds {
+		for max := len(seed) - 1; max > 0; max -= 7 {
+			out, truncated := TruncateMessage(seed, max)
+			if !truncated {
+				t.Fatalf("%q to %d: not truncated", seed, max)
+			}
+			if out == nil {
+				continue
+			}
+			if len(out) > max {
+				t.Errorf("%q to %d: %d bytes", seed, max, len(out))
+			}
+			var err error
+			if bytes.HasPrefix(out, []byte("HTTP/")) {
+				_, err = UnmarshalResponse(out)
+			} else {
+				_, err = UnmarshalRequest(out)
+			}
+			if err != nil {
+				t.Errorf("%q to %d: %q does not parse: %v", seed, max, out, err)
+			}
+		}
+	}
+}
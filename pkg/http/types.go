@@ -19,8 +19,11 @@
 package http
 
 import (
+	"net/textproto"
 	"strconv"
 	"strings"
+
+	"github.com/shapestone/shape-http/internal/fastparser"
 )
 
 // Request represents an HTTP/1.1 request message.
@@ -31,6 +34,14 @@ type Request struct {
 	Scheme  string  // "https", "http", or "" — set when request-target was absolute-form
 	Headers Headers // ordered, repeatable headers
 	Body    []byte  // raw body (nil if none)
+
+	// RawBody is the body as framed on the wire and RawHeaders the header
+	// section as received, kept when the parser decoded a chunked body and
+	// ParserLimits.KeepRawBody or LenientOptions.KeepRawBody was set; nil
+	// otherwise. RawHeaders is only set by the strict parser, whose Headers
+	// describe the decoded body. MarshalOptions.UseRawBody re-emits them.
+	RawBody    []byte
+	RawHeaders Headers
 }
 
 // Response represents an HTTP/1.1 response message.
@@ -40,6 +51,8 @@ type Response struct {
 	Reason     string  // "OK", "Not Found"
 	Headers    Headers // ordered, repeatable headers
 	Body       []byte  // raw body (nil if none)
+	RawBody    []byte  // wire-framed body; see Request.RawBody
+	RawHeaders Headers // header section as received; see Request.RawHeaders
 }
 
 // Header represents a single HTTP header key-value pair.
@@ -56,14 +69,29 @@ type Header struct {
 type Headers []Header
 
 // Get returns the first header value for the given key (case-insensitive).
-// Returns empty string if not found.
+// Returns empty string if not found; use Lookup or Has to tell a missing
+// header from one with an empty value.
 func (h Headers) Get(key string) string {
+	v, _ := h.Lookup(key)
+	return v
+}
+
+// Lookup returns the first header value for the given key
+// (case-insensitive) and whether a header with that key is present.
+func (h Headers) Lookup(key string) (string, bool) {
 	for _, hdr := range h {
 		if strings.EqualFold(hdr.Key, key) {
-			return hdr.Value
+			return hdr.Value, true
 		}
 	}
-	return ""
+	return "", false
+}
+
+// Has reports whether a header with the given key (case-insensitive) is
+// present, even with an empty value.
+func (h Headers) Has(key string) bool {
+	_, ok := h.Lookup(key)
+	return ok
 }
 
 // Values returns all header values for the given key (case-insensitive).
@@ -125,6 +153,100 @@ func (h Headers) Clone() Headers {
 	return clone
 }
 
+// DuplicatePolicy selects how ToMap and Dedupe treat a header that appears
+// more than once.
+type DuplicatePolicy int
+
+// Duplicate header policies.
+const (
+	KeepFirst DuplicatePolicy = iota // keep the first value
+	KeepLast                         // keep the last value
+	JoinComma                        // join the values with ", " (RFC 9110 §5.3)
+)
+
+// unjoinable lists headers whose values cannot be combined into one
+// comma-separated value: Set-Cookie values contain commas of their own
+// (RFC 6265 §3), and authentication challenges are easily misread when
+// merged.
+var unjoinable = map[string]bool{
+	"Set-Cookie":         true,
+	"Www-Authenticate":   true,
+	"Proxy-Authenticate": true,
+}
+
+// canJoin reports whether repeated key headers may be joined with commas.
+func canJoin(key string) bool {
+	return !unjoinable[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+// ToMap flattens the headers into a map keyed by canonical header name
+// ("content-type" and "Content-Type" both become "Content-Type"), resolving
+// repeated names with policy. Under JoinComma a repeated Set-Cookie,
+// WWW-Authenticate or Proxy-Authenticate is left out of the map, since
+// joining would corrupt it; use ToMultiMap or Values for those.
+func (h Headers) ToMap(policy DuplicatePolicy) map[string]string {
+	m := make(map[string]string, len(h))
+	var skip map[string]bool
+	for _, hdr := range h {
+		key := textproto.CanonicalMIMEHeaderKey(hdr.Key)
+		prev, seen := m[key]
+		switch {
+		case !seen:
+			if !skip[key] {
+				m[key] = hdr.Value
+			}
+		case policy == KeepLast:
+			m[key] = hdr.Value
+		case policy == JoinComma && !canJoin(key):
+			delete(m, key)
+			if skip == nil {
+				skip = make(map[string]bool)
+			}
+			skip[key] = true
+		case policy == JoinComma:
+			m[key] = prev + ", " + hdr.Value
+		}
+	}
+	return m
+}
+
+// ToMultiMap returns every header value, in order, keyed by canonical
+// header name. The result has the shape of net/http's Header.
+func (h Headers) ToMultiMap() map[string][]string {
+	m := make(map[string][]string, len(h))
+	for _, hdr := range h {
+		key := textproto.CanonicalMIMEHeaderKey(hdr.Key)
+		m[key] = append(m[key], hdr.Value)
+	}
+	return m
+}
+
+// Dedupe rewrites h in place so that each header name (compared
+// case-insensitively) appears once, at the position and with the spelling
+// of its first occurrence, its value chosen by policy. Under JoinComma,
+// repeated Set-Cookie, WWW-Authenticate and Proxy-Authenticate headers are
+// left as they are.
+func (h *Headers) Dedupe(policy DuplicatePolicy) {
+	out := (*h)[:0]
+	first := make(map[string]int, len(*h))
+	for _, hdr := range *h {
+		key := textproto.CanonicalMIMEHeaderKey(hdr.Key)
+		i, seen := first[key]
+		switch {
+		case !seen:
+			first[key] = len(out)
+			out = append(out, hdr)
+		case policy == KeepLast:
+			out[i].Value = hdr.Value
+		case policy == JoinComma && canJoin(key):
+			out[i].Value += ", " + hdr.Value
+		case policy == JoinComma:
+			out = append(out, hdr)
+		}
+	}
+	*h = out
+}
+
 // ContentLength returns the Content-Length header value, or -1 if absent or invalid.
 func (h Headers) ContentLength() int64 {
 	v := h.Get("Content-Length")
@@ -165,15 +287,28 @@ func (r *Request) GetHeaders() Headers { return r.Headers }
 // GetBody returns the body bytes.
 func (r *Request) GetBody() []byte { return r.Body }
 
-// String returns the HTTP/1.1 wire-format representation of the request.
-// It is equivalent to calling Marshal and is provided for convenience when
-// logging or debugging. If serialization fails, a compact fallback is returned.
+// String returns a one-line summary of the request for logs and %v, such
+// as "POST /api/users HTTP/1.1 [4 headers, 48-byte body]". It never
+// includes header values, the body, the query string or the userinfo of
+// an absolute-form target, which may carry credentials; Marshal gives the
+// full message. Empty fields are left out
+// and a nil request is "<nil>".
 func (r *Request) String() string {
-	b, err := Marshal(r)
-	if err != nil {
-		return "Request{" + r.Method + " " + r.Path + " " + r.Version + "}"
+	if r == nil {
+		return "<nil>"
 	}
-	return string(b)
+	return messageSummary("Request", []string{r.Method, summaryPath(r.Path), r.Version}, len(r.Headers), len(r.Body))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with Marshal.
+func (r *Request) MarshalBinary() ([]byte, error) {
+	return Marshal(r)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler with Unmarshal,
+// parsing data strictly and failing as Unmarshal does.
+func (r *Request) UnmarshalBinary(data []byte) error {
+	return Unmarshal(data, r)
 }
 
 // GetVersion returns the HTTP version string.
@@ -185,15 +320,71 @@ func (r *Response) GetHeaders() Headers { return r.Headers }
 // GetBody returns the body bytes.
 func (r *Response) GetBody() []byte { return r.Body }
 
-// String returns the HTTP/1.1 wire-format representation of the response.
-// It is equivalent to calling Marshal and is provided for convenience when
-// logging or debugging. If serialization fails, a compact fallback is returned.
+// String returns a one-line summary of the response for logs and %v, such
+// as "HTTP/1.1 200 OK [2 headers, 5-byte body]". Like Request.String it
+// never includes header values or the body.
 func (r *Response) String() string {
-	b, err := Marshal(r)
-	if err != nil {
-		return "Response{" + strconv.Itoa(r.StatusCode) + " " + r.Reason + "}"
+	if r == nil {
+		return "<nil>"
+	}
+	status := ""
+	if r.StatusCode != 0 {
+		status = strconv.Itoa(r.StatusCode)
+	}
+	return messageSummary("Response", []string{r.Version, status, r.Reason}, len(r.Headers), len(r.Body))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with Marshal.
+func (r *Response) MarshalBinary() ([]byte, error) {
+	return Marshal(r)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler with Unmarshal,
+// parsing data strictly and failing as Unmarshal does.
+func (r *Response) UnmarshalBinary(data []byte) error {
+	return Unmarshal(data, r)
+}
+
+// messageSummary joins the non-empty start-line fields, or name if there
+// are none, and the header and body sizes, quoting a field that holds a
+// control character so the summary stays on one line.
+func messageSummary(name string, fields []string, headers, body int) string {
+	var b strings.Builder
+	for _, f := range fields {
+		if f == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		if strings.IndexFunc(f, func(c rune) bool { return c < ' ' || c == 0x7f }) >= 0 {
+			f = strconv.Quote(f)
+		}
+		b.WriteString(f)
+	}
+	if b.Len() == 0 {
+		b.WriteString(name)
+	}
+	b.WriteString(" [")
+	b.WriteString(strconv.Itoa(headers))
+	if headers == 1 {
+		b.WriteString(" header, ")
+	} else {
+		b.WriteString(" headers, ")
+	}
+	b.WriteString(strconv.Itoa(body))
+	b.WriteString("-byte body]")
+	return b.String()
+}
+
+// summaryPath is path with its userinfo, if any, shown as "[redacted]"
+// and its query as "?…".
+func summaryPath(path string) string {
+	path = fastparser.RedactUserinfo(path)
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		return path[:i] + "?…"
 	}
-	return string(b)
+	return path
 }
 
 // Marshaler is the interface implemented by types that can marshal themselves
@@ -220,4 +411,242 @@ type ParseResult struct {
 	Response *Response // non-nil if a response was detected
 	Warnings []string  // non-fatal issues encountered during parsing
 	Partial  bool      // true if the message was incomplete or truncated
+	URL      string    // normalized absolute URL (ParseCurl only; "" otherwise)
+
+	// Requests holds one request per URL when a ParseCurl command's URL
+	// uses curl's globbing patterns, such as "page[1-3]" or "{a,b}", in
+	// the order curl sends them, with Request first. It is nil otherwise.
+	Requests []*Request
+
+	// Complete reports that UnmarshalLenient found the whole message: its
+	// header section ended and the body its framing calls for arrived, be
+	// it Content-Length bytes, a chunked body through its trailers, or
+	// none. A response that runs until the connection closes is never
+	// Complete. Unlike Partial, which flags what is missing or broken,
+	// Complete says more bytes could not change the result.
+	Complete bool
+
+	// Informational lists interim 1xx responses (100 Continue, 103 Early
+	// Hints, ...) that preceded Response in the input, in order.
+	Informational []*Response
+
+	// Stats gives the byte layout of Request or Response. It covers what
+	// was read of a partial message and is zero for ParseCurl.
+	Stats Stats
+
+	// DetectedAs and Confidence report how UnmarshalLenient classified the
+	// input. Confidence is zero for results that involve no detection,
+	// such as ParseCurl.
+	DetectedAs MessageType
+	Confidence Confidence
+
+	// Verdict tells a ParseCurl caller whether the input was a complete
+	// command (CurlComplete), a curl command missing essentials such as
+	// its URL (CurlPartial), or not a curl command at all (NotCurl).
+	// Partial is true exactly when Verdict is not CurlComplete. Verdict is
+	// zero for the lenient parser.
+	Verdict CurlVerdict
+
+	// ClientHints holds what a curl command requires of the client but
+	// the request cannot carry, such as a file body that was not read.
+	ClientHints ClientHints
+
+	// Observations describes how the input's head departed from canonical
+	// form. It is filled by UnmarshalLenient only.
+	Observations FormatObservations
+
+	// InvalidHeaderNames lists the header names UnmarshalLenient kept in
+	// Headers although they are not RFC 9110 tokens, such as
+	// "Résumé-Token", "X-Key\x01" or "", each with a WarnInvalidHeaderName
+	// warning, in order of appearance.
+	InvalidHeaderNames []InvalidHeaderName
+
+	// Raw is the input given to UnmarshalLenient, or everything fed to an
+	// IncrementalParser, the same slice rather than a copy, so that Patch
+	// can compare FixedWire against it. Do not modify it while the result
+	// is in use. It is nil for ParseCurl.
+	Raw []byte
+}
+
+// FormatObservations records how a leniently parsed message was formatted:
+// its line endings, the header-section quirks the parser tolerated, and
+// the capitalization style of its header names. The counters are gathered
+// while parsing, without another pass over the input.
+type FormatObservations struct {
+	LineEnding       LineEnding // the first line ending of the head
+	MixedLineEndings bool       // the head used more than one kind
+
+	ObsFoldCount               int  // continuation lines folded into a header
+	WhitespaceBeforeColonCount int  // header names followed by spaces or tabs
+	StrayBlankLines            int  // blank lines skipped before the headers
+	LeadingBlankLines          int  // empty lines skipped before the start line
+	IndentBytes                int  // common indentation stripped from each line
+	HadBOM                     bool // a UTF-8 or UTF-16 byte order mark was stripped
+
+	HeaderCase HeaderCase // a guess at the header naming style
+}
+
+// LineEnding identifies a line terminator.
+type LineEnding int
+
+// Line endings. The zero value means none was seen.
+const (
+	LineEndingCRLF LineEnding = LineEnding(fastparser.LineEndingCRLF)
+	LineEndingLF   LineEnding = LineEnding(fastparser.LineEndingLF)
+	LineEndingCR   LineEnding = LineEnding(fastparser.LineEndingCR)
+)
+
+// String returns "crlf", "lf", "cr", or "none" for the zero value.
+func (e LineEnding) String() string {
+	switch e {
+	case 0:
+		return "none"
+	case LineEndingCRLF:
+		return "crlf"
+	case LineEndingLF:
+		return "lf"
+	case LineEndingCR:
+		return "cr"
+	}
+	return "LineEnding(" + strconv.Itoa(int(e)) + ")"
+}
+
+// HeaderCase is a capitalization style of header names.
+type HeaderCase int
+
+// Header name styles. The zero value means there were no names to judge.
+// All-uppercase names such as "TE" also fit the canonical style, so
+// HeaderCaseUpper is only reported when every name is uppercase.
+const (
+	HeaderCaseCanonical HeaderCase = HeaderCase(fastparser.HeaderCaseCanonical) // Content-Type
+	HeaderCaseLower     HeaderCase = HeaderCase(fastparser.HeaderCaseLower)     // content-type
+	HeaderCaseUpper     HeaderCase = HeaderCase(fastparser.HeaderCaseUpper)     // CONTENT-TYPE
+	HeaderCaseMixed     HeaderCase = HeaderCase(fastparser.HeaderCaseMixed)     // names disagree or follow no one style
+)
+
+// String returns "canonical", "lower", "upper", "mixed", or "none" for the
+// zero value.
+func (c HeaderCase) String() string {
+	switch c {
+	case 0:
+		return "none"
+	case HeaderCaseCanonical:
+		return "canonical"
+	case HeaderCaseLower:
+		return "lower"
+	case HeaderCaseUpper:
+		return "upper"
+	case HeaderCaseMixed:
+		return "mixed"
+	}
+	return "HeaderCase(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Stats records how many bytes the start line, header section and body of
+// a parsed message took in the input. TotalBytes is StartLineBytes +
+// HeaderBytes + BodyBytes, so the message occupies
+// data[StartOffset : StartOffset+TotalBytes].
+type Stats struct {
+	StartOffset    int // offset of the start line, past leading blank lines or interim responses
+	StartLineBytes int // the start line, including its line ending
+	HeaderBytes    int // the header field lines and the blank line ending them
+	HeaderCount    int // header fields parsed; an obs-folded field counts once
+	BodyBytes      int // the body as framed on the wire, chunk framing and trailers included
+	TotalBytes     int
+
+	// DecodedBodyBytes is the body length once chunked framing is removed;
+	// it equals BodyBytes for a body that is not chunked.
+	DecodedBodyBytes int
+
+	// LargestHeader names the header with the longest value, the first of
+	// them on a tie, and LargestHeaderBytes is that value's length as
+	// received. Both are zero for a message without headers.
+	LargestHeader      string
+	LargestHeaderBytes int
+
+	// UsedBareLF reports that a line of the start line or headers ended in
+	// LF alone. Only the strict parser sets it; the lenient parser
+	// describes line endings in ParseResult.Observations.
+	UsedBareLF bool
+
+	// LeadingBlankLines counts the empty lines skipped before the start
+	// line, as RFC 9112 §2.2 allows. Only the strict parser sets it; the
+	// lenient parser counts them in ParseResult.Observations.
+	LeadingBlankLines int
+
+	// HeaderEndOffset is the offset of the blank line ending the header
+	// section and BodyOffset that of the first byte of the body, the first
+	// chunk-size line of a chunked one, both from the start of the input.
+	// A message without a body has BodyOffset at the end of its head. When
+	// the lenient parser infers a body that no blank line introduced, both
+	// are where it decided the body began. Both are -1 when the header
+	// section never ended, and when UnmarshalLenient rewrote the input
+	// before parsing it: transcoding UTF-16, stripping a common indent,
+	// unescaping a string literal or undoing doubled CRs.
+	HeaderEndOffset int
+	BodyOffset      int
+}
+
+// InvalidHeaderName is a header name outside the token grammar, as
+// received, with Sanitized, a token made from it: each run of offending
+// bytes becomes '-', or is dropped at either end, so "X-Custom Header"
+// gives "X-Custom-Header" and "Résumé-Token" gives "R-sum-Token".
+// Sanitized is "" when no token bytes remain. The name itself is kept in
+// Headers so that nothing is lost, and Marshal writes it as received;
+// replace it with Sanitized before sending the message on.
+type InvalidHeaderName struct {
+	Name      string
+	Sanitized string
+}
+
+// CurlVerdict classifies a ParseCurl result.
+type CurlVerdict int
+
+// Curl verdicts. The zero value means the result is not from ParseCurl.
+const (
+	CurlComplete CurlVerdict = CurlVerdict(fastparser.CurlComplete)
+	CurlPartial  CurlVerdict = CurlVerdict(fastparser.CurlPartial)
+	NotCurl      CurlVerdict = CurlVerdict(fastparser.NotCurl)
+)
+
+// String returns "complete", "partial", "not-curl", or "none" for the zero
+// value.
+func (v CurlVerdict) String() string {
+	switch v {
+	case 0:
+		return "none"
+	case CurlComplete:
+		return "complete"
+	case CurlPartial:
+		return "partial"
+	case NotCurl:
+		return "not-curl"
+	}
+	return "CurlVerdict(" + strconv.Itoa(int(v)) + ")"
+}
+
+// Confidence grades how sure the lenient parser is that it classified a
+// message as a request or response correctly.
+type Confidence int
+
+// Confidence levels. The zero value means no detection took place.
+const (
+	ConfidenceLow    Confidence = Confidence(fastparser.ConfidenceLow)
+	ConfidenceMedium Confidence = Confidence(fastparser.ConfidenceMedium)
+	ConfidenceHigh   Confidence = Confidence(fastparser.ConfidenceHigh)
+)
+
+// String returns "low", "medium", "high", or "none" for the zero value.
+func (c Confidence) String() string {
+	switch c {
+	case 0:
+		return "none"
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+	return "Confidence(" + strconv.Itoa(int(c)) + ")"
 }
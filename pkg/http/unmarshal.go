@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"github.com/shapestone/shape-http/internal/fastparser"
 )
@@ -14,6 +15,18 @@ import (
 //
 // This function uses a high-performance fast path that bypasses AST construction.
 //
+// # Request targets
+//
+// All four request-target forms of RFC 9112 §3.2 are accepted:
+//
+//	GET /api?q=1 HTTP/1.1                  → Path "/api?q=1"
+//	GET http://example.com/api HTTP/1.1    → Path "/api", Scheme "http", Host "example.com"
+//	CONNECT example.com:443 HTTP/1.1       → Path "example.com:443"
+//	OPTIONS * HTTP/1.1                     → Path "*"
+//
+// For absolute-form, a Host header is synthesized from the authority when
+// absent; a Host header naming a different authority is an error.
+//
 // # Authentication
 //
 // Authentication headers are parsed as ordinary HTTP headers and are available
@@ -29,8 +42,9 @@ import (
 //
 //	// GET /api/users?api_key=abc123 HTTP/1.1  →  req.Path = "/api/users?api_key=abc123"
 func Unmarshal(data []byte, v interface{}) error {
+	m := currentMetrics()
 	if v == nil {
-		return fmt.Errorf("http: Unmarshal(nil)")
+		return m.failed("strict", len(data), fmt.Errorf("http: Unmarshal(nil)"))
 	}
 
 	// Check for Unmarshaler interface
@@ -38,23 +52,26 @@ func Unmarshal(data []byte, v interface{}) error {
 		return u.UnmarshalHTTP(data)
 	}
 
-	isResp := bytes.HasPrefix(data, []byte("HTTP/"))
+	isResp := bytes.HasPrefix(bytes.TrimLeft(data, "\r\n"), []byte("HTTP/"))
 
 	switch target := v.(type) {
 	case *Request:
 		if isResp {
-			return fmt.Errorf("http: data appears to be a response but target is *Request")
+			return m.failed("strict", len(data), fmt.Errorf("http: data appears to be a response but target is *Request"))
 		}
 		return unmarshalRequest(data, target)
 
 	case *Response:
 		if !isResp {
-			return fmt.Errorf("http: data appears to be a request but target is *Response")
+			if err := notHTTP1Error(data); err != nil {
+				return m.failed("strict", len(data), err)
+			}
+			return m.failed("strict", len(data), fmt.Errorf("http: data appears to be a request but target is *Response"))
 		}
 		return unmarshalResponse(data, target)
 
 	default:
-		return fmt.Errorf("http: Unmarshal unsupported type %T (expected *Request or *Response)", v)
+		return m.failed("strict", len(data), fastparser.WithKind(fmt.Errorf("http: Unmarshal unsupported type %T (expected *Request or *Response)", v), ErrUnsupportedType))
 	}
 }
 
@@ -78,46 +95,156 @@ func UnmarshalResponse(data []byte) (*Response, error) {
 	return resp, nil
 }
 
+// UnmarshalRequestWithStats parses data as a request like UnmarshalRequest
+// and also returns the byte statistics of the message.
+func UnmarshalRequestWithStats(data []byte) (*Request, Stats, error) {
+	m := currentMetrics()
+	start := m.now()
+	if err := notHTTP1Error(data); err != nil {
+		m.outcome("strict", start, len(data), err)
+		return nil, Stats{}, err
+	}
+	r, stats, err := fastparser.UnmarshalRequestWithStats(data)
+	m.outcome("strict", start, len(data), err)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	return requestFromInternal(r), Stats(stats), nil
+}
+
+// ParseRequestFunc parses data as a request, like UnmarshalRequest, but
+// reports the headers and body through callbacks instead of building a
+// Request, for scanning large captures without per-message allocation.
+// onHeader is called with each header field as it appears and onBody with
+// the body: once for a Content-Length or read-to-end body, once per chunk
+// for a chunked one, so the chunks concatenate to what Dechunk returns.
+//
+// The slices alias data, so they stay valid only while data is unchanged
+// and must not be modified; an obs-folded value is a fresh copy. Returning
+// false stops that callback's calls; parsing continues, so framing and
+// errors are the same as UnmarshalRequest's. Either callback may be nil.
+func ParseRequestFunc(data []byte, onHeader func(key, value []byte) bool, onBody func(chunk []byte) bool) error {
+	if err := notHTTP1Error(data); err != nil {
+		return err
+	}
+	return fastparser.ParseRequestFunc(data, onHeader, onBody)
+}
+
+// UnmarshalResponseWithStats parses data as a response like
+// UnmarshalResponse and also returns the byte statistics of the message.
+func UnmarshalResponseWithStats(data []byte) (*Response, Stats, error) {
+	m := currentMetrics()
+	start := m.now()
+	if err := notHTTP1Error(data); err != nil {
+		m.outcome("strict", start, len(data), err)
+		return nil, Stats{}, err
+	}
+	r, stats, err := fastparser.UnmarshalResponseWithStats(data)
+	m.outcome("strict", start, len(data), err)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	return responseFromInternal(r), Stats(stats), nil
+}
+
 // DetectMessageType returns "request" or "response" based on the data prefix.
 // Data starting with "HTTP/" is detected as a response; everything else as a request.
+//
+// DetectMessageType is kept for compatibility; DetectMessage also recognizes
+// HTTP/2, TLS and other binary input.
 func DetectMessageType(data []byte) string {
 	return fastparser.DetectMessageType(data)
 }
 
-func unmarshalRequest(data []byte, target *Request) error {
-	req, err := fastparser.UnmarshalRequest(data)
-	if err != nil {
-		return err
+// MessageType classifies raw input by the protocol it appears to carry.
+type MessageType int
+
+// Message types returned by DetectMessage. The values mirror the internal
+// parser's classification order so DetectMessage can convert directly.
+const (
+	MessageRequest       MessageType = iota // HTTP/1.x request (the fallback)
+	MessageResponse                         // HTTP/1.x response ("HTTP/" prefix)
+	MessageHTTP2Preface                     // HTTP/2 client connection preface
+	MessageTLS                              // TLS handshake record (0x16 0x03)
+	MessageUnknownBinary                    // >10% control or invalid UTF-8 in the first 64 bytes
+)
+
+// String returns a short lowercase name for the message type.
+func (t MessageType) String() string {
+	switch t {
+	case MessageRequest:
+		return "request"
+	case MessageResponse:
+		return "response"
+	case MessageHTTP2Preface:
+		return "http2-preface"
+	case MessageTLS:
+		return "tls"
+	case MessageUnknownBinary:
+		return "binary"
+	default:
+		return "MessageType(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// DetectMessage classifies data by its leading bytes. Unlike
+// DetectMessageType it recognizes the exact HTTP/2 connection preface
+// ("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"), TLS handshake records, and
+// binary input, none of which can be parsed as HTTP/1.x.
+func DetectMessage(data []byte) MessageType {
+	return MessageType(fastparser.DetectMessageKind(data))
+}
+
+// notHTTP1Error returns a descriptive error when data is recognizably some
+// other protocol, or nil when it may be HTTP/1.x.
+func notHTTP1Error(data []byte) error {
+	switch DetectMessage(data) {
+	case MessageHTTP2Preface:
+		return fmt.Errorf("http: input is an HTTP/2 connection preface, not HTTP/1.x")
+	case MessageTLS:
+		return fmt.Errorf("http: input looks like a TLS handshake, not HTTP/1.x")
+	case MessageUnknownBinary:
+		return fmt.Errorf("http: input looks like binary data, not HTTP/1.x")
 	}
-	target.Method = req.Method
-	target.Path = req.Path
-	target.Version = req.Version
-	target.Scheme = req.Scheme
-	target.Headers = convertHeaders(req.Headers)
-	target.Body = req.Body
 	return nil
 }
 
-func unmarshalResponse(data []byte, target *Response) error {
-	resp, err := fastparser.UnmarshalResponse(data)
+func unmarshalRequest(data []byte, target *Request) error {
+	return unmarshalRequestWithLimits(data, target, fastparser.Limits{})
+}
+
+func unmarshalRequestWithLimits(data []byte, target *Request, limits fastparser.Limits) error {
+	m := currentMetrics()
+	start := m.now()
+	if err := notHTTP1Error(data); err != nil {
+		m.outcome("strict", start, len(data), err)
+		return err
+	}
+	req, err := fastparser.UnmarshalRequestWithLimits(data, limits)
+	m.outcome("strict", start, len(data), err)
 	if err != nil {
 		return err
 	}
-	target.Version = resp.Version
-	target.StatusCode = resp.StatusCode
-	target.Reason = resp.Reason
-	target.Headers = convertHeaders(resp.Headers)
-	target.Body = resp.Body
+	fillRequest(target, req)
 	return nil
 }
 
-func convertHeaders(internal []fastparser.Header) Headers {
-	if len(internal) == 0 {
-		return nil
+func unmarshalResponse(data []byte, target *Response) error {
+	return unmarshalResponseWithLimits(data, target, fastparser.Limits{})
+}
+
+func unmarshalResponseWithLimits(data []byte, target *Response, limits fastparser.Limits) error {
+	m := currentMetrics()
+	start := m.now()
+	if err := notHTTP1Error(data); err != nil {
+		m.outcome("strict", start, len(data), err)
+		return err
 	}
-	headers := make(Headers, len(internal))
-	for i, h := range internal {
-		headers[i] = Header{Key: h.Key, Value: h.Value}
+	resp, err := fastparser.UnmarshalResponseWithLimits(data, limits)
+	m.outcome("strict", start, len(data), err)
+	if err != nil {
+		return err
 	}
-	return headers
+	fillResponse(target, resp)
+	return nil
 }
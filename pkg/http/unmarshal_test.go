@@ -1,6 +1,8 @@
 package http
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -324,3 +326,178 @@ func TestUnmarshal_UnmarshalerInterface(t *testing.T) {
 		t.Error("UnmarshalHTTP was not called on Unmarshaler")
 	}
 }
+
+// Captured leading bytes of non-HTTP/1.x protocols.
+var (
+	h2PrefaceSnippet = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n\x00\x00\x12\x04\x00\x00\x00\x00\x00\x00\x03\x00\x00\x00\x64")
+	tlsHelloSnippet  = []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01, 0x00, 0x01, 0xfc, 0x03, 0x03, 0x9a, 0x2b, 0x11}
+	wsFrameSnippet   = []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+)
+
+func TestDetectMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want MessageType
+	}{
+		{"request", []byte("GET / HTTP/1.1\r\n\r\n"), MessageRequest},
+		{"response", []byte("HTTP/1.1 200 OK\r\n\r\n"), MessageResponse},
+		{"h2 preface", h2PrefaceSnippet, MessageHTTP2Preface},
+		{"tls", tlsHelloSnippet, MessageTLS},
+		{"websocket", wsFrameSnippet, MessageUnknownBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectMessage(tt.data); got != tt.want {
+				t.Errorf("DetectMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageType_String(t *testing.T) {
+	want := map[MessageType]string{
+		MessageRequest:       "request",
+		MessageResponse:      "response",
+		MessageHTTP2Preface:  "http2-preface",
+		MessageTLS:           "tls",
+		MessageUnknownBinary: "binary",
+		MessageType(42):      "MessageType(42)",
+	}
+	for mt, s := range want {
+		if mt.String() != s {
+			t.Errorf("MessageType(%d).String() = %q, want %q", int(mt), mt.String(), s)
+		}
+	}
+}
+
+func TestUnmarshal_NotHTTP1(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"h2 preface", h2PrefaceSnippet, "HTTP/2 connection preface"},
+		{"tls", tlsHelloSnippet, "looks like a TLS handshake"},
+		{"websocket", wsFrameSnippet, "looks like binary data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalRequest(tt.data); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("UnmarshalRequest() error = %v, want %q", err, tt.want)
+			}
+			if _, err := UnmarshalResponse(tt.data); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("UnmarshalResponse() error = %v, want %q", err, tt.want)
+			}
+			if err := Unmarshal(tt.data, &Response{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal(*Response) error = %v, want %q", err, tt.want)
+			}
+			if err := Unmarshal(tt.data, &Request{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal(*Request) error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequestFunc(t *testing.T) {
+	// The callbacks see what UnmarshalRequest parses: every Host header and
+	// the decoded body.
+	for _, seed := range requestSeeds {
+		req, err := UnmarshalRequest(seed)
+		if err != nil {
+			t.Fatalf("%q: %v", seed, err)
+		}
+		var host string
+		var body []byte
+		err = ParseRequestFunc(seed, func(key, value []byte) bool {
+			if strings.EqualFold(string(key), "Host") {
+				host = string(value)
+				return false
+			}
+			return true
+		}, func(chunk []byte) bool {
+			body = append(body, chunk...)
+			return true
+		})
+		if err != nil {
+			t.Errorf("%q: %v", seed, err)
+		}
+		if host != req.Headers.Get("Host") || string(body) != string(req.Body) {
+			t.Errorf("%q: Host %q, body %q; want %q, %q", seed, host, body, req.Headers.Get("Host"), req.Body)
+		}
+	}
+
+	if err := ParseRequestFunc([]byte("\x16\x03\x01\x00\x05hello"), nil, nil); err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Errorf("TLS input: err = %v", err)
+	}
+}
+
+func TestParseRequestFunc_Parity(t *testing.T) {
+	// ParseRequestFunc fails exactly when UnmarshalRequest does, and
+	// otherwise reports the body UnmarshalRequest reads.
+	for _, input := range []string{
+		"GET http://a.example/ HTTP/1.1\r\nHost: b.example\r\n\r\n",
+		"GET http://a.example/ HTTP/1.1\r\nHost: A.example\r\n\r\n",
+		"GET http://a.example/ HTTP/1.1\r\n\r\n",
+		"GET / HTTP/1.1\r\nHost: a.example\r\nHost: b.example\r\n\r\n",
+		"GET / HTTP/1.1\r\nHost: a.example\r\nHost: a.example\r\n\r\n",
+		"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked, gzip\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\nTransfer-Encoding: gzip\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: gzip, chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 3\r\nContent-Length: 5\r\n\r\nabcde",
+		"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: abc\r\nContent-Length: 3\r\n\r\nabcde",
+		"POST http://a/ HTTP/1.1\r\nContent-Length: 2\r\n\r\nabc",
+	} {
+		req, want := UnmarshalRequest([]byte(input))
+		var body []byte
+		err := ParseRequestFunc([]byte(input), nil, func(chunk []byte) bool {
+			body = append(body, chunk...)
+			return true
+		})
+		if (err == nil) != (want == nil) {
+			t.Errorf("%q: ParseRequestFunc error %v, UnmarshalRequest error %v", input, err, want)
+			continue
+		}
+		if err == nil && string(body) != string(req.Body) {
+			t.Errorf("%q: body %q, want %q", input, body, req.Body)
+		}
+	}
+}
+
+func TestUnmarshal_LeadingEmptyLines(t *testing.T) {
+	for _, tt := range []struct {
+		lead string
+		n    int
+	}{{"\r\n", 1}, {"\r\n\r\n\r\n", 3}, {"\n\r\n", 2}} {
+		reqData := []byte(tt.lead + "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+		req, stats, err := UnmarshalRequestWithStats(reqData)
+		if err != nil || req.Path != "/" {
+			t.Fatalf("%q: UnmarshalRequestWithStats = %+v, %v", tt.lead, req, err)
+		}
+		if stats.LeadingBlankLines != tt.n || stats.StartOffset != len(tt.lead) || stats.StartOffset+stats.TotalBytes != len(reqData) {
+			t.Errorf("%q: stats = %+v, want %d leading blank lines", tt.lead, stats, tt.n)
+		}
+
+		respData := []byte(tt.lead + "HTTP/1.1 204 No Content\r\n\r\n")
+		var resp Response
+		if err := Unmarshal(respData, &resp); err != nil || resp.StatusCode != 204 {
+			t.Errorf("%q: Unmarshal response = %+v, %v", tt.lead, resp, err)
+		}
+
+		for _, data := range [][]byte{reqData, respData} {
+			r := UnmarshalLenient(data)
+			if r.Request == nil && r.Response == nil || len(r.Warnings) != 0 || r.Observations.LeadingBlankLines != tt.n {
+				t.Errorf("%q: lenient = %+v, want %d leading blank lines", data, r, tt.n)
+			}
+		}
+	}
+
+	if _, err := UnmarshalRequest([]byte("\r\n\r\n")); err == nil {
+		t.Error("UnmarshalRequest of empty lines only: want an error")
+	}
+	// RequireCRLF still rejects a leading bare LF.
+	_, err := UnmarshalRequestWithLimits([]byte("\nGET / HTTP/1.1\r\nHost: a\r\n\r\n"), ParserLimits{RequireCRLF: true})
+	if !errors.Is(err, ErrBareLineEnding) {
+		t.Errorf("RequireCRLF error = %v, want ErrBareLineEnding", err)
+	}
+}